@@ -0,0 +1,170 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package ansible
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPlaySummaryMessage(t *testing.T) {
+	started := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	summary := PlaySummary{
+		Playbook: "site.yml",
+		Play:     "Configure web servers",
+		Started:  started,
+		Ended:    started.Add(83 * time.Second),
+		Hosts: map[string]HostStats{
+			"web2": {OK: 4, Changed: 1},
+			"web1": {OK: 5, Changed: 2},
+			"db1":  {OK: 1, Failures: 1},
+			"lb1":  {Unreachable: 1},
+		},
+	}
+
+	msg := summary.Message()
+
+	if want := "Ansible play failed: Configure web servers"; msg.Title != want {
+		t.Errorf("got title %q, want %q", msg.Title, want)
+	}
+
+	if want := "2 changed, 1 failed, 1 unreachable in 1m23s."; !strings.Contains(msg.Text, want) {
+		t.Errorf("text %q does not contain %q", msg.Text, want)
+	}
+
+	facts := make(map[string]string)
+	for _, fact := range msg.Facts {
+		facts[fact.Title] = fact.Value
+	}
+
+	for title, want := range map[string]string{
+		"Playbook":          "site.yml",
+		"Duration":          "1m23s",
+		"Tasks":             "ok=10 changed=3 unreachable=1 failed=1 skipped=0 rescued=0 ignored=0",
+		"Changed hosts":     "web1, web2",
+		"Failed hosts":      "db1",
+		"Unreachable hosts": "lb1",
+	} {
+		if facts[title] != want {
+			t.Errorf("got %s fact %q, want %q", title, facts[title], want)
+		}
+	}
+}
+
+func TestParseModuleArgs(t *testing.T) {
+	args, err := ParseModuleArgs([]byte(`{
+		"url": "https://example.com",
+		"play": "Deploy",
+		"hosts": ["web1", "web2"],
+		"failed_hosts": ["web2"],
+		"started": "2026-01-02T03:04:05.123456",
+		"_ansible_check_mode": true,
+		"_ansible_verbosity": 2
+	}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !args.CheckMode {
+		t.Error("check mode not set")
+	}
+
+	now := time.Date(2026, 1, 2, 3, 5, 5, 0, time.UTC)
+	summary, err := args.Summary(now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := summary.FailedHosts(); len(got) != 1 || got[0] != "web2" {
+		t.Errorf("got failed hosts %v, want [web2]", got)
+	}
+
+	if got := summary.Duration().Round(time.Second); got != time.Minute {
+		t.Errorf("got duration %s, want 1m0s", got)
+	}
+
+	if _, err := ParseModuleArgs([]byte(`{"url": "https://example.com", "colour": "red"}`)); err == nil {
+		t.Error("expected an error for an unknown argument")
+	}
+
+	if _, err := ParseModuleArgs([]byte(`{"play": "Deploy"}`)); err == nil {
+		t.Error("expected an error for a missing url argument")
+	}
+}
+
+func TestRunModule(t *testing.T) {
+	var received int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&received, 1)
+		_, _ = w.Write([]byte("1"))
+	}))
+	defer server.Close()
+
+	run := func(extra string) ModuleResult {
+		t.Helper()
+
+		args := `{"url": "` + server.URL + `", "validate_url": false, "retries": 0, "play": "Deploy", "hosts": {"web1": {"ok": 2, "changed": 1}}` + extra + `}`
+
+		var out bytes.Buffer
+		code := RunModule(context.Background(), []byte(args), &out)
+
+		var result ModuleResult
+		if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+			t.Fatalf("failed to decode module result %q: %v", out.String(), err)
+		}
+
+		if (code != 0) != result.Failed {
+			t.Errorf("exit code %d inconsistent with result %+v", code, result)
+		}
+
+		return result
+	}
+
+	if result := run(`, "_ansible_check_mode": true`); result.Failed || !result.Changed {
+		t.Errorf("unexpected check mode result: %+v", result)
+	}
+	if got := atomic.LoadInt32(&received); got != 0 {
+		t.Errorf("message sent in check mode")
+	}
+
+	if result := run(""); result.Failed || result.Title != "Ansible play completed: Deploy" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if got := atomic.LoadInt32(&received); got != 1 {
+		t.Errorf("got %d messages, want 1", got)
+	}
+
+	if result := run(`, "timeout": -1`); !result.Failed {
+		t.Errorf("expected a failed result for an invalid timeout, got %+v", result)
+	}
+}
+
+func TestRunModuleRedactsWebhookURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	webhookURL := server.URL + "/webhookb2/SECRETPATH"
+	server.Close()
+
+	args := `{"url": "` + webhookURL + `", "validate_url": false, "retries": 0, "play": "Deploy"}`
+
+	var out bytes.Buffer
+	if code := RunModule(context.Background(), []byte(args), &out); code == 0 {
+		t.Fatalf("got exit code 0 sending to a closed server")
+	}
+
+	if strings.Contains(out.String(), "SECRETPATH") {
+		t.Errorf("got module result %q; want webhook URL redacted", out.String())
+	}
+}
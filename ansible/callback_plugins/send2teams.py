@@ -0,0 +1,168 @@
+# Copyright 2021 Adam Chalkley
+#
+# https://github.com/atc0005/send2teams
+#
+# Licensed under the MIT License. See LICENSE file in the project root for
+# full license information.
+
+"""Send a Microsoft Teams summary card for each Ansible play.
+
+Task results are counted for each host (as for the PLAY RECAP, with failures
+handled by a rescue section counted as rescued) and passed as a JSON
+descriptor on stdin to the send2teams-ansible binary module, which
+submits the card. Enable the plugin via the callbacks_enabled setting and
+specify the webhook URL using the SEND2TEAMS_WEBHOOK_URL environment
+variable. The module binary is located using the SEND2TEAMS_ANSIBLE_PATH
+environment variable or the PATH.
+"""
+
+from __future__ import absolute_import, division, print_function
+
+__metaclass__ = type
+
+DOCUMENTATION = """
+    name: send2teams
+    type: notification
+    short_description: Send a Microsoft Teams summary card for each play
+    description:
+      - Sends a summary card noting the changed, failed and unreachable hosts
+        and the duration of each play using the send2teams-ansible module.
+    requirements:
+      - the send2teams-ansible binary
+      - enable in configuration via callbacks_enabled
+    options:
+      webhook_url:
+        description: The Microsoft Teams webhook URL.
+        required: true
+        env:
+          - name: SEND2TEAMS_WEBHOOK_URL
+      module_path:
+        description: The path to the send2teams-ansible binary.
+        default: send2teams-ansible
+        env:
+          - name: SEND2TEAMS_ANSIBLE_PATH
+"""
+
+import json
+import os
+import re
+import subprocess
+from datetime import datetime, timezone
+
+from ansible.plugins.callback import CallbackBase
+
+# The http and https URLs within text, as redacted by the send2teams-ansible
+# module. The host of each URL is retained.
+URL_PATTERN = re.compile(r"""(https?://)([^/\s"'<>()?#@]*@)?([^/\s"'<>()?#]+)[^\s"'<>()]*""")
+
+
+def redact_urls(text):
+    """Return the given text with the path and query of each URL (which
+    carry the token of a webhook URL) redacted."""
+
+    def redact(match):
+        redacted = match.group(1) + match.group(3)
+        if len(match.group(0)) > len(redacted):
+            redacted += "/REDACTED"
+        return redacted
+
+    return URL_PATTERN.sub(redact, text)
+
+
+def rescued(task):
+    """Return whether a failure of the given task is handled by the rescue
+    section of an enclosing block."""
+    child = task
+    parent = getattr(task, "_parent", None)
+    while parent is not None:
+        if getattr(parent, "rescue", None) and any(t._uuid == child._uuid for t in getattr(parent, "block", [])):
+            return True
+        child = parent
+        parent = getattr(parent, "_parent", None)
+
+    return False
+
+
+class CallbackModule(CallbackBase):
+    CALLBACK_VERSION = 2.0
+    CALLBACK_TYPE = "notification"
+    CALLBACK_NAME = "send2teams"
+    CALLBACK_NEEDS_ENABLED = True
+
+    def __init__(self):
+        super(CallbackModule, self).__init__()
+        self.playbook = None
+        self.play = None
+        self.started = None
+        self.hosts = {}
+
+    def set_options(self, task_keys=None, var_options=None, direct=None):
+        super(CallbackModule, self).set_options(task_keys=task_keys, var_options=var_options, direct=direct)
+        self.webhook_url = self.get_option("webhook_url")
+        self.module_path = self.get_option("module_path")
+
+    def count(self, result, key):
+        name = result._host.get_name()
+        stats = self.hosts.setdefault(name, {})
+        stats[key] = stats.get(key, 0) + 1
+
+    def v2_playbook_on_start(self, playbook):
+        self.playbook = os.path.basename(playbook._file_name)
+
+    def v2_playbook_on_play_start(self, play):
+        self.send_summary()
+        self.play = play.get_name()
+        self.started = datetime.now(timezone.utc)
+        self.hosts = {}
+
+    def v2_runner_on_ok(self, result):
+        # Changed tasks are also counted as ok, as for the PLAY RECAP.
+        self.count(result, "ok")
+        if result._result.get("changed"):
+            self.count(result, "changed")
+
+    def v2_runner_on_failed(self, result, ignore_errors=False):
+        if ignore_errors:
+            self.count(result, "ignored")
+        elif rescued(result._task):
+            self.count(result, "rescued")
+        else:
+            self.count(result, "failures")
+
+    def v2_runner_on_unreachable(self, result):
+        self.count(result, "unreachable")
+
+    def v2_runner_on_skipped(self, result):
+        self.count(result, "skipped")
+
+    def v2_playbook_on_stats(self, stats):
+        self.send_summary()
+
+    def send_summary(self):
+        if self.play is None:
+            return
+
+        descriptor = {
+            "url": self.webhook_url,
+            "playbook": self.playbook,
+            "play": self.play,
+            "started": self.started.isoformat(),
+            "ended": datetime.now(timezone.utc).isoformat(),
+            "hosts": self.hosts,
+        }
+        self.play = None
+
+        try:
+            proc = subprocess.run(
+                [self.module_path],
+                input=json.dumps(descriptor).encode("utf-8"),
+                stdout=subprocess.PIPE,
+                check=False,
+            )
+            result = json.loads(proc.stdout.decode("utf-8") or "{}")
+        except (OSError, ValueError) as err:
+            self._display.warning("send2teams: failed to run %s: %s" % (self.module_path, redact_urls(str(err))))
+            return
+
+        if result.get("failed"):
+            self._display.warning("send2teams: %s" % redact_urls(str(result.get("msg"))))
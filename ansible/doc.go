@@ -0,0 +1,35 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package ansible submits summary cards for Ansible plays to Microsoft Teams,
+describing the hosts which were changed, failed or unreachable and how long
+the play took.
+
+A PlaySummary is converted into a message suitable for a sender.Client:
+
+	summary := ansible.PlaySummary{
+		Playbook: "site.yml",
+		Play:     "Configure web servers",
+		Started:  started,
+		Ended:    time.Now(),
+		Hosts: map[string]ansible.HostStats{
+			"web1": {OK: 12, Changed: 3},
+			"web2": {OK: 9, Failures: 1},
+		},
+	}
+
+	result, err := client.Send(ctx, summary.Message())
+
+RunModule implements the Ansible module protocol: it reads the module
+arguments (a JSON descriptor of the play summary and webhook URL) and writes
+the JSON result expected by Ansible. The cmd/send2teams-ansible command
+provides a ready-made binary module which reads the descriptor from the
+arguments file provided by Ansible or from stdin, as used by the companion
+callback plugin in the callback_plugins directory of this package.
+*/
+package ansible
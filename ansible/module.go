@@ -0,0 +1,288 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package ansible
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/atc0005/send2teams/internal/debugbundle"
+	"github.com/atc0005/send2teams/sender"
+)
+
+// Default values of the optional module arguments.
+const (
+	defaultModuleTimeout float64 = 60
+	defaultRetries       int     = 2
+	defaultRetriesDelay  int     = 2
+)
+
+// checkModeArg is the internal argument used by Ansible to indicate that the
+// module is run in check mode. Other internal arguments (prefixed with
+// internalArgPrefix) are ignored.
+const (
+	checkModeArg      string = "_ansible_check_mode"
+	internalArgPrefix string = "_ansible_"
+)
+
+// timeLayouts are the accepted formats of the play start and end times. Times
+// without a time zone (e.g., as produced by the Ansible now() function) are
+// interpreted as UTC.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// hostsArg is the hosts module argument, specified either as stats keyed by
+// host name or as a list of host names.
+type hostsArg map[string]HostStats
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (h *hostsArg) UnmarshalJSON(data []byte) error {
+	var names []string
+	if err := json.Unmarshal(data, &names); err == nil {
+		*h = make(hostsArg, len(names))
+		for _, name := range names {
+			(*h)[name] = HostStats{}
+		}
+		return nil
+	}
+
+	var stats map[string]HostStats
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return fmt.Errorf("expected a list of host names or stats keyed by host name: %w", err)
+	}
+	*h = stats
+
+	return nil
+}
+
+// ModuleArgs are the arguments of the Ansible module, specified as a JSON
+// object.
+type ModuleArgs struct {
+
+	// URL is the webhook URL.
+	URL string `json:"url"`
+
+	// ValidateURL controls whether the webhook URL is validated. Enabled if
+	// not specified.
+	ValidateURL *bool `json:"validate_url"`
+
+	// Retries and RetriesDelay (in seconds) control how failed submission
+	// attempts are retried.
+	Retries      int `json:"retries"`
+	RetriesDelay int `json:"retries_delay"`
+
+	// Timeout is the maximum time in seconds spent submitting the summary
+	// card, including any retry attempts.
+	Timeout float64 `json:"timeout"`
+
+	// Title, Playbook and Play are as described for PlaySummary.
+	Title    string `json:"title"`
+	Playbook string `json:"playbook"`
+	Play     string `json:"play"`
+
+	// Started and Ended are when the play started and ended. Ended defaults
+	// to the current time if Started is specified.
+	Started string `json:"started"`
+	Ended   string `json:"ended"`
+
+	// Hosts are the hosts targeted by the play, either with their task
+	// results (keyed by host name) or as a list of host names.
+	Hosts hostsArg `json:"hosts"`
+
+	// FailedHosts are the (optional) names of hosts which failed, for use
+	// when task results are not available (e.g., the difference between the
+	// ansible_play_hosts_all and ansible_play_hosts variables).
+	FailedHosts []string `json:"failed_hosts"`
+
+	// CheckMode indicates that the module was run in check mode, in which
+	// case the summary card is not sent.
+	CheckMode bool `json:"_ansible_check_mode"`
+}
+
+// ModuleResult is the result of the Ansible module, written as a JSON object.
+type ModuleResult struct {
+	Changed bool `json:"changed"`
+	Failed  bool `json:"failed,omitempty"`
+
+	// Msg describes the outcome of the module. The path and query of any
+	// URLs (e.g., the webhook URL) are redacted, as the result is shown in
+	// task output and logs.
+	Msg string `json:"msg"`
+
+	// Title is the title of the summary card.
+	Title string `json:"title,omitempty"`
+}
+
+// ParseModuleArgs parses the given JSON module arguments. Internal arguments
+// added by Ansible are ignored; other unknown arguments are rejected.
+func ParseModuleArgs(data []byte) (ModuleArgs, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return ModuleArgs{}, fmt.Errorf("failed to decode module arguments: %w", err)
+	}
+
+	for name := range raw {
+		if strings.HasPrefix(name, internalArgPrefix) && name != checkModeArg {
+			delete(raw, name)
+		}
+	}
+
+	filtered, err := json.Marshal(raw)
+	if err != nil {
+		return ModuleArgs{}, fmt.Errorf("failed to decode module arguments: %w", err)
+	}
+
+	args := ModuleArgs{
+		Retries:      defaultRetries,
+		RetriesDelay: defaultRetriesDelay,
+		Timeout:      defaultModuleTimeout,
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(filtered))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&args); err != nil {
+		return ModuleArgs{}, fmt.Errorf("invalid module arguments: %w", err)
+	}
+
+	switch {
+	case args.URL == "":
+		return ModuleArgs{}, errors.New("missing required argument: url")
+	case args.Retries < 0 || args.RetriesDelay < 0:
+		return ModuleArgs{}, errors.New("retries and retries_delay must not be negative")
+	case args.Timeout <= 0:
+		return ModuleArgs{}, errors.New("timeout must be positive")
+	}
+
+	return args, nil
+}
+
+// parseTime parses the given play start or end time.
+func parseTime(name string, value string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.UTC); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid %s time %q; expected an ISO 8601 timestamp", name, value)
+}
+
+// Summary returns the play summary described by the module arguments.
+func (args ModuleArgs) Summary(now time.Time) (PlaySummary, error) {
+	summary := PlaySummary{
+		Title:    args.Title,
+		Playbook: args.Playbook,
+		Play:     args.Play,
+		Hosts:    make(map[string]HostStats, len(args.Hosts)+len(args.FailedHosts)),
+	}
+
+	for name, stats := range args.Hosts {
+		summary.Hosts[name] = stats
+	}
+
+	for _, name := range args.FailedHosts {
+		stats := summary.Hosts[name]
+		if stats.Failures == 0 {
+			stats.Failures = 1
+		}
+		summary.Hosts[name] = stats
+	}
+
+	if args.Started != "" {
+		started, err := parseTime("started", args.Started)
+		if err != nil {
+			return PlaySummary{}, err
+		}
+		summary.Started = started
+		summary.Ended = now
+	}
+
+	if args.Ended != "" {
+		ended, err := parseTime("ended", args.Ended)
+		if err != nil {
+			return PlaySummary{}, err
+		}
+		summary.Ended = ended
+	}
+
+	return summary, nil
+}
+
+// RunModule sends the summary card described by the given JSON module
+// arguments and writes the module result to the given writer, returning
+// the exit code of the module. The given options are applied to the client
+// after those specified by the arguments.
+func RunModule(ctx context.Context, data []byte, w io.Writer, opts ...sender.Option) int {
+	result, err := runModule(ctx, data, opts)
+	if err != nil {
+		result = ModuleResult{Failed: true, Msg: debugbundle.RedactURLs(err.Error()), Title: result.Title}
+	}
+
+	if encodeErr := json.NewEncoder(w).Encode(result); encodeErr != nil || err != nil {
+		return 1
+	}
+
+	return 0
+}
+
+// runModule sends the summary card described by the given JSON module
+// arguments.
+func runModule(ctx context.Context, data []byte, opts []sender.Option) (ModuleResult, error) {
+	args, err := ParseModuleArgs(data)
+	if err != nil {
+		return ModuleResult{}, err
+	}
+
+	summary, err := args.Summary(time.Now())
+	if err != nil {
+		return ModuleResult{}, err
+	}
+
+	msg := summary.Message()
+	result := ModuleResult{Changed: true, Title: msg.Title}
+
+	if args.CheckMode {
+		result.Msg = "summary card not sent in check mode"
+		return result, nil
+	}
+
+	clientOpts := []sender.Option{
+		sender.WithWorkers(1),
+		sender.WithRetries(args.Retries, args.RetriesDelay),
+	}
+	if args.ValidateURL != nil {
+		clientOpts = append(clientOpts, sender.WithWebhookURLValidation(*args.ValidateURL))
+	}
+	clientOpts = append(clientOpts, opts...)
+
+	client, err := sender.New(args.URL, clientOpts...)
+	if err != nil {
+		return result, err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(args.Timeout*float64(time.Second)))
+	defer cancel()
+
+	if _, err := client.Send(ctx, msg); err != nil {
+		return result, fmt.Errorf("failed to send summary card: %w", err)
+	}
+
+	result.Msg = "summary card sent"
+
+	return result, nil
+}
@@ -0,0 +1,216 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package ansible
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/send2teams/sender"
+)
+
+// maxListedHosts is the maximum number of hosts listed individually for
+// each outcome in a summary.
+const maxListedHosts int = 20
+
+// senderName is the sender noted on summary cards.
+const senderName string = "Ansible"
+
+// HostStats are the task results for a single host, as reported by Ansible
+// at the end of a play (i.e., the PLAY RECAP line for the host).
+type HostStats struct {
+	OK          int `json:"ok"`
+	Changed     int `json:"changed"`
+	Failures    int `json:"failures"`
+	Unreachable int `json:"unreachable"`
+	Skipped     int `json:"skipped"`
+	Rescued     int `json:"rescued"`
+	Ignored     int `json:"ignored"`
+}
+
+// add returns the sum of the given stats.
+func (hs HostStats) add(other HostStats) HostStats {
+	return HostStats{
+		OK:          hs.OK + other.OK,
+		Changed:     hs.Changed + other.Changed,
+		Failures:    hs.Failures + other.Failures,
+		Unreachable: hs.Unreachable + other.Unreachable,
+		Skipped:     hs.Skipped + other.Skipped,
+		Rescued:     hs.Rescued + other.Rescued,
+		Ignored:     hs.Ignored + other.Ignored,
+	}
+}
+
+// String returns the stats in the format used by the Ansible PLAY RECAP.
+func (hs HostStats) String() string {
+	return fmt.Sprintf(
+		"ok=%d changed=%d unreachable=%d failed=%d skipped=%d rescued=%d ignored=%d",
+		hs.OK, hs.Changed, hs.Unreachable, hs.Failures, hs.Skipped, hs.Rescued, hs.Ignored,
+	)
+}
+
+// PlaySummary describes the outcome of an Ansible play.
+type PlaySummary struct {
+
+	// Title is the (optional) title of the card. If not specified, the title
+	// notes the outcome and name of the play.
+	Title string `json:"title,omitempty"`
+
+	// Playbook is the (optional) name of the playbook.
+	Playbook string `json:"playbook,omitempty"`
+
+	// Play is the name of the play.
+	Play string `json:"play"`
+
+	// Started and Ended are (optionally) when the play started and ended.
+	Started time.Time `json:"started,omitempty"`
+	Ended   time.Time `json:"ended,omitempty"`
+
+	// Hosts are the task results for each host targeted by the play, keyed
+	// by host name.
+	Hosts map[string]HostStats `json:"hosts"`
+}
+
+// Duration returns how long the play took, or zero if not known.
+func (s PlaySummary) Duration() time.Duration {
+	if s.Started.IsZero() || s.Ended.IsZero() || s.Ended.Before(s.Started) {
+		return 0
+	}
+
+	return s.Ended.Sub(s.Started)
+}
+
+// Totals returns the sum of the task results of all hosts.
+func (s PlaySummary) Totals() HostStats {
+	var totals HostStats
+	for _, stats := range s.Hosts {
+		totals = totals.add(stats)
+	}
+
+	return totals
+}
+
+// hostsWhere returns the sorted names of the hosts whose stats match the
+// given condition.
+func (s PlaySummary) hostsWhere(match func(HostStats) bool) []string {
+	var names []string
+	for name, stats := range s.Hosts {
+		if match(stats) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// ChangedHosts returns the sorted names of the hosts with changed tasks.
+func (s PlaySummary) ChangedHosts() []string {
+	return s.hostsWhere(func(hs HostStats) bool { return hs.Changed > 0 })
+}
+
+// FailedHosts returns the sorted names of the hosts with failed tasks.
+func (s PlaySummary) FailedHosts() []string {
+	return s.hostsWhere(func(hs HostStats) bool { return hs.Failures > 0 })
+}
+
+// UnreachableHosts returns the sorted names of the hosts which could not be
+// reached.
+func (s PlaySummary) UnreachableHosts() []string {
+	return s.hostsWhere(func(hs HostStats) bool { return hs.Unreachable > 0 })
+}
+
+// Failed indicates whether any host failed or was unreachable.
+func (s PlaySummary) Failed() bool {
+	totals := s.Totals()
+
+	return totals.Failures > 0 || totals.Unreachable > 0
+}
+
+// Message returns the summary card for the play.
+func (s PlaySummary) Message() sender.Message {
+	changed, failed, unreachable := s.ChangedHosts(), s.FailedHosts(), s.UnreachableHosts()
+
+	play := s.Play
+	if play == "" {
+		play = "(unnamed play)"
+	}
+
+	title := s.Title
+	if title == "" {
+		outcome := "completed"
+		if s.Failed() {
+			outcome = "failed"
+		}
+		title = fmt.Sprintf("Ansible play %s: %s", outcome, play)
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(
+		&text,
+		"Play **%s** ran against %d host(s): %d changed, %d failed, %d unreachable",
+		play, len(s.Hosts), len(changed), len(failed), len(unreachable),
+	)
+	if d := s.Duration(); d > 0 {
+		fmt.Fprintf(&text, " in %s", d.Round(time.Second))
+	}
+	text.WriteString(".")
+
+	msg := sender.Message{
+		Title:  title,
+		Text:   text.String(),
+		Sender: senderName,
+	}
+
+	addFact := func(title string, value string) {
+		msg.Facts = append(msg.Facts, sender.Fact{Title: title, Value: value})
+	}
+
+	if s.Playbook != "" {
+		addFact("Playbook", s.Playbook)
+	}
+	if !s.Started.IsZero() {
+		addFact("Started", s.Started.UTC().Format(time.RFC3339))
+	}
+	if d := s.Duration(); d > 0 {
+		addFact("Duration", d.Round(time.Second).String())
+	}
+
+	addFact("Tasks", s.Totals().String())
+
+	for _, hosts := range []struct {
+		title string
+		names []string
+	}{
+		{"Failed hosts", failed},
+		{"Unreachable hosts", unreachable},
+		{"Changed hosts", changed},
+	} {
+		if len(hosts.names) > 0 {
+			addFact(hosts.title, listHosts(hosts.names))
+		}
+	}
+
+	return msg
+}
+
+// listHosts returns the given host names as a comma-separated list, limited
+// to maxListedHosts names.
+func listHosts(names []string) string {
+	if len(names) <= maxListedHosts {
+		return strings.Join(names, ", ")
+	}
+
+	return fmt.Sprintf(
+		"%s and %d more",
+		strings.Join(names[:maxListedHosts], ", "),
+		len(names)-maxListedHosts,
+	)
+}
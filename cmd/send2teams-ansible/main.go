@@ -0,0 +1,86 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+// Command send2teams-ansible is an Ansible binary module which sends a
+// summary card for an Ansible play to Microsoft Teams.
+//
+// The module arguments are read as a JSON descriptor from the file given as
+// the first argument (as provided by Ansible when running a binary module)
+// or from stdin if no argument is given (as used by the companion callback
+// plugin). The module result is written to stdout as a JSON object.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/atc0005/send2teams/ansible"
+	"github.com/atc0005/send2teams/sender"
+)
+
+// maxArgsSize is the maximum size in bytes of the module arguments.
+const maxArgsSize = 8 * 1024 * 1024
+
+// version is updated via Makefile builds by referencing the fully-qualified
+// path to this variable, including the package.
+var version = "dev build"
+
+func main() {
+	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
+	log.SetPrefix("[send2teams-ansible] ")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	args, err := readArgs()
+	if err != nil {
+		// Ansible expects a JSON result even if the arguments are unusable.
+		_ = json.NewEncoder(os.Stdout).Encode(ansible.ModuleResult{Failed: true, Msg: err.Error()})
+		os.Exit(1)
+	}
+
+	os.Exit(ansible.RunModule(
+		ctx,
+		args,
+		os.Stdout,
+		sender.WithUserAgent(fmt.Sprintf("send2teams-ansible/%s", version)),
+	))
+}
+
+// readArgs returns the module arguments from the file given as the first
+// argument or from stdin.
+func readArgs() ([]byte, error) {
+	r := io.Reader(os.Stdin)
+	source := "stdin"
+
+	if len(os.Args) > 1 {
+		f, err := os.Open(os.Args[1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to open module arguments file: %w", err)
+		}
+		defer f.Close()
+
+		r = f
+		source = os.Args[1]
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, maxArgsSize+1))
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("failed to read module arguments from %s: %w", source, err)
+	case len(data) > maxArgsSize:
+		return nil, fmt.Errorf("module arguments read from %s exceed %d bytes", source, maxArgsSize)
+	}
+
+	return data, nil
+}
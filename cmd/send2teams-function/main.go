@@ -0,0 +1,162 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+// Command send2teams-function runs send2teams as an AWS Lambda function
+// (using a custom runtime) or an Azure Functions custom handler, submitting
+// a message to Microsoft Teams for each SNS notification or Event Grid event
+// which triggers the function. The platform is detected from the
+// environment.
+//
+// The webhook URL is specified using exactly one of the following
+// environment variables:
+//
+//	SEND2TEAMS_WEBHOOK_URL      the webhook URL
+//	SEND2TEAMS_URL_KEYVAULT     an Azure Key Vault secret (vault/secret[/version])
+//	SEND2TEAMS_URL_AWS_SSM      an AWS SSM Parameter Store parameter name or ARN
+//	SEND2TEAMS_URL_AWS_SECRETS  an AWS Secrets Manager secret name or ARN
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/atc0005/send2teams/internal/secrets"
+	"github.com/atc0005/send2teams/sender"
+	"github.com/atc0005/send2teams/serverless"
+)
+
+// Environment variables used to specify the webhook URL.
+const (
+	envWebhookURL        string = "SEND2TEAMS_WEBHOOK_URL"
+	envWebhookKeyVault   string = "SEND2TEAMS_URL_KEYVAULT"
+	envWebhookAWSSSM     string = "SEND2TEAMS_URL_AWS_SSM"
+	envWebhookAWSSecrets string = "SEND2TEAMS_URL_AWS_SECRETS"
+)
+
+// secretLookupTimeout is the maximum time spent retrieving the webhook URL
+// from a secret store.
+const secretLookupTimeout = 15 * time.Second
+
+// version is updated via Makefile builds by referencing the fully-qualified
+// path to this variable, including the package.
+var version = "dev build"
+
+func main() {
+	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
+	log.SetPrefix("[send2teams-function] ")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	_, isLambda := os.LookupEnv(serverless.LambdaRuntimeAPIEnv)
+
+	client, err := newClient(ctx)
+	if err != nil {
+		if isLambda {
+			if reportErr := serverless.ReportLambdaInitError(ctx, err); reportErr != nil {
+				log.Printf("failed to report initialization error: %v", reportErr)
+			}
+		}
+		log.Fatalf("failed to initialize function: %v", err)
+	}
+	defer client.Close()
+
+	h := serverless.NewHandler(client)
+
+	switch {
+	case isLambda:
+		err = serverless.ServeLambda(ctx, h)
+	case os.Getenv(serverless.AzureFunctionsPortEnv) != "":
+		err = serverless.ServeAzureFunctions(ctx, h, "")
+	default:
+		err = fmt.Errorf(
+			"neither %s nor %s environment variable set; not running as an AWS Lambda function or Azure Functions custom handler",
+			serverless.LambdaRuntimeAPIEnv,
+			serverless.AzureFunctionsPortEnv,
+		)
+	}
+
+	if err != nil && !errors.Is(err, context.Canceled) {
+		log.Printf("function stopped: %v", err)
+		client.Close()
+		os.Exit(1)
+	}
+}
+
+// newClient creates the client used to submit messages, retrieving the
+// webhook URL from the source specified by the environment.
+func newClient(ctx context.Context) (*sender.Client, error) {
+	webhookURL, err := webhookURL(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return sender.New(
+		webhookURL,
+		sender.WithUserAgent(fmt.Sprintf("send2teams-function/%s", version)),
+	)
+}
+
+// webhookURL returns the webhook URL specified by the environment.
+func webhookURL(ctx context.Context) (string, error) {
+	var source secrets.Source
+	var specified []string
+
+	if os.Getenv(envWebhookURL) != "" {
+		specified = append(specified, envWebhookURL)
+	}
+
+	for _, env := range []string{envWebhookKeyVault, envWebhookAWSSSM, envWebhookAWSSecrets} {
+		ref := os.Getenv(env)
+		if ref == "" {
+			continue
+		}
+		specified = append(specified, env)
+
+		var err error
+		switch env {
+		case envWebhookKeyVault:
+			source, err = secrets.ParseKeyVault(ref)
+		case envWebhookAWSSSM:
+			source, err = secrets.ParseSSMParameter(ref)
+		case envWebhookAWSSecrets:
+			source, err = secrets.ParseAWSSecret(ref)
+		}
+		if err != nil {
+			return "", fmt.Errorf("invalid %s value: %w", env, err)
+		}
+	}
+
+	switch {
+	case len(specified) == 0:
+		return "", fmt.Errorf(
+			"webhook URL not specified; set one of the %s, %s, %s or %s environment variables",
+			envWebhookURL, envWebhookKeyVault, envWebhookAWSSSM, envWebhookAWSSecrets,
+		)
+	case len(specified) > 1:
+		return "", fmt.Errorf("only one of the %s and %s environment variables may be set", specified[0], specified[1])
+	case source == nil:
+		return os.Getenv(envWebhookURL), nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, secretLookupTimeout)
+	defer cancel()
+
+	webhookURL, err := source.Fetch(ctx, http.DefaultClient)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve webhook URL from %s: %w", source, err)
+	}
+
+	return webhookURL, nil
+}
@@ -0,0 +1,252 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"golang.org/x/term"
+
+	goteamsnotify "github.com/atc0005/go-teams-notify/v2"
+	"github.com/atc0005/send2teams/internal/batch"
+	"github.com/atc0005/send2teams/internal/config"
+	"github.com/atc0005/send2teams/internal/delivery"
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// batchStdin is the batch file name indicating that message definitions are
+// read from stdin.
+const batchStdin string = "-"
+
+// batchResult is the machine-readable summary of a message defined by a
+// batch file.
+type batchResult struct {
+
+	// Line is the line of the batch file defining the message.
+	Line int `json:"line"`
+
+	delivery.Result
+}
+
+// runBatch sends each message defined by the user-specified batch file,
+// returning the exit code for the application. A result is reported for
+// each message; the exit code is non-zero if any message could not be sent.
+func runBatch(cfg *config.Config, deliverer *delivery.Deliverer) int {
+	input, name, err := openBatchFile(cfg.BatchFile)
+	if err != nil {
+		if !cfg.SilentOutput {
+			log.Printf("\n\nERROR: Failed to read batch file: %v\n\n", err)
+		}
+		return 1
+	}
+	defer input.Close()
+
+	reader := batch.NewReader(input)
+
+	var sent, failed int
+	for {
+		entry, err := reader.Next()
+		switch {
+		case errors.Is(err, io.EOF):
+			if !cfg.SilentOutput {
+				log.Printf("Batch %s complete: %d message(s) sent, %d failed", name, sent, failed)
+			}
+
+			if failed > 0 {
+				return 1
+			}
+			return 0
+
+		case errors.Is(err, batch.ErrInvalidEntry):
+			if !cfg.SilentOutput {
+				log.Printf("\n\nERROR: Skipping line %d of batch %s: %v\n\n", reader.Line(), name, err)
+			}
+			emitBatchResult(cfg, reader.Line(), deliverer.NewResult(teams.NewReceiptID(), err))
+			failed++
+
+		case err != nil:
+			if !cfg.SilentOutput {
+				log.Printf("\n\nERROR: Failed to read batch %s after line %d: %v\n\n", name, reader.Line(), err)
+			}
+			return 1
+
+		default:
+			if sendBatchEntry(cfg, deliverer, reader.Line(), entry) {
+				sent++
+			} else {
+				failed++
+			}
+		}
+	}
+}
+
+// openBatchFile opens the given batch file, returning the name used to refer
+// to it in log messages. Message definitions are read from stdin if the
+// file is not specified (or is "-"), provided that stdin is not a terminal.
+func openBatchFile(path string) (io.ReadCloser, string, error) {
+	if path != "" && path != batchStdin {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, "", err
+		}
+		return f, path, nil
+	}
+
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil, "", fmt.Errorf("batch file not specified and stdin is a terminal")
+	}
+
+	return io.NopCloser(os.Stdin), "stdin", nil
+}
+
+// batchMessage returns the message defined by the given batch entry. Facts,
+// sections, target URLs, user mentions and attachments specified via flags
+// are added to those of the entry.
+func batchMessage(cfg *config.Config, entry batch.Entry) teams.Message {
+	common := cfg.TeamsMessage()
+	msg := entry.Message
+
+	title := msg.Title
+	if title == "" {
+		title = cfg.MessageTitle
+	}
+	if title == "" && cfg.AllowUntitled {
+		title = teams.DeriveTitle(msg.Text, cfg.Sender)
+	}
+	msg.Title = cfg.DecorateTitle(title)
+
+	if msg.Sender == "" {
+		msg.Sender = common.Sender
+	}
+
+	if msg.Activity == (teams.Activity{}) {
+		msg.Activity = common.Activity
+	}
+
+	msg.Facts = append(msg.Facts, common.Facts...)
+	msg.Sections = append(msg.Sections, common.Sections...)
+	msg.TargetURLs = append(msg.TargetURLs, common.TargetURLs...)
+	msg.UserMentions = append(msg.UserMentions, common.UserMentions...)
+	msg.Attachments = append(msg.Attachments, common.Attachments...)
+
+	return msg
+}
+
+// sendBatchEntry sends the message defined by the given line of the batch
+// file, indicating whether the message was sent.
+func sendBatchEntry(cfg *config.Config, deliverer *delivery.Deliverer, line int, entry batch.Entry) bool {
+	result, sent := sendEntry(cfg, deliverer, fmt.Sprintf("line %d", line), entry)
+	emitBatchResult(cfg, line, result)
+
+	return sent
+}
+
+// sendEntry sends the message defined by the given batch entry, returning
+// the result and whether the message was sent. The source describes where
+// the entry was read from (e.g., "line 3") in log messages. Messages for a
+// webhook URL whose circuit breaker is open are rejected without being
+// submitted.
+func sendEntry(cfg *config.Config, deliverer *delivery.Deliverer, source string, entry batch.Entry) (delivery.Result, bool) {
+	ctxSubmissionTimeout, cancel := context.WithTimeout(context.Background(), cfg.TeamsSubmissionTimeout())
+	defer cancel()
+
+	receiptID := teams.NewReceiptID()
+	msg := batchMessage(cfg, entry)
+
+	cardOpts := cfg.CardOptions(msg.Sender)
+	if cfg.ReceiptFact {
+		cardOpts.ReceiptID = receiptID
+	}
+	if entry.Color != "" {
+		cardOpts.TitleColor = entry.Color
+	}
+
+	webhookURL := cfg.WebhookURL
+	if entry.WebhookURL != "" {
+		webhookURL = entry.WebhookURL
+	}
+
+	message, err := teams.NewAdaptiveCardMessage(msg, cardOpts)
+	if err != nil {
+		if !cfg.SilentOutput {
+			log.Printf("\n\nERROR: Failed to generate message for %s: %v\n\n", source, err)
+		}
+		result := deliverer.NewResult(receiptID, err)
+		recordSession(cfg, msg.Title, result)
+		recordHistory(cfg, msg.Title, result)
+
+		return result, false
+	}
+
+	if cfg.VerboseOutput {
+		if err := message.Prepare(); err == nil {
+			log.Println(message.PrettyPrint())
+		}
+	}
+
+	timing, sendErr := deliverer.DeliverTimed(ctxSubmissionTimeout, receiptID, webhookURL, message)
+
+	if cfg.VerboseOutput && len(timing.Attempts) > 0 {
+		log.Print(timing.Histogram())
+	}
+
+	ignoreSendErr := cfg.IgnoreInvalidResponse &&
+		errors.Is(sendErr, goteamsnotify.ErrInvalidWebhookURLResponseText)
+
+	resultErr := sendErr
+	if ignoreSendErr {
+		resultErr = nil
+	}
+	result := deliverer.NewResult(receiptID, resultErr)
+	if len(timing.Attempts) > 0 {
+		result.Timing = &timing
+	}
+
+	recordSession(cfg, msg.Title, result)
+	recordHistory(cfg, msg.Title, result)
+
+	switch {
+	case ignoreSendErr:
+		if !cfg.SilentOutput {
+			log.Printf("WARNING: invalid response received for %s (receipt %s); ignoring as requested: %v",
+				source, receiptID, sendErr)
+		}
+
+	case sendErr != nil:
+		if !cfg.SilentOutput {
+			log.Printf("\n\nERROR: Failed to submit message for %s (receipt %s): %v\n\n",
+				source, receiptID, sendErr)
+		}
+		return result, false
+
+	default:
+		if !cfg.SilentOutput {
+			log.Printf("Message for %s successfully sent! (receipt %s)", source, receiptID)
+		}
+	}
+
+	return result, true
+}
+
+// emitBatchResult emits the JSON formatted summary (if requested) of the
+// message defined by the given line of the batch file.
+func emitBatchResult(cfg *config.Config, line int, result delivery.Result) {
+	if !cfg.JSONOutput {
+		return
+	}
+
+	if err := json.NewEncoder(resultOutput).Encode(batchResult{Line: line, Result: result}); err != nil {
+		log.Printf("ERROR: Failed to emit JSON result: %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+//go:build !minimal
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/atc0005/send2teams/internal/bench"
+	"github.com/atc0005/send2teams/internal/config"
+	"github.com/atc0005/send2teams/internal/delivery"
+	"github.com/atc0005/send2teams/internal/mock"
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// benchMessageText is the message text submitted by bench mode if not
+// specified by the user.
+const benchMessageText = "send2teams benchmark message"
+
+// runBench submits generated messages to the built-in mock webhook server at
+// the user-specified rate and duration, then reports the resulting
+// throughput and latency percentiles. The exit code for the application is
+// returned.
+func runBench(cfg *config.Config, deliverer *delivery.Deliverer) int {
+	// Validated as part of initializing the configuration.
+	rate, _ := bench.ParseRate(cfg.BenchRate)
+
+	server, err := mock.Start(cfg.MockLatency)
+	if err != nil {
+		if !cfg.SilentOutput {
+			log.Printf("ERROR: Failed to start %s mode: %v", config.SubcommandBench, err)
+		}
+		return 1
+	}
+	defer func() {
+		if err := server.Close(); err != nil && !cfg.SilentOutput {
+			log.Printf("WARNING: Failed to stop mock webhook server: %v", err)
+		}
+	}()
+
+	msg := cfg.TeamsMessage()
+	if msg.Text == "" {
+		msg.Text = benchMessageText
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if !cfg.SilentOutput {
+		log.Printf(
+			"Submitting messages to %s at %s for %v",
+			server.URL(),
+			cfg.BenchRate,
+			cfg.BenchDuration,
+		)
+	}
+
+	// Each message is generated and submitted in the same way as a one-off
+	// message.
+	send := func(ctx context.Context) error {
+		receiptID := teams.NewReceiptID()
+
+		opts := cfg.CardOptions(cfg.Sender)
+		if cfg.ReceiptFact {
+			opts.ReceiptID = receiptID
+		}
+
+		message, err := teams.NewAdaptiveCardMessage(msg, opts)
+		if err != nil {
+			return fmt.Errorf("failed to generate message: %w", err)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, cfg.TeamsSubmissionTimeout())
+		defer cancel()
+
+		return deliverer.Deliver(ctx, receiptID, server.URL(), message)
+	}
+
+	report := bench.Run(ctx, rate, cfg.BenchDuration, send)
+
+	// Machine-readable output is emitted regardless of the silent flag.
+	switch {
+	case cfg.JSONOutput:
+		if err := report.Write(resultOutput); err != nil {
+			log.Printf("ERROR: Failed to emit JSON report: %v", err)
+		}
+	default:
+		fmt.Fprint(resultOutput, report.Text())
+	}
+
+	if report.Failed > 0 {
+		return 1
+	}
+
+	return 0
+}
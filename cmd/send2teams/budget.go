@@ -0,0 +1,167 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/atc0005/go-teams-notify/v2/adaptivecard"
+	"github.com/atc0005/send2teams/internal/budget"
+	"github.com/atc0005/send2teams/internal/config"
+	"github.com/atc0005/send2teams/internal/delivery"
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// buildFunc generates a Microsoft Teams message from the given message
+// details.
+type buildFunc func(msg teams.Message) (*adaptivecard.Message, error)
+
+// budgetOutcome is the result of checking the send budget for a message.
+type budgetOutcome struct {
+
+	// message is the generated message. Nil if the message was dropped or
+	// suppressed.
+	message *adaptivecard.Message
+
+	// status is the result status for a message which is not sent now.
+	// Empty if the message is within budget.
+	status string
+
+	// spooled is the collection of previously spooled messages which are
+	// now within budget.
+	spooled []budget.Spooled
+}
+
+// reserveSend checks the send budget for the given message, counting it
+// against the budget if allowed and otherwise applying the user-specified
+// over budget action. The message is generated using the given function.
+func reserveSend(cfg *config.Config, receiptID string, msg teams.Message, build buildFunc) (outcome budgetOutcome, err error) {
+	ledger, err := budget.Open(cfg.BudgetDir, cfg.WebhookURL, cfg.SendBudget())
+	if err != nil {
+		return budgetOutcome{}, err
+	}
+
+	defer func() {
+		if closeErr := ledger.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	now := time.Now()
+
+	if cfg.OverBudget == budget.ActionSpool {
+		outcome.spooled, err = ledger.TakeSpooled(now)
+		if err != nil {
+			return outcome, err
+		}
+	}
+
+	switch {
+	case ledger.Allow(now):
+		ledger.Record(now)
+
+		if cfg.OverBudget == budget.ActionSummarize {
+			if note := budget.Summary(ledger.TakeSuppressed()); note != "" {
+				msg.Text += "\n\n" + note
+			}
+		}
+
+	case cfg.OverBudget == budget.ActionSummarize:
+		ledger.Suppress(budget.Suppression{
+			Time:      now.UTC(),
+			Title:     msg.Title,
+			ReceiptID: receiptID,
+		})
+		outcome.status = delivery.StatusSuppressed
+
+		return outcome, nil
+
+	case cfg.OverBudget == budget.ActionSpool:
+		outcome.status = delivery.StatusSpooled
+
+	default:
+		outcome.status = delivery.StatusDropped
+
+		return outcome, nil
+	}
+
+	outcome.message, err = build(msg)
+	if err != nil {
+		return outcome, err
+	}
+
+	if outcome.status == delivery.StatusSpooled {
+		payload, err := json.Marshal(outcome.message)
+		if err != nil {
+			return outcome, fmt.Errorf("failed to encode message for spooling: %w", err)
+		}
+
+		if err := ledger.Spool(now, receiptID, payload); err != nil {
+			return outcome, err
+		}
+	}
+
+	return outcome, nil
+}
+
+// deliverSpooled submits previously spooled messages which are now within
+// the send budget. If a submission fails the message and those spooled after
+// it are returned to the spool for a later invocation. Failures are logged,
+// but do not affect the exit code.
+func deliverSpooled(cfg *config.Config, deliverer *delivery.Deliverer, spooled []budget.Spooled) {
+	for i, item := range spooled {
+		var message adaptivecard.Message
+		if err := json.Unmarshal(item.Payload, &message); err != nil {
+			if !cfg.SilentOutput {
+				log.Printf("WARNING: Failed to decode spooled message (receipt %s): %v", item.ReceiptID, err)
+			}
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.TeamsSubmissionTimeout())
+		err := deliverer.Deliver(ctx, item.ReceiptID, cfg.WebhookURL, &message)
+		cancel()
+
+		if err != nil {
+			if !cfg.SilentOutput {
+				log.Printf("WARNING: Failed to submit spooled message (receipt %s): %v", item.ReceiptID, err)
+			}
+			restoreSpooled(cfg, spooled[i:])
+
+			return
+		}
+
+		if !cfg.SilentOutput {
+			log.Printf("Spooled message successfully sent! (receipt %s)", item.ReceiptID)
+		}
+	}
+}
+
+// restoreSpooled returns the given previously spooled messages to the spool,
+// no longer counting them against the send budget.
+func restoreSpooled(cfg *config.Config, items []budget.Spooled) {
+	ledger, err := budget.Open(cfg.BudgetDir, cfg.WebhookURL, cfg.SendBudget())
+	if err != nil {
+		if !cfg.SilentOutput {
+			log.Printf("WARNING: Failed to restore %d spooled message(s): %v", len(items), err)
+		}
+		return
+	}
+
+	if err := ledger.Restore(items); err != nil && !cfg.SilentOutput {
+		log.Printf("WARNING: Failed to restore spooled messages: %v", err)
+	}
+
+	if err := ledger.Close(); err != nil && !cfg.SilentOutput {
+		log.Printf("WARNING: Failed to restore spooled messages: %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+
+	"github.com/atc0005/send2teams/internal/config"
+	"github.com/atc0005/send2teams/internal/debugbundle"
+	"github.com/atc0005/send2teams/internal/history"
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// debugBundleHistoryEntries is the number of the most recent history
+// records included in a debug bundle.
+const debugBundleHistoryEntries int = 20
+
+// debugBundleEnvVars are the environment variables (in addition to those
+// with the SEND2TEAMS_ prefix) whose presence is reported in a debug bundle.
+// Their values are not included.
+var debugBundleEnvVars = []string{
+	"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY",
+	"http_proxy", "https_proxy", "no_proxy",
+	"NAGIOS_NOTIFICATIONTIMEOUT", "NO_COLOR", "TZ", "LANG",
+}
+
+// runDebugBundle writes redacted diagnostic details for the user-specified
+// configuration to the user-specified zip archive, returning the exit code
+// for the application.
+func runDebugBundle(cfg *config.Config) int {
+	bundle := debugbundle.New(time.Now())
+
+	bundle.Add("version.txt", []byte(fmt.Sprintf(
+		"%s %s (%s build)\n%s %s/%s\n",
+		cfg.App.Name,
+		cfg.App.Version,
+		config.BuildVariant,
+		runtime.Version(),
+		runtime.GOOS,
+		runtime.GOARCH,
+	)))
+
+	bundle.Add("environment.txt", debugBundleEnvironment())
+
+	if err := bundle.AddJSON("config.json", cfg.RedactedFlagValues()); err != nil {
+		return debugBundleFailed(cfg, err)
+	}
+
+	bundle.Add("validation.txt", []byte(debugbundle.RedactURLs(string(debugBundleValidation(cfg)))))
+
+	if cfg.HistoryDir != "" {
+		entries, err := debugBundleHistory(cfg.HistoryDir)
+		if err != nil {
+			entries = []byte(err.Error() + "\n")
+		}
+		bundle.Add("history.jsonl", entries)
+	}
+
+	bundle.Add("payload.json", debugBundlePayload(cfg))
+
+	if err := bundle.Write(cfg.BundleFile); err != nil {
+		return debugBundleFailed(cfg, err)
+	}
+
+	if !cfg.SilentOutput {
+		log.Printf(
+			"Wrote %s (%s); review the content before sharing it",
+			cfg.BundleFile,
+			strings.Join(bundle.Names(), ", "),
+		)
+	}
+
+	return 0
+}
+
+// debugBundleFailed reports the failure to write a debug bundle, returning
+// the exit code for the application.
+func debugBundleFailed(cfg *config.Config, err error) int {
+	if !cfg.SilentOutput {
+		log.Printf("\n\nERROR: Failed to write debug bundle: %v\n\n", err)
+	}
+
+	return 1
+}
+
+// debugBundleEnvironment returns a summary of the environment: whether the
+// relevant environment variables are set (but not their values) and whether
+// stdin and stdout are terminals.
+func debugBundleEnvironment() []byte {
+	names := append([]string(nil), debugBundleEnvVars...)
+	for _, env := range os.Environ() {
+		if name, _, _ := strings.Cut(env, "="); strings.HasPrefix(name, "SEND2TEAMS_") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var b bytes.Buffer
+	for _, name := range names {
+		state := "unset"
+		if _, ok := os.LookupEnv(name); ok {
+			state = "set"
+		}
+		fmt.Fprintf(&b, "%s: %s\n", name, state)
+	}
+
+	fmt.Fprintf(&b, "stdin terminal: %t\n", term.IsTerminal(int(os.Stdin.Fd())))
+	fmt.Fprintf(&b, "stdout terminal: %t\n", term.IsTerminal(int(os.Stdout.Fd())))
+
+	return b.Bytes()
+}
+
+// debugBundleValidation returns the warnings for the configuration along
+// with the problems which would prevent a message from being sent using it.
+func debugBundleValidation(cfg *config.Config) []byte {
+	var b bytes.Buffer
+	for _, warning := range cfg.Warnings() {
+		fmt.Fprintf(&b, "WARNING: %s\n", warning)
+	}
+
+	send := *cfg
+	send.Subcommand = ""
+	if err := send.Validate(cfg.DisableWebhookURLValidation); err != nil {
+		fmt.Fprintf(&b, "ERROR: %v\n", err)
+	}
+
+	if b.Len() == 0 {
+		b.WriteString("No problems found\n")
+	}
+
+	return b.Bytes()
+}
+
+// debugBundleHistory returns the most recent records from the given history
+// directory as newline delimited JSON. URLs within errors (e.g., the webhook
+// URL of a failed request) are redacted.
+func debugBundleHistory(dir string) ([]byte, error) {
+	records, err := history.Search(dir, history.Query{})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) > debugBundleHistoryEntries {
+		records = records[len(records)-debugBundleHistoryEntries:]
+	}
+
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	for _, rec := range records {
+		rec.Error = debugbundle.RedactURLs(rec.Error)
+		if err := encoder.Encode(rec); err != nil {
+			return nil, err
+		}
+	}
+
+	return b.Bytes(), nil
+}
+
+// debugBundlePayload returns the payload which would be submitted for the
+// configuration, or a description of why it could not be generated.
+func debugBundlePayload(cfg *config.Config) []byte {
+	if payload := cfg.Payload(); payload != nil {
+		return []byte(payload.PrettyPrint() + "\n")
+	}
+
+	message, err := teams.NewAdaptiveCardMessage(cfg.TeamsMessage(), cfg.CardOptions(cfg.Sender))
+	if err == nil {
+		err = message.Prepare()
+	}
+	if err != nil {
+		return []byte(fmt.Sprintf("Failed to generate payload: %v\n", err))
+	}
+
+	return []byte(message.PrettyPrint() + "\n")
+}
@@ -0,0 +1,41 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"log"
+
+	"github.com/atc0005/send2teams/internal/config"
+	"github.com/atc0005/send2teams/internal/webhook"
+)
+
+// explainValidation displays a report of each webhook URL validation stage
+// for the user-specified webhook URL (or the webhook URL of each selected
+// target), returning the exit code for the application. A non-zero exit code
+// is returned if any webhook URL fails validation.
+func explainValidation(cfg *config.Config) int {
+	var exitCode int
+
+	for _, target := range cfg.WebhookTargets() {
+		report := webhook.Explain(target.Name, target.WebhookURL)
+		if err := report.Write(diagnosticOutput); err != nil {
+			log.Printf("\n\nERROR: Failed to display validation report: %v\n\n", err)
+			return 1
+		}
+
+		if !report.Valid() {
+			exitCode = 1
+		}
+	}
+
+	if exitCode != 0 && cfg.DisableWebhookURLValidation {
+		log.Printf("WARNING: webhook URL validation is disabled; messages would be submitted regardless of these failures")
+	}
+
+	return exitCode
+}
@@ -0,0 +1,51 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"log"
+	"strings"
+
+	"github.com/atc0005/send2teams/internal/config"
+	"github.com/atc0005/send2teams/internal/defaults"
+)
+
+// runExportDefaults writes the embedded default themes and schemas to the
+// user-specified directory, returning the exit code for the application.
+// Existing files are left as-is.
+func runExportDefaults(cfg *config.Config) int {
+	written, skipped, err := defaults.Export(cfg.ExportDir)
+
+	if !cfg.SilentOutput {
+		for _, path := range written {
+			log.Printf("Exported %s", path)
+		}
+
+		for _, path := range skipped {
+			log.Printf("WARNING: Skipped %s; file already exists", path)
+		}
+	}
+
+	if err != nil {
+		if !cfg.SilentOutput {
+			log.Printf("\n\nERROR: Failed to export defaults: %v\n\n", err)
+		}
+		return 1
+	}
+
+	if !cfg.SilentOutput {
+		log.Printf(
+			"Exported %d file(s) to %s; place customized files within one of these directories to override the defaults: %s",
+			len(written),
+			cfg.ExportDir,
+			strings.Join(defaults.SearchPath(), ", "),
+		)
+	}
+
+	return 0
+}
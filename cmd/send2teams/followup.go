@@ -0,0 +1,116 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	goteamsnotify "github.com/atc0005/go-teams-notify/v2"
+	"github.com/atc0005/send2teams/internal/config"
+	"github.com/atc0005/send2teams/internal/delivery"
+	"github.com/atc0005/send2teams/internal/followup"
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// updateFollowUps records the follow-up (if any) requested for the message
+// successfully sent with the given receipt ID and title, cancels the
+// pending follow-up for a resolved message and then posts any follow-ups
+// which are due for the webhook URL. Failures are logged, but do not affect
+// the exit code.
+func updateFollowUps(cfg *config.Config, deliverer *delivery.Deliverer, receiptID string, title string) {
+	store, err := followup.Open(cfg.FollowUpDir, cfg.WebhookURL)
+	if err != nil {
+		// Follow-ups are not used unless a directory is available.
+		return
+	}
+
+	now := time.Now()
+
+	switch {
+	case cfg.Resolved:
+		resolved, err := store.Resolve(cfg.CorrelationID)
+		switch {
+		case err != nil && !cfg.SilentOutput:
+			log.Printf("WARNING: Failed to cancel follow-up for correlation ID %q: %v", cfg.CorrelationID, err)
+		case resolved && cfg.VerboseOutput:
+			log.Printf("Cancelled pending follow-up for correlation ID %q", cfg.CorrelationID)
+		}
+
+	case cfg.FollowUpAfter > 0:
+		f := followup.FollowUp{
+			CorrelationID: cfg.CorrelationID,
+			Due:           now.Add(cfg.FollowUpAfter).UTC(),
+			ReceiptID:     receiptID,
+			Sent:          now.UTC(),
+			Title:         title,
+			Message:       cfg.FollowUpMessage,
+			Sender:        cfg.Sender,
+		}
+
+		switch err := store.Schedule(f); {
+		case err != nil && !cfg.SilentOutput:
+			log.Printf("WARNING: Failed to schedule follow-up for correlation ID %q: %v", cfg.CorrelationID, err)
+		case err == nil && cfg.VerboseOutput:
+			log.Printf("Follow-up for correlation ID %q due at %s", cfg.CorrelationID, f.Due.Local().Format(time.RFC3339))
+		}
+	}
+
+	due, err := store.TakeDue(now)
+	if err != nil && !cfg.SilentOutput {
+		log.Printf("WARNING: %v", err)
+	}
+
+	for i, f := range due {
+		if err := sendFollowUp(cfg, deliverer, f); err != nil {
+			if !cfg.SilentOutput {
+				log.Printf("WARNING: Failed to post follow-up for correlation ID %q: %v", f.CorrelationID, err)
+			}
+
+			// Remaining follow-ups are posted by a later invocation.
+			for _, pending := range due[i:] {
+				if err := store.Schedule(pending); err != nil && !cfg.SilentOutput {
+					log.Printf("WARNING: Failed to reschedule follow-up for correlation ID %q: %v", pending.CorrelationID, err)
+				}
+			}
+
+			return
+		}
+
+		if !cfg.SilentOutput {
+			log.Printf("Follow-up for correlation ID %q successfully sent!", f.CorrelationID)
+		}
+	}
+}
+
+// sendFollowUp generates and submits the given follow-up message.
+func sendFollowUp(cfg *config.Config, deliverer *delivery.Deliverer, f followup.FollowUp) error {
+	receiptID := teams.NewReceiptID()
+
+	opts := cfg.CardOptions(f.Sender)
+	if cfg.ReceiptFact {
+		opts.ReceiptID = receiptID
+	}
+
+	message, err := teams.NewAdaptiveCardMessage(f.TeamsMessage(), opts)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.TeamsSubmissionTimeout())
+	defer cancel()
+
+	err = deliverer.Deliver(ctx, receiptID, cfg.WebhookURL, message)
+	if cfg.IgnoreInvalidResponse && errors.Is(err, goteamsnotify.ErrInvalidWebhookURLResponseText) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,128 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/atc0005/send2teams/internal/config"
+	"github.com/atc0005/send2teams/internal/delivery"
+	"github.com/atc0005/send2teams/internal/history"
+)
+
+// historyTimeLayout is the layout of the times listed by the history
+// subcommand.
+const historyTimeLayout string = "2006-01-02 15:04:05 MST"
+
+// recordHistory records the given submission result in the user-specified
+// history directory, if any. Failures are logged, but do not affect the
+// outcome of the submission.
+func recordHistory(cfg *config.Config, title string, result delivery.Result) {
+	if cfg.HistoryDir == "" {
+		return
+	}
+
+	rec := history.Record{
+		Time:      result.Time,
+		ReceiptID: result.ReceiptID,
+		Title:     title,
+		Status:    result.Status,
+		Error:     result.Error,
+		Target:    cfg.HistoryTargetName(),
+		Team:      result.Team,
+		Channel:   result.Channel,
+	}
+
+	if err := history.Append(cfg.HistoryDir, rec); err != nil {
+		if !cfg.SilentOutput {
+			log.Printf("WARNING: %v", err)
+		}
+		return
+	}
+
+	if cfg.HistoryMaxAge > 0 {
+		if err := history.Prune(cfg.HistoryDir, time.Now().Add(-cfg.HistoryMaxAge)); err != nil && !cfg.SilentOutput {
+			log.Printf("WARNING: %v", err)
+		}
+	}
+}
+
+// runHistory lists the sends recorded in the user-specified history
+// directory, returning the exit code for the application.
+func runHistory(cfg *config.Config) int {
+	records, err := history.Search(cfg.HistoryDir, history.Query{
+		Since:      time.Now().Add(-cfg.HistorySince),
+		Target:     cfg.HistoryTarget,
+		FailedOnly: cfg.HistoryFailedOnly,
+	})
+	if err != nil {
+		if !cfg.SilentOutput {
+			log.Printf("\n\nERROR: Failed to query send history: %v\n\n", err)
+		}
+		return 1
+	}
+
+	// Machine-readable output is emitted regardless of the silent flag.
+	if cfg.JSONOutput {
+		encoder := json.NewEncoder(resultOutput)
+		for _, rec := range records {
+			if err := encoder.Encode(rec); err != nil {
+				log.Printf("ERROR: Failed to emit JSON result: %v", err)
+				return 1
+			}
+		}
+		return 0
+	}
+
+	if len(records) == 0 {
+		if !cfg.SilentOutput {
+			log.Printf("No sends recorded within the last %v", cfg.HistorySince)
+		}
+		return 0
+	}
+
+	w := tabwriter.NewWriter(resultOutput, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tSTATUS\tTARGET\tTEAM\tCHANNEL\tTITLE\tRECEIPT\tERROR")
+	for _, rec := range records {
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			rec.Time.Local().Format(historyTimeLayout),
+			rec.Status,
+			historyField(rec.Target),
+			historyField(rec.Team),
+			historyField(rec.Channel),
+			historyField(rec.Title),
+			rec.ReceiptID,
+			historyField(rec.Error),
+		)
+	}
+
+	if err := w.Flush(); err != nil {
+		log.Printf("ERROR: Failed to list send history: %v", err)
+		return 1
+	}
+
+	return 0
+}
+
+// historyField returns the given value for display as a single column of the
+// history listing. Empty values are shown as "-".
+func historyField(value string) string {
+	value = strings.Join(strings.Fields(value), " ")
+	if value == "" {
+		return "-"
+	}
+
+	return value
+}
@@ -0,0 +1,79 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/atc0005/send2teams/internal/config"
+	"github.com/atc0005/send2teams/internal/netcheck"
+)
+
+// lintProbeTimeout is the maximum time spent checking that each webhook URL
+// is reachable when the online flag is specified.
+const lintProbeTimeout time.Duration = 10 * time.Second
+
+// runLintConfig reports the problems found in the user-specified
+// configuration file, returning the exit code for the application. The
+// exit code is non-zero if any errors (rather than only warnings) are
+// found.
+func runLintConfig(cfg *config.Config) int {
+	var probe func(string) error
+	if cfg.Online {
+		probe = func(webhookURL string) error {
+			return netcheck.Probe(context.Background(), webhookURL, lintProbeTimeout)
+		}
+	}
+
+	problems, err := cfg.LintConfigFile(probe)
+	if err != nil {
+		if !cfg.SilentOutput {
+			log.Printf("\n\nERROR: Failed to check configuration file: %v\n\n", err)
+		}
+		return 1
+	}
+
+	var errorCount, warningCount int
+	for _, problem := range problems {
+		if problem.Severity == config.LintSeverityError {
+			errorCount++
+		} else {
+			warningCount++
+		}
+
+		// The problems are emitted regardless of the silent flag so that
+		// they may be collected by the caller.
+		if cfg.JSONOutput {
+			if err := json.NewEncoder(resultOutput).Encode(problem); err != nil {
+				log.Printf("ERROR: Failed to emit JSON result: %v", err)
+				return 1
+			}
+			continue
+		}
+		fmt.Fprintln(resultOutput, problem)
+	}
+
+	if !cfg.SilentOutput {
+		switch {
+		case len(problems) == 0:
+			log.Printf("No problems found in %s", cfg.LintFile)
+		default:
+			log.Printf("%d error(s) and %d warning(s) found in %s", errorCount, warningCount, cfg.LintFile)
+		}
+	}
+
+	if errorCount > 0 {
+		return 1
+	}
+
+	return 0
+}
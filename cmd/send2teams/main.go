@@ -12,13 +12,17 @@ package main
 import (
 	"context"
 	"errors"
-	"fmt"
 	"log"
 	"os"
+	"time"
 
 	goteamsnotify "github.com/atc0005/go-teams-notify/v2"
 	"github.com/atc0005/go-teams-notify/v2/adaptivecard"
 	"github.com/atc0005/send2teams/internal/config"
+	"github.com/atc0005/send2teams/internal/delivery"
+	"github.com/atc0005/send2teams/internal/idempotency"
+	"github.com/atc0005/send2teams/internal/netcheck"
+	"github.com/atc0005/send2teams/internal/teams"
 )
 
 func main() {
@@ -27,6 +31,7 @@ func main() {
 	// differentiate between loggers from other imported packages
 	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
 	log.SetPrefix("[send2teams] ")
+	configureOutput()
 
 	// Toggle library debug logging output
 	// goteamsnotify.EnableLogging()
@@ -37,6 +42,13 @@ func main() {
 	case errors.Is(cfgErr, config.ErrVersionRequested):
 		config.Branding()
 		os.Exit(0)
+	case errors.Is(cfgErr, config.ErrHelpRequested):
+		if err := cfg.WriteHelp(os.Stdout); err != nil {
+			log.Fatalf("failed to write help: %s", err)
+		}
+		os.Exit(0)
+	case errors.Is(cfgErr, config.ErrExplainValidationRequested):
+		os.Exit(explainValidation(cfg))
 	case cfgErr != nil:
 		log.Fatalf("failed to initialize application: %s", cfgErr)
 	}
@@ -45,6 +57,12 @@ func main() {
 		log.Printf("Configuration: %s\n", cfg)
 	}
 
+	if !cfg.SilentOutput {
+		for _, warning := range cfg.Warnings() {
+			log.Printf("WARNING: %s", warning)
+		}
+	}
+
 	// Emulate returning exit code from main function by "queuing up" a
 	// default exit code that matches expectations, but allow explicitly
 	// setting the exit code in such a way that is compatible with using
@@ -58,204 +76,277 @@ func main() {
 		os.Exit(exitCode)
 	}(&appExitCode)
 
-	// This should only trigger if user specifies large retry values.
-	if cfg.TeamsSubmissionTimeout() > config.DefaultNagiosNotificationTimeout {
-		if !cfg.SilentOutput {
-			log.Printf(
-				"WARNING: app cancellation timeout value of %v greater than default Nagios command timeout value!",
-				cfg.TeamsSubmissionTimeout(),
-			)
-		}
+	if cfg.Subcommand == config.SubcommandTop {
+		appExitCode = runTop(cfg)
+		return
 	}
 
-	ctxSubmissionTimeout, cancel := context.WithTimeout(context.Background(), cfg.TeamsSubmissionTimeout())
-	defer cancel()
+	if cfg.Subcommand == config.SubcommandExportDefaults {
+		appExitCode = runExportDefaults(cfg)
+		return
+	}
 
-	// Create Microsoft Teams client
-	mstClient := goteamsnotify.NewTeamsClient()
+	if cfg.Subcommand == config.SubcommandMigrateURL {
+		appExitCode = runMigrateURL(cfg)
+		return
+	}
 
-	// Override User Agent.
-	mstClient.SetUserAgent(cfg.UserAgent())
+	if cfg.Subcommand == config.SubcommandLintConfig {
+		appExitCode = runLintConfig(cfg)
+		return
+	}
 
-	// Disable webhook URL validation if requested by user.
-	mstClient.SkipWebhookURLValidationOnSend(cfg.DisableWebhookURLValidation)
+	if cfg.Subcommand == config.SubcommandHistory {
+		appExitCode = runHistory(cfg)
+		return
+	}
 
-	// Convert EOL (useful for output from scripts) in the incoming text if
-	// user requested it.
-	if cfg.ConvertEOL {
-		cfg.MessageText = adaptivecard.ConvertEOL(cfg.MessageText)
+	if cfg.Subcommand == config.SubcommandDebugBundle {
+		appExitCode = runDebugBundle(cfg)
+		return
+	}
 
-		// Not 100% safe to apply across the board.
-		//
-		// It is unlikely, but not impossible that someone would submit raw
-		// text with break statements. When you consider that the flag is
-		// named "convert-eol", it is entirely reasonable that the user would
-		// expect break statements to remain untouched.
-		//
-		// cfg.MessageText = adaptivecard.ConvertBreakToEOL(cfg.MessageText)
+	if cfg.PreviewTerminal {
+		appExitCode = previewTerminal(cfg)
+		return
 	}
 
-	card, err := adaptivecard.NewTextBlockCard(cfg.MessageText, cfg.MessageTitle, true)
+	deliverer, err := delivery.New(cfg, newTeamsClient(cfg))
 	if err != nil {
 		if !cfg.SilentOutput {
-			log.Printf(
-				"\n\nERROR: Failed to create new card using specified text/title values for %q channel in the %q team: %v\n\n",
-				cfg.Channel,
-				cfg.Team,
-				err,
-			)
+			log.Printf("\n\nERROR: Failed to initialize message delivery: %v\n\n", err)
 		}
 		// Regardless of silent flag, explicitly note unsuccessful results
 		appExitCode = 1
 		return
 	}
-	card.SetFullWidth()
 
-	if len(cfg.UserMentions) > 0 {
-		// Process user mention details specified by user, create user mention
-		// values that we can attach to the card.
-		userMentions := make([]adaptivecard.Mention, 0, len(cfg.UserMentions))
-		for _, mention := range cfg.UserMentions {
-			userMention, err := adaptivecard.NewMention(mention.Name, mention.ID)
+	switch cfg.Subcommand {
+	case config.SubcommandServe:
+		appExitCode = runServe(cfg, deliverer)
+		return
+
+	case config.SubcommandSessionSummary:
+		appExitCode = runSessionSummary(cfg, deliverer)
+		return
+
+	case config.SubcommandBench:
+		appExitCode = runBench(cfg, deliverer)
+		return
+
+	case config.SubcommandWatchFile:
+		appExitCode = runWatchFile(cfg, deliverer)
+		return
+
+	case config.SubcommandWatchSpool:
+		appExitCode = runWatchSpool(cfg, deliverer)
+		return
+
+	case config.SubcommandBatch:
+		appExitCode = runBatch(cfg, deliverer)
+		return
+	}
+
+	// This should only trigger if user specifies large retry values. The
+	// retry schedule computed from the Nagios notification timeout always
+	// fits.
+	if cfg.NagiosTimeoutAware {
+		if cfg.VerboseOutput {
+			log.Printf(
+				"Retry schedule: %d attempt(s) of up to %v, %ds apart, within %v",
+				cfg.Retries+1,
+				cfg.AttemptTimeout().Round(time.Millisecond),
+				cfg.RetriesDelay,
+				cfg.TeamsSubmissionTimeout(),
+			)
+		}
+	} else if cfg.TeamsSubmissionTimeout() > config.DefaultNagiosNotificationTimeout {
+		if !cfg.SilentOutput {
+			log.Printf(
+				"WARNING: app cancellation timeout value of %v greater than default Nagios command timeout value!",
+				cfg.TeamsSubmissionTimeout(),
+			)
+		}
+	}
+
+	for _, targetCfg := range cfg.TargetConfigs() {
+		targetDeliverer := deliverer
+		if targetCfg != cfg {
+			// Each target uses its own client so that settings applied to
+			// the client (e.g., request signing) do not carry over.
+			targetDeliverer, err = delivery.New(targetCfg, newTeamsClient(targetCfg))
 			if err != nil {
 				if !cfg.SilentOutput {
-					log.Printf("\n\nERROR: Failed to process user mention for %q channel in the %q team: %v\n\n",
-						cfg.Channel, cfg.Team, err)
+					log.Printf("\n\nERROR: Failed to initialize message delivery: %v\n\n", err)
 				}
-				// Regardless of silent flag, explicitly note unsuccessful results
 				appExitCode = 1
-				return
+				continue
 			}
-			userMentions = append(userMentions, userMention)
 		}
 
-		// Add user mention collection to card.
-		if err := card.AddMention(true, userMentions...); err != nil {
-			if !cfg.SilentOutput {
-				log.Printf("\n\nERROR: Failed to add user mentions to message for %q channel in the %q team: %v\n\n",
-					cfg.Channel, cfg.Team, err)
-			}
-			// Regardless of silent flag, explicitly note unsuccessful results
-			appExitCode = 1
-			return
+		if code := sendMessage(targetCfg, targetDeliverer); code != 0 {
+			appExitCode = code
 		}
 	}
 
-	// If provided, use target URLs and their descriptions to add labelled
-	// URL "buttons" to Microsoft Teams message.
-	if len(cfg.TargetURLs) > 0 {
+	// The exit code of a failed command takes precedence over that of the
+	// message submission so that wrapped commands remain transparent.
+	if cfg.PropagateExit || cfg.Subcommand == config.SubcommandRun {
+		if code := cfg.ExecExitCode(); code != 0 {
+			appExitCode = code
+		}
+	}
+}
+
+// newTeamsClient creates the Microsoft Teams client used to submit messages
+// using the given configuration.
+func newTeamsClient(cfg *config.Config) *goteamsnotify.TeamsClient {
+	mstClient := goteamsnotify.NewTeamsClient()
+
+	// Override User Agent.
+	mstClient.SetUserAgent(cfg.UserAgent())
+
+	// Disable webhook URL validation if requested by user. Bench mode
+	// submits messages to the built-in mock webhook server.
+	mstClient.SkipWebhookURLValidationOnSend(
+		cfg.DisableWebhookURLValidation || cfg.Subcommand == config.SubcommandBench,
+	)
+
+	return mstClient
+}
+
+// sendMessage generates and submits the user-specified message using the
+// given configuration, returning the exit code for the application.
+func sendMessage(cfg *config.Config, deliverer *delivery.Deliverer) int {
+	if payload := cfg.Payload(); payload != nil {
+		return sendPayload(cfg, deliverer, payload)
+	}
+
+	ctxSubmissionTimeout, cancel := context.WithTimeout(context.Background(), cfg.TeamsSubmissionTimeout())
+	defer cancel()
+
+	// Assign a unique ID used to correlate this submission with the results
+	// and records produced for it.
+	receiptID := teams.NewReceiptID()
 
-		// Create dedicated container for all action items.
-		actionsContainer := adaptivecard.NewContainer()
-		actionsContainer.Separator = false
-		actionsContainer.Style = adaptivecard.ContainerStyleEmphasis
-		actionsContainer.Spacing = adaptivecard.SpacingExtraLarge
+	cardOpts := cfg.CardOptions(cfg.Sender)
+	if cfg.ReceiptFact {
+		cardOpts.ReceiptID = receiptID
+	}
 
-		actions := make([]adaptivecard.Action, 0, len(cfg.TargetURLs))
+	buildMessage := func(msg teams.Message) (*adaptivecard.Message, error) {
+		return teams.NewAdaptiveCardMessage(msg, cardOpts)
+	}
 
-		for i := range cfg.TargetURLs {
+	teamsMsg := cfg.TeamsMessage()
+	mentionsAllowed := true
 
-			urlAction, err := adaptivecard.NewActionOpenURL(
-				cfg.TargetURLs[i].URL.String(),
-				cfg.TargetURLs[i].Description,
-			)
-			if err != nil {
-				if !cfg.SilentOutput {
-					log.Printf(
-						"\n\nERROR: Failed to process openURL action for %q channel in the %q team: %v\n\n",
-						cfg.Channel,
-						cfg.Team,
-						err,
-					)
-				}
-				// Regardless of silent flag, explicitly note unsuccessful results
-				appExitCode = 1
-				return
-			}
-			actions = append(actions, urlAction)
+	// Input which produced no content is not sent, if requested.
+	if cfg.EmptyMessageSkipped() {
+		if !cfg.SilentOutput {
+			log.Printf("Message input empty; message %s as requested (receipt %s)", delivery.StatusSkipped, receiptID)
 		}
+		emitSkippedResult(cfg, deliverer, receiptID, teamsMsg.Title, delivery.StatusSkipped)
 
-		if err := actionsContainer.AddAction(true, actions...); err != nil {
+		return 0
+	}
+
+	// Messages already sent for the idempotency key (if any) are not sent
+	// again.
+	claim, done, code := claimIdempotencyKey(cfg, deliverer, teamsMsg)
+	if done {
+		return code
+	}
+	if claim != nil {
+		defer claim.abandon()
+	}
+
+	// Apply the quiet hours policy for the selected message class.
+	if class := cfg.MessageClass(); class.QuietHours(time.Now()) {
+		switch class.QuietHoursPolicy {
+		case config.QuietHoursPolicyDrop:
 			if !cfg.SilentOutput {
 				log.Printf(
-					"\n\nERROR: Failed to add openURL action to container for %q channel in the %q team: %v\n\n",
-					cfg.Channel,
-					cfg.Team,
-					err,
+					"WARNING: quiet hours in effect for message class %q; message %s (receipt %s)",
+					class.Name,
+					delivery.StatusDropped,
+					receiptID,
 				)
 			}
-			// Regardless of silent flag, explicitly note unsuccessful results
-			appExitCode = 1
-			return
-		}
+			emitSkippedResult(cfg, deliverer, receiptID, teamsMsg.Title, delivery.StatusDropped)
 
-		if err := card.AddContainer(false, actionsContainer); err != nil {
-			if !cfg.SilentOutput {
-				log.Printf("\n\nERROR: Failed to add actions container to card for %q channel in the %q team: %v\n\n",
-					cfg.Channel, cfg.Team, err)
+			return 0
+
+		default:
+			if cfg.VerboseOutput && len(teamsMsg.UserMentions) > 0 {
+				log.Printf("Quiet hours in effect for message class %q; omitting user mentions", class.Name)
 			}
-			// Regardless of silent flag, explicitly note unsuccessful results
-			appExitCode = 1
-			return
+			teamsMsg.UserMentions = nil
+			mentionsAllowed = false
 		}
 	}
 
-	// If requested, skip appending the branding trailer to messages.
-	if !cfg.DisableBrandingTrailer {
+	if cfg.OnCallSchedule != "" && mentionsAllowed {
+		teamsMsg.UserMentions = append(teamsMsg.UserMentions, onCallMentions(ctxSubmissionTimeout, cfg)...)
+	}
 
-		// Process branding trailer content.
-		//
-		// NOTE: Unlike MessageCard text which has benefited from \r\n
-		// (windows), \r (mac) and \n (unix) conversion to <br> statements in
-		// the past, <br> statements in Adaptive Card text remain as-is in the
-		// final rendered message. This is not useful.
-		trailerText := fmt.Sprintf(
-			"\n\n%s",
-			config.MessageTrailer(cfg.Sender),
-		)
+	if err := verifyLinks(ctxSubmissionTimeout, cfg, teamsMsg); err != nil {
+		if !cfg.SilentOutput {
+			log.Printf(
+				"\n\nERROR: Message for %q channel in the %q team not sent: %v\n\n",
+				cfg.Channel,
+				cfg.Team,
+				err,
+			)
+		}
+		// Regardless of silent flag, explicitly note unsuccessful results
+		return 1
+	}
 
-		trailerContainer := adaptivecard.NewContainer()
-		trailerContainer.Separator = true
-		trailerContainer.Spacing = adaptivecard.SpacingExtraLarge
+	// The response links are specific to this submission and are generated
+	// again when the invocation is replayed.
+	recordInvocation(cfg, teamsMsg, cardOpts)
+	teamsMsg.TargetURLs = append(teamsMsg.TargetURLs, cfg.ResponseLinks(receiptID)...)
 
-		trailerTextBlock := adaptivecard.NewTextBlock(trailerText, true)
-		trailerTextBlock.Size = adaptivecard.SizeSmall
-		trailerTextBlock.Weight = adaptivecard.WeightLighter
+	var message *adaptivecard.Message
+	var err error
+	switch {
+	case cfg.SendBudget().Enabled():
+		var outcome budgetOutcome
+		outcome, err = reserveSend(cfg, receiptID, teamsMsg, buildMessage)
+		deliverSpooled(cfg, deliverer, outcome.spooled)
 
-		if err := trailerContainer.AddElement(false, trailerTextBlock); err != nil {
+		if err == nil && outcome.status != "" {
 			if !cfg.SilentOutput {
-				log.Printf("\n\nERROR: Failed to add text block to trailer container for card for %q channel in the %q team: %v\n\n",
-					cfg.Channel, cfg.Team, err)
-			}
-			// Regardless of silent flag, explicitly note unsuccessful results
-			appExitCode = 1
-			return
-		}
-		if err := card.AddContainer(false, trailerContainer); err != nil {
-			if !cfg.SilentOutput {
-				log.Printf("\n\nERROR: Failed to add trailer container to card for %q channel in the %q team: %v\n\n",
-					cfg.Channel, cfg.Team, err)
+				log.Printf(
+					"WARNING: send budget exceeded for %q channel in the %q team; message %s (receipt %s)",
+					cfg.Channel,
+					cfg.Team,
+					outcome.status,
+					receiptID,
+				)
 			}
-			// Regardless of silent flag, explicitly note unsuccessful results
-			appExitCode = 1
-			return
+			emitSkippedResult(cfg, deliverer, receiptID, teamsMsg.Title, outcome.status)
+
+			return 0
 		}
+		message = outcome.message
+
+	default:
+		message, err = buildMessage(teamsMsg)
 	}
 
-	message, err := adaptivecard.NewMessageFromCard(card)
 	if err != nil {
 		if !cfg.SilentOutput {
 			log.Printf(
-				"\n\nERROR: Failed to create new message from card for %q channel in the %q team: %v\n\n",
+				"\n\nERROR: Failed to generate message for %q channel in the %q team: %v\n\n",
 				cfg.Channel,
 				cfg.Team,
 				err,
 			)
-
-			// Regardless of silent flag, explicitly note unsuccessful results
-			appExitCode = 1
-			return
 		}
+		// Regardless of silent flag, explicitly note unsuccessful results
+		return 1
 	}
 
 	if cfg.VerboseOutput {
@@ -264,21 +355,95 @@ func main() {
 				cfg.Channel, cfg.Team, err)
 
 			// Regardless of silent flag, explicitly note unsuccessful results
-			appExitCode = 1
-			return
+			return 1
 		}
 
 		log.Println(message.PrettyPrint())
 	}
 
+	// Queue the message instead of spending the retry budget on attempts
+	// which cannot succeed while the network is unavailable.
+	if cfg.OfflineOK {
+		if offlineErr := netcheck.Check(ctxSubmissionTimeout, cfg.WebhookURL, offlineCheckTimeout); offlineErr != nil {
+			return queueMessage(cfg, deliverer, receiptID, teamsMsg.Title, message, offlineErr)
+		}
+
+		deliverQueued(cfg, deliverer)
+	}
+
 	// Submit message card using Microsoft Teams client, retry submission if
 	// needed up to specified number of retry attempts.
-	sendErr := mstClient.SendWithRetry(ctxSubmissionTimeout, cfg.WebhookURL, message, cfg.Retries, cfg.RetriesDelay)
+	timing, sendErr := deliverer.DeliverTimed(ctxSubmissionTimeout, receiptID, cfg.WebhookURL, message)
+
+	if cfg.VerboseOutput && len(timing.Attempts) > 0 {
+		log.Print(timing.Histogram())
+	}
+
+	// The network may become unavailable after the initial check.
+	if cfg.OfflineOK && netcheck.IsOffline(sendErr) {
+		return queueMessage(cfg, deliverer, receiptID, teamsMsg.Title, message, sendErr)
+	}
+
+	if !reportResult(cfg, deliverer, receiptID, teamsMsg.Title, timing, sendErr) {
+		// Regardless of silent flag, explicitly note unsuccessful results
+		return 1
+	}
+
+	if err := sendReportPages(cfg, deliverer, teamsMsg.Title, cardOpts); err != nil {
+		if !cfg.SilentOutput {
+			log.Printf("\n\nERROR: Failed to send report to %q channel in the %q team: %v\n\n",
+				cfg.Channel, cfg.Team, err)
+		}
+
+		// Regardless of silent flag, explicitly note unsuccessful results
+		return 1
+	}
+
+	if claim != nil {
+		claim.complete(cfg, receiptID, teamsMsg.Title)
+	}
+
+	updateFollowUps(cfg, deliverer, receiptID, teamsMsg.Title)
+
+	if cfg.VerboseOutput {
+		log.Printf("Configuration used: %#v\n", cfg)
+		log.Printf("Webhook URL: %s\n", cfg.WebhookURL)
+		log.Printf("Message values sent: %#v\n", message)
+	}
+
+	return 0
+}
+
+// reportResult emits the JSON formatted summary (if requested) for a
+// submitted message, records the outcome under the user-specified session ID
+// (if any) and logs the result of the submission. Whether the message should
+// be considered sent is returned.
+func reportResult(cfg *config.Config, deliverer *delivery.Deliverer, receiptID string, title string, timing delivery.Timing, sendErr error) bool {
+	ignoreSendErr := cfg.IgnoreInvalidResponse &&
+		errors.Is(sendErr, goteamsnotify.ErrInvalidWebhookURLResponseText)
+
+	resultErr := sendErr
+	if ignoreSendErr {
+		resultErr = nil
+	}
+	result := deliverer.NewResult(receiptID, resultErr)
+	if len(timing.Attempts) > 0 {
+		result.Timing = &timing
+	}
+
+	// Machine-readable output is emitted regardless of the silent flag.
+	if cfg.JSONOutput && !cfg.TerraformMode {
+		if err := result.Write(resultOutput); err != nil {
+			log.Printf("ERROR: Failed to emit JSON result: %v", err)
+		}
+	}
+
+	recordSession(cfg, title, result)
+	recordHistory(cfg, title, result)
 
 	switch {
 
-	case cfg.IgnoreInvalidResponse &&
-		errors.Is(sendErr, goteamsnotify.ErrInvalidWebhookURLResponseText):
+	case ignoreSendErr:
 
 		if !cfg.SilentOutput {
 			log.Printf(
@@ -290,8 +455,8 @@ func main() {
 	case sendErr != nil:
 		// Display error output if silence is not requested
 		if !cfg.SilentOutput {
-			log.Printf("\n\nERROR: Failed to submit message to %q channel in the %q team: %v\n\n",
-				cfg.Channel, cfg.Team, sendErr)
+			log.Printf("\n\nERROR: Failed to submit message to %q channel in the %q team (receipt %s): %v\n\n",
+				cfg.Channel, cfg.Team, receiptID, sendErr)
 
 			if cfg.VerboseOutput {
 				log.Printf("[Config]: %+v\n[Error]: %v", cfg, sendErr)
@@ -299,22 +464,45 @@ func main() {
 
 		}
 
-		// Regardless of silent flag, explicitly note unsuccessful results
-		appExitCode = 1
-		return
+		return false
 
 	default:
 		if !cfg.SilentOutput {
 			// Emit basic success message
-			log.Println("Message successfully sent!")
+			log.Printf("Message successfully sent! (receipt %s)", receiptID)
 		}
 
 	}
 
-	if cfg.VerboseOutput {
-		log.Printf("Configuration used: %#v\n", cfg)
-		log.Printf("Webhook URL: %s\n", cfg.WebhookURL)
-		log.Printf("Message values sent: %#v\n", message)
+	return true
+}
+
+// emitSkippedResult emits the JSON formatted summary (if requested) for a
+// message which was intentionally not sent and records the outcome under the
+// user-specified session ID (if any).
+func emitSkippedResult(cfg *config.Config, deliverer *delivery.Deliverer, receiptID string, title string, status string) {
+	result := deliverer.NewResult(receiptID, nil)
+	result.Status = status
+
+	recordSession(cfg, title, result)
+	recordHistory(cfg, title, result)
+
+	if cfg.TerraformMode {
+		writeTerraformOutput(idempotency.Record{
+			ReceiptID: receiptID,
+			Time:      result.Time,
+			Title:     title,
+			Team:      result.Team,
+			Channel:   result.Channel,
+		}, status)
+		return
 	}
 
+	if !cfg.JSONOutput {
+		return
+	}
+
+	if err := result.Write(resultOutput); err != nil {
+		log.Printf("ERROR: Failed to emit JSON result: %v", err)
+	}
 }
@@ -8,11 +8,23 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
+	"flag"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
+	goteamsnotify "github.com/atc0005/go-teams-notify/v2"
+	"github.com/atc0005/send2teams/internal/budget"
 	"github.com/atc0005/send2teams/internal/config"
+	"github.com/atc0005/send2teams/internal/delivery"
 )
 
 // Setup basic tests to ensure that config initialization works as expected.
@@ -44,3 +56,99 @@ func TestConfigInitialization(t *testing.T) {
 	}
 
 }
+
+// Assert that diagnostics and machine-readable results are written to
+// separate destinations so that results can be safely piped to other tools.
+func TestOutputSeparation(t *testing.T) {
+	var results, diagnostics bytes.Buffer
+
+	oldResults, oldDiagnostics := resultOutput, diagnosticOutput
+	defer func() {
+		resultOutput, diagnosticOutput = oldResults, oldDiagnostics
+		log.SetOutput(os.Stderr)
+		flag.CommandLine.SetOutput(nil)
+	}()
+
+	resultOutput, diagnosticOutput = &results, &diagnostics
+	configureOutput()
+
+	cfg := &config.Config{JSONOutput: true}
+	deliverer, err := delivery.New(cfg, goteamsnotify.NewTeamsClient())
+	if err != nil {
+		t.Fatalf("failed to create deliverer: %v", err)
+	}
+
+	log.Printf("WARNING: example diagnostic")
+	config.Branding()
+	emitSkippedResult(cfg, deliverer, "example", "Example", delivery.StatusDropped)
+
+	if !strings.Contains(diagnostics.String(), "example diagnostic") {
+		t.Errorf("diagnostic output missing log message: %q", diagnostics.String())
+	}
+
+	var result map[string]interface{}
+	dec := json.NewDecoder(&results)
+	if err := dec.Decode(&result); err != nil {
+		t.Fatalf("result output is not valid JSON: %v", err)
+	}
+	if err := dec.Decode(&result); !errors.Is(err, io.EOF) {
+		t.Errorf("unexpected content in result output after JSON result: %v", err)
+	}
+}
+
+// Assert that spooled messages which could not be delivered remain spooled
+// and no longer count against the send budget.
+func TestDeliverSpooledFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		WebhookURL:      server.URL,
+		BudgetDir:       t.TempDir(),
+		MaxSendsPerHour: 1,
+		Retries:         1,
+		RetriesDelay:    1,
+		SilentOutput:    true,
+	}
+
+	client := goteamsnotify.NewTeamsClient()
+	client.SkipWebhookURLValidationOnSend(true)
+	deliverer, err := delivery.New(cfg, client)
+	if err != nil {
+		t.Fatalf("failed to create deliverer: %v", err)
+	}
+
+	now := time.Now()
+	ledger, err := budget.Open(cfg.BudgetDir, cfg.WebhookURL, cfg.SendBudget())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ledger.Spool(now, "receipt", []byte(`{"type":"message"}`)); err != nil {
+		t.Fatal(err)
+	}
+	spooled, err := ledger.TakeSpooled(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ledger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	deliverSpooled(cfg, deliverer, spooled)
+
+	ledger, err = budget.Open(cfg.BudgetDir, cfg.WebhookURL, cfg.SendBudget())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ledger.Close() }()
+
+	spooled, err = ledger.TakeSpooled(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spooled) != 1 || spooled[0].ReceiptID != "receipt" {
+		t.Errorf("got spooled messages %+v; want undelivered message still spooled", spooled)
+	}
+}
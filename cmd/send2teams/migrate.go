@@ -0,0 +1,189 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/atc0005/send2teams/internal/config"
+	"github.com/atc0005/send2teams/internal/input"
+	"github.com/atc0005/send2teams/internal/webhook"
+)
+
+// Limits applied to the provision command run by the migrate-url
+// subcommand.
+const (
+	provisionTimeout  time.Duration = 5 * time.Minute
+	provisionMaxBytes int           = 64 * 1024
+)
+
+// kindDescriptions describe each kind of webhook URL reported by
+// webhook.Classify.
+var kindDescriptions = map[string]string{
+	webhook.KindConnector:       "an Office 365 connector (incoming webhook)",
+	webhook.KindLegacyConnector: "an Office 365 connector (incoming webhook) using the original outlook.office.com format",
+	webhook.KindWorkflow:        "a Power Automate or Logic Apps workflow",
+	webhook.KindUnknown:         "not recognized as a Microsoft Teams webhook URL",
+}
+
+// runMigrateURL explains how the retirement of Office 365 connectors affects
+// the user-specified webhook URL and, if requested, runs the provision
+// command to create the replacement workflow, updating the configuration
+// file with the new webhook URL. The exit code for the application is
+// returned.
+func runMigrateURL(cfg *config.Config) int {
+	kind := webhook.Classify(cfg.WebhookURL)
+
+	if !cfg.SilentOutput {
+		writeMigrationReport(cfg, kind)
+	}
+
+	if !webhook.IsConnector(kind) {
+		if cfg.ProvisionCommand != "" && !cfg.SilentOutput {
+			log.Printf("WARNING: The webhook URL is not a connector URL; not running the provision command")
+		}
+		return 0
+	}
+
+	if cfg.ProvisionCommand == "" {
+		if !cfg.SilentOutput {
+			log.Printf(
+				"Create a workflow using the \"Post to a channel when a webhook request is received\" template " +
+					"(or specify the provision-command flag to run a helper which does so) and use its URL in place of the connector URL",
+			)
+		}
+		return 0
+	}
+
+	newURL, err := provisionWorkflow(cfg, kind)
+	if err != nil {
+		if !cfg.SilentOutput {
+			log.Printf("\n\nERROR: Failed to provision the replacement workflow: %v\n\n", err)
+		}
+		return 1
+	}
+
+	if !cfg.WriteConfig {
+		// The new webhook URL is emitted regardless of the silent flag so
+		// that it may be captured by the caller.
+		fmt.Fprintln(resultOutput, newURL)
+		return 0
+	}
+
+	section, backup, err := cfg.UpdateConfigFileURL(newURL)
+	if err != nil {
+		if !cfg.SilentOutput {
+			log.Printf("\n\nERROR: Failed to update configuration file %s: %v\n\n", cfg.ConfigFile, err)
+		}
+		return 1
+	}
+
+	if !cfg.SilentOutput {
+		log.Printf(
+			"Updated the webhook URL in the [%s] section of %s (original saved as %s)",
+			section,
+			cfg.ConfigFile,
+			backup,
+		)
+	}
+
+	return 0
+}
+
+// writeMigrationReport describes the kind of the webhook URL and the
+// announced connector retirement timeline. The URL itself is omitted since
+// it is a credential.
+func writeMigrationReport(cfg *config.Config, kind string) {
+	label := "webhook URL"
+	if cfg.Profile != "" {
+		label = fmt.Sprintf("webhook URL of profile %q", cfg.Profile)
+	}
+
+	fmt.Fprintf(diagnosticOutput, "The %s is %s.\n", label, kindDescriptions[kind])
+
+	if !webhook.IsConnector(kind) {
+		if kind == webhook.KindWorkflow {
+			fmt.Fprintln(diagnosticOutput, "It is not affected by the retirement of Office 365 connectors; no migration is needed.")
+		}
+		fmt.Fprintln(diagnosticOutput)
+		return
+	}
+
+	fmt.Fprintln(diagnosticOutput, "\nAnnounced retirement timeline for Office 365 connectors:")
+	for _, m := range webhook.RetirementTimeline {
+		marker := " "
+		if m.Affects(kind) {
+			marker = "*"
+		}
+		fmt.Fprintf(diagnosticOutput, "  %s %-20s %s\n", marker, m.Date, m.Description)
+	}
+	fmt.Fprintf(
+		diagnosticOutput,
+		"\nSteps marked * affect this webhook URL. Microsoft has revised these dates; see %s for the current timeline.\n\n",
+		webhook.RetirementAnnouncementURL,
+	)
+}
+
+// provisionWorkflow runs the provision command to create the workflow
+// replacing the connector webhook URL, returning the new webhook URL.
+func provisionWorkflow(cfg *config.Config, kind string) (string, error) {
+	env := []string{
+		"SEND2TEAMS_MIGRATE_URL=" + cfg.WebhookURL,
+		"SEND2TEAMS_MIGRATE_KIND=" + kind,
+		"SEND2TEAMS_MIGRATE_TEAM=" + cfg.Team,
+		"SEND2TEAMS_MIGRATE_CHANNEL=" + cfg.Channel,
+		"SEND2TEAMS_MIGRATE_PROFILE=" + cfg.Profile,
+	}
+
+	if cfg.VerboseOutput {
+		log.Printf("Running provision command %q", cfg.ProvisionCommand)
+	}
+
+	result, err := input.ExecEnv(context.Background(), cfg.ProvisionCommand, env, provisionTimeout, provisionMaxBytes)
+	if err != nil {
+		if stderr := strings.TrimSpace(result.Stderr); stderr != "" {
+			return "", fmt.Errorf("%w: %s", err, stderr)
+		}
+		return "", err
+	}
+
+	var newURL string
+	for _, line := range strings.Split(result.Stdout, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			newURL = line
+			break
+		}
+	}
+
+	u, err := url.Parse(newURL)
+	switch {
+	case newURL == "":
+		return "", fmt.Errorf("provision command %q did not output a webhook URL", cfg.ProvisionCommand)
+	case err != nil || u.Scheme != "https" || u.Host == "":
+		return "", fmt.Errorf("provision command %q output is not an https URL", cfg.ProvisionCommand)
+	}
+
+	if !cfg.SilentOutput {
+		switch newKind := webhook.Classify(newURL); newKind {
+		case webhook.KindWorkflow:
+			log.Printf(
+				"WARNING: Workflow URLs are not matched by the default webhook URL validation; " +
+					"specify the disable-url-validation flag (or setting) when sending messages to the new webhook URL",
+			)
+		default:
+			log.Printf("WARNING: The webhook URL returned by the provision command is %s", kindDescriptions[newKind])
+		}
+	}
+
+	return newURL, nil
+}
@@ -0,0 +1,49 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+//go:build minimal
+
+package main
+
+import (
+	"log"
+
+	"github.com/atc0005/send2teams/internal/config"
+	"github.com/atc0005/send2teams/internal/delivery"
+)
+
+// The subcommands below are omitted from the minimal build. Configuration
+// validation rejects them before these placeholders are reached.
+
+func runServe(cfg *config.Config, _ *delivery.Deliverer) int {
+	return unavailable(cfg, config.SubcommandServe)
+}
+
+func runTop(cfg *config.Config) int {
+	return unavailable(cfg, config.SubcommandTop)
+}
+
+func runBench(cfg *config.Config, _ *delivery.Deliverer) int {
+	return unavailable(cfg, config.SubcommandBench)
+}
+
+func runWatchFile(cfg *config.Config, _ *delivery.Deliverer) int {
+	return unavailable(cfg, config.SubcommandWatchFile)
+}
+
+func runWatchSpool(cfg *config.Config, _ *delivery.Deliverer) int {
+	return unavailable(cfg, config.SubcommandWatchSpool)
+}
+
+// unavailable reports that the given subcommand is not included in this
+// build, returning the exit code for the application.
+func unavailable(cfg *config.Config, subcommand string) int {
+	if !cfg.SilentOutput {
+		log.Printf("\n\nERROR: the %s subcommand is not available in the %s build\n\n", subcommand, config.BuildVariant)
+	}
+	return 1
+}
@@ -0,0 +1,141 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/atc0005/go-teams-notify/v2/adaptivecard"
+	"github.com/atc0005/send2teams/internal/budget"
+	"github.com/atc0005/send2teams/internal/config"
+	"github.com/atc0005/send2teams/internal/delivery"
+)
+
+// offlineCheckTimeout is the maximum time spent determining whether the
+// network is available before submitting a message.
+const offlineCheckTimeout = 3 * time.Second
+
+// queueOffline retains the given message for delivery by a later invocation
+// once the network is available.
+func queueOffline(cfg *config.Config, receiptID string, message *adaptivecard.Message) (err error) {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode message for queuing: %w", err)
+	}
+
+	// The send budget ledger without limits is used as a plain queue.
+	queue, err := budget.Open(cfg.OfflineDir, cfg.WebhookURL, budget.Limits{})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if closeErr := queue.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	return queue.Spool(time.Now(), receiptID, payload)
+}
+
+// deliverQueued submits messages queued while the network was unavailable,
+// oldest first. If a submission fails the message and those queued after it
+// are requeued for a later invocation. Failures are logged, but do not affect
+// the exit code.
+func deliverQueued(cfg *config.Config, deliverer *delivery.Deliverer) {
+	queue, err := budget.Open(cfg.OfflineDir, cfg.WebhookURL, budget.Limits{})
+	if err != nil {
+		if !cfg.SilentOutput {
+			log.Printf("WARNING: Failed to open offline queue: %v", err)
+		}
+		return
+	}
+
+	queued, err := queue.TakeSpooled(time.Now())
+	if closeErr := queue.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil && !cfg.SilentOutput {
+		log.Printf("WARNING: Failed to read offline queue: %v", err)
+	}
+
+	for i, item := range queued {
+		var message adaptivecard.Message
+		if err := json.Unmarshal(item.Payload, &message); err != nil {
+			if !cfg.SilentOutput {
+				log.Printf("WARNING: Failed to decode queued message (receipt %s): %v", item.ReceiptID, err)
+			}
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.TeamsSubmissionTimeout())
+		err := deliverer.Deliver(ctx, item.ReceiptID, cfg.WebhookURL, &message)
+		cancel()
+
+		if err != nil {
+			if !cfg.SilentOutput {
+				log.Printf("WARNING: Failed to submit queued message (receipt %s): %v", item.ReceiptID, err)
+			}
+			requeue(cfg, queued[i:])
+
+			return
+		}
+
+		if !cfg.SilentOutput {
+			log.Printf("Queued message successfully sent! (receipt %s)", item.ReceiptID)
+		}
+	}
+}
+
+// requeue returns the given previously queued messages to the offline queue,
+// retaining their order.
+func requeue(cfg *config.Config, items []budget.Spooled) {
+	queue, err := budget.Open(cfg.OfflineDir, cfg.WebhookURL, budget.Limits{})
+	if err != nil {
+		if !cfg.SilentOutput {
+			log.Printf("WARNING: Failed to requeue %d message(s): %v", len(items), err)
+		}
+		return
+	}
+
+	now := time.Now()
+	for i, item := range items {
+		// Preserve ordering by spacing out the queue times.
+		if err := queue.Spool(now.Add(time.Duration(i)), item.ReceiptID, item.Payload); err != nil && !cfg.SilentOutput {
+			log.Printf("WARNING: Failed to requeue message (receipt %s): %v", item.ReceiptID, err)
+		}
+	}
+
+	if err := queue.Close(); err != nil && !cfg.SilentOutput {
+		log.Printf("WARNING: Failed to requeue messages: %v", err)
+	}
+}
+
+// queueMessage queues the given message because the network is unavailable
+// (as indicated by the given error), returning the exit code for the
+// application.
+func queueMessage(cfg *config.Config, deliverer *delivery.Deliverer, receiptID string, title string, message *adaptivecard.Message, offlineErr error) int {
+	if err := queueOffline(cfg, receiptID, message); err != nil {
+		if !cfg.SilentOutput {
+			log.Printf("\n\nERROR: Failed to queue message for %q channel in the %q team (receipt %s): %v\n\n",
+				cfg.Channel, cfg.Team, receiptID, err)
+		}
+		return 1
+	}
+
+	if !cfg.SilentOutput {
+		log.Printf("WARNING: %v; message %s (receipt %s)", offlineErr, delivery.StatusQueued, receiptID)
+	}
+	emitSkippedResult(cfg, deliverer, receiptID, title, delivery.StatusQueued)
+
+	return 0
+}
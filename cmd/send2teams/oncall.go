@@ -0,0 +1,61 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/atc0005/send2teams/internal/config"
+	"github.com/atc0005/send2teams/internal/oncall"
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// onCallLookupTimeout is the maximum time spent resolving the users
+// currently on call.
+const onCallLookupTimeout = 10 * time.Second
+
+// onCallMentions resolves the users currently on call for the user-specified
+// schedule and returns a user mention for each. Failures are logged, but do
+// not prevent the message from being sent.
+func onCallMentions(ctx context.Context, cfg *config.Config) []teams.UserMention {
+	provider, err := oncall.New(cfg.OnCallProvider, cfg.OnCallToken, &http.Client{Timeout: onCallLookupTimeout})
+	if err != nil {
+		if !cfg.SilentOutput {
+			log.Printf("WARNING: Failed to resolve on-call users: %v", err)
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, onCallLookupTimeout)
+	defer cancel()
+
+	users, err := provider.OnCall(ctx, cfg.OnCallSchedule)
+	if err != nil {
+		if !cfg.SilentOutput {
+			log.Printf("WARNING: Failed to resolve on-call users; sending message without on-call mention: %v", err)
+		}
+		return nil
+	}
+
+	mentions := make([]teams.UserMention, 0, len(users))
+	names := make([]string, 0, len(users))
+	for _, user := range users {
+		mentions = append(mentions, teams.UserMention{Name: user.Name, ID: user.Email})
+		names = append(names, user.Name)
+	}
+
+	if cfg.VerboseOutput {
+		log.Printf("Mentioning on-call user(s) for schedule %s: %s", cfg.OnCallSchedule, strings.Join(names, ", "))
+	}
+
+	return mentions
+}
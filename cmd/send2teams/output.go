@@ -0,0 +1,33 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"os"
+)
+
+// Output destinations used by this application. Only machine-readable output
+// (e.g., JSON results, status snapshots) is written to resultOutput. All
+// human-readable diagnostics (e.g., success, warning and error messages,
+// retry details, usage and version details) are written to diagnosticOutput
+// so that results can be piped to other tools regardless of whether
+// submission succeeds.
+var (
+	resultOutput     io.Writer = os.Stdout
+	diagnosticOutput io.Writer = os.Stderr
+)
+
+// configureOutput routes all diagnostic output produced via the standard
+// library log and flag packages to the diagnostic output destination.
+func configureOutput() {
+	log.SetOutput(diagnosticOutput)
+	flag.CommandLine.SetOutput(diagnosticOutput)
+}
@@ -0,0 +1,44 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/atc0005/send2teams/internal/config"
+	"github.com/atc0005/send2teams/internal/delivery"
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// sendPayload submits the pre-built payload read from the user-specified
+// payload file as-is, returning the exit code for the application.
+func sendPayload(cfg *config.Config, deliverer *delivery.Deliverer, payload *teams.RawMessage) int {
+	ctxSubmissionTimeout, cancel := context.WithTimeout(context.Background(), cfg.TeamsSubmissionTimeout())
+	defer cancel()
+
+	receiptID := teams.NewReceiptID()
+
+	if cfg.VerboseOutput {
+		log.Printf("Submitting pre-built %s payload from %s", payload.Format(), cfg.PayloadFile)
+		log.Println(payload.PrettyPrint())
+	}
+
+	timing, sendErr := deliverer.DeliverTimed(ctxSubmissionTimeout, receiptID, cfg.WebhookURL, payload)
+
+	if cfg.VerboseOutput && len(timing.Attempts) > 0 {
+		log.Print(timing.Histogram())
+	}
+
+	if !reportResult(cfg, deliverer, receiptID, "", timing, sendErr) {
+		// Regardless of silent flag, explicitly note unsuccessful results
+		return 1
+	}
+
+	return 0
+}
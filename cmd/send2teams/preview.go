@@ -0,0 +1,70 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"golang.org/x/term"
+
+	"github.com/atc0005/send2teams/internal/config"
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// previewTerminal displays an approximate preview of the card (and of any
+// CSV report cards) generated for the user-specified message instead of
+// sending it, returning the exit code for the application. The preview is
+// colored and fitted to the width of the terminal if stdout is a terminal
+// and the NO_COLOR environment variable is not set.
+func previewTerminal(cfg *config.Config) int {
+	if cfg.EmptyMessageSkipped() {
+		if !cfg.SilentOutput {
+			log.Printf("Message input empty; no card would be sent")
+		}
+		return 0
+	}
+
+	previewOpts := teams.PreviewOptions{}
+	if fd := int(os.Stdout.Fd()); term.IsTerminal(fd) {
+		previewOpts.Color = os.Getenv("NO_COLOR") == ""
+		if width, _, err := term.GetSize(fd); err == nil && width < teams.DefaultPreviewWidth {
+			previewOpts.Width = width
+		}
+	}
+
+	// The receipt ID is generated as for a submission so that the receipt
+	// fact and response buttons are shown as they would be sent.
+	receiptID := teams.NewReceiptID()
+	cardOpts := cfg.CardOptions(cfg.Sender)
+	if cfg.ReceiptFact {
+		cardOpts.ReceiptID = receiptID
+	}
+
+	msg := cfg.TeamsMessage()
+	msg.TargetURLs = append(msg.TargetURLs, cfg.ResponseLinks(receiptID)...)
+
+	messages := append([]teams.Message{msg}, cfg.ReportPages(msg.Title)...)
+	for i, page := range messages {
+		preview, err := page.Preview(cardOpts, previewOpts)
+		if err != nil {
+			if !cfg.SilentOutput {
+				log.Printf("\n\nERROR: Failed to preview card %d of %d: %v\n\n", i+1, len(messages), err)
+			}
+			return 1
+		}
+
+		if len(messages) > 1 {
+			fmt.Fprintf(resultOutput, "Card %d of %d\n", i+1, len(messages))
+		}
+		fmt.Fprint(resultOutput, preview)
+	}
+
+	return 0
+}
@@ -0,0 +1,48 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/atc0005/send2teams/internal/config"
+	"github.com/atc0005/send2teams/internal/replay"
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// recordInvocation records the effective configuration and the given
+// message content and card options to the user-specified file so that the
+// invocation may be re-executed via the replay subcommand. Failures are
+// logged, but do not affect the exit code.
+func recordInvocation(cfg *config.Config, msg teams.Message, opts teams.CardOptions) {
+	if cfg.Record == "" {
+		return
+	}
+
+	inv := replay.Invocation{
+		AppVersion:      cfg.App.Version,
+		Recorded:        time.Now().UTC(),
+		Flags:           cfg.FlagValues(),
+		ResponseChoices: cfg.ResponseChoices,
+		WebhookURL:      cfg.WebhookURL,
+		Message:         msg,
+		Card:            replay.NewCard(msg, opts),
+	}
+
+	if err := replay.Write(cfg.Record, inv); err != nil {
+		if !cfg.SilentOutput {
+			log.Printf("WARNING: %v", err)
+		}
+		return
+	}
+
+	if cfg.VerboseOutput {
+		log.Printf("Invocation recorded to %s", cfg.Record)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/atc0005/send2teams/internal/config"
+	"github.com/atc0005/send2teams/internal/delivery"
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// sendReportPages sends the cards listing the rows of the CSV report (if
+// any) after the summary message with the given title was sent. Each card
+// is assigned its own receipt ID and is subject to the usual retry
+// behavior. Sending stops at the first card which could not be sent.
+func sendReportPages(cfg *config.Config, deliverer *delivery.Deliverer, title string, cardOpts teams.CardOptions) error {
+	pages := cfg.ReportPages(title)
+
+	for i, page := range pages {
+		receiptID := teams.NewReceiptID()
+		if cfg.ReceiptFact {
+			cardOpts.ReceiptID = receiptID
+		}
+
+		message, err := teams.NewAdaptiveCardMessage(page, cardOpts)
+		if err != nil {
+			return fmt.Errorf("failed to generate report card %d of %d: %w", i+1, len(pages), err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.TeamsSubmissionTimeout())
+		_, err = deliverer.DeliverTimed(ctx, receiptID, cfg.WebhookURL, message)
+		cancel()
+
+		if err != nil {
+			return fmt.Errorf("failed to submit report card %d of %d (receipt %s): %w", i+1, len(pages), receiptID, err)
+		}
+
+		if cfg.VerboseOutput {
+			log.Printf("Report card %d of %d sent (receipt %s)", i+1, len(pages), receiptID)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+//go:build !minimal
+
+package main
+
+import (
+	"context"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/atc0005/send2teams/internal/config"
+	"github.com/atc0005/send2teams/internal/delivery"
+	"github.com/atc0005/send2teams/internal/serve"
+)
+
+// runServe runs this application as a long-lived relay until interrupted,
+// returning the exit code for the application.
+func runServe(cfg *config.Config, deliverer *delivery.Deliverer) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var listener net.Listener
+	var fifo *serve.FIFO
+	var httpListener net.Listener
+
+	closeSources := func() {
+		if listener != nil {
+			_ = listener.Close()
+		}
+		if fifo != nil {
+			_ = fifo.Close()
+		}
+		if httpListener != nil {
+			_ = httpListener.Close()
+		}
+	}
+
+	if cfg.ListenUnix != "" {
+		var err error
+		listener, err = serve.ListenUnix(cfg.ListenUnix, cfg.UnixSocketMode())
+		if err != nil {
+			if !cfg.SilentOutput {
+				log.Printf("ERROR: Failed to start %s mode: %v", config.SubcommandServe, err)
+			}
+			return 1
+		}
+
+		if !cfg.SilentOutput {
+			log.Printf("Accepting messages on unix domain socket %s", cfg.ListenUnix)
+		}
+	}
+
+	if cfg.ListenFIFO != "" {
+		var err error
+		fifo, err = serve.OpenFIFO(cfg.ListenFIFO, cfg.UnixSocketMode())
+		if err != nil {
+			closeSources()
+			if !cfg.SilentOutput {
+				log.Printf("ERROR: Failed to start %s mode: %v", config.SubcommandServe, err)
+			}
+			return 1
+		}
+
+		if !cfg.SilentOutput {
+			log.Printf("Reading messages from named pipe %s", cfg.ListenFIFO)
+		}
+	}
+
+	if cfg.ListenHTTP != "" {
+		var err error
+		httpListener, err = serve.ListenHTTP(cfg.ListenHTTP)
+		if err != nil {
+			closeSources()
+			if !cfg.SilentOutput {
+				log.Printf("ERROR: Failed to start %s mode: %v", config.SubcommandServe, err)
+			}
+			return 1
+		}
+
+		if !cfg.SilentOutput {
+			log.Printf("Accepting messages via HTTP API %s on %s", serve.APIVersion, httpListener.Addr())
+		}
+	}
+
+	server, err := serve.New(cfg, deliverer)
+	if err != nil {
+		closeSources()
+		if !cfg.SilentOutput {
+			log.Printf("ERROR: Failed to start %s mode: %v", config.SubcommandServe, err)
+		}
+		return 1
+	}
+
+	if err := server.Serve(ctx, listener, fifo, httpListener); err != nil {
+		if !cfg.SilentOutput {
+			log.Printf("ERROR: %s mode stopped unexpectedly: %v", config.SubcommandServe, err)
+		}
+		return 1
+	}
+
+	if !cfg.SilentOutput {
+		log.Println("Shutdown complete")
+	}
+
+	return 0
+}
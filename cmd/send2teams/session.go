@@ -0,0 +1,146 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	goteamsnotify "github.com/atc0005/go-teams-notify/v2"
+	"github.com/atc0005/go-teams-notify/v2/adaptivecard"
+	"github.com/atc0005/send2teams/internal/config"
+	"github.com/atc0005/send2teams/internal/delivery"
+	"github.com/atc0005/send2teams/internal/session"
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// recordSession records the given submission result under the
+// user-specified session ID, if any. Failures are logged, but do not affect
+// the outcome of the submission.
+func recordSession(cfg *config.Config, title string, result delivery.Result) {
+	if cfg.SessionID == "" {
+		return
+	}
+
+	rec := session.Record{
+		Time:      result.Time,
+		ReceiptID: result.ReceiptID,
+		Title:     title,
+		Status:    result.Status,
+		Error:     result.Error,
+	}
+
+	if err := session.Append(cfg.SessionDir, cfg.SessionID, rec); err != nil {
+		if !cfg.SilentOutput {
+			log.Printf("WARNING: %v", err)
+		}
+		return
+	}
+
+	if cfg.VerboseOutput {
+		log.Printf("Recorded %s message under session %s", result.Status, cfg.SessionID)
+	}
+}
+
+// runSessionSummary posts a single message summarizing all sends recorded
+// for the user-specified session ID, returning the exit code for the
+// application. The session records are claimed before the summary is
+// generated, so that sends recorded meanwhile are kept for a later summary,
+// and removed once the summary has been sent.
+func runSessionSummary(cfg *config.Config, deliverer *delivery.Deliverer) int {
+	claimed, err := session.Claim(cfg.SessionDir, cfg.SessionID)
+	if err != nil {
+		if !cfg.SilentOutput {
+			log.Printf("\n\nERROR: Failed to load session %s: %v\n\n", cfg.SessionID, err)
+		}
+		return 1
+	}
+
+	// Unless the summary is sent, the records are returned to the session
+	// so that the summary may be retried.
+	sent := false
+	defer func() {
+		if sent {
+			return
+		}
+		if err := claimed.Restore(); err != nil && !cfg.SilentOutput {
+			log.Printf("WARNING: %v", err)
+		}
+	}()
+
+	summary := session.Summarize(cfg.SessionID, claimed.Records)
+
+	msg := cfg.TeamsMessage()
+	if msg.Title == "" {
+		msg.Title = fmt.Sprintf("Session summary: %s", cfg.SessionID)
+	}
+
+	// Any user-specified message text introduces the summary.
+	msg.Text = summary.Text()
+	if cfg.MessageText != "" {
+		msg.Text = cfg.MessageText + "\n\n" + msg.Text
+	}
+
+	receiptID := teams.NewReceiptID()
+
+	opts := cfg.CardOptions(cfg.Sender)
+	if cfg.ReceiptFact {
+		opts.ReceiptID = receiptID
+	}
+
+	if opts.TitleColor == "" {
+		opts.TitleColor = adaptivecard.ColorGood
+		if len(summary.Failures) > 0 {
+			opts.TitleColor = adaptivecard.ColorAttention
+		}
+	}
+
+	message, err := teams.NewAdaptiveCardMessage(msg, opts)
+	if err != nil {
+		if !cfg.SilentOutput {
+			log.Printf("\n\nERROR: Failed to generate summary for session %s: %v\n\n", cfg.SessionID, err)
+		}
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.TeamsSubmissionTimeout())
+	defer cancel()
+
+	sendErr := deliverer.Deliver(ctx, receiptID, cfg.WebhookURL, message)
+	if cfg.IgnoreInvalidResponse && errors.Is(sendErr, goteamsnotify.ErrInvalidWebhookURLResponseText) {
+		sendErr = nil
+	}
+
+	if cfg.JSONOutput {
+		if err := deliverer.NewResult(receiptID, sendErr).Write(resultOutput); err != nil {
+			log.Printf("ERROR: Failed to emit JSON result: %v", err)
+		}
+	}
+
+	if sendErr != nil {
+		if !cfg.SilentOutput {
+			log.Printf("\n\nERROR: Failed to submit summary for session %s (receipt %s): %v\n\n",
+				cfg.SessionID, receiptID, sendErr)
+		}
+		return 1
+	}
+
+	sent = true
+	if err := claimed.Remove(); err != nil && !cfg.SilentOutput {
+		log.Printf("WARNING: %v", err)
+	}
+
+	if !cfg.SilentOutput {
+		log.Printf("Summary of %d send(s) for session %s successfully sent! (receipt %s)",
+			summary.Total, cfg.SessionID, receiptID)
+	}
+
+	return 0
+}
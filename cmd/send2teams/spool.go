@@ -0,0 +1,143 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+//go:build !minimal
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/atc0005/send2teams/internal/config"
+	"github.com/atc0005/send2teams/internal/delivery"
+	"github.com/atc0005/send2teams/internal/spool"
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// errSpoolDeliveryFailed indicates that the message for a spooled file could
+// not be sent, leaving the remaining files for the next check.
+var errSpoolDeliveryFailed = errors.New("spooled message not sent")
+
+// spoolResult is the machine-readable summary of the message for a spooled
+// file.
+type spoolResult struct {
+
+	// File is the name of the spooled file.
+	File string `json:"file"`
+
+	delivery.Result
+}
+
+// runWatchSpool watches the user-specified spool directory, sending a
+// message for each file dropped into it until interrupted. The exit code for
+// the application is returned.
+func runWatchSpool(cfg *config.Config, deliverer *delivery.Deliverer) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	opts := spool.Options{
+		Interval: cfg.PollInterval,
+		Settle:   cfg.Debounce,
+	}
+
+	if !cfg.SilentOutput {
+		log.Printf("Watching spool directory %s (checking every %v)", cfg.SpoolDir, cfg.PollInterval)
+	}
+
+	err := spool.Watch(ctx, cfg.SpoolDir, opts, func(file spool.File) error {
+		return sendSpooledFile(cfg, deliverer, file)
+	})
+	if err != nil {
+		if !cfg.SilentOutput {
+			log.Printf("\n\nERROR: Failed to watch spool directory %s: %v\n\n", cfg.SpoolDir, err)
+		}
+		return 1
+	}
+
+	return 0
+}
+
+// sendSpooledFile sends the message for the given spooled file, archiving
+// (or removing) the file once sent. A file which does not describe a valid
+// message is set aside. A file whose message could not be sent is left in
+// place and errSpoolDeliveryFailed returned so that it is retried by the
+// next check.
+func sendSpooledFile(cfg *config.Config, deliverer *delivery.Deliverer, file spool.File) error {
+	source := "spooled file " + file.Name
+
+	entry, err := spool.ReadEntry(file)
+	if err == nil {
+		// Messages which cannot be generated are not retried.
+		_, err = teams.NewAdaptiveCardMessage(batchMessage(cfg, entry), cfg.CardOptions(cfg.Sender))
+	}
+
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		// The file was removed since the spool directory was checked.
+		return nil
+
+	case err != nil:
+		if !cfg.SilentOutput {
+			log.Printf("\n\nERROR: Skipping %s: %v\n\n", source, err)
+		}
+		emitSpoolResult(cfg, file.Name, deliverer.NewResult(teams.NewReceiptID(), err))
+		setAsideSpooledFile(cfg, file)
+
+		return nil
+	}
+
+	result, sent := sendEntry(cfg, deliverer, source, entry)
+	emitSpoolResult(cfg, file.Name, result)
+
+	if !sent {
+		return errSpoolDeliveryFailed
+	}
+
+	if err := spool.Archive(file, cfg.SpoolArchiveDir, result.ReceiptID); err != nil {
+		if !cfg.SilentOutput {
+			log.Printf("\n\nERROR: Failed to archive %s (receipt %s): %v\n\n", source, result.ReceiptID, err)
+		}
+
+		// The file must not be picked up again once its message is sent.
+		setAsideSpooledFile(cfg, file)
+	}
+
+	return nil
+}
+
+// setAsideSpooledFile renames the given spooled file so that it is no longer
+// picked up.
+func setAsideSpooledFile(cfg *config.Config, file spool.File) {
+	path, err := spool.MarkFailed(file)
+
+	switch {
+	case err != nil:
+		if !cfg.SilentOutput {
+			log.Printf("\n\nERROR: %v\n\n", err)
+		}
+	case !cfg.SilentOutput:
+		log.Printf("Spooled file %s set aside as %s", file.Name, path)
+	}
+}
+
+// emitSpoolResult emits the JSON formatted summary (if requested) of the
+// message for the given spooled file.
+func emitSpoolResult(cfg *config.Config, name string, result delivery.Result) {
+	if !cfg.JSONOutput {
+		return
+	}
+
+	if err := json.NewEncoder(resultOutput).Encode(spoolResult{File: name, Result: result}); err != nil {
+		log.Printf("ERROR: Failed to emit JSON result: %v", err)
+	}
+}
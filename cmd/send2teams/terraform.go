@@ -0,0 +1,158 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/atc0005/send2teams/internal/config"
+	"github.com/atc0005/send2teams/internal/delivery"
+	"github.com/atc0005/send2teams/internal/idempotency"
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// idempotencyClaim is the claim on the idempotency key of a message which is
+// about to be sent.
+type idempotencyClaim struct {
+	store   *idempotency.Store
+	key     string
+	release func()
+}
+
+// claimIdempotencyKey claims the idempotency key (if any) of the given
+// message. If the message was already sent for the key, the outcome of the
+// earlier submission is reported and done is true along with the exit code
+// for the application. A nil claim is returned if no key applies.
+func claimIdempotencyKey(cfg *config.Config, deliverer *delivery.Deliverer, msg teams.Message) (claim *idempotencyClaim, done bool, code int) {
+	key := cfg.MessageIdempotencyKey(msg)
+	if key == "" {
+		return nil, false, 0
+	}
+
+	fail := func(err error) (*idempotencyClaim, bool, int) {
+		if !cfg.SilentOutput {
+			log.Printf(
+				"\n\nERROR: Message for %q channel in the %q team not sent: %v\n\n",
+				cfg.Channel,
+				cfg.Team,
+				err,
+			)
+		}
+		// Regardless of silent flag, explicitly note unsuccessful results
+		return nil, true, 1
+	}
+
+	store, err := idempotency.Open(cfg.IdempotencyDir, cfg.WebhookURL)
+	if err != nil {
+		return fail(err)
+	}
+
+	if rec, found, err := store.Lookup(key); err != nil {
+		return fail(err)
+	} else if found {
+		reportDuplicate(cfg, deliverer, rec)
+		return nil, true, 0
+	}
+
+	release, err := store.Lock(key)
+	if err != nil {
+		return fail(err)
+	}
+
+	// Another invocation may have completed the submission just before the
+	// lock was acquired.
+	if rec, found, err := store.Lookup(key); err != nil || found {
+		release()
+		if err != nil {
+			return fail(err)
+		}
+		reportDuplicate(cfg, deliverer, rec)
+		return nil, true, 0
+	}
+
+	return &idempotencyClaim{store: store, key: key, release: release}, false, 0
+}
+
+// reportDuplicate reports that a message was not sent since it was already
+// sent as described by the given record. In Terraform mode the output of
+// the earlier submission is emitted again unchanged.
+func reportDuplicate(cfg *config.Config, deliverer *delivery.Deliverer, rec idempotency.Record) {
+	if !cfg.SilentOutput {
+		log.Printf(
+			"Message already sent for idempotency key %q (receipt %s); not sending again",
+			rec.Key,
+			rec.ReceiptID,
+		)
+	}
+
+	if cfg.TerraformMode {
+		writeTerraformOutput(rec, delivery.StatusSent)
+		return
+	}
+
+	emitSkippedResult(cfg, deliverer, rec.ReceiptID, rec.Title, delivery.StatusDuplicate)
+}
+
+// complete records that the message with the given receipt ID and title was
+// sent for the claimed idempotency key, emitting the Terraform output if
+// requested. The claim is released regardless of whether the record could be
+// saved.
+func (c *idempotencyClaim) complete(cfg *config.Config, receiptID string, title string) {
+	defer c.release()
+
+	rec := idempotency.Record{
+		Key:       c.key,
+		ReceiptID: receiptID,
+		Time:      time.Now().UTC().Truncate(time.Second),
+		Title:     title,
+		Team:      cfg.Team,
+		Channel:   cfg.Channel,
+	}
+
+	if err := c.store.Save(rec); err != nil && !cfg.SilentOutput {
+		log.Printf("WARNING: Failed to record idempotency key %q: %v", c.key, err)
+	}
+
+	if cfg.TerraformMode {
+		writeTerraformOutput(rec, delivery.StatusSent)
+	}
+}
+
+// abandon releases the claimed idempotency key without recording a
+// submission, allowing a later invocation to send the message.
+func (c *idempotencyClaim) abandon() {
+	if c != nil {
+		c.release()
+	}
+}
+
+// terraformOutput returns the output for the given record in the format
+// expected by the Terraform external data source: a flat JSON object of
+// string values. Keys are emitted in sorted order, so the output for a
+// record is always the same.
+func terraformOutput(rec idempotency.Record, status string) map[string]string {
+	return map[string]string{
+		"idempotency_key": rec.Key,
+		"receipt_id":      rec.ReceiptID,
+		"status":          status,
+		"time":            rec.Time.UTC().Format(time.RFC3339),
+		"team":            rec.Team,
+		"channel":         rec.Channel,
+		"title":           rec.Title,
+	}
+}
+
+// writeTerraformOutput writes the Terraform output for the given record.
+// Machine-readable output is emitted regardless of the silent flag.
+func writeTerraformOutput(rec idempotency.Record, status string) {
+	if err := json.NewEncoder(resultOutput).Encode(terraformOutput(rec, status)); err != nil {
+		log.Printf("ERROR: Failed to emit Terraform output: %v", err)
+	}
+}
@@ -0,0 +1,344 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+//go:build !minimal
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+
+	"github.com/atc0005/send2teams/internal/breaker"
+	"github.com/atc0005/send2teams/internal/config"
+	"github.com/atc0005/send2teams/internal/serve"
+)
+
+// topRefreshInterval is how often the top display is refreshed.
+const topRefreshInterval time.Duration = time.Second
+
+// ANSI escape sequences used by the top display.
+const (
+	ansiClearScreen string = "\x1b[H\x1b[2J"
+	ansiHideCursor  string = "\x1b[?25l"
+	ansiShowCursor  string = "\x1b[?25h"
+	ansiReverse     string = "\x1b[7m"
+	ansiBold        string = "\x1b[1m"
+	ansiReset       string = "\x1b[0m"
+)
+
+// Keys recognized by the top display.
+const (
+	keyQuit    byte = 'q'
+	keyRetry   byte = 'r'
+	keyDiscard byte = 'd'
+	keyUp      byte = 'k'
+	keyDown    byte = 'j'
+	keyCtrlC   byte = 0x03
+	keyEscape  byte = 0x1b
+)
+
+// topView is the state of the top display.
+type topView struct {
+	socket   string
+	client   *serve.Client
+	status   serve.QueueStatus
+	err      error
+	notice   string
+	selected int
+}
+
+// runTop displays the live status of the delivery queue for the serve
+// instance listening on the user-specified unix domain socket, returning the
+// exit code for the application. If stdin is not a terminal, a single
+// snapshot is displayed.
+func runTop(cfg *config.Config) int {
+	view := topView{socket: cfg.ListenUnix}
+	defer view.disconnect()
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		view.refresh()
+		if view.err != nil {
+			log.Printf("ERROR: Failed to retrieve status from %s: %v", cfg.ListenUnix, view.err)
+			return 1
+		}
+
+		fmt.Fprint(resultOutput, view.render(0, false))
+		return 0
+	}
+
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		log.Printf("ERROR: Failed to configure terminal: %v", err)
+		return 1
+	}
+	defer func() {
+		fmt.Print(ansiShowCursor + ansiClearScreen)
+		_ = term.Restore(fd, oldState)
+	}()
+
+	fmt.Print(ansiHideCursor)
+
+	keys := make(chan byte)
+	go func() {
+		buf := make([]byte, 16)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				close(keys)
+				return
+			}
+			for _, b := range translateKeys(buf[:n]) {
+				keys <- b
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(topRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		view.refresh()
+
+		width, height, err := term.GetSize(fd)
+		if err != nil {
+			width, height = 80, 24
+		}
+		fmt.Print(ansiClearScreen + strings.ReplaceAll(view.fit(width, height), "\n", "\r\n"))
+
+		select {
+		case key, ok := <-keys:
+			if !ok || key == keyQuit || key == keyCtrlC {
+				return 0
+			}
+			view.handleKey(key)
+
+		case <-ticker.C:
+		}
+	}
+}
+
+// translateKeys converts arrow key escape sequences within the given input
+// to the equivalent navigation keys.
+func translateKeys(input []byte) []byte {
+	keys := make([]byte, 0, len(input))
+	for i := 0; i < len(input); i++ {
+		if input[i] == keyEscape && i+2 < len(input) && input[i+1] == '[' {
+			switch input[i+2] {
+			case 'A':
+				keys = append(keys, keyUp)
+			case 'B':
+				keys = append(keys, keyDown)
+			}
+			i += 2
+			continue
+		}
+		keys = append(keys, input[i])
+	}
+
+	return keys
+}
+
+// refresh retrieves the current queue status, reconnecting to the serve
+// instance if needed.
+func (v *topView) refresh() {
+	if v.client == nil {
+		client, err := serve.Dial(v.socket)
+		if err != nil {
+			v.err = err
+			return
+		}
+		v.client = client
+	}
+
+	status, err := v.client.Status()
+	if err != nil {
+		v.err = err
+		v.disconnect()
+		return
+	}
+
+	v.status = status
+	v.err = nil
+}
+
+// disconnect closes the connection to the serve instance.
+func (v *topView) disconnect() {
+	if v.client != nil {
+		_ = v.client.Close()
+		v.client = nil
+	}
+}
+
+// selectable returns the messages which may be retried or discarded, in
+// display order.
+func (v *topView) selectable() []serve.ItemStatus {
+	items := make([]serve.ItemStatus, 0, len(v.status.Pending)+len(v.status.Failed))
+	items = append(items, v.status.Pending...)
+
+	return append(items, v.status.Failed...)
+}
+
+// handleKey applies the action for the given key.
+func (v *topView) handleKey(key byte) {
+	items := v.selectable()
+
+	switch key {
+	case keyUp:
+		if v.selected > 0 {
+			v.selected--
+		}
+		return
+
+	case keyDown:
+		if v.selected < len(items)-1 {
+			v.selected++
+		}
+		return
+
+	case keyRetry, keyDiscard:
+	default:
+		return
+	}
+
+	if v.client == nil || v.selected >= len(items) {
+		return
+	}
+
+	item := items[v.selected]
+
+	var err error
+	var action string
+	switch {
+	case key == keyRetry && item.State == serve.StateFailed:
+		action = "retry"
+		err = v.client.Retry(item.ReceiptID)
+	case key == keyRetry:
+		v.notice = "Only failed messages may be retried"
+		return
+	default:
+		action = "discard"
+		err = v.client.Discard(item.ReceiptID)
+	}
+
+	if err != nil {
+		v.notice = fmt.Sprintf("Failed to %s %s: %v", action, item.ReceiptID, err)
+		return
+	}
+	v.notice = fmt.Sprintf("Requested %s of %s", action, item.ReceiptID)
+}
+
+// fit renders the display, clamping the selection and limiting the output
+// to the given terminal height.
+func (v *topView) fit(width int, height int) string {
+	if n := len(v.selectable()); v.selected >= n {
+		v.selected = n - 1
+	}
+	if v.selected < 0 {
+		v.selected = 0
+	}
+
+	lines := strings.Split(v.render(width, true), "\n")
+	if height > 0 && len(lines) > height {
+		// Keep the key help on the last line visible.
+		lines = append(lines[:height-1], lines[len(lines)-1])
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// render returns the display for the current queue status. If interactive,
+// the selected message and key help are included.
+func (v *topView) render(width int, interactive bool) string {
+	var b strings.Builder
+
+	line := func(style string, format string, args ...interface{}) {
+		text := fmt.Sprintf(format, args...)
+		if width > 0 && utf8.RuneCountInString(text) > width {
+			text = string([]rune(text)[:width])
+		}
+		if style != "" {
+			text = style + text + ansiReset
+		}
+		b.WriteString(text + "\n")
+	}
+
+	line(ansiBoldIf(interactive), "send2teams top - %s - %s", v.socket, time.Now().Format("2006-01-02 15:04:05"))
+
+	if v.err != nil {
+		line("", "")
+		line("", "Unable to retrieve status: %v", v.err)
+	} else {
+		throttled := "no"
+		if v.status.Throttled {
+			throttled = "YES (rate limited by Microsoft Teams)"
+		}
+		line("", "Queue: %d/%d  Sent: %d  Failed: %d  Throttled: %s",
+			v.status.Depth, v.status.Capacity, v.status.SentTotal, v.status.FailedTotal, throttled)
+
+		if v.status.Paused {
+			line("", "Delivery: PAUSED (remove the pause file to resume)")
+		}
+
+		if c := v.status.Circuit; c != nil && c.State != breaker.StateClosed {
+			line("", "Circuit: %s after %d consecutive failures; next attempt at %s",
+				strings.ToUpper(c.State), c.ConsecutiveFailures, c.ProbeAt.Local().Format("15:04:05"))
+		}
+
+		index := 0
+		item := func(i serve.ItemStatus, detail string) {
+			style := ""
+			marker := "  "
+			if interactive && index == v.selected {
+				style = ansiReverse
+				marker = "> "
+			}
+			line(style, "%s%-36s  %-9s  %s%s", marker, i.ReceiptID, i.State, i.Title, detail)
+			index++
+		}
+
+		line("", "")
+		line(ansiBoldIf(interactive), "PENDING (%d)", len(v.status.Pending))
+		for _, i := range v.status.Pending {
+			item(i, fmt.Sprintf("  (waiting %s)", time.Since(i.Queued).Round(time.Second)))
+		}
+
+		line("", "")
+		line(ansiBoldIf(interactive), "FAILED (%d)", len(v.status.Failed))
+		for _, i := range v.status.Failed {
+			item(i, "  - "+i.Error)
+		}
+
+		line("", "")
+		line(ansiBoldIf(interactive), "RECENT")
+		for _, i := range v.status.Recent {
+			line("", "  %s  %-9s  %-36s  %s", i.Completed.Local().Format("15:04:05"), i.State, i.ReceiptID, i.Title)
+		}
+	}
+
+	if interactive {
+		line("", "")
+		line(ansiReverse, "[up/down] select  [r] retry  [d] discard  [q] quit  %s", v.notice)
+	}
+
+	return b.String()
+}
+
+// ansiBoldIf returns the bold text escape sequence if enabled.
+func ansiBoldIf(enabled bool) string {
+	if enabled {
+		return ansiBold
+	}
+	return ""
+}
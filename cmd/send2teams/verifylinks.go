@@ -0,0 +1,72 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/atc0005/send2teams/internal/config"
+	"github.com/atc0005/send2teams/internal/linkcheck"
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// verifyLinks checks the URLs of the target URL buttons and links within the
+// text of the given message, logging a warning for each dead link. An error
+// is returned if dead links were found and the user requested that they
+// prevent the message from being sent.
+func verifyLinks(ctx context.Context, cfg *config.Config, msg teams.Message) error {
+	if !cfg.VerifyLinks {
+		return nil
+	}
+
+	urls := make([]string, 0, len(msg.TargetURLs))
+	for _, target := range msg.TargetURLs {
+		urls = append(urls, target.URL)
+	}
+	urls = append(urls, linkcheck.Extract(msg.Text)...)
+
+	allowlist := cfg.VerifyLinksAllowlist()
+	seen := make(map[string]struct{}, len(urls))
+	checked := make([]string, 0, len(urls))
+	for _, u := range urls {
+		if _, dup := seen[u]; dup || linkcheck.Allowed(u, allowlist) {
+			continue
+		}
+		seen[u] = struct{}{}
+		checked = append(checked, u)
+	}
+
+	results := linkcheck.Check(ctx, http.DefaultClient, checked, linkcheck.Options{
+		Timeout:   cfg.VerifyLinksTimeout,
+		UserAgent: cfg.UserAgent(),
+	})
+
+	var dead int
+	for _, result := range results {
+		if !result.Dead() {
+			if cfg.VerboseOutput {
+				log.Printf("Verified link %s", result)
+			}
+			continue
+		}
+
+		dead++
+		if !cfg.SilentOutput {
+			log.Printf("WARNING: dead link %s", result)
+		}
+	}
+
+	if dead > 0 && cfg.VerifyLinksFail {
+		return fmt.Errorf("%d of %d checked link(s) are dead", dead, len(results))
+	}
+
+	return nil
+}
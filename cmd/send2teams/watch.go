@@ -0,0 +1,145 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+//go:build !minimal
+
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	goteamsnotify "github.com/atc0005/go-teams-notify/v2"
+	"github.com/atc0005/send2teams/internal/config"
+	"github.com/atc0005/send2teams/internal/delivery"
+	"github.com/atc0005/send2teams/internal/teams"
+	"github.com/atc0005/send2teams/internal/templates"
+	"github.com/atc0005/send2teams/internal/watch"
+)
+
+// runWatchFile watches the user-specified file or directory, sending a
+// message each time it changes until interrupted. Failures to send a message
+// are logged, but do not stop watching. The exit code for the application is
+// returned.
+func runWatchFile(cfg *config.Config, deliverer *delivery.Deliverer) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var ops watch.Op
+	if cfg.OnChange {
+		ops |= watch.Modify
+	}
+	if cfg.OnCreate {
+		ops |= watch.Create
+	}
+	if cfg.OnRemove {
+		ops |= watch.Remove
+	}
+
+	opts := watch.Options{
+		Interval:  cfg.PollInterval,
+		Debounce:  cfg.Debounce,
+		Ops:       ops,
+		DiffLines: cfg.DiffLines,
+		OnError: func(path string, err error) {
+			if !cfg.SilentOutput {
+				log.Printf("WARNING: Skipping %s: %v", path, err)
+			}
+		},
+	}
+
+	if !cfg.SilentOutput {
+		log.Printf("Watching %s for changes (checking every %v)", cfg.WatchPath, cfg.PollInterval)
+	}
+
+	err := watch.Watch(ctx, cfg.WatchPath, opts, func(event watch.Event) {
+		sendWatchEvent(cfg, deliverer, event)
+	})
+	if err != nil {
+		if !cfg.SilentOutput {
+			log.Printf("\n\nERROR: Failed to watch %s: %v\n\n", cfg.WatchPath, err)
+		}
+		return 1
+	}
+
+	return 0
+}
+
+// sendWatchEvent submits the message describing the given changes.
+func sendWatchEvent(cfg *config.Config, deliverer *delivery.Deliverer, event watch.Event) {
+	data := templates.WatchData{
+		Path:          event.Path,
+		Time:          event.Time,
+		Diff:          event.Diff,
+		DiffTruncated: event.DiffTruncated,
+	}
+	for _, change := range event.Changes {
+		data.Changes = append(data.Changes, templates.WatchChange{
+			Path: change.Path,
+			Op:   change.Op.String(),
+		})
+	}
+
+	if cfg.VerboseOutput {
+		log.Printf("Detected %d change(s) in %s", len(data.Changes), event.Path)
+	}
+
+	receiptID := teams.NewReceiptID()
+
+	msg, err := cfg.WatchMessage(&data)
+	if err != nil {
+		if !cfg.SilentOutput {
+			log.Printf("ERROR: Failed to generate message for changes to %s: %v", event.Path, err)
+		}
+		return
+	}
+
+	opts := cfg.CardOptions(cfg.Sender)
+	if cfg.ReceiptFact {
+		opts.ReceiptID = receiptID
+	}
+
+	message, err := teams.NewAdaptiveCardMessage(msg, opts)
+	if err != nil {
+		if !cfg.SilentOutput {
+			log.Printf("ERROR: Failed to generate message for changes to %s: %v", event.Path, err)
+		}
+		return
+	}
+
+	// Allow an in-flight submission to complete when interrupted.
+	sendCtx, cancel := context.WithTimeout(context.Background(), cfg.TeamsSubmissionTimeout())
+	defer cancel()
+
+	sendErr := deliverer.Deliver(sendCtx, receiptID, cfg.WebhookURL, message)
+	if cfg.IgnoreInvalidResponse && errors.Is(sendErr, goteamsnotify.ErrInvalidWebhookURLResponseText) {
+		sendErr = nil
+	}
+
+	result := deliverer.NewResult(receiptID, sendErr)
+	recordHistory(cfg, msg.Title, result)
+
+	// Machine-readable output is emitted regardless of the silent flag.
+	if cfg.JSONOutput {
+		if err := result.Write(resultOutput); err != nil {
+			log.Printf("ERROR: Failed to emit JSON result: %v", err)
+		}
+	}
+
+	switch {
+	case sendErr != nil:
+		if !cfg.SilentOutput {
+			log.Printf("ERROR: Failed to submit message for changes to %s (receipt %s): %v", event.Path, receiptID, sendErr)
+		}
+	case !cfg.SilentOutput:
+		log.Printf("Message for %d change(s) to %s successfully sent! (receipt %s)", len(data.Changes), event.Path, receiptID)
+	}
+}
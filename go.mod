@@ -13,4 +13,9 @@ go 1.20
 //
 // replace github.com/atc0005/go-teams-notify/v2 => ../go-teams-notify
 
-require github.com/atc0005/go-teams-notify/v2 v2.8.0
+require (
+	github.com/atc0005/go-teams-notify/v2 v2.8.0
+	golang.org/x/term v0.15.0
+)
+
+require golang.org/x/sys v0.15.0 // indirect
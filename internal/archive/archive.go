@@ -0,0 +1,145 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/atc0005/send2teams/internal/debugbundle"
+)
+
+// requestTimeout is the timeout applied to each archive upload and to
+// retrieving the credentials for an archive destination.
+const requestTimeout time.Duration = 10 * time.Second
+
+// ErrInvalidSpec indicates that an archive destination was specified in an
+// unsupported format.
+var ErrInvalidSpec = errors.New("invalid archive destination")
+
+// Archiver is an object storage destination for archived records.
+type Archiver interface {
+
+	// Store saves the given data using the given object name. The name is
+	// relative to any prefix configured for the destination.
+	Store(ctx context.Context, name string, data []byte) error
+
+	// String returns a description of the destination for use in log
+	// messages.
+	String() string
+}
+
+// Record is the archived copy of a submitted payload and the result of
+// submitting it.
+type Record struct {
+
+	// Time is when the submission completed.
+	Time time.Time `json:"time"`
+
+	// ReceiptID is the unique identifier assigned to the submission.
+	ReceiptID string `json:"receipt_id"`
+
+	// Team is the human-readable name of the target Microsoft Teams team.
+	Team string `json:"team"`
+
+	// Channel is the human-readable name of the target channel.
+	Channel string `json:"channel"`
+
+	// WebhookHost is the host portion of the webhook URL. The full URL is
+	// not recorded as anyone with it is able to submit messages.
+	WebhookHost string `json:"webhook_host"`
+
+	// Payload is the JSON payload submitted to the webhook URL.
+	Payload json.RawMessage `json:"payload"`
+
+	// Error is the reason the submission failed, with the path and query of
+	// any URLs (e.g., the webhook URL) redacted. Empty if successful.
+	Error string `json:"error,omitempty"`
+
+	// Success indicates whether the payload was successfully submitted.
+	Success bool `json:"success"`
+}
+
+// NewRecord creates a Record for the given payload and submission result.
+func NewRecord(receiptID string, team string, channel string, webhookURL string, payload []byte, sendErr error) Record {
+	rec := Record{
+		Time:      time.Now().UTC(),
+		ReceiptID: receiptID,
+		Team:      team,
+		Channel:   channel,
+		Payload:   payload,
+		Success:   sendErr == nil,
+	}
+
+	if u, err := url.Parse(webhookURL); err == nil {
+		rec.WebhookHost = u.Host
+	}
+
+	if sendErr != nil {
+		rec.Error = debugbundle.RedactURLs(sendErr.Error())
+	}
+
+	return rec
+}
+
+// Name returns the object name used to store the record. Names are grouped
+// by (UTC) date and include the receipt ID for the submission.
+func (r Record) Name() string {
+	name := fmt.Sprintf(
+		"%s-%s.json",
+		r.Time.Format("20060102T150405Z"),
+		r.ReceiptID,
+	)
+
+	return path.Join(r.Time.Format("2006/01/02"), name)
+}
+
+// Submit stores the given record in each of the given archive destinations,
+// returning any errors encountered.
+func Submit(ctx context.Context, archivers []Archiver, rec Record) error {
+	if len(archivers) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode archive record: %w", err)
+	}
+
+	name := rec.Name()
+
+	errs := make([]error, 0, len(archivers))
+	for _, archiver := range archivers {
+		reqCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+		if err := archiver.Store(reqCtx, name, data); err != nil {
+			errs = append(errs, fmt.Errorf("failed to archive record to %s: %w", archiver, err))
+		}
+		cancel()
+	}
+
+	return errors.Join(errs...)
+}
+
+// splitSpec splits an archive destination in the form of "name/prefix" into
+// its components.
+func splitSpec(spec string) (string, string, error) {
+	spec = strings.Trim(strings.TrimSpace(spec), "/")
+	if spec == "" {
+		return "", "", fmt.Errorf("%w: empty value", ErrInvalidSpec)
+	}
+
+	name, prefix, _ := strings.Cut(spec, "/")
+
+	return name, prefix, nil
+}
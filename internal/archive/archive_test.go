@@ -0,0 +1,35 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package archive
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestNewRecordRedactsWebhookURL(t *testing.T) {
+	const webhookURL = "https://example.webhook.office.com/webhookb2/SECRETPATH/IncomingWebhook/SECRETTOKEN"
+
+	sendErr := &url.Error{Op: "Post", URL: webhookURL, Err: errors.New("connection reset by peer")}
+
+	rec := NewRecord("1a2b3c4d", "Team", "Channel", webhookURL, []byte(`{}`), sendErr)
+
+	if strings.Contains(rec.Error, "SECRET") {
+		t.Errorf("got error %q; want webhook URL redacted", rec.Error)
+	}
+
+	if !strings.Contains(rec.Error, "example.webhook.office.com") || !strings.Contains(rec.Error, "connection reset by peer") {
+		t.Errorf("got error %q; want host and cause retained", rec.Error)
+	}
+
+	if rec.WebhookHost != "example.webhook.office.com" {
+		t.Errorf("got webhook host %q", rec.WebhookHost)
+	}
+}
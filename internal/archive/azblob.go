@@ -0,0 +1,102 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package archive
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// azureStorageAPIVersion is the Azure Storage REST API version requested
+// when uploading blobs.
+const azureStorageAPIVersion string = "2021-08-06"
+
+// AzureBlob is an Azure Blob Storage archive destination.
+//
+// Requests are authorized using a shared access signature (SAS) token
+// retrieved from the AZURE_STORAGE_SAS_TOKEN environment variable. The token
+// requires create and write permissions for the container.
+type AzureBlob struct {
+	client    *http.Client
+	baseURL   string
+	prefix    string
+	sasToken  string
+	container string
+}
+
+// NewAzureBlob creates an Azure Blob Storage archive destination from a
+// specification in the form of "account/container/prefix". The prefix is
+// optional. A full container URL (e.g.,
+// "https://account.blob.core.windows.net/container/prefix") may be given
+// instead for storage emulators or sovereign clouds.
+func NewAzureBlob(spec string, client *http.Client) (*AzureBlob, error) {
+	sasToken := strings.TrimPrefix(os.Getenv("AZURE_STORAGE_SAS_TOKEN"), "?")
+	if sasToken == "" {
+		return nil, fmt.Errorf("%w: AZURE_STORAGE_SAS_TOKEN must be set", ErrInvalidSpec)
+	}
+
+	var account, remainder string
+	switch {
+	case strings.HasPrefix(spec, "https://"), strings.HasPrefix(spec, "http://"):
+		u, err := url.Parse(spec)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSpec, err)
+		}
+		account = u.Scheme + "://" + u.Host
+		remainder = u.Path
+
+	default:
+		var err error
+		account, remainder, err = splitSpec(spec)
+		if err != nil {
+			return nil, err
+		}
+		account = fmt.Sprintf("https://%s.blob.core.windows.net", account)
+	}
+
+	container, prefix, err := splitSpec(remainder)
+	if err != nil {
+		return nil, fmt.Errorf("%w: container not specified", ErrInvalidSpec)
+	}
+
+	return &AzureBlob{
+		client:    client,
+		baseURL:   account,
+		prefix:    prefix,
+		sasToken:  sasToken,
+		container: container,
+	}, nil
+}
+
+// String returns a description of the destination.
+func (a *AzureBlob) String() string {
+	return a.baseURL + "/" + path.Join(a.container, a.prefix)
+}
+
+// Store uploads the given data as a block blob with the given name.
+func (a *AzureBlob) Store(ctx context.Context, name string, data []byte) error {
+	blob := (&url.URL{Path: path.Join(a.container, a.prefix, name)}).EscapedPath()
+	blobURL := fmt.Sprintf("%s/%s?%s", a.baseURL, blob, a.sasToken)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, blobURL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Ms-Blob-Type", "BlockBlob")
+	req.Header.Set("X-Ms-Version", azureStorageAPIVersion)
+
+	return doUpload(a.client, req)
+}
@@ -0,0 +1,14 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package archive provides support for retaining a copy of every submitted
+payload (along with the submission result) in object storage. This allows
+notification history to be kept beyond the retention period applied by
+Microsoft Teams.
+*/
+package archive
@@ -0,0 +1,164 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package archive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/atc0005/send2teams/internal/aws"
+)
+
+// credentialsRefreshWindow is how long before they expire that temporary
+// credentials are refreshed, allowing for uploads in progress and clock
+// skew.
+const credentialsRefreshWindow time.Duration = 5 * time.Minute
+
+// S3 is an Amazon S3 (or S3 compatible) archive destination.
+//
+// Credentials and region are retrieved using the default AWS credential
+// chain (environment variables, shared files, container or instance
+// metadata). If set, the AWS_ENDPOINT_URL_S3 (or AWS_ENDPOINT_URL)
+// environment variable overrides the default endpoint and path-style
+// addressing is used. Temporary credentials are refreshed before they
+// expire.
+type S3 struct {
+	client   *http.Client
+	mu       sync.Mutex
+	creds    aws.Credentials
+	bucket   string
+	prefix   string
+	region   string
+	endpoint string
+}
+
+// NewS3 creates an S3 archive destination from a specification in the form
+// of "bucket/prefix". The prefix is optional.
+func NewS3(spec string, client *http.Client) (*S3, error) {
+	bucket, prefix, err := splitSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	creds, err := aws.DefaultCredentials(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	region, err := aws.DefaultRegion(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := os.Getenv("AWS_ENDPOINT_URL_S3")
+	if endpoint == "" {
+		endpoint = os.Getenv("AWS_ENDPOINT_URL")
+	}
+
+	return &S3{
+		client:   client,
+		creds:    creds,
+		bucket:   bucket,
+		prefix:   prefix,
+		region:   region,
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+	}, nil
+}
+
+// String returns a description of the destination.
+func (s *S3) String() string {
+	return "s3://" + path.Join(s.bucket, s.prefix)
+}
+
+// objectURL returns the URL for the object with the given name.
+func (s *S3) objectURL(name string) string {
+	key := (&url.URL{Path: path.Join(s.prefix, name)}).EscapedPath()
+
+	if s.endpoint != "" {
+		return fmt.Sprintf("%s/%s/%s", s.endpoint, s.bucket, key)
+	}
+
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.bucket, s.region, key)
+}
+
+// credentials returns the credentials used to sign requests, refreshing
+// temporary credentials which are about to expire.
+func (s *S3) credentials(ctx context.Context) (aws.Credentials, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.creds.ExpiresWithin(credentialsRefreshWindow, time.Now()) {
+		return s.creds, nil
+	}
+
+	creds, err := aws.DefaultCredentials(ctx, s.client)
+	if err != nil {
+		return aws.Credentials{}, fmt.Errorf("failed to refresh credentials: %w", err)
+	}
+	s.creds = creds
+
+	return creds, nil
+}
+
+// Store uploads the given data as an object with the given name.
+func (s *S3) Store(ctx context.Context, name string, data []byte) error {
+	creds, err := s.credentials(ctx)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(name), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	payloadHash := aws.HashHex(data)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	aws.SignRequest(req, payloadHash, creds, s.region, "s3", time.Now())
+
+	return doUpload(s.client, req)
+}
+
+// doUpload submits the given upload request and asserts that it was
+// successful.
+func doUpload(client *http.Client, req *http.Request) error {
+	res, err := client.Do(req)
+	if err != nil {
+		// Avoid exposing request URLs (which may include a SAS token) in
+		// error output.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return fmt.Errorf("%s request failed: %w", urlErr.Op, urlErr.Err)
+		}
+
+		return err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+
+		return fmt.Errorf("unexpected response: %s, %q", res.Status, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
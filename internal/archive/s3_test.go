@@ -0,0 +1,227 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package archive
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// sigv4Signature independently computes the Signature Version 4 signature
+// expected for the given S3 upload request.
+func sigv4Signature(r *http.Request, secretKey string, region string) string {
+	hmacSHA256 := func(key []byte, data string) []byte {
+		mac := hmac.New(sha256.New, key)
+		_, _ = mac.Write([]byte(data))
+		return mac.Sum(nil)
+	}
+	hashHex := func(data string) string {
+		sum := sha256.Sum256([]byte(data))
+		return hex.EncodeToString(sum[:])
+	}
+
+	amzDate := r.Header.Get("X-Amz-Date")
+	date := amzDate[:8]
+
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		r.URL.EscapedPath(),
+		"",
+		"content-type:" + r.Header.Get("Content-Type") + "\n" +
+			"host:" + r.Host + "\n" +
+			"x-amz-content-sha256:" + r.Header.Get("X-Amz-Content-Sha256") + "\n" +
+			"x-amz-date:" + amzDate + "\n" +
+			"x-amz-security-token:" + r.Header.Get("X-Amz-Security-Token") + "\n",
+		"content-type;host;x-amz-content-sha256;x-amz-date;x-amz-security-token",
+		r.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		date + "/" + region + "/s3/aws4_request",
+		hashHex(canonicalRequest),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func TestS3Store(t *testing.T) {
+	const (
+		accessKey    = "AKIDEXAMPLE"
+		secretKey    = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+		sessionToken = "session-token"
+		region       = "eu-west-1"
+	)
+
+	// Credentials and region are only available from the shared files.
+	for _, name := range []string{
+		"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN",
+		"AWS_REGION", "AWS_DEFAULT_REGION", "AWS_PROFILE", "AWS_ENDPOINT_URL",
+		"AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "AWS_CONTAINER_CREDENTIALS_FULL_URI",
+	} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+
+	dir := t.TempDir()
+	credsFile := filepath.Join(dir, "credentials")
+	configFile := filepath.Join(dir, "config")
+
+	credsData := fmt.Sprintf(
+		"[default]\naws_access_key_id = %s\naws_secret_access_key = %s\naws_session_token = %s\n",
+		accessKey, secretKey, sessionToken,
+	)
+	if err := os.WriteFile(credsFile, []byte(credsData), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configFile, []byte("[default]\nregion = "+region+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credsFile)
+	t.Setenv("AWS_CONFIG_FILE", configFile)
+
+	data := []byte(`{"receipt_id":"1a2b3c4d"}`)
+
+	var got *http.Request
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		gotBody, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	t.Setenv("AWS_ENDPOINT_URL_S3", server.URL+"/")
+
+	s3, err := NewS3("archive-bucket/teams", server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s3.Store(context.Background(), "2024/01/31/record one.json", data); err != nil {
+		t.Fatal(err)
+	}
+
+	if got == nil {
+		t.Fatal("no upload request received")
+	}
+
+	if got.Method != http.MethodPut {
+		t.Errorf("got method %s; want %s", got.Method, http.MethodPut)
+	}
+
+	if want := "/archive-bucket/teams/2024/01/31/record%20one.json"; got.URL.EscapedPath() != want {
+		t.Errorf("got path %q; want %q", got.URL.EscapedPath(), want)
+	}
+
+	if string(gotBody) != string(data) {
+		t.Errorf("got body %q; want %q", gotBody, data)
+	}
+
+	sum := sha256.Sum256(data)
+	wantHeaders := map[string]string{
+		"Content-Type":         "application/json",
+		"X-Amz-Content-Sha256": hex.EncodeToString(sum[:]),
+		"X-Amz-Security-Token": sessionToken,
+	}
+	for name, want := range wantHeaders {
+		if value := got.Header.Get(name); value != want {
+			t.Errorf("got %s header %q; want %q", name, value, want)
+		}
+	}
+
+	amzDate, err := time.Parse("20060102T150405Z", got.Header.Get("X-Amz-Date"))
+	if err != nil || time.Since(amzDate) > time.Minute {
+		t.Fatalf("got X-Amz-Date header %q; want current time", got.Header.Get("X-Amz-Date"))
+	}
+
+	wantAuth := fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s/%s/s3/aws4_request, "+
+			"SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date;x-amz-security-token, "+
+			"Signature=%s",
+		accessKey, amzDate.Format("20060102"), region, sigv4Signature(got, secretKey, region),
+	)
+	if auth := got.Header.Get("Authorization"); auth != wantAuth {
+		t.Errorf("got Authorization header %q; want %q", auth, wantAuth)
+	}
+}
+
+func TestS3RefreshCredentials(t *testing.T) {
+	for _, name := range []string{
+		"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN",
+		"AWS_ENDPOINT_URL", "AWS_CONTAINER_CREDENTIALS_RELATIVE_URI",
+		"AWS_CONTAINER_AUTHORIZATION_TOKEN", "AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE",
+	} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+
+	// The first credentials are about to expire.
+	var refreshes atomic.Int32
+	credsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := refreshes.Add(1)
+		expiration := time.Now().Add(time.Minute)
+		if n > 1 {
+			expiration = time.Now().Add(time.Hour)
+		}
+		fmt.Fprintf(w, `{"AccessKeyId":"KEY%d","SecretAccessKey":"SECRET","Token":"SESSION","Expiration":%q}`,
+			n, expiration.UTC().Format(time.RFC3339))
+	}))
+	defer credsServer.Close()
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", credsServer.URL+"/creds")
+
+	var auth []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = append(auth, r.Header.Get("Authorization"))
+	}))
+	defer server.Close()
+	t.Setenv("AWS_ENDPOINT_URL_S3", server.URL)
+
+	s3, err := NewS3("archive-bucket", server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := s3.Store(context.Background(), "record.json", []byte(`{}`)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(auth) != 2 {
+		t.Fatalf("got %d uploads; want 2", len(auth))
+	}
+	for i, header := range auth {
+		if !strings.Contains(header, "Credential=KEY2/") {
+			t.Errorf("upload %d: got Authorization header %q; want refreshed credentials", i, header)
+		}
+	}
+
+	if n := refreshes.Load(); n != 2 {
+		t.Errorf("got %d credential retrievals; want 2", n)
+	}
+}
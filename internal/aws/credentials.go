@@ -0,0 +1,323 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package aws
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// metadataTimeout is the maximum time spent retrieving details from the
+// instance metadata service. The service only responds quickly (if at all)
+// when running on EC2.
+const metadataTimeout = 2 * time.Second
+
+// Endpoints used to retrieve credentials for containers (ECS, EKS Pod
+// Identity) and EC2 instances.
+var (
+	containerEndpoint = "http://169.254.170.2"
+	imdsEndpoint      = "http://169.254.169.254"
+)
+
+// DefaultCredentials retrieves AWS credentials using the default credential
+// chain: the standard environment variables (as set by Lambda), the shared
+// credentials file (using the AWS_PROFILE profile), container credentials
+// and finally the instance profile of an EC2 instance.
+func DefaultCredentials(ctx context.Context, client *http.Client) (Credentials, error) {
+	if os.Getenv("AWS_ACCESS_KEY_ID") != "" {
+		return CredentialsFromEnv()
+	}
+
+	if creds, ok, err := credentialsFromFile(); ok || err != nil {
+		return creds, err
+	}
+
+	if creds, ok, err := credentialsFromContainer(ctx, client); ok || err != nil {
+		return creds, err
+	}
+
+	if creds, err := credentialsFromInstance(ctx, client); err == nil {
+		return creds, nil
+	}
+
+	return Credentials{}, fmt.Errorf(
+		"%w: not found in environment, shared credentials file, container or instance metadata",
+		ErrMissingCredentials,
+	)
+}
+
+// DefaultRegion retrieves the AWS region from the standard environment
+// variables, the shared configuration file (using the AWS_PROFILE profile)
+// or the instance metadata of an EC2 instance.
+func DefaultRegion(ctx context.Context, client *http.Client) (string, error) {
+	if region, err := RegionFromEnv(); err == nil {
+		return region, nil
+	}
+
+	configFile := os.Getenv("AWS_CONFIG_FILE")
+	if configFile == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			configFile = filepath.Join(home, ".aws", "config")
+		}
+	}
+
+	// Profiles other than the default are named "profile NAME" within the
+	// shared configuration file.
+	section := "default"
+	if profile := profileName(); profile != "default" {
+		section = "profile " + profile
+	}
+
+	if values, err := readINISection(configFile, section); err == nil && values["region"] != "" {
+		return values["region"], nil
+	}
+
+	if region, err := instanceMetadata(ctx, client, "/latest/meta-data/placement/region"); err == nil && region != "" {
+		return region, nil
+	}
+
+	return "", fmt.Errorf(
+		"%w: AWS_REGION or AWS_DEFAULT_REGION must be set",
+		ErrMissingRegion,
+	)
+}
+
+// profileName returns the name of the shared configuration profile.
+func profileName() string {
+	if profile := os.Getenv("AWS_PROFILE"); profile != "" {
+		return profile
+	}
+
+	return "default"
+}
+
+// credentialsFromFile retrieves credentials for the selected profile from
+// the shared credentials file, along with false if the file or profile does
+// not exist.
+func credentialsFromFile() (Credentials, bool, error) {
+	path := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return Credentials{}, false, nil
+		}
+		path = filepath.Join(home, ".aws", "credentials")
+	}
+
+	values, err := readINISection(path, profileName())
+	if err != nil || values == nil {
+		return Credentials{}, false, nil
+	}
+
+	creds := Credentials{
+		AccessKeyID:     values["aws_access_key_id"],
+		SecretAccessKey: values["aws_secret_access_key"],
+		SessionToken:    values["aws_session_token"],
+	}
+
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return Credentials{}, true, fmt.Errorf(
+			"%w: profile %q in %s does not specify aws_access_key_id and aws_secret_access_key",
+			ErrMissingCredentials, profileName(), path,
+		)
+	}
+
+	return creds, true, nil
+}
+
+// credentialsFromContainer retrieves credentials from the container
+// credentials endpoint, along with false if not running in a container
+// which provides credentials.
+func credentialsFromContainer(ctx context.Context, client *http.Client) (Credentials, bool, error) {
+	var endpoint string
+	switch {
+	case os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI") != "":
+		endpoint = containerEndpoint + os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI")
+	case os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI") != "":
+		endpoint = os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	default:
+		return Credentials{}, false, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return Credentials{}, true, err
+	}
+
+	token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	if tokenFile := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); tokenFile != "" {
+		data, err := os.ReadFile(filepath.Clean(tokenFile))
+		if err != nil {
+			return Credentials{}, true, fmt.Errorf("failed to read container authorization token: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+
+	body, err := metadataRequest(client, req)
+	if err != nil {
+		return Credentials{}, true, fmt.Errorf("failed to retrieve container credentials: %w", err)
+	}
+
+	creds, err := decodeCredentials(body)
+	if err != nil {
+		return Credentials{}, true, fmt.Errorf("failed to decode container credentials: %w", err)
+	}
+
+	return creds, true, nil
+}
+
+// credentialsFromInstance retrieves the credentials of the instance profile
+// role from the EC2 instance metadata service.
+func credentialsFromInstance(ctx context.Context, client *http.Client) (Credentials, error) {
+	roles, err := instanceMetadata(ctx, client, "/latest/meta-data/iam/security-credentials/")
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	role := strings.TrimSpace(strings.SplitN(roles, "\n", 2)[0])
+	if role == "" {
+		return Credentials{}, fmt.Errorf("no instance profile role")
+	}
+
+	body, err := instanceMetadata(ctx, client, "/latest/meta-data/iam/security-credentials/"+role)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	return decodeCredentials([]byte(body))
+}
+
+// instanceMetadata retrieves the given path from the EC2 instance metadata
+// service using a session token (IMDSv2). Disabled if the
+// AWS_EC2_METADATA_DISABLED environment variable is set to true.
+func instanceMetadata(ctx context.Context, client *http.Client, path string) (string, error) {
+	if strings.EqualFold(os.Getenv("AWS_EC2_METADATA_DISABLED"), "true") {
+		return "", fmt.Errorf("instance metadata service disabled")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, metadataTimeout)
+	defer cancel()
+
+	tokenReq, err := http.NewRequestWithContext(ctx, http.MethodPut, imdsEndpoint+"/latest/api/token", nil)
+	if err != nil {
+		return "", err
+	}
+	tokenReq.Header.Set("X-Aws-Ec2-Metadata-Token-Ttl-Seconds", "300")
+
+	token, err := metadataRequest(client, tokenReq)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imdsEndpoint+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Aws-Ec2-Metadata-Token", string(token))
+
+	body, err := metadataRequest(client, req)
+	if err != nil {
+		return "", err
+	}
+
+	return string(body), nil
+}
+
+// metadataRequest performs the given request, returning the response body.
+func metadataRequest(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("request failed: %s", resp.Status)
+	}
+
+	return body, nil
+}
+
+// decodeCredentials decodes credentials in the format returned by the
+// container and instance metadata endpoints.
+func decodeCredentials(body []byte) (Credentials, error) {
+	var resp struct {
+		AccessKeyID     string    `json:"AccessKeyId"`
+		SecretAccessKey string    `json:"SecretAccessKey"`
+		Token           string    `json:"Token"`
+		Expiration      time.Time `json:"Expiration"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return Credentials{}, err
+	}
+
+	if resp.AccessKeyID == "" || resp.SecretAccessKey == "" {
+		return Credentials{}, fmt.Errorf("%w: incomplete credentials returned", ErrMissingCredentials)
+	}
+
+	return Credentials{
+		AccessKeyID:     resp.AccessKeyID,
+		SecretAccessKey: resp.SecretAccessKey,
+		SessionToken:    resp.Token,
+		Expiration:      resp.Expiration,
+	}, nil
+}
+
+// readINISection returns the key/value pairs within the given section of
+// the given INI formatted file (as used by the shared credentials and
+// configuration files). A nil map is returned if the section does not
+// exist.
+func readINISection(path string, section string) (map[string]string, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var values map[string]string
+	inSection := false
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "", strings.HasPrefix(line, "#"), strings.HasPrefix(line, ";"):
+			continue
+
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			name := strings.Join(strings.Fields(line[1:len(line)-1]), " ")
+			inSection = name == section
+			if inSection && values == nil {
+				values = make(map[string]string)
+			}
+
+		case inSection:
+			if key, value, ok := strings.Cut(line, "="); ok {
+				values[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+			}
+		}
+	}
+
+	return values, scanner.Err()
+}
@@ -0,0 +1,154 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// clearEnv unsets the environment variables consulted by the default
+// credential chain.
+func clearEnv(t *testing.T) {
+	t.Helper()
+
+	for _, name := range []string{
+		"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN",
+		"AWS_REGION", "AWS_DEFAULT_REGION", "AWS_PROFILE",
+		"AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "AWS_CONTAINER_CREDENTIALS_FULL_URI",
+		"AWS_CONTAINER_AUTHORIZATION_TOKEN", "AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE",
+	} {
+		t.Setenv(name, "")
+	}
+
+	dir := t.TempDir()
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+}
+
+func TestDefaultCredentialsSharedFile(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("AWS_PROFILE", "ops")
+
+	data := "[default]\naws_access_key_id = DEFAULT\naws_secret_access_key = x\n\n" +
+		"[ops]\naws_access_key_id = OPSKEY\naws_secret_access_key = OPSSECRET\n"
+	if err := os.WriteFile(os.Getenv("AWS_SHARED_CREDENTIALS_FILE"), []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	config := "[profile ops]\nregion = eu-west-1\n"
+	if err := os.WriteFile(os.Getenv("AWS_CONFIG_FILE"), []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	creds, err := DefaultCredentials(context.Background(), http.DefaultClient)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if creds.AccessKeyID != "OPSKEY" || creds.SecretAccessKey != "OPSSECRET" {
+		t.Errorf("unexpected credentials: %+v", creds)
+	}
+
+	region, err := DefaultRegion(context.Background(), http.DefaultClient)
+	if err != nil || region != "eu-west-1" {
+		t.Errorf("got region %q, error %v", region, err)
+	}
+}
+
+func TestDefaultCredentialsContainer(t *testing.T) {
+	clearEnv(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "container-token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"AccessKeyId":"AKID","SecretAccessKey":"SECRET","Token":"SESSION"}`)
+	}))
+	defer srv.Close()
+
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", srv.URL+"/creds")
+	t.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN", "container-token")
+
+	creds, err := DefaultCredentials(context.Background(), srv.Client())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := Credentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET", SessionToken: "SESSION"}
+	if creds != want {
+		t.Errorf("got %+v, want %+v", creds, want)
+	}
+}
+
+func TestDefaultCredentialsInstance(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/latest/api/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		fmt.Fprint(w, "imds-token")
+	})
+	mux.HandleFunc("/latest/meta-data/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Aws-Ec2-Metadata-Token") != "imds-token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/latest/meta-data/iam/security-credentials/":
+			fmt.Fprint(w, "app-role")
+		case "/latest/meta-data/iam/security-credentials/app-role":
+			fmt.Fprint(w, `{"AccessKeyId":"ROLEKEY","SecretAccessKey":"ROLESECRET","Token":"ROLETOKEN"}`)
+		case "/latest/meta-data/placement/region":
+			fmt.Fprint(w, "us-east-2")
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	orig := imdsEndpoint
+	imdsEndpoint = srv.URL
+	defer func() { imdsEndpoint = orig }()
+
+	creds, err := DefaultCredentials(context.Background(), srv.Client())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if creds.AccessKeyID != "ROLEKEY" || creds.SessionToken != "ROLETOKEN" {
+		t.Errorf("unexpected credentials: %+v", creds)
+	}
+
+	region, err := DefaultRegion(context.Background(), srv.Client())
+	if err != nil || region != "us-east-2" {
+		t.Errorf("got region %q, error %v", region, err)
+	}
+}
+
+func TestDefaultCredentialsMissing(t *testing.T) {
+	clearEnv(t)
+
+	_, err := DefaultCredentials(context.Background(), http.DefaultClient)
+	if !errors.Is(err, ErrMissingCredentials) {
+		t.Errorf("expected ErrMissingCredentials, got %v", err)
+	}
+}
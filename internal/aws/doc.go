@@ -0,0 +1,13 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package aws provides a minimal implementation of the AWS credential lookup and
+Signature Version 4 request signing used to call AWS service APIs without
+depending on the AWS SDK.
+*/
+package aws
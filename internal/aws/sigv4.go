@@ -0,0 +1,238 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package aws
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrMissingCredentials indicates that AWS credentials could not be found.
+var ErrMissingCredentials = errors.New("AWS credentials not found")
+
+// ErrMissingRegion indicates that the AWS region could not be determined.
+var ErrMissingRegion = errors.New("AWS region not specified")
+
+// signingAlgorithm is the Signature Version 4 algorithm identifier.
+const signingAlgorithm string = "AWS4-HMAC-SHA256"
+
+// timeFormat is the format used for the X-Amz-Date header.
+const timeFormat string = "20060102T150405Z"
+
+// dateFormat is the format used for the credential scope date.
+const dateFormat string = "20060102"
+
+// Credentials is a set of AWS security credentials.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// Expiration is when temporary credentials (e.g., those retrieved for a
+	// container or an EC2 instance) expire. Zero if the credentials do not
+	// expire.
+	Expiration time.Time
+}
+
+// ExpiresWithin indicates whether the credentials expire within the given
+// duration of the given time.
+func (c Credentials) ExpiresWithin(d time.Duration, now time.Time) bool {
+	return !c.Expiration.IsZero() && !now.Add(d).Before(c.Expiration)
+}
+
+// CredentialsFromEnv retrieves AWS credentials from the standard
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and (optional) AWS_SESSION_TOKEN
+// environment variables.
+func CredentialsFromEnv() (Credentials, error) {
+	creds := Credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return Credentials{}, fmt.Errorf(
+			"%w: AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set",
+			ErrMissingCredentials,
+		)
+	}
+
+	return creds, nil
+}
+
+// RegionFromEnv retrieves the AWS region from the standard AWS_REGION or
+// AWS_DEFAULT_REGION environment variables.
+func RegionFromEnv() (string, error) {
+	for _, name := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if region := os.Getenv(name); region != "" {
+			return region, nil
+		}
+	}
+
+	return "", fmt.Errorf(
+		"%w: AWS_REGION or AWS_DEFAULT_REGION must be set",
+		ErrMissingRegion,
+	)
+}
+
+// SignRequest signs the given request for the specified region and service
+// using AWS Signature Version 4. The SHA-256 hash of the request body is
+// provided by the caller. Services which require it (e.g., S3) expect the
+// caller to also set the X-Amz-Content-Sha256 header before signing.
+func SignRequest(req *http.Request, payloadHash string, creds Credentials, region string, service string, now time.Time) {
+	now = now.UTC()
+
+	req.Header.Set("X-Amz-Date", now.Format(timeFormat))
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	host := req.URL.Host
+	if req.Host != "" {
+		host = req.Host
+	}
+
+	canonicalHeaders, signedHeaders := canonicalizeHeaders(req.Header, host)
+
+	canonicalURI := req.URL.EscapedPath()
+	if canonicalURI == "" {
+		canonicalURI = "/"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		canonicalQuery(req),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{
+		now.Format(dateFormat),
+		region,
+		service,
+		"aws4_request",
+	}, "/")
+
+	stringToSign := strings.Join([]string{
+		signingAlgorithm,
+		now.Format(timeFormat),
+		scope,
+		HashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), now.Format(dateFormat))
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signingAlgorithm,
+		creds.AccessKeyID,
+		scope,
+		signedHeaders,
+		signature,
+	))
+}
+
+// HashHex returns the hex encoded SHA-256 hash of the given data.
+func HashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of the given data using the given key.
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
+
+// canonicalizeHeaders returns the canonical headers block and the list of
+// signed headers for the given request headers and host.
+func canonicalizeHeaders(header http.Header, host string) (string, string) {
+	values := map[string]string{"host": strings.TrimSpace(host)}
+
+	for name, vals := range header {
+		lower := strings.ToLower(name)
+		if lower != "content-type" && !strings.HasPrefix(lower, "x-amz-") {
+			continue
+		}
+
+		trimmed := make([]string, 0, len(vals))
+		for _, v := range vals {
+			trimmed = append(trimmed, strings.Join(strings.Fields(v), " "))
+		}
+		values[lower] = strings.Join(trimmed, ",")
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonical strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&canonical, "%s:%s\n", name, values[name])
+	}
+
+	return canonical.String(), strings.Join(names, ";")
+}
+
+// canonicalQuery returns the canonical query string for the given request.
+func canonicalQuery(req *http.Request) string {
+	query := req.URL.Query()
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		vals := query[key]
+		sort.Strings(vals)
+		for _, val := range vals {
+			pairs = append(pairs, uriEncode(key)+"="+uriEncode(val))
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode applies the URI encoding rules required by Signature Version 4.
+func uriEncode(s string) string {
+	var encoded strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			encoded.WriteByte(c)
+		default:
+			fmt.Fprintf(&encoded, "%%%02X", c)
+		}
+	}
+
+	return encoded.String()
+}
@@ -0,0 +1,40 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package aws
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestSignRequestVanilla uses the "get-vanilla" case from the AWS Signature
+// Version 4 test suite to verify request signing.
+func TestSignRequestVanilla(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+
+	creds := Credentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+	SignRequest(req, HashHex(nil), creds, "us-east-1", "service", now)
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, " +
+		"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/atc0005/go-teams-notify/v2/adaptivecard"
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// MaxEntrySize is the maximum size (in bytes) of a single line of a batch
+// file.
+const MaxEntrySize int64 = 256 * 1024
+
+// commentPrefix indicates that a line of a batch file is a comment.
+const commentPrefix string = "#"
+
+// Colors are the title colors which may be specified for a message.
+var Colors = []string{
+	adaptivecard.ColorDefault,
+	adaptivecard.ColorDark,
+	adaptivecard.ColorLight,
+	adaptivecard.ColorAccent,
+	adaptivecard.ColorGood,
+	adaptivecard.ColorWarning,
+	adaptivecard.ColorAttention,
+}
+
+// ErrInvalidEntry indicates that a line of a batch file does not describe a
+// valid message.
+var ErrInvalidEntry = errors.New("invalid batch entry")
+
+// Entry is a single message definition read from a batch file.
+type Entry struct {
+	teams.Message
+
+	// Color is the (optional) Adaptive Card color (e.g., "good",
+	// "attention") applied to the message title.
+	Color string `json:"color,omitempty"`
+
+	// WebhookURL is the (optional) webhook URL used to submit the message
+	// in place of the user-specified webhook URL.
+	WebhookURL string `json:"webhook_url,omitempty"`
+}
+
+// Validate asserts that the entry describes a message which may be sent.
+func (e Entry) Validate() error {
+	if strings.TrimSpace(e.Text) == "" {
+		return fmt.Errorf("%w: text not specified", ErrInvalidEntry)
+	}
+
+	if e.Color == "" {
+		return nil
+	}
+
+	for _, color := range Colors {
+		if strings.EqualFold(e.Color, color) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf(
+		"%w: unsupported color %q; expected one of %s",
+		ErrInvalidEntry,
+		e.Color,
+		strings.Join(Colors, ", "),
+	)
+}
+
+// Reader reads the entries of a batch file.
+type Reader struct {
+	reader *bufio.Reader
+	line   int
+}
+
+// NewReader returns a Reader reading the entries of the given batch file.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{reader: bufio.NewReader(r)}
+}
+
+// Line returns the line number of the most recently read entry.
+func (r *Reader) Line() int {
+	return r.line
+}
+
+// Next returns the next entry of the batch file, or io.EOF once all entries
+// have been read. An error wrapping ErrInvalidEntry is returned for a line
+// which does not describe a valid message; reading may continue with the
+// following line. Any other error is fatal.
+func (r *Reader) Next() (Entry, error) {
+	for {
+		line, tooLong, err := r.readLine()
+		if err != nil {
+			return Entry{}, err
+		}
+		r.line++
+
+		if tooLong {
+			return Entry{}, fmt.Errorf("%w: line exceeds %d bytes", ErrInvalidEntry, MaxEntrySize)
+		}
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || bytes.HasPrefix(line, []byte(commentPrefix)) {
+			continue
+		}
+
+		return ParseEntry(line)
+	}
+}
+
+// ParseEntry returns the entry described by the given JSON object. An error
+// wrapping ErrInvalidEntry is returned if the object does not describe a
+// valid message.
+func ParseEntry(data []byte) (Entry, error) {
+	var entry Entry
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&entry); err != nil {
+		return Entry{}, fmt.Errorf("%w: %v", ErrInvalidEntry, err)
+	}
+
+	if decoder.More() {
+		return Entry{}, fmt.Errorf("%w: unexpected content after JSON object", ErrInvalidEntry)
+	}
+
+	entry.Color = strings.ToLower(entry.Color)
+
+	return entry, entry.Validate()
+}
+
+// readLine returns the next line without the line ending, indicating
+// whether the line was discarded for exceeding MaxEntrySize. The final line
+// need not end with a newline.
+func (r *Reader) readLine() ([]byte, bool, error) {
+	var line []byte
+	tooLong := false
+
+	for {
+		chunk, isPrefix, err := r.reader.ReadLine()
+		if err != nil {
+			return nil, false, err
+		}
+
+		if !tooLong {
+			line = append(line, chunk...)
+			if int64(len(line)) > MaxEntrySize {
+				line, tooLong = nil, true
+			}
+		}
+
+		if !isPrefix {
+			return line, tooLong, nil
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package batch
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReader(t *testing.T) {
+	input := strings.Join([]string{
+		`# nightly jobs`,
+		`{"title": "Backup", "text": "Backup complete", "color": "Good"}`,
+		``,
+		`{"text": "Disk full", "webhook_url": "https://example.com/hook"}`,
+		`{"title": "Missing text"}`,
+		`not json`,
+		`{"text": "Unknown color", "color": "purple"}`,
+		`{"text": "Typo", "colour": "good"}`,
+		`{"text": "Last line without newline"}`,
+	}, "\n")
+
+	type result struct {
+		line    int
+		text    string
+		invalid bool
+	}
+
+	want := []result{
+		{line: 2, text: "Backup complete"},
+		{line: 4, text: "Disk full"},
+		{line: 5, invalid: true},
+		{line: 6, invalid: true},
+		{line: 7, text: "Unknown color", invalid: true},
+		{line: 8, invalid: true},
+		{line: 9, text: "Last line without newline"},
+	}
+
+	r := NewReader(strings.NewReader(input))
+
+	var got []result
+	for {
+		entry, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil && !errors.Is(err, ErrInvalidEntry) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		got = append(got, result{line: r.Line(), text: entry.Text, invalid: err != nil})
+
+		if r.Line() == 2 && (entry.Title != "Backup" || entry.Color != "good") {
+			t.Errorf("line 2: got title %q and color %q", entry.Title, entry.Color)
+		}
+		if r.Line() == 4 && entry.WebhookURL != "https://example.com/hook" {
+			t.Errorf("line 4: got webhook URL %q", entry.WebhookURL)
+		}
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d entries (%+v); want %d", len(got), got, len(want))
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d: got %+v; want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestReaderLineTooLong(t *testing.T) {
+	long := `{"text": "` + strings.Repeat("x", int(MaxEntrySize)) + `"}`
+	r := NewReader(strings.NewReader(long + "\n" + `{"text": "next"}` + "\n"))
+
+	if _, err := r.Next(); !errors.Is(err, ErrInvalidEntry) {
+		t.Fatalf("got error %v; want %v", err, ErrInvalidEntry)
+	}
+
+	entry, err := r.Next()
+	if err != nil || entry.Text != "next" || r.Line() != 2 {
+		t.Fatalf("got entry %+v, line %d and error %v", entry, r.Line(), err)
+	}
+}
@@ -0,0 +1,18 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package batch reads the message definitions delivered by the batch
+subcommand.
+
+Each line of a batch file is a JSON object (newline delimited JSON)
+describing a single message: the title, text and title color, along with an
+(optional) webhook URL overriding the user-specified webhook URL. Blank
+lines and lines starting with # are ignored. An invalid line does not
+prevent the remaining messages from being read.
+*/
+package batch
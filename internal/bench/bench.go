@@ -0,0 +1,124 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxInFlight is the maximum number of operations in progress at once. The
+// achieved rate falls below the requested rate if this limit is reached.
+const maxInFlight int = 1000
+
+// ErrInvalidRate indicates that a rate specification could not be parsed.
+var ErrInvalidRate = errors.New("invalid rate")
+
+// Rate is the number of operations started per second.
+type Rate float64
+
+// ParseRate parses a rate given as a count per unit of time (e.g., 50/s,
+// 300/m, 1000/h). A count without a unit is taken as per second.
+func ParseRate(s string) (Rate, error) {
+	count, unit, found := strings.Cut(strings.TrimSpace(s), "/")
+
+	per := time.Second
+	if found {
+		switch strings.TrimSpace(unit) {
+		case "s":
+		case "m":
+			per = time.Minute
+		case "h":
+			per = time.Hour
+		default:
+			return 0, fmt.Errorf("%w %q: unsupported unit %q; expected one of s, m or h", ErrInvalidRate, s, unit)
+		}
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(count), 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%w %q: count must be a positive number", ErrInvalidRate, s)
+	}
+
+	return Rate(n / per.Seconds()), nil
+}
+
+// interval returns the time between the start of each operation.
+func (r Rate) interval() time.Duration {
+	return time.Duration(float64(time.Second) / float64(r))
+}
+
+// Run starts the given operation at the given rate until the given duration
+// elapses or the context is cancelled, then waits for operations in
+// progress to complete. The outcome of every operation is summarized in the
+// returned Report.
+func Run(ctx context.Context, rate Rate, duration time.Duration, op func(ctx context.Context) error) Report {
+	ctx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var report Report
+
+	slots := make(chan struct{}, maxInFlight)
+	ticker := time.NewTicker(rate.interval())
+	defer ticker.Stop()
+
+	start := time.Now()
+
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case slots <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			// Operations are not bound to the run duration so that those in
+			// progress when it elapses are allowed to complete.
+			opStart := time.Now()
+			err := op(context.Background())
+			latency := time.Since(opStart)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			report.latencies = append(report.latencies, latency)
+			if err != nil {
+				report.Failed++
+				if report.FirstError == "" {
+					report.FirstError = err.Error()
+				}
+			}
+		}()
+
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-ticker.C:
+		}
+	}
+
+	wg.Wait()
+
+	report.Requested = float64(rate)
+	report.Elapsed = time.Since(start)
+	report.summarize()
+
+	return report
+}
@@ -0,0 +1,89 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package bench
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Rate
+		wantErr bool
+	}{
+		{input: "50/s", want: 50},
+		{input: "50", want: 50},
+		{input: "120/m", want: 2},
+		{input: "3600/h", want: 1},
+		{input: "0.5/s", want: 0.5},
+		{input: "0/s", wantErr: true},
+		{input: "-1", wantErr: true},
+		{input: "50/d", wantErr: true},
+		{input: "fast", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseRate(tt.input)
+			switch {
+			case tt.wantErr && !errors.Is(err, ErrInvalidRate):
+				t.Errorf("ParseRate(%q) error = %v, want %v", tt.input, err, ErrInvalidRate)
+			case !tt.wantErr && err != nil:
+				t.Errorf("ParseRate(%q) unexpected error: %v", tt.input, err)
+			case !tt.wantErr && got != tt.want:
+				t.Errorf("ParseRate(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+
+	for p, want := range map[float64]time.Duration{
+		50:  50 * time.Millisecond,
+		99:  99 * time.Millisecond,
+		100: 100 * time.Millisecond,
+	} {
+		if got := percentile(sorted, p); got != want {
+			t.Errorf("percentile(%v) = %v, want %v", p, got, want)
+		}
+	}
+
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("percentile of no latencies = %v, want 0", got)
+	}
+}
+
+func TestRun(t *testing.T) {
+	failure := errors.New("failed")
+
+	var calls atomic.Int64
+	report := Run(context.Background(), 100, 200*time.Millisecond, func(ctx context.Context) error {
+		if calls.Add(1) == 1 {
+			return failure
+		}
+		return nil
+	})
+
+	if report.Total == 0 || int64(report.Total) != calls.Load() {
+		t.Fatalf("report total = %d, want %d (and non-zero)", report.Total, calls.Load())
+	}
+
+	if report.Failed != 1 || report.FirstError != failure.Error() {
+		t.Errorf("report failures = %d (%q), want 1 (%q)", report.Failed, report.FirstError, failure)
+	}
+}
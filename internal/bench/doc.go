@@ -0,0 +1,13 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package bench generates load at a fixed rate for a fixed duration and
+reports the resulting throughput and latency percentiles. It is used to
+size a relay for bursts of messages such as alert storms.
+*/
+package bench
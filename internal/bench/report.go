@@ -0,0 +1,121 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Percentiles is a collection of latency percentiles.
+type Percentiles struct {
+	P50 time.Duration `json:"p50"`
+	P90 time.Duration `json:"p90"`
+	P95 time.Duration `json:"p95"`
+	P99 time.Duration `json:"p99"`
+	Max time.Duration `json:"max"`
+}
+
+// Report summarizes the outcome of a benchmark run.
+type Report struct {
+
+	// Requested is the requested number of operations per second.
+	Requested float64 `json:"requested_rate"`
+
+	// Throughput is the achieved number of completed operations per
+	// second.
+	Throughput float64 `json:"throughput"`
+
+	// Elapsed is the total time taken by the run, including the time
+	// taken for operations in progress at the end of the run to complete.
+	Elapsed time.Duration `json:"elapsed"`
+
+	// Total is the number of operations performed.
+	Total int `json:"total"`
+
+	// Failed is the number of operations which returned an error.
+	Failed int `json:"failed"`
+
+	// FirstError is the error returned by the first failed operation.
+	FirstError string `json:"first_error,omitempty"`
+
+	// Latency is the distribution of operation latencies.
+	Latency Percentiles `json:"latency"`
+
+	latencies []time.Duration
+}
+
+// summarize calculates the throughput and latency percentiles for the
+// recorded operations.
+func (r *Report) summarize() {
+	r.Total = len(r.latencies)
+	if r.Elapsed > 0 {
+		r.Throughput = float64(r.Total) / r.Elapsed.Seconds()
+	}
+
+	sort.Slice(r.latencies, func(i, j int) bool { return r.latencies[i] < r.latencies[j] })
+
+	r.Latency = Percentiles{
+		P50: percentile(r.latencies, 50),
+		P90: percentile(r.latencies, 90),
+		P95: percentile(r.latencies, 95),
+		P99: percentile(r.latencies, 99),
+		Max: percentile(r.latencies, 100),
+	}
+}
+
+// percentile returns the given percentile of the sorted latencies using the
+// nearest-rank method.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
+
+// Text returns a human-readable description of the report.
+func (r Report) Text() string {
+	var output strings.Builder
+
+	fmt.Fprintf(&output, "Requested rate: %.2f/s\n", r.Requested)
+	fmt.Fprintf(&output, "Achieved throughput: %.2f/s\n", r.Throughput)
+	fmt.Fprintf(&output, "Elapsed: %s\n", r.Elapsed.Round(time.Millisecond))
+	fmt.Fprintf(&output, "Messages: %d sent, %d failed\n", r.Total-r.Failed, r.Failed)
+	fmt.Fprintf(
+		&output,
+		"Latency: p50 %s, p90 %s, p95 %s, p99 %s, max %s\n",
+		r.Latency.P50.Round(time.Microsecond),
+		r.Latency.P90.Round(time.Microsecond),
+		r.Latency.P95.Round(time.Microsecond),
+		r.Latency.P99.Round(time.Microsecond),
+		r.Latency.Max.Round(time.Microsecond),
+	)
+
+	if r.FirstError != "" {
+		fmt.Fprintf(&output, "First error: %s\n", r.FirstError)
+	}
+
+	return output.String()
+}
+
+// Write emits the report in JSON format to the given writer. Durations are
+// given in nanoseconds.
+func (r Report) Write(w io.Writer) error {
+	return json.NewEncoder(w).Encode(r)
+}
@@ -0,0 +1,161 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// States of a Breaker.
+const (
+	StateClosed   string = "closed"
+	StateOpen     string = "open"
+	StateHalfOpen string = "half-open"
+)
+
+// ErrOpen indicates that a submission was rejected since the circuit
+// breaker is open.
+var ErrOpen = errors.New("circuit breaker is open")
+
+// Status describes the state of a Breaker.
+type Status struct {
+
+	// State is the state of the Breaker: one of StateClosed, StateOpen or
+	// StateHalfOpen.
+	State string `json:"state"`
+
+	// ConsecutiveFailures is the number of failures recorded since the last
+	// success.
+	ConsecutiveFailures int `json:"consecutive_failures"`
+
+	// OpenedAt is when the Breaker last opened. Zero if closed.
+	OpenedAt time.Time `json:"opened_at,omitempty"`
+
+	// ProbeAt is when a probe submission is next allowed. Zero if closed.
+	ProbeAt time.Time `json:"probe_at,omitempty"`
+}
+
+// Breaker is a circuit breaker for a single webhook URL. A nil Breaker
+// allows every submission. A Breaker is safe for concurrent use.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// New creates a Breaker which opens after the given number of consecutive
+// failures and allows a probe submission once the given cooldown period has
+// elapsed. A nil Breaker (allowing every submission) is returned if the
+// threshold is less than one.
+func New(threshold int, cooldown time.Duration) *Breaker {
+	if threshold < 1 {
+		return nil
+	}
+
+	return &Breaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// Allow indicates whether a submission may proceed, returning ErrOpen if
+// not. Each allowed submission must be followed by a call to Record or
+// Abandon.
+func (b *Breaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state() {
+	case StateClosed:
+		return nil
+	case StateHalfOpen:
+		if !b.probing {
+			b.probing = true
+			return nil
+		}
+	}
+
+	return ErrOpen
+}
+
+// Record records the outcome of an allowed submission: a nil error closes
+// the Breaker, while a failure opens it once the threshold is reached (or
+// immediately if the submission was a probe).
+func (b *Breaker) Record(err error) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	wasProbe := b.probing
+	b.probing = false
+
+	if err == nil {
+		b.failures = 0
+		b.openedAt = time.Time{}
+		return
+	}
+
+	b.failures++
+	if wasProbe || b.failures >= b.threshold {
+		b.openedAt = b.now()
+	}
+}
+
+// Abandon releases an allowed submission without recording an outcome
+// (e.g., if the submission was cancelled by the caller), allowing another
+// probe if the Breaker is half-open.
+func (b *Breaker) Abandon() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	b.probing = false
+	b.mu.Unlock()
+}
+
+// Status returns the current state of the Breaker.
+func (b *Breaker) Status() Status {
+	if b == nil {
+		return Status{State: StateClosed}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := Status{State: b.state(), ConsecutiveFailures: b.failures}
+	if !b.openedAt.IsZero() {
+		status.OpenedAt = b.openedAt
+		status.ProbeAt = b.openedAt.Add(b.cooldown)
+	}
+
+	return status
+}
+
+// state returns the current state. The caller must hold the lock.
+func (b *Breaker) state() string {
+	switch {
+	case b.openedAt.IsZero():
+		return StateClosed
+	case b.now().Before(b.openedAt.Add(b.cooldown)):
+		return StateOpen
+	default:
+		return StateHalfOpen
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package breaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	b := New(3, time.Minute)
+	b.now = func() time.Time { return now }
+
+	failure := errors.New("connector unavailable")
+
+	// The breaker opens after the threshold is reached.
+	for i := 0; i < 3; i++ {
+		if err := b.Allow(); err != nil {
+			t.Fatalf("attempt %d rejected: %v", i+1, err)
+		}
+		b.Record(failure)
+	}
+
+	if got := b.Status().State; got != StateOpen {
+		t.Fatalf("got state %q after %d failures; want %q", got, 3, StateOpen)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Fatalf("got %v while open; want %v", err, ErrOpen)
+	}
+
+	// A single probe is allowed once the cooldown elapses; a failed probe
+	// opens the breaker again.
+	now = now.Add(time.Minute)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe rejected: %v", err)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Fatalf("got %v for second concurrent probe; want %v", err, ErrOpen)
+	}
+	b.Record(failure)
+
+	if got := b.Status().State; got != StateOpen {
+		t.Fatalf("got state %q after failed probe; want %q", got, StateOpen)
+	}
+
+	// A successful probe closes the breaker.
+	now = now.Add(time.Minute)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe rejected: %v", err)
+	}
+	b.Record(nil)
+
+	if status := b.Status(); status.State != StateClosed || status.ConsecutiveFailures != 0 {
+		t.Errorf("got status %+v after successful probe; want closed without failures", status)
+	}
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	b := New(0, time.Minute)
+	for i := 0; i < 10; i++ {
+		if err := b.Allow(); err != nil {
+			t.Fatalf("disabled breaker rejected attempt: %v", err)
+		}
+		b.Record(errors.New("failed"))
+	}
+
+	if got := b.Status().State; got != StateClosed {
+		t.Errorf("got state %q; want %q", got, StateClosed)
+	}
+}
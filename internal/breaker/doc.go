@@ -0,0 +1,17 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package breaker provides a circuit breaker used to stop submitting messages
+to a webhook URL which keeps failing.
+
+A Breaker opens after a number of consecutive failures. While open,
+submissions are rejected immediately with ErrOpen. Once the cooldown period
+has elapsed the Breaker is half-open: a single probe submission is allowed,
+closing the Breaker if it succeeds or opening it again if it fails.
+*/
+package breaker
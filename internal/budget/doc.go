@@ -0,0 +1,17 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package budget provides a local, file-based ledger used to limit the number
+of messages sent to a webhook URL within a time period.
+
+Endpoints such as Power Automate workflows consume a flow run for every
+message received. A send budget keeps licensing costs predictable when many
+messages are generated in a short time (e.g., during an alert storm). The
+ledger is shared by all invocations using the same directory and webhook URL.
+*/
+package budget
@@ -0,0 +1,343 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package budget
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Supported actions taken for messages submitted while over budget.
+const (
+
+	// ActionDrop indicates that the message is discarded.
+	ActionDrop string = "drop"
+
+	// ActionSpool indicates that the message is retained and sent by a later
+	// invocation once the budget allows.
+	ActionSpool string = "spool"
+
+	// ActionSummarize indicates that the message is discarded, but noted in
+	// the next message sent once the budget allows.
+	ActionSummarize string = "summarize"
+)
+
+// Names of the files and directories within a ledger directory.
+const (
+	stateFileName string = "state.json"
+	lockFileName  string = "state.lock"
+	spoolDirName  string = "spool"
+)
+
+// lockRetryInterval is the amount of time to wait between attempts to
+// acquire the ledger lock.
+const lockRetryInterval time.Duration = 50 * time.Millisecond
+
+// lockTimeout is the maximum amount of time to wait for the ledger lock.
+const lockTimeout time.Duration = 10 * time.Second
+
+// lockStaleAge is the age after which a ledger lock is assumed to have been
+// abandoned (e.g., by a terminated process) and is removed.
+const lockStaleAge time.Duration = time.Minute
+
+// ErrLockTimeout indicates that the ledger lock could not be acquired.
+var ErrLockTimeout = errors.New("timeout acquiring send budget lock")
+
+// Limits is the maximum number of messages which may be sent within each
+// time period. A zero value indicates no limit for that period.
+type Limits struct {
+	PerHour int
+	PerDay  int
+
+	// PerPeriod is the maximum number of messages which may be sent within
+	// any one Period.
+	PerPeriod int
+	Period    time.Duration
+}
+
+// Enabled indicates whether any limits are set.
+func (l Limits) Enabled() bool {
+	return l.PerHour > 0 || l.PerDay > 0 || l.PerPeriod > 0
+}
+
+// retention returns how long sends count against any limit.
+func (l Limits) retention() time.Duration {
+	if l.Period > 24*time.Hour {
+		return l.Period
+	}
+
+	return 24 * time.Hour
+}
+
+// Suppression records a message discarded while over budget.
+type Suppression struct {
+	Time      time.Time `json:"time"`
+	Title     string    `json:"title"`
+	ReceiptID string    `json:"receipt_id"`
+}
+
+// Spooled is a message retained while over budget.
+type Spooled struct {
+	ReceiptID string
+	Payload   []byte
+
+	// name is the name of the file the message was spooled to.
+	name string
+
+	// recorded is when the message was counted as sent.
+	recorded time.Time
+}
+
+// state is the persisted content of a ledger.
+type state struct {
+	Sends      []time.Time   `json:"sends"`
+	Suppressed []Suppression `json:"suppressed,omitempty"`
+}
+
+// Ledger tracks messages sent to a webhook URL. A Ledger holds an exclusive
+// lock on its directory until closed.
+type Ledger struct {
+	dir    string
+	limits Limits
+	state  state
+}
+
+// Open acquires the ledger for the given webhook URL within the given base
+// directory, creating it if necessary. The returned Ledger must be closed to
+// persist changes and release the lock.
+func Open(baseDir string, webhookURL string, limits Limits) (*Ledger, error) {
+	sum := sha256.Sum256([]byte(webhookURL))
+	dir := filepath.Join(baseDir, hex.EncodeToString(sum[:]))
+
+	if err := os.MkdirAll(filepath.Join(dir, spoolDirName), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create send budget directory: %w", err)
+	}
+
+	if err := lock(filepath.Join(dir, lockFileName)); err != nil {
+		return nil, err
+	}
+
+	l := Ledger{dir: dir, limits: limits}
+
+	data, err := os.ReadFile(filepath.Join(dir, stateFileName))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		l.unlock()
+		return nil, fmt.Errorf("failed to read send budget state: %w", err)
+	default:
+		if err := json.Unmarshal(data, &l.state); err != nil {
+			l.unlock()
+			return nil, fmt.Errorf("failed to decode send budget state: %w", err)
+		}
+	}
+
+	return &l, nil
+}
+
+// lock creates the given lock file, waiting for any other holder to release
+// it. Lock files older than lockStaleAge are removed.
+func lock(path string) error {
+	deadline := time.Now().Add(lockTimeout)
+
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			return f.Close()
+		}
+
+		if !errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("failed to acquire send budget lock: %w", err)
+		}
+
+		if info, statErr := os.Stat(path); statErr == nil && time.Since(info.ModTime()) > lockStaleAge {
+			_ = os.Remove(path)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return ErrLockTimeout
+		}
+
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// unlock releases the ledger lock.
+func (l *Ledger) unlock() {
+	_ = os.Remove(filepath.Join(l.dir, lockFileName))
+}
+
+// Close persists the ledger state and releases the lock.
+func (l *Ledger) Close() error {
+	defer l.unlock()
+
+	data, err := json.Marshal(l.state)
+	if err != nil {
+		return fmt.Errorf("failed to encode send budget state: %w", err)
+	}
+
+	path := filepath.Join(l.dir, stateFileName)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to save send budget state: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to save send budget state: %w", err)
+	}
+
+	return nil
+}
+
+// prune discards sends which no longer count against any limit.
+func (l *Ledger) prune(now time.Time) {
+	cutoff := now.Add(-l.limits.retention())
+
+	sends := l.state.Sends[:0]
+	for _, sent := range l.state.Sends {
+		if sent.After(cutoff) {
+			sends = append(sends, sent)
+		}
+	}
+	l.state.Sends = sends
+}
+
+// countSince returns the number of sends recorded after the given time.
+func (l *Ledger) countSince(t time.Time) int {
+	var count int
+	for _, sent := range l.state.Sends {
+		if sent.After(t) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Allow indicates whether another message may be sent at the given time
+// without exceeding the limits.
+func (l *Ledger) Allow(now time.Time) bool {
+	l.prune(now)
+
+	if l.limits.PerHour > 0 && l.countSince(now.Add(-time.Hour)) >= l.limits.PerHour {
+		return false
+	}
+
+	if l.limits.PerDay > 0 && l.countSince(now.Add(-24*time.Hour)) >= l.limits.PerDay {
+		return false
+	}
+
+	if l.limits.PerPeriod > 0 && l.countSince(now.Add(-l.limits.Period)) >= l.limits.PerPeriod {
+		return false
+	}
+
+	return true
+}
+
+// Record counts a message sent at the given time against the limits.
+func (l *Ledger) Record(now time.Time) {
+	l.state.Sends = append(l.state.Sends, now)
+}
+
+// Suppress records a message discarded while over budget.
+func (l *Ledger) Suppress(s Suppression) {
+	l.state.Suppressed = append(l.state.Suppressed, s)
+}
+
+// TakeSuppressed returns and clears the recorded suppressed messages.
+func (l *Ledger) TakeSuppressed() []Suppression {
+	suppressed := l.state.Suppressed
+	l.state.Suppressed = nil
+
+	return suppressed
+}
+
+// Spool retains the given message payload for delivery by a later
+// invocation.
+func (l *Ledger) Spool(now time.Time, receiptID string, payload []byte) error {
+	name := fmt.Sprintf("%020d-%s.json", now.UnixNano(), receiptID)
+
+	if err := os.WriteFile(filepath.Join(l.dir, spoolDirName, name), payload, 0o600); err != nil {
+		return fmt.Errorf("failed to spool message: %w", err)
+	}
+
+	return nil
+}
+
+// TakeSpooled removes and returns the oldest spooled messages, as many as
+// the limits allow, recording each as sent at the given time. Messages which
+// are not delivered must be returned to the spool using Restore.
+func (l *Ledger) TakeSpooled(now time.Time) ([]Spooled, error) {
+	spoolDir := filepath.Join(l.dir, spoolDirName)
+
+	entries, err := os.ReadDir(spoolDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spooled messages: %w", err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	var spooled []Spooled
+	for _, name := range names {
+		if !l.Allow(now) {
+			break
+		}
+
+		path := filepath.Join(spoolDir, name)
+		payload, err := os.ReadFile(path)
+		if err != nil {
+			return spooled, fmt.Errorf("failed to read spooled message: %w", err)
+		}
+
+		if err := os.Remove(path); err != nil {
+			return spooled, fmt.Errorf("failed to remove spooled message: %w", err)
+		}
+
+		_, receiptID, _ := strings.Cut(strings.TrimSuffix(name, ".json"), "-")
+		spooled = append(spooled, Spooled{ReceiptID: receiptID, Payload: payload, name: name, recorded: now})
+		l.Record(now)
+	}
+
+	return spooled, nil
+}
+
+// Restore returns the given messages taken from the spool, but not
+// delivered, to the spool in their original order. The messages no longer
+// count against the limits.
+func (l *Ledger) Restore(items []Spooled) error {
+	for _, item := range items {
+		if err := os.WriteFile(filepath.Join(l.dir, spoolDirName, item.name), item.Payload, 0o600); err != nil {
+			return fmt.Errorf("failed to restore spooled message: %w", err)
+		}
+
+		for i, sent := range l.state.Sends {
+			if sent.Equal(item.recorded) {
+				l.state.Sends = append(l.state.Sends[:i], l.state.Sends[i+1:]...)
+				break
+			}
+		}
+	}
+
+	return nil
+}
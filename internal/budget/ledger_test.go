@@ -0,0 +1,180 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package budget
+
+import (
+	"testing"
+	"time"
+)
+
+const testWebhookURL = "https://example.webhook.office.com/webhookb2/test"
+
+func TestLedgerLimits(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)
+
+	ledger, err := Open(dir, testWebhookURL, Limits{PerHour: 2, PerDay: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if !ledger.Allow(now) {
+			t.Fatalf("send %d unexpectedly over budget", i+1)
+		}
+		ledger.Record(now)
+	}
+
+	if ledger.Allow(now) {
+		t.Fatal("expected hourly limit to be reached")
+	}
+
+	if err := ledger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// State persists across invocations.
+	ledger, err = Open(dir, testWebhookURL, Limits{PerHour: 2, PerDay: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ledger.Close() }()
+
+	later := now.Add(61 * time.Minute)
+	if !ledger.Allow(later) {
+		t.Fatal("expected hourly limit to reset")
+	}
+	ledger.Record(later)
+
+	if ledger.Allow(later) {
+		t.Fatal("expected daily limit to be reached")
+	}
+}
+
+func TestLedgerSpool(t *testing.T) {
+	now := time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)
+
+	ledger, err := Open(t.TempDir(), testWebhookURL, Limits{PerHour: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ledger.Close() }()
+
+	for i, receiptID := range []string{"first", "second", "third"} {
+		if err := ledger.Spool(now.Add(time.Duration(i)*time.Second), receiptID, []byte(receiptID)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	spooled, err := ledger.TakeSpooled(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(spooled) != 2 || spooled[0].ReceiptID != "first" || spooled[1].ReceiptID != "second" {
+		t.Fatalf("got %+v; want first and second spooled messages", spooled)
+	}
+
+	if ledger.Allow(now) {
+		t.Fatal("expected spooled messages to count against the budget")
+	}
+}
+
+func TestLedgerRestore(t *testing.T) {
+	now := time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+
+	ledger, err := Open(dir, testWebhookURL, Limits{PerHour: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, receiptID := range []string{"first", "second"} {
+		if err := ledger.Spool(now.Add(time.Duration(i)*time.Second), receiptID, []byte(receiptID)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	spooled, err := ledger.TakeSpooled(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The second message was not delivered.
+	if err := ledger.Restore(spooled[1:]); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ledger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	ledger, err = Open(dir, testWebhookURL, Limits{PerHour: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ledger.Close() }()
+
+	spooled, err = ledger.TakeSpooled(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(spooled) != 1 || spooled[0].ReceiptID != "second" || string(spooled[0].Payload) != "second" {
+		t.Fatalf("got %+v; want restored second spooled message", spooled)
+	}
+}
+
+func TestLedgerPeriodLimit(t *testing.T) {
+	now := time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)
+
+	ledger, err := Open(t.TempDir(), testWebhookURL, Limits{PerPeriod: 2, Period: 10 * time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ledger.Close() }()
+
+	ledger.Record(now)
+	ledger.Record(now.Add(time.Minute))
+
+	if ledger.Allow(now.Add(5 * time.Minute)) {
+		t.Fatal("expected period limit to be reached")
+	}
+
+	if !ledger.Allow(now.Add(11 * time.Minute)) {
+		t.Fatal("expected period limit to reset")
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		spec   string
+		count  int
+		period time.Duration
+		valid  bool
+	}{
+		{spec: "30/hour", count: 30, period: time.Hour, valid: true},
+		{spec: "5/m", count: 5, period: time.Minute, valid: true},
+		{spec: " 100 / Day ", count: 100, period: 24 * time.Hour, valid: true},
+		{spec: "30"},
+		{spec: "0/hour"},
+		{spec: "30/week"},
+	}
+
+	for _, tt := range tests {
+		count, period, err := ParseLimit(tt.spec)
+		switch {
+		case tt.valid && err != nil:
+			t.Errorf("ParseLimit(%q) unexpected error: %v", tt.spec, err)
+		case !tt.valid && err == nil:
+			t.Errorf("ParseLimit(%q) expected error", tt.spec)
+		case count != tt.count || period != tt.period:
+			t.Errorf("ParseLimit(%q) = %d, %v; want %d, %v", tt.spec, count, period, tt.count, tt.period)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package budget
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidLimit indicates that a limit specification could not be parsed.
+var ErrInvalidLimit = errors.New("invalid limit")
+
+// ParseLimit parses a limit given as a count of messages per time period
+// (e.g., 30/hour, 5/minute, 100/day), returning the count and period.
+func ParseLimit(s string) (int, time.Duration, error) {
+	count, unit, found := strings.Cut(strings.TrimSpace(s), "/")
+	if !found {
+		return 0, 0, fmt.Errorf("%w %q: expected count/period (e.g., 30/hour)", ErrInvalidLimit, s)
+	}
+
+	var period time.Duration
+	switch strings.ToLower(strings.TrimSpace(unit)) {
+	case "m", "min", "minute":
+		period = time.Minute
+	case "h", "hour":
+		period = time.Hour
+	case "d", "day":
+		period = 24 * time.Hour
+	default:
+		return 0, 0, fmt.Errorf("%w %q: unsupported period %q; expected one of minute, hour or day", ErrInvalidLimit, s, unit)
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || n < 1 {
+		return 0, 0, fmt.Errorf("%w %q: count must be a positive whole number", ErrInvalidLimit, s)
+	}
+
+	return n, period, nil
+}
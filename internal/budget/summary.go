@@ -0,0 +1,51 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package budget
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxSummaryTitles is the maximum number of suppressed message titles
+// listed in a summary.
+const maxSummaryTitles int = 10
+
+// Summary returns a Markdown formatted note describing the given suppressed
+// messages. An empty string is returned if no messages were suppressed.
+func Summary(suppressed []Suppression) string {
+	if len(suppressed) == 0 {
+		return ""
+	}
+
+	var output strings.Builder
+
+	fmt.Fprintf(
+		&output,
+		"**Send budget exceeded:** %d message(s) suppressed between %s and %s.",
+		len(suppressed),
+		suppressed[0].Time.UTC().Format("2006-01-02 15:04:05Z"),
+		suppressed[len(suppressed)-1].Time.UTC().Format("2006-01-02 15:04:05Z"),
+	)
+	output.WriteString("\n")
+
+	for i, s := range suppressed {
+		if i == maxSummaryTitles {
+			fmt.Fprintf(&output, "\n- ... and %d more", len(suppressed)-maxSummaryTitles)
+			break
+		}
+
+		title := s.Title
+		if title == "" {
+			title = "(untitled)"
+		}
+		fmt.Fprintf(&output, "\n- %s", title)
+	}
+
+	return output.String()
+}
@@ -0,0 +1,199 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package colorrule
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/atc0005/go-teams-notify/v2/adaptivecard"
+)
+
+// Separators between the pattern and color of a rule. The last separator on
+// a line is used so that patterns may contain either sequence.
+var separators = []string{"→", "=>"}
+
+// ErrInvalidRules indicates that a color rules file is malformed.
+var ErrInvalidRules = errors.New("invalid color rules")
+
+// Rule maps message content matching a pattern to a color.
+type Rule struct {
+
+	// Pattern is matched against the message title and text.
+	Pattern *regexp.Regexp
+
+	// Color is the Adaptive Card color applied if the pattern matches.
+	Color string
+}
+
+// Rules is an ordered collection of color rules. The first matching rule
+// determines the color.
+type Rules []Rule
+
+// Load reads color rules from the given file. Each non-empty line not
+// beginning with a # character specifies a rule as a regular expression
+// and color separated by → or =>, for example:
+//
+//	(?i)failed|error => attention
+//
+// Colors are Adaptive Card color names or hex RGB values (e.g., #cc0000),
+// which are mapped to the closest Adaptive Card color.
+func Load(path string) (Rules, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read color rules %s: %w", path, err)
+	}
+
+	var rules Rules
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule, err := parseRule(line)
+		if err != nil {
+			return nil, fmt.Errorf("%w %s:%d: %v", ErrInvalidRules, path, lineNum, err)
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read color rules %s: %w", path, err)
+	}
+
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("%w %s: no rules defined", ErrInvalidRules, path)
+	}
+
+	return rules, nil
+}
+
+// parseRule parses a single pattern and color pair.
+func parseRule(line string) (Rule, error) {
+	sepIndex, sepLen := -1, 0
+	for _, sep := range separators {
+		if i := strings.LastIndex(line, sep); i > sepIndex {
+			sepIndex, sepLen = i, len(sep)
+		}
+	}
+
+	if sepIndex < 0 {
+		return Rule{}, fmt.Errorf("expected pattern => color, got %q", line)
+	}
+
+	pattern := strings.TrimSpace(line[:sepIndex])
+	if pattern == "" {
+		return Rule{}, fmt.Errorf("pattern not specified")
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return Rule{}, fmt.Errorf("invalid pattern %q: %v", pattern, err)
+	}
+
+	color, err := ParseColor(strings.TrimSpace(line[sepIndex+sepLen:]))
+	if err != nil {
+		return Rule{}, err
+	}
+
+	return Rule{Pattern: re, Color: color}, nil
+}
+
+// Match returns the color of the first rule whose pattern matches the given
+// title or text. An empty string is returned if no rule matches.
+func (r Rules) Match(title string, text string) string {
+	for _, rule := range r {
+		if rule.Pattern.MatchString(title) || rule.Pattern.MatchString(text) {
+			return rule.Color
+		}
+	}
+
+	return ""
+}
+
+// ParseColor returns the Adaptive Card color for the given color name or hex
+// RGB value (e.g., #cc0000 or #c00). Hex values are mapped to the Adaptive
+// Card color with the closest hue: red to attention, orange and yellow to
+// warning, green to good and blue to accent. Grays are mapped to default.
+func ParseColor(s string) (string, error) {
+	name := strings.ToLower(s)
+
+	switch name {
+	case adaptivecard.ColorDefault, adaptivecard.ColorDark, adaptivecard.ColorLight,
+		adaptivecard.ColorAccent, adaptivecard.ColorGood, adaptivecard.ColorWarning,
+		adaptivecard.ColorAttention:
+		return name, nil
+	}
+
+	if !strings.HasPrefix(name, "#") {
+		return "", fmt.Errorf("unsupported color %q; expected an Adaptive Card color name or hex RGB value", s)
+	}
+
+	hex := name[1:]
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+
+	rgb, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 6 {
+		return "", fmt.Errorf("invalid hex color %q", s)
+	}
+
+	return closestColor(
+		float64(rgb>>16&0xff)/255,
+		float64(rgb>>8&0xff)/255,
+		float64(rgb&0xff)/255,
+	), nil
+}
+
+// closestColor returns the Adaptive Card color with the closest hue to the
+// given RGB color (with components between 0 and 1).
+func closestColor(r, g, b float64) string {
+	maxC := math.Max(r, math.Max(g, b))
+	minC := math.Min(r, math.Min(g, b))
+
+	// Colors with little saturation have no meaningful hue.
+	if maxC-minC < 0.15 {
+		return adaptivecard.ColorDefault
+	}
+
+	var hue float64
+	switch maxC {
+	case r:
+		hue = math.Mod((g-b)/(maxC-minC), 6)
+	case g:
+		hue = (b-r)/(maxC-minC) + 2
+	default:
+		hue = (r-g)/(maxC-minC) + 4
+	}
+	hue *= 60
+	if hue < 0 {
+		hue += 360
+	}
+
+	switch {
+	case hue < 20 || hue >= 330:
+		return adaptivecard.ColorAttention
+	case hue < 70:
+		return adaptivecard.ColorWarning
+	case hue < 170:
+		return adaptivecard.ColorGood
+	default:
+		return adaptivecard.ColorAccent
+	}
+}
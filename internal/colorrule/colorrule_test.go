@@ -0,0 +1,82 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package colorrule
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadAndMatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules")
+	content := "# severity rules\n" +
+		"(?i)failed|error → #cc0000\n" +
+		"\n" +
+		"(?i)warn => warning\n" +
+		"(?i)ok|recovered => #2e7d32\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		title string
+		text  string
+		want  string
+	}{
+		{title: "Backup FAILED", want: "attention"},
+		{title: "Backup", text: "warning: disk nearly full", want: "warning"},
+		{title: "Backup recovered", text: "error count 0", want: "attention"},
+		{title: "Backup OK", want: "good"},
+		{title: "Backup", text: "done", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := rules.Match(tt.title, tt.text); got != tt.want {
+			t.Errorf("Match(%q, %q) = %q, want %q", tt.title, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	for _, content := range []string{"", "error\n", "error => purple\n", "( => good\n"} {
+		path := filepath.Join(t.TempDir(), "rules")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := Load(path); !errors.Is(err, ErrInvalidRules) {
+			t.Errorf("Load(%q) error = %v, want ErrInvalidRules", content, err)
+		}
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	tests := map[string]string{
+		"Good":    "good",
+		"#cc0000": "attention",
+		"#f90":    "warning",
+		"#ffd700": "warning",
+		"#00aa00": "good",
+		"#0078d4": "accent",
+		"#808080": "default",
+	}
+
+	for in, want := range tests {
+		got, err := ParseColor(in)
+		if err != nil || got != want {
+			t.Errorf("ParseColor(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+}
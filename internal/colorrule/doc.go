@@ -0,0 +1,13 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package colorrule loads ordered regular expression to color mappings which
+select the color of a card title based on message content, so that scripts
+submitting messages do not need their own severity parsing logic.
+*/
+package colorrule
@@ -0,0 +1,475 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/atc0005/go-teams-notify/v2/adaptivecard"
+	"github.com/atc0005/send2teams/internal/teams"
+	"github.com/atc0005/send2teams/internal/yamlite"
+)
+
+// maxCardFileSize is the maximum size of a YAML card definition file.
+const maxCardFileSize int64 = 256 * 1024
+
+// markdownCardExtensions are the extensions of card definition files which
+// are Markdown documents with (optional) YAML front matter.
+var markdownCardExtensions = []string{".md", ".markdown"}
+
+// frontMatterDelimiter opens and closes the YAML front matter of a Markdown
+// card definition file. The front matter may also be closed by "...".
+const frontMatterDelimiter string = "---"
+
+// cardFileColors are the title colors which may be specified by a card
+// definition file.
+var cardFileColors = []string{
+	adaptivecard.ColorDefault,
+	adaptivecard.ColorDark,
+	adaptivecard.ColorLight,
+	adaptivecard.ColorAccent,
+	adaptivecard.ColorGood,
+	adaptivecard.ColorWarning,
+	adaptivecard.ColorAttention,
+}
+
+// cardDefinition is a card described by a YAML card definition file.
+type cardDefinition struct {
+	title    string
+	text     string
+	color    string
+	facts    []teams.Fact
+	sections []teams.Section
+	actions  []TargetURL
+	mentions []UserMention
+}
+
+// cardErrorf returns an error describing a problem on the given line of a
+// card definition file.
+func cardErrorf(line int, format string, a ...interface{}) error {
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, a...))
+}
+
+// loadCardFile uses the card described by the YAML file specified via the
+// card-file flag as the message. A title specified via the title flag takes
+// precedence over the title of the card, while facts, target URLs and user
+// mentions specified via flags are added to those of the card.
+func (c *Config) loadCardFile() error {
+	if c.CardFile == "" {
+		return nil
+	}
+
+	contentFlags := []struct {
+		name string
+		set  bool
+	}{
+		{"message-base64", c.MessageBase64 != ""},
+		{"message", c.MessageText != ""},
+		{"message-file", c.MessageFile != ""},
+		{"payload-file", c.PayloadFile != ""},
+		{"exec", c.Exec != ""},
+		{"template", c.Template != ""},
+		{"input-format", c.InputFormat != ""},
+		{"map", c.mapRequested()},
+		{"nagios", c.Nagios},
+	}
+
+	for _, f := range contentFlags {
+		if f.set {
+			return fmt.Errorf("unsupported: You cannot specify both the %s and card-file flags", f.name)
+		}
+	}
+
+	f, err := os.Open(c.CardFile)
+	if err != nil {
+		return fmt.Errorf("failed to read card file: %w", err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxCardFileSize+1))
+	switch {
+	case err != nil:
+		return fmt.Errorf("failed to read card file: %w", err)
+	case int64(len(data)) > maxCardFileSize:
+		return fmt.Errorf("card file %s exceeds %d bytes", c.CardFile, maxCardFileSize)
+	}
+
+	parse := parseCardDefinition
+	if isMarkdownCardFile(c.CardFile) {
+		parse = parseMarkdownCard
+	}
+
+	card, err := parse(data)
+	if err != nil {
+		return fmt.Errorf("invalid card file %s: %w", c.CardFile, err)
+	}
+
+	if c.MessageTitle == "" {
+		c.MessageTitle = card.title
+	}
+	c.MessageText = card.text
+	c.inputColor = card.color
+	c.facts = append(c.facts, card.facts...)
+	c.sections = append(c.sections, card.sections...)
+	c.TargetURLs = append(targetURLsStringFlag(card.actions), c.TargetURLs...)
+	c.UserMentions = append(userMentionsStringFlag(card.mentions), c.UserMentions...)
+
+	return nil
+}
+
+// isMarkdownCardFile indicates whether the given card definition file is a
+// Markdown document (based on its extension).
+func isMarkdownCardFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, markdownExt := range markdownCardExtensions {
+		if ext == markdownExt {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseCardDefinition parses and validates the given YAML card definition.
+func parseCardDefinition(data []byte) (cardDefinition, error) {
+	root, err := yamlite.Parse(data)
+	if err != nil {
+		return cardDefinition{}, err
+	}
+
+	card, err := parseCardSettings(root, true)
+	if err != nil {
+		return card, err
+	}
+
+	if strings.TrimSpace(card.text) == "" {
+		return card, cardErrorf(root.Line, "the text setting is required")
+	}
+
+	return card, nil
+}
+
+// parseMarkdownCard parses and validates the given Markdown card definition.
+// The settings of the card other than the text are read from the (optional)
+// YAML front matter, while the remainder of the document is used as the
+// text.
+func parseMarkdownCard(data []byte) (cardDefinition, error) {
+	doc := strings.TrimPrefix(string(data), "\ufeff")
+	lines := strings.SplitAfter(doc, "\n")
+
+	if strings.TrimSpace(lines[0]) != frontMatterDelimiter {
+		card := cardDefinition{text: strings.TrimSpace(doc)}
+		if card.text == "" {
+			return card, cardErrorf(1, "the document body is required")
+		}
+		return card, nil
+	}
+
+	end := 0
+	for i := 1; i < len(lines); i++ {
+		if marker := strings.TrimSpace(lines[i]); marker == frontMatterDelimiter || marker == "..." {
+			end = i
+			break
+		}
+	}
+
+	if end == 0 {
+		return cardDefinition{}, cardErrorf(1, "front matter is not closed by a %s line", frontMatterDelimiter)
+	}
+
+	// The opening delimiter is replaced by an empty line so that reported
+	// line numbers match those of the document.
+	frontMatter := "\n" + strings.Join(lines[1:end], "")
+
+	root, err := yamlite.Parse([]byte(frontMatter))
+	if err != nil {
+		return cardDefinition{}, err
+	}
+
+	// Empty front matter specifies no settings.
+	var card cardDefinition
+	if root.Kind != yamlite.KindNull {
+		if card, err = parseCardSettings(root, false); err != nil {
+			return card, err
+		}
+	}
+
+	card.text = strings.TrimSpace(strings.Join(lines[end+1:], ""))
+	if card.text == "" {
+		return card, cardErrorf(end+2, "the document body is required")
+	}
+
+	return card, nil
+}
+
+// parseCardSettings parses and validates the given card settings. The text
+// setting is rejected unless withText is true.
+func parseCardSettings(root *yamlite.Node, withText bool) (cardDefinition, error) {
+	var card cardDefinition
+
+	if root.Kind != yamlite.KindMapping {
+		return card, cardErrorf(root.Line, "expected a mapping of card settings, got %s", root.Kind)
+	}
+
+	var err error
+
+	for _, pair := range root.Pairs {
+		switch pair.Key {
+		case "title":
+			card.title, err = cardScalar(pair)
+		case "text":
+			if !withText {
+				err = cardErrorf(pair.Line, "the text setting is not supported in front matter; the document body is used as the text")
+				break
+			}
+			card.text, err = cardScalar(pair)
+		case "color":
+			card.color, err = cardColor(pair)
+		case "facts":
+			card.facts, err = cardFacts(pair)
+		case "sections":
+			card.sections, err = cardSections(pair)
+		case "actions":
+			card.actions, err = cardActions(pair)
+		case "mentions":
+			card.mentions, err = cardMentions(pair)
+		default:
+			err = cardErrorf(pair.Line, "unknown card setting %q; expected title, text, color, facts, sections, actions or mentions", pair.Key)
+		}
+
+		if err != nil {
+			return card, err
+		}
+	}
+
+	return card, nil
+}
+
+// cardScalar returns the string value of the given setting without any
+// trailing newlines (e.g., those of literal block scalars). A null value is
+// returned as an empty string.
+func cardScalar(pair yamlite.Pair) (string, error) {
+	switch pair.Value.Kind {
+	case yamlite.KindScalar:
+		return strings.TrimRight(pair.Value.Value, "\r\n"), nil
+	case yamlite.KindNull:
+		return "", nil
+	default:
+		return "", cardErrorf(pair.Value.Line, "expected a string for %q, got %s", pair.Key, pair.Value.Kind)
+	}
+}
+
+// cardColor returns the title color specified by the given setting.
+func cardColor(pair yamlite.Pair) (string, error) {
+	color, err := cardScalar(pair)
+	if err != nil || color == "" {
+		return "", err
+	}
+
+	color = strings.ToLower(color)
+	for _, supported := range cardFileColors {
+		if color == supported {
+			return color, nil
+		}
+	}
+
+	return "", cardErrorf(pair.Value.Line, "unsupported color %q; expected one of %s", color, strings.Join(cardFileColors, ", "))
+}
+
+// cardMapping returns the settings of the given mapping, keyed by name. An
+// error is returned for settings other than those given.
+func cardMapping(node *yamlite.Node, what string, keys ...string) (map[string]string, error) {
+	if node.Kind != yamlite.KindMapping {
+		return nil, cardErrorf(node.Line, "expected a mapping for each %s, got %s", what, node.Kind)
+	}
+
+	values := make(map[string]string, len(node.Pairs))
+	for _, pair := range node.Pairs {
+		known := false
+		for _, key := range keys {
+			known = known || pair.Key == key
+		}
+
+		if !known {
+			return nil, cardErrorf(pair.Line, "unknown %s setting %q; expected %s", what, pair.Key, strings.Join(keys, " or "))
+		}
+
+		value, err := cardScalar(pair)
+		if err != nil {
+			return nil, err
+		}
+		values[pair.Key] = value
+	}
+
+	return values, nil
+}
+
+// cardItems returns the items of the given sequence setting.
+func cardItems(pair yamlite.Pair) ([]*yamlite.Node, error) {
+	switch pair.Value.Kind {
+	case yamlite.KindSequence:
+		return pair.Value.Items, nil
+	case yamlite.KindNull:
+		return nil, nil
+	default:
+		return nil, cardErrorf(pair.Value.Line, "expected a list for %q, got %s", pair.Key, pair.Value.Kind)
+	}
+}
+
+// cardFacts returns the facts specified by the given setting, either as a
+// list of title and value mappings or as a mapping of titles to values.
+func cardFacts(pair yamlite.Pair) ([]teams.Fact, error) {
+	if pair.Value.Kind == yamlite.KindMapping {
+		facts := make([]teams.Fact, 0, len(pair.Value.Pairs))
+		for _, fact := range pair.Value.Pairs {
+			value, err := cardScalar(fact)
+			if err != nil {
+				return nil, err
+			}
+			facts = append(facts, cardFact(fact.Key, value))
+		}
+		return facts, nil
+	}
+
+	items, err := cardItems(pair)
+	if err != nil {
+		return nil, err
+	}
+
+	facts := make([]teams.Fact, 0, len(items))
+	for _, item := range items {
+		values, err := cardMapping(item, "fact", "title", "value")
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.TrimSpace(values["title"]) == "" {
+			return nil, cardErrorf(item.Line, "the title of each fact is required")
+		}
+		facts = append(facts, cardFact(values["title"], values["value"]))
+	}
+
+	return facts, nil
+}
+
+// cardFact returns the fact with the given title and value. As for facts
+// specified via the fact flag, an empty value is displayed as a placeholder.
+func cardFact(title string, value string) teams.Fact {
+	if strings.TrimSpace(value) == "" {
+		value = emptyFactValue
+	}
+
+	return teams.Fact{Title: title, Value: value}
+}
+
+// cardSections returns the sections specified by the given setting.
+func cardSections(pair yamlite.Pair) ([]teams.Section, error) {
+	items, err := cardItems(pair)
+	if err != nil {
+		return nil, err
+	}
+
+	sections := make([]teams.Section, 0, len(items))
+	for _, item := range items {
+		if item.Kind != yamlite.KindMapping {
+			return nil, cardErrorf(item.Line, "expected a mapping for each section, got %s", item.Kind)
+		}
+
+		var section teams.Section
+		for _, setting := range item.Pairs {
+			switch setting.Key {
+			case "title":
+				section.Title, err = cardScalar(setting)
+			case "text":
+				section.Text, err = cardScalar(setting)
+			case "facts":
+				section.Facts, err = cardFacts(setting)
+			case "style":
+				section.Style, err = cardScalar(setting)
+				if err == nil {
+					section.Style = strings.ToLower(strings.TrimSpace(section.Style))
+					if styleErr := teams.ValidateSectionStyle(section.Style); styleErr != nil {
+						err = cardErrorf(setting.Line, "%v", styleErr)
+					}
+				}
+			default:
+				err = cardErrorf(setting.Line, "unknown section setting %q; expected title, text, facts or style", setting.Key)
+			}
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if section.Title == "" && section.Text == "" && len(section.Facts) == 0 {
+			return nil, cardErrorf(item.Line, "sections must specify a title, text or facts")
+		}
+
+		sections = append(sections, section)
+	}
+
+	return sections, nil
+}
+
+// cardActions returns the target URLs specified by the given setting.
+func cardActions(pair yamlite.Pair) ([]TargetURL, error) {
+	items, err := cardItems(pair)
+	if err != nil {
+		return nil, err
+	}
+
+	actions := make([]TargetURL, 0, len(items))
+	for _, item := range items {
+		values, err := cardMapping(item, "action", "title", "url")
+		if err != nil {
+			return nil, err
+		}
+
+		if values["title"] == "" || values["url"] == "" {
+			return nil, cardErrorf(item.Line, "the title and url of each action are required")
+		}
+
+		u, err := url.Parse(values["url"])
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, cardErrorf(item.Line, "invalid action URL %q", values["url"])
+		}
+
+		actions = append(actions, TargetURL{URL: *u, Description: values["title"]})
+	}
+
+	return actions, nil
+}
+
+// cardMentions returns the user mentions specified by the given setting.
+func cardMentions(pair yamlite.Pair) ([]UserMention, error) {
+	items, err := cardItems(pair)
+	if err != nil {
+		return nil, err
+	}
+
+	mentions := make([]UserMention, 0, len(items))
+	for _, item := range items {
+		values, err := cardMapping(item, "mention", "name", "id")
+		if err != nil {
+			return nil, err
+		}
+
+		if values["name"] == "" || values["id"] == "" {
+			return nil, cardErrorf(item.Line, "the name and id of each mention are required")
+		}
+
+		mentions = append(mentions, UserMention{Name: values["name"], ID: values["id"]})
+	}
+
+	return mentions, nil
+}
@@ -0,0 +1,169 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+func TestParseCardDefinition(t *testing.T) {
+	doc := `# Disk usage alert
+title: Disk usage high
+color: Attention
+text: |
+  Free space on **/var** is below the warning threshold.
+facts:
+  Host: web01
+  Free: 8%
+sections:
+  - title: Largest directories
+    facts:
+      - title: /var/log
+        value: 12 GB
+      - title: /var/cache
+        value: ""
+  - text: Cleanup runs nightly at 02:00.
+actions:
+  - title: Dashboard
+    url: https://example.com/dashboards/web01
+mentions:
+  - name: Jane Doe
+    id: jane.doe@example.com
+`
+
+	card, err := parseCardDefinition([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if card.title != "Disk usage high" || card.color != "attention" {
+		t.Errorf("got title %q and color %q", card.title, card.color)
+	}
+
+	if want := "Free space on **/var** is below the warning threshold."; card.text != want {
+		t.Errorf("got text %q; want %q", card.text, want)
+	}
+
+	wantFacts := []teams.Fact{{Title: "Host", Value: "web01"}, {Title: "Free", Value: "8%"}}
+	if !reflect.DeepEqual(card.facts, wantFacts) {
+		t.Errorf("got facts %+v; want %+v", card.facts, wantFacts)
+	}
+
+	wantSections := []teams.Section{
+		{
+			Title: "Largest directories",
+			Facts: []teams.Fact{{Title: "/var/log", Value: "12 GB"}, {Title: "/var/cache", Value: emptyFactValue}},
+		},
+		{Text: "Cleanup runs nightly at 02:00."},
+	}
+	if !reflect.DeepEqual(card.sections, wantSections) {
+		t.Errorf("got sections %+v; want %+v", card.sections, wantSections)
+	}
+
+	if len(card.actions) != 1 || card.actions[0].Description != "Dashboard" || card.actions[0].URL.Host != "example.com" {
+		t.Errorf("got actions %+v", card.actions)
+	}
+
+	if len(card.mentions) != 1 || card.mentions[0] != (UserMention{Name: "Jane Doe", ID: "jane.doe@example.com"}) {
+		t.Errorf("got mentions %+v", card.mentions)
+	}
+}
+
+func TestParseCardDefinitionErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{name: "no text", doc: "title: A\n", want: "line 1: the text setting is required"},
+		{name: "unknown setting", doc: "text: A\nbody: B\n", want: "line 2: unknown card setting"},
+		{name: "color", doc: "text: A\ncolor: red\n", want: "line 2: unsupported color"},
+		{name: "fact title", doc: "text: A\nfacts:\n  - value: x\n", want: "line 3: the title of each fact is required"},
+		{name: "action URL", doc: "text: A\nactions:\n  - title: X\n    url: not a url\n", want: "line 3: invalid action URL"},
+		{name: "section", doc: "text: A\nsections:\n  - summary: x\n", want: "line 3: unknown section setting"},
+		{name: "section style", doc: "text: A\nsections:\n  - text: x\n    style: loud\n", want: "line 4: invalid section style"},
+		{name: "not a list", doc: "text: A\nmentions: Jane\n", want: "line 2: expected a list"},
+		{name: "syntax", doc: "text: [A]\n", want: "line 1: invalid YAML"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseCardDefinition([]byte(tt.doc))
+			if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Errorf("got error %v; want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMarkdownCard(t *testing.T) {
+	doc := "---\n" +
+		"title: Release 2.4 deployed\n" +
+		"color: good\n" +
+		"actions:\n" +
+		"  - title: Release notes\n" +
+		"    url: https://example.com/releases/2.4\n" +
+		"mentions:\n" +
+		"  - name: Jane Doe\n" +
+		"    id: jane.doe@example.com\n" +
+		"---\n" +
+		"\n" +
+		"## Highlights\n" +
+		"\n" +
+		"- Faster **exports**\n"
+
+	card, err := parseMarkdownCard([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if card.title != "Release 2.4 deployed" || card.color != "good" {
+		t.Errorf("got title %q and color %q", card.title, card.color)
+	}
+
+	if want := "## Highlights\n\n- Faster **exports**"; card.text != want {
+		t.Errorf("got text %q; want %q", card.text, want)
+	}
+
+	if len(card.actions) != 1 || len(card.mentions) != 1 {
+		t.Errorf("got actions %+v and mentions %+v", card.actions, card.mentions)
+	}
+
+	// A document without front matter is used as-is.
+	card, err = parseMarkdownCard([]byte("Plain **text**\n"))
+	if err != nil || card.text != "Plain **text**" || card.title != "" {
+		t.Errorf("got card %+v and error %v", card, err)
+	}
+}
+
+func TestParseMarkdownCardErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{name: "not closed", doc: "---\ntitle: A\n", want: "line 1: front matter is not closed"},
+		{name: "no body", doc: "---\ntitle: A\n---\n\n", want: "line 4: the document body is required"},
+		{name: "text setting", doc: "---\ntext: A\n---\nB\n", want: "line 2: the text setting is not supported"},
+		{name: "color", doc: "---\ntitle: A\ncolor: red\n---\nB\n", want: "line 3: unsupported color"},
+		{name: "empty", doc: "\n", want: "line 1: the document body is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseMarkdownCard([]byte(tt.doc))
+			if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Errorf("got error %v; want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-teams-notify/v2/adaptivecard"
+)
+
+// Supported quiet hours policies for a message class.
+const (
+
+	// QuietHoursPolicySilent indicates that messages sent during quiet hours
+	// omit user mentions so that recipients are not notified.
+	QuietHoursPolicySilent string = "silent"
+
+	// QuietHoursPolicyDrop indicates that messages are not sent during quiet
+	// hours.
+	QuietHoursPolicyDrop string = "drop"
+)
+
+// quietHoursLayout is the time layout used for quiet hours boundaries.
+const quietHoursLayout string = "15:04"
+
+// MessageClass is a named set of message defaults defined in a configuration
+// file and selected via the class flag.
+type MessageClass struct {
+
+	// Name is the name of the message class.
+	Name string
+
+	// TitlePrefix is the (optional) text prepended to the message title.
+	TitlePrefix string
+
+	// Color is the (optional) Adaptive Card color applied to the message
+	// title.
+	Color string
+
+	// QuietHoursPolicy is the action taken for messages sent during quiet
+	// hours.
+	QuietHoursPolicy string
+
+	// quietStart and quietEnd are the (local time) start and end of quiet
+	// hours as offsets from midnight. Quiet hours are disabled if equal.
+	quietStart time.Duration
+	quietEnd   time.Duration
+}
+
+// QuietHours indicates whether the given time falls within the quiet hours
+// for the message class.
+func (mc MessageClass) QuietHours(t time.Time) bool {
+	if mc.quietStart == mc.quietEnd {
+		return false
+	}
+
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+
+	// Quiet hours may span midnight (e.g., 22:00-07:00).
+	if mc.quietStart < mc.quietEnd {
+		return offset >= mc.quietStart && offset < mc.quietEnd
+	}
+
+	return offset >= mc.quietStart || offset < mc.quietEnd
+}
+
+// parseQuietHours parses a quiet hours range given as HH:MM-HH:MM in local
+// time.
+func parseQuietHours(value string) (time.Duration, time.Duration, error) {
+	start, end, found := strings.Cut(value, "-")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid quiet hours %q; expected HH:MM-HH:MM", value)
+	}
+
+	parse := func(s string) (time.Duration, error) {
+		t, err := time.Parse(quietHoursLayout, strings.TrimSpace(s))
+		if err != nil {
+			return 0, fmt.Errorf("invalid quiet hours %q; expected HH:MM-HH:MM", value)
+		}
+		return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+	}
+
+	startOffset, err := parse(start)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	endOffset, err := parse(end)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return startOffset, endOffset, nil
+}
+
+// loadClass records the class specific settings from the given message
+// class section.
+func (c *Config) loadClass(section *configSection) error {
+	mc := MessageClass{
+		Name:             c.Class,
+		QuietHoursPolicy: QuietHoursPolicySilent,
+	}
+
+	mc.TitlePrefix, _ = section.get(classKeyTitlePrefix)
+
+	if color, ok := section.get(classKeyColor); ok {
+		switch strings.ToLower(color) {
+		case adaptivecard.ColorDefault, adaptivecard.ColorDark, adaptivecard.ColorLight,
+			adaptivecard.ColorAccent, adaptivecard.ColorGood, adaptivecard.ColorWarning,
+			adaptivecard.ColorAttention:
+			mc.Color = strings.ToLower(color)
+		default:
+			return fmt.Errorf("unsupported color %q for message class %q", color, c.Class)
+		}
+	}
+
+	if quietHours, ok := section.get(classKeyQuietHours); ok {
+		start, end, err := parseQuietHours(quietHours)
+		if err != nil {
+			return err
+		}
+		mc.quietStart, mc.quietEnd = start, end
+	}
+
+	if policy, ok := section.get(classKeyQuietHoursPolicy); ok {
+		switch policy {
+		case QuietHoursPolicySilent, QuietHoursPolicyDrop:
+			mc.QuietHoursPolicy = policy
+		default:
+			return fmt.Errorf(
+				"unsupported quiet hours policy %q; expected %q or %q",
+				policy,
+				QuietHoursPolicySilent,
+				QuietHoursPolicyDrop,
+			)
+		}
+	}
+
+	c.class = mc
+
+	return nil
+}
+
+// MessageClass returns the user-selected message class. The zero value is
+// returned if no message class was selected.
+func (c Config) MessageClass() MessageClass {
+	return c.class
+}
@@ -0,0 +1,49 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMessageClassQuietHours(t *testing.T) {
+	tests := map[string]struct {
+		quietHours string
+		at         string
+		want       bool
+	}{
+		"spans midnight, late":  {quietHours: "22:00-07:00", at: "23:30", want: true},
+		"spans midnight, early": {quietHours: "22:00-07:00", at: "06:59", want: true},
+		"spans midnight, day":   {quietHours: "22:00-07:00", at: "07:00", want: false},
+		"same day, inside":      {quietHours: "12:00-13:00", at: "12:15", want: true},
+		"same day, outside":     {quietHours: "12:00-13:00", at: "13:15", want: false},
+		"disabled":              {quietHours: "00:00-00:00", at: "00:00", want: false},
+	}
+
+	for name, tt := range tests {
+		start, end, err := parseQuietHours(tt.quietHours)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+
+		at, err := time.Parse(quietHoursLayout, tt.at)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mc := MessageClass{quietStart: start, quietEnd: end}
+		if got := mc.QuietHours(at); got != tt.want {
+			t.Errorf("%s: QuietHours(%s) = %t; want %t", name, tt.at, got, tt.want)
+		}
+	}
+
+	if _, _, err := parseQuietHours("22:00"); err == nil {
+		t.Error("expected error for quiet hours without an end time")
+	}
+}
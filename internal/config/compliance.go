@@ -0,0 +1,121 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// Standard compliance tag keys. These are displayed with standard labels
+// ahead of any other keys so that the footers of all messages are
+// consistent regardless of the order in which the tags are specified.
+const (
+	ComplianceTagClassification string = "classification"
+	ComplianceTagRetention      string = "retention"
+	ComplianceTagTicket         string = "ticket"
+)
+
+// complianceTagLabels are the labels displayed for the standard compliance
+// tag keys, in display order.
+var complianceTagLabels = []struct {
+	key   string
+	label string
+}{
+	{ComplianceTagClassification, "Data classification"},
+	{ComplianceTagRetention, "Retention"},
+	{ComplianceTagTicket, "Ticket"},
+}
+
+// complianceTag is a compliance field displayed in the card footer.
+type complianceTag struct {
+	key   string
+	value string
+}
+
+type complianceTagsStringFlag []complianceTag
+
+// String returns a comma-separated list of all user-specified compliance
+// tags.
+func (cts *complianceTagsStringFlag) String() string {
+	if cts == nil {
+		return ""
+	}
+
+	tags := make([]string, 0, len(*cts))
+	for _, tag := range *cts {
+		tags = append(tags, tag.key+"="+tag.value)
+	}
+
+	return strings.Join(tags, ", ")
+}
+
+// Set is called once by the flag package, in command line order, for each
+// flag present. The key and value are separated by the first equals sign;
+// keys are compared case-insensitively and may only be specified once.
+func (cts *complianceTagsStringFlag) Set(value string) error {
+	key, tagValue, found := strings.Cut(value, "=")
+	key = strings.TrimSpace(key)
+	tagValue = strings.TrimSpace(tagValue)
+
+	switch {
+	case !found:
+		return fmt.Errorf("expected KEY=VALUE for compliance-tag flag, got %q", value)
+	case key == "":
+		return fmt.Errorf("empty key specified for compliance-tag flag")
+	case tagValue == "":
+		return fmt.Errorf("empty value specified for compliance tag %q", key)
+	}
+
+	for _, tag := range *cts {
+		if strings.EqualFold(tag.key, key) {
+			return fmt.Errorf("compliance tag %q specified more than once", key)
+		}
+	}
+
+	*cts = append(*cts, complianceTag{key: key, value: tagValue})
+
+	return nil
+}
+
+// facts returns the compliance tags as facts for display in the card
+// footer: the standard keys with their labels, followed by any other keys
+// as specified.
+func (cts complianceTagsStringFlag) facts() []teams.Fact {
+	if len(cts) == 0 {
+		return nil
+	}
+
+	rank := func(key string) int {
+		for i, standard := range complianceTagLabels {
+			if strings.EqualFold(key, standard.key) {
+				return i
+			}
+		}
+		return len(complianceTagLabels)
+	}
+
+	tags := append([]complianceTag(nil), cts...)
+	sort.SliceStable(tags, func(i, j int) bool {
+		return rank(tags[i].key) < rank(tags[j].key)
+	})
+
+	facts := make([]teams.Fact, 0, len(tags))
+	for _, tag := range tags {
+		title := tag.key
+		if i := rank(tag.key); i < len(complianceTagLabels) {
+			title = complianceTagLabels[i].label
+		}
+		facts = append(facts, teams.Fact{Title: title, Value: tag.value})
+	}
+
+	return facts
+}
@@ -0,0 +1,40 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+func TestComplianceTags(t *testing.T) {
+	var tags complianceTagsStringFlag
+	for _, value := range []string{"ticket=CHG-1042", "Region=EU", "Classification=Confidential", "retention=7 years"} {
+		if err := tags.Set(value); err != nil {
+			t.Fatalf("Set(%q) error = %v", value, err)
+		}
+	}
+
+	want := []teams.Fact{
+		{Title: "Data classification", Value: "Confidential"},
+		{Title: "Retention", Value: "7 years"},
+		{Title: "Ticket", Value: "CHG-1042"},
+		{Title: "Region", Value: "EU"},
+	}
+	if got := tags.facts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("facts() = %v; want %v", got, want)
+	}
+
+	for _, invalid := range []string{"classification", "=Internal", "ticket=", "TICKET=CHG-2"} {
+		if err := tags.Set(invalid); err == nil {
+			t.Errorf("Set(%q) succeeded; want error", invalid)
+		}
+	}
+}
@@ -11,6 +11,8 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"net"
+	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -19,6 +21,18 @@ import (
 	"time"
 
 	goteamsnotify "github.com/atc0005/go-teams-notify/v2"
+	"github.com/atc0005/send2teams/internal/budget"
+	"github.com/atc0005/send2teams/internal/colorrule"
+	"github.com/atc0005/send2teams/internal/inlineimage"
+	"github.com/atc0005/send2teams/internal/input"
+	"github.com/atc0005/send2teams/internal/oncall"
+	"github.com/atc0005/send2teams/internal/replay"
+	"github.com/atc0005/send2teams/internal/report"
+	"github.com/atc0005/send2teams/internal/session"
+	"github.com/atc0005/send2teams/internal/teams"
+	"github.com/atc0005/send2teams/internal/templates"
+	"github.com/atc0005/send2teams/internal/theme"
+	"golang.org/x/term"
 )
 
 const (
@@ -26,20 +40,162 @@ const (
 	verboseOutputFlagHelp               = "Whether detailed output should be shown after message submission success or failure."
 	silentOutputFlagHelp                = "Whether ANY output should be shown after message submission success or failure."
 	disableWebhookURLValidationFlagHelp = "Whether webhook URL validation should be disabled. Useful when submitting generated JSON payloads to a service like \"https://httpbin.org/\"."
+	recordFlagHelp                      = "The (optional) path of a file to which the effective configuration and message content of this invocation are recorded so that it may be re-executed via the replay subcommand. The file contains the webhook URL."
+	helpLongFlagHelp                    = "Whether detailed help (including category descriptions and examples) for the specified subcommand should be displayed and then immediately exit application."
+	helpManFlagHelp                     = "Whether a man page for the specified subcommand should be written to stdout and then immediately exit application."
+	helpPowerShellFlagHelp              = "Whether a PowerShell module exporting the Send-TeamsMessage function (which wraps this application) should be written to stdout and then immediately exit application."
+	previewTerminalFlagHelp             = "Whether an approximate preview of the card (sections, facts and button labels drawn with box drawing characters, with the title color shown using ANSI colors when writing to a terminal) should be written to stdout instead of sending the message. Useful for checking the layout on headless servers."
+	explainValidationFlagHelp           = "Whether each webhook URL validation stage should be run and a pass/fail report (with remediation hints) displayed instead of sending a message. The webhook URL for each selected target is also checked."
 	disableBrandingTrailerFlagHelp      = "Whether the branding trailer should be omitted from all messages generated by this application."
+	complianceTagFlagHelp               = "A compliance field (specified as KEY=VALUE) displayed in the card footer, such as the data classification required on automated communications. The classification, retention and ticket keys are shown with standard labels ahead of any other keys. This flag may be repeated and is typically set per profile via the configuration file."
 	ignoreInvalidResponseFlagHelp       = "Whether an invalid response from remote endpoint should be ignored. This is expected if submitting a message to a non-standard webhook URL."
 	convertEOLFlagHelp                  = "Whether messages with Windows, Mac and Linux newlines are updated to use break statements before message submission."
+	convertEscapedEOLFlagHelp           = "Whether escaped Windows, Mac and Linux newline sequences (e.g., a literal \\n) are treated as newlines before message submission. Useful for tools which are unable to pass actual newlines."
+	strictSchemaFlagHelp                = "Whether generated payloads should be validated against the bundled message card schemas before submission. Payloads which do not conform are not sent and the offending fields are reported."
+	emojiFallbackFlagHelp               = "Whether emoji in the title, message, facts and target URL labels should be replaced with text tokens (e.g., [WARN] or [OK]) for channels whose compliance policies strip emoji. Emoji without a token are removed. Useful in a profile so that one script can serve both emoji-friendly and emoji-free channels."
+	expandTabsFlagHelp                  = "The (optional) width of the tab stops used to expand tabs in the message (e.g., 4 or 8). If specified, runs of column-aligned lines (e.g., the output of df or netstat) outside of existing code blocks are also placed in code blocks so that their alignment is preserved. A value of 0 disables tab expansion."
+	bidiIsolateFlagHelp                 = "Whether the title, message and target URL labels should be wrapped in Unicode bidirectional isolation characters so that mixed right-to-left (e.g., Hebrew, Arabic) and left-to-right content is displayed in the correct order."
+	convertEOLCompatFlagHelp            = "Whether the convert-eol flag should apply the original conversion behavior (escaped newline sequences are also converted, Linux newlines are left as-is). Provided for compatibility with existing scripts."
+	configFileFlagHelp                  = "The (optional) path to a configuration file providing default flag values, profiles and message classes. Values specified via command-line flags take precedence."
+	classFlagHelp                       = "The (optional) message class defined in the configuration file (e.g., backup for a [class.backup] section) whose defaults are applied to the message."
+	profileFlagHelp                     = "The (optional) channel profile defined in the configuration file (e.g., ops for a [profile.ops] section) whose settings are applied. Overrides any profile selected by the message class."
 	teamNameFlagHelp                    = "The name of the Team containing our target channel. Used in log messages. If not specified, defaults to \"unspecified\"."
 	channelNameFlagHelp                 = "The target channel where we will send a message. Used in log messages. If not specified, defaults to \"unspecified\"."
 	webhookURLFlagHelp                  = "The Webhook URL provided by a preconfigured Connector."
+	webhookPartsFlagHelp                = "The (optional) components of the webhook URL, specified as comma-separated tenant GUID, webhook GUID, connector ID and group ID values (with an optional trailing signature for newer webhook URLs). The webhook URL is composed from these values and the webhook host. Incompatible with the url flag."
+	webhookURLKeyVaultFlagHelp          = "The (optional) Azure Key Vault secret (specified as vault-name/secret-name, with an optional trailing /version) containing the webhook URL. Retrieved at runtime using client credentials from the AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_CLIENT_SECRET environment variables or the managed identity of the host. Incompatible with the url flag."
+	webhookURLAWSSSMFlagHelp            = "The (optional) name or ARN of the AWS Systems Manager Parameter Store parameter (String or SecureString) containing the webhook URL. Retrieved at runtime using the default AWS credential chain. Incompatible with the url flag."
+	webhookURLAWSSecretsFlagHelp        = "The (optional) name or ARN of the AWS Secrets Manager secret containing the webhook URL. Retrieved at runtime using the default AWS credential chain. Incompatible with the url flag."
+	webhookHostFlagHelp                 = "The host used when composing the webhook URL from the webhook-parts flag (e.g., outlook.office.com, example.webhook.office.com)."
 	targetURLFlagHelp                   = "The target URL and label (specified as comma separated pair) usually visible as a button towards the bottom of the Microsoft Teams message."
 	userMentionFlagHelp                 = "The DisplayName and ID of the recipient (specified as comma separated pair) for a user mention."
+	activityTitleFlagHelp               = "The (optional) activity title (e.g., the name of the reporting application) shown alongside the activity image in a header above the message text."
+	activitySubtitleFlagHelp            = "The (optional) activity subtitle shown below the activity title."
+	activityImageFlagHelp               = "The (optional) URL of the avatar image shown alongside the activity title and subtitle."
+	responseURLFlagHelp                 = "The (optional) URL of an internal endpoint used to collect responses to the message. A button is added for each response choice which opens the URL with the receipt ID and chosen response given as the receipt_id and response query parameters."
+	responseChoiceFlagHelp              = "A response choice (e.g., Acknowledge) shown as a button which opens the response URL. May be repeated. If not specified, a single Respond button is shown."
+	onCallScheduleFlagHelp              = "The (optional) ID of the on-call schedule (or schedule name for Opsgenie) whose current on-call users are mentioned in the message. Resolved at send time."
+	onCallProviderFlagHelp              = "The on-call scheduling provider managing the on-call schedule. Supported providers are pagerduty, opsgenie and opsgenie-eu."
+	onCallTokenFlagHelp                 = "The API token (PagerDuty) or API key (Opsgenie) used to retrieve the on-call schedule. If not specified, the PAGERDUTY_TOKEN or OPSGENIE_API_KEY environment variable is used."
 	themeColorFlagHelp                  = "NOOP; this setting is no longer used. Values specified for this flag are ignored."
 	titleFlagHelp                       = "The title for the message to submit."
-	messageFlagHelp                     = "The message to submit. This message may be provided in Markdown format."
+	titlePrefixFlagHelp                 = "The (optional) text prepended to the message title (including messages submitted in serve mode)."
+	titleSuffixFlagHelp                 = "The (optional) text appended to the message title (including messages submitted in serve mode)."
+	environmentFlagHelp                 = "The (optional) name of the deployment environment (e.g., prod or staging) the message is sent from. A badge (e.g., [PROD]) is prepended to the message title and well-known environments select the title color. Defaults to the value of the SEND2TEAMS_ENVIRONMENT environment variable."
+	allowUntitledFlagHelp               = "Whether a title should be derived for messages submitted without one (including messages submitted in serve mode): the first line of the message, or the sender if the message provides none."
+	messageFlagHelp                     = "The message to submit. This message may be provided in Markdown format. If omitted, the message is read from standard input when it is a pipe or file."
+	messageBase64FlagHelp               = "The (optional) message to submit, encoded as base64 (standard or URL-safe alphabet, padding optional). Useful where quoting or newlines cannot be passed through (e.g., Nagios command definitions or Windows Task Scheduler). The decoded message must be UTF-8 text."
+	messageFileFlagHelp                 = "The (optional) path of a file containing the message to submit (e.g., output written to a temporary file by a Nagios event handler or cron job). Output beyond the message size limit is truncated. Incompatible with the message and exec flags."
+	titleFileFlagHelp                   = "The (optional) path of a file containing the message title (e.g., written by an earlier pipeline step). Surrounding whitespace is removed and newlines are joined with spaces. Incompatible with the title flag."
+	footerFileFlagHelp                  = "The (optional) path of a file containing Markdown text displayed in the card footer (e.g., build details written by a later pipeline step). May be combined with the title-file and message-file flags to assemble a card from separate parts."
+	cardFileFlagHelp                    = "The (optional) path of a YAML file describing the card to submit (title, text, color, facts, sections, actions and mentions). Markdown files (.md) are also accepted, with the settings read from YAML front matter and the remainder of the document used as the text. A title specified via the title flag takes precedence; facts, target URLs and user mentions specified via flags are added to those of the card. Incompatible with the message, message-file, payload-file, exec, template, input-format and map flags."
+	payloadFileFlagHelp                 = "The (optional) path of a file containing a pre-built MessageCard or Adaptive Card JSON payload which is submitted as-is, bypassing the card builder (e.g., when another system already renders the card). A bare Adaptive Card is wrapped in the message envelope required by webhook URLs. Incompatible with flags providing message content."
 	senderFlagHelp                      = "The (optional) sending application name or generator of the message this app will attempt to deliver."
 	retriesFlagHelp                     = "The number of attempts that this application will make to deliver messages before giving up."
 	retriesDelayFlagHelp                = "The number of seconds that this application will wait before making another delivery attempt."
+	verifyLinksFlagHelp                 = "Whether the URLs of target-url buttons and links within the message should be checked (using HEAD requests) before the message is sent, logging a warning for dead links."
+	verifyLinksTimeoutFlagHelp          = "The maximum time (e.g., 5s) spent checking each URL when the verify-links flag is specified."
+	verifyLinksAllowFlagHelp            = "The (optional) comma-separated list of hosts (e.g., intranet.example.com,*.corp.example.com) whose URLs are not checked when the verify-links flag is specified, such as hosts only reachable by recipients."
+	verifyLinksFailFlagHelp             = "Whether the message should not be sent (and the application should exit with an error) if the verify-links flag finds dead links."
+	verifyWorkflowRunFlagHelp           = "Whether the run triggered by a message accepted (HTTP 202) by a Power Automate or Logic Apps workflow should be verified by polling the run status URL provided by the endpoint, treating a failed run as a delivery failure."
+	verifyWorkflowRunTimeoutFlagHelp    = "The maximum time (e.g., 30s) spent waiting for a workflow run to complete when the verify-workflow-run flag is specified."
+	idempotencyKeyFlagHelp              = "The (optional) key identifying this message (e.g., a pipeline run ID). A message with the same key already sent to the webhook URL is not sent again; the outcome of the original send is reported instead. Defaults to a checksum of the webhook URL and message content in Terraform mode."
+	idempotencyDirFlagHelp              = "The directory used to record the messages sent for idempotency keys."
+	terraformFlagHelp                   = "Whether Terraform mode should be used, for use with the Terraform external data source or a null_resource. Flag values are also read from a JSON object on stdin (keyed by flag name; command-line values take precedence) and the result is written to stdout as a single JSON object of string values, identical for repeated runs with the same idempotency key. Diagnostics are written to stderr and failures result in a non-zero exit code."
+	nagiosTimeoutAwareFlagHelp          = "Whether the number of attempts, the delay between them and the time allowed for each attempt should be computed from the Nagios notification timeout (the max runtime flag, the NAGIOS_NOTIFICATIONTIMEOUT environment variable or the 30 second Nagios default) so that all attempts finish before Nagios kills the notification command. The retries and retries delay flags are treated as upper limits."
+	maxRuntimeFlagHelp                  = "The (optional) maximum time (e.g., 60s) the application is allowed to run when the nagios-timeout-aware flag is specified. Takes precedence over the NAGIOS_NOTIFICATIONTIMEOUT environment variable."
+	attemptWarnThresholdFlagHelp        = "The duration (e.g., 5s) after which a warning is logged for a slow delivery attempt, noting the time spent connecting (including any proxy) and waiting for a response from Microsoft Teams. Set to 0 to disable."
+	breakerThresholdFlagHelp            = "The number of consecutive failed deliveries to a webhook URL after which further messages are rejected without being submitted until the breaker cooldown elapses (in serve and batch modes and when sending multiple messages). Set to 0 to disable."
+	breakerCooldownFlagHelp             = "The duration (e.g., 1m) after which a single probe message is submitted to a webhook URL whose circuit breaker is open, closing the breaker if it succeeds."
+	pauseFileFlagHelp                   = "The (optional) path of a control file which pauses delivery while it exists (e.g., during a declared Microsoft Teams outage). Messages are held, rather than retried, until the file is removed: serve mode waits indefinitely (retaining undelivered messages for delivery after a restart if stopped while paused), while other modes wait for up to the submission timeout."
+	signSecretFlagHelp                  = "The (optional) shared secret used to sign each request with an HMAC-SHA256 of its timestamp and payload, allowing relays or gateways in front of Microsoft Teams to authenticate requests from approved deployments. A value of @PATH is read from the named file. Prefer the SEND2TEAMS_SIGN_SECRET environment variable or a file over the command-line."
+	signHeaderFlagHelp                  = "The name of the request header carrying the signature (t=TIMESTAMP,sha256=HMAC) when the sign-secret flag is specified."
+	listenUnixFlagHelp                  = "The path to the unix domain socket used by serve mode to accept messages from local clients. Also used by top mode to connect to a running serve instance."
+	listenUnixModeFlagHelp              = "The (octal) filesystem permissions applied to the serve mode unix domain socket (and to the named pipe, if created). Used to restrict which local users may submit messages."
+	listenFIFOFlagHelp                  = "The path to a named pipe (FIFO) from which serve mode reads messages, created if it does not exist. Each line written to the pipe is delivered as a message; lines starting with { are read as a JSON encoded message (which may span multiple lines) in the format accepted via the unix domain socket. Not supported on Windows."
+	listenHTTPFlagHelp                  = "The (optional) TCP address (e.g., 127.0.0.1:8080) on which serve mode provides a versioned HTTP API for submitting messages (POST /v1/messages) and discovering the supported formats and limits (GET /v1/capabilities). Requests are not authenticated; use a loopback address or restrict access by other means."
+	journalDirFlagHelp                  = "The directory used by serve mode to checkpoint accepted messages so that they are neither lost nor duplicated if the host restarts mid-delivery. Set to an empty value to keep the delivery queue in memory only."
+	benchTargetFlagHelp                 = "The endpoint used by bench mode to receive generated messages. Only the built-in mock webhook server (mock) is supported. For the history subcommand, the (optional) name of the target, profile or channel whose recorded sends are listed."
+	benchRateFlagHelp                   = "The rate at which bench mode submits messages, given as a count per second (s), minute (m) or hour (h) such as 50/s."
+	benchDurationFlagHelp               = "How long bench mode submits messages (e.g., 30s, 1m)."
+	onChangeFlagHelp                    = "Whether watch-file mode should send a message when a watched file is modified. If none of the on-change, on-create and on-remove flags are specified, all changes send a message."
+	onCreateFlagHelp                    = "Whether watch-file mode should send a message when a watched file is created."
+	onRemoveFlagHelp                    = "Whether watch-file mode should send a message when a watched file is removed."
+	pollIntervalFlagHelp                = "How often watch-file mode checks the watched path for changes (or watch-spool mode checks the spool directory for files) (e.g., 1s, 30s)."
+	debounceFlagHelp                    = "How long the watched path must remain unchanged before watch-file mode sends a message, so that a burst of changes produces a single message (e.g., 2s). Set to 0 to send as soon as a change is detected. In watch-spool mode, how long a spooled file must remain unmodified before it is sent."
+	spoolArchiveDirFlagHelp             = "The (optional) directory to which watch-spool mode moves each spooled file once its message is sent. Sent files are deleted if not specified."
+	diffLinesFlagHelp                   = "The maximum number of lines of the diff describing changes to text files included in messages sent by watch-file mode. Set to 0 to omit the diff."
+	provisionCommandFlagHelp            = "The (optional) command (and arguments) run by the migrate-url subcommand to create the workflow replacing a connector webhook URL (e.g., via Microsoft Graph or Power Automate). The command is run without a shell and receives details of the connector via SEND2TEAMS_MIGRATE_* environment variables; the first line of its output is the new webhook URL."
+	writeConfigFlagHelp                 = "Whether the migrate-url subcommand should replace the url setting in the configuration file (in the selected profile, or the defaults section) with the webhook URL returned by the provision command. A backup of the original file is kept."
+	onlineFlagHelp                      = "Whether the lint-config subcommand should also check that the webhook URLs in the configuration file are reachable."
+	mockLatencyFlagHelp                 = "The simulated processing time for each message received by the built-in mock webhook server (e.g., 250ms). Useful for approximating the response times of Microsoft Teams."
+	archiveS3FlagHelp                   = "The (optional) S3 bucket and key prefix (specified as bucket/prefix) used to archive every submitted payload and result. Credentials and region are retrieved from the standard AWS environment variables."
+	jsonOutputFlagHelp                  = "Whether a JSON formatted summary of the submission result (including the receipt ID) should be emitted to stdout. Emitted regardless of the silent flag."
+	integrityFactFlagHelp               = "Whether the SHA-256 checksum of the original (unformatted) message text should be added to the message as a fact, so that recipients of security-sensitive notifications can verify the content against the source system."
+	receiptFactFlagHelp                 = "Whether the receipt ID assigned to the submission should be added to the message as a (visible) fact. Useful for correlating a message with the invocation and log entries which produced it."
+	execFlagHelp                        = "The (optional) command (and arguments) to execute. The standard output of the command is used as the message. The command is run directly (not via a shell) and is terminated if it does not complete within the exec timeout. Incompatible with the message flag."
+	execTimeoutFlagHelp                 = "The number of seconds that the command specified via the exec flag is allowed to run before it is terminated."
+	execReportFailureFlagHelp           = "Whether a message should still be sent if the command specified via the exec flag fails or times out. The title color reflects the outcome of the command, whose exit code, duration and output are available to templates as .Exec values."
+	allowEmptyMessageFlagHelp           = "The placeholder text sent as the message if the message input (e.g., the output of the command specified via the exec flag or the content of the message file) is empty or only whitespace, instead of failing validation."
+	interactiveFlagHelp                 = "Whether to prompt on the terminal for the webhook URL (entered without echo), title and message if they are not otherwise specified. Useful for ad-hoc manual sends."
+	runTimeoutFlagHelp                  = "The (optional) maximum time (e.g., 30m) the command wrapped by the run subcommand is allowed to run before it is terminated and reported as failed. The command runs until it completes if not specified."
+	skipEmptyFlagHelp                   = "Whether the message should be skipped (exiting successfully) if the message input is empty or only whitespace, instead of failing validation."
+	propagateExitFlagHelp               = "Whether the application should exit with the exit code of the command specified via the exec flag (after sending the message), allowing it to wrap commands transparently in cron jobs and CI steps. Implies the exec-report-failure flag."
+	summarizeFlagHelp                   = "Whether very large messages (e.g., command output) should be reduced to excerpts from the start and end of the message along with a count of omitted lines and a list of the most frequently repeated omitted lines."
+	summarizeLinesFlagHelp              = "The number of lines retained from both the start and end of a summarized message."
+	attachFileFlagHelp                  = "The (optional) path to a file whose content is included in the message. May be repeated to include multiple files. Content beyond the attach max bytes limit is omitted."
+	imageFileFlagHelp                   = "The (optional) path of a small PNG, JPEG or GIF image (e.g., a chart or QR code) embedded in the message as a base64 encoded data URI, for environments without image hosting reachable by Microsoft Teams. May be repeated to embed multiple images."
+	imageMaxBytesFlagHelp               = "The maximum size in bytes of each image specified via the image-file flag. Larger images are rejected unless the image-fit flag is specified."
+	imageFitFlagHelp                    = "Whether images specified via the image-file flag which exceed the image max bytes limit should be re-encoded and downscaled until they fit instead of being rejected."
+	attachMaxBytesFlagHelp              = "The maximum number of bytes included from the start of each file specified via the attach-file flag."
+	attachChecksumsFlagHelp             = "Whether the size and SHA-256 checksum of each complete file specified via the attach-file flag should be included as facts so that recipients are able to verify the content corresponds to the original file."
+	tailFlagHelp                        = "The (optional) path of a log file whose last lines (see the tail lines flag) are displayed as a code block in a dedicated section of the message. The message defaults to a description of the excerpt. Saves wrapping tail in a shell pipeline."
+	tailLinesFlagHelp                   = "The maximum number of lines included from the end of the file specified via the tail flag."
+	reportCSVFlagHelp                   = "The (optional) path of a CSV report (whose first row contains the column headings) sent as a summary card followed by cards listing the rows as facts (for two columns) or as a table. The message defaults to a summary of the report."
+	rowsPerCardFlagHelp                 = "The maximum number of rows of the report specified via the report-csv flag listed on each card."
+	factFlagHelp                        = "A fact (specified as TITLE=VALUE) displayed after the message text. The value may contain Markdown and newlines; a value of @PATH is read from the named file (useful for short multi-line snippets such as certificate subjects) and a leading @@ stands for a literal @. This flag may be repeated."
+	sectionFlagHelp                     = "A section (specified as TITLE|TEXT) displayed in its own container after the facts, instead of combining all content into the message text. The text may contain Markdown and either part may be empty. A section continues the preceding group unless its title is prefixed with + (a literal leading + is given as ++), which starts a new group drawn with a separator line. This flag may be repeated."
+	sectionStyleFlagHelp                = "The (optional) Adaptive Card container style (default, emphasis, good, attention, warning or accent) applied to a section specified via the section flag, or severity to select the style matching the title color (e.g., attention for a critical alert). If repeated, each value styles the section flag in the same position, with the last value applying to any remaining sections."
+	factsCSVFlagHelp                    = "The (optional) path of a two-column CSV file whose rows (title, value) are displayed as facts in a dedicated section of the message. Lines starting with # are ignored."
+	factsFromJSONFlagHelp               = "The (optional) comma-separated list of JSON paths (e.g., $.host,$.state) whose values are extracted from a JSON body and displayed as facts. Each path may be prefixed with a label (e.g., Host=$.host). The JSON body is read from stdin if provided, otherwise the message is used."
+	mapFlagHelp                         = "The (optional) semicolon-separated list of field=path pairs (e.g., title=$.event.title;severity=$.level;url=$.url) mapping values of a JSON body to the title, text, sender, severity (title color), url (button) and fact.TITLE card fields. The JSON body is read from stdin if provided, otherwise the message is used."
+	nagiosFlagHelp                      = "Whether the title, message, facts and title color should be built from the standard Nagios macros provided to notification commands via NAGIOS_* environment variables (e.g., NAGIOS_HOSTNAME, NAGIOS_SERVICEDESC, NAGIOS_SERVICESTATE and NAGIOS_SERVICEOUTPUT). User-specified title and message values take precedence. Incompatible with the input-format and map flags."
+	mapTitleFlagHelp                    = "The (optional) JSON path (e.g., .alert.name) of the value of a JSON body used as the message title. The JSON body is read from stdin if provided, otherwise the message is used. Equivalent to a title mapping specified via the map flag."
+	mapTextFlagHelp                     = "The (optional) JSON path (e.g., .alert.description) of the value of a JSON body used as the message text. Equivalent to a text mapping specified via the map flag."
+	mapSenderFlagHelp                   = "The (optional) JSON path of the value of a JSON body used as the sender. Equivalent to a sender mapping specified via the map flag."
+	mapSeverityFlagHelp                 = "The (optional) JSON path (e.g., .alert.severity) of the value of a JSON body whose severity (e.g., critical, warning or resolved) selects the title color. Equivalent to a severity mapping specified via the map flag."
+	mapURLFlagHelp                      = "The (optional) JSON path of the URL within a JSON body shown as a button. Equivalent to a url mapping specified via the map flag."
+	inputFormatFlagHelp                 = "The (optional) format of an event payload (one of auto, sns, cloudwatch-alarm or azure-monitor) translated into the title, message, facts and title color. The payload is read from stdin if provided, otherwise the message is used."
+	localeFlagHelp                      = "The (optional) locale (e.g., de, fr-CA) used to render the message template. Templates may define a localized variant using {{define \"LOCALE\"}}...{{end}}."
+	targetsFlagHelp                     = "The (optional) comma-separated list of targets defined in the configuration file (as [target.NAME] sections) to send the message to. Each target specifies a webhook URL and optionally a locale, team and channel."
+	inventoryFlagHelp                   = "The (optional) path to an inventory file defining named webhook targets (as [target.NAME] sections) selected via the to flag. Keeping webhook URLs in one inventory file means a rotated URL is updated in one place rather than in every script."
+	toFlagHelp                          = "The (optional) comma-separated list of targets defined in the inventory file (e.g., ops-critical,db-team) to send the message to."
+	templateFlagHelp                    = "The (optional) message template to render. Specified as a local file path, an HTTPS URL or a file within a Git repository (e.g., git+https://example.com/templates.git#alert.tmpl). The title, message, sender, team and channel values are available to the template."
+	templateDataFlagHelp                = "The (optional) path of a JSON file whose values are available to the message template as .Data (e.g., {{.Data.host}}), keeping the card layout of the template separate from the data provided by a monitoring script. Requires the template flag."
+	templateChecksumFlagHelp            = "The (optional) SHA-256 checksum (e.g., sha256:<hex>) that the template must match. Pinned remote templates are used from the local cache without being retrieved again."
+	themeFlagHelp                       = "The (optional) name of a theme bundle within the theme directory (or path to a theme bundle) defining the color palette, icon set, footer style and layout defaults applied to the message."
+	themeDirFlagHelp                    = "The directory containing theme bundles selected by name via the theme flag."
+	colorRulesFlagHelp                  = "The (optional) path to a file containing ordered regular expression to color rules (e.g., (?i)failed|error => attention) matched against the message title and text. The first matching rule selects the title color."
+	templateCacheDirFlagHelp            = "The directory used to cache remote templates. If a remote template cannot be retrieved, the cached copy is used (subject to checksum pinning)."
+	maxSendsPerHourFlagHelp             = "The (optional) maximum number of messages sent to the webhook URL within any one hour period. Shared by all invocations using the same budget directory. Useful for endpoints such as Power Automate workflows which consume a flow run for every message."
+	maxSendsPerDayFlagHelp              = "The (optional) maximum number of messages sent to the webhook URL within any 24 hour period. Shared by all invocations using the same budget directory."
+	maxPerTargetFlagHelp                = "The (optional) maximum number of messages sent to each target webhook URL within a time period, given as count/period (e.g., 30/hour) where period is one of minute, hour or day. Protects channels from runaway loops (e.g., a misconfigured cron job). Messages exceeding the limit are handled as specified by the over budget flag."
+	overBudgetFlagHelp                  = "The action taken for messages submitted while over the send budget. Messages may be discarded (drop), retained and sent by a later invocation once the budget allows (spool) or discarded and noted in the next message sent (summarize)."
+	budgetDirFlagHelp                   = "The directory used to track messages sent against the send budget."
+	historyDirFlagHelp                  = "The (optional) directory in which the outcome of each send is recorded for the history subcommand. If not specified, sends are not recorded."
+	historyMaxAgeFlagHelp               = "How long sends recorded in the history directory are retained (e.g., 720h). Older records are removed as new sends are recorded."
+	sinceFlagHelp                       = "How far back (e.g., 24h) the history subcommand lists recorded sends."
+	failedOnlyFlagHelp                  = "Whether the history subcommand lists only sends which failed."
+	sessionIDFlagHelp                   = "The (optional) session ID under which the outcome of this send is recorded. Use the session-summary subcommand with the same session ID to post a single card summarizing all sends recorded for the session."
+	sessionDirFlagHelp                  = "The directory used to record sends performed under a session ID."
+	offlineOKFlagHelp                   = "Whether the message should be queued instead of submitted if the network is unavailable (e.g., no route to the webhook URL host or DNS unreachable). Queued messages are sent ahead of the next message submitted once the network is available and the queued status is reported as success."
+	offlineDirFlagHelp                  = "The directory used to queue messages submitted while the network is unavailable."
+	correlationIDFlagHelp               = "The (optional) ID correlating messages about the same issue (e.g., disk-full-web01). Used to match a resolved message to the message which scheduled a follow-up."
+	followUpAfterFlagHelp               = "The (optional) time (e.g., 1h) after which a follow-up message is posted if a resolved message with the same correlation ID has not been sent. Pending follow-ups are posted by the next invocation using the same webhook URL (or by a running serve instance) once due."
+	followUpMessageFlagHelp             = "The text of the follow-up message posted if the issue is not resolved within the follow-up-after time."
+	resolvedFlagHelp                    = "Whether this message reports the resolution of the issue identified by the correlation ID. Any pending follow-up for the correlation ID is cancelled."
+	followUpDirFlagHelp                 = "The directory used to record pending follow-up messages."
+	archiveAzureBlobFlagHelp            = "The (optional) Azure Storage account, container and blob prefix (specified as account/container/prefix) used to archive every submitted payload and result. A SAS token is retrieved from the AZURE_STORAGE_SAS_TOKEN environment variable."
 )
 
 // shorthandFlagSuffix is appended to short flag help text to emphasize that
@@ -52,20 +208,241 @@ const (
 	defaultSilentOutput                bool   = false
 	defaultVerboseOutput               bool   = false
 	defaultConvertEOL                  bool   = false
+	defaultConvertEscapedEOL           bool   = false
+	defaultConvertEOLCompat            bool   = false
+	defaultBidiIsolate                 bool   = false
+	defaultEmojiFallback               bool   = false
+	defaultStrictSchema                bool   = false
 	defaultDisableWebhookURLValidation bool   = false
+	defaultExplainValidation           bool   = false
+	defaultPreviewTerminal             bool   = false
+	defaultRecord                      string = ""
+	defaultHelpLong                    bool   = false
+	defaultHelpMan                     bool   = false
+	defaultHelpPowerShell              bool   = false
 	defaultDisableBrandingTrailer      bool   = false
 	defaultIgnoreInvalidResponse       bool   = false
 	defaultTeamName                    string = "unspecified"
 	defaultChannelName                 string = "unspecified"
 	defaultWebhookURL                  string = ""
+	defaultWebhookParts                string = ""
+	defaultWebhookHost                 string = "outlook.office.com"
+	defaultWebhookURLKeyVault          string = ""
+	defaultWebhookURLAWSSSM            string = ""
+	defaultWebhookURLAWSSecrets        string = ""
 	defaultMessageTitle                string = ""
+	defaultAllowUntitled               bool   = false
+	defaultTitlePrefix                 string = ""
+	defaultTitleSuffix                 string = ""
+	defaultEnvironment                 string = ""
 	defaultMessageText                 string = ""
+	defaultMessageFile                 string = ""
+	defaultMessageBase64               string = ""
+	defaultTitleFile                   string = ""
+	defaultFooterFile                  string = ""
+	defaultCardFile                    string = ""
+	defaultPayloadFile                 string = ""
 	defaultSender                      string = ""
 	defaultDisplayVersionAndExit       bool   = false
 	defaultRetries                     int    = 2
 	defaultRetriesDelay                int    = 2
+	defaultListenUnix                  string = ""
+	defaultListenFIFO                  string = ""
+	defaultListenHTTP                  string = ""
+	defaultListenUnixMode              string = "0660"
+	defaultBenchTarget                 string = BenchTargetMock
+	defaultBenchRate                   string = "10/s"
+	defaultOnChange                    bool   = false
+	defaultOnCreate                    bool   = false
+	defaultOnRemove                    bool   = false
+	defaultDiffLines                   int    = 50
+	defaultSpoolArchiveDir             string = ""
+	defaultProvisionCommand            string = ""
+	defaultWriteConfig                 bool   = false
+	defaultOnline                      bool   = false
+	defaultArchiveS3                   string = ""
+	defaultExec                        string = ""
+	defaultJSONOutput                  bool   = false
+	defaultReceiptFact                 bool   = false
+	defaultIntegrityFact               bool   = false
+	defaultActivityTitle               string = ""
+	defaultActivitySubtitle            string = ""
+	defaultActivityImage               string = ""
+	defaultResponseURL                 string = ""
+	defaultOnCallSchedule              string = ""
+	defaultOnCallProvider              string = oncall.ProviderPagerDuty
+	defaultOnCallToken                 string = ""
+	defaultExecTimeout                 int    = 30
+	defaultExecReportFailure           bool   = false
+	defaultPropagateExit               bool   = false
+	defaultNagiosTimeoutAware          bool   = false
+	defaultAllowEmptyMessage           string = ""
+	defaultSkipEmpty                   bool   = false
+	defaultInteractive                 bool   = false
+	defaultArchiveAzureBlob            string = ""
+	defaultTemplate                    string = ""
+	defaultTheme                       string = ""
+	defaultColorRules                  string = ""
+	defaultAttachMaxBytes              int    = 8 * 1024
+	defaultImageMaxBytes               int    = inlineimage.DefaultMaxBytes
+	defaultImageFit                    bool   = false
+	defaultAttachChecksums             bool   = false
+	defaultBreakerThreshold            int    = 0
+	defaultTail                        string = ""
+	defaultTailLines                   int    = 20
+	defaultReportCSV                   string = ""
+	defaultRowsPerCard                 int    = 20
+	defaultConfigFile                  string = ""
+	defaultClass                       string = ""
+	defaultProfile                     string = ""
+	defaultSummarize                   bool   = false
+	defaultSummarizeLines              int    = 20
+	defaultMaxSendsPerHour             int    = 0
+	defaultMaxSendsPerDay              int    = 0
+	defaultExpandTabs                  int    = 0
+	defaultMaxPerTarget                string = ""
+	defaultOverBudget                  string = budget.ActionDrop
+	defaultSessionID                   string = ""
+	defaultHistoryDir                  string = ""
+	defaultFailedOnly                  bool   = false
+	defaultOfflineOK                   bool   = false
+	defaultCorrelationID               string = ""
+	defaultFollowUpMessage             string = "This issue has not been resolved."
+	defaultResolved                    bool   = false
+	defaultFactsFromJSON               string = ""
+	defaultFactsCSV                    string = ""
+	defaultInputFormat                 string = ""
+	defaultMap                         string = ""
+	defaultMapTitle                    string = ""
+	defaultMapText                     string = ""
+	defaultMapSender                   string = ""
+	defaultMapSeverity                 string = ""
+	defaultMapURL                      string = ""
+	defaultNagios                      bool   = false
+	defaultVerifyLinks                 bool   = false
+	defaultVerifyLinksAllow            string = ""
+	defaultVerifyLinksFail             bool   = false
+	defaultVerifyWorkflowRun           bool   = false
+	defaultIdempotencyKey              string = ""
+	defaultTerraformMode               bool   = false
+	defaultLocale                      string = ""
+	defaultTargets                     string = ""
+	defaultInventory                   string = ""
+	defaultTo                          string = ""
+	defaultTemplateChecksum            string = ""
+	defaultTemplateData                string = ""
+	defaultPauseFile                   string = ""
+	defaultSignSecret                  string = ""
+	defaultSignHeader                  string = "X-Send2Teams-Signature"
 )
 
+const (
+	defaultBenchDuration time.Duration = 10 * time.Second
+	defaultMockLatency   time.Duration = 0
+
+	defaultPollInterval time.Duration = time.Second
+	defaultDebounce     time.Duration = 2 * time.Second
+
+	defaultAttemptWarnThreshold time.Duration = 5 * time.Second
+
+	defaultMaxRuntime time.Duration = 0
+
+	defaultRunTimeout time.Duration = 0
+
+	defaultBreakerCooldown time.Duration = 30 * time.Second
+
+	defaultVerifyLinksTimeout time.Duration = 5 * time.Second
+
+	defaultVerifyWorkflowRunTimeout time.Duration = 30 * time.Second
+
+	defaultFollowUpAfter time.Duration = 0
+
+	defaultHistoryMaxAge time.Duration = 30 * 24 * time.Hour
+	defaultSince         time.Duration = 24 * time.Hour
+)
+
+// Supported subcommands. If specified, a subcommand is given as the first
+// command-line argument ahead of any flags.
+const (
+
+	// SubcommandServe indicates that this application should run as a
+	// long-lived relay, accepting messages from local clients and submitting
+	// them to Microsoft Teams.
+	SubcommandServe string = "serve"
+
+	// SubcommandTop indicates that this application should display the live
+	// status of the delivery queue for a running serve instance.
+	SubcommandTop string = "top"
+
+	// SubcommandSessionSummary indicates that this application should post a
+	// single message summarizing all sends recorded for a session. The
+	// session ID may be given as the first argument after the subcommand.
+	SubcommandSessionSummary string = "session-summary"
+
+	// SubcommandBench indicates that this application should submit
+	// generated messages at a fixed rate to a benchmark target and report
+	// the resulting throughput and latency.
+	SubcommandBench string = "bench"
+
+	// SubcommandExportDefaults indicates that this application should write
+	// the embedded default themes and schemas to the directory given as the
+	// first argument after the subcommand.
+	SubcommandExportDefaults string = "export-defaults"
+
+	// SubcommandReplay indicates that this application should re-execute
+	// the invocation recorded in the file given as the first argument after
+	// the subcommand.
+	SubcommandReplay string = "replay"
+
+	// SubcommandWatchFile indicates that this application should watch the
+	// file or directory given as the first argument after the subcommand,
+	// sending a message each time it changes.
+	SubcommandWatchFile string = "watch-file"
+
+	// SubcommandWatchSpool indicates that this application should watch the
+	// spool directory given as the first argument after the subcommand,
+	// sending a message for each file dropped into it.
+	SubcommandWatchSpool string = "watch-spool"
+
+	// SubcommandBatch indicates that this application should send each
+	// message defined by the newline delimited JSON file given as the first
+	// argument after the subcommand (or read from stdin).
+	SubcommandBatch string = "batch"
+
+	// SubcommandHistory indicates that this application should list the
+	// sends recorded in the history directory.
+	SubcommandHistory string = "history"
+
+	// SubcommandFlags indicates that this application should describe all
+	// supported flags (as JSON if requested) for use by external tools.
+	SubcommandFlags string = "flags"
+
+	// SubcommandMigrateURL indicates that this application should explain
+	// how the retirement of Office 365 connectors affects the webhook URL
+	// and (optionally) replace it with a newly provisioned workflow URL.
+	SubcommandMigrateURL string = "migrate-url"
+
+	// SubcommandLintConfig indicates that this application should check the
+	// given configuration file for problems (e.g., unknown settings and
+	// conflicting defaults) instead of submitting a message.
+	SubcommandLintConfig string = "lint-config"
+
+	// SubcommandDebugBundle indicates that this application should write
+	// redacted diagnostic details (e.g., the effective configuration and a
+	// sample payload) to the zip archive given as the first argument after
+	// the subcommand, for attaching to a problem report.
+	SubcommandDebugBundle string = "debug-bundle"
+
+	// SubcommandRun indicates that this application should run the command
+	// given after the flags (following "--"), sending a message reporting
+	// its exit status and output before exiting with the same exit code.
+	SubcommandRun string = "run"
+)
+
+// BenchTargetMock indicates that bench mode submits messages to the
+// built-in mock webhook server.
+const BenchTargetMock string = "mock"
+
 // Overridden via Makefile for release builds
 var version = "dev build"
 
@@ -73,6 +450,14 @@ var version = "dev build"
 // information.
 var ErrVersionRequested = errors.New("version information requested")
 
+// ErrExplainValidationRequested indicates that the user requested a webhook
+// URL validation report instead of message submission.
+var ErrExplainValidationRequested = errors.New("webhook URL validation report requested")
+
+// ErrHelpRequested indicates that the user requested detailed help or a man
+// page.
+var ErrHelpRequested = errors.New("detailed help requested")
+
 // Primarily used with branding
 const myAppName string = "send2teams"
 const myAppURL string = "https://github.com/atc0005/" + myAppName
@@ -141,6 +526,389 @@ type UserMention struct {
 // struct is configured via command-line flags provided by the user.
 type Config struct {
 
+	// Subcommand is the (optional) subcommand specified by the user as the
+	// first command-line argument. If not specified, a single message is
+	// submitted using the provided flag values.
+	Subcommand string
+
+	// ExportDir is the directory to which the embedded defaults are written
+	// by the export-defaults subcommand.
+	ExportDir string
+
+	// BundleFile is the zip archive written by the debug-bundle subcommand.
+	BundleFile string
+
+	// LintFile is the configuration file checked by the lint-config
+	// subcommand.
+	LintFile string
+
+	// ReplayFile is the invocation file re-executed by the replay
+	// subcommand.
+	ReplayFile string
+
+	// WatchPath is the file or directory watched by the watch-file
+	// subcommand.
+	WatchPath string
+
+	// SpoolDir is the spool directory watched by the watch-spool
+	// subcommand.
+	SpoolDir string
+
+	// BatchFile is the newline delimited JSON file of message definitions
+	// sent by the batch subcommand. If not specified (or "-"), the message
+	// definitions are read from stdin.
+	BatchFile string
+
+	// RunCommand is the command (and arguments) wrapped by the run
+	// subcommand, as given after the flags.
+	RunCommand []string
+
+	// Record is the (optional) path of the file to which the effective
+	// configuration and message content of this invocation are recorded.
+	Record string
+
+	// ConfigFile is the (optional) path to a configuration file providing
+	// default flag values, profiles and message classes.
+	ConfigFile string
+
+	// Class is the (optional) name of the message class defined in the
+	// configuration file whose defaults are applied to the message.
+	Class string
+
+	// Profile is the (optional) name of the channel profile defined in the
+	// configuration file whose settings are applied.
+	Profile string
+
+	// ListenUnix is the path to the unix domain socket used by serve mode to
+	// accept messages from local clients.
+	ListenUnix string
+
+	// ListenUnixMode is the octal filesystem permissions applied to the
+	// serve mode unix domain socket and named pipe.
+	ListenUnixMode string
+
+	// ListenFIFO is the path to the named pipe from which serve mode reads
+	// messages.
+	ListenFIFO string
+
+	// ListenHTTP is the TCP address on which serve mode provides the HTTP
+	// API.
+	ListenHTTP string
+
+	// JournalDir is the directory used by serve mode to checkpoint accepted
+	// messages. If empty, the delivery queue is kept in memory only.
+	JournalDir string
+
+	// BenchTarget is the endpoint used by bench mode to receive generated
+	// messages.
+	BenchTarget string
+
+	// BenchRate is the rate at which bench mode submits messages.
+	BenchRate string
+
+	// BenchDuration is how long bench mode submits messages.
+	BenchDuration time.Duration
+
+	// MockLatency is the simulated processing time for each message
+	// received by the built-in mock webhook server.
+	MockLatency time.Duration
+
+	// OnChange indicates whether watch-file mode sends a message when a
+	// watched file is modified.
+	OnChange bool
+
+	// OnCreate indicates whether watch-file mode sends a message when a
+	// watched file is created.
+	OnCreate bool
+
+	// OnRemove indicates whether watch-file mode sends a message when a
+	// watched file is removed.
+	OnRemove bool
+
+	// PollInterval is how often watch-file mode checks the watched path for
+	// changes (or watch-spool mode checks the spool directory for files).
+	PollInterval time.Duration
+
+	// Debounce is how long the watched path must remain unchanged before
+	// watch-file mode sends a message (or a spooled file must remain
+	// unmodified before watch-spool mode sends it).
+	Debounce time.Duration
+
+	// DiffLines is the maximum number of lines of the diff included in
+	// messages sent by watch-file mode. Zero omits the diff.
+	DiffLines int
+
+	// SpoolArchiveDir is the (optional) directory to which watch-spool mode
+	// moves each spooled file once its message is sent. Sent files are
+	// deleted if not specified.
+	SpoolArchiveDir string
+
+	// ProvisionCommand is the (optional) command run by the migrate-url
+	// subcommand to create the workflow replacing a connector webhook URL.
+	ProvisionCommand string
+
+	// WriteConfig indicates whether the migrate-url subcommand should
+	// update the configuration file with the new webhook URL.
+	WriteConfig bool
+
+	// Online indicates whether the lint-config subcommand should check that
+	// the webhook URLs in the configuration file are reachable.
+	Online bool
+
+	// Exec is the (optional) command (and arguments) to execute. The
+	// standard output of the command is used as the message.
+	Exec string
+
+	// ExecTimeout is the number of seconds that the command specified via
+	// Exec is allowed to run before it is terminated.
+	ExecTimeout int
+
+	// ExecReportFailure indicates whether a message should still be sent if
+	// the command specified via Exec fails or times out.
+	ExecReportFailure bool
+
+	// PropagateExit indicates whether the application should exit with the
+	// exit code of the command specified via Exec once the message is sent.
+	PropagateExit bool
+
+	// RunTimeout is the (optional) maximum time the command wrapped by the
+	// run subcommand is allowed to run before it is terminated.
+	RunTimeout time.Duration
+
+	// AllowEmptyMessage is the (optional) placeholder text sent as the
+	// message if the message input is empty or only whitespace.
+	AllowEmptyMessage string
+
+	// SkipEmpty indicates whether the message is skipped if the message
+	// input is empty or only whitespace.
+	SkipEmpty bool
+
+	// Interactive indicates whether missing webhook URL, title and message
+	// values are prompted for on the terminal.
+	Interactive bool
+
+	// AttachFiles is the collection of files whose content is included in
+	// the message.
+	AttachFiles attachFilesStringFlag
+
+	// ImageFiles is the collection of image files embedded in the message.
+	ImageFiles imageFilesStringFlag
+
+	// ImageMaxBytes is the maximum size in bytes of each embedded image.
+	ImageMaxBytes int
+
+	// ImageFit indicates whether embedded images exceeding ImageMaxBytes
+	// should be reduced to fit instead of being rejected.
+	ImageFit bool
+
+	// AttachMaxBytes is the maximum number of bytes included from the start
+	// of each attached file.
+	AttachMaxBytes int
+
+	// AttachChecksums indicates whether the size and SHA-256 checksum of each
+	// attached file should be included as facts.
+	AttachChecksums bool
+
+	// Tail is the (optional) path of a log file whose last lines are
+	// displayed as a code block in a dedicated section of the message.
+	Tail string
+
+	// TailLines is the maximum number of lines included from the end of the
+	// file specified via the Tail field.
+	TailLines int
+
+	// ReportCSV is the (optional) path of a CSV report sent as a summary
+	// followed by cards listing the rows.
+	ReportCSV string
+
+	// RowsPerCard is the maximum number of report rows listed on each card.
+	RowsPerCard int
+
+	// Facts is the collection of user-specified facts displayed after the
+	// message text. Values of the form @PATH are read when the message
+	// input is loaded.
+	Facts factsStringFlag
+
+	// Sections is the collection of user-specified sections displayed after
+	// the facts.
+	Sections sectionsStringFlag
+
+	// SectionStyles is the collection of user-specified container styles
+	// applied to Sections, in order.
+	SectionStyles sectionStylesStringFlag
+
+	// FactsFromJSON is the comma-separated list of JSON paths whose values
+	// are extracted from a JSON body and displayed as facts.
+	FactsFromJSON string
+
+	// FactsCSV is the (optional) path of a two-column CSV file whose rows are
+	// displayed as facts in a dedicated section.
+	FactsCSV string
+
+	// InputFormat is the (optional) format of an event payload translated
+	// into the title, message, facts and title color.
+	InputFormat string
+
+	// Map is the (optional) semicolon-separated list of field=path pairs
+	// mapping values of a JSON body to card fields.
+	Map string
+
+	// MapTitle is the (optional) JSON path of the value of a JSON body used
+	// as the message title.
+	MapTitle string
+
+	// MapText is the (optional) JSON path of the value of a JSON body used
+	// as the message text.
+	MapText string
+
+	// MapSender is the (optional) JSON path of the value of a JSON body used
+	// as the sender.
+	MapSender string
+
+	// MapSeverity is the (optional) JSON path of the value of a JSON body
+	// whose severity selects the title color.
+	MapSeverity string
+
+	// MapURL is the (optional) JSON path of the URL within a JSON body shown
+	// as a button.
+	MapURL string
+
+	// Nagios indicates whether the message is built from the Nagios macros
+	// provided to notification commands via environment variables.
+	Nagios bool
+
+	// Locale is the (optional) locale used to render the message template.
+	Locale string
+
+	// Targets is the comma-separated list of configuration file targets to
+	// send the message to.
+	Targets string
+
+	// Inventory is the (optional) path of the inventory file defining the
+	// targets selected via the To field.
+	Inventory string
+
+	// To is the comma-separated list of inventory file targets to send the
+	// message to.
+	To string
+
+	// Summarize indicates whether very large messages should be reduced to
+	// excerpts along with a summary of the omitted lines.
+	Summarize bool
+
+	// SummarizeLines is the number of lines retained from both the start and
+	// end of a summarized message.
+	SummarizeLines int
+
+	// Template is the (optional) source of a message template to render.
+	Template string
+
+	// TemplateChecksum is the (optional) SHA-256 checksum that the template
+	// must match.
+	TemplateChecksum string
+
+	// TemplateData is the (optional) path of a JSON file whose values are
+	// available to the message template.
+	TemplateData string
+
+	// TemplateCacheDir is the directory used to cache remote templates.
+	TemplateCacheDir string
+
+	// Theme is the (optional) name of (or path to) the theme bundle applied
+	// to the message.
+	Theme string
+
+	// ThemeDir is the directory containing theme bundles selected by name.
+	ThemeDir string
+
+	// ColorRules is the (optional) path to a file of rules selecting the
+	// title color based on the message content.
+	ColorRules string
+
+	// MaxSendsPerHour is the (optional) maximum number of messages sent to
+	// the webhook URL within any one hour period.
+	MaxSendsPerHour int
+
+	// MaxSendsPerDay is the (optional) maximum number of messages sent to
+	// the webhook URL within any 24 hour period.
+	MaxSendsPerDay int
+
+	// MaxPerTarget is the (optional) maximum number of messages sent to each
+	// target webhook URL within a time period, given as count/period.
+	MaxPerTarget string
+
+	// OverBudget is the action taken for messages submitted while over the
+	// send budget.
+	OverBudget string
+
+	// BudgetDir is the directory used to track messages sent against the
+	// send budget.
+	BudgetDir string
+
+	// SessionID is the (optional) session ID under which the outcome of each
+	// send is recorded.
+	SessionID string
+
+	// SessionDir is the directory used to record sends performed under a
+	// session ID.
+	SessionDir string
+
+	// HistoryDir is the (optional) directory in which the outcome of each
+	// send is recorded for the history subcommand.
+	HistoryDir string
+
+	// HistoryMaxAge is how long sends recorded in the history directory are
+	// retained.
+	HistoryMaxAge time.Duration
+
+	// HistorySince is how far back the history subcommand lists recorded
+	// sends.
+	HistorySince time.Duration
+
+	// HistoryFailedOnly indicates whether the history subcommand lists only
+	// sends which failed.
+	HistoryFailedOnly bool
+
+	// HistoryTarget is the (optional) name of the target, profile or
+	// channel whose sends are listed by the history subcommand. Specified
+	// via the target flag.
+	HistoryTarget string
+
+	// OfflineOK indicates whether messages are queued instead of submitted
+	// while the network is unavailable.
+	OfflineOK bool
+
+	// OfflineDir is the directory used to queue messages submitted while the
+	// network is unavailable.
+	OfflineDir string
+
+	// CorrelationID is the (optional) ID correlating messages about the same
+	// issue.
+	CorrelationID string
+
+	// FollowUpAfter is the (optional) time after which a follow-up message
+	// is posted if the issue identified by CorrelationID is not resolved.
+	// Zero disables follow-ups.
+	FollowUpAfter time.Duration
+
+	// FollowUpMessage is the text of the follow-up message.
+	FollowUpMessage string
+
+	// Resolved indicates whether the message reports the resolution of the
+	// issue identified by CorrelationID, cancelling any pending follow-up.
+	Resolved bool
+
+	// FollowUpDir is the directory used to record pending follow-ups.
+	FollowUpDir string
+
+	// ArchiveS3 is the (optional) S3 bucket and key prefix used to archive
+	// every submitted payload and result.
+	ArchiveS3 string
+
+	// ArchiveAzureBlob is the (optional) Azure Storage account, container and
+	// blob prefix used to archive every submitted payload and result.
+	ArchiveAzureBlob string
+
 	// Team is the human-readable name of the Microsoft Teams "team" that
 	// contains the channel we wish to post a message to. This is used in
 	// informational output produced by this application only; the remote API
@@ -160,6 +928,27 @@ type Config struct {
 	// channel that you wish to submit messages to using this application.
 	WebhookURL string
 
+	// WebhookParts is the (optional) comma-separated list of components
+	// (tenant GUID, webhook GUID, connector ID, group ID and optional
+	// signature) from which the webhook URL is composed.
+	WebhookParts string
+
+	// WebhookHost is the host used when composing the webhook URL from
+	// WebhookParts.
+	WebhookHost string
+
+	// WebhookURLKeyVault is the Azure Key Vault secret (vault-name/secret-name)
+	// from which the webhook URL is retrieved at runtime.
+	WebhookURLKeyVault string
+
+	// WebhookURLAWSSSM is the AWS Systems Manager Parameter Store parameter
+	// from which the webhook URL is retrieved at runtime.
+	WebhookURLAWSSSM string
+
+	// WebhookURLAWSSecrets is the AWS Secrets Manager secret from which the
+	// webhook URL is retrieved at runtime.
+	WebhookURLAWSSecrets string
+
 	// ThemeColor is no longer used. Values specified for this flag are
 	// ignored. If/when the Adaptive Card format adds support for message
 	// theming (or border color) we can re-enable this setting.
@@ -169,10 +958,48 @@ type Config struct {
 	// that is displayed in Microsoft Teams for the message that we send.
 	MessageTitle string
 
+	// TitlePrefix is the (optional) text prepended to the message title.
+	TitlePrefix string
+
+	// TitleSuffix is the (optional) text appended to the message title.
+	TitleSuffix string
+
+	// Environment is the (optional) name of the deployment environment the
+	// message is sent from, used to badge the message title and select the
+	// title color.
+	Environment string
+
+	// AllowUntitled indicates whether a title is derived from the message
+	// text or sender for messages submitted without one.
+	AllowUntitled bool
+
 	// MessageText is an (optionally) Markdown-formatted string representing
 	// the message that we will submit.
 	MessageText string
 
+	// MessageFile is the (optional) path of a file containing the message.
+	MessageFile string
+
+	// MessageBase64 is the (optional) base64 encoded message.
+	MessageBase64 string
+
+	// TitleFile is the (optional) path of a file containing the message
+	// title.
+	TitleFile string
+
+	// FooterFile is the (optional) path of a file containing the text
+	// displayed in the card footer.
+	FooterFile string
+
+	// CardFile is the (optional) path of a YAML file describing the card to
+	// submit.
+	CardFile string
+
+	// PayloadFile is the (optional) path of a file containing a pre-built
+	// MessageCard or Adaptive Card payload submitted in place of a
+	// generated card.
+	PayloadFile string
+
 	// Sender is an optional value provided to indicate what application was
 	// responsible for generating the message that this one will attempt to
 	// deliver.
@@ -191,6 +1018,37 @@ type Config struct {
 	// Microsoft Teams message.
 	UserMentions userMentionsStringFlag
 
+	// ActivityTitle is the (optional) activity title shown in a header
+	// above the message text.
+	ActivityTitle string
+
+	// ActivitySubtitle is the (optional) activity subtitle shown below the
+	// activity title.
+	ActivitySubtitle string
+
+	// ActivityImage is the (optional) URL of the avatar image shown
+	// alongside the activity title and subtitle.
+	ActivityImage string
+
+	// ResponseURL is the (optional) URL of an internal endpoint used to
+	// collect responses to the message.
+	ResponseURL string
+
+	// ResponseChoices is the collection of response choices shown as
+	// buttons which open the response URL.
+	ResponseChoices responseChoicesStringFlag
+
+	// OnCallSchedule is the (optional) ID or name of the on-call schedule
+	// whose current on-call users are mentioned in the message.
+	OnCallSchedule string
+
+	// OnCallProvider is the on-call scheduling provider managing the
+	// on-call schedule.
+	OnCallProvider string
+
+	// OnCallToken is the API token used to retrieve the on-call schedule.
+	OnCallToken string
+
 	// Retries is the number of attempts that this application will make
 	// to deliver messages before giving up.
 	Retries int
@@ -198,14 +1056,95 @@ type Config struct {
 	// RetriesDelay is the number of seconds to wait between retry attempts.
 	RetriesDelay int
 
+	// NagiosTimeoutAware indicates whether the retry schedule is computed
+	// from the Nagios notification timeout (or MaxRuntime).
+	NagiosTimeoutAware bool
+
+	// MaxRuntime is the (optional) maximum time the application is allowed
+	// to run when NagiosTimeoutAware is set. Zero selects the Nagios
+	// notification timeout.
+	MaxRuntime time.Duration
+
+	// AttemptWarnThreshold is the duration after which a warning is logged
+	// for a slow delivery attempt. Zero disables the warnings.
+	AttemptWarnThreshold time.Duration
+
+	// BreakerThreshold is the number of consecutive failed deliveries to a
+	// webhook URL after which its circuit breaker opens. Zero disables the
+	// circuit breaker.
+	BreakerThreshold int
+
+	// BreakerCooldown is the duration after which an open circuit breaker
+	// allows a probe delivery.
+	BreakerCooldown time.Duration
+
+	// PauseFile is the (optional) path of the control file which pauses
+	// delivery while it exists.
+	PauseFile string
+
+	// SignSecret is the (optional) shared secret, or @PATH of a file
+	// containing it, used to sign each request.
+	SignSecret string
+
+	// SignHeader is the name of the request header carrying the signature.
+	SignHeader string
+
+	// VerifyLinks indicates whether the URLs referenced by the message should
+	// be checked before the message is sent.
+	VerifyLinks bool
+
+	// VerifyLinksTimeout is the maximum time spent checking each URL.
+	VerifyLinksTimeout time.Duration
+
+	// VerifyLinksAllow is the (optional) comma-separated list of hosts whose
+	// URLs are not checked.
+	VerifyLinksAllow string
+
+	// VerifyLinksFail indicates whether dead links should prevent the
+	// message from being sent.
+	VerifyLinksFail bool
+
+	// VerifyWorkflowRun indicates whether the run triggered by a message
+	// accepted by a workflow should be verified.
+	VerifyWorkflowRun bool
+
+	// VerifyWorkflowRunTimeout is the maximum time spent waiting for a
+	// workflow run to complete.
+	VerifyWorkflowRunTimeout time.Duration
+
+	// IdempotencyKey is the (optional) key identifying the message so that
+	// it is sent at most once.
+	IdempotencyKey string
+
+	// IdempotencyDir is the directory used to record the messages sent for
+	// idempotency keys.
+	IdempotencyDir string
+
+	// TerraformMode indicates whether flag values are read from a JSON
+	// object on stdin and the result written as a JSON object of string
+	// values, as expected by the Terraform external data source.
+	TerraformMode bool
+
 	// DisableWebhookURLValidation indicates whether validation of the
 	// user-specified WebhookURL should be disabled. Useful for testing.
 	DisableWebhookURLValidation bool
 
+	// ExplainValidation indicates whether a report of each webhook URL
+	// validation stage should be displayed instead of sending a message.
+	ExplainValidation bool
+
+	// PreviewTerminal indicates whether an approximate preview of the card
+	// should be displayed instead of sending the message.
+	PreviewTerminal bool
+
 	// DisableBrandingTrailer indicates whether the branding trailer should be
 	// appended to all messages generated by this application.
 	DisableBrandingTrailer bool
 
+	// ComplianceTags is the collection of user-specified compliance fields
+	// (e.g., the data classification) displayed in the card footer.
+	ComplianceTags complianceTagsStringFlag
+
 	// IgnoreInvalidResponse indicates whether an invalid response from remote
 	// endpoint should be ignored. This is expected if submitting a message to
 	// a non-standard webhook URL.
@@ -219,41 +1158,180 @@ type Config struct {
 	// failure.
 	SilentOutput bool
 
+	// JSONOutput indicates whether a JSON formatted summary of the
+	// submission result should be emitted to stdout.
+	JSONOutput bool
+
+	// ReceiptFact indicates whether the receipt ID assigned to the
+	// submission should be added to the message as a fact.
+	ReceiptFact bool
+
+	// IntegrityFact indicates whether the SHA-256 checksum of the original
+	// message text should be added to the message as a fact.
+	IntegrityFact bool
+
 	// Whether messages with Windows, Mac and Linux newlines are updated to
 	// use break statements before message submission.
 	ConvertEOL bool
 
+	// ConvertEscapedEOL indicates whether escaped Windows, Mac and Linux
+	// newline sequences are treated as newlines before message submission.
+	ConvertEscapedEOL bool
+
+	// ConvertEOLCompat indicates whether the original ConvertEOL behavior
+	// (which also converts escaped newline sequences) should be applied.
+	ConvertEOLCompat bool
+
+	// StrictSchema indicates whether generated payloads are validated against
+	// the bundled message card schemas before submission.
+	StrictSchema bool
+
+	// BidiIsolate indicates whether user-provided text is wrapped in Unicode
+	// bidirectional isolation characters.
+	BidiIsolate bool
+
+	// EmojiFallback indicates whether emoji in user-provided text are
+	// replaced with text tokens.
+	EmojiFallback bool
+
+	// ExpandTabs is the width of the tab stops used to expand tabs in the
+	// message text. If greater than zero, column-aligned lines are also
+	// placed in code blocks.
+	ExpandTabs int
+
 	// ShowVersion is a flag indicating whether the user opted to display only
 	// the version string and then immediately exit the application
 	ShowVersion bool
+
+	// HelpLong is a flag indicating whether the user opted to display only
+	// detailed help and then immediately exit the application.
+	HelpLong bool
+
+	// HelpMan is a flag indicating whether the user opted to display only a
+	// man page and then immediately exit the application.
+	HelpMan bool
+
+	// HelpPowerShell is a flag indicating whether the user opted to display
+	// only a PowerShell module wrapping this application and then
+	// immediately exit the application.
+	HelpPowerShell bool
+
+	// warnings is the collection of non-fatal issues encountered while
+	// loading the configuration.
+	warnings []string
+
+	// class is the message class selected via the Class field.
+	class MessageClass
+
+	// execData describes the outcome of the command specified via the Exec
+	// field, if run.
+	execData *templates.ExecData
+
+	// commandPending indicates that the message is yet to be provided by the
+	// command specified via the Exec field (or wrapped by the run
+	// subcommand) while the configuration is validated ahead of running it.
+	commandPending bool
+
+	// attachments is the content retrieved from the files specified via the
+	// AttachFiles field.
+	attachments []input.FileExcerpt
+
+	// images are the images prepared from the files specified via the
+	// ImageFiles field.
+	images []inlineimage.Image
+
+	// report is the content of the CSV report specified via the ReportCSV
+	// field, if any.
+	report *report.Report
+
+	// facts is the collection of facts specified via the Facts field,
+	// extracted from a JSON body via the FactsFromJSON or Map fields or
+	// translated from an event payload via the InputFormat field.
+	facts []teams.Fact
+
+	// sections is the collection of sections described by the card
+	// definition file specified via the CardFile field or read from the
+	// CSV file specified via the FactsCSV field.
+	sections []teams.Section
+
+	// footer is the text displayed in the card footer, read from the file
+	// specified via the FooterFile field.
+	footer string
+
+	// signSecret is the shared secret used to sign each request, resolved
+	// from the SignSecret field.
+	signSecret []byte
+
+	// payload is the pre-built payload read from the file specified via the
+	// PayloadFile field.
+	payload *teams.RawMessage
+
+	// inputColor is the title color selected by the severity of an event
+	// payload translated via the InputFormat field, mapped via the Map
+	// field or described by Nagios macros via the Nagios field.
+	inputColor string
+
+	// jsonBody is the JSON body (read from stdin or translated via the
+	// InputFormat field) shared by the flags which use it, once read.
+	jsonBody *[]byte
+
+	// targets is the collection of targets selected via the Targets field.
+	targets []Target
+
+	// targetName is the name of the target this configuration sends the
+	// message to, if any.
+	targetName string
+
+	// theme is the theme bundle selected via the Theme field.
+	theme theme.Theme
+
+	// colorRules is the collection of rules loaded via the ColorRules field.
+	colorRules colorrule.Rules
+
+	// replayed is the invocation loaded via the ReplayFile field.
+	replayed *replay.Invocation
+
+	// watchTemplate is the template rendered for each message sent by
+	// watch-file mode. Nil if no template is specified.
+	watchTemplate *templates.Template
+
+	// templateData is the collection of values read from the file
+	// specified via the TemplateData field, if any.
+	templateData interface{}
+
+	// retrySchedule is the retry schedule computed from the Nagios
+	// notification timeout. The zero value applies the user-specified
+	// retries and retries delay.
+	retrySchedule retrySchedule
 }
 
 type targetURLsStringFlag []TargetURL
 
+type attachFilesStringFlag []string
+
+type imageFilesStringFlag []string
+
 type userMentionsStringFlag []UserMention
 
-// String returns a list of all user-specified target URLs.
-func (tus *targetURLsStringFlag) String() string {
+type responseChoicesStringFlag []string
 
-	// From the `flag` package docs:
-	// "The flag package may call the String method with a zero-valued
-	// receiver, such as a nil pointer."
-	if tus == nil {
+type factsStringFlag []teams.Fact
+
+type sectionStylesStringFlag []string
+
+// String returns a list of all user-specified facts.
+func (fs *factsStringFlag) String() string {
+	if fs == nil {
 		return ""
 	}
 
 	var output strings.Builder
 
-	for i, target := range *tus {
-		fmt.Fprintf(
-			&output,
-			"[URL: %s, Desc: %s]",
-			target.URL.String(),
-			target.Description,
-		)
+	for i, fact := range *fs {
+		fmt.Fprintf(&output, "[Title: %s, Value: %s]", fact.Title, fact.Value)
 
 		// separate the current entry from the next if more to process
-		if i+1 != len(*tus) {
+		if i+1 != len(*fs) {
 			fmt.Fprintf(&output, ", ")
 		}
 	}
@@ -262,53 +1340,246 @@ func (tus *targetURLsStringFlag) String() string {
 }
 
 // Set is called once by the flag package, in command line order, for each
-// flag present. At most, two comma-separated values are allowed per flag
-// invocation in order to specify the target URL and the target URL
-// description. An error is returned if more comma-separated values are
-// specified than expected or if the provided URL is in an invalid format.
-func (tus *targetURLsStringFlag) Set(value string) error {
+// flag present. The title and value are separated by the first equals sign.
+// Values read from a file (@PATH) are resolved when the message input is
+// loaded.
+func (fs *factsStringFlag) Set(value string) error {
+	title, factValue, found := strings.Cut(value, "=")
+	title = strings.TrimSpace(title)
+
+	switch {
+	case !found:
+		return fmt.Errorf("expected TITLE=VALUE for fact flag, got %q", value)
+	case title == "":
+		return fmt.Errorf("empty title specified for fact flag")
+	}
 
-	// split comma-separated string into multiple values
-	items := strings.Split(value, ",")
+	*fs = append(*fs, teams.Fact{Title: title, Value: factValue})
 
-	// We should only have two items after splitting on the comma, the target
-	// URL and its description. Abort if more or less are supplied.
-	if len(items) != 2 {
-		return fmt.Errorf(
-			"received %d arguments for target URL flag, expected 2",
-			len(items),
-		)
-	}
+	return nil
+}
 
-	// prune any leading and trailing whitespace, drop any quotes which might
-	// cause issues later.
-	for index, item := range items {
-		items[index] = strings.TrimSpace(item)
-		items[index] = strings.ReplaceAll(items[index], "'", "")
-		items[index] = strings.ReplaceAll(items[index], "\"", "")
-	}
+type sectionsStringFlag []teams.Section
 
-	u, err := url.Parse(items[0])
-	if err != nil {
-		return fmt.Errorf(
-			"provided URL %s failed to parse: %w",
-			items[0],
-			err,
-		)
-	}
+// sectionStartGroupPrefix is the prefix of the title of a section flag value
+// which starts a new group.
+const sectionStartGroupPrefix string = "+"
 
-	desc := items[1]
+// String returns a list of all user-specified sections.
+func (ss *sectionsStringFlag) String() string {
+	if ss == nil {
+		return ""
+	}
 
-	// add them to the collection
-	*tus = append(*tus, TargetURL{
-		URL:         *u,
-		Description: desc,
-	})
+	var output strings.Builder
 
-	return nil
-}
+	for i, section := range *ss {
+		fmt.Fprintf(&output, "[Title: %s, Text: %s, StartGroup: %t]", section.Title, section.Text, !section.Continued)
 
-// String returns a list of all user-specified user mentions.
+		// separate the current entry from the next if more to process
+		if i+1 != len(*ss) {
+			fmt.Fprintf(&output, ", ")
+		}
+	}
+
+	return output.String()
+}
+
+// Set is called once by the flag package, in command line order, for each
+// flag present. The title and text are separated by the first pipe
+// character, so the text may itself contain pipes (e.g., a Markdown table).
+func (ss *sectionsStringFlag) Set(value string) error {
+	title, text, found := strings.Cut(value, "|")
+	if !found {
+		return fmt.Errorf("expected TITLE|TEXT for section flag, got %q", value)
+	}
+
+	title = strings.TrimSpace(title)
+	startGroup := strings.HasPrefix(title, sectionStartGroupPrefix) &&
+		!strings.HasPrefix(title, sectionStartGroupPrefix+sectionStartGroupPrefix)
+	title = strings.TrimPrefix(title, sectionStartGroupPrefix)
+	if startGroup {
+		title = strings.TrimSpace(title)
+	}
+
+	if title == "" && strings.TrimSpace(text) == "" {
+		return fmt.Errorf("empty title and text specified for section flag")
+	}
+
+	*ss = append(*ss, teams.Section{Title: title, Text: text, Continued: !startGroup})
+
+	return nil
+}
+
+// String returns a comma-separated list of all user-specified section
+// styles.
+func (sss *sectionStylesStringFlag) String() string {
+	if sss == nil {
+		return ""
+	}
+
+	return strings.Join(*sss, ", ")
+}
+
+// Set is called once by the flag package, in command line order, for each
+// flag present.
+func (sss *sectionStylesStringFlag) Set(value string) error {
+	style := strings.ToLower(strings.TrimSpace(value))
+	if style == "" {
+		return fmt.Errorf("empty style specified for section-style flag")
+	}
+
+	if err := teams.ValidateSectionStyle(style); err != nil {
+		return err
+	}
+
+	*sss = append(*sss, style)
+
+	return nil
+}
+
+// String returns a comma-separated list of all user-specified response
+// choices.
+func (rcs *responseChoicesStringFlag) String() string {
+	if rcs == nil {
+		return ""
+	}
+
+	return strings.Join(*rcs, ", ")
+}
+
+// Set is called once by the flag package, in command line order, for each
+// flag present.
+func (rcs *responseChoicesStringFlag) Set(value string) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return fmt.Errorf("empty choice specified for response-choice flag")
+	}
+
+	*rcs = append(*rcs, value)
+
+	return nil
+}
+
+// String returns a comma-separated list of all user-specified files.
+func (afs *attachFilesStringFlag) String() string {
+	if afs == nil {
+		return ""
+	}
+
+	return strings.Join(*afs, ", ")
+}
+
+// Set is called once by the flag package, in command line order, for each
+// flag present.
+func (afs *attachFilesStringFlag) Set(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("empty file path specified for attach-file flag")
+	}
+
+	*afs = append(*afs, value)
+
+	return nil
+}
+
+// String returns a comma-separated list of all user-specified image files.
+func (ifs *imageFilesStringFlag) String() string {
+	if ifs == nil {
+		return ""
+	}
+
+	return strings.Join(*ifs, ", ")
+}
+
+// Set is called once by the flag package, in command line order, for each
+// flag present.
+func (ifs *imageFilesStringFlag) Set(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("empty file path specified for image-file flag")
+	}
+
+	*ifs = append(*ifs, value)
+
+	return nil
+}
+
+// String returns a list of all user-specified target URLs.
+func (tus *targetURLsStringFlag) String() string {
+
+	// From the `flag` package docs:
+	// "The flag package may call the String method with a zero-valued
+	// receiver, such as a nil pointer."
+	if tus == nil {
+		return ""
+	}
+
+	var output strings.Builder
+
+	for i, target := range *tus {
+		fmt.Fprintf(
+			&output,
+			"[URL: %s, Desc: %s]",
+			target.URL.String(),
+			target.Description,
+		)
+
+		// separate the current entry from the next if more to process
+		if i+1 != len(*tus) {
+			fmt.Fprintf(&output, ", ")
+		}
+	}
+
+	return output.String()
+}
+
+// Set is called once by the flag package, in command line order, for each
+// flag present. At most, two comma-separated values are allowed per flag
+// invocation in order to specify the target URL and the target URL
+// description. An error is returned if more comma-separated values are
+// specified than expected or if the provided URL is in an invalid format.
+func (tus *targetURLsStringFlag) Set(value string) error {
+
+	// split comma-separated string into multiple values
+	items := strings.Split(value, ",")
+
+	// We should only have two items after splitting on the comma, the target
+	// URL and its description. Abort if more or less are supplied.
+	if len(items) != 2 {
+		return fmt.Errorf(
+			"received %d arguments for target URL flag, expected 2",
+			len(items),
+		)
+	}
+
+	// prune any leading and trailing whitespace, drop any quotes which might
+	// cause issues later.
+	for index, item := range items {
+		items[index] = strings.TrimSpace(item)
+		items[index] = strings.ReplaceAll(items[index], "'", "")
+		items[index] = strings.ReplaceAll(items[index], "\"", "")
+	}
+
+	u, err := url.Parse(items[0])
+	if err != nil {
+		return fmt.Errorf(
+			"provided URL %s failed to parse: %w",
+			items[0],
+			err,
+		)
+	}
+
+	desc := items[1]
+
+	// add them to the collection
+	*tus = append(*tus, TargetURL{
+		URL:         *u,
+		Description: desc,
+	})
+
+	return nil
+}
+
+// String returns a list of all user-specified user mentions.
 func (ums *userMentionsStringFlag) String() string {
 
 	// From the `flag` package docs:
@@ -366,9 +1637,42 @@ func (ums *userMentionsStringFlag) Set(value string) error {
 	return nil
 }
 
+// isSubcommand indicates whether the given command-line argument is a
+// supported subcommand.
+func isSubcommand(arg string) bool {
+	switch arg {
+	case SubcommandServe, SubcommandTop, SubcommandSessionSummary, SubcommandBench,
+		SubcommandExportDefaults, SubcommandReplay, SubcommandWatchFile, SubcommandBatch,
+		SubcommandHistory, SubcommandFlags, SubcommandMigrateURL, SubcommandDebugBundle,
+		SubcommandLintConfig, SubcommandRun, SubcommandWatchSpool:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseFileMode parses the given octal string (e.g., "0660") as filesystem
+// permissions.
+func parseFileMode(mode string) (os.FileMode, error) {
+	perm, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil {
+		return 0, err
+	}
+
+	if perm > uint64(os.ModePerm) {
+		return 0, fmt.Errorf("mode %s exceeds %o", mode, os.ModePerm)
+	}
+
+	return os.FileMode(perm), nil
+}
+
 // Branding is responsible for emitting application name, version and origin
 func Branding() {
-	fmt.Fprintf(flag.CommandLine.Output(), "\n%s %s\n%s\n\n", myAppName, version, myAppURL)
+	variant := ""
+	if BuildVariant != "full" {
+		variant = " (" + BuildVariant + ")"
+	}
+	fmt.Fprintf(flag.CommandLine.Output(), "\n%s %s%s\n%s\n\n", myAppName, version, variant, myAppURL)
 }
 
 // MessageTrailer generates a branded "footer" for use with submitted Teams
@@ -392,59 +1696,348 @@ func MessageTrailer(sender string) string {
 	)
 }
 
-// flagsUsage displays branding information and general usage details
-func flagsUsage() func() {
+// flagsUsage displays branding information and the flags supported by the
+// given subcommand (or for sending a message if no subcommand is
+// specified), grouped by category.
+func flagsUsage(subcommand string) func() {
 
 	return func() {
 
-		myBinaryName := filepath.Base(os.Args[0])
-
 		Branding()
 
-		fmt.Fprintf(flag.CommandLine.Output(), "Usage of \"%s\":\n",
-			myBinaryName,
-		)
-		flag.PrintDefaults()
+		_ = helpPage(subcommand).WriteText(flag.CommandLine.Output(), false)
 
 	}
 }
 
 func (c Config) String() string {
 	return fmt.Sprintf(
-		"Team=%q, "+
+		"Subcommand=%q, "+
+			"ExportDir=%q, "+
+			"BundleFile=%q, "+
+			"ReplayFile=%q, "+
+			"WatchPath=%q, "+
+			"SpoolDir=%q, "+
+			"BatchFile=%q, "+
+			"RunCommand=%q, "+
+			"Record=%q, "+
+			"ConfigFile=%q, "+
+			"Class=%q, "+
+			"Profile=%q, "+
+			"ListenUnix=%q, "+
+			"ListenFIFO=%q, "+
+			"ListenHTTP=%q, "+
+			"ListenUnixMode=%q, "+
+			"JournalDir=%q, "+
+			"BenchTarget=%q, "+
+			"BenchRate=%q, "+
+			"BenchDuration=%v, "+
+			"MockLatency=%v, "+
+			"OnChange=%t, "+
+			"OnCreate=%t, "+
+			"OnRemove=%t, "+
+			"PollInterval=%v, "+
+			"Debounce=%v, "+
+			"SpoolArchiveDir=%q, "+
+			"DiffLines=%q, "+
+			"ProvisionCommand=%q, "+
+			"WriteConfig=%t, "+
+			"Online=%t, "+
+			"Exec=%q, "+
+			"ExecTimeout=%q, "+
+			"ExecReportFailure=%t, "+
+			"PropagateExit=%t, "+
+			"RunTimeout=%v, "+
+			"AllowEmptyMessage=%q, "+
+			"SkipEmpty=%t, "+
+			"Interactive=%t, "+
+			"AttachFiles=%q, "+
+			"Facts=%q, "+
+			"Sections=%q, "+
+			"SectionStyles=%q, "+
+			"AttachMaxBytes=%q, "+
+			"AttachChecksums=%t, "+
+			"Tail=%q, "+
+			"TailLines=%q, "+
+			"ImageFiles=%q, "+
+			"ImageMaxBytes=%q, "+
+			"ImageFit=%t, "+
+			"ReportCSV=%q, "+
+			"RowsPerCard=%q, "+
+			"FactsFromJSON=%q, "+
+			"FactsCSV=%q, "+
+			"InputFormat=%q, "+
+			"Map=%q, "+
+			"MapTitle=%q, "+
+			"MapText=%q, "+
+			"MapSender=%q, "+
+			"MapSeverity=%q, "+
+			"MapURL=%q, "+
+			"Nagios=%t, "+
+			"Locale=%q, "+
+			"Targets=%q, "+
+			"Inventory=%q, "+
+			"To=%q, "+
+			"Summarize=%t, "+
+			"SummarizeLines=%q, "+
+			"Template=%q, "+
+			"TemplateChecksum=%q, "+
+			"TemplateData=%q, "+
+			"TemplateCacheDir=%q, "+
+			"Theme=%q, "+
+			"ThemeDir=%q, "+
+			"ColorRules=%q, "+
+			"MaxSendsPerHour=%q, "+
+			"MaxSendsPerDay=%q, "+
+			"MaxPerTarget=%q, "+
+			"OverBudget=%q, "+
+			"BudgetDir=%q, "+
+			"SessionID=%q, "+
+			"SessionDir=%q, "+
+			"HistoryDir=%q, "+
+			"HistoryMaxAge=%v, "+
+			"HistorySince=%v, "+
+			"HistoryFailedOnly=%t, "+
+			"HistoryTarget=%q, "+
+			"OfflineOK=%t, "+
+			"OfflineDir=%q, "+
+			"CorrelationID=%q, "+
+			"FollowUpAfter=%v, "+
+			"FollowUpMessage=%q, "+
+			"Resolved=%t, "+
+			"FollowUpDir=%q, "+
+			"ActivityTitle=%q, "+
+			"ActivitySubtitle=%q, "+
+			"ActivityImage=%q, "+
+			"ResponseURL=%q, "+
+			"ResponseChoices=%q, "+
+			"OnCallSchedule=%q, "+
+			"OnCallProvider=%q, "+
+			"ArchiveS3=%q, "+
+			"ArchiveAzureBlob=%q, "+
+			"Team=%q, "+
 			"Channel=%q, "+
 			"WebhookURL=%q, "+
+			"WebhookParts=%q, "+
+			"WebhookHost=%q, "+
+			"WebhookURLKeyVault=%q, "+
+			"WebhookURLAWSSSM=%q, "+
+			"WebhookURLAWSSecrets=%q, "+
 			"ThemeColor=%q, "+
 			"MessageTitle=%q, "+
+			"TitlePrefix=%q, "+
+			"TitleSuffix=%q, "+
+			"Environment=%q, "+
+			"AllowUntitled=%t, "+
 			"MessageText=%q, "+
+			"MessageFile=%q, "+
+			"MessageBase64=%q, "+
+			"TitleFile=%q, "+
+			"FooterFile=%q, "+
+			"CardFile=%q, "+
+			"PayloadFile=%q, "+
 			"Sender=%q, "+
 			"TargetURLs=%q, "+
 			"Retries=%q, "+
 			"RetriesDelay=%q, "+
+			"NagiosTimeoutAware=%t, "+
+			"MaxRuntime=%v, "+
+			"AttemptWarnThreshold=%v, "+
+			"BreakerThreshold=%q, "+
+			"BreakerCooldown=%v, "+
+			"PauseFile=%q, "+
+			"SignHeader=%q, "+
+			"VerifyLinks=%t, "+
+			"VerifyLinksTimeout=%v, "+
+			"VerifyLinksAllow=%q, "+
+			"VerifyLinksFail=%t, "+
+			"VerifyWorkflowRun=%t, "+
+			"VerifyWorkflowRunTimeout=%v, "+
+			"IdempotencyKey=%q, "+
+			"IdempotencyDir=%q, "+
+			"TerraformMode=%t, "+
 			"AppTimeout=%q, "+
 			"DisableWebhookURLValidation=%t, "+
+			"ExplainValidation=%t, "+
+			"PreviewTerminal=%t, "+
 			"DisableBrandingTrailer=%t, "+
+			"ComplianceTags=%q, "+
 			"IgnoreInvalidResponse=%t, "+
 			"VerboseOutput=%t, "+
 			"SilentOutput=%t, "+
-			"ConvertEOL=%t",
+			"ConvertEOL=%t, "+
+			"ConvertEscapedEOL=%t, "+
+			"ConvertEOLCompat=%t, "+
+			"StrictSchema=%t, "+
+			"BidiIsolate=%t, "+
+			"EmojiFallback=%t, "+
+			"ExpandTabs=%q, "+
+			"JSONOutput=%t, "+
+			"ReceiptFact=%t, "+
+			"IntegrityFact=%t",
+		c.Subcommand,
+		c.ExportDir,
+		c.BundleFile,
+		c.ReplayFile,
+		c.WatchPath,
+		c.SpoolDir,
+		c.BatchFile,
+		c.RunCommand,
+		c.Record,
+		c.ConfigFile,
+		c.Class,
+		c.Profile,
+		c.ListenUnix,
+		c.ListenFIFO,
+		c.ListenHTTP,
+		c.ListenUnixMode,
+		c.JournalDir,
+		c.BenchTarget,
+		c.BenchRate,
+		c.BenchDuration,
+		c.MockLatency,
+		c.OnChange,
+		c.OnCreate,
+		c.OnRemove,
+		c.PollInterval,
+		c.Debounce,
+		c.SpoolArchiveDir,
+		strconv.Itoa(c.DiffLines),
+		c.ProvisionCommand,
+		c.WriteConfig,
+		c.Online,
+		c.Exec,
+		strconv.Itoa(c.ExecTimeout),
+		c.ExecReportFailure,
+		c.PropagateExit,
+		c.RunTimeout,
+		c.AllowEmptyMessage,
+		c.SkipEmpty,
+		c.Interactive,
+		c.AttachFiles.String(),
+		c.Facts.String(),
+		c.Sections.String(),
+		c.SectionStyles.String(),
+		strconv.Itoa(c.AttachMaxBytes),
+		c.AttachChecksums,
+		c.Tail,
+		strconv.Itoa(c.TailLines),
+		c.ImageFiles.String(),
+		strconv.Itoa(c.ImageMaxBytes),
+		c.ImageFit,
+		c.ReportCSV,
+		strconv.Itoa(c.RowsPerCard),
+		c.FactsFromJSON,
+		c.FactsCSV,
+		c.InputFormat,
+		c.Map,
+		c.MapTitle,
+		c.MapText,
+		c.MapSender,
+		c.MapSeverity,
+		c.MapURL,
+		c.Nagios,
+		c.Locale,
+		c.Targets,
+		c.Inventory,
+		c.To,
+		c.Summarize,
+		strconv.Itoa(c.SummarizeLines),
+		c.Template,
+		c.TemplateChecksum,
+		c.TemplateData,
+		c.TemplateCacheDir,
+		c.Theme,
+		c.ThemeDir,
+		c.ColorRules,
+		strconv.Itoa(c.MaxSendsPerHour),
+		strconv.Itoa(c.MaxSendsPerDay),
+		c.MaxPerTarget,
+		c.OverBudget,
+		c.BudgetDir,
+		c.SessionID,
+		c.SessionDir,
+		c.HistoryDir,
+		c.HistoryMaxAge,
+		c.HistorySince,
+		c.HistoryFailedOnly,
+		c.HistoryTarget,
+		c.OfflineOK,
+		c.OfflineDir,
+		c.CorrelationID,
+		c.FollowUpAfter,
+		c.FollowUpMessage,
+		c.Resolved,
+		c.FollowUpDir,
+		c.ActivityTitle,
+		c.ActivitySubtitle,
+		c.ActivityImage,
+		c.ResponseURL,
+		c.ResponseChoices.String(),
+		c.OnCallSchedule,
+		c.OnCallProvider,
+		c.ArchiveS3,
+		c.ArchiveAzureBlob,
 		c.Team,
 		c.Channel,
 		c.WebhookURL,
+		c.WebhookParts,
+		c.WebhookHost,
+		c.WebhookURLKeyVault,
+		c.WebhookURLAWSSSM,
+		c.WebhookURLAWSSecrets,
 		c.ThemeColor,
 		c.MessageTitle,
+		c.TitlePrefix,
+		c.TitleSuffix,
+		c.Environment,
+		c.AllowUntitled,
 		c.MessageText,
+		c.MessageFile,
+		c.MessageBase64,
+		c.TitleFile,
+		c.FooterFile,
+		c.CardFile,
+		c.PayloadFile,
 		c.Sender,
 		c.TargetURLs.String(),
 		strconv.Itoa(c.Retries),
 		strconv.Itoa(c.RetriesDelay),
+		c.NagiosTimeoutAware,
+		c.MaxRuntime,
+		c.AttemptWarnThreshold,
+		strconv.Itoa(c.BreakerThreshold),
+		c.BreakerCooldown,
+		c.PauseFile,
+		c.SignHeader,
+		c.VerifyLinks,
+		c.VerifyLinksTimeout,
+		c.VerifyLinksAllow,
+		c.VerifyLinksFail,
+		c.VerifyWorkflowRun,
+		c.VerifyWorkflowRunTimeout,
+		c.IdempotencyKey,
+		c.IdempotencyDir,
+		c.TerraformMode,
 		c.TeamsSubmissionTimeout(),
 		c.DisableWebhookURLValidation,
+		c.ExplainValidation,
+		c.PreviewTerminal,
 		c.DisableBrandingTrailer,
+		c.ComplianceTags.String(),
 		c.IgnoreInvalidResponse,
 		c.VerboseOutput,
 		c.SilentOutput,
 		c.ConvertEOL,
+		c.ConvertEscapedEOL,
+		c.ConvertEOLCompat,
+		c.StrictSchema,
+		c.BidiIsolate,
+		c.EmojiFallback,
+		strconv.Itoa(c.ExpandTabs),
+		c.JSONOutput,
+		c.ReceiptFact,
+		c.IntegrityFact,
 	)
 }
 
@@ -453,7 +2046,86 @@ func (c Config) String() string {
 func NewConfig() (*Config, error) {
 	cfg := Config{}
 
-	cfg.handleFlagsConfig()
+	args := os.Args[1:]
+	if len(args) > 0 && isSubcommand(args[0]) {
+		cfg.Subcommand = args[0]
+		args = args[1:]
+	}
+
+	// The session ID may be given ahead of any flags for the session summary
+	// subcommand.
+	var sessionID string
+	if cfg.Subcommand == SubcommandSessionSummary && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		sessionID = args[0]
+		args = args[1:]
+	}
+
+	// The destination directory is given ahead of any flags for the export
+	// defaults subcommand.
+	if cfg.Subcommand == SubcommandExportDefaults && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cfg.ExportDir = args[0]
+		args = args[1:]
+	}
+
+	// The archive is given ahead of any flags for the debug bundle
+	// subcommand.
+	if cfg.Subcommand == SubcommandDebugBundle && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cfg.BundleFile = args[0]
+		args = args[1:]
+	}
+
+	// As is the configuration file for the lint config subcommand.
+	if cfg.Subcommand == SubcommandLintConfig && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cfg.LintFile = args[0]
+		args = args[1:]
+	}
+
+	// The invocation file is given ahead of any flags for the replay
+	// subcommand.
+	if cfg.Subcommand == SubcommandReplay && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cfg.ReplayFile = args[0]
+		args = args[1:]
+	}
+
+	// The watched path is given ahead of any flags for the watch file
+	// subcommand.
+	if cfg.Subcommand == SubcommandWatchFile && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cfg.WatchPath = args[0]
+		args = args[1:]
+	}
+
+	// As is the spool directory for the watch spool subcommand.
+	if cfg.Subcommand == SubcommandWatchSpool && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cfg.SpoolDir = args[0]
+		args = args[1:]
+	}
+
+	// The batch file is given ahead of any flags for the batch subcommand.
+	if cfg.Subcommand == SubcommandBatch && len(args) > 0 && (args[0] == "-" || !strings.HasPrefix(args[0], "-")) {
+		cfg.BatchFile = args[0]
+		args = args[1:]
+	}
+
+	cfg.handleFlagsConfig(args)
+
+	// The wrapped command follows the flags for the run subcommand.
+	if cfg.Subcommand == SubcommandRun {
+		cfg.RunCommand = flag.Args()
+	}
+
+	if sessionID != "" {
+		cfg.SessionID = sessionID
+	}
+
+	// The target flag selects the recorded sends listed by the history
+	// subcommand in place of the bench target.
+	if cfg.Subcommand == SubcommandHistory {
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "target" {
+				cfg.HistoryTarget = cfg.BenchTarget
+			}
+		})
+	}
 
 	cfg.App = AppInfo{
 		Name:    myAppName,
@@ -466,6 +2138,138 @@ func NewConfig() (*Config, error) {
 		return &cfg, ErrVersionRequested
 	}
 
+	// Return immediately if user just wants detailed help or a description
+	// of the supported flags
+	if cfg.HelpLong || cfg.HelpMan || cfg.HelpPowerShell || cfg.Subcommand == SubcommandFlags {
+		return &cfg, ErrHelpRequested
+	}
+
+	// The linted configuration file is checked as-is rather than applied.
+	if cfg.Subcommand == SubcommandLintConfig {
+		if err := cfg.validateLintConfig(); err != nil {
+			flag.Usage()
+			return nil, err
+		}
+
+		return &cfg, nil
+	}
+
+	if err := cfg.checkInteractive(); err != nil {
+		return nil, err
+	}
+
+	// A replayed invocation provides the effective configuration and message
+	// content in place of a configuration file and other inputs.
+	if cfg.Subcommand == SubcommandReplay {
+		if err := cfg.loadReplay(); err != nil {
+			return nil, err
+		}
+
+		if err := cfg.checkWebhookURLSources(); err != nil {
+			return nil, err
+		}
+
+		if err := cfg.fetchWebhookURL(); err != nil {
+			return nil, err
+		}
+
+		if err := cfg.Validate(cfg.DisableWebhookURLValidation); err != nil {
+			flag.Usage()
+			return nil, err
+		}
+
+		cfg.warnings = append(cfg.warnings, cfg.validationWarnings()...)
+
+		return &cfg, nil
+	}
+
+	// The Terraform query provides flag values which take precedence over
+	// those from a configuration file.
+	if cfg.TerraformMode {
+		if err := cfg.loadTerraformQuery(); err != nil {
+			return nil, err
+		}
+	}
+
+	// As do the values provided by the environment, allowing the
+	// configuration to be given without a long command line. Values for
+	// individual flags take precedence over the combined JSON object.
+	if err := cfg.loadFlagEnvVars(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.loadConfigJSON(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.loadConfigFile(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.loadInventory(); err != nil {
+		return nil, err
+	}
+
+	cfg.loadEnvironment()
+
+	if err := cfg.applyNagiosTimeout(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.composeWebhookURL(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.fetchWebhookURL(); err != nil {
+		return nil, err
+	}
+
+	readSecret := func() ([]byte, error) { return term.ReadPassword(int(os.Stdin.Fd())) }
+	if err := cfg.promptWebhookURL(readSecret, os.Stderr); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.loadSignSecret(); err != nil {
+		return nil, err
+	}
+
+	// The message is not needed to report on webhook URL validation.
+	if cfg.ExplainValidation {
+		return &cfg, ErrExplainValidationRequested
+	}
+
+	// Nor is it needed to migrate the webhook URL.
+	if cfg.Subcommand == SubcommandMigrateURL {
+		if err := cfg.validateMigrateURL(); err != nil {
+			flag.Usage()
+			return nil, err
+		}
+
+		return &cfg, nil
+	}
+
+	if err := cfg.loadTheme(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.loadColorRules(); err != nil {
+		return nil, err
+	}
+
+	// Commands are only run once the configuration is known to be valid.
+	if err := cfg.validateBeforeCommand(); err != nil {
+		flag.Usage()
+		return nil, err
+	}
+
+	if err := cfg.loadMessageInput(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.promptMessage(os.Stdin, os.Stderr); err != nil {
+		return nil, err
+	}
+
 	// log.Debug("Validating configuration ...")
 	if err := cfg.Validate(cfg.DisableWebhookURLValidation); err != nil {
 		flag.Usage()
@@ -473,22 +2277,388 @@ func NewConfig() (*Config, error) {
 	}
 	// log.Debug("Configuration validated")
 
+	cfg.warnings = append(cfg.warnings, cfg.validationWarnings()...)
+
 	return &cfg, nil
 }
 
+// validateBeforeCommand validates the configuration ahead of running the
+// command specified via the exec flag (or wrapped by the run subcommand),
+// allowing for the message it is yet to provide. The message itself is
+// validated once loaded.
+func (c Config) validateBeforeCommand() error {
+	if c.Exec == "" && c.Subcommand != SubcommandRun {
+		return nil
+	}
+
+	c.commandPending = true
+
+	return c.Validate(c.DisableWebhookURLValidation)
+}
+
 // Validate verifies all struct fields have been provided acceptable values.
+// All problems found are reported together as ValidationErrors rather than
+// only the first.
 func (c Config) Validate(disableWebhookURLValidation bool) error {
+	var errs ValidationErrors
 
 	// We rely on the Set() method for the flag.Value interface to ensure that
 	// the required URL and description values are provided for each target
 	// URL.
 
 	if c.SilentOutput && c.VerboseOutput {
-		return fmt.Errorf("unsupported: You cannot have both silent and verbose output")
+		errs.add("silent", fmt.Errorf("unsupported: You cannot have both silent and verbose output"))
+	}
+
+	if c.Subcommand != "" && !subcommandAvailable(c.Subcommand) {
+		errs.add("subcommand", fmt.Errorf("unsupported: the %s subcommand is not available in the %s build of this application", c.Subcommand, BuildVariant))
+	}
+
+	if c.Record != "" && c.Subcommand != "" {
+		errs.add("record", fmt.Errorf("unsupported: invocations cannot be recorded in %s mode", c.Subcommand))
+	}
+
+	if c.Record != "" && len(c.targets) > 0 {
+		errs.add("record", fmt.Errorf("unsupported: invocations sending to targets cannot be recorded"))
+	}
+
+	if c.SignSecret != "" && !validHeaderName(c.SignHeader) {
+		errs.add("sign-header", fmt.Errorf("invalid sign header name %q", c.SignHeader))
 	}
 
-	if c.MessageText == "" {
-		return fmt.Errorf("message content too short")
+	if c.Exec != "" {
+		switch {
+		// The output of the wrapped command is sent instead.
+		case c.Subcommand == SubcommandRun:
+			errs.add("exec", fmt.Errorf("unsupported: the exec flag is not supported in %s mode", SubcommandRun))
+		case c.ExecTimeout <= 0:
+			errs.add("exec-timeout", fmt.Errorf("exec timeout too short"))
+		}
+	}
+
+	if c.Tail != "" {
+		switch {
+		case c.Subcommand != "":
+			errs.add("tail", fmt.Errorf("unsupported: log file excerpts are not supported in %s mode", c.Subcommand))
+		case c.Record != "":
+			errs.add("tail", fmt.Errorf("unsupported: invocations sending log file excerpts cannot be recorded"))
+		}
+	}
+
+	if c.ReportCSV != "" {
+		switch {
+		case c.Subcommand != "":
+			errs.add("report-csv", fmt.Errorf("unsupported: CSV reports are not supported in %s mode", c.Subcommand))
+		case c.Record != "":
+			errs.add("report-csv", fmt.Errorf("unsupported: invocations sending CSV reports cannot be recorded"))
+		case c.RowsPerCard < 1:
+			errs.add("rows-per-card", fmt.Errorf("rows per card too short"))
+		}
+	}
+
+	if c.TerraformMode {
+		switch {
+		case c.Subcommand != "":
+			errs.add("tf", fmt.Errorf("unsupported: Terraform mode is not supported in %s mode", c.Subcommand))
+		case c.InputFormat != "" || c.mapRequested() || c.FactsFromJSON != "":
+			errs.add("tf", fmt.Errorf("unsupported: the input-format, map and facts-from-json flags are not supported in Terraform mode (stdin provides the Terraform query)"))
+		case len(c.targets) > 0:
+			errs.add("tf", fmt.Errorf("unsupported: targets are not supported in Terraform mode"))
+		case c.OfflineOK:
+			errs.add("tf", fmt.Errorf("unsupported: offline queuing is not supported in Terraform mode"))
+		}
+	}
+
+	if c.IdempotencyKey != "" && c.Subcommand != "" {
+		errs.add("idempotency-key", fmt.Errorf("unsupported: idempotency keys are not supported in %s mode", c.Subcommand))
+	}
+
+	if c.PayloadFile != "" {
+		switch {
+		case c.Subcommand != "":
+			errs.add("payload-file", fmt.Errorf("unsupported: pre-built payloads are not supported in %s mode", c.Subcommand))
+		case c.Record != "":
+			errs.add("payload-file", fmt.Errorf("unsupported: invocations sending pre-built payloads cannot be recorded"))
+		case c.TerraformMode:
+			errs.add("payload-file", fmt.Errorf("unsupported: pre-built payloads are not supported in Terraform mode"))
+		case c.IdempotencyKey != "":
+			errs.add("payload-file", fmt.Errorf("unsupported: idempotency keys are not supported for pre-built payloads"))
+		case c.OfflineOK:
+			errs.add("payload-file", fmt.Errorf("unsupported: offline queuing is not supported for pre-built payloads"))
+		case c.SendBudget().Enabled():
+			errs.add("payload-file", fmt.Errorf("unsupported: send budgets are not supported for pre-built payloads"))
+		}
+	}
+
+	if c.PreviewTerminal {
+		switch {
+		case c.Subcommand != "":
+			errs.add("preview-terminal", fmt.Errorf("unsupported: card previews are not supported in %s mode", c.Subcommand))
+		case c.PayloadFile != "":
+			errs.add("preview-terminal", fmt.Errorf("unsupported: card previews are not supported for pre-built payloads"))
+		}
+	}
+
+	if c.AllowEmptyMessage != "" || c.SkipEmpty {
+		switch {
+		case c.AllowEmptyMessage != "" && c.SkipEmpty:
+			errs.add("allow-empty-message", fmt.Errorf("unsupported: You cannot specify both the allow-empty-message and skip-empty flags"))
+		case c.Subcommand != "":
+			errs.add("allow-empty-message", fmt.Errorf("unsupported: the allow-empty-message and skip-empty flags are not supported in %s mode", c.Subcommand))
+		}
+	}
+
+	if (c.IdempotencyKey != "" || c.TerraformMode) && c.IdempotencyDir == "" {
+		errs.add("idempotency-dir", fmt.Errorf("idempotency directory not specified"))
+	}
+
+	switch c.Subcommand {
+	case SubcommandReplay:
+		if c.replayed == nil {
+			errs.add("subcommand", fmt.Errorf("invocation file not specified for %s", SubcommandReplay))
+		}
+
+		// The message content is validated when the file is loaded.
+
+	case SubcommandExportDefaults:
+		if c.ExportDir == "" {
+			errs.add("subcommand", fmt.Errorf("destination directory not specified for %s", SubcommandExportDefaults))
+		}
+
+		// No messages are submitted by this mode.
+		return errs.err()
+
+	case SubcommandDebugBundle:
+		if c.BundleFile == "" {
+			errs.add("subcommand", fmt.Errorf("bundle file not specified for %s", SubcommandDebugBundle))
+		}
+
+		// No messages are submitted by this mode; problems with the message
+		// content are recorded within the bundle instead.
+		return errs.err()
+
+	case SubcommandHistory:
+		if c.HistoryDir == "" {
+			errs.add("history-dir", fmt.Errorf("history directory not specified for %s", SubcommandHistory))
+		}
+
+		if c.HistorySince <= 0 {
+			errs.add("since", fmt.Errorf("history period too short"))
+		}
+
+		// No messages are submitted by this mode.
+		return errs.err()
+
+	case SubcommandTop:
+		if c.ListenUnix == "" {
+			errs.add("listen-unix", fmt.Errorf("unix domain socket path not specified for %s mode", SubcommandTop))
+		}
+
+		// No messages are submitted by this mode.
+		return errs.err()
+
+	case SubcommandServe:
+		if c.ListenUnix == "" && c.ListenFIFO == "" && c.ListenHTTP == "" {
+			errs.add("listen-unix", fmt.Errorf("unix domain socket, named pipe path or HTTP address not specified for %s mode", SubcommandServe))
+		}
+
+		if c.ListenHTTP != "" {
+			if _, _, err := net.SplitHostPort(c.ListenHTTP); err != nil {
+				errs.add("listen-http", fmt.Errorf("invalid HTTP address %q: %w", c.ListenHTTP, err))
+			}
+		}
+
+		if c.ListenUnix != "" && c.ListenUnix == c.ListenFIFO {
+			errs.add("listen-fifo", fmt.Errorf("the unix domain socket and named pipe paths must differ"))
+		}
+
+		if _, err := parseFileMode(c.ListenUnixMode); err != nil {
+			errs.add("listen-unix-mode", fmt.Errorf("invalid unix domain socket mode: %w", err))
+		}
+
+		if c.Template != "" {
+			errs.add("template", fmt.Errorf("unsupported: templates are not supported in %s mode", SubcommandServe))
+		}
+
+		if c.SendBudget().Enabled() {
+			errs.add("max-sends-per-hour", fmt.Errorf("unsupported: send budgets are not supported in %s mode", SubcommandServe))
+		}
+
+		if c.SessionID != "" {
+			errs.add("session-id", fmt.Errorf("unsupported: sessions are not supported in %s mode", SubcommandServe))
+		}
+
+		if c.OfflineOK {
+			errs.add("offline-ok", fmt.Errorf("unsupported: offline queuing is not supported in %s mode", SubcommandServe))
+		}
+
+		// Follow-ups are requested per message by clients.
+		if c.CorrelationID != "" || c.FollowUpAfter != 0 || c.Resolved {
+			errs.add("correlation-id", fmt.Errorf(
+				"unsupported: the correlation-id, follow-up-after and resolved flags are not supported in %s mode; specify them for each message instead",
+				SubcommandServe,
+			))
+		}
+
+		if c.OnCallSchedule != "" {
+			errs.add("oncall-schedule", fmt.Errorf("unsupported: on-call mentions are not supported in %s mode", SubcommandServe))
+		}
+
+		if c.ResponseURL != "" {
+			errs.add("response-url", fmt.Errorf("unsupported: response URLs are not supported in %s mode", SubcommandServe))
+		}
+
+		if len(c.targets) > 0 {
+			errs.add("targets", fmt.Errorf("unsupported: targets are not supported in %s mode", SubcommandServe))
+		}
+
+	case SubcommandBench:
+		if c.BenchTarget != BenchTargetMock {
+			errs.add("target", fmt.Errorf(
+				"unsupported bench target %q; expected %q",
+				c.BenchTarget,
+				BenchTargetMock,
+			))
+		}
+
+		if err := validateBenchRate(c.BenchRate); err != nil {
+			errs.add("rate", err)
+		}
+
+		if c.BenchDuration <= 0 {
+			errs.add("duration", fmt.Errorf("bench duration too short"))
+		}
+
+		if c.MockLatency < 0 {
+			errs.add("mock-latency", fmt.Errorf("mock latency must not be negative"))
+		}
+
+		// Generated messages must not be mixed in with archived payloads
+		// for real submissions.
+		if c.ArchiveS3 != "" || c.ArchiveAzureBlob != "" {
+			errs.add("archive-s3", fmt.Errorf("unsupported: payload archival is not supported in %s mode", SubcommandBench))
+		}
+
+		if len(c.targets) > 0 {
+			errs.add("targets", fmt.Errorf("unsupported: targets are not supported in %s mode", SubcommandBench))
+		}
+
+		// The message text is generated if not specified.
+
+	case SubcommandWatchFile:
+		if c.WatchPath == "" {
+			errs.add("subcommand", fmt.Errorf("watched path not specified for %s", SubcommandWatchFile))
+		}
+
+		if c.PollInterval <= 0 {
+			errs.add("poll-interval", fmt.Errorf("poll interval too short"))
+		}
+
+		if c.Debounce < 0 {
+			errs.add("debounce", fmt.Errorf("debounce must not be negative"))
+		}
+
+		if c.DiffLines < 0 {
+			errs.add("diff-lines", fmt.Errorf("diff lines must not be negative"))
+		}
+
+		// Messages are generated for each change rather than from input
+		// read at startup.
+		if c.Exec != "" || c.InputFormat != "" || c.mapRequested() {
+			errs.add("exec", fmt.Errorf("unsupported: the exec, input-format and map flags are not supported in %s mode", SubcommandWatchFile))
+		}
+
+		if len(c.targets) > 0 {
+			errs.add("targets", fmt.Errorf("unsupported: targets are not supported in %s mode", SubcommandWatchFile))
+		}
+
+		// The message text is generated from the detected changes if not
+		// specified.
+
+	case SubcommandBatch, SubcommandWatchSpool:
+		if c.Subcommand == SubcommandWatchSpool {
+			if c.SpoolDir == "" {
+				errs.add("subcommand", fmt.Errorf("spool directory not specified for %s", SubcommandWatchSpool))
+			}
+
+			if c.PollInterval <= 0 {
+				errs.add("poll-interval", fmt.Errorf("poll interval too short"))
+			}
+
+			if c.Debounce < 0 {
+				errs.add("debounce", fmt.Errorf("debounce must not be negative"))
+			}
+
+			// Archived files would otherwise be sent again.
+			if c.SpoolArchiveDir != "" && filepath.Clean(c.SpoolArchiveDir) == filepath.Clean(c.SpoolDir) {
+				errs.add("spool-archive-dir", fmt.Errorf("spool archive directory must differ from the spool directory"))
+			}
+		}
+
+		// Each message definition (or spooled file) provides its own
+		// content.
+		contentFlags := []struct {
+			name string
+			set  bool
+		}{
+			{"message-base64", c.MessageBase64 != ""},
+			{"message", c.MessageText != ""},
+			{"message-file", c.MessageFile != ""},
+			{"card-file", c.CardFile != ""},
+			{"exec", c.Exec != ""},
+			{"template", c.Template != ""},
+			{"input-format", c.InputFormat != ""},
+			{"map", c.mapRequested()},
+			{"facts-from-json", c.FactsFromJSON != ""},
+		}
+
+		for _, f := range contentFlags {
+			if f.set {
+				errs.add(f.name, fmt.Errorf("unsupported: the %s flag is not supported in %s mode", f.name, c.Subcommand))
+			}
+		}
+
+		if c.IdempotencyKey != "" {
+			errs.add("idempotency-key", fmt.Errorf("unsupported: idempotency keys are not supported in %s mode", c.Subcommand))
+		}
+
+		if c.SendBudget().Enabled() {
+			errs.add("max-sends-per-hour", fmt.Errorf("unsupported: send budgets are not supported in %s mode", c.Subcommand))
+		}
+
+		if c.OfflineOK {
+			errs.add("offline-ok", fmt.Errorf("unsupported: offline queuing is not supported in %s mode", c.Subcommand))
+		}
+
+		if len(c.targets) > 0 {
+			errs.add("targets", fmt.Errorf("unsupported: targets are not supported in %s mode", c.Subcommand))
+		}
+
+	case SubcommandRun:
+		if len(c.RunCommand) == 0 {
+			errs.add("subcommand", fmt.Errorf("command not specified for %s", SubcommandRun))
+		}
+
+		if c.RunTimeout < 0 {
+			errs.add("run-timeout", fmt.Errorf("run timeout must not be negative"))
+		}
+
+		// The message text is generated from the outcome of the command if
+		// not specified.
+
+	case SubcommandSessionSummary:
+		if c.SessionID == "" {
+			errs.add("session-id", fmt.Errorf("session ID not specified for %s", SubcommandSessionSummary))
+		}
+
+		// The message text is generated from the recorded sends.
+
+	default:
+		// Pre-built payloads are validated when the file is loaded and empty
+		// messages are skipped if requested.
+		if c.MessageText == "" && c.PayloadFile == "" && !c.SkipEmpty && !c.commandPending {
+			errs.add("message", fmt.Errorf("message content too short"))
+		}
 	}
 
 	// Title is optional. If provided, use as-is.
@@ -498,24 +2668,266 @@ func (c Config) Validate(disableWebhookURLValidation bool) error {
 	// Sender is optional. If provided, use as-is.
 
 	if c.Retries < 0 {
-		return fmt.Errorf("retries too short")
+		errs.add("retries", fmt.Errorf("retries too short"))
 	}
 
 	if c.RetriesDelay < 0 {
-		return fmt.Errorf("retries delay too short")
+		errs.add("retries-delay", fmt.Errorf("retries delay too short"))
+	}
+
+	if c.HistoryMaxAge < 0 {
+		errs.add("history-max-age", fmt.Errorf("history max age must not be negative"))
+	}
+
+	if c.ExpandTabs < 0 {
+		errs.add("expand-tabs", fmt.Errorf("expand tabs width must not be negative"))
+	}
+
+	if c.AttemptWarnThreshold < 0 {
+		errs.add("attempt-warn-threshold", fmt.Errorf("attempt warning threshold must not be negative"))
+	}
+
+	if c.MaxRuntime < 0 {
+		errs.add("max-runtime", fmt.Errorf("max runtime must not be negative"))
+	}
+
+	// The retry schedule is computed for the delivery of a single message.
+	if c.NagiosTimeoutAware {
+		switch {
+		case c.Subcommand != "":
+			errs.add("nagios-timeout-aware", fmt.Errorf("unsupported: Nagios timeout aware retries are not supported in %s mode", c.Subcommand))
+		case len(c.targets) > 0:
+			errs.add("nagios-timeout-aware", fmt.Errorf("unsupported: Nagios timeout aware retries are not supported when sending to targets"))
+		case c.ReportCSV != "":
+			errs.add("nagios-timeout-aware", fmt.Errorf("unsupported: Nagios timeout aware retries are not supported for CSV reports"))
+		}
+	}
+
+	if c.BreakerThreshold < 0 {
+		errs.add("breaker-threshold", fmt.Errorf("breaker threshold must not be negative"))
+	}
+
+	if c.BreakerThreshold > 0 && c.BreakerCooldown <= 0 {
+		errs.add("breaker-cooldown", fmt.Errorf("breaker cooldown too short"))
+	}
+
+	if c.VerifyLinks && c.VerifyLinksTimeout <= 0 {
+		errs.add("verify-links-timeout", fmt.Errorf("verify links timeout too short"))
+	}
+
+	if c.VerifyWorkflowRun && c.VerifyWorkflowRunTimeout <= 0 {
+		errs.add("verify-workflow-run-timeout", fmt.Errorf("verify workflow run timeout too short"))
+	}
+
+	if c.Summarize && c.SummarizeLines < 1 {
+		errs.add("summarize-lines", fmt.Errorf("summarize lines too short"))
+	}
+
+	if c.MaxSendsPerHour < 0 || c.MaxSendsPerDay < 0 {
+		errs.add("max-sends-per-hour", fmt.Errorf("send budget limits must not be negative"))
+	}
+
+	if c.MaxPerTarget != "" {
+		if _, _, err := budget.ParseLimit(c.MaxPerTarget); err != nil {
+			errs.add("max-per-target", err)
+		}
+	}
+
+	switch c.OverBudget {
+	case budget.ActionDrop, budget.ActionSpool, budget.ActionSummarize:
+	default:
+		errs.add("over-budget", fmt.Errorf(
+			"unsupported over budget action %q; expected one of %q, %q or %q",
+			c.OverBudget,
+			budget.ActionDrop,
+			budget.ActionSpool,
+			budget.ActionSummarize,
+		))
+	}
+
+	if c.SendBudget().Enabled() && c.BudgetDir == "" {
+		errs.add("budget-dir", fmt.Errorf("send budget directory not specified"))
+	}
+
+	if c.ActivityImage != "" {
+		u, err := url.Parse(c.ActivityImage)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			errs.add("activity-image", fmt.Errorf("invalid activity image URL %q; expected an absolute http or https URL", c.ActivityImage))
+		}
+	}
+
+	if len(c.SectionStyles) > 1 && len(c.SectionStyles) > len(c.Sections) {
+		errs.add("section-style", fmt.Errorf(
+			"%d section styles specified for %d sections; expected at most one style per section flag",
+			len(c.SectionStyles),
+			len(c.Sections),
+		))
+	}
+
+	if len(c.ResponseChoices) > 0 && c.ResponseURL == "" {
+		errs.add("response-url", fmt.Errorf("response URL not specified for response choices"))
+	}
+
+	if c.ResponseURL != "" {
+		u, err := url.Parse(c.ResponseURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			errs.add("response-url", fmt.Errorf("invalid response URL %q; expected an absolute http or https URL", c.ResponseURL))
+		}
+	}
+
+	// The on-call schedule is resolved at send time, but the provider and
+	// API token are verified up front.
+	if c.OnCallSchedule != "" {
+		if _, err := oncall.New(c.OnCallProvider, c.OnCallToken, http.DefaultClient); err != nil {
+			errs.add("oncall-provider", err)
+		}
+	}
+
+	if c.OfflineOK && c.OfflineDir == "" {
+		errs.add("offline-dir", fmt.Errorf("offline queue directory not specified"))
+	}
+
+	if err := ValidateFollowUp(c.CorrelationID, c.FollowUpAfter, c.FollowUpMessage, c.Resolved); err != nil {
+		errs.add("follow-up-after", err)
+	}
+
+	if (c.FollowUpAfter > 0 || c.Resolved || c.Subcommand == SubcommandServe) && c.FollowUpDir == "" {
+		errs.add("follow-up-dir", fmt.Errorf("follow-up directory not specified"))
+	}
+
+	if c.SessionID != "" {
+		if err := session.ValidateID(c.SessionID); err != nil {
+			errs.add("session-id", err)
+		}
+
+		if c.SessionDir == "" {
+			errs.add("session-dir", fmt.Errorf("session directory not specified"))
+		}
 	}
 
 	// Create Microsoft Teams client
 	mstClient := goteamsnotify.NewTeamsClient()
 
-	// Allow selective toggling of webhook URL validation.
-	if !disableWebhookURLValidation {
-		if err := mstClient.ValidateWebhook(c.WebhookURL); err != nil {
-			return fmt.Errorf("webhook URL validation failed: %w", err)
+	// Allow selective toggling of webhook URL validation. Bench mode
+	// submits messages to the built-in mock webhook server and no messages
+	// are submitted when previewing the card.
+	if !disableWebhookURLValidation && c.Subcommand != SubcommandBench && !c.PreviewTerminal {
+		if len(c.targets) == 0 {
+			if err := mstClient.ValidateWebhook(c.WebhookURL); err != nil {
+				errs.add("url", fmt.Errorf("webhook URL validation failed: %w", err))
+			}
+		}
+
+		// The webhook URL of each target is used in place of the
+		// user-specified webhook URL.
+		for _, target := range c.targets {
+			if err := mstClient.ValidateWebhook(target.WebhookURL); err != nil {
+				errs.add("targets", fmt.Errorf("webhook URL validation failed for target %q: %w", target.Name, err))
+			}
 		}
 	}
 
-	// Indicate that we didn't spot any problems
-	return nil
+	// Indicate whether we spotted any problems
+	return errs.err()
+
+}
+
+// validationWarnings returns the issues with the user-specified values which
+// do not prevent messages from being sent, but which are likely unintended.
+// Unlike the errors returned by Validate, these are reported (unless silent
+// output is requested) and included in JSON results.
+func (c Config) validationWarnings() []string {
+	switch c.Subcommand {
+	case SubcommandTop, SubcommandExportDefaults, SubcommandBench, SubcommandFlags, SubcommandMigrateURL,
+		SubcommandHistory, SubcommandDebugBundle:
+		// No messages are sent to Microsoft Teams by these modes.
+		return nil
+	}
+
+	var warnings []string
+
+	// Targets specify their own team and channel labels, which are unused
+	// when previewing the card.
+	if len(c.targets) == 0 && !c.PreviewTerminal {
+		var unspecified []string
+		if c.Team == defaultTeamName {
+			unspecified = append(unspecified, "team")
+		}
+		if c.Channel == defaultChannelName {
+			unspecified = append(unspecified, "channel")
+		}
+		switch len(unspecified) {
+		case 1:
+			warnings = append(warnings, fmt.Sprintf(
+				"%s label unspecified; log messages and results refer to %q",
+				unspecified[0],
+				defaultTeamName,
+			))
+		case 2:
+			warnings = append(warnings, fmt.Sprintf(
+				"team and channel labels unspecified; log messages and results refer to %q",
+				defaultTeamName,
+			))
+		}
+	}
+
+	if c.ThemeColor != defaultMessageThemeColor {
+		warnings = append(warnings, fmt.Sprintf(
+			"the color flag is no longer used; value %q ignored (use a message class color, theme or color rules instead)",
+			c.ThemeColor,
+		))
+	}
+
+	if c.class.Color != "" && c.Environment != "" && c.environmentBadge().color != "" {
+		warnings = append(warnings, fmt.Sprintf(
+			"the color of message class %q takes precedence over the color of environment %q",
+			c.class.Name,
+			c.Environment,
+		))
+	}
+
+	if c.Retries > 0 && c.RetriesDelay == 0 {
+		warnings = append(warnings, "retries-delay is 0; failed deliveries are retried immediately")
+	}
+
+	if c.ExecReportFailure && c.Exec == "" {
+		warnings = append(warnings, "the exec-report-failure flag has no effect without the exec flag")
+	}
+
+	if len(c.SectionStyles) > 0 && len(c.Sections) == 0 {
+		warnings = append(warnings, "the section-style flag has no effect without the section flag")
+	}
+
+	if c.ImageFit && len(c.ImageFiles) == 0 {
+		warnings = append(warnings, "the image-fit flag has no effect without the image-file flag")
+	}
+
+	if c.PropagateExit && c.Exec == "" {
+		warnings = append(warnings, "the propagate-exit flag has no effect without the exec flag")
+	}
+
+	if c.SpoolArchiveDir != "" && c.Subcommand != SubcommandWatchSpool {
+		warnings = append(warnings, fmt.Sprintf("the spool-archive-dir flag has no effect without the %s subcommand", SubcommandWatchSpool))
+	}
+
+	if c.RunTimeout > 0 && c.Subcommand != SubcommandRun {
+		warnings = append(warnings, fmt.Sprintf("the run-timeout flag has no effect without the %s subcommand", SubcommandRun))
+	}
+
+	if c.MaxRuntime > 0 && !c.NagiosTimeoutAware {
+		warnings = append(warnings, "the max-runtime flag has no effect without the nagios-timeout-aware flag")
+	}
+
+	if c.VerifyLinksFail && !c.VerifyLinks {
+		warnings = append(warnings, "the verify-links-fail flag has no effect without the verify-links flag")
+	}
+
+	if c.Subcommand == SubcommandServe && c.ListenHTTP != "" && !isLoopbackAddr(c.ListenHTTP) {
+		warnings = append(warnings, fmt.Sprintf(
+			"HTTP address %q is not a loopback address; requests are not authenticated",
+			c.ListenHTTP,
+		))
+	}
 
+	return warnings
 }
@@ -0,0 +1,152 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidationWarnings(t *testing.T) {
+	labeled := Config{
+		Team:       "Support",
+		Channel:    "Alerts",
+		ThemeColor: defaultMessageThemeColor,
+	}
+
+	tests := map[string]struct {
+		cfg  Config
+		want []string
+	}{
+		"none": {
+			cfg: labeled,
+		},
+		"unspecified labels": {
+			cfg: Config{
+				Team:       defaultTeamName,
+				Channel:    defaultChannelName,
+				ThemeColor: defaultMessageThemeColor,
+			},
+			want: []string{"team and channel labels unspecified"},
+		},
+		"ignored color": {
+			cfg:  Config{Team: "Support", Channel: "Alerts", ThemeColor: "#ff0000"},
+			want: []string{"color flag is no longer used"},
+		},
+		"ineffective flags": {
+			cfg: func() Config {
+				c := labeled
+				c.ExecReportFailure = true
+				c.VerifyLinksFail = true
+				return c
+			}(),
+			want: []string{"exec-report-failure", "verify-links-fail"},
+		},
+		"no messages sent": {
+			cfg: Config{Subcommand: SubcommandTop, Team: defaultTeamName, Channel: defaultChannelName},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := tt.cfg.validationWarnings()
+			if len(got) != len(tt.want) {
+				t.Fatalf("got warnings %q, want %d", got, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(got[i], want) {
+					t.Errorf("warning %q does not mention %q", got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateReportsAllProblems(t *testing.T) {
+	cfg := Config{
+		MessageText:  "Backup complete",
+		SilentOutput: true,
+		Retries:      -1,
+		RetriesDelay: -1,
+		OverBudget:   defaultOverBudget,
+	}
+
+	err := cfg.Validate(true)
+
+	var problems ValidationErrors
+	if !errors.As(err, &problems) {
+		t.Fatalf("got error %v; want ValidationErrors", err)
+	}
+
+	var got []string
+	for _, problem := range problems {
+		got = append(got, problem.Field)
+	}
+	if strings.Join(got, ",") != "retries,retries-delay" {
+		t.Errorf("got problems with fields %q; want retries and retries-delay", got)
+	}
+
+	if !strings.Contains(err.Error(), "2 configuration problems found") ||
+		!strings.Contains(err.Error(), "- retries-delay: retries delay too short") {
+		t.Errorf("got error message %q", err)
+	}
+
+	cfg.RetriesDelay = 0
+	if err := cfg.Validate(true); err == nil || err.Error() != "retries too short" {
+		t.Errorf("got error %v; want single problem described as-is", err)
+	}
+}
+
+func TestSectionsStringFlag(t *testing.T) {
+	var sections sectionsStringFlag
+	for _, value := range []string{"+Disk usage|/var at 91%", "|Inodes | 40%", "++1 rollback|", "Notes|see ticket"} {
+		if err := sections.Set(value); err != nil {
+			t.Fatalf("Set(%q) error = %v", value, err)
+		}
+	}
+
+	want := sectionsStringFlag{
+		{Title: "Disk usage", Text: "/var at 91%"},
+		{Text: "Inodes | 40%", Continued: true},
+		{Title: "+1 rollback", Continued: true},
+		{Title: "Notes", Text: "see ticket", Continued: true},
+	}
+	if !reflect.DeepEqual(sections, want) {
+		t.Errorf("sections = %+v; want %+v", sections, want)
+	}
+
+	for _, invalid := range []string{"no separator", "|", "+ | "} {
+		if err := sections.Set(invalid); err == nil {
+			t.Errorf("Set(%q) succeeded; want error", invalid)
+		}
+	}
+}
+
+func TestValidateBeforeCommand(t *testing.T) {
+	cfg := Config{
+		Exec:                        "uptime",
+		ExecTimeout:                 defaultExecTimeout,
+		OverBudget:                  defaultOverBudget,
+		DisableWebhookURLValidation: true,
+	}
+
+	// The message is yet to be provided by the command.
+	if err := cfg.validateBeforeCommand(); err != nil {
+		t.Errorf("got error %v; want none", err)
+	}
+	if err := cfg.Validate(true); err == nil {
+		t.Errorf("got no error once the command provided no message")
+	}
+
+	cfg.ExecTimeout = 0
+	if err := cfg.validateBeforeCommand(); err == nil || err.Error() != "exec timeout too short" {
+		t.Errorf("got error %v; want exec timeout too short", err)
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// configJSONEnvVar is the environment variable providing flag values as a
+// single JSON object, for environments (e.g., Kubernetes CronJobs and CI
+// jobs) where long command lines are awkward and files are unavailable.
+const configJSONEnvVar string = "SEND2TEAMS_CONFIG_JSON"
+
+// flagEnvVarPrefix is the prefix of the environment variable consulted for
+// each flag which is not specified via the command-line. The remainder of
+// the name is the flag name in upper case with dashes replaced by
+// underscores (e.g., SEND2TEAMS_RETRIES_DELAY).
+const flagEnvVarPrefix string = "SEND2TEAMS_"
+
+// envIgnoredFlags are the flags which are not set via environment variables,
+// as they replace the usual operation of the application.
+var envIgnoredFlags = map[string]struct{}{
+	"version":         {},
+	"v":               {},
+	"help-long":       {},
+	"help-man":        {},
+	"help-powershell": {},
+	"tf":              {},
+}
+
+// maxConfigJSONSize is the maximum size of the JSON object provided by the
+// configJSONEnvVar environment variable.
+const maxConfigJSONSize int = 1024 * 1024
+
+// loadConfigJSON applies the flag values given by the JSON object provided
+// by the configJSONEnvVar environment variable. Keys are flag names
+// (underscores may be used in place of dashes) and values are strings,
+// numbers or booleans; lists of values may be given for repeatable flags.
+// Values specified via the command-line take precedence.
+func (c *Config) loadConfigJSON() error {
+	data := bytes.TrimSpace([]byte(os.Getenv(configJSONEnvVar)))
+	switch {
+	case len(data) == 0:
+		return nil
+	case len(data) > maxConfigJSONSize:
+		return fmt.Errorf("%s exceeds %d bytes", configJSONEnvVar, maxConfigJSONSize)
+	}
+
+	values, err := parseFlagValuesJSON(data)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", configJSONEnvVar, err)
+	}
+
+	return applyFlagValues(values, nil, configJSONEnvVar)
+}
+
+// flagEnvVar returns the name of the environment variable consulted for the
+// given flag, or an empty string if the flag is not set via the environment.
+func flagEnvVar(name string) string {
+	if _, ok := envIgnoredFlags[name]; ok {
+		return ""
+	}
+
+	return flagEnvVarPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// loadFlagEnvVars applies the values of the environment variables consulted
+// for each flag which is not specified via the command-line, keeping
+// secrets such as the webhook URL out of process listings. Empty values are
+// ignored. Repeatable flags accept one value per line.
+func (c *Config) loadFlagEnvVars() error {
+	explicit := make(map[string]struct{})
+	flag.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = struct{}{}
+	})
+
+	var err error
+	flag.VisitAll(func(f *flag.Flag) {
+		envVar := flagEnvVar(f.Name)
+		if _, ok := explicit[f.Name]; ok || envVar == "" || err != nil {
+			return
+		}
+
+		value := os.Getenv(envVar)
+		if strings.TrimSpace(value) == "" {
+			return
+		}
+
+		values := []string{value}
+		if _, repeatable := flagType(f); repeatable {
+			values = nil
+			for _, line := range strings.Split(value, "\n") {
+				if line = strings.TrimRight(line, "\r"); strings.TrimSpace(line) != "" {
+					values = append(values, line)
+				}
+			}
+		}
+
+		for _, v := range values {
+			if setErr := flag.CommandLine.Set(f.Name, v); setErr != nil {
+				err = fmt.Errorf("invalid value %q for %s: %v", v, envVar, setErr)
+				return
+			}
+		}
+	})
+
+	return err
+}
+
+// parseFlagValuesJSON returns the flag values given by the JSON object,
+// keyed by the keys of the object. Null values are ignored.
+func parseFlagValuesJSON(data []byte) (map[string][]string, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, fmt.Errorf("expected a JSON object of flag values: %w", err)
+	}
+
+	values := make(map[string][]string, len(object))
+	for key, raw := range object {
+		var value interface{}
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil {
+			return nil, fmt.Errorf("invalid value for %q: %w", key, err)
+		}
+
+		list, ok := value.([]interface{})
+		if !ok {
+			list = []interface{}{value}
+		}
+
+		for _, item := range list {
+			switch v := item.(type) {
+			case nil:
+			case string:
+				values[key] = append(values[key], v)
+			case json.Number:
+				values[key] = append(values[key], v.String())
+			case bool:
+				values[key] = append(values[key], strconv.FormatBool(v))
+			default:
+				return nil, fmt.Errorf("unsupported value for %q; expected a string, number, boolean or list of these", key)
+			}
+		}
+	}
+
+	return values, nil
+}
+
+// applyFlagValues sets the given flag values, keyed by flag name (with
+// underscores permitted in place of dashes), unless the flag was specified
+// via the command-line. Keys in ignored are skipped and multiple values are
+// only accepted for repeatable flags. The source of the values is used to
+// describe any problems.
+func applyFlagValues(values map[string][]string, ignored map[string]struct{}, source string) error {
+	explicit := make(map[string]struct{})
+	flag.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = struct{}{}
+	})
+
+	// Keys are applied in a consistent order so that errors are reported
+	// deterministically.
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		name := strings.ReplaceAll(key, "_", "-")
+		if _, ok := ignored[name]; ok {
+			continue
+		}
+
+		f := flag.Lookup(name)
+		if f == nil {
+			return fmt.Errorf("unknown flag %q in %s", key, source)
+		}
+
+		if _, ok := explicit[name]; ok {
+			continue
+		}
+
+		if _, repeatable := flagType(f); !repeatable && len(values[key]) > 1 {
+			return fmt.Errorf("multiple values for %q in %s; the flag may only be specified once", key, source)
+		}
+
+		for _, value := range values[key] {
+			if err := flag.CommandLine.Set(name, value); err != nil {
+				return fmt.Errorf("invalid value %q for %q in %s: %v", value, key, source, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFlagValuesJSON(t *testing.T) {
+	doc := `{
+		"title": "Nightly backup",
+		"retries": 3,
+		"retries-delay": 1.5,
+		"silent": true,
+		"fact": ["Host=db01", "Size=12 GB"],
+		"sender": null
+	}`
+
+	got, err := parseFlagValuesJSON([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]string{
+		"title":         {"Nightly backup"},
+		"retries":       {"3"},
+		"retries-delay": {"1.5"},
+		"silent":        {"true"},
+		"fact":          {"Host=db01", "Size=12 GB"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+
+	for _, invalid := range []string{`["title"]`, `{"fact": [{"Host": "db01"}]}`, `{"title": `} {
+		if _, err := parseFlagValuesJSON([]byte(invalid)); err == nil {
+			t.Errorf("no error for %s", invalid)
+		}
+	}
+}
+
+func TestFlagEnvVar(t *testing.T) {
+	tests := map[string]string{
+		"url":           "SEND2TEAMS_URL",
+		"retries-delay": "SEND2TEAMS_RETRIES_DELAY",
+		"environment":   environmentEnvVar,
+		"version":       "",
+	}
+
+	for name, want := range tests {
+		if got := flagEnvVar(name); got != want {
+			t.Errorf("flagEnvVar(%q) = %q; want %q", name, got, want)
+		}
+	}
+}
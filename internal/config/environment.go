@@ -0,0 +1,89 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"os"
+	"strings"
+
+	"github.com/atc0005/go-teams-notify/v2/adaptivecard"
+)
+
+// environmentEnvVar is the environment variable consulted for the name of
+// the deployment environment if the environment flag is not specified.
+const environmentEnvVar string = "SEND2TEAMS_ENVIRONMENT"
+
+// environmentBadge is the label and title color used to mark messages sent
+// from a well-known deployment environment.
+type environmentBadge struct {
+	label string
+	color string
+}
+
+// environmentBadges are the badges of the well-known deployment
+// environments, keyed by the (lowercase) names commonly used for them.
+// Other environments are labeled with their name in uppercase and do not
+// change the title color.
+var environmentBadges = map[string]environmentBadge{
+	"prod":        {label: "PROD", color: adaptivecard.ColorAttention},
+	"prd":         {label: "PROD", color: adaptivecard.ColorAttention},
+	"production":  {label: "PROD", color: adaptivecard.ColorAttention},
+	"staging":     {label: "STAGING", color: adaptivecard.ColorWarning},
+	"stage":       {label: "STAGING", color: adaptivecard.ColorWarning},
+	"stg":         {label: "STAGING", color: adaptivecard.ColorWarning},
+	"preprod":     {label: "STAGING", color: adaptivecard.ColorWarning},
+	"test":        {label: "TEST", color: adaptivecard.ColorAccent},
+	"testing":     {label: "TEST", color: adaptivecard.ColorAccent},
+	"qa":          {label: "QA", color: adaptivecard.ColorAccent},
+	"uat":         {label: "UAT", color: adaptivecard.ColorAccent},
+	"dev":         {label: "DEV", color: adaptivecard.ColorGood},
+	"development": {label: "DEV", color: adaptivecard.ColorGood},
+}
+
+// loadEnvironment applies the name of the deployment environment from the
+// environment variable if not otherwise specified.
+func (c *Config) loadEnvironment() {
+	if c.Environment == "" {
+		c.Environment = os.Getenv(environmentEnvVar)
+	}
+	c.Environment = strings.TrimSpace(c.Environment)
+}
+
+// environmentBadge returns the badge for the user-specified deployment
+// environment. The zero value is returned if no environment was specified.
+func (c Config) environmentBadge() environmentBadge {
+	if c.Environment == "" {
+		return environmentBadge{}
+	}
+
+	if badge, ok := environmentBadges[strings.ToLower(c.Environment)]; ok {
+		return badge
+	}
+
+	return environmentBadge{label: strings.ToUpper(c.Environment)}
+}
+
+// DecorateTitle returns the given message title with the environment badge
+// (e.g., [PROD]), the title prefix of the selected message class and the
+// user-specified title prefix and suffix applied. Each is separated from the
+// title by a single space.
+func (c Config) DecorateTitle(title string) string {
+	parts := make([]string, 0, 5)
+
+	if badge := c.environmentBadge(); badge.label != "" {
+		parts = append(parts, "["+badge.label+"]")
+	}
+
+	for _, part := range []string{c.class.TitlePrefix, c.TitlePrefix, title, c.TitleSuffix} {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
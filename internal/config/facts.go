@@ -0,0 +1,295 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/atc0005/send2teams/internal/jsonpath"
+	"github.com/atc0005/send2teams/internal/teams"
+	"golang.org/x/term"
+)
+
+// maxJSONBodySize is the maximum number of bytes read from stdin for use
+// with the facts-from-json flag.
+const maxJSONBodySize int64 = 1024 * 1024
+
+// emptyFactValue is displayed in place of an empty fact value. Microsoft
+// Teams rejects facts without a value.
+const emptyFactValue string = "(empty)"
+
+// maxFactFileSize is the maximum size in bytes of a file providing the
+// value of a fact specified via the fact flag.
+const maxFactFileSize int64 = 16 * 1024
+
+// maxFactsCSVSize is the maximum size in bytes of a CSV file specified via
+// the facts-csv flag.
+const maxFactsCSVSize int64 = 256 * 1024
+
+// Prefixes of fact values specified via the fact flag. A value starting with
+// factFilePrefix is read from the named file; factLiteralPrefix stands for a
+// literal factFilePrefix.
+const (
+	factFilePrefix    string = "@"
+	factLiteralPrefix string = "@@"
+)
+
+// jsonFact is a fact extracted from a JSON body via a JSON path.
+type jsonFact struct {
+	title string
+	path  jsonpath.Path
+}
+
+// parseJSONFacts parses the given comma-separated list of JSON paths, each
+// optionally prefixed with a label (e.g., "Host=$.host").
+func parseJSONFacts(spec string) ([]jsonFact, error) {
+	var facts []jsonFact
+
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		var title string
+		expr := item
+		if label, rest, found := strings.Cut(item, "="); found {
+			title, expr = strings.TrimSpace(label), rest
+		}
+
+		path, err := jsonpath.Parse(expr)
+		if err != nil {
+			return nil, err
+		}
+
+		if title == "" {
+			title = path.Name()
+		}
+
+		facts = append(facts, jsonFact{title: title, path: path})
+	}
+
+	if len(facts) == 0 {
+		return nil, fmt.Errorf("no JSON paths specified for facts-from-json flag")
+	}
+
+	return facts, nil
+}
+
+// loadFacts resolves the values of the facts specified via the fact flag,
+// reading values given as @PATH from the named file. Trailing newlines are
+// removed from values read from a file.
+func (c *Config) loadFacts() error {
+	for _, fact := range c.Facts {
+		value := fact.Value
+
+		switch {
+		case strings.HasPrefix(value, factLiteralPrefix):
+			value = value[len(factFilePrefix):]
+
+		case strings.HasPrefix(value, factFilePrefix):
+			data, err := readFactFile(strings.TrimPrefix(value, factFilePrefix))
+			if err != nil {
+				return fmt.Errorf("failed to load value of %q fact: %w", fact.Title, err)
+			}
+			value = strings.TrimRight(string(data), "\r\n")
+		}
+
+		if strings.TrimSpace(value) == "" {
+			value = emptyFactValue
+		}
+
+		c.facts = append(c.facts, teams.Fact{Title: fact.Title, Value: value})
+	}
+
+	return nil
+}
+
+// readFactFile reads the file at the given path, limited to
+// maxFactFileSize bytes.
+func readFactFile(path string) ([]byte, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxFactFileSize+1))
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	case int64(len(data)) > maxFactFileSize:
+		return nil, fmt.Errorf("%s exceeds %d bytes", path, maxFactFileSize)
+	}
+
+	return data, nil
+}
+
+// loadCSVFacts displays the rows of the CSV file specified via the facts-csv
+// flag as facts in a dedicated section of the message.
+func (c *Config) loadCSVFacts() error {
+	if c.FactsCSV == "" {
+		return nil
+	}
+
+	f, err := os.Open(filepath.Clean(c.FactsCSV))
+	if err != nil {
+		return fmt.Errorf("failed to read facts CSV file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxFactsCSVSize+1))
+	switch {
+	case err != nil:
+		return fmt.Errorf("failed to read facts CSV file: %w", err)
+	case int64(len(data)) > maxFactsCSVSize:
+		return fmt.Errorf("facts CSV file %s exceeds %d bytes", c.FactsCSV, maxFactsCSVSize)
+	}
+
+	facts, err := parseCSVFacts(data)
+	if err != nil {
+		return fmt.Errorf("invalid facts CSV file %s: %w", c.FactsCSV, err)
+	}
+
+	c.sections = append(c.sections, teams.Section{Facts: facts})
+
+	return nil
+}
+
+// parseCSVFacts returns the facts given by the rows of the given two-column
+// CSV content. Lines starting with # are ignored and empty values are
+// displayed as a placeholder.
+func parseCSVFacts(data []byte) ([]teams.Fact, error) {
+	cr := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	cr.FieldsPerRecord = 2
+	cr.TrimLeadingSpace = true
+	cr.Comment = '#'
+
+	var facts []teams.Fact
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := cr.FieldPos(0)
+		if strings.TrimSpace(record[0]) == "" {
+			return nil, fmt.Errorf("record on line %d: the fact title is required", line)
+		}
+
+		value := record[1]
+		if strings.TrimSpace(value) == "" {
+			value = emptyFactValue
+		}
+
+		facts = append(facts, teams.Fact{Title: record[0], Value: value})
+	}
+
+	if len(facts) == 0 {
+		return nil, fmt.Errorf("no facts specified")
+	}
+
+	return facts, nil
+}
+
+// loadJSONFacts extracts the fields specified via the facts-from-json flag
+// from a JSON body. The JSON body is read from stdin if stdin is not a
+// terminal and provides content, otherwise the message text is used. Fields
+// not present in the JSON body are omitted with a warning.
+func (c *Config) loadJSONFacts() error {
+	if c.FactsFromJSON == "" {
+		return nil
+	}
+
+	if c.Subcommand == SubcommandServe {
+		return fmt.Errorf("unsupported: facts-from-json is not supported in %s mode", SubcommandServe)
+	}
+
+	specs, err := parseJSONFacts(c.FactsFromJSON)
+	if err != nil {
+		return err
+	}
+
+	body, err := c.readJSONBody()
+	if err != nil {
+		return err
+	}
+
+	if len(body) == 0 {
+		body = []byte(c.MessageText)
+	}
+
+	// If no message was specified the JSON body is shown as the message so
+	// that the original content remains available to recipients.
+	if c.MessageText == "" {
+		c.MessageText = string(body)
+	}
+
+	doc, err := jsonpath.Decode(body)
+	if err != nil {
+		return fmt.Errorf("failed to extract facts: %w", err)
+	}
+
+	for _, spec := range specs {
+		value, found := spec.path.Lookup(doc)
+		if !found {
+			c.warnings = append(c.warnings, fmt.Sprintf(
+				"JSON path %s not found in JSON body; omitting %q fact",
+				spec.path,
+				spec.title,
+			))
+			continue
+		}
+
+		text := jsonpath.Format(value)
+		if text == "" {
+			text = emptyFactValue
+		}
+
+		c.facts = append(c.facts, teams.Fact{Title: spec.title, Value: text})
+	}
+
+	return nil
+}
+
+// readJSONBody reads a JSON body from stdin if stdin is not a terminal. An
+// empty result is returned if stdin is a terminal or provides no content.
+// The body is read once and shared by the flags which use it.
+func (c *Config) readJSONBody() ([]byte, error) {
+	if c.jsonBody != nil {
+		return *c.jsonBody, nil
+	}
+
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil, nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(os.Stdin, maxJSONBodySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JSON body from stdin: %w", err)
+	}
+
+	if int64(len(data)) > maxJSONBodySize {
+		return nil, fmt.Errorf("JSON body read from stdin exceeds %d bytes", maxJSONBodySize)
+	}
+
+	data = bytes.TrimSpace(data)
+	c.jsonBody = &data
+
+	return data, nil
+}
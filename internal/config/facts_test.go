@@ -0,0 +1,97 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+func TestLoadFacts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "subject.txt")
+	if err := os.WriteFile(path, []byte("CN=web01\nO=Example\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var c Config
+	for _, value := range []string{
+		"Subject=@" + path,
+		"Handle=@@ops",
+		"Note = **bold**",
+		"Empty=",
+	} {
+		if err := c.Facts.Set(value); err != nil {
+			t.Fatalf("Set(%q) failed: %v", value, err)
+		}
+	}
+
+	if err := c.loadFacts(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []teams.Fact{
+		{Title: "Subject", Value: "CN=web01\nO=Example"},
+		{Title: "Handle", Value: "@ops"},
+		{Title: "Note", Value: " **bold**"},
+		{Title: "Empty", Value: emptyFactValue},
+	}
+
+	if len(c.facts) != len(want) {
+		t.Fatalf("got %d facts; want %d", len(c.facts), len(want))
+	}
+	for i := range want {
+		if c.facts[i] != want[i] {
+			t.Errorf("fact %d = %+v; want %+v", i, c.facts[i], want[i])
+		}
+	}
+
+	if err := c.Facts.Set("no separator"); err == nil {
+		t.Error("Set without a separator succeeded; want error")
+	}
+}
+
+func TestParseCSVFacts(t *testing.T) {
+	data := "\xef\xbb\xbf# nightly summary\n" +
+		"Host,db01\n" +
+		"Backup size, 12 GB\n" +
+		"\"Errors, warnings\",\n" +
+		"Duration,\"1h\n2m\"\n"
+
+	got, err := parseCSVFacts([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []teams.Fact{
+		{Title: "Host", Value: "db01"},
+		{Title: "Backup size", Value: "12 GB"},
+		{Title: "Errors, warnings", Value: emptyFactValue},
+		{Title: "Duration", Value: "1h\n2m"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v; want %+v", got, want)
+	}
+
+	invalid := map[string]string{
+		"Host,db01\nSize\n":   "record on line 2",
+		"Host,db01\n,12 GB\n": "record on line 2: the fact title is required",
+		"# only a comment\n":  "no facts specified",
+		"Host,db01,extra\n":   "record on line 1",
+	}
+
+	for doc, want := range invalid {
+		if _, err := parseCSVFacts([]byte(doc)); err == nil || !strings.HasPrefix(err.Error(), want) {
+			t.Errorf("got error %v for %q; want %q", err, doc, want)
+		}
+	}
+}
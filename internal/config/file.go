@@ -0,0 +1,430 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Names and prefixes of the sections supported in a configuration file.
+const (
+	defaultsSectionName   string = "defaults"
+	profileSectionPrefix  string = "profile."
+	classSectionPrefix    string = "class."
+	targetSectionPrefix   string = "target."
+	configFileCommentHash string = "#"
+	configFileCommentSemi string = ";"
+)
+
+// Keys which are specific to message class sections of a configuration
+// file. All other keys in a configuration file are flag names.
+const (
+	classKeyTitlePrefix      string = "title-prefix"
+	classKeyColor            string = "color"
+	classKeyProfile          string = "profile"
+	classKeyQuietHours       string = "quiet-hours"
+	classKeyQuietHoursPolicy string = "quiet-hours-policy"
+)
+
+// ErrInvalidConfigFile indicates that a configuration file could not be
+// parsed.
+var ErrInvalidConfigFile = errors.New("invalid configuration file")
+
+// configFileExcludedFlags are flags which may not be specified within a
+// configuration file.
+var configFileExcludedFlags = map[string]struct{}{
+	"config":  {},
+	"class":   {},
+	"version": {},
+	"v":       {},
+}
+
+// configSetting is a single key and value pair from a configuration file.
+type configSetting struct {
+	key   string
+	value string
+	line  int
+}
+
+// configSection is a named group of settings from a configuration file.
+type configSection struct {
+	name     string
+	settings []configSetting
+	line     int
+}
+
+// get returns the last value for the given key in the section.
+func (cs *configSection) get(key string) (string, bool) {
+	if cs == nil {
+		return "", false
+	}
+
+	value, found := "", false
+	for _, setting := range cs.settings {
+		if setting.key == key {
+			value, found = setting.value, true
+		}
+	}
+
+	return value, found
+}
+
+// configFile is the parsed content of a configuration file.
+type configFile struct {
+	path     string
+	sections map[string]*configSection
+}
+
+// section returns the section with the given name, or nil if not present.
+func (cf configFile) section(name string) *configSection {
+	return cf.sections[name]
+}
+
+// configProblem is a problem found at a line of a configuration file.
+type configProblem struct {
+	line int
+	msg  string
+}
+
+// parseConfigFile parses configuration file content in an INI-like format.
+// Settings are given as key = value pairs within [section] headers. Lines
+// starting with # or ; are ignored. Keys may be repeated.
+func parseConfigFile(path string, r io.Reader) (configFile, error) {
+	cf, problems, err := scanConfigFile(path, r)
+	if err != nil {
+		return configFile{}, err
+	}
+
+	if len(problems) > 0 {
+		return configFile{}, cf.errorf(problems[0].line, "%s", problems[0].msg)
+	}
+
+	return cf, nil
+}
+
+// scanConfigFile parses configuration file content, returning every problem
+// found (ordered by line) instead of stopping at the first. Lines with
+// problems are skipped, as are the settings of unsupported or duplicate
+// sections once their keys are checked. An error is returned only if the
+// content could not be read.
+func scanConfigFile(path string, r io.Reader) (configFile, []configProblem, error) {
+	cf := configFile{
+		path:     path,
+		sections: make(map[string]*configSection),
+	}
+
+	var problems []configProblem
+	report := func(line int, format string, args ...interface{}) {
+		problems = append(problems, configProblem{line: line, msg: fmt.Sprintf(format, args...)})
+	}
+
+	var current *configSection
+	var skipped []*configSection
+	var invalidSection bool
+	scanner := bufio.NewScanner(r)
+	var lineNum int
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "",
+			strings.HasPrefix(line, configFileCommentHash),
+			strings.HasPrefix(line, configFileCommentSemi):
+			continue
+
+		case strings.HasPrefix(line, "["):
+			if !strings.HasSuffix(line, "]") {
+				report(lineNum, "unterminated section header %q", line)
+				current, invalidSection = nil, true
+				continue
+			}
+
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			current = &configSection{name: name, line: lineNum}
+			invalidSection = false
+
+			if err := validateSectionName(name); err != nil {
+				report(lineNum, "%v", err)
+				current, invalidSection = nil, true
+				continue
+			}
+
+			if _, exists := cf.sections[name]; exists {
+				report(lineNum, "duplicate section %q", name)
+				skipped = append(skipped, current)
+				continue
+			}
+
+			cf.sections[name] = current
+
+		default:
+			if current == nil {
+				// The settings of an invalid section are not reported
+				// again.
+				if !invalidSection {
+					report(lineNum, "setting found outside of a section")
+				}
+				continue
+			}
+
+			key, value, found := strings.Cut(line, "=")
+			key = strings.TrimSpace(key)
+			if !found || key == "" {
+				report(lineNum, "expected key = value, got %q", line)
+				continue
+			}
+
+			current.settings = append(current.settings, configSetting{
+				key:   key,
+				value: unquote(strings.TrimSpace(value)),
+				line:  lineNum,
+			})
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return configFile{}, nil, fmt.Errorf("failed to read configuration file %s: %w", path, err)
+	}
+
+	for _, section := range cf.sections {
+		problems = append(problems, validateSection(section)...)
+	}
+	for _, section := range skipped {
+		problems = append(problems, validateSection(section)...)
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].line < problems[j].line
+	})
+
+	return cf, problems, nil
+}
+
+// readConfigFile reads and parses the configuration file at the given path.
+func readConfigFile(path string) (configFile, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return configFile{}, fmt.Errorf("failed to open configuration file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	return parseConfigFile(path, f)
+}
+
+// errorf returns an error describing a problem at the given line of the
+// configuration file.
+func (cf configFile) errorf(line int, format string, args ...interface{}) error {
+	return fmt.Errorf(
+		"%w: %s:%d: %s",
+		ErrInvalidConfigFile,
+		cf.path,
+		line,
+		fmt.Sprintf(format, args...),
+	)
+}
+
+// unquote removes a single pair of matching surrounding quotes from the
+// given value.
+func unquote(value string) string {
+	if len(value) >= 2 {
+		first, last := value[0], value[len(value)-1]
+		if (first == '"' || first == '\'') && first == last {
+			return value[1 : len(value)-1]
+		}
+	}
+
+	return value
+}
+
+// validateSectionName asserts that the given section name is supported.
+func validateSectionName(name string) error {
+	switch {
+	case name == defaultsSectionName:
+		return nil
+
+	case strings.HasPrefix(name, profileSectionPrefix) && len(name) > len(profileSectionPrefix),
+		strings.HasPrefix(name, classSectionPrefix) && len(name) > len(classSectionPrefix),
+		strings.HasPrefix(name, targetSectionPrefix) && len(name) > len(targetSectionPrefix):
+		return nil
+
+	default:
+		return fmt.Errorf(
+			"unsupported section %q; expected [%s], [%sNAME], [%sNAME] or [%sNAME]",
+			name,
+			defaultsSectionName,
+			profileSectionPrefix,
+			classSectionPrefix,
+			targetSectionPrefix,
+		)
+	}
+}
+
+// isClassKey indicates whether the given key is specific to message class
+// sections.
+func isClassKey(key string) bool {
+	switch key {
+	case classKeyTitlePrefix, classKeyColor, classKeyQuietHours, classKeyQuietHoursPolicy:
+		return true
+	default:
+		return false
+	}
+}
+
+// validateSection returns the problems with the keys within the given
+// section.
+func validateSection(section *configSection) []configProblem {
+	isClass := strings.HasPrefix(section.name, classSectionPrefix)
+
+	var problems []configProblem
+	report := func(line int, format string, args ...interface{}) {
+		problems = append(problems, configProblem{line: line, msg: fmt.Sprintf(format, args...)})
+	}
+
+	// Target sections describe a webhook destination rather than flag
+	// values.
+	if strings.HasPrefix(section.name, targetSectionPrefix) {
+		for _, setting := range section.settings {
+			if !isTargetKey(setting.key) {
+				report(setting.line, "unknown setting %q in section [%s]", setting.key, section.name)
+			}
+		}
+		return problems
+	}
+
+	for _, setting := range section.settings {
+		switch {
+		case isClass && isClassKey(setting.key):
+			continue
+
+		case setting.key == classKeyProfile && section.name != defaultsSectionName &&
+			!isClass:
+			report(setting.line, "profiles may not select another profile")
+			continue
+		}
+
+		if _, excluded := configFileExcludedFlags[setting.key]; excluded {
+			report(setting.line, "setting %q may not be specified in a configuration file", setting.key)
+			continue
+		}
+
+		if flag.CommandLine.Lookup(setting.key) == nil {
+			report(setting.line, "unknown setting %q in section [%s]", setting.key, section.name)
+		}
+	}
+
+	return problems
+}
+
+// loadConfigFile applies settings from the user-specified configuration
+// file. Values specified via command-line flags take precedence over the
+// selected message class, which takes precedence over the selected profile,
+// which takes precedence over the defaults section.
+func (c *Config) loadConfigFile() error {
+	if c.ConfigFile == "" {
+		switch {
+		case c.Class != "":
+			return fmt.Errorf("message class %q specified without a configuration file", c.Class)
+		case c.Profile != "":
+			return fmt.Errorf("profile %q specified without a configuration file", c.Profile)
+		case c.Targets != "":
+			return fmt.Errorf("targets %q specified without a configuration file", c.Targets)
+		default:
+			return nil
+		}
+	}
+
+	cf, err := readConfigFile(c.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	explicit := make(map[string]struct{})
+	flag.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = struct{}{}
+	})
+
+	var class *configSection
+	if c.Class != "" {
+		class = cf.section(classSectionPrefix + c.Class)
+		if class == nil {
+			return fmt.Errorf("message class %q not defined in configuration file %s", c.Class, c.ConfigFile)
+		}
+
+		if err := c.loadClass(class); err != nil {
+			return cf.errorf(class.line, "%v", err)
+		}
+	}
+
+	defaults := cf.section(defaultsSectionName)
+
+	if _, ok := explicit[classKeyProfile]; !ok {
+		if profile, ok := class.get(classKeyProfile); ok {
+			c.Profile = profile
+		} else if profile, ok := defaults.get(classKeyProfile); ok {
+			c.Profile = profile
+		}
+	}
+
+	var profile *configSection
+	if c.Profile != "" {
+		profile = cf.section(profileSectionPrefix + c.Profile)
+		if profile == nil {
+			return fmt.Errorf("profile %q not defined in configuration file %s", c.Profile, c.ConfigFile)
+		}
+	}
+
+	for _, section := range []*configSection{class, profile, defaults} {
+		if err := cf.applySection(section, explicit); err != nil {
+			return err
+		}
+	}
+
+	// The targets flag may be set by any of the applied sections.
+	return c.loadTargets(cf)
+}
+
+// applySection sets the flags named in the given section which have not
+// already been set, recording each as set once the section is applied.
+func (cf configFile) applySection(section *configSection, set map[string]struct{}) error {
+	if section == nil {
+		return nil
+	}
+
+	isClass := strings.HasPrefix(section.name, classSectionPrefix)
+
+	applied := make(map[string]struct{})
+	for _, setting := range section.settings {
+		if (isClass && isClassKey(setting.key)) || setting.key == classKeyProfile {
+			continue
+		}
+
+		if _, ok := set[setting.key]; ok {
+			continue
+		}
+
+		if err := flag.CommandLine.Set(setting.key, setting.value); err != nil {
+			return cf.errorf(setting.line, "invalid value %q for %q: %v", setting.value, setting.key, err)
+		}
+		applied[setting.key] = struct{}{}
+	}
+
+	for key := range applied {
+		set[key] = struct{}{}
+	}
+
+	return nil
+}
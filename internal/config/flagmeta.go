@@ -0,0 +1,266 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/atc0005/send2teams/internal/budget"
+	"github.com/atc0005/send2teams/internal/events"
+	"github.com/atc0005/send2teams/internal/oncall"
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// flagConstraint describes the values accepted by a flag beyond its type,
+// as enforced by validation.
+type flagConstraint struct {
+
+	// Choices are the supported values. Any value is accepted if empty.
+	Choices []string `json:"choices,omitempty"`
+
+	// Min is the minimum value (in the syntax of the flag value, e.g., 0 or
+	// 0s), if any.
+	Min string `json:"min,omitempty"`
+
+	// MinExclusive indicates that the value must be greater than Min.
+	MinExclusive bool `json:"min_exclusive,omitempty"`
+
+	// Requires are the flags which must also be specified.
+	Requires []string `json:"requires,omitempty"`
+
+	// Conflicts are the flags which may not be specified along with this
+	// flag.
+	Conflicts []string `json:"conflicts,omitempty"`
+}
+
+// flagConstraints are the constraints on flag values, keyed by flag name.
+var flagConstraints = map[string]flagConstraint{
+	"retries":                     {Min: "0"},
+	"retries-delay":               {Min: "0"},
+	"attempt-warn-threshold":      {Min: "0s"},
+	"max-runtime":                 {Min: "0s", Requires: []string{"nagios-timeout-aware"}},
+	"breaker-threshold":           {Min: "0"},
+	"breaker-cooldown":            {Min: "0s", MinExclusive: true},
+	"verify-links-timeout":        {Min: "0s", MinExclusive: true},
+	"verify-workflow-run-timeout": {Min: "0s", MinExclusive: true},
+	"summarize-lines":             {Min: "1"},
+	"image-max-bytes":             {Min: "1"},
+	"tail-lines":                  {Min: "1", Requires: []string{"tail"}},
+	"max-sends-per-hour":          {Min: "0"},
+	"max-sends-per-day":           {Min: "0"},
+	"over-budget":                 {Choices: []string{budget.ActionDrop, budget.ActionSpool, budget.ActionSummarize}},
+	"oncall-provider":             {Choices: []string{oncall.ProviderPagerDuty, oncall.ProviderOpsgenie, oncall.ProviderOpsgenieEU}},
+	"input-format":                {Choices: events.Formats()},
+	"nagios":                      {Conflicts: []string{"input-format", "map", "map-title", "map-text", "map-sender", "map-severity", "map-url", "card-file", "payload-file"}},
+	"propagate-exit":              {Requires: []string{"exec"}},
+	"run-timeout":                 {Min: "0s"},
+	"allow-empty-message":         {Conflicts: []string{"skip-empty", "payload-file"}},
+	"skip-empty":                  {Conflicts: []string{"allow-empty-message", "payload-file"}},
+	"compliance-tag":              {Conflicts: []string{"payload-file"}},
+	"integrity-fact":              {Conflicts: []string{"payload-file"}},
+	"sign-header":                 {Requires: []string{"sign-secret"}},
+	"interactive":                 {Conflicts: []string{"tf"}},
+	"to":                          {Requires: []string{"inventory"}, Conflicts: []string{"targets"}},
+	"response-choice":             {Requires: []string{"response-url"}},
+	"template-data":               {Requires: []string{"template"}},
+	"image-fit":                   {Requires: []string{"image-file"}},
+	"section-style":               {Choices: teams.SectionStyles(), Requires: []string{"section"}},
+	"target":                      {Choices: []string{BenchTargetMock}},
+	"duration":                    {Min: "0s", MinExclusive: true},
+	"mock-latency":                {Min: "0s"},
+	"poll-interval":               {Min: "0s", MinExclusive: true},
+	"debounce":                    {Min: "0s"},
+	"diff-lines":                  {Min: "0"},
+	"history-max-age":             {Min: "0s"},
+	"since":                       {Min: "0s", MinExclusive: true},
+	"expand-tabs":                 {Min: "0"},
+	"message-file":                {Conflicts: []string{"message", "exec"}},
+	"message-base64":              {Conflicts: []string{"message", "message-file", "exec"}},
+	"title-file":                  {Conflicts: []string{"title"}},
+	"card-file":                   {Conflicts: []string{"message", "message-base64", "message-file", "payload-file", "exec", "template", "input-format", "map", "map-title", "map-text", "map-sender", "map-severity", "map-url", "nagios"}},
+	"payload-file":                {Conflicts: []string{"title", "message", "message-base64", "message-file", "title-file", "footer-file", "card-file", "exec", "template", "input-format", "map", "map-title", "map-text", "map-sender", "map-severity", "map-url", "nagios", "facts-from-json", "facts-csv", "fact", "section", "target-url", "user-mention", "attach-file", "tail", "image-file", "report-csv", "allow-empty-message", "skip-empty", "compliance-tag", "integrity-fact"}},
+	"silent":                      {Conflicts: []string{"verbose"}},
+	"verbose":                     {Conflicts: []string{"silent"}},
+}
+
+// flagEnvVars are the environment variables consulted when a flag is not
+// specified in addition to the SEND2TEAMS_* variable for the flag, keyed by
+// flag name.
+var flagEnvVars = map[string][]string{
+	"oncall-token": {"PAGERDUTY_TOKEN", "OPSGENIE_API_KEY"},
+}
+
+// flagMetadata is the machine-readable description of a single flag.
+type flagMetadata struct {
+	Name string `json:"name"`
+
+	// Type is the type of the flag value: bool, string, int, uint, float or
+	// duration.
+	Type string `json:"type"`
+
+	// Default is the default value, as accepted by the flag.
+	Default string `json:"default"`
+
+	Usage    string `json:"usage"`
+	Category string `json:"category"`
+
+	// Repeatable indicates that the flag may be specified multiple times,
+	// each value being added to a list.
+	Repeatable bool `json:"repeatable"`
+
+	// Env are the environment variables consulted if the flag is not
+	// specified.
+	Env []string `json:"env"`
+
+	// Commands are the commands (e.g., send2teams or send2teams serve)
+	// supporting the flag.
+	Commands []string `json:"commands"`
+
+	Constraints *flagConstraint `json:"constraints,omitempty"`
+}
+
+// flagsDocument is the machine-readable description of all flags.
+type flagsDocument struct {
+	Name    string         `json:"name"`
+	Version string         `json:"version"`
+	Flags   []flagMetadata `json:"flags"`
+}
+
+// flagType returns the type of the value of the given flag. Flags whose
+// value does not provide the parsed value (i.e., the list flags defined by
+// this package) are described as repeatable strings.
+func flagType(f *flag.Flag) (string, bool) {
+	getter, ok := f.Value.(flag.Getter)
+	if !ok {
+		return "string", true
+	}
+
+	switch getter.Get().(type) {
+	case bool:
+		return "bool", false
+	case int, int64:
+		return "int", false
+	case uint, uint64:
+		return "uint", false
+	case float64:
+		return "float", false
+	case time.Duration:
+		return "duration", false
+	default:
+		return "string", false
+	}
+}
+
+// flagCommands returns the commands supporting the flags of each category,
+// keyed by category name.
+func flagCommands() map[string][]string {
+	commands := make(map[string][]string)
+
+	for _, sub := range append([]string{""}, subcommandOrder...) {
+		if !subcommandAvailable(sub) {
+			continue
+		}
+
+		name := myAppName
+		if sub != "" {
+			name += " " + sub
+		}
+
+		for _, group := range subcommandHelps[sub].groups {
+			commands[group] = append(commands[group], name)
+		}
+	}
+
+	return commands
+}
+
+// flagsMetadata returns the machine-readable description of all flags, in
+// category order.
+func flagsMetadata() flagsDocument {
+	doc := flagsDocument{Name: myAppName, Version: version, Flags: []flagMetadata{}}
+	commands := flagCommands()
+
+	add := func(f *flag.Flag, category string) {
+		typeName, repeatable := flagType(f)
+		_, usage := flag.UnquoteUsage(f)
+
+		meta := flagMetadata{
+			Name:       f.Name,
+			Type:       typeName,
+			Default:    f.DefValue,
+			Usage:      usage,
+			Category:   category,
+			Repeatable: repeatable,
+			Env:        flagEnvVars[f.Name],
+			Commands:   commands[category],
+		}
+
+		if envVar := flagEnvVar(f.Name); envVar != "" {
+			meta.Env = append([]string{envVar}, meta.Env...)
+		}
+		if meta.Env == nil {
+			meta.Env = []string{}
+		}
+		if meta.Commands == nil {
+			meta.Commands = []string{}
+		}
+
+		if constraint, ok := flagConstraints[f.Name]; ok {
+			meta.Constraints = &constraint
+		}
+
+		doc.Flags = append(doc.Flags, meta)
+	}
+
+	categorized := make(map[string]struct{})
+	for _, fg := range flagGroups {
+		for _, name := range fg.flags {
+			categorized[name] = struct{}{}
+			if f := flag.Lookup(name); f != nil {
+				add(f, fg.name)
+			}
+		}
+	}
+
+	flag.VisitAll(func(f *flag.Flag) {
+		if _, ok := categorized[f.Name]; !ok {
+			add(f, groupOther)
+		}
+	})
+
+	return doc
+}
+
+// writeFlags writes the description of all flags to the given writer, as
+// JSON if requested or otherwise as a table.
+func writeFlags(w io.Writer, asJSON bool) error {
+	doc := flagsMetadata()
+
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(doc)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FLAG\tTYPE\tDEFAULT\tCATEGORY")
+	for _, f := range doc.Flags {
+		typeName := f.Type
+		if f.Repeatable {
+			typeName += " (repeatable)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", f.Name, typeName, f.Default, f.Category)
+	}
+
+	return tw.Flush()
+}
@@ -7,35 +7,182 @@
 
 package config
 
-import "flag"
+import (
+	"flag"
+
+	"github.com/atc0005/send2teams/internal/templates"
+)
 
 // handleFlagsConfig wraps flag setup code into a bundle for potential ease of
 // use and future testability
-func (c *Config) handleFlagsConfig() {
+func (c *Config) handleFlagsConfig(args []string) {
 
+	flag.StringVar(&c.ConfigFile, "config", defaultConfigFile, configFileFlagHelp)
+	flag.StringVar(&c.Class, "class", defaultClass, classFlagHelp)
+	flag.StringVar(&c.Profile, "profile", defaultProfile, profileFlagHelp)
 	flag.BoolVar(&c.VerboseOutput, "verbose", defaultVerboseOutput, verboseOutputFlagHelp)
 	flag.BoolVar(&c.SilentOutput, "silent", defaultSilentOutput, silentOutputFlagHelp)
 	flag.BoolVar(&c.ConvertEOL, "convert-eol", defaultConvertEOL, convertEOLFlagHelp)
+	flag.BoolVar(&c.ConvertEscapedEOL, "convert-escaped-eol", defaultConvertEscapedEOL, convertEscapedEOLFlagHelp)
+	flag.BoolVar(&c.ConvertEOLCompat, "convert-eol-compat", defaultConvertEOLCompat, convertEOLCompatFlagHelp)
+	flag.BoolVar(&c.StrictSchema, "strict-schema", defaultStrictSchema, strictSchemaFlagHelp)
+	flag.BoolVar(&c.BidiIsolate, "bidi-isolate", defaultBidiIsolate, bidiIsolateFlagHelp)
+	flag.BoolVar(&c.EmojiFallback, "emoji-fallback", defaultEmojiFallback, emojiFallbackFlagHelp)
+	flag.IntVar(&c.ExpandTabs, "expand-tabs", defaultExpandTabs, expandTabsFlagHelp)
 	flag.BoolVar(&c.DisableWebhookURLValidation, "disable-url-validation", defaultDisableWebhookURLValidation, disableWebhookURLValidationFlagHelp)
+	flag.StringVar(&c.Record, "record", defaultRecord, recordFlagHelp)
+	flag.BoolVar(&c.ExplainValidation, "explain-validation", defaultExplainValidation, explainValidationFlagHelp)
+	flag.BoolVar(&c.PreviewTerminal, "preview-terminal", defaultPreviewTerminal, previewTerminalFlagHelp)
 	flag.BoolVar(&c.DisableBrandingTrailer, "disable-branding-trailer", defaultDisableBrandingTrailer, disableBrandingTrailerFlagHelp)
+	flag.Var(&c.ComplianceTags, "compliance-tag", complianceTagFlagHelp)
 	flag.BoolVar(&c.IgnoreInvalidResponse, "ignore-invalid-response", defaultIgnoreInvalidResponse, ignoreInvalidResponseFlagHelp)
 	flag.StringVar(&c.Team, "team", defaultTeamName, teamNameFlagHelp)
 	flag.Var(&c.TargetURLs, "target-url", targetURLFlagHelp)
 	flag.Var(&c.UserMentions, "user-mention", userMentionFlagHelp)
+	flag.StringVar(&c.ActivityTitle, "activity-title", defaultActivityTitle, activityTitleFlagHelp)
+	flag.StringVar(&c.ActivitySubtitle, "activity-subtitle", defaultActivitySubtitle, activitySubtitleFlagHelp)
+	flag.StringVar(&c.ActivityImage, "activity-image", defaultActivityImage, activityImageFlagHelp)
+	flag.StringVar(&c.ResponseURL, "response-url", defaultResponseURL, responseURLFlagHelp)
+	flag.Var(&c.ResponseChoices, "response-choice", responseChoiceFlagHelp)
+	flag.StringVar(&c.OnCallSchedule, "oncall-schedule", defaultOnCallSchedule, onCallScheduleFlagHelp)
+	flag.StringVar(&c.OnCallProvider, "oncall-provider", defaultOnCallProvider, onCallProviderFlagHelp)
+	flag.StringVar(&c.OnCallToken, "oncall-token", defaultOnCallToken, onCallTokenFlagHelp)
 	flag.StringVar(&c.Channel, "channel", defaultChannelName, channelNameFlagHelp)
 	flag.StringVar(&c.WebhookURL, "url", defaultWebhookURL, webhookURLFlagHelp)
+	flag.StringVar(&c.WebhookParts, "webhook-parts", defaultWebhookParts, webhookPartsFlagHelp)
+	flag.StringVar(&c.WebhookHost, "webhook-host", defaultWebhookHost, webhookHostFlagHelp)
+	flag.StringVar(&c.WebhookURLKeyVault, "url-keyvault", defaultWebhookURLKeyVault, webhookURLKeyVaultFlagHelp)
+	flag.StringVar(&c.WebhookURLAWSSSM, "url-aws-ssm", defaultWebhookURLAWSSSM, webhookURLAWSSSMFlagHelp)
+	flag.StringVar(&c.WebhookURLAWSSecrets, "url-aws-secrets", defaultWebhookURLAWSSecrets, webhookURLAWSSecretsFlagHelp)
 	flag.StringVar(&c.ThemeColor, "color", defaultMessageThemeColor, themeColorFlagHelp)
 	flag.StringVar(&c.MessageTitle, "title", defaultMessageTitle, titleFlagHelp)
+	flag.StringVar(&c.TitlePrefix, "title-prefix", defaultTitlePrefix, titlePrefixFlagHelp)
+	flag.StringVar(&c.TitleSuffix, "title-suffix", defaultTitleSuffix, titleSuffixFlagHelp)
+	flag.StringVar(&c.Environment, "environment", defaultEnvironment, environmentFlagHelp)
+	flag.BoolVar(&c.AllowUntitled, "allow-untitled", defaultAllowUntitled, allowUntitledFlagHelp)
 	flag.StringVar(&c.MessageText, "message", defaultMessageText, messageFlagHelp)
+	flag.StringVar(&c.MessageBase64, "message-base64", defaultMessageBase64, messageBase64FlagHelp)
+	flag.StringVar(&c.MessageFile, "message-file", defaultMessageFile, messageFileFlagHelp)
+	flag.StringVar(&c.TitleFile, "title-file", defaultTitleFile, titleFileFlagHelp)
+	flag.StringVar(&c.FooterFile, "footer-file", defaultFooterFile, footerFileFlagHelp)
+	flag.StringVar(&c.CardFile, "card-file", defaultCardFile, cardFileFlagHelp)
+	flag.StringVar(&c.PayloadFile, "payload-file", defaultPayloadFile, payloadFileFlagHelp)
 	flag.StringVar(&c.Sender, "sender", defaultSender, senderFlagHelp)
 	flag.IntVar(&c.Retries, "retries", defaultRetries, retriesFlagHelp)
 	flag.IntVar(&c.RetriesDelay, "retries-delay", defaultRetriesDelay, retriesDelayFlagHelp)
+	flag.BoolVar(&c.NagiosTimeoutAware, "nagios-timeout-aware", defaultNagiosTimeoutAware, nagiosTimeoutAwareFlagHelp)
+	flag.DurationVar(&c.MaxRuntime, "max-runtime", defaultMaxRuntime, maxRuntimeFlagHelp)
+	flag.DurationVar(&c.AttemptWarnThreshold, "attempt-warn-threshold", defaultAttemptWarnThreshold, attemptWarnThresholdFlagHelp)
+	flag.IntVar(&c.BreakerThreshold, "breaker-threshold", defaultBreakerThreshold, breakerThresholdFlagHelp)
+	flag.DurationVar(&c.BreakerCooldown, "breaker-cooldown", defaultBreakerCooldown, breakerCooldownFlagHelp)
+	flag.StringVar(&c.PauseFile, "pause-file", defaultPauseFile, pauseFileFlagHelp)
+	flag.StringVar(&c.SignSecret, "sign-secret", defaultSignSecret, signSecretFlagHelp)
+	flag.StringVar(&c.SignHeader, "sign-header", defaultSignHeader, signHeaderFlagHelp)
+	flag.BoolVar(&c.VerifyLinks, "verify-links", defaultVerifyLinks, verifyLinksFlagHelp)
+	flag.DurationVar(&c.VerifyLinksTimeout, "verify-links-timeout", defaultVerifyLinksTimeout, verifyLinksTimeoutFlagHelp)
+	flag.StringVar(&c.VerifyLinksAllow, "verify-links-allow", defaultVerifyLinksAllow, verifyLinksAllowFlagHelp)
+	flag.BoolVar(&c.VerifyLinksFail, "verify-links-fail", defaultVerifyLinksFail, verifyLinksFailFlagHelp)
+	flag.BoolVar(&c.VerifyWorkflowRun, "verify-workflow-run", defaultVerifyWorkflowRun, verifyWorkflowRunFlagHelp)
+	flag.DurationVar(&c.VerifyWorkflowRunTimeout, "verify-workflow-run-timeout", defaultVerifyWorkflowRunTimeout, verifyWorkflowRunTimeoutFlagHelp)
+	flag.StringVar(&c.IdempotencyKey, "idempotency-key", defaultIdempotencyKey, idempotencyKeyFlagHelp)
+	flag.StringVar(&c.IdempotencyDir, "idempotency-dir", defaultIdempotencyDir(), idempotencyDirFlagHelp)
+	flag.BoolVar(&c.TerraformMode, "tf", defaultTerraformMode, terraformFlagHelp)
 	flag.BoolVar(&c.ShowVersion, "version", defaultDisplayVersionAndExit, versionFlagHelp)
 	flag.BoolVar(&c.ShowVersion, "v", defaultDisplayVersionAndExit, versionFlagHelp+shorthandFlagSuffix)
+	flag.BoolVar(&c.HelpLong, "help-long", defaultHelpLong, helpLongFlagHelp)
+	flag.BoolVar(&c.HelpMan, "help-man", defaultHelpMan, helpManFlagHelp)
+	flag.BoolVar(&c.HelpPowerShell, "help-powershell", defaultHelpPowerShell, helpPowerShellFlagHelp)
+	flag.StringVar(&c.ListenUnix, "listen-unix", defaultListenUnix, listenUnixFlagHelp)
+	flag.StringVar(&c.ListenUnixMode, "listen-unix-mode", defaultListenUnixMode, listenUnixModeFlagHelp)
+	flag.StringVar(&c.ListenFIFO, "listen-fifo", defaultListenFIFO, listenFIFOFlagHelp)
+	flag.StringVar(&c.ListenHTTP, "listen-http", defaultListenHTTP, listenHTTPFlagHelp)
+	flag.StringVar(&c.JournalDir, "journal-dir", defaultJournalDir(), journalDirFlagHelp)
+	flag.StringVar(&c.BenchTarget, "target", defaultBenchTarget, benchTargetFlagHelp)
+	flag.StringVar(&c.BenchRate, "rate", defaultBenchRate, benchRateFlagHelp)
+	flag.DurationVar(&c.BenchDuration, "duration", defaultBenchDuration, benchDurationFlagHelp)
+	flag.DurationVar(&c.MockLatency, "mock-latency", defaultMockLatency, mockLatencyFlagHelp)
+	flag.BoolVar(&c.OnChange, "on-change", defaultOnChange, onChangeFlagHelp)
+	flag.BoolVar(&c.OnCreate, "on-create", defaultOnCreate, onCreateFlagHelp)
+	flag.BoolVar(&c.OnRemove, "on-remove", defaultOnRemove, onRemoveFlagHelp)
+	flag.DurationVar(&c.PollInterval, "poll-interval", defaultPollInterval, pollIntervalFlagHelp)
+	flag.DurationVar(&c.Debounce, "debounce", defaultDebounce, debounceFlagHelp)
+	flag.IntVar(&c.DiffLines, "diff-lines", defaultDiffLines, diffLinesFlagHelp)
+	flag.StringVar(&c.SpoolArchiveDir, "spool-archive-dir", defaultSpoolArchiveDir, spoolArchiveDirFlagHelp)
+	flag.StringVar(&c.ProvisionCommand, "provision-command", defaultProvisionCommand, provisionCommandFlagHelp)
+	flag.BoolVar(&c.WriteConfig, "write-config", defaultWriteConfig, writeConfigFlagHelp)
+	flag.BoolVar(&c.Online, "online", defaultOnline, onlineFlagHelp)
+	flag.BoolVar(&c.JSONOutput, "json", defaultJSONOutput, jsonOutputFlagHelp)
+	flag.BoolVar(&c.ReceiptFact, "receipt-fact", defaultReceiptFact, receiptFactFlagHelp)
+	flag.BoolVar(&c.IntegrityFact, "integrity-fact", defaultIntegrityFact, integrityFactFlagHelp)
+	flag.StringVar(&c.Exec, "exec", defaultExec, execFlagHelp)
+	flag.IntVar(&c.ExecTimeout, "exec-timeout", defaultExecTimeout, execTimeoutFlagHelp)
+	flag.BoolVar(&c.ExecReportFailure, "exec-report-failure", defaultExecReportFailure, execReportFailureFlagHelp)
+	flag.BoolVar(&c.PropagateExit, "propagate-exit", defaultPropagateExit, propagateExitFlagHelp)
+	flag.DurationVar(&c.RunTimeout, "run-timeout", defaultRunTimeout, runTimeoutFlagHelp)
+	flag.StringVar(&c.AllowEmptyMessage, "allow-empty-message", defaultAllowEmptyMessage, allowEmptyMessageFlagHelp)
+	flag.BoolVar(&c.SkipEmpty, "skip-empty", defaultSkipEmpty, skipEmptyFlagHelp)
+	flag.BoolVar(&c.Interactive, "interactive", defaultInteractive, interactiveFlagHelp)
+	flag.Var(&c.AttachFiles, "attach-file", attachFileFlagHelp)
+	flag.IntVar(&c.AttachMaxBytes, "attach-max-bytes", defaultAttachMaxBytes, attachMaxBytesFlagHelp)
+	flag.Var(&c.ImageFiles, "image-file", imageFileFlagHelp)
+	flag.IntVar(&c.ImageMaxBytes, "image-max-bytes", defaultImageMaxBytes, imageMaxBytesFlagHelp)
+	flag.BoolVar(&c.ImageFit, "image-fit", defaultImageFit, imageFitFlagHelp)
+	flag.BoolVar(&c.AttachChecksums, "attach-checksums", defaultAttachChecksums, attachChecksumsFlagHelp)
+	flag.StringVar(&c.Tail, "tail", defaultTail, tailFlagHelp)
+	flag.IntVar(&c.TailLines, "tail-lines", defaultTailLines, tailLinesFlagHelp)
+	flag.StringVar(&c.ReportCSV, "report-csv", defaultReportCSV, reportCSVFlagHelp)
+	flag.IntVar(&c.RowsPerCard, "rows-per-card", defaultRowsPerCard, rowsPerCardFlagHelp)
+	flag.Var(&c.Facts, "fact", factFlagHelp)
+	flag.Var(&c.Sections, "section", sectionFlagHelp)
+	flag.Var(&c.SectionStyles, "section-style", sectionStyleFlagHelp)
+	flag.StringVar(&c.FactsFromJSON, "facts-from-json", defaultFactsFromJSON, factsFromJSONFlagHelp)
+	flag.StringVar(&c.FactsCSV, "facts-csv", defaultFactsCSV, factsCSVFlagHelp)
+	flag.StringVar(&c.InputFormat, "input-format", defaultInputFormat, inputFormatFlagHelp)
+	flag.StringVar(&c.Map, "map", defaultMap, mapFlagHelp)
+	flag.StringVar(&c.MapTitle, "map-title", defaultMapTitle, mapTitleFlagHelp)
+	flag.StringVar(&c.MapText, "map-text", defaultMapText, mapTextFlagHelp)
+	flag.StringVar(&c.MapSender, "map-sender", defaultMapSender, mapSenderFlagHelp)
+	flag.StringVar(&c.MapSeverity, "map-severity", defaultMapSeverity, mapSeverityFlagHelp)
+	flag.StringVar(&c.MapURL, "map-url", defaultMapURL, mapURLFlagHelp)
+	flag.BoolVar(&c.Nagios, "nagios", defaultNagios, nagiosFlagHelp)
+	flag.StringVar(&c.Locale, "locale", defaultLocale, localeFlagHelp)
+	flag.StringVar(&c.Targets, "targets", defaultTargets, targetsFlagHelp)
+	flag.StringVar(&c.Inventory, "inventory", defaultInventory, inventoryFlagHelp)
+	flag.StringVar(&c.To, "to", defaultTo, toFlagHelp)
+	flag.BoolVar(&c.Summarize, "summarize", defaultSummarize, summarizeFlagHelp)
+	flag.IntVar(&c.SummarizeLines, "summarize-lines", defaultSummarizeLines, summarizeLinesFlagHelp)
+	flag.StringVar(&c.Template, "template", defaultTemplate, templateFlagHelp)
+	flag.StringVar(&c.TemplateChecksum, "template-checksum", defaultTemplateChecksum, templateChecksumFlagHelp)
+	flag.StringVar(&c.TemplateData, "template-data", defaultTemplateData, templateDataFlagHelp)
+	flag.StringVar(&c.TemplateCacheDir, "template-cache-dir", templates.DefaultCacheDir(), templateCacheDirFlagHelp)
+	flag.StringVar(&c.Theme, "theme", defaultTheme, themeFlagHelp)
+	flag.StringVar(&c.ThemeDir, "theme-dir", defaultThemeDir(), themeDirFlagHelp)
+	flag.StringVar(&c.ColorRules, "color-rules", defaultColorRules, colorRulesFlagHelp)
+	flag.IntVar(&c.MaxSendsPerHour, "max-sends-per-hour", defaultMaxSendsPerHour, maxSendsPerHourFlagHelp)
+	flag.IntVar(&c.MaxSendsPerDay, "max-sends-per-day", defaultMaxSendsPerDay, maxSendsPerDayFlagHelp)
+	flag.StringVar(&c.MaxPerTarget, "max-per-target", defaultMaxPerTarget, maxPerTargetFlagHelp)
+	flag.StringVar(&c.OverBudget, "over-budget", defaultOverBudget, overBudgetFlagHelp)
+	flag.StringVar(&c.BudgetDir, "budget-dir", defaultBudgetDir(), budgetDirFlagHelp)
+	flag.StringVar(&c.SessionID, "session-id", defaultSessionID, sessionIDFlagHelp)
+	flag.StringVar(&c.SessionDir, "session-dir", defaultSessionDir(), sessionDirFlagHelp)
+	flag.StringVar(&c.HistoryDir, "history-dir", defaultHistoryDir, historyDirFlagHelp)
+	flag.DurationVar(&c.HistoryMaxAge, "history-max-age", defaultHistoryMaxAge, historyMaxAgeFlagHelp)
+	flag.DurationVar(&c.HistorySince, "since", defaultSince, sinceFlagHelp)
+	flag.BoolVar(&c.HistoryFailedOnly, "failed-only", defaultFailedOnly, failedOnlyFlagHelp)
+	flag.BoolVar(&c.OfflineOK, "offline-ok", defaultOfflineOK, offlineOKFlagHelp)
+	flag.StringVar(&c.OfflineDir, "offline-dir", defaultOfflineDir(), offlineDirFlagHelp)
+	flag.StringVar(&c.CorrelationID, "correlation-id", defaultCorrelationID, correlationIDFlagHelp)
+	flag.DurationVar(&c.FollowUpAfter, "follow-up-after", defaultFollowUpAfter, followUpAfterFlagHelp)
+	flag.StringVar(&c.FollowUpMessage, "follow-up-message", defaultFollowUpMessage, followUpMessageFlagHelp)
+	flag.BoolVar(&c.Resolved, "resolved", defaultResolved, resolvedFlagHelp)
+	flag.StringVar(&c.FollowUpDir, "follow-up-dir", defaultFollowUpDir(), followUpDirFlagHelp)
+	flag.StringVar(&c.ArchiveS3, "archive-s3", defaultArchiveS3, archiveS3FlagHelp)
+	flag.StringVar(&c.ArchiveAzureBlob, "archive-azblob", defaultArchiveAzureBlob, archiveAzureBlobFlagHelp)
 
-	flag.Usage = flagsUsage()
+	flag.Usage = flagsUsage(c.Subcommand)
 
-	// parse flag definitions from the argument list
-	flag.Parse()
+	// parse flag definitions from the argument list (excluding any
+	// subcommand)
+	_ = flag.CommandLine.Parse(args)
 
 }
@@ -0,0 +1,34 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ValidateFollowUp asserts that the given follow-up settings for a message
+// are consistent.
+func ValidateFollowUp(correlationID string, after time.Duration, message string, resolved bool) error {
+	switch {
+	case after < 0:
+		return fmt.Errorf("follow-up time must not be negative")
+
+	case after > 0 && resolved:
+		return fmt.Errorf("unsupported: a resolved message cannot schedule a follow-up")
+
+	case (after > 0 || resolved) && strings.TrimSpace(correlationID) == "":
+		return fmt.Errorf("correlation ID not specified for follow-up")
+
+	case after > 0 && strings.TrimSpace(message) == "":
+		return fmt.Errorf("follow-up message not specified")
+	}
+
+	return nil
+}
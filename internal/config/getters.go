@@ -8,17 +8,53 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
+
+	"github.com/atc0005/send2teams/internal/budget"
+	"github.com/atc0005/send2teams/internal/teams"
 )
 
 // TeamsSubmissionTimeout is the timeout value for sending messages to
-// Microsoft Teams.
+// Microsoft Teams, including any time spent verifying workflow runs.
 func (c Config) TeamsSubmissionTimeout() time.Duration {
 
-	return time.Duration(c.Retries) *
+	// A retry schedule computed from the Nagios notification timeout has a
+	// fixed budget for all attempts.
+	if c.retrySchedule.attempts > 0 {
+		return c.retrySchedule.budget
+	}
+
+	timeout := time.Duration(c.Retries) *
 		time.Duration(c.RetriesDelay) *
 		teamsSubmissionTimeoutMultiplier
+
+	// Allow for waiting on the workflow run triggered by each attempt.
+	if c.VerifyWorkflowRun {
+		timeout += time.Duration(c.Retries+1) * c.VerifyWorkflowRunTimeout
+	}
+
+	return timeout
+}
+
+// AttemptTimeout returns the time allowed for each delivery attempt by the
+// retry schedule computed from the Nagios notification timeout, or zero if
+// attempts are only limited by the submission timeout.
+func (c Config) AttemptTimeout() time.Duration {
+	return c.retrySchedule.attemptTimeout
+}
+
+// SigningSecret returns the shared secret used to sign each request, or nil
+// if requests are not signed.
+func (c Config) SigningSecret() []byte {
+	return c.signSecret
 }
 
 // UserAgent returns a string usable as-is as a custom user agent for plugins
@@ -34,3 +70,391 @@ func (c Config) UserAgent() string {
 	)
 
 }
+
+// UnixSocketMode returns the filesystem permissions applied to the serve
+// mode unix domain socket. The default mode is returned if the
+// user-specified value is invalid.
+func (c Config) UnixSocketMode() os.FileMode {
+	mode, err := parseFileMode(c.ListenUnixMode)
+	if err != nil {
+		mode, _ = parseFileMode(defaultListenUnixMode)
+	}
+
+	return mode
+}
+
+// CardOptions returns the user-specified options for generating a Microsoft
+// Teams card for a message from the given sender.
+func (c Config) CardOptions(sender string) teams.CardOptions {
+	// A replayed invocation uses the recorded settings.
+	if c.replayed != nil {
+		return c.replayed.Card.Options()
+	}
+
+	opts := teams.CardOptions{
+		ConvertEOL:        c.ConvertEOL,
+		ConvertEscapedEOL: c.ConvertEscapedEOL,
+		LegacyConvertEOL:  c.ConvertEOLCompat,
+		BidiIsolate:       c.BidiIsolate,
+		EmojiFallback:     c.EmojiFallback,
+		ExpandTabs:        c.ExpandTabs,
+		IntegrityFact:     c.IntegrityFact,
+		ComplianceTags:    c.ComplianceTags.facts(),
+		TitleColor:        c.class.Color,
+		ColorRules:        c.colorRules,
+		Theme:             c.theme,
+	}
+
+	// The color of a message class takes precedence over the color of the
+	// deployment environment, which takes precedence over the severity of a
+	// translated event payload.
+	if opts.TitleColor == "" {
+		opts.TitleColor = c.environmentBadge().color
+	}
+	if opts.TitleColor == "" {
+		opts.TitleColor = c.inputColor
+	}
+
+	// If requested, skip appending the branding trailer to messages.
+	if !c.DisableBrandingTrailer {
+		opts.Trailer = MessageTrailer(sender)
+	}
+
+	return opts
+}
+
+// TeamsMessage returns the user-specified message details in a
+// format-neutral form suitable for generating a Microsoft Teams message.
+func (c Config) TeamsMessage() teams.Message {
+	// A replayed invocation uses the recorded message content.
+	if c.replayed != nil {
+		return c.replayed.Message
+	}
+
+	title := c.MessageTitle
+	if title == "" && c.AllowUntitled {
+		title = teams.DeriveTitle(c.MessageText, c.Sender)
+	}
+	title = c.DecorateTitle(title)
+
+	msg := teams.Message{
+		Title:  title,
+		Text:   c.MessageText,
+		Sender: c.Sender,
+		Footer: c.footer,
+		Activity: teams.Activity{
+			Title:    c.ActivityTitle,
+			Subtitle: c.ActivitySubtitle,
+			Image:    c.ActivityImage,
+		},
+	}
+
+	for _, target := range c.TargetURLs {
+		msg.TargetURLs = append(msg.TargetURLs, teams.TargetURL{
+			URL:         target.URL.String(),
+			Description: target.Description,
+		})
+	}
+
+	msg.Facts = append(msg.Facts, c.facts...)
+	msg.Sections = append(msg.Sections, c.sections...)
+	msg.Sections = append(msg.Sections, c.styledSections()...)
+
+	if c.report != nil {
+		msg.Facts = append(msg.Facts, c.report.SummaryFacts(c.RowsPerCard)...)
+	}
+
+	for _, img := range c.images {
+		msg.Images = append(msg.Images, teams.Image{URL: img.DataURI()})
+	}
+
+	for _, excerpt := range c.attachments {
+		attachment := teams.Attachment{
+			Name:      excerpt.Name,
+			Content:   excerpt.Content,
+			Truncated: excerpt.Truncated,
+			Language:  excerpt.Language,
+		}
+
+		if c.AttachChecksums {
+			attachment.SHA256 = excerpt.SHA256
+			attachment.Size = excerpt.Size
+		}
+
+		msg.Attachments = append(msg.Attachments, attachment)
+	}
+
+	for _, mention := range c.UserMentions {
+		msg.UserMentions = append(msg.UserMentions, teams.UserMention{
+			Name: mention.Name,
+			ID:   mention.ID,
+		})
+	}
+
+	return msg
+}
+
+// ReportPages returns the messages listing the rows of the CSV report (if
+// any) specified via the report-csv flag, titled using the given title of
+// the summary message.
+func (c Config) ReportPages(title string) []teams.Message {
+	if c.report == nil {
+		return nil
+	}
+
+	return c.report.PageMessages(title, c.Sender, c.RowsPerCard)
+}
+
+// Warnings returns the non-fatal issues encountered while loading the
+// configuration.
+func (c Config) Warnings() []string {
+	return c.warnings
+}
+
+// SendBudget returns the user-specified limits on the number of messages
+// sent to the webhook URL.
+func (c Config) SendBudget() budget.Limits {
+	limits := budget.Limits{
+		PerHour: c.MaxSendsPerHour,
+		PerDay:  c.MaxSendsPerDay,
+	}
+
+	// The limit is validated when the configuration is loaded.
+	if c.MaxPerTarget != "" {
+		limits.PerPeriod, limits.Period, _ = budget.ParseLimit(c.MaxPerTarget)
+	}
+
+	return limits
+}
+
+// defaultBudgetDir returns the default directory used to track messages sent
+// against the send budget. An empty string is returned if the user cache
+// directory cannot be determined.
+func defaultBudgetDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, myAppName, "budget")
+}
+
+// HistoryTargetName returns the name recorded in the send history for the
+// destination of the message: the name of the configuration file target
+// sent to, otherwise the name of the selected profile (if any).
+func (c Config) HistoryTargetName() string {
+	if c.targetName != "" {
+		return c.targetName
+	}
+
+	return c.Profile
+}
+
+// defaultSessionDir returns the default directory used to record sends
+// performed under a session ID. An empty string is returned if the user
+// cache directory cannot be determined.
+func defaultSessionDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, myAppName, "sessions")
+}
+
+// defaultResponseChoice is the response choice shown if none are specified
+// for the response URL.
+const defaultResponseChoice string = "Respond"
+
+// ResponseLinks returns a link for each user-specified response choice which
+// opens the response URL with the given receipt ID and the chosen response as
+// query parameters. Nil is returned if no response URL is specified.
+func (c Config) ResponseLinks(receiptID string) []teams.TargetURL {
+	if c.ResponseURL == "" {
+		return nil
+	}
+
+	choices := []string(c.ResponseChoices)
+	if len(choices) == 0 {
+		choices = []string{defaultResponseChoice}
+	}
+
+	links := make([]teams.TargetURL, 0, len(choices))
+	for _, choice := range choices {
+		// Validated as part of initializing the configuration.
+		u, _ := url.Parse(c.ResponseURL)
+
+		query := u.Query()
+		query.Set("receipt_id", receiptID)
+		if len(c.ResponseChoices) > 0 {
+			query.Set("response", choice)
+		}
+		u.RawQuery = query.Encode()
+
+		links = append(links, teams.TargetURL{URL: u.String(), Description: choice})
+	}
+
+	return links
+}
+
+// defaultThemeDir returns the default directory containing theme bundles
+// selected by name. An empty string is returned if the user configuration
+// directory cannot be determined.
+func defaultThemeDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, myAppName, "themes")
+}
+
+// defaultOfflineDir returns the default directory used to queue messages
+// submitted while the network is unavailable. An empty string is returned if
+// the user cache directory cannot be determined.
+func defaultOfflineDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, myAppName, "offline")
+}
+
+// defaultIdempotencyDir returns the default directory used to record the
+// messages sent for idempotency keys. An empty string is returned if the
+// user cache directory cannot be determined.
+func defaultIdempotencyDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, myAppName, "idempotency")
+}
+
+// defaultFollowUpDir returns the default directory used to record pending
+// follow-up messages. An empty string is returned if the user cache
+// directory cannot be determined.
+func defaultFollowUpDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, myAppName, "followups")
+}
+
+// defaultJournalDir returns the default directory used by serve mode to
+// checkpoint accepted messages. An empty string is returned if the user
+// cache directory cannot be determined.
+func defaultJournalDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, myAppName, "journal")
+}
+
+// ServeJournalDir returns the directory used by serve mode to checkpoint
+// accepted messages. Each unix domain socket path (or named pipe path or
+// HTTP address, if no socket is used) is given a separate directory so that multiple serve
+// instances may share the journal directory. An empty string is returned if
+// checkpointing is disabled.
+func (c Config) ServeJournalDir() string {
+	if c.JournalDir == "" {
+		return ""
+	}
+
+	socket := c.ListenUnix
+	if socket == "" {
+		socket = c.ListenFIFO
+	}
+	if socket != "" {
+		if abs, err := filepath.Abs(socket); err == nil {
+			socket = abs
+		}
+	} else {
+		socket = c.ListenHTTP
+	}
+	sum := sha256.Sum256([]byte(socket))
+
+	return filepath.Join(c.JournalDir, hex.EncodeToString(sum[:]))
+}
+
+// styledSections returns the sections specified via the section flag with
+// the styles specified via the section-style flag applied. The last style
+// applies to any sections beyond the number of styles.
+func (c Config) styledSections() []teams.Section {
+	if len(c.SectionStyles) == 0 {
+		return c.Sections
+	}
+
+	sections := make([]teams.Section, 0, len(c.Sections))
+	for i, section := range c.Sections {
+		style := c.SectionStyles[len(c.SectionStyles)-1]
+		if i < len(c.SectionStyles) {
+			style = c.SectionStyles[i]
+		}
+
+		section.Style = style
+		sections = append(sections, section)
+	}
+
+	return sections
+}
+
+// isLoopbackAddr indicates whether the host of the given TCP address (e.g.,
+// 127.0.0.1:8080) is a loopback address. An empty host listens on all
+// interfaces and is not a loopback address.
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
+
+// VerifyLinksAllowlist returns the hosts whose URLs are not checked when the
+// verify-links flag is specified.
+func (c Config) VerifyLinksAllowlist() []string {
+	var hosts []string
+	for _, host := range strings.Split(c.VerifyLinksAllow, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+
+	return hosts
+}
+
+// EmptyMessageSkipped indicates whether the message is skipped as requested
+// via the skip-empty flag because the message input is empty (or only
+// whitespace).
+func (c Config) EmptyMessageSkipped() bool {
+	return c.SkipEmpty && strings.TrimSpace(c.MessageText) == ""
+}
+
+// ExecExitCode returns the exit code of the command specified via the exec
+// flag for use as the exit code of the application, or 0 if no command was
+// run. If the command could not be started or timed out, 1 is returned.
+func (c Config) ExecExitCode() int {
+	switch {
+	case c.execData == nil:
+		return 0
+	case c.execData.ExitCode > 0:
+		return c.execData.ExitCode
+	case c.execData.Failed:
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,103 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+func TestResponseLinks(t *testing.T) {
+	tests := map[string]struct {
+		cfg  Config
+		want []teams.TargetURL
+	}{
+		"no response URL": {
+			cfg:  Config{ResponseChoices: responseChoicesStringFlag{"Approve"}},
+			want: nil,
+		},
+		"default choice": {
+			cfg: Config{ResponseURL: "https://ops.example.com/respond"},
+			want: []teams.TargetURL{
+				{URL: "https://ops.example.com/respond?receipt_id=r1", Description: "Respond"},
+			},
+		},
+		"choices with existing query": {
+			cfg: Config{
+				ResponseURL:     "https://ops.example.com/respond?form=override",
+				ResponseChoices: responseChoicesStringFlag{"Approve", "Deny & escalate"},
+			},
+			want: []teams.TargetURL{
+				{URL: "https://ops.example.com/respond?form=override&receipt_id=r1&response=Approve", Description: "Approve"},
+				{URL: "https://ops.example.com/respond?form=override&receipt_id=r1&response=Deny+%26+escalate", Description: "Deny & escalate"},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tt.cfg.ResponseLinks("r1"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResponseLinks() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecorateTitle(t *testing.T) {
+	tests := map[string]struct {
+		cfg       Config
+		title     string
+		want      string
+		wantColor string
+	}{
+		"undecorated": {
+			cfg:   Config{},
+			title: "Backup failed",
+			want:  "Backup failed",
+		},
+		"well-known environment": {
+			cfg:       Config{Environment: "Production", TitlePrefix: "billing:"},
+			title:     "Backup failed",
+			want:      "[PROD] billing: Backup failed",
+			wantColor: "attention",
+		},
+		"other environment": {
+			cfg:   Config{Environment: "eu-west", TitleSuffix: "(nightly)"},
+			title: "Backup failed",
+			want:  "[EU-WEST] Backup failed (nightly)",
+		},
+		"class prefix": {
+			cfg: Config{
+				Environment: "staging",
+				TitlePrefix: "billing:",
+				class:       MessageClass{TitlePrefix: "ALERT", Color: "accent"},
+			},
+			title:     "Backup failed",
+			want:      "[STAGING] ALERT billing: Backup failed",
+			wantColor: "accent",
+		},
+		"untitled": {
+			cfg:       Config{Environment: "dev"},
+			want:      "[DEV]",
+			wantColor: "good",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tt.cfg.DecorateTitle(tt.title); got != tt.want {
+				t.Errorf("DecorateTitle() = %q, want %q", got, tt.want)
+			}
+			if got := tt.cfg.CardOptions("").TitleColor; got != tt.wantColor {
+				t.Errorf("TitleColor = %q, want %q", got, tt.wantColor)
+			}
+		})
+	}
+}
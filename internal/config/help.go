@@ -0,0 +1,589 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/atc0005/send2teams/internal/help"
+)
+
+// Names of the flag categories used to group flags in help output.
+const (
+	groupWebhook   string = "Webhook"
+	groupContent   string = "Message content"
+	groupTemplates string = "Templates"
+	groupFormat    string = "Formatting"
+	groupConfig    string = "Configuration"
+	groupDelivery  string = "Delivery"
+	groupBudget    string = "Send budget"
+	groupOnCall    string = "On-call mentions"
+	groupFollowUp  string = "Follow-up messages"
+	groupSessions  string = "Sessions and archival"
+	groupHistory   string = "Send history"
+	groupServe     string = "Serve mode"
+	groupBench     string = "Bench mode"
+	groupWatch     string = "Watch mode"
+	groupMigrate   string = "URL migration"
+	groupLint      string = "Configuration linting"
+	groupOutput    string = "Output"
+)
+
+// groupOther is the category for flags which are not assigned a category.
+const groupOther string = "Other"
+
+// flagGroup is a category of related flags.
+type flagGroup struct {
+	name        string
+	description string
+	flags       []string
+}
+
+// flagGroups are the categories of flags, in display order. Each flag is
+// listed in exactly one category.
+var flagGroups = []flagGroup{
+	{
+		name:        groupWebhook,
+		description: "Where messages are sent. The webhook URL is specified directly, composed from its components, retrieved from a secret store or selected via named targets defined in a configuration file.",
+		flags: []string{
+			"url", "webhook-parts", "webhook-host", "url-keyvault", "url-aws-ssm",
+			"url-aws-secrets", "targets", "inventory", "to", "disable-url-validation",
+			"explain-validation", "team", "channel",
+		},
+	},
+	{
+		name:        groupContent,
+		description: "The content of the message. The message may be given directly, produced by a command or template and supplemented with facts, files, buttons and mentions.",
+		flags: []string{
+			"title", "title-prefix", "title-suffix", "environment", "allow-untitled", "message", "message-base64", "message-file", "title-file", "footer-file", "card-file", "payload-file", "sender", "exec", "exec-timeout", "exec-report-failure", "propagate-exit", "run-timeout",
+			"allow-empty-message", "skip-empty", "interactive",
+			"fact", "section", "section-style", "facts-from-json", "facts-csv",
+			"input-format", "map", "map-title", "map-text", "map-sender", "map-severity", "map-url", "nagios", "attach-file", "attach-max-bytes", "attach-checksums", "tail", "tail-lines", "image-file", "image-max-bytes", "image-fit", "report-csv",
+			"rows-per-card", "summarize",
+			"summarize-lines", "target-url", "user-mention", "activity-title",
+			"activity-subtitle", "activity-image", "response-url", "response-choice",
+			"receipt-fact", "integrity-fact",
+		},
+	},
+	{
+		name:        groupTemplates,
+		description: "Rendering the message from a local or remote template.",
+		flags:       []string{"template", "template-data", "template-checksum", "template-cache-dir", "locale"},
+	},
+	{
+		name:        groupFormat,
+		description: "How the message is converted to a Microsoft Teams card.",
+		flags: []string{
+			"theme", "theme-dir", "color-rules", "convert-eol", "convert-escaped-eol",
+			"convert-eol-compat", "bidi-isolate", "emoji-fallback", "expand-tabs",
+			"disable-branding-trailer", "compliance-tag", "strict-schema", "color", "preview-terminal",
+		},
+	},
+	{
+		name:        groupConfig,
+		description: "Default flag values, channel profiles and message classes provided by a configuration file, and recording invocations for later replay. Any flag may also be set via a SEND2TEAMS_* environment variable (e.g., SEND2TEAMS_URL for the url flag) or the SEND2TEAMS_CONFIG_JSON object.",
+		flags:       []string{"config", "class", "profile", "record"},
+	},
+	{
+		name:        groupDelivery,
+		description: "How delivery is attempted and how failures are handled.",
+		flags: []string{
+			"retries", "retries-delay", "nagios-timeout-aware", "max-runtime",
+			"attempt-warn-threshold",
+			"breaker-threshold", "breaker-cooldown", "pause-file",
+			"sign-secret", "sign-header",
+			"ignore-invalid-response", "offline-ok", "offline-dir",
+			"verify-links", "verify-links-timeout", "verify-links-allow",
+			"verify-links-fail", "verify-workflow-run", "verify-workflow-run-timeout",
+			"idempotency-key", "idempotency-dir",
+		},
+	},
+	{
+		name:        groupBudget,
+		description: "Limits on the number of messages sent to a webhook URL, shared by all invocations using the same budget directory.",
+		flags: []string{
+			"max-sends-per-hour", "max-sends-per-day", "max-per-target",
+			"over-budget", "budget-dir",
+		},
+	},
+	{
+		name:        groupOnCall,
+		description: "Mentioning the users currently on call for a PagerDuty or Opsgenie schedule.",
+		flags:       []string{"oncall-schedule", "oncall-provider", "oncall-token"},
+	},
+	{
+		name:        groupFollowUp,
+		description: "Posting a follow-up message if an issue is not resolved in time.",
+		flags: []string{
+			"correlation-id", "follow-up-after", "follow-up-message", "resolved",
+			"follow-up-dir",
+		},
+	},
+	{
+		name:        groupSessions,
+		description: "Recording the outcome of sends for a session summary and archiving submitted payloads.",
+		flags:       []string{"session-id", "session-dir", "archive-s3", "archive-azblob"},
+	},
+	{
+		name:        groupHistory,
+		description: "Recording the outcome of each send locally and listing past sends via the history subcommand.",
+		flags:       []string{"history-dir", "history-max-age", "since", "failed-only"},
+	},
+	{
+		name:        groupServe,
+		description: "The unix domain socket relay used by the serve and top subcommands.",
+		flags:       []string{"listen-unix", "listen-unix-mode", "listen-fifo", "listen-http", "journal-dir"},
+	},
+	{
+		name:        groupBench,
+		description: "Benchmarking message submission against the built-in mock webhook server.",
+		flags:       []string{"target", "rate", "duration", "mock-latency"},
+	},
+	{
+		name:        groupWatch,
+		description: "Which changes to a watched file or directory send a message and how they are described, and how files dropped into a spool directory are picked up.",
+		flags: []string{
+			"on-change", "on-create", "on-remove", "poll-interval", "debounce",
+			"diff-lines", "spool-archive-dir",
+		},
+	},
+	{
+		name:        groupMigrate,
+		description: "Replacing an Office 365 connector webhook URL with a workflow URL.",
+		flags:       []string{"provision-command", "write-config"},
+	},
+	{
+		name:        groupLint,
+		description: "How configuration files are checked for problems.",
+		flags:       []string{"online"},
+	},
+	{
+		name:        groupOutput,
+		description: "What is displayed while running and how help is requested.",
+		flags: []string{
+			"verbose", "silent", "json", "tf", "version", "v", "help-long",
+			"help-man", "help-powershell",
+		},
+	},
+}
+
+// subcommandHelp is the usage information for a subcommand (or for sending
+// a message if no subcommand is specified).
+type subcommandHelp struct {
+	summary     string
+	description string
+	synopsis    []string
+	groups      []string
+	examples    []help.Example
+}
+
+// subcommandHelps is the usage information for each subcommand, keyed by
+// name. The entry for sending a message (no subcommand) has an empty name.
+var subcommandHelps = map[string]subcommandHelp{
+	"": {
+		summary: "send messages to Microsoft Teams channels",
+		description: "Submits a message to a Microsoft Teams channel using an incoming webhook URL, " +
+			"retrying failed attempts. The message may be given directly, produced by a command " +
+			"or template and supplemented with facts, files, buttons and user mentions.\n\n" +
+			"Flag defaults may be provided by a configuration file; values specified via the " +
+			"command-line take precedence.",
+		synopsis: []string{
+			myAppName + " [flags]",
+			myAppName + " SUBCOMMAND [ARGUMENT] [flags]",
+		},
+		groups: []string{
+			groupWebhook, groupContent, groupTemplates, groupFormat, groupConfig,
+			groupDelivery, groupBudget, groupOnCall, groupFollowUp, groupSessions,
+			groupHistory, groupOutput, groupOther,
+		},
+		examples: []help.Example{
+			{
+				Description: "Send a simple message:",
+				Command:     myAppName + ` -url "$WEBHOOK_URL" -title "Backup complete" -message "All volumes backed up"`,
+			},
+			{
+				Description: "Send the output of a command, summarized if very large:",
+				Command:     myAppName + ` -url "$WEBHOOK_URL" -title "Nightly report" -exec "/usr/local/bin/report" -summarize`,
+			},
+			{
+				Description: "Send a message to targets defined in a configuration file:",
+				Command:     myAppName + ` -config /etc/send2teams.conf -targets ops,dev -title "Deploy" -message "v2 released"`,
+			},
+			{
+				Description: "Check why a webhook URL is rejected:",
+				Command:     myAppName + ` -explain-validation -url "$WEBHOOK_URL"`,
+			},
+		},
+	},
+	SubcommandServe: {
+		summary: "relay messages submitted via a unix domain socket or named pipe",
+		description: "Accepts messages from local clients via a unix domain socket (or lines written " +
+			"to a named pipe) and delivers them in the background. Accepted messages may be " +
+			"checkpointed to a journal so that they are neither lost nor duplicated if the host " +
+			"restarts mid-delivery.",
+		synopsis: []string{
+			myAppName + " " + SubcommandServe + " -listen-unix PATH [flags]",
+			myAppName + " " + SubcommandServe + " -listen-fifo PATH [flags]",
+			myAppName + " " + SubcommandServe + " -listen-http ADDR [flags]",
+		},
+		groups: []string{groupWebhook, groupServe, groupFormat, groupConfig, groupDelivery, groupBudget, groupSessions, groupOutput},
+		examples: []help.Example{
+			{
+				Description: "Relay messages to a webhook URL, checkpointing them to the default journal directory:",
+				Command:     myAppName + ` serve -url "$WEBHOOK_URL" -listen-unix /run/send2teams.sock`,
+			},
+			{
+				Description: "Relay each line written to a named pipe by a legacy daemon:",
+				Command:     myAppName + ` serve -url "$WEBHOOK_URL" -listen-fifo /run/send2teams.fifo`,
+			},
+			{
+				Description: "Accept messages from producers via the HTTP API on the loopback interface:",
+				Command:     myAppName + ` serve -url "$WEBHOOK_URL" -listen-http 127.0.0.1:8080`,
+			},
+		},
+	},
+	SubcommandTop: {
+		summary:     "display the live status of a serve mode delivery queue",
+		description: "Connects to a running serve instance and displays its delivery queue, allowing failed messages to be retried or discarded.",
+		synopsis:    []string{myAppName + " " + SubcommandTop + " -listen-unix PATH [flags]"},
+		groups:      []string{groupServe, groupOutput},
+		examples: []help.Example{
+			{
+				Description: "Monitor the serve instance listening on the given socket:",
+				Command:     myAppName + ` top -listen-unix /run/send2teams.sock`,
+			},
+		},
+	},
+	SubcommandSessionSummary: {
+		summary:     "post a summary card for the sends recorded under a session ID",
+		description: "Posts a single card summarizing all sends recorded under the given session ID (counts, first and last timestamps and failures) and removes the session records.",
+		synopsis:    []string{myAppName + " " + SubcommandSessionSummary + " SESSION-ID [flags]"},
+		groups:      []string{groupWebhook, groupSessions, groupFormat, groupConfig, groupDelivery, groupOutput},
+		examples: []help.Example{
+			{
+				Description: "Summarize the sends performed by a script using the session ID nightly-42:",
+				Command:     myAppName + ` session-summary nightly-42 -url "$WEBHOOK_URL"`,
+			},
+		},
+	},
+	SubcommandBench: {
+		summary:     "benchmark message submission against a mock webhook server",
+		description: "Submits generated messages at a fixed rate to a built-in mock webhook server and reports throughput and latency percentiles.",
+		synopsis:    []string{myAppName + " " + SubcommandBench + " [flags]"},
+		groups:      []string{groupBench, groupFormat, groupDelivery, groupOutput},
+		examples: []help.Example{
+			{
+				Description: "Submit 50 messages per second for 30 seconds with simulated latency:",
+				Command:     myAppName + ` bench -rate 50/s -duration 30s -mock-latency 250ms`,
+			},
+		},
+	},
+	SubcommandExportDefaults: {
+		summary:     "write the embedded default themes and schemas to a directory",
+		description: "Writes the embedded default files to the given directory so that they may be customized. Existing files are left as-is.",
+		synopsis:    []string{myAppName + " " + SubcommandExportDefaults + " DIR [flags]"},
+		groups:      []string{groupOutput},
+		examples: []help.Example{
+			{
+				Description: "Export the defaults to the user configuration directory:",
+				Command:     myAppName + ` export-defaults ~/.config/send2teams`,
+			},
+		},
+	},
+	SubcommandReplay: {
+		summary:     "re-execute an invocation recorded via the record flag",
+		description: "Sends the message recorded in the given invocation file again exactly as it was generated. Flags specified along with the subcommand take precedence over recorded values.",
+		synopsis:    []string{myAppName + " " + SubcommandReplay + " FILE [flags]"},
+		groups:      []string{groupWebhook, groupDelivery, groupOutput},
+		examples: []help.Example{
+			{
+				Description: "Replay a recorded invocation against a test channel:",
+				Command:     myAppName + ` replay /tmp/invocation.json -url "$TEST_WEBHOOK_URL"`,
+			},
+		},
+	},
+	SubcommandWatchFile: {
+		summary:     "send a message each time a file or directory changes",
+		description: "Watches the given file or directory, sending a message (optionally rendered from a template) describing the changed files along with a diff of changes to text files. A burst of changes produces a single message.",
+		synopsis:    []string{myAppName + " " + SubcommandWatchFile + " PATH [flags]"},
+		groups:      []string{groupWebhook, groupWatch, groupContent, groupTemplates, groupFormat, groupConfig, groupDelivery, groupOutput},
+		examples: []help.Example{
+			{
+				Description: "Alert on changes to a configuration file using a custom template:",
+				Command:     myAppName + ` watch-file /etc/app/app.conf -on-change -template drift.tmpl -url "$WEBHOOK_URL"`,
+			},
+		},
+	},
+	SubcommandWatchSpool: {
+		summary:     "send a message for each file dropped into a spool directory",
+		description: "Watches the given spool directory, sending a message for each file dropped into it (oldest first) once the file has not been modified for the debounce period. A file with a .json extension contains a JSON message definition in the format used by the batch subcommand; the content of any other file is used as the message text. Once sent, the file is moved to the archive directory or deleted. Files which could not be sent are left in place and retried, while files which do not describe a valid message are renamed with a .failed extension. Hidden files and files with a .tmp or .part extension are ignored, so producers may write a file under a temporary name before renaming it into place without ever waiting on the network.",
+		synopsis:    []string{myAppName + " " + SubcommandWatchSpool + " DIR [flags]"},
+		groups:      []string{groupWebhook, groupWatch, groupContent, groupFormat, groupConfig, groupDelivery, groupOutput},
+		examples: []help.Example{
+			{
+				Description: "Send the messages dropped into a spool directory, archiving each file once sent:",
+				Command:     myAppName + ` watch-spool /var/spool/send2teams -spool-archive-dir /var/spool/send2teams-sent -url "$WEBHOOK_URL"`,
+			},
+			{
+				Description: "Spool a message from a producer without waiting on the network:",
+				Command:     `printf '%s' "$REPORT" > /var/spool/send2teams/.report.tmp && mv /var/spool/send2teams/.report.tmp /var/spool/send2teams/report-$(date +%s).txt`,
+			},
+		},
+	},
+	SubcommandBatch: {
+		summary:     "send each message defined by a newline delimited JSON file",
+		description: "Reads one JSON message definition (title, text, color and an optional webhook_url override) per line from the given file, or from stdin if no file (or -) is given, and sends each message in turn. A result is reported for each message; the exit code is non-zero if any message could not be sent. Facts, target URLs and user mentions specified via flags are added to every message.",
+		synopsis:    []string{myAppName + " " + SubcommandBatch + " [FILE] [flags]"},
+		groups:      []string{groupWebhook, groupContent, groupFormat, groupConfig, groupDelivery, groupOutput},
+		examples: []help.Example{
+			{
+				Description: "Send the messages generated by a nightly report script:",
+				Command:     myAppName + ` batch /var/tmp/nightly.ndjson -url "$WEBHOOK_URL" -json`,
+			},
+			{
+				Description: "Send messages written to stdin, stopping further attempts to a failing webhook URL:",
+				Command:     `./report.sh | ` + myAppName + ` batch - -url "$WEBHOOK_URL" -breaker-threshold 3`,
+			},
+		},
+	},
+	SubcommandHistory: {
+		summary:     "list the sends recorded in the history directory",
+		description: "Lists the sends recorded in the history directory within the given period (most recent last), optionally only those to the given target, profile or channel or only those which failed. Answers questions such as whether a backup alert was actually sent last night without searching the channel.",
+		synopsis:    []string{myAppName + " " + SubcommandHistory + " [flags]"},
+		groups:      []string{groupHistory, groupBench, groupConfig, groupOutput},
+		examples: []help.Example{
+			{
+				Description: "List failed sends to the ops target during the last day:",
+				Command:     myAppName + ` history -since 24h -target ops -failed-only`,
+			},
+			{
+				Description: "Emit the sends of the last week as JSON:",
+				Command:     myAppName + ` history -since 168h -json`,
+			},
+		},
+	},
+	SubcommandMigrateURL: {
+		summary:     "explain and migrate an Office 365 connector webhook URL to a workflow",
+		description: "Identifies the kind of the webhook URL, explains how the retirement of Office 365 connectors affects it and, if a provision command is specified, runs the command to create the replacement workflow. The new webhook URL may be written to the configuration file in place of the connector URL.",
+		synopsis:    []string{myAppName + " " + SubcommandMigrateURL + " [flags]"},
+		groups:      []string{groupWebhook, groupMigrate, groupConfig, groupOutput},
+		examples: []help.Example{
+			{
+				Description: "Explain how the retirement of connectors affects the webhook URL of a profile:",
+				Command:     myAppName + ` migrate-url -config /etc/send2teams.conf -profile ops`,
+			},
+			{
+				Description: "Create the replacement workflow using a helper script and update the profile:",
+				Command:     myAppName + ` migrate-url -config /etc/send2teams.conf -profile ops -provision-command "./create-workflow.sh" -write-config`,
+			},
+		},
+	},
+	SubcommandLintConfig: {
+		summary:     "check a configuration file for problems",
+		description: "Checks the given configuration file (or the file specified via the config flag) for syntax errors, unknown sections and settings, invalid values, references to undefined profiles or targets and settings which conflict once the defaults section is combined with a profile or message class. Each problem is reported along with its file and line. If requested, the webhook URLs are also checked for reachability. The exit code is non-zero if any errors are found.",
+		synopsis:    []string{myAppName + " " + SubcommandLintConfig + " [FILE] [flags]"},
+		groups:      []string{groupLint, groupOutput},
+		examples: []help.Example{
+			{
+				Description: "Check a configuration file before deploying it:",
+				Command:     myAppName + ` lint-config /etc/send2teams.conf`,
+			},
+			{
+				Description: "Also check that the webhook URLs are reachable:",
+				Command:     myAppName + ` lint-config /etc/send2teams.conf -online`,
+			},
+		},
+	},
+	SubcommandRun: {
+		summary:     "run a command and report its exit status and output",
+		description: "Runs the command given after the flags (following \"--\"), capturing its standard output, standard error and exit code. A message is then sent reporting whether the command succeeded, with a green (success) or red (failure) title, along with the output of the command as code blocks in dedicated sections. The title and message default to a description of the outcome. Once the message is sent, the application exits with the exit code of the command so that it may wrap commands transparently in cron jobs and CI steps.",
+		synopsis:    []string{myAppName + " " + SubcommandRun + " [flags] -- COMMAND [ARGS...]"},
+		groups:      []string{groupWebhook, groupContent, groupTemplates, groupFormat, groupConfig, groupDelivery, groupOutput},
+		examples: []help.Example{
+			{
+				Description: "Report the outcome of a nightly backup:",
+				Command:     myAppName + ` run -url "$WEBHOOK_URL" -- /usr/local/bin/backup.sh --full`,
+			},
+			{
+				Description: "Terminate the command if it runs for more than 30 minutes:",
+				Command:     myAppName + ` run -profile ops -run-timeout 30m -title "Database vacuum" -- vacuumdb --all`,
+			},
+		},
+	},
+	SubcommandDebugBundle: {
+		summary:     "write redacted diagnostics to a zip archive for a problem report",
+		description: "Writes the effective configuration, recent history entries, an environment summary, version details and the payload which would be sent to the given zip archive. Webhook URLs and credentials are redacted so that the archive may be attached to a public issue. The flags used for the failing invocation should be specified along with the subcommand.",
+		synopsis:    []string{myAppName + " " + SubcommandDebugBundle + " FILE [flags]"},
+		groups:      []string{groupWebhook, groupContent, groupTemplates, groupFormat, groupConfig, groupDelivery, groupHistory, groupOutput},
+		examples: []help.Example{
+			{
+				Description: "Collect diagnostics for a failing invocation using a profile:",
+				Command:     myAppName + ` debug-bundle /tmp/send2teams-debug.zip -config /etc/send2teams.conf -profile ops -title "Backup failed" -message "See log"`,
+			},
+		},
+	},
+	SubcommandFlags: {
+		summary:     "describe all supported flags for use by external tools",
+		description: "Lists every flag along with its type, default value and category. The json flag emits a machine-readable description which additionally includes the usage, environment variables, supporting commands and validation constraints of each flag.",
+		synopsis:    []string{myAppName + " " + SubcommandFlags + " [-json]"},
+		groups:      []string{groupOutput},
+		examples: []help.Example{
+			{
+				Description: "List the names of all repeatable flags:",
+				Command:     myAppName + ` flags -json | jq -r '.flags[] | select(.repeatable) | .name'`,
+			},
+		},
+	},
+}
+
+// subcommandOrder is the order in which subcommands are listed.
+var subcommandOrder = []string{
+	SubcommandServe, SubcommandTop, SubcommandSessionSummary, SubcommandBench,
+	SubcommandExportDefaults, SubcommandReplay, SubcommandWatchFile,
+	SubcommandWatchSpool, SubcommandBatch, SubcommandRun, SubcommandHistory, SubcommandMigrateURL,
+	SubcommandLintConfig, SubcommandDebugBundle,
+	SubcommandFlags,
+}
+
+// helpPage returns the usage information for the given subcommand (or for
+// sending a message if no subcommand is specified) from the flag metadata.
+func helpPage(subcommand string) help.Page {
+	info := subcommandHelps[subcommand]
+
+	name := myAppName
+	if subcommand != "" {
+		name += " " + subcommand
+	}
+
+	page := help.Page{
+		Name:        name,
+		Version:     version,
+		URL:         myAppURL,
+		Summary:     info.summary,
+		Description: info.description,
+		Synopsis:    info.synopsis,
+		Examples:    info.examples,
+	}
+
+	if subcommand == "" {
+		for _, sub := range subcommandOrder {
+			if subcommandAvailable(sub) {
+				page.Commands = append(page.Commands, help.Command{Name: sub, Summary: subcommandHelps[sub].summary})
+			}
+		}
+	}
+
+	groups := make(map[string]*help.Group, len(flagGroups)+1)
+	categorized := make(map[string]string)
+	for _, fg := range flagGroups {
+		groups[fg.name] = &help.Group{Name: fg.name, Description: fg.description}
+		for _, name := range fg.flags {
+			categorized[name] = fg.name
+		}
+	}
+	groups[groupOther] = &help.Group{Name: groupOther}
+
+	// Flags are listed in category order rather than alphabetically.
+	for _, fg := range flagGroups {
+		for _, name := range fg.flags {
+			if f := flag.Lookup(name); f != nil {
+				groups[fg.name].Flags = append(groups[fg.name].Flags, helpFlag(f))
+			}
+		}
+	}
+
+	flag.VisitAll(func(f *flag.Flag) {
+		if _, ok := categorized[f.Name]; !ok {
+			groups[groupOther].Flags = append(groups[groupOther].Flags, helpFlag(f))
+		}
+	})
+
+	for _, name := range info.groups {
+		page.Groups = append(page.Groups, *groups[name])
+	}
+
+	footer := "Run \"" + name + " -help-long\" for detailed help with examples"
+	if subcommand == "" {
+		footer += " or \"" + myAppName + " SUBCOMMAND -h\" for help with a subcommand"
+	}
+	page.Footer = footer + "."
+
+	return page
+}
+
+// helpFlag returns the help metadata for the given flag.
+func helpFlag(f *flag.Flag) help.Flag {
+	typeName, usage := flag.UnquoteUsage(f)
+
+	hf := help.Flag{Name: f.Name, Type: typeName, Usage: usage}
+
+	// Zero values are not noted, matching the standard flag package.
+	switch f.DefValue {
+	case "", "false", "0", "0s":
+	default:
+		hf.Default = f.DefValue
+		if typeName == "string" {
+			hf.Default = `"` + f.DefValue + `"`
+		}
+	}
+
+	return hf
+}
+
+// powerShellExcludedFlags are the flags which are not mapped to parameters
+// of the generated PowerShell module since they do not send a message.
+var powerShellExcludedFlags = map[string]struct{}{
+	"version": {}, "v": {}, "help-long": {}, "help-man": {}, "help-powershell": {},
+	"explain-validation": {}, "preview-terminal": {},
+}
+
+// powerShellPage returns the help metadata used to generate the PowerShell
+// module, omitting flags which do not send a message.
+func powerShellPage() help.Page {
+	page := helpPage("")
+
+	for i, group := range page.Groups {
+		flags := make([]help.Flag, 0, len(group.Flags))
+		for _, f := range group.Flags {
+			if _, excluded := powerShellExcludedFlags[f.Name]; !excluded {
+				flags = append(flags, f)
+			}
+		}
+		page.Groups[i].Flags = flags
+	}
+
+	return page
+}
+
+// WriteHelp writes the detailed help for the user-specified subcommand (or
+// for sending a message if no subcommand is specified) to the given writer,
+// as a man page or PowerShell module if requested. The description of all
+// flags is written for the flags subcommand.
+func (c Config) WriteHelp(w io.Writer) error {
+	if c.HelpPowerShell {
+		if c.Subcommand != "" {
+			return fmt.Errorf("unsupported: the PowerShell module is only generated for sending messages, not the %s subcommand", c.Subcommand)
+		}
+		return powerShellPage().WritePowerShell(w)
+	}
+
+	if c.Subcommand == SubcommandFlags && !c.HelpLong && !c.HelpMan {
+		return writeFlags(w, c.JSONOutput)
+	}
+
+	page := helpPage(c.Subcommand)
+
+	if c.HelpMan {
+		return page.WriteMan(w, time.Now())
+	}
+
+	return page.WriteText(w, true)
+}
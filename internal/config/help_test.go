@@ -0,0 +1,113 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"flag"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var registerFlagsOnce sync.Once
+
+// registerFlags defines the application flags on the default flag set, as
+// flags may only be defined once.
+func registerFlags() {
+	registerFlagsOnce.Do(func() {
+		var c Config
+		c.handleFlagsConfig(nil)
+	})
+}
+
+// TestFlagGroups asserts that every flag is assigned to exactly one
+// category so that no flag is omitted from (or listed twice in) the help
+// output.
+func TestFlagGroups(t *testing.T) {
+	registerFlags()
+
+	seen := make(map[string]string)
+	for _, fg := range flagGroups {
+		for _, name := range fg.flags {
+			if other, ok := seen[name]; ok {
+				t.Errorf("flag %q listed in both %q and %q categories", name, other, fg.name)
+			}
+			seen[name] = fg.name
+
+			if flag.Lookup(name) == nil {
+				t.Errorf("flag %q listed in %q category is not defined", name, fg.name)
+			}
+		}
+	}
+
+	flag.VisitAll(func(f *flag.Flag) {
+		// Flags registered by the testing package are ignored.
+		if strings.HasPrefix(f.Name, "test.") {
+			return
+		}
+
+		if _, ok := seen[f.Name]; !ok {
+			t.Errorf("flag %q is not assigned to a category", f.Name)
+		}
+	})
+
+	groups := map[string]struct{}{groupOther: {}}
+	for _, fg := range flagGroups {
+		groups[fg.name] = struct{}{}
+	}
+
+	for _, sub := range append([]string{""}, subcommandOrder...) {
+		info, ok := subcommandHelps[sub]
+		if !ok {
+			t.Errorf("no help defined for subcommand %q", sub)
+			continue
+		}
+
+		for _, name := range info.groups {
+			if _, ok := groups[name]; !ok {
+				t.Errorf("subcommand %q references undefined category %q", sub, name)
+			}
+		}
+	}
+}
+
+// TestFlagsMetadata asserts that the machine-readable flag metadata only
+// describes defined flags and that every flag is supported by a command.
+func TestFlagsMetadata(t *testing.T) {
+	registerFlags()
+
+	for name := range flagConstraints {
+		if flag.Lookup(name) == nil {
+			t.Errorf("constraint defined for undefined flag %q", name)
+		}
+	}
+
+	for name := range flagEnvVars {
+		if flag.Lookup(name) == nil {
+			t.Errorf("environment variables defined for undefined flag %q", name)
+		}
+	}
+
+	for _, f := range flagsMetadata().Flags {
+		if strings.HasPrefix(f.Name, "test.") {
+			continue
+		}
+
+		if len(f.Commands) == 0 {
+			t.Errorf("flag %q is not supported by any command", f.Name)
+		}
+
+		if f.Name == "target-url" && !f.Repeatable {
+			t.Errorf("flag %q not described as repeatable", f.Name)
+		}
+
+		if f.Name == "retries-delay" && f.Type != "int" {
+			t.Errorf("flag %q described as %s, expected int", f.Name, f.Type)
+		}
+	}
+}
@@ -0,0 +1,656 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/atc0005/go-teams-notify/v2/adaptivecard"
+	"github.com/atc0005/send2teams/internal/colorrule"
+	"github.com/atc0005/send2teams/internal/defaults"
+	"github.com/atc0005/send2teams/internal/inlineimage"
+	"github.com/atc0005/send2teams/internal/input"
+	"github.com/atc0005/send2teams/internal/report"
+	"github.com/atc0005/send2teams/internal/teams"
+	"github.com/atc0005/send2teams/internal/templates"
+	"github.com/atc0005/send2teams/internal/theme"
+)
+
+// maxExecOutputSize is the maximum number of bytes of output retained from a
+// command specified via the exec flag. Microsoft Teams rejects messages
+// larger than approximately 28 KB.
+const maxExecOutputSize int = 24 * 1024
+
+// maxSummarizeInputSize is the maximum number of bytes of output retained
+// from a command specified via the exec flag when the output is summarized.
+const maxSummarizeInputSize int = 16 * 1024 * 1024
+
+// execTruncatedNotice is appended to the message when output from a command
+// specified via the exec flag exceeds maxExecOutputSize.
+const execTruncatedNotice string = "\n\n(output truncated)"
+
+// maxTailSize is the maximum number of bytes retained from the end of the
+// log file specified via the tail flag.
+const maxTailSize int = 16 * 1024
+
+// maxTitleFileSize is the maximum size of the file specified via the
+// title-file flag.
+const maxTitleFileSize int = 1024
+
+// maxFooterFileSize is the maximum size of the file specified via the
+// footer-file flag. The footer is displayed in small text below the message,
+// so longer content belongs in the message itself.
+const maxFooterFileSize int = 4 * 1024
+
+// templateFetchTimeout is the maximum amount of time allowed to retrieve a
+// remote message template.
+const templateFetchTimeout time.Duration = 15 * time.Second
+
+// loadMessageInput retrieves message content from any user-specified
+// sources other than the message flag.
+func (c *Config) loadMessageInput() error {
+	// The decoded message is used as if specified via the message flag.
+	if err := c.loadMessageBase64(); err != nil {
+		return err
+	}
+
+	if err := c.loadPayloadFile(); err != nil {
+		return err
+	}
+
+	if err := c.loadTitleFile(); err != nil {
+		return err
+	}
+
+	if err := c.loadCardFile(); err != nil {
+		return err
+	}
+
+	if err := c.loadMessageFile(); err != nil {
+		return err
+	}
+
+	if err := c.loadFooterFile(); err != nil {
+		return err
+	}
+
+	if err := c.loadExecOutput(); err != nil {
+		return err
+	}
+
+	if err := c.loadRunOutput(); err != nil {
+		return err
+	}
+
+	if err := c.loadInputFormat(); err != nil {
+		return err
+	}
+
+	if err := c.loadFieldMappings(); err != nil {
+		return err
+	}
+
+	if err := c.loadNagiosMacros(); err != nil {
+		return err
+	}
+
+	if err := c.loadAttachments(); err != nil {
+		return err
+	}
+
+	if err := c.loadImages(); err != nil {
+		return err
+	}
+
+	if err := c.loadTail(); err != nil {
+		return err
+	}
+
+	if err := c.loadStdinMessage(os.Stdin); err != nil {
+		return err
+	}
+
+	if err := c.loadReport(); err != nil {
+		return err
+	}
+
+	if err := c.loadFacts(); err != nil {
+		return err
+	}
+
+	if err := c.loadCSVFacts(); err != nil {
+		return err
+	}
+
+	// Facts are extracted before the message is summarized so that the
+	// complete JSON body is available.
+	if err := c.loadJSONFacts(); err != nil {
+		return err
+	}
+
+	if c.Summarize && c.SummarizeLines > 0 {
+		c.MessageText = teams.Summarize(c.MessageText, c.SummarizeLines)
+	}
+
+	if err := c.renderTemplate(); err != nil {
+		return err
+	}
+
+	// Input which produced no content (e.g., a command without output) is
+	// replaced by the user-specified placeholder, if any.
+	if c.AllowEmptyMessage != "" && strings.TrimSpace(c.MessageText) == "" {
+		c.MessageText = c.AllowEmptyMessage
+	}
+
+	return nil
+}
+
+// loadMessageFile uses the content of the file specified via the
+// message-file flag as the message.
+func (c *Config) loadMessageFile() error {
+	if c.MessageFile == "" {
+		return nil
+	}
+
+	switch {
+	case c.MessageText != "":
+		return fmt.Errorf("unsupported: You cannot specify both the message and message-file flags")
+	case c.Exec != "":
+		return fmt.Errorf("unsupported: You cannot specify both the exec and message-file flags")
+	}
+
+	// As with command output, more of the file is retained for
+	// summarization.
+	maxSize := maxExecOutputSize
+	if c.Summarize {
+		maxSize = maxSummarizeInputSize
+	}
+
+	// Log excerpts archived in compressed form are decompressed.
+	data, truncated, err := input.ReadDecompressed(c.MessageFile, maxSize)
+	if err != nil {
+		return fmt.Errorf("failed to read message file: %w", err)
+	}
+
+	if strings.TrimSpace(string(data)) == "" {
+		return fmt.Errorf("message file %s is empty", c.MessageFile)
+	}
+
+	c.MessageText = string(data)
+	if truncated {
+		c.MessageText = strings.ToValidUTF8(c.MessageText, "") + execTruncatedNotice
+	}
+
+	return nil
+}
+
+// loadMessageBase64 uses the decoded value of the message-base64 flag as the
+// message. Whitespace within the value (e.g., line wrapping added by base64
+// tools) is ignored. The decoded message must be UTF-8 text; binary content
+// is rejected rather than submitted.
+func (c *Config) loadMessageBase64() error {
+	if c.MessageBase64 == "" {
+		return nil
+	}
+
+	switch {
+	case c.MessageText != "":
+		return fmt.Errorf("unsupported: You cannot specify both the message and message-base64 flags")
+	case c.MessageFile != "":
+		return fmt.Errorf("unsupported: You cannot specify both the message-file and message-base64 flags")
+	case c.Exec != "":
+		return fmt.Errorf("unsupported: You cannot specify both the exec and message-base64 flags")
+	}
+
+	data, err := decodeBase64(c.MessageBase64)
+	switch {
+	case err != nil:
+		return fmt.Errorf("failed to decode message-base64 flag value: %w", err)
+	case !utf8.Valid(data) || bytes.IndexByte(data, 0) != -1:
+		return fmt.Errorf("decoded message-base64 flag value is not UTF-8 text")
+	case strings.TrimSpace(string(data)) == "":
+		return fmt.Errorf("decoded message-base64 flag value is empty")
+	}
+
+	// As with command output, more of the message is retained for
+	// summarization.
+	maxSize := maxExecOutputSize
+	if c.Summarize {
+		maxSize = maxSummarizeInputSize
+	}
+
+	if len(data) > maxSize {
+		c.MessageText = strings.ToValidUTF8(string(data[:maxSize]), "") + execTruncatedNotice
+		return nil
+	}
+
+	c.MessageText = string(data)
+
+	return nil
+}
+
+// decodeBase64 decodes the given value using the standard or URL-safe
+// base64 alphabet, with or without padding, ignoring any whitespace.
+func decodeBase64(value string) ([]byte, error) {
+	value = strings.Join(strings.Fields(value), "")
+
+	encoding := base64.StdEncoding
+	if strings.ContainsAny(value, "-_") {
+		encoding = base64.URLEncoding
+	}
+
+	return encoding.WithPadding(base64.NoPadding).DecodeString(strings.TrimRight(value, "="))
+}
+
+// loadTitleFile uses the content of the file specified via the title-file
+// flag as the message title. The title is loaded ahead of other input so
+// that it takes precedence over any title the input provides.
+func (c *Config) loadTitleFile() error {
+	if c.TitleFile == "" {
+		return nil
+	}
+
+	if c.MessageTitle != "" {
+		return fmt.Errorf("unsupported: You cannot specify both the title and title-file flags")
+	}
+
+	content, err := readPartFile(c.TitleFile, "title", maxTitleFileSize)
+	if err != nil {
+		return err
+	}
+
+	c.MessageTitle = strings.Join(strings.Fields(content), " ")
+
+	return nil
+}
+
+// loadFooterFile reads the text displayed in the card footer from the file
+// specified via the footer-file flag.
+func (c *Config) loadFooterFile() error {
+	if c.FooterFile == "" {
+		return nil
+	}
+
+	content, err := readPartFile(c.FooterFile, "footer", maxFooterFileSize)
+	if err != nil {
+		return err
+	}
+
+	c.footer = strings.TrimSpace(content)
+
+	return nil
+}
+
+// readPartFile returns the content of the given file providing the named
+// part of the message. Unlike the message file, content beyond the size
+// limit is rejected rather than truncated as a partial title or footer is
+// unlikely to be useful.
+func readPartFile(path string, part string, maxSize int) (string, error) {
+	excerpt, err := input.ReadExcerpt(path, maxSize)
+	switch {
+	case err != nil:
+		return "", fmt.Errorf("failed to read %s file: %w", part, err)
+	case excerpt.Truncated:
+		return "", fmt.Errorf("%s file %s exceeds %d bytes", part, path, maxSize)
+	case strings.TrimSpace(excerpt.Content) == "":
+		return "", fmt.Errorf("%s file %s is empty", part, path)
+	}
+
+	return excerpt.Content, nil
+}
+
+// loadStdinMessage uses the content piped to the given stdin as the message
+// if no other source provides the message text (even if that source turned
+// out to be empty). Stdin is only read if it is a
+// pipe or regular file so that invocations with an interactive or inherited
+// terminal (or /dev/null) are unaffected, and not if it is reserved for other
+// input (e.g., the JSON body read via the input-format flag). Empty content
+// leaves the message empty, which is then rejected as for other empty input.
+func (c *Config) loadStdinMessage(stdin *os.File) error {
+	switch {
+	case c.MessageText != "",
+		c.MessageFile != "",
+		c.MessageBase64 != "",
+		c.Exec != "",
+		c.Tail != "",
+		c.ReportCSV != "",
+		c.Nagios,
+		c.Subcommand != "",
+		c.TerraformMode,
+		c.PayloadFile != "",
+		c.CardFile != "",
+		c.InputFormat != "",
+		c.mapRequested(),
+		c.FactsFromJSON != "",
+		c.jsonBody != nil:
+		return nil
+	}
+
+	info, err := stdin.Stat()
+	if err != nil || (info.Mode()&fs.ModeNamedPipe == 0 && !info.Mode().IsRegular()) {
+		return nil
+	}
+
+	// As with command output, more of the input is retained for
+	// summarization.
+	maxSize := maxExecOutputSize
+	if c.Summarize {
+		maxSize = maxSummarizeInputSize
+	}
+
+	data, err := io.ReadAll(io.LimitReader(stdin, int64(maxSize)+1))
+	if err != nil {
+		return fmt.Errorf("failed to read message from stdin: %w", err)
+	}
+
+	switch {
+	case strings.TrimSpace(string(data)) == "":
+		return nil
+	case len(data) > maxSize:
+		c.MessageText = strings.ToValidUTF8(string(data[:maxSize]), "") + execTruncatedNotice
+	default:
+		c.MessageText = string(data)
+	}
+
+	return nil
+}
+
+// loadExecOutput uses the output of the command specified via the exec flag
+// as the message.
+func (c *Config) loadExecOutput() error {
+	if c.Exec == "" {
+		return nil
+	}
+
+	if c.MessageText != "" {
+		return fmt.Errorf("unsupported: You cannot specify both the message and exec flags")
+	}
+
+	// Summarized output is reduced well below the size limit for messages,
+	// so more of the output is retained for summarization.
+	maxOutput := maxExecOutputSize
+	if c.Summarize {
+		maxOutput = maxSummarizeInputSize
+	}
+
+	result, err := input.Exec(
+		context.Background(),
+		c.Exec,
+		time.Duration(c.ExecTimeout)*time.Second,
+		maxOutput,
+	)
+
+	c.execData = &templates.ExecData{
+		Command:   c.Exec,
+		ExitCode:  result.ExitCode,
+		Duration:  result.Duration,
+		Stdout:    result.Stdout,
+		Stderr:    result.Stderr,
+		Truncated: result.Truncated,
+		TimedOut:  result.TimedOut,
+		Failed:    err != nil,
+	}
+
+	switch {
+	case err != nil && !c.ExecReportFailure && !c.PropagateExit:
+		return fmt.Errorf("failed to retrieve message from command: %w", err)
+
+	// The failure is reported using whatever output is available.
+	case err != nil:
+		c.execData.Error = err.Error()
+		c.inputColor = adaptivecard.ColorAttention
+		c.warnings = append(c.warnings, fmt.Sprintf("reporting failure of command: %v", err))
+
+	case c.ExecReportFailure || c.PropagateExit:
+		c.inputColor = adaptivecard.ColorGood
+	}
+
+	c.MessageText = result.Stdout
+	if c.MessageText == "" && c.execData.Failed {
+		c.MessageText = result.Stderr
+	}
+	if c.MessageText == "" && c.execData.Failed {
+		c.MessageText = c.execData.Error
+	}
+
+	if result.Truncated {
+		c.MessageText = strings.ToValidUTF8(c.MessageText, "") + execTruncatedNotice
+	}
+
+	return nil
+}
+
+// renderTemplate replaces the message with the result of rendering the
+// user-specified template.
+func (c *Config) renderTemplate() error {
+	if c.Template == "" {
+		if c.TemplateData != "" {
+			return fmt.Errorf("unsupported: the template-data flag requires the template flag")
+		}
+		return nil
+	}
+
+	if c.TemplateData != "" {
+		values, err := templates.ReadDataFile(c.TemplateData)
+		if err != nil {
+			return err
+		}
+		c.templateData = values
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), templateFetchTimeout)
+	defer cancel()
+
+	tmpl, err := templates.Fetch(ctx, c.Template, templates.FetchOptions{
+		CacheDir: c.TemplateCacheDir,
+		Checksum: c.TemplateChecksum,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load template %q: %w", c.Template, err)
+	}
+
+	if tmpl.RefreshErr != nil {
+		c.warnings = append(c.warnings, fmt.Sprintf(
+			"failed to refresh template %q; using cached copy: %v",
+			c.Template,
+			tmpl.RefreshErr,
+		))
+	}
+
+	// In watch-file mode the template is rendered for each change.
+	if c.Subcommand == SubcommandWatchFile {
+		c.watchTemplate = &tmpl
+		return nil
+	}
+
+	// Each target is rendered using its own locale, team and channel values
+	// before the message is replaced by the rendered template.
+	for i := range c.targets {
+		targetCfg := c.forTarget(c.targets[i])
+
+		text, err := targetCfg.render(tmpl)
+		if err != nil {
+			return fmt.Errorf("template %q for target %q: %w", c.Template, c.targets[i].Name, err)
+		}
+		c.targets[i].messageText = text
+	}
+
+	text, err := c.render(tmpl)
+	if err != nil {
+		return fmt.Errorf("template %q: %w", c.Template, err)
+	}
+
+	c.MessageText = text
+
+	return nil
+}
+
+// render applies the configuration values to the given template.
+func (c Config) render(tmpl templates.Template) (string, error) {
+	return templates.Render(tmpl, templates.Data{
+		Title:   c.MessageTitle,
+		Message: c.MessageText,
+		Sender:  c.Sender,
+		Team:    c.Team,
+		Channel: c.Channel,
+		Locale:  c.Locale,
+		Exec:    c.execData,
+		Data:    c.templateData,
+	})
+}
+
+// loadAttachments retrieves the content of the files specified via the
+// attach-file flag.
+func (c *Config) loadAttachments() error {
+	if len(c.AttachFiles) > 0 && c.AttachMaxBytes < 1 {
+		return fmt.Errorf("attach max bytes too short")
+	}
+
+	for _, path := range c.AttachFiles {
+		excerpt, err := input.ReadExcerpt(path, c.AttachMaxBytes)
+		if err != nil {
+			return fmt.Errorf("failed to attach file: %w", err)
+		}
+		c.attachments = append(c.attachments, excerpt)
+	}
+
+	return nil
+}
+
+// loadTail displays the last lines of the log file specified via the tail
+// flag as a code block in a dedicated section of the message. The message
+// defaults to a description of the excerpt unless the file is empty, in
+// which case the message input is treated as empty.
+func (c *Config) loadTail() error {
+	if c.Tail == "" {
+		return nil
+	}
+
+	if c.TailLines < 1 {
+		return fmt.Errorf("tail lines too short")
+	}
+
+	tail, err := input.ReadTail(c.Tail, c.TailLines, maxTailSize)
+	if err != nil {
+		return fmt.Errorf("failed to read log file: %w", err)
+	}
+
+	section := teams.Section{Title: tail.Name, Text: emptyFactValue}
+	if tail.Content != "" {
+		section.Text = "```\n" + tail.Content + "\n```"
+	}
+	c.sections = append(c.sections, section)
+
+	switch {
+	case c.MessageText != "" || tail.Content == "":
+	case tail.Truncated:
+		c.MessageText = fmt.Sprintf("Last %d line(s) of %s", tail.Lines, c.Tail)
+	default:
+		c.MessageText = fmt.Sprintf("All %d line(s) of %s", tail.Lines, c.Tail)
+	}
+
+	return nil
+}
+
+// loadImages prepares the images specified via the image-file flag for
+// embedding in the message.
+func (c *Config) loadImages() error {
+	if len(c.ImageFiles) > 0 && c.ImageMaxBytes < 1 {
+		return fmt.Errorf("image max bytes too short")
+	}
+
+	for _, path := range c.ImageFiles {
+		img, err := inlineimage.ReadFile(path, c.ImageMaxBytes, c.ImageFit)
+		if err != nil {
+			return fmt.Errorf("failed to embed image: %w", err)
+		}
+		c.images = append(c.images, img)
+	}
+
+	return nil
+}
+
+// loadReport reads the CSV report specified via the report-csv flag. The
+// report is summarized as the message if none is specified.
+func (c *Config) loadReport() error {
+	if c.ReportCSV == "" {
+		return nil
+	}
+
+	r, err := report.ReadFile(c.ReportCSV)
+	if err != nil {
+		return fmt.Errorf("failed to load report: %w", err)
+	}
+	c.report = &r
+
+	if c.MessageText == "" {
+		c.MessageText = r.Summary(c.RowsPerCard)
+	}
+
+	return nil
+}
+
+// loadTheme loads the theme bundle selected via the Theme field, if any.
+func (c *Config) loadTheme() error {
+	if c.Theme == "" {
+		return nil
+	}
+
+	// Themes selected by name are looked up in the theme directory, then
+	// the defaults override search path and finally the embedded themes.
+	path := theme.Resolve(c.ThemeDir, c.Theme)
+	if path == c.Theme || c.ThemeDir != "" {
+		t, err := theme.Load(path)
+		switch {
+		case err == nil:
+			c.theme = t
+			return nil
+		case path == c.Theme || !errors.Is(err, fs.ErrNotExist):
+			return err
+		}
+	}
+
+	data, source, err := defaults.ReadFile(defaults.ThemesDir + "/" + c.Theme + ".json")
+	if err != nil {
+		return fmt.Errorf("theme %q not found in theme directory %q or defaults search path: %w", c.Theme, c.ThemeDir, err)
+	}
+
+	t, err := theme.Parse(data, source)
+	if err != nil {
+		return err
+	}
+	c.theme = t
+
+	return nil
+}
+
+// loadColorRules loads the color rules file specified via the ColorRules
+// field, if any.
+func (c *Config) loadColorRules() error {
+	if c.ColorRules == "" {
+		return nil
+	}
+
+	rules, err := colorrule.Load(c.ColorRules)
+	if err != nil {
+		return err
+	}
+	c.colorRules = rules
+
+	return nil
+}
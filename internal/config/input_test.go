@@ -0,0 +1,202 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestLoadMessageFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output.txt")
+	if err := os.WriteFile(path, []byte("CRITICAL - disk full\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := Config{MessageFile: path}
+	if err := c.loadMessageFile(); err != nil {
+		t.Fatal(err)
+	}
+	if c.MessageText != "CRITICAL - disk full\n" {
+		t.Errorf("MessageText = %q", c.MessageText)
+	}
+
+	c = Config{MessageFile: path, MessageText: "set"}
+	err := c.loadMessageFile()
+	if err == nil || !strings.Contains(err.Error(), "message and message-file") {
+		t.Errorf("expected error for both message flags, got %v", err)
+	}
+}
+
+func TestLoadExecOutputTruncated(t *testing.T) {
+	if _, err := exec.LookPath("cat"); err != nil {
+		t.Skip("cat not available")
+	}
+
+	// The limit falls within the multi-byte character.
+	path := filepath.Join(t.TempDir(), "output.txt")
+	output := strings.Repeat("a", maxExecOutputSize-1) + "é more"
+	if err := os.WriteFile(path, []byte(output), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := Config{Exec: "cat " + path, ExecTimeout: 10}
+	if err := c.loadExecOutput(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(c.MessageText, execTruncatedNotice) {
+		t.Errorf("got message without truncation notice")
+	}
+
+	if !utf8.ValidString(c.MessageText) {
+		t.Errorf("got message with invalid UTF-8")
+	}
+}
+
+func TestLoadTitleAndFooterFiles(t *testing.T) {
+	dir := t.TempDir()
+	titlePath := filepath.Join(dir, "title.txt")
+	footerPath := filepath.Join(dir, "footer.md")
+	emptyPath := filepath.Join(dir, "empty.txt")
+
+	files := map[string]string{
+		titlePath:  "Deploy of web\n  build 42\n",
+		footerPath: "\nPipeline [#981](https://ci.example.com/981)\n",
+		emptyPath:  "\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := Config{TitleFile: titlePath, FooterFile: footerPath}
+	if err := c.loadTitleFile(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.loadFooterFile(); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.MessageTitle != "Deploy of web build 42" {
+		t.Errorf("MessageTitle = %q", c.MessageTitle)
+	}
+	if c.footer != "Pipeline [#981](https://ci.example.com/981)" {
+		t.Errorf("footer = %q", c.footer)
+	}
+
+	c = Config{TitleFile: titlePath, MessageTitle: "set"}
+	if err := c.loadTitleFile(); err == nil || !strings.Contains(err.Error(), "title and title-file") {
+		t.Errorf("expected error for both title flags, got %v", err)
+	}
+
+	c = Config{FooterFile: emptyPath}
+	if err := c.loadFooterFile(); err == nil || !strings.Contains(err.Error(), "is empty") {
+		t.Errorf("expected error for empty footer file, got %v", err)
+	}
+}
+
+func TestLoadStdinMessage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stdin.txt")
+	if err := os.WriteFile(path, []byte("backup finished\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	open := func() *os.File {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = f.Close() })
+		return f
+	}
+
+	c := Config{}
+	if err := c.loadStdinMessage(open()); err != nil {
+		t.Fatal(err)
+	}
+	if c.MessageText != "backup finished\n" {
+		t.Errorf("MessageText = %q", c.MessageText)
+	}
+
+	// Stdin reserved for other input or superseded by another source is
+	// not read.
+	for _, c := range []Config{
+		{MessageText: "set"},
+		{InputFormat: "sns"},
+		{Subcommand: SubcommandBatch},
+		{Exec: "true"},
+		{Tail: "/var/log/app.log"},
+		{MessageFile: "/tmp/message.txt"},
+		{MessageBase64: "aGk="},
+	} {
+		want := c.MessageText
+		if err := c.loadStdinMessage(open()); err != nil {
+			t.Fatal(err)
+		}
+		if c.MessageText != want {
+			t.Errorf("MessageText = %q; want %q", c.MessageText, want)
+		}
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.Write([]byte(" \n"))
+	_ = w.Close()
+
+	c = Config{}
+	if err := c.loadStdinMessage(r); err != nil {
+		t.Fatal(err)
+	}
+	if c.MessageText != "" {
+		t.Errorf("MessageText = %q for blank piped input; want empty", c.MessageText)
+	}
+}
+
+func TestLoadMessageBase64(t *testing.T) {
+	tests := map[string]struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		"standard":         {value: "RGlzayBmdWxsOiAxMDAlIHVzZWQ=", want: "Disk full: 100% used"},
+		"unpadded":         {value: "RGlzayBmdWxsOiAxMDAlIHVzZWQ", want: "Disk full: 100% used"},
+		"wrapped":          {value: "RGlzayBm\r\ndWxsOiAx\nMDAlIHVzZWQ=", want: "Disk full: 100% used"},
+		"url safe":         {value: "Pz8_Pz8-", want: "?????>"},
+		"multiline":        {value: "bGluZSAxCmxpbmUgMg==", want: "line 1\nline 2"},
+		"invalid encoding": {value: "not base64!", wantErr: true},
+		"binary content":   {value: "AAEC/w==", wantErr: true},
+		"whitespace only":  {value: "ICAK", wantErr: true},
+	}
+
+	for name, tt := range tests {
+		c := Config{MessageBase64: tt.value}
+		err := c.loadMessageBase64()
+
+		switch {
+		case tt.wantErr && err == nil:
+			t.Errorf("%s: loadMessageBase64() succeeded; want error", name)
+		case !tt.wantErr && err != nil:
+			t.Errorf("%s: loadMessageBase64() error = %v", name, err)
+		case !tt.wantErr && c.MessageText != tt.want:
+			t.Errorf("%s: MessageText = %q; want %q", name, c.MessageText, tt.want)
+		}
+	}
+
+	c := Config{MessageBase64: "aGk=", MessageText: "hi"}
+	if err := c.loadMessageBase64(); err == nil {
+		t.Error("loadMessageBase64() with the message flag succeeded; want error")
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/atc0005/send2teams/internal/events"
+)
+
+// loadInputFormat translates the event payload specified via the
+// input-format flag into the title, message, facts and title color. The
+// payload is read from stdin if stdin is not a terminal and provides
+// content, otherwise the message text is used. User-specified title and
+// message values take precedence over the translated values.
+func (c *Config) loadInputFormat() error {
+	if c.InputFormat == "" {
+		return nil
+	}
+
+	if c.Subcommand == SubcommandServe {
+		return fmt.Errorf("unsupported: input-format is not supported in %s mode", SubcommandServe)
+	}
+
+	body, err := c.readJSONBody()
+	if err != nil {
+		return err
+	}
+
+	fromMessage := len(body) == 0
+	if fromMessage {
+		body = []byte(c.MessageText)
+		c.jsonBody = &body
+	}
+
+	if len(body) == 0 {
+		return fmt.Errorf("no event payload provided for input-format flag; provide it via stdin or the message flag")
+	}
+
+	translated, err := events.Translate(c.InputFormat, body)
+	if err != nil {
+		return fmt.Errorf("failed to translate %s event payload: %w", c.InputFormat, err)
+	}
+
+	if c.MessageTitle == "" {
+		c.MessageTitle = translated.Message.Title
+	}
+
+	if c.MessageText == "" || fromMessage {
+		c.MessageText = translated.Message.Text
+	}
+
+	c.facts = append(c.facts, translated.Message.Facts...)
+	c.inputColor = translated.Severity.Color()
+
+	return nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ErrInteractiveNoTerminal indicates that interactive prompts were requested
+// without a terminal to read the responses from.
+var ErrInteractiveNoTerminal = errors.New("the interactive flag requires a terminal on stdin")
+
+// checkInteractive asserts that interactive prompts, if requested, can be
+// answered via the terminal.
+func (c Config) checkInteractive() error {
+	switch {
+	case !c.Interactive:
+		return nil
+	case c.Subcommand != "":
+		return fmt.Errorf("unsupported: interactive prompts are not supported in %s mode", c.Subcommand)
+	case c.TerraformMode:
+		return fmt.Errorf("unsupported: You cannot specify both the interactive and tf flags")
+	case !term.IsTerminal(int(os.Stdin.Fd())):
+		return ErrInteractiveNoTerminal
+	}
+
+	return nil
+}
+
+// promptWebhookURL prompts for the webhook URL if interactive prompts are
+// requested and neither a webhook URL nor targets are specified. The URL is
+// read using the given function so that it is not echoed to the terminal
+// (or recorded by terminal session logging).
+func (c *Config) promptWebhookURL(readSecret func() ([]byte, error), out io.Writer) error {
+	if !c.Interactive || c.WebhookURL != "" || len(c.targets) > 0 || c.PreviewTerminal {
+		return nil
+	}
+
+	fmt.Fprint(out, "Webhook URL (not echoed): ")
+	secret, err := readSecret()
+	fmt.Fprintln(out)
+	if err != nil {
+		return fmt.Errorf("failed to read webhook URL: %w", err)
+	}
+
+	c.WebhookURL = strings.TrimSpace(string(secret))
+
+	return nil
+}
+
+// promptMessage prompts for the title and message if interactive prompts are
+// requested and they are not otherwise specified. An empty title leaves the
+// message untitled. The message may span multiple lines and ends with an
+// empty line (or end of input).
+func (c *Config) promptMessage(in io.Reader, out io.Writer) error {
+	if !c.Interactive || c.PayloadFile != "" {
+		return nil
+	}
+
+	reader := bufio.NewReader(in)
+
+	if c.MessageTitle == "" {
+		fmt.Fprint(out, "Title (optional): ")
+		line, err := reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to read title: %w", err)
+		}
+		c.MessageTitle = strings.TrimSpace(line)
+	}
+
+	if c.MessageText != "" {
+		return nil
+	}
+
+	fmt.Fprintln(out, "Message (end with an empty line):")
+
+	var lines []string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to read message: %w", err)
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		lines = append(lines, line)
+
+		if err != nil {
+			break
+		}
+	}
+
+	c.MessageText = strings.Join(lines, "\n")
+
+	return nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestPromptMessage(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       Config
+		input     string
+		wantTitle string
+		wantText  string
+	}{
+		{
+			name:      "title and multi-line message",
+			cfg:       Config{Interactive: true},
+			input:     "Backup\nLine one\nLine two\n\nignored\n",
+			wantTitle: "Backup",
+			wantText:  "Line one\nLine two",
+		},
+		{
+			name:     "untitled message ending at end of input",
+			cfg:      Config{Interactive: true},
+			input:    "\nDone",
+			wantText: "Done",
+		},
+		{
+			name:      "specified values kept",
+			cfg:       Config{Interactive: true, MessageTitle: "Given", MessageText: "Text"},
+			input:     "Other\nOther\n",
+			wantTitle: "Given",
+			wantText:  "Text",
+		},
+		{
+			name:     "not interactive",
+			cfg:      Config{},
+			input:    "Title\nText\n",
+			wantText: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			if err := cfg.promptMessage(strings.NewReader(tt.input), io.Discard); err != nil {
+				t.Fatal(err)
+			}
+
+			if cfg.MessageTitle != tt.wantTitle || cfg.MessageText != tt.wantText {
+				t.Errorf("got title %q, text %q; want %q, %q", cfg.MessageTitle, cfg.MessageText, tt.wantTitle, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestPromptWebhookURL(t *testing.T) {
+	readSecret := func() ([]byte, error) { return []byte(" https://example.com/webhook \n"), nil }
+
+	cfg := Config{Interactive: true}
+	var out strings.Builder
+	if err := cfg.promptWebhookURL(readSecret, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.WebhookURL != "https://example.com/webhook" {
+		t.Errorf("got webhook URL %q", cfg.WebhookURL)
+	}
+
+	if strings.Contains(out.String(), "example.com") {
+		t.Errorf("webhook URL echoed in prompt output %q", out.String())
+	}
+
+	cfg = Config{Interactive: true, WebhookURL: "https://example.com/given"}
+	if err := cfg.promptWebhookURL(readSecret, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.WebhookURL != "https://example.com/given" {
+		t.Errorf("got webhook URL %q; want specified URL kept", cfg.WebhookURL)
+	}
+}
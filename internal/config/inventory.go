@@ -0,0 +1,55 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// loadInventory resolves the targets selected via the to flag using the
+// target sections of the inventory file. The inventory file uses the
+// configuration file format, but may only define targets. Either flag may be
+// set via the configuration file (e.g., the inventory file in the defaults
+// section and the targets for each profile).
+func (c *Config) loadInventory() error {
+	switch {
+	case c.To == "":
+		return nil
+	case c.Inventory == "":
+		return fmt.Errorf("targets %q specified via the to flag without an inventory file", c.To)
+	case c.Targets != "":
+		return fmt.Errorf("unsupported: You cannot specify both the targets and to flags")
+	}
+
+	cf, err := readConfigFile(c.Inventory)
+	if err != nil {
+		return fmt.Errorf("failed to load inventory file: %w", err)
+	}
+
+	// The first unsupported section is reported so that the result does not
+	// depend on map iteration order.
+	var unsupported *configSection
+	for _, section := range cf.sections {
+		if !strings.HasPrefix(section.name, targetSectionPrefix) &&
+			(unsupported == nil || section.line < unsupported.line) {
+			unsupported = section
+		}
+	}
+
+	if unsupported != nil {
+		return cf.errorf(
+			unsupported.line,
+			"unsupported section [%s] in inventory file; only [%sNAME] sections are supported",
+			unsupported.name,
+			targetSectionPrefix,
+		)
+	}
+
+	return c.addTargets(cf, splitTargetNames(c.To))
+}
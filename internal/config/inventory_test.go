@@ -0,0 +1,56 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadInventory(t *testing.T) {
+	dir := t.TempDir()
+
+	inventory := filepath.Join(dir, "inventory.conf")
+	content := "[target.ops-critical]\n" +
+		"url = https://example.webhook.office.com/ops\n" +
+		"channel = Ops Critical\n" +
+		"\n" +
+		"[target.db-team]\n" +
+		"url = https://example.webhook.office.com/db\n"
+	if err := os.WriteFile(inventory, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := Config{Inventory: inventory, To: "db-team, ops-critical"}
+	if err := c.loadInventory(); err != nil {
+		t.Fatal(err)
+	}
+
+	targets := c.WebhookTargets()
+	if len(targets) != 2 || targets[0].Name != "db-team" || targets[1].Channel != "Ops Critical" ||
+		targets[1].WebhookURL != "https://example.webhook.office.com/ops" {
+		t.Errorf("WebhookTargets() = %+v", targets)
+	}
+
+	invalid := filepath.Join(dir, "invalid.conf")
+	if err := os.WriteFile(invalid, []byte("[defaults]\nretries = 2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, c := range map[string]Config{
+		"without inventory": {To: "db-team"},
+		"with targets":      {Inventory: inventory, To: "db-team", Targets: "emea"},
+		"undefined target":  {Inventory: inventory, To: "web-team"},
+		"defaults section":  {Inventory: invalid, To: "db-team"},
+	} {
+		if err := c.loadInventory(); err == nil {
+			t.Errorf("%s: loadInventory() succeeded; want error", name)
+		}
+	}
+}
@@ -0,0 +1,529 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	goteamsnotify "github.com/atc0005/go-teams-notify/v2"
+
+	"github.com/atc0005/send2teams/internal/webhook"
+)
+
+// Severities of the problems reported by the lint-config subcommand.
+const (
+	LintSeverityError   string = "error"
+	LintSeverityWarning string = "warning"
+)
+
+// LintProblem is a problem found in a configuration file by the lint-config
+// subcommand.
+type LintProblem struct {
+	Path     string `json:"path"`
+	Line     int    `json:"line"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// String returns the problem in the FILE:LINE: SEVERITY: MESSAGE format
+// understood by editors and CI systems.
+func (p LintProblem) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s", p.Path, p.Line, p.Severity, p.Message)
+}
+
+// validateLintConfig asserts that the flag values are usable by the
+// lint-config subcommand, falling back to the file specified via the config
+// flag if no file is given to the subcommand.
+func (c *Config) validateLintConfig() error {
+	if c.LintFile == "" {
+		c.LintFile = c.ConfigFile
+	}
+
+	switch {
+	case c.SilentOutput && c.VerboseOutput:
+		return fmt.Errorf("unsupported: You cannot have both silent and verbose output")
+
+	case c.LintFile == "":
+		return fmt.Errorf("configuration file not specified for %s", SubcommandLintConfig)
+	}
+
+	return nil
+}
+
+// linter collects the problems found in a configuration file.
+type linter struct {
+	cf       configFile
+	problems []LintProblem
+}
+
+// report records a problem found at the given line.
+func (l *linter) report(line int, severity string, format string, args ...interface{}) {
+	l.problems = append(l.problems, LintProblem{
+		Path:     l.cf.path,
+		Line:     line,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// LintConfigFile checks the configuration file given to the lint-config
+// subcommand, returning every problem found ordered by line. Unlike loading
+// the file, all sections are checked rather than only those selected. If
+// probe is not nil, it is used to check that each webhook URL is reachable.
+// An error is returned only if the file could not be read.
+func (c Config) LintConfigFile(probe func(webhookURL string) error) ([]LintProblem, error) {
+	f, err := os.Open(filepath.Clean(c.LintFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open configuration file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	cf, problems, err := scanConfigFile(c.LintFile, f)
+	if err != nil {
+		return nil, err
+	}
+
+	l := linter{cf: cf}
+	for _, problem := range problems {
+		l.report(problem.line, LintSeverityError, "%s", problem.msg)
+	}
+
+	l.checkValues()
+	l.checkReferences()
+	l.checkScopes()
+	l.checkWebhookURLs(probe)
+
+	// Problems found at the same line are reported in a consistent order.
+	sort.SliceStable(l.problems, func(i, j int) bool {
+		if l.problems[i].Line != l.problems[j].Line {
+			return l.problems[i].Line < l.problems[j].Line
+		}
+		return l.problems[i].Message < l.problems[j].Message
+	})
+
+	return l.problems, nil
+}
+
+// orderedSections returns the sections of the file in the order they
+// appear.
+func (l *linter) orderedSections() []*configSection {
+	sections := make([]*configSection, 0, len(l.cf.sections))
+	for _, section := range l.cf.sections {
+		sections = append(sections, section)
+	}
+
+	sort.Slice(sections, func(i, j int) bool {
+		return sections[i].line < sections[j].line
+	})
+
+	return sections
+}
+
+// flagSetting returns the flag set by the given setting, or nil if the
+// setting is not a (supported) flag.
+func flagSetting(section *configSection, setting configSetting) *flag.Flag {
+	switch {
+	case strings.HasPrefix(section.name, targetSectionPrefix),
+		strings.HasPrefix(section.name, classSectionPrefix) && isClassKey(setting.key),
+		setting.key == classKeyProfile:
+		return nil
+	}
+
+	if _, excluded := configFileExcludedFlags[setting.key]; excluded {
+		return nil
+	}
+
+	return flag.CommandLine.Lookup(setting.key)
+}
+
+// checkValues reports values which are not accepted by their flag and
+// settings which are repeated although only the last value is used.
+func (l *linter) checkValues() {
+	for _, section := range l.orderedSections() {
+		seen := make(map[string]int)
+
+		for _, setting := range section.settings {
+			f := flagSetting(section, setting)
+
+			if f != nil {
+				// The value is applied to a new value of the same type so
+				// that the flag itself is left as-is.
+				value, ok := reflect.New(reflect.TypeOf(f.Value).Elem()).Interface().(flag.Value)
+				if ok {
+					if err := value.Set(setting.value); err != nil {
+						l.report(setting.line, LintSeverityError,
+							"invalid value %q for %q: %v", setting.value, setting.key, err)
+					}
+				}
+
+				// The target flag selects the mock webhook server only in
+				// bench mode.
+				constraint := flagConstraints[setting.key]
+				if len(constraint.Choices) > 0 && setting.key != "target" &&
+					!containsString(constraint.Choices, setting.value) {
+					l.report(setting.line, LintSeverityError,
+						"unsupported value %q for %q; expected one of %s",
+						setting.value, setting.key, strings.Join(constraint.Choices, ", "))
+				}
+
+				if _, repeatable := flagType(f); repeatable {
+					continue
+				}
+			}
+
+			if line, dup := seen[setting.key]; dup {
+				l.report(setting.line, LintSeverityWarning,
+					"setting %q repeated in section [%s]; the value on line %d is ignored",
+					setting.key, section.name, line)
+			}
+			seen[setting.key] = setting.line
+		}
+	}
+}
+
+// checkReferences reports profiles and targets which are selected but not
+// defined, and target sections which do not provide a webhook URL.
+func (l *linter) checkReferences() {
+	for _, section := range l.orderedSections() {
+		if strings.HasPrefix(section.name, targetSectionPrefix) {
+			l.checkTarget(section)
+			continue
+		}
+
+		for _, setting := range section.settings {
+			switch {
+			case setting.key == classKeyProfile && (section.name == defaultsSectionName ||
+				strings.HasPrefix(section.name, classSectionPrefix)):
+				if setting.value != "" && l.cf.section(profileSectionPrefix+setting.value) == nil {
+					l.report(setting.line, LintSeverityError,
+						"profile %q not defined in configuration file", setting.value)
+				}
+
+			case setting.key == "targets":
+				targets := Config{Targets: setting.value}
+				for _, name := range targets.targetNames() {
+					if l.cf.section(targetSectionPrefix+name) == nil {
+						l.report(setting.line, LintSeverityError,
+							"target %q not defined in configuration file", name)
+					}
+				}
+			}
+		}
+	}
+}
+
+// checkTarget reports problems with the webhook URL of the given target
+// section.
+func (l *linter) checkTarget(section *configSection) {
+	name := strings.TrimPrefix(section.name, targetSectionPrefix)
+
+	webhookURL, hasURL := section.get(targetKeyURL)
+	parts, hasParts := section.get(targetKeyWebhookParts)
+
+	switch {
+	case hasURL && hasParts:
+		l.report(section.line, LintSeverityError,
+			"both %s and %s specified for target %q", targetKeyURL, targetKeyWebhookParts, name)
+
+	case hasParts:
+		if _, err := webhook.Compose(l.webhookHost(section), parts); err != nil {
+			l.report(section.line, LintSeverityError, "target %q: %v", name, err)
+		}
+
+	case webhookURL == "":
+		l.report(section.line, LintSeverityError, "webhook URL not specified for target %q", name)
+	}
+}
+
+// webhookHost returns the webhook host used to compose the webhook URL of
+// the given section.
+func (l *linter) webhookHost(section *configSection) string {
+	key := "webhook-host"
+	if strings.HasPrefix(section.name, targetSectionPrefix) {
+		key = targetKeyWebhookHost
+	}
+
+	if host, ok := section.get(key); ok {
+		return host
+	}
+
+	if host, ok := l.cf.section(defaultsSectionName).get("webhook-host"); ok {
+		return host
+	}
+
+	if f := flag.CommandLine.Lookup("webhook-host"); f != nil {
+		return f.DefValue
+	}
+
+	return ""
+}
+
+// lintScope is a combination of sections applied together when the file is
+// loaded, most specific first.
+type lintScope struct {
+	name     string
+	sections []*configSection
+}
+
+// lintScopes returns the combinations of sections which may be applied
+// together: the defaults section (along with the profile it selects), each
+// profile along with the defaults section and each message class along
+// with the profile it (or the defaults section) selects and the defaults
+// section.
+func (l *linter) lintScopes() []lintScope {
+	defaults := l.cf.section(defaultsSectionName)
+	defaultProfile, _ := defaults.get(classKeyProfile)
+
+	var scopes []lintScope
+	if defaults != nil {
+		scopes = append(scopes, lintScope{
+			name:     defaultsSectionName,
+			sections: l.nonNil(l.cf.section(profileSectionPrefix+defaultProfile), defaults),
+		})
+	}
+
+	for _, section := range l.orderedSections() {
+		switch {
+		case strings.HasPrefix(section.name, profileSectionPrefix):
+			scopes = append(scopes, lintScope{
+				name:     section.name,
+				sections: l.nonNil(section, defaults),
+			})
+
+		case strings.HasPrefix(section.name, classSectionPrefix):
+			profile, ok := section.get(classKeyProfile)
+			if !ok {
+				profile = defaultProfile
+			}
+			scopes = append(scopes, lintScope{
+				name:     section.name,
+				sections: l.nonNil(section, l.cf.section(profileSectionPrefix+profile), defaults),
+			})
+		}
+	}
+
+	return scopes
+}
+
+// nonNil returns the given sections which are present.
+func (l *linter) nonNil(sections ...*configSection) []*configSection {
+	present := make([]*configSection, 0, len(sections))
+	for _, section := range sections {
+		if section != nil {
+			present = append(present, section)
+		}
+	}
+
+	return present
+}
+
+// effective returns the flag settings in effect for the given scope, keyed
+// by flag name. Flags set to their zero value (e.g., false or an empty
+// string) are omitted since they do not enable the behavior of the flag.
+func (l *linter) effective(scope lintScope) map[string]configSetting {
+	settings := make(map[string]configSetting)
+
+	for _, section := range scope.sections {
+		applied := make(map[string]configSetting)
+		for _, setting := range section.settings {
+			if f := flagSetting(section, setting); f != nil {
+				if _, set := settings[setting.key]; !set {
+					applied[setting.key] = setting
+				}
+			}
+		}
+
+		for key, setting := range applied {
+			settings[key] = setting
+		}
+	}
+
+	for key, setting := range settings {
+		if setting.value == "" {
+			delete(settings, key)
+			continue
+		}
+
+		if typ, _ := flagType(flag.CommandLine.Lookup(key)); typ == "bool" {
+			if enabled, err := strconv.ParseBool(setting.value); err == nil && !enabled {
+				delete(settings, key)
+			}
+		}
+	}
+
+	return settings
+}
+
+// checkScopes reports settings which conflict once combined with the other
+// sections applied along with them, and settings which require a flag not
+// set by any combination of sections.
+func (l *linter) checkScopes() {
+	type requirement struct {
+		line     int
+		key      string
+		required string
+	}
+
+	reported := make(map[[2]int]struct{})
+	unmet := make(map[requirement]struct{})
+	met := make(map[requirement]struct{})
+
+	for _, scope := range l.lintScopes() {
+		settings := l.effective(scope)
+
+		keys := make([]string, 0, len(settings))
+		for key := range settings {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			setting := settings[key]
+			constraint := flagConstraints[key]
+
+			for _, conflict := range constraint.Conflicts {
+				other, ok := settings[conflict]
+				if !ok {
+					continue
+				}
+
+				first, second := other, setting
+				if first.line > second.line {
+					first, second = second, first
+				}
+
+				pair := [2]int{first.line, second.line}
+				if _, done := reported[pair]; done {
+					continue
+				}
+				reported[pair] = struct{}{}
+
+				l.report(second.line, LintSeverityError,
+					"setting %q conflicts with %q set on line %d (in effect for [%s])",
+					second.key, first.key, first.line, scope.name)
+			}
+
+			for _, required := range constraint.Requires {
+				r := requirement{line: setting.line, key: key, required: required}
+				if _, ok := settings[required]; ok {
+					met[r] = struct{}{}
+				} else {
+					unmet[r] = struct{}{}
+				}
+			}
+		}
+	}
+
+	for r := range unmet {
+		if _, ok := met[r]; ok {
+			continue
+		}
+
+		l.report(r.line, LintSeverityWarning,
+			"setting %q requires %q, which is not set in the configuration file; it must be specified on the command line or via the environment",
+			r.key, r.required)
+	}
+}
+
+// checkWebhookURLs reports webhook URLs which fail validation and, if probe
+// is not nil, webhook URLs which are not reachable. Each webhook URL is
+// probed only once.
+func (l *linter) checkWebhookURLs(probe func(webhookURL string) error) {
+	mstClient := goteamsnotify.NewTeamsClient()
+	defaults := l.cf.section(defaultsSectionName)
+
+	probed := make(map[string]error)
+
+	for _, section := range l.orderedSections() {
+		isTarget := strings.HasPrefix(section.name, targetSectionPrefix)
+
+		urlKey, partsKey := webhookURLKey, "webhook-parts"
+		if isTarget {
+			urlKey, partsKey = targetKeyURL, targetKeyWebhookParts
+		}
+
+		webhookURL, ok := section.get(urlKey)
+		line := l.settingLine(section, urlKey)
+
+		if parts, hasParts := section.get(partsKey); hasParts && !ok {
+			composed, err := webhook.Compose(l.webhookHost(section), parts)
+			if err != nil {
+				// Problems with the components of target webhook URLs are
+				// reported along with the other target problems.
+				if !isTarget {
+					l.report(l.settingLine(section, partsKey), LintSeverityError, "%v", err)
+				}
+				continue
+			}
+			webhookURL, ok, line = composed, true, l.settingLine(section, partsKey)
+		}
+
+		if !ok || webhookURL == "" {
+			continue
+		}
+
+		// Validation is disabled for targets only via the defaults
+		// section.
+		skipValidation, _ := defaults.get("disable-url-validation")
+		if value, ok := section.get("disable-url-validation"); ok && !isTarget {
+			skipValidation = value
+		}
+
+		if disabled, _ := strconv.ParseBool(skipValidation); !disabled {
+			if err := mstClient.ValidateWebhook(webhookURL); err != nil {
+				l.report(line, LintSeverityError, "webhook URL validation failed for [%s]: %v", section.name, err)
+				continue
+			}
+		}
+
+		if probe == nil {
+			continue
+		}
+
+		err, done := probed[webhookURL]
+		if !done {
+			err = probe(webhookURL)
+			probed[webhookURL] = err
+		}
+
+		if err != nil {
+			l.report(line, LintSeverityError, "webhook URL for [%s] is unreachable: %v", section.name, err)
+		}
+	}
+}
+
+// settingLine returns the line of the last value for the given key in the
+// section, or the line of the section header if the key is not present.
+func (l *linter) settingLine(section *configSection, key string) int {
+	line := section.line
+	for _, setting := range section.settings {
+		if setting.key == key {
+			line = setting.line
+		}
+	}
+
+	return line
+}
+
+// containsString indicates whether the given value is in the list.
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,97 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLintConfigFile(t *testing.T) {
+	registerFlags()
+
+	content := strings.Join([]string{
+		"[defaults]",                // 1
+		"profile = missing",         // 2
+		"retries = three",           // 3
+		"message = hello",           // 4
+		"skip-empty = false",        // 5
+		"",                          // 6
+		"[profile.ops]",             // 7
+		"message-file = /tmp/x.txt", // 8
+		"allow-empty-message = n/a", // 9
+		"unknown-key = 1",           // 10
+		"retries = 2",               // 11
+		"retries = 3",               // 12
+		"targets = a, nope",         // 13
+		"",                          // 14
+		"[target.a]",                // 15
+		"url = http://127.0.0.1/x",  // 16
+		"disable-url-validation = true",
+	}, "\n")
+
+	path := filepath.Join(t.TempDir(), "send2teams.conf")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := Config{LintFile: path}
+	probe := func(string) error { return errors.New("connection refused") }
+
+	problems, err := c.LintConfigFile(probe)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		line     int
+		severity string
+		contains string
+	}{
+		{2, LintSeverityError, `profile "missing" not defined`},
+		{3, LintSeverityError, `invalid value "three" for "retries"`},
+		{8, LintSeverityError, `"message-file" conflicts with "message" set on line 4`},
+		{10, LintSeverityError, `unknown setting "unknown-key"`},
+		{12, LintSeverityWarning, `value on line 11 is ignored`},
+		{13, LintSeverityError, `target "nope" not defined`},
+		{16, LintSeverityError, `validation failed for [target.a]`},
+		{17, LintSeverityError, `unknown setting "disable-url-validation"`},
+	}
+
+	if len(problems) != len(want) {
+		t.Fatalf("LintConfigFile() returned %d problems; want %d:\n%v", len(problems), len(want), problems)
+	}
+
+	for i, w := range want {
+		p := problems[i]
+		if p.Line != w.line || p.Severity != w.severity || !strings.Contains(p.Message, w.contains) {
+			t.Errorf("problem %d = %s; want line %d %s containing %q", i, p, w.line, w.severity, w.contains)
+		}
+	}
+
+	// The webhook URLs which pass validation are probed.
+	if err := os.WriteFile(path, []byte("[defaults]\nurl = https://example.webhook.office.com/webhookb2/a@b/IncomingWebhook/c/d\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	problems, err = c.LintConfigFile(probe)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(problems) != 1 || !strings.Contains(problems[0].Message, "unreachable: connection refused") {
+		t.Errorf("LintConfigFile() = %v; want unreachable webhook URL", problems)
+	}
+
+	if problems, err := c.LintConfigFile(nil); err != nil || len(problems) != 0 {
+		t.Errorf("LintConfigFile(nil) = %v, %v; want no problems", problems, err)
+	}
+}
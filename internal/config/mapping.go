@@ -0,0 +1,268 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/atc0005/send2teams/internal/events"
+	"github.com/atc0005/send2teams/internal/jsonpath"
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// Card fields which may be mapped via the map flag. Facts are mapped using
+// the fact. prefix followed by the fact title (e.g., fact.Host=$.host).
+const (
+	mapFieldTitle      string = "title"
+	mapFieldText       string = "text"
+	mapFieldMessage    string = "message"
+	mapFieldSender     string = "sender"
+	mapFieldSeverity   string = "severity"
+	mapFieldURL        string = "url"
+	mapFieldFactPrefix string = "fact."
+)
+
+// mapURLDescription is the label used for the button generated for a URL
+// mapped via the map flag.
+const mapURLDescription string = "Open"
+
+// fieldMapping maps a card field to a JSON path.
+type fieldMapping struct {
+	field string
+	path  jsonpath.Path
+}
+
+// parseFieldMappings parses the given semicolon-separated list of
+// field=path pairs (e.g., "title=$.event.title;severity=$.level").
+func parseFieldMappings(spec string) ([]fieldMapping, error) {
+	var mappings []fieldMapping
+	seen := make(map[string]struct{})
+
+	for _, item := range strings.Split(spec, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		field, expr, found := strings.Cut(item, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid mapping %q; expected field=path", item)
+		}
+
+		field = strings.TrimSpace(field)
+		if !strings.HasPrefix(field, mapFieldFactPrefix) {
+			field = strings.ToLower(field)
+		}
+
+		switch {
+		case field == mapFieldMessage:
+			field = mapFieldText
+		case field == mapFieldTitle, field == mapFieldText, field == mapFieldSender,
+			field == mapFieldSeverity, field == mapFieldURL:
+		case strings.HasPrefix(field, mapFieldFactPrefix) && len(field) > len(mapFieldFactPrefix):
+		default:
+			return nil, fmt.Errorf(
+				"unsupported field %q in mapping %q; expected one of %s, %s, %s, %s, %s or %sTITLE",
+				field, item, mapFieldTitle, mapFieldText, mapFieldSender,
+				mapFieldSeverity, mapFieldURL, mapFieldFactPrefix,
+			)
+		}
+
+		if _, dup := seen[field]; dup {
+			return nil, fmt.Errorf("field %q mapped more than once", field)
+		}
+		seen[field] = struct{}{}
+
+		path, err := jsonpath.Parse(strings.TrimSpace(expr))
+		if err != nil {
+			return nil, err
+		}
+
+		mappings = append(mappings, fieldMapping{field: field, path: path})
+	}
+
+	if len(mappings) == 0 {
+		return nil, fmt.Errorf("no field mappings specified for map flag")
+	}
+
+	return mappings, nil
+}
+
+// fieldFlag is a flag mapping a single card field to a JSON path.
+type fieldFlag struct {
+	flag  string
+	field string
+	expr  string
+}
+
+// fieldFlags returns the flags mapping a single card field to a JSON path
+// (e.g., map-title), whether specified or not.
+func (c Config) fieldFlags() []fieldFlag {
+	return []fieldFlag{
+		{flag: "map-title", field: mapFieldTitle, expr: c.MapTitle},
+		{flag: "map-text", field: mapFieldText, expr: c.MapText},
+		{flag: "map-sender", field: mapFieldSender, expr: c.MapSender},
+		{flag: "map-severity", field: mapFieldSeverity, expr: c.MapSeverity},
+		{flag: "map-url", field: mapFieldURL, expr: c.MapURL},
+	}
+}
+
+// mapRequested indicates whether field mappings were specified via the map
+// flag or the flags mapping a single card field.
+func (c Config) mapRequested() bool {
+	if c.Map != "" {
+		return true
+	}
+
+	for _, f := range c.fieldFlags() {
+		if f.expr != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fieldMappings returns the field mappings specified via the map flag
+// followed by those specified via the flags mapping a single card field.
+func (c Config) fieldMappings() ([]fieldMapping, error) {
+	var mappings []fieldMapping
+	if c.Map != "" {
+		parsed, err := parseFieldMappings(c.Map)
+		if err != nil {
+			return nil, err
+		}
+		mappings = parsed
+	}
+
+	for _, f := range c.fieldFlags() {
+		if f.expr == "" {
+			continue
+		}
+
+		for _, m := range mappings {
+			if m.field == f.field {
+				return nil, fmt.Errorf("field %q mapped by both the map and %s flags", f.field, f.flag)
+			}
+		}
+
+		path, err := jsonpath.Parse(f.expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s flag value: %w", f.flag, err)
+		}
+
+		mappings = append(mappings, fieldMapping{field: f.field, path: path})
+	}
+
+	return mappings, nil
+}
+
+// loadFieldMappings applies the field mappings specified via the map flag
+// (and the flags mapping a single card field, such as map-title) to a JSON
+// body. The JSON body is read from stdin if stdin is not a terminal and
+// provides content, otherwise the message text is used. User-specified
+// title, message and sender values take precedence over mapped values.
+// Fields not present in the JSON body are omitted with a warning.
+func (c *Config) loadFieldMappings() error {
+	if !c.mapRequested() {
+		return nil
+	}
+
+	if c.Subcommand == SubcommandServe {
+		return fmt.Errorf("unsupported: map is not supported in %s mode", SubcommandServe)
+	}
+
+	if c.InputFormat != "" {
+		return fmt.Errorf("unsupported: the input-format and map flags are incompatible")
+	}
+
+	mappings, err := c.fieldMappings()
+	if err != nil {
+		return err
+	}
+
+	body, err := c.readJSONBody()
+	if err != nil {
+		return err
+	}
+
+	fromMessage := len(body) == 0
+	if fromMessage {
+		body = []byte(c.MessageText)
+		c.jsonBody = &body
+	}
+
+	doc, err := jsonpath.Decode(body)
+	if err != nil {
+		return fmt.Errorf("failed to apply field mappings: %w", err)
+	}
+
+	for _, m := range mappings {
+		value, found := m.path.Lookup(doc)
+		if !found {
+			c.warnings = append(c.warnings, fmt.Sprintf(
+				"JSON path %s not found in JSON body; omitting mapped %s field",
+				m.path,
+				m.field,
+			))
+			continue
+		}
+		text := jsonpath.Format(value)
+
+		switch {
+		case m.field == mapFieldTitle:
+			if c.MessageTitle == "" {
+				c.MessageTitle = text
+			}
+
+		case m.field == mapFieldText:
+			if c.MessageText == "" || fromMessage {
+				c.MessageText = text
+				fromMessage = false
+			}
+
+		case m.field == mapFieldSender:
+			if c.Sender == "" {
+				c.Sender = text
+			}
+
+		case m.field == mapFieldSeverity:
+			c.inputColor = events.ParseSeverity(text).Color()
+
+		case m.field == mapFieldURL:
+			u, err := url.Parse(text)
+			if err != nil || u.Scheme == "" || u.Host == "" {
+				c.warnings = append(c.warnings, fmt.Sprintf(
+					"value %q of JSON path %s is not a valid URL; omitting mapped %s field",
+					text,
+					m.path,
+					m.field,
+				))
+				continue
+			}
+			c.TargetURLs = append(c.TargetURLs, TargetURL{URL: *u, Description: mapURLDescription})
+
+		default:
+			if text == "" {
+				text = emptyFactValue
+			}
+			title := strings.TrimPrefix(m.field, mapFieldFactPrefix)
+			c.facts = append(c.facts, teams.Fact{Title: title, Value: text})
+		}
+	}
+
+	// If no message was specified (or mapped) the JSON body is shown as the
+	// message so that the original content remains available to recipients.
+	if c.MessageText == "" {
+		c.MessageText = string(body)
+	}
+
+	return nil
+}
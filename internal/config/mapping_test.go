@@ -0,0 +1,71 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import "testing"
+
+func TestParseFieldMappings(t *testing.T) {
+	mappings, err := parseFieldMappings("Title=$.event.title; message=$.message;severity=$.level;fact.Host Name=$.host;")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{mapFieldTitle, mapFieldText, mapFieldSeverity, "fact.Host Name"}
+	if len(mappings) != len(want) {
+		t.Fatalf("got %d mappings, want %d", len(mappings), len(want))
+	}
+
+	for i, m := range mappings {
+		if m.field != want[i] {
+			t.Errorf("mapping %d: got field %q, want %q", i, m.field, want[i])
+		}
+	}
+
+	invalid := map[string]string{
+		"empty":         " ; ",
+		"no path":       "title",
+		"unknown field": "color=$.color",
+		"empty fact":    "fact.=$.host",
+		"duplicate":     "title=$.a;title=$.b",
+		"bad path":      "title=$.a[",
+	}
+
+	for name, spec := range invalid {
+		if _, err := parseFieldMappings(spec); err == nil {
+			t.Errorf("%s: expected error for %q", name, spec)
+		}
+	}
+}
+
+func TestFieldMappings(t *testing.T) {
+	cfg := Config{Map: "fact.Host=$.host", MapTitle: ".alert.name", MapSeverity: ".alert.severity"}
+
+	mappings, err := cfg.fieldMappings()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"fact.Host", mapFieldTitle, mapFieldSeverity}
+	if len(mappings) != len(want) {
+		t.Fatalf("got %d mappings, want %d", len(mappings), len(want))
+	}
+
+	for i, m := range mappings {
+		if m.field != want[i] {
+			t.Errorf("mapping %d: got field %q, want %q", i, m.field, want[i])
+		}
+	}
+
+	if _, err := (Config{Map: "title=$.a", MapTitle: ".b"}).fieldMappings(); err == nil {
+		t.Error("expected error for field mapped by both the map and map-title flags")
+	}
+
+	if _, err := (Config{MapText: ".a["}).fieldMappings(); err == nil {
+		t.Error("expected error for invalid map-text path")
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// webhookURLKey is the configuration file key (and flag name) of the webhook
+// URL.
+const webhookURLKey string = "url"
+
+// configBackupSuffix is appended to the path of a configuration file to name
+// the backup kept when the file is updated.
+const configBackupSuffix string = ".bak"
+
+// validateMigrateURL asserts that the flag values are usable by the
+// migrate-url subcommand. No message is needed by the subcommand.
+func (c Config) validateMigrateURL() error {
+	switch {
+	case c.SilentOutput && c.VerboseOutput:
+		return fmt.Errorf("unsupported: You cannot have both silent and verbose output")
+
+	case len(c.targets) > 0:
+		return fmt.Errorf("unsupported: targets are not supported by %s; select a profile instead", SubcommandMigrateURL)
+
+	case c.WebhookURL == "":
+		return fmt.Errorf("webhook URL not specified for %s", SubcommandMigrateURL)
+
+	case c.WriteConfig && c.ProvisionCommand == "":
+		return fmt.Errorf("the write-config flag requires the provision-command flag")
+
+	case c.WriteConfig && c.ConfigFile == "":
+		return fmt.Errorf("configuration file not specified for the write-config flag")
+
+	case c.WriteConfig && (c.WebhookParts != "" || c.WebhookURLKeyVault != "" ||
+		c.WebhookURLAWSSSM != "" || c.WebhookURLAWSSecrets != ""):
+		return fmt.Errorf(
+			"unsupported: the webhook URL is composed or retrieved from a secret store; " +
+				"update the source of the webhook URL instead of specifying the write-config flag",
+		)
+	}
+
+	return nil
+}
+
+// UpdateConfigFileURL replaces the webhook URL setting in the configuration
+// file with the given webhook URL, returning the name of the updated section
+// and the path of the backup of the original file. The setting is updated
+// in the first of the selected message class, the selected profile and the
+// defaults section which specifies a webhook URL. If none does, the setting
+// is added to the selected profile (or the defaults section). Comments and
+// all other settings are left as-is.
+func (c Config) UpdateConfigFileURL(webhookURL string) (section string, backup string, err error) {
+	path := filepath.Clean(c.ConfigFile)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to access configuration file: %w", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read configuration file: %w", err)
+	}
+
+	cf, err := parseConfigFile(path, bytes.NewReader(content))
+	if err != nil {
+		return "", "", err
+	}
+
+	section = defaultsSectionName
+	if c.Profile != "" {
+		section = profileSectionPrefix + c.Profile
+	}
+
+	var candidates []string
+	if c.Class != "" {
+		candidates = append(candidates, classSectionPrefix+c.Class)
+	}
+	candidates = append(candidates, section, defaultsSectionName)
+
+	for _, name := range candidates {
+		if _, ok := cf.section(name).get(webhookURLKey); ok {
+			section = name
+			break
+		}
+	}
+
+	updated := setConfigFileValue(content, cf.section(section), section, webhookURLKey, webhookURL)
+
+	backup = path + configBackupSuffix
+	if err := os.WriteFile(backup, content, info.Mode().Perm()); err != nil {
+		return "", "", fmt.Errorf("failed to back up configuration file: %w", err)
+	}
+
+	// Write the updated file alongside the original so that it is replaced
+	// in a single step.
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to update configuration file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(updated); err != nil {
+		_ = tmp.Close()
+		return "", "", fmt.Errorf("failed to update configuration file: %w", err)
+	}
+
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		_ = tmp.Close()
+		return "", "", fmt.Errorf("failed to update configuration file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return "", "", fmt.Errorf("failed to update configuration file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", "", fmt.Errorf("failed to update configuration file: %w", err)
+	}
+
+	return section, backup, nil
+}
+
+// setConfigFileValue returns the given configuration file content with the
+// last setting of the given key in the given (parsed) section replaced by
+// the given value. If the section does not specify the key, the setting is
+// added after the section header, and if the section does not exist, it is
+// appended to the content. Line endings of the content are preserved.
+func setConfigFileValue(content []byte, section *configSection, name string, key string, value string) []byte {
+	eol := "\n"
+	if bytes.Contains(content, []byte("\r\n")) {
+		eol = "\r\n"
+	}
+
+	setting := key + " = " + value
+	lines := strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+
+	lastLine := 0
+	if section != nil {
+		for _, s := range section.settings {
+			if s.key == key {
+				lastLine = s.line
+			}
+		}
+	}
+
+	switch {
+	case lastLine > 0:
+		line := lines[lastLine-1]
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		lines[lastLine-1] = indent + setting
+
+	case section != nil:
+		lines = append(lines[:section.line], append([]string{setting}, lines[section.line:]...)...)
+
+	default:
+		// Drop the final empty element (from a trailing newline) so that the
+		// new section is separated from the content by a single blank line.
+		if n := len(lines); n > 0 && lines[n-1] == "" {
+			lines = lines[:n-1]
+		}
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, "["+name+"]", setting, "")
+	}
+
+	return []byte(strings.Join(lines, eol))
+}
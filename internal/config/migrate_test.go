@@ -0,0 +1,71 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetConfigFileValue(t *testing.T) {
+	registerFlags()
+
+	content := "# Shared settings\n" +
+		"[defaults]\n" +
+		"team = Support\n" +
+		"\n" +
+		"[profile.ops]\n" +
+		"  url = https://old.example.com/a\n" +
+		"channel = Alerts\n"
+
+	tests := []struct {
+		name    string
+		content string
+		section string
+		want    string
+	}{
+		{
+			name:    "replace",
+			content: content,
+			section: "profile.ops",
+			want:    strings.Replace(content, "  url = https://old.example.com/a", "  url = https://new.example.com/b", 1),
+		},
+		{
+			name:    "add to section",
+			content: content,
+			section: "defaults",
+			want:    strings.Replace(content, "[defaults]\n", "[defaults]\nurl = https://new.example.com/b\n", 1),
+		},
+		{
+			name:    "add section",
+			content: "[profile.ops]\nchannel = Alerts\n",
+			section: "defaults",
+			want:    "[profile.ops]\nchannel = Alerts\n\n[defaults]\nurl = https://new.example.com/b\n",
+		},
+		{
+			name:    "preserve line endings",
+			content: "[defaults]\r\nurl = https://old.example.com/a\r\n",
+			section: "defaults",
+			want:    "[defaults]\r\nurl = https://new.example.com/b\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cf, err := parseConfigFile("test.conf", strings.NewReader(tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := string(setConfigFileValue([]byte(tt.content), cf.section(tt.section), tt.section, webhookURLKey, "https://new.example.com/b"))
+			if got != tt.want {
+				t.Errorf("got:\n%q\nwant:\n%q", got, tt.want)
+			}
+		})
+	}
+}
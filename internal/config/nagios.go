@@ -0,0 +1,169 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	goteamsnotify "github.com/atc0005/go-teams-notify/v2"
+	"github.com/atc0005/send2teams/internal/events"
+)
+
+// nagiosTimeoutEnvVar is the environment variable providing the number of
+// seconds Nagios allows a notification command to run before killing it.
+const nagiosTimeoutEnvVar string = "NAGIOS_NOTIFICATIONTIMEOUT"
+
+// nagiosRuntimeMargin is the minimum portion of the Nagios notification
+// timeout reserved for starting up, generating the message and reporting
+// the result. A tenth of the timeout is reserved if larger.
+const nagiosRuntimeMargin time.Duration = 2 * time.Second
+
+// minAttemptTimeout is the least time allowed for each delivery attempt
+// before attempts are given up in favor of a longer time for each.
+const minAttemptTimeout time.Duration = goteamsnotify.DefaultWebhookSendTimeout
+
+// retrySchedule is the number of delivery attempts, the time allowed for
+// each attempt and the delay between them computed to fit within a maximum
+// runtime.
+type retrySchedule struct {
+
+	// budget is the time available for all attempts and the delays between
+	// them.
+	budget time.Duration
+
+	attempts       int
+	attemptTimeout time.Duration
+	delay          time.Duration
+}
+
+// applyNagiosTimeout replaces the user-specified retries and retries delay
+// with a retry schedule which completes within the Nagios notification
+// timeout (or the user-specified max runtime), if requested.
+func (c *Config) applyNagiosTimeout() error {
+	if !c.NagiosTimeoutAware {
+		return nil
+	}
+
+	runtime := c.MaxRuntime
+	if runtime == 0 {
+		runtime = DefaultNagiosNotificationTimeout
+
+		if value := strings.TrimSpace(os.Getenv(nagiosTimeoutEnvVar)); value != "" {
+			seconds, err := strconv.Atoi(value)
+			if err != nil || seconds < 1 {
+				return fmt.Errorf("invalid %s value %q; expected a positive number of seconds", nagiosTimeoutEnvVar, value)
+			}
+			runtime = time.Duration(seconds) * time.Second
+		}
+	}
+
+	// Negative values are rejected by validation.
+	if c.Retries < 0 || c.RetriesDelay < 0 {
+		return nil
+	}
+
+	schedule, err := computeRetrySchedule(runtime, c.Retries, time.Duration(c.RetriesDelay)*time.Second)
+	if err != nil {
+		return err
+	}
+
+	c.retrySchedule = schedule
+	c.Retries = schedule.attempts - 1
+	c.RetriesDelay = int(schedule.delay / time.Second)
+
+	return nil
+}
+
+// computeRetrySchedule returns the retry schedule making as many attempts as
+// possible (up to maxRetries retries, maxDelay apart) within the given
+// runtime, allowing at least minAttemptTimeout for each attempt. The delay
+// is shortened (in whole seconds) before retries are given up. A single
+// attempt is allowed the entire time available if no retries fit.
+func computeRetrySchedule(runtime time.Duration, maxRetries int, maxDelay time.Duration) (retrySchedule, error) {
+	margin := runtime / 10
+	if margin < nagiosRuntimeMargin {
+		margin = nagiosRuntimeMargin
+	}
+
+	budget := runtime - margin
+	if budget < time.Second {
+		return retrySchedule{}, fmt.Errorf(
+			"max runtime of %v is too short; at least %v is required to send a message",
+			runtime,
+			nagiosRuntimeMargin+time.Second,
+		)
+	}
+
+	for retries := maxRetries; retries > 0; retries-- {
+		attempts := retries + 1
+
+		spare := budget - time.Duration(attempts)*minAttemptTimeout
+		if spare < 0 {
+			continue
+		}
+
+		delay := maxDelay
+		if spare < time.Duration(retries)*delay {
+			delay = (spare / time.Duration(retries)).Truncate(time.Second)
+		}
+
+		return retrySchedule{
+			budget:         budget,
+			attempts:       attempts,
+			attemptTimeout: (budget - time.Duration(retries)*delay) / time.Duration(attempts),
+			delay:          delay,
+		}, nil
+	}
+
+	return retrySchedule{budget: budget, attempts: 1, attemptTimeout: budget}, nil
+}
+
+// loadNagiosMacros builds the title, message, facts and title color from
+// the Nagios macros provided to notification commands via environment
+// variables, if requested. User-specified title and message values take
+// precedence over the translated values.
+func (c *Config) loadNagiosMacros() error {
+	if !c.Nagios {
+		return nil
+	}
+
+	switch {
+	case c.Subcommand != "":
+		return fmt.Errorf("unsupported: the nagios flag is not supported in %s mode", c.Subcommand)
+	case c.InputFormat != "":
+		return fmt.Errorf("unsupported: the input-format and nagios flags are incompatible")
+	case c.mapRequested():
+		return fmt.Errorf("unsupported: the map and nagios flags are incompatible")
+	}
+
+	translated, err := events.NagiosFromEnv(os.Getenv).Translate()
+	if err != nil {
+		return fmt.Errorf("failed to translate Nagios notification: %w", err)
+	}
+
+	if c.MessageTitle == "" {
+		c.MessageTitle = translated.Message.Title
+	}
+
+	if c.MessageText == "" {
+		c.MessageText = translated.Message.Text
+	}
+
+	if c.Sender == "" {
+		c.Sender = "Nagios"
+	}
+
+	c.facts = append(c.facts, translated.Message.Facts...)
+	c.inputColor = translated.Severity.Color()
+
+	return nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeRetrySchedule(t *testing.T) {
+	tests := []struct {
+		runtime    time.Duration
+		maxRetries int
+		maxDelay   time.Duration
+		want       retrySchedule
+	}{
+		{
+			runtime: 30 * time.Second, maxRetries: 2, maxDelay: 2 * time.Second,
+			want: retrySchedule{budget: 27 * time.Second, attempts: 3, attemptTimeout: 23 * time.Second / 3, delay: 2 * time.Second},
+		},
+		{
+			// The delay is shortened so that each attempt is allowed the
+			// minimum attempt timeout.
+			runtime: 20 * time.Second, maxRetries: 2, maxDelay: 5 * time.Second,
+			want: retrySchedule{budget: 18 * time.Second, attempts: 3, attemptTimeout: 16 * time.Second / 3, delay: time.Second},
+		},
+		{
+			// Retries which do not fit are given up.
+			runtime: 12 * time.Second, maxRetries: 5, maxDelay: time.Second,
+			want: retrySchedule{budget: 10 * time.Second, attempts: 2, attemptTimeout: 5 * time.Second, delay: 0},
+		},
+		{
+			runtime: 5 * time.Second, maxRetries: 2, maxDelay: 2 * time.Second,
+			want: retrySchedule{budget: 3 * time.Second, attempts: 1, attemptTimeout: 3 * time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := computeRetrySchedule(tt.runtime, tt.maxRetries, tt.maxDelay)
+		if err != nil {
+			t.Errorf("computeRetrySchedule(%v, %d, %v) failed: %v", tt.runtime, tt.maxRetries, tt.maxDelay, err)
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("computeRetrySchedule(%v, %d, %v) = %+v; want %+v", tt.runtime, tt.maxRetries, tt.maxDelay, got, tt.want)
+		}
+
+		if total := time.Duration(got.attempts)*got.attemptTimeout + time.Duration(got.attempts-1)*got.delay; total > got.budget {
+			t.Errorf("schedule for %v takes %v; want no more than %v", tt.runtime, total, got.budget)
+		}
+	}
+
+	if _, err := computeRetrySchedule(2*time.Second, 2, time.Second); err == nil {
+		t.Error("computeRetrySchedule accepted a runtime too short to send a message")
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/atc0005/send2teams/internal/input"
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// maxPayloadFileSize is the maximum size of a pre-built payload file.
+// Microsoft Teams rejects messages larger than approximately 28 KB; larger
+// files are only accepted so that the webhook URL reports the problem.
+const maxPayloadFileSize int64 = 1024 * 1024
+
+// errPayloadFileTooLarge indicates that a pre-built payload file exceeds
+// maxPayloadFileSize.
+var errPayloadFileTooLarge = errors.New("payload file too large")
+
+// loadPayloadFile reads the pre-built payload from the file specified via
+// the payload-file flag. The payload is submitted as-is, so flags providing
+// content for a generated card may not be specified.
+func (c *Config) loadPayloadFile() error {
+	if c.PayloadFile == "" {
+		return nil
+	}
+
+	contentFlags := []struct {
+		name string
+		set  bool
+	}{
+		{"title", c.MessageTitle != ""},
+		{"message-base64", c.MessageBase64 != ""},
+		{"message", c.MessageText != ""},
+		{"message-file", c.MessageFile != ""},
+		{"title-file", c.TitleFile != ""},
+		{"footer-file", c.FooterFile != ""},
+		{"card-file", c.CardFile != ""},
+		{"exec", c.Exec != ""},
+		{"template", c.Template != ""},
+		{"input-format", c.InputFormat != ""},
+		{"map", c.mapRequested()},
+		{"nagios", c.Nagios},
+		{"facts-from-json", c.FactsFromJSON != ""},
+		{"facts-csv", c.FactsCSV != ""},
+		{"fact", len(c.Facts) > 0},
+		{"section", len(c.Sections) > 0},
+		{"target-url", len(c.TargetURLs) > 0},
+		{"user-mention", len(c.UserMentions) > 0},
+		{"attach-file", len(c.AttachFiles) > 0},
+		{"tail", c.Tail != ""},
+		{"image-file", len(c.ImageFiles) > 0},
+		{"report-csv", c.ReportCSV != ""},
+		{"allow-empty-message", c.AllowEmptyMessage != ""},
+		{"skip-empty", c.SkipEmpty},
+		{"compliance-tag", len(c.ComplianceTags) > 0},
+		{"integrity-fact", c.IntegrityFact},
+	}
+
+	for _, f := range contentFlags {
+		if f.set {
+			return fmt.Errorf("unsupported: You cannot specify both the %s and payload-file flags", f.name)
+		}
+	}
+
+	// Gzip compressed payloads are decompressed; the size limit applies to
+	// the decompressed payload.
+	data, truncated, err := input.ReadDecompressed(c.PayloadFile, int(maxPayloadFileSize))
+	switch {
+	case err != nil:
+		return fmt.Errorf("failed to read payload file: %w", err)
+	case truncated:
+		return fmt.Errorf("%w: %s exceeds %d bytes", errPayloadFileTooLarge, c.PayloadFile, maxPayloadFileSize)
+	}
+
+	payload, err := teams.NewRawMessage(data)
+	if err != nil {
+		return fmt.Errorf("invalid payload file %s: %w", c.PayloadFile, err)
+	}
+
+	c.payload = payload
+
+	return nil
+}
+
+// Payload returns the pre-built payload read from the file specified via the
+// payload-file flag, or nil if not specified.
+func (c *Config) Payload() *teams.RawMessage {
+	return c.payload
+}
@@ -0,0 +1,232 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+
+	"github.com/atc0005/send2teams/internal/debugbundle"
+	"github.com/atc0005/send2teams/internal/replay"
+)
+
+// replayExcludedFlags are flags whose recorded values are not applied when
+// replaying an invocation. Their effect is captured by the recorded message
+// content and card settings (or they select inputs and sources which are
+// not used by a replay).
+var replayExcludedFlags = map[string]struct{}{
+	"activity-image":           {},
+	"activity-subtitle":        {},
+	"activity-title":           {},
+	"allow-empty-message":      {},
+	"allow-untitled":           {},
+	"attach-checksums":         {},
+	"attach-file":              {},
+	"attach-max-bytes":         {},
+	"bidi-isolate":             {},
+	"card-file":                {},
+	"class":                    {},
+	"color":                    {},
+	"color-rules":              {},
+	"compliance-tag":           {},
+	"config":                   {},
+	"convert-eol":              {},
+	"convert-eol-compat":       {},
+	"convert-escaped-eol":      {},
+	"disable-branding-trailer": {},
+	"emoji-fallback":           {},
+	"environment":              {},
+	"exec":                     {},
+	"exec-report-failure":      {},
+	"exec-timeout":             {},
+	"expand-tabs":              {},
+	"explain-validation":       {},
+	"fact":                     {},
+	"footer-file":              {},
+	"facts-csv":                {},
+	"facts-from-json":          {},
+	"idempotency-key":          {},
+	"image-file":               {},
+	"image-fit":                {},
+	"image-max-bytes":          {},
+	"input-format":             {},
+	"integrity-fact":           {},
+	"interactive":              {},
+	"inventory":                {},
+	"locale":                   {},
+	"map":                      {},
+	"map-sender":               {},
+	"map-severity":             {},
+	"map-text":                 {},
+	"map-title":                {},
+	"map-url":                  {},
+	"message":                  {},
+	"message-base64":           {},
+	"message-file":             {},
+	"nagios":                   {},
+	"oncall-provider":          {},
+	"payload-file":             {},
+	"oncall-schedule":          {},
+	"oncall-token":             {},
+	"preview-terminal":         {},
+	"profile":                  {},
+	"propagate-exit":           {},
+	"record":                   {},
+	"report-csv":               {},
+	"response-choice":          {},
+	"rows-per-card":            {},
+	"section":                  {},
+	"section-style":            {},
+	"sender":                   {},
+	"skip-empty":               {},
+	"summarize":                {},
+	"summarize-lines":          {},
+	"tail":                     {},
+	"tail-lines":               {},
+	"target-url":               {},
+	"targets":                  {},
+	"template":                 {},
+	"template-cache-dir":       {},
+	"template-checksum":        {},
+	"template-data":            {},
+	"tf":                       {},
+	"theme":                    {},
+	"theme-dir":                {},
+	"title":                    {},
+	"title-file":               {},
+	"title-prefix":             {},
+	"title-suffix":             {},
+	"to":                       {},
+	"url":                      {},
+	"url-aws-secrets":          {},
+	"url-aws-ssm":              {},
+	"url-keyvault":             {},
+	"user-mention":             {},
+	"v":                        {},
+	"version":                  {},
+	"webhook-host":             {},
+	"webhook-parts":            {},
+}
+
+// recordExcludedFlags are flags which are not recorded: credentials and
+// repeatable flags whose values are recorded separately.
+var recordExcludedFlags = map[string]struct{}{
+	"oncall-token":    {},
+	"response-choice": {},
+	"sign-secret":     {},
+}
+
+// FlagValues returns the effective value of each flag other than
+// credentials (except the webhook URL) and repeatable flags, for recording
+// an invocation.
+func (c Config) FlagValues() map[string]string {
+	values := make(map[string]string)
+	flag.VisitAll(func(f *flag.Flag) {
+		if _, excluded := recordExcludedFlags[f.Name]; !excluded {
+			values[f.Name] = f.Value.String()
+		}
+	})
+
+	return values
+}
+
+// redactedFlags are flags whose values are replaced in full when shared: the
+// components of a webhook URL are meaningless once redacted individually.
+var redactedFlags = map[string]struct{}{
+	"webhook-parts": {},
+}
+
+// RedactedFlagValues returns the flag values reported by FlagValues with
+// credentials redacted (e.g., the path and query of the webhook URL), for
+// sharing as part of a problem report.
+func (c Config) RedactedFlagValues() map[string]string {
+	values := c.FlagValues()
+	for name, value := range values {
+		if _, redacted := redactedFlags[name]; redacted && value != "" {
+			values[name] = debugbundle.Redacted
+			continue
+		}
+		values[name] = debugbundle.RedactURLs(value)
+	}
+
+	return values
+}
+
+// loadReplay loads the invocation file specified via the ReplayFile field.
+// Recorded flag values are applied unless specified via the command-line;
+// the url flag may be used to replay the invocation against a different
+// webhook URL.
+func (c *Config) loadReplay() error {
+	if c.ReplayFile == "" {
+		return nil
+	}
+
+	inv, err := replay.Read(c.ReplayFile)
+	if err != nil {
+		return err
+	}
+
+	explicit := make(map[string]struct{})
+	flag.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = struct{}{}
+	})
+
+	names := make([]string, 0, len(inv.Flags))
+	for name := range inv.Flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, ok := explicit[name]; ok {
+			continue
+		}
+
+		if _, excluded := replayExcludedFlags[name]; excluded {
+			continue
+		}
+
+		f := flag.Lookup(name)
+		if f == nil {
+			c.warnings = append(c.warnings, fmt.Sprintf("ignoring unknown flag %q recorded in invocation file %s", name, c.ReplayFile))
+			continue
+		}
+
+		if inv.Flags[name] == f.DefValue {
+			continue
+		}
+
+		if err := flag.Set(name, inv.Flags[name]); err != nil {
+			return fmt.Errorf("invalid value for flag %q recorded in invocation file %s: %w", name, c.ReplayFile, err)
+		}
+	}
+
+	if _, ok := explicit["response-choice"]; !ok {
+		c.ResponseChoices = append(c.ResponseChoices[:0], inv.ResponseChoices...)
+	}
+
+	// The webhook URL may be overridden directly or via a secret store.
+	overridden := false
+	for _, name := range []string{"url", "url-keyvault", "url-aws-ssm", "url-aws-secrets"} {
+		if _, ok := explicit[name]; ok {
+			overridden = true
+		}
+	}
+
+	if !overridden {
+		c.WebhookURL = inv.WebhookURL
+	}
+
+	c.MessageTitle = inv.Message.Title
+	c.MessageText = inv.Message.Text
+	c.Sender = inv.Message.Sender
+	c.replayed = &inv
+
+	return nil
+}
@@ -0,0 +1,161 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-teams-notify/v2/adaptivecard"
+	"github.com/atc0005/send2teams/internal/input"
+	"github.com/atc0005/send2teams/internal/teams"
+	"github.com/atc0005/send2teams/internal/templates"
+)
+
+// maxRunOutputSize is the maximum number of bytes retained from each output
+// stream of the command wrapped by the run subcommand. Both streams are
+// displayed, so together they stay within maxExecOutputSize.
+const maxRunOutputSize int = maxExecOutputSize / 2
+
+// runOutputTruncatedNotice is appended to an output stream of the command
+// wrapped by the run subcommand which exceeds maxRunOutputSize.
+const runOutputTruncatedNotice string = "\n(output truncated)"
+
+// Titles of the sections displaying the output of the command wrapped by the
+// run subcommand.
+const (
+	runStdoutSectionTitle string = "Standard output"
+	runStderrSectionTitle string = "Standard error"
+)
+
+// loadRunOutput runs the command wrapped by the run subcommand, reporting
+// its exit status as the message (unless specified) and its output as code
+// blocks in dedicated sections of the message. The title color reflects the
+// outcome of the command.
+func (c *Config) loadRunOutput() error {
+	if c.Subcommand != SubcommandRun || len(c.RunCommand) == 0 {
+		return nil
+	}
+
+	// The command is not started if it could not be terminated as
+	// requested.
+	if c.RunTimeout < 0 {
+		return fmt.Errorf("run timeout must not be negative")
+	}
+
+	command := displayCommand(c.RunCommand)
+
+	result, err := input.ExecArgs(
+		context.Background(),
+		c.RunCommand,
+		nil,
+		c.RunTimeout,
+		maxRunOutputSize,
+	)
+
+	c.execData = &templates.ExecData{
+		Command:   command,
+		ExitCode:  result.ExitCode,
+		Duration:  result.Duration,
+		Stdout:    result.Stdout,
+		Stderr:    result.Stderr,
+		Truncated: result.Truncated || result.StderrTruncated,
+		TimedOut:  result.TimedOut,
+		Failed:    err != nil,
+	}
+
+	c.inputColor = adaptivecard.ColorGood
+	if err != nil {
+		c.execData.Error = err.Error()
+		c.inputColor = adaptivecard.ColorAttention
+	}
+
+	if c.MessageTitle == "" {
+		c.MessageTitle = runTitle(c.RunCommand[0], err != nil)
+	}
+
+	if c.MessageText == "" {
+		c.MessageText = runSummary(command, result, c.RunTimeout, err)
+	}
+
+	if result.Stdout != "" {
+		c.sections = append(c.sections, runOutputSection(runStdoutSectionTitle, result.Stdout, result.Truncated))
+	}
+
+	if result.Stderr != "" {
+		c.sections = append(c.sections, runOutputSection(runStderrSectionTitle, result.Stderr, result.StderrTruncated))
+	}
+
+	return nil
+}
+
+// runTitle returns the default title for the message reporting the outcome
+// of the given program.
+func runTitle(program string, failed bool) string {
+	if failed {
+		return "Command failed: " + filepath.Base(program)
+	}
+
+	return "Command succeeded: " + filepath.Base(program)
+}
+
+// runSummary describes the outcome of the given command.
+func runSummary(command string, result input.ExecResult, timeout time.Duration, err error) string {
+	duration := result.Duration.Round(time.Millisecond)
+
+	var summary string
+	switch {
+	case result.TimedOut:
+		summary = fmt.Sprintf("`%s` did not complete within %v and was terminated.", command, timeout)
+	// The command could not be started or was terminated by a signal.
+	case err != nil && result.ExitCode < 0:
+		cause := errors.Unwrap(err)
+		if cause == nil {
+			cause = err
+		}
+		summary = fmt.Sprintf("`%s` failed after %v: %v", command, duration, cause)
+	default:
+		summary = fmt.Sprintf("`%s` exited with code %d after %v.", command, result.ExitCode, duration)
+	}
+
+	if result.Stdout == "" && result.Stderr == "" {
+		summary += " No output was produced."
+	}
+
+	return summary
+}
+
+// runOutputSection returns a section displaying the given output of the
+// command wrapped by the run subcommand as a code block.
+func runOutputSection(title string, output string, truncated bool) teams.Section {
+	output = strings.TrimRight(strings.ToValidUTF8(output, ""), "\r\n")
+	if truncated {
+		output += runOutputTruncatedNotice
+	}
+
+	return teams.Section{Title: title, Text: "```\n" + output + "\n```"}
+}
+
+// displayCommand returns the given command arguments joined for display,
+// quoting any arguments which are empty or contain whitespace or quotes.
+func displayCommand(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\r\n'\"`") {
+			arg = strconv.Quote(arg)
+		}
+		quoted = append(quoted, arg)
+	}
+
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,92 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/atc0005/go-teams-notify/v2/adaptivecard"
+)
+
+func TestLoadRunOutput(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	tests := []struct {
+		name         string
+		command      []string
+		wantTitle    string
+		wantText     string
+		wantColor    string
+		wantExitCode int
+		wantSections []string
+	}{
+		{
+			name:         "success",
+			command:      []string{"sh", "-c", "echo done"},
+			wantTitle:    "Command succeeded: sh",
+			wantText:     "`sh -c \"echo done\"` exited with code 0",
+			wantColor:    adaptivecard.ColorGood,
+			wantSections: []string{runStdoutSectionTitle},
+		},
+		{
+			name:         "failure",
+			command:      []string{"sh", "-c", "echo partial; echo broken >&2; exit 3"},
+			wantTitle:    "Command failed: sh",
+			wantText:     "exited with code 3",
+			wantColor:    adaptivecard.ColorAttention,
+			wantExitCode: 3,
+			wantSections: []string{runStdoutSectionTitle, runStderrSectionTitle},
+		},
+		{
+			name:         "no output",
+			command:      []string{"sh", "-c", "exit 0"},
+			wantTitle:    "Command succeeded: sh",
+			wantText:     "No output was produced.",
+			wantColor:    adaptivecard.ColorGood,
+			wantSections: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{Subcommand: SubcommandRun, RunCommand: tt.command}
+			if err := cfg.loadRunOutput(); err != nil {
+				t.Fatal(err)
+			}
+
+			if cfg.MessageTitle != tt.wantTitle {
+				t.Errorf("got title %q; want %q", cfg.MessageTitle, tt.wantTitle)
+			}
+
+			if !strings.Contains(cfg.MessageText, tt.wantText) {
+				t.Errorf("got message %q; want it to contain %q", cfg.MessageText, tt.wantText)
+			}
+
+			if cfg.inputColor != tt.wantColor {
+				t.Errorf("got color %q; want %q", cfg.inputColor, tt.wantColor)
+			}
+
+			if code := cfg.ExecExitCode(); code != tt.wantExitCode {
+				t.Errorf("got exit code %d; want %d", code, tt.wantExitCode)
+			}
+
+			if len(cfg.sections) != len(tt.wantSections) {
+				t.Fatalf("got %d sections; want %d", len(cfg.sections), len(tt.wantSections))
+			}
+			for i, title := range tt.wantSections {
+				if cfg.sections[i].Title != title || !strings.HasPrefix(cfg.sections[i].Text, "```\n") {
+					t.Errorf("section %d: got %+v; want code block titled %q", i, cfg.sections[i], title)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// minSignSecretLength is the minimum length of the secret used to sign
+// requests. Shorter secrets are easily guessed from a signed request.
+const minSignSecretLength int = 16
+
+// loadSignSecret resolves the secret used to sign requests from the
+// SignSecret field, reading it from a file if given as @PATH. Surrounding
+// whitespace (e.g., a final newline in the file) is removed.
+func (c *Config) loadSignSecret() error {
+	if c.SignSecret == "" {
+		return nil
+	}
+
+	secret := c.SignSecret
+	if path, ok := strings.CutPrefix(secret, "@"); ok {
+		data, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return fmt.Errorf("failed to read sign secret: %w", err)
+		}
+		secret = string(data)
+	}
+
+	secret = strings.TrimSpace(secret)
+	if len(secret) < minSignSecretLength {
+		return fmt.Errorf("sign secret too short; at least %d characters are required", minSignSecretLength)
+	}
+
+	c.signSecret = []byte(secret)
+
+	return nil
+}
+
+// validHeaderName indicates whether the given value is a valid HTTP header
+// field name (a token as defined by RFC 9110).
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", r):
+		default:
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,195 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/atc0005/send2teams/internal/webhook"
+)
+
+// Keys which are supported within target sections of a configuration file.
+const (
+	targetKeyURL          string = "url"
+	targetKeyWebhookParts string = "webhook-parts"
+	targetKeyWebhookHost  string = "webhook-host"
+	targetKeyLocale       string = "locale"
+	targetKeyTeam         string = "team"
+	targetKeyChannel      string = "channel"
+)
+
+// Target is a named webhook destination defined in a configuration file and
+// selected via the targets flag.
+type Target struct {
+
+	// Name is the name of the target.
+	Name string
+
+	// WebhookURL is the webhook URL used to submit messages to the target.
+	WebhookURL string
+
+	// Locale is the (optional) locale used to render message templates for
+	// the target. If not specified, the user-specified locale is used.
+	Locale string
+
+	// Team is the (optional) name of the team for the target. If not
+	// specified, the user-specified team name is used.
+	Team string
+
+	// Channel is the (optional) name of the channel for the target. If not
+	// specified, the user-specified channel name is used.
+	Channel string
+
+	// messageText is the message rendered for the target.
+	messageText string
+}
+
+// isTargetKey indicates whether the given key is supported within target
+// sections.
+func isTargetKey(key string) bool {
+	switch key {
+	case targetKeyURL, targetKeyWebhookParts, targetKeyWebhookHost,
+		targetKeyLocale, targetKeyTeam, targetKeyChannel:
+		return true
+	default:
+		return false
+	}
+}
+
+// targetNames returns the target names specified via the targets flag.
+func (c Config) targetNames() []string {
+	return splitTargetNames(c.Targets)
+}
+
+// splitTargetNames returns the names in the given comma-separated list of
+// targets.
+func splitTargetNames(list string) []string {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// loadTargets resolves the targets selected via the targets flag using the
+// target sections of the given configuration file.
+func (c *Config) loadTargets(cf configFile) error {
+	return c.addTargets(cf, c.targetNames())
+}
+
+// addTargets resolves the given targets using the target sections of the
+// given file (a configuration or inventory file).
+func (c *Config) addTargets(cf configFile, names []string) error {
+	seen := make(map[string]struct{})
+
+	for _, name := range names {
+		if _, dup := seen[name]; dup {
+			return fmt.Errorf("target %q specified more than once", name)
+		}
+		seen[name] = struct{}{}
+
+		section := cf.section(targetSectionPrefix + name)
+		if section == nil {
+			return fmt.Errorf("target %q not defined in %s", name, cf.path)
+		}
+
+		target := Target{Name: name}
+		target.WebhookURL, _ = section.get(targetKeyURL)
+		target.Locale, _ = section.get(targetKeyLocale)
+		target.Team, _ = section.get(targetKeyTeam)
+		target.Channel, _ = section.get(targetKeyChannel)
+
+		// The webhook URL may instead be composed from its components, using
+		// the webhook host of the target if specified.
+		if parts, ok := section.get(targetKeyWebhookParts); ok {
+			if target.WebhookURL != "" {
+				return cf.errorf(section.line, "both %s and %s specified for target %q", targetKeyURL, targetKeyWebhookParts, name)
+			}
+
+			host, ok := section.get(targetKeyWebhookHost)
+			if !ok {
+				host = c.WebhookHost
+			}
+
+			webhookURL, err := webhook.Compose(host, parts)
+			if err != nil {
+				return cf.errorf(section.line, "target %q: %v", name, err)
+			}
+			target.WebhookURL = webhookURL
+		}
+
+		if target.WebhookURL == "" {
+			return cf.errorf(section.line, "webhook URL not specified for target %q", name)
+		}
+
+		c.targets = append(c.targets, target)
+	}
+
+	return nil
+}
+
+// forTarget returns a copy of the configuration with the details of the
+// given target applied.
+func (c Config) forTarget(target Target) Config {
+	c.targets = nil
+	c.targetName = target.Name
+	c.WebhookURL = target.WebhookURL
+
+	if target.Locale != "" {
+		c.Locale = target.Locale
+	}
+
+	if target.Team != "" {
+		c.Team = target.Team
+	}
+
+	if target.Channel != "" {
+		c.Channel = target.Channel
+	}
+
+	if target.messageText != "" {
+		c.MessageText = target.messageText
+	}
+
+	return c
+}
+
+// TargetConfigs returns the configuration used to send the message to each
+// target selected via the targets flag. If no targets are selected, the
+// configuration itself is returned as the only entry.
+func (c *Config) TargetConfigs() []*Config {
+	if len(c.targets) == 0 {
+		return []*Config{c}
+	}
+
+	configs := make([]*Config, 0, len(c.targets))
+	for _, target := range c.targets {
+		targetCfg := c.forTarget(target)
+		configs = append(configs, &targetCfg)
+	}
+
+	return configs
+}
+
+// WebhookTargets returns the webhook URL used for each target selected via
+// the targets flag. If no targets are selected, a single entry named after
+// the url flag is returned for the user-specified webhook URL.
+func (c Config) WebhookTargets() []Target {
+	if len(c.targets) == 0 {
+		return []Target{{Name: "url", WebhookURL: c.WebhookURL}}
+	}
+
+	targets := make([]Target, len(c.targets))
+	copy(targets, c.targets)
+
+	return targets
+}
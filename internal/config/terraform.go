@@ -0,0 +1,95 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/atc0005/send2teams/internal/teams"
+	"golang.org/x/term"
+)
+
+// maxTerraformQuerySize is the maximum number of bytes read from stdin for
+// the Terraform external data source query.
+const maxTerraformQuerySize int64 = 1024 * 1024
+
+// terraformIgnoredQueryKeys are the query keys which do not set flags.
+// Setting Terraform mode again is harmless and allows a shared query object
+// to be used for both the program arguments and the query.
+var terraformIgnoredQueryKeys = map[string]struct{}{
+	"tf": {},
+}
+
+// loadTerraformQuery applies the flag values given by the JSON object read
+// from stdin, as provided by the Terraform external data source. Keys are
+// flag names (underscores may be used in place of dashes) and values are
+// strings, as for command-line flags. Values specified via the command-line
+// take precedence. Nothing is applied if stdin is a terminal or provides no
+// content.
+func (c *Config) loadTerraformQuery() error {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(os.Stdin, maxTerraformQuerySize+1))
+	switch {
+	case err != nil:
+		return fmt.Errorf("failed to read Terraform query from stdin: %w", err)
+	case int64(len(data)) > maxTerraformQuerySize:
+		return fmt.Errorf("Terraform query read from stdin exceeds %d bytes", maxTerraformQuerySize)
+	}
+
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil
+	}
+
+	var query map[string]string
+	if err := json.Unmarshal(data, &query); err != nil {
+		return fmt.Errorf("invalid Terraform query read from stdin; expected a JSON object of string values: %w", err)
+	}
+
+	values := make(map[string][]string, len(query))
+	for key, value := range query {
+		values[key] = []string{value}
+	}
+
+	return applyFlagValues(values, terraformIgnoredQueryKeys, "Terraform query")
+}
+
+// MessageIdempotencyKey returns the idempotency key for the given message.
+// The user-specified key is returned if set; in Terraform mode the key
+// otherwise defaults to a checksum of the webhook URL and message content so
+// that repeated runs for unchanged content do not send the message again.
+// An empty string is returned if no key applies.
+func (c Config) MessageIdempotencyKey(msg teams.Message) string {
+	switch {
+	case c.IdempotencyKey != "":
+		return c.IdempotencyKey
+	case !c.TerraformMode:
+		return ""
+	}
+
+	content, err := json.Marshal(msg)
+	if err != nil {
+		return ""
+	}
+
+	h := sha256.New()
+	h.Write([]byte(c.WebhookURL))
+	h.Write([]byte{0})
+	h.Write(content)
+
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
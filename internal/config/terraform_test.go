@@ -0,0 +1,52 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// TestMessageIdempotencyKey asserts that the default Terraform mode key
+// only changes along with the message content or webhook URL.
+func TestMessageIdempotencyKey(t *testing.T) {
+	msg := teams.Message{Title: "Apply", Text: "Applied"}
+
+	c := Config{WebhookURL: "https://example.com/a"}
+	if key := c.MessageIdempotencyKey(msg); key != "" {
+		t.Errorf("got key %q without Terraform mode; want none", key)
+	}
+
+	c.TerraformMode = true
+	key := c.MessageIdempotencyKey(msg)
+	switch {
+	case !strings.HasPrefix(key, "sha256:"):
+		t.Errorf("got key %q; want sha256 checksum", key)
+	case c.MessageIdempotencyKey(msg) != key:
+		t.Error("key differs for the same message")
+	}
+
+	changed := msg
+	changed.Text = "Applied again"
+	if c.MessageIdempotencyKey(changed) == key {
+		t.Error("key unchanged for different message content")
+	}
+
+	other := c
+	other.WebhookURL = "https://example.com/b"
+	if other.MessageIdempotencyKey(msg) == key {
+		t.Error("key unchanged for different webhook URL")
+	}
+
+	c.IdempotencyKey = "release-1"
+	if got := c.MessageIdempotencyKey(msg); got != "release-1" {
+		t.Errorf("got key %q; want user-specified key", got)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationError is a problem with the value of a configuration setting
+// found during validation.
+type ValidationError struct {
+
+	// Field is the name of the flag (or subcommand argument) whose value
+	// caused the problem.
+	Field string
+
+	// Err describes the problem.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the error describing the problem.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationErrors is the collection of all problems found during
+// validation, in the order they were found. All problems are reported at
+// once so that they may be corrected together.
+type ValidationErrors []*ValidationError
+
+// Error implements the error interface. A single problem is described as-is
+// while multiple problems are listed along with the name of the field
+// causing each problem.
+func (e ValidationErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d configuration problems found:", len(e))
+	for _, err := range e {
+		fmt.Fprintf(&b, "\n  - %s: %s", err.Field, err.Err)
+	}
+
+	return b.String()
+}
+
+// Unwrap returns the errors describing each problem, allowing errors.Is and
+// errors.As to match any of them.
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, err := range e {
+		errs = append(errs, err)
+	}
+
+	return errs
+}
+
+// add records a problem with the value of the given field, if any.
+func (e *ValidationErrors) add(field string, err error) {
+	if err != nil {
+		*e = append(*e, &ValidationError{Field: field, Err: err})
+	}
+}
+
+// err returns the collection of problems, or nil if no problems were found.
+func (e ValidationErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	return e
+}
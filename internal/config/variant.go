@@ -0,0 +1,30 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+//go:build !minimal
+
+package config
+
+import "github.com/atc0005/send2teams/internal/bench"
+
+// BuildVariant is the name of the variant of this application selected at
+// build time. The minimal variant (built with the minimal build tag) omits
+// the long-running and diagnostic subcommands.
+const BuildVariant string = "full"
+
+// subcommandAvailable indicates whether the given subcommand is included in
+// this build variant.
+func subcommandAvailable(string) bool {
+	return true
+}
+
+// validateBenchRate verifies that the given rate for the bench subcommand
+// can be parsed.
+func validateBenchRate(rate string) error {
+	_, err := bench.ParseRate(rate)
+	return err
+}
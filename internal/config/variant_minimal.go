@@ -0,0 +1,33 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+//go:build minimal
+
+package config
+
+// BuildVariant is the name of the variant of this application selected at
+// build time. The minimal variant (built with the minimal build tag) omits
+// the long-running and diagnostic subcommands.
+const BuildVariant string = "minimal"
+
+// subcommandAvailable indicates whether the given subcommand is included in
+// this build variant.
+func subcommandAvailable(name string) bool {
+	switch name {
+	case SubcommandServe, SubcommandTop, SubcommandBench, SubcommandWatchFile, SubcommandWatchSpool:
+		return false
+	default:
+		return true
+	}
+}
+
+// validateBenchRate verifies that the given rate for the bench subcommand
+// can be parsed. The bench subcommand is not included in this build variant,
+// so the rate is not used.
+func validateBenchRate(string) error {
+	return nil
+}
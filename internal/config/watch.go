@@ -0,0 +1,76 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/atc0005/send2teams/internal/teams"
+	"github.com/atc0005/send2teams/internal/templates"
+)
+
+// watchDiffName is the name displayed with the diff included in messages
+// sent by watch-file mode.
+const watchDiffName string = "changes.diff"
+
+// WatchMessage returns the message sent by watch-file mode for the given
+// changes. The user-specified template (if any) is rendered with the
+// changes, otherwise the changes are listed after any user-specified
+// message text. A unified diff of changes to text files is included after
+// the message text if available.
+func (c Config) WatchMessage(data *templates.WatchData) (teams.Message, error) {
+	if c.MessageTitle == "" {
+		c.MessageTitle = fmt.Sprintf("Changes detected: %s", filepath.Base(data.Path))
+	}
+
+	switch {
+	case c.watchTemplate != nil:
+		text, err := templates.Render(*c.watchTemplate, templates.Data{
+			Title:   c.MessageTitle,
+			Message: c.MessageText,
+			Sender:  c.Sender,
+			Team:    c.Team,
+			Channel: c.Channel,
+			Locale:  c.Locale,
+			Watch:   data,
+			Data:    c.templateData,
+		})
+		if err != nil {
+			return teams.Message{}, fmt.Errorf("template %q: %w", c.Template, err)
+		}
+		c.MessageText = text
+
+	default:
+		var b strings.Builder
+		if c.MessageText != "" {
+			b.WriteString(c.MessageText)
+			b.WriteString("\n\n")
+		}
+
+		fmt.Fprintf(&b, "%d change(s) detected in `%s`:\n", len(data.Changes), data.Path)
+		for _, change := range data.Changes {
+			fmt.Fprintf(&b, "\n- %s `%s`", change.Op, change.Path)
+		}
+		c.MessageText = b.String()
+	}
+
+	msg := c.TeamsMessage()
+
+	if data.Diff != "" {
+		msg.Attachments = append(msg.Attachments, teams.Attachment{
+			Name:      watchDiffName,
+			Content:   data.Diff,
+			Truncated: data.DiffTruncated,
+			Language:  "Diff",
+		})
+	}
+
+	return msg, nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/atc0005/send2teams/internal/secrets"
+	"github.com/atc0005/send2teams/internal/webhook"
+)
+
+// secretLookupTimeout is the maximum time spent retrieving the webhook URL
+// from a secret store.
+const secretLookupTimeout = 15 * time.Second
+
+// checkWebhookURLSources asserts that the webhook URL is specified using at
+// most one of the supported sources.
+func (c Config) checkWebhookURLSources() error {
+	var sources []string
+	for _, source := range []struct {
+		flag  string
+		value string
+	}{
+		{"url", c.WebhookURL},
+		{"webhook-parts", c.WebhookParts},
+		{"url-keyvault", c.WebhookURLKeyVault},
+		{"url-aws-ssm", c.WebhookURLAWSSSM},
+		{"url-aws-secrets", c.WebhookURLAWSSecrets},
+	} {
+		if source.value != "" {
+			sources = append(sources, source.flag)
+		}
+	}
+
+	if len(sources) > 1 {
+		return fmt.Errorf("unsupported: the %s flags are incompatible", strings.Join(sources, " and "))
+	}
+
+	return nil
+}
+
+// composeWebhookURL sets the webhook URL from the components specified via
+// the webhook-parts flag (or configuration file setting), if any.
+func (c *Config) composeWebhookURL() error {
+	if err := c.checkWebhookURLSources(); err != nil {
+		return err
+	}
+
+	if c.WebhookParts == "" {
+		return nil
+	}
+
+	webhookURL, err := webhook.Compose(c.WebhookHost, c.WebhookParts)
+	if err != nil {
+		return fmt.Errorf("failed to compose webhook URL: %w", err)
+	}
+	c.WebhookURL = webhookURL
+
+	return nil
+}
+
+// webhookURLSecret returns the secret specified via the url-keyvault,
+// url-aws-ssm or url-aws-secrets flags (or configuration file settings)
+// which contains the webhook URL, or nil if not specified.
+func (c Config) webhookURLSecret() (secrets.Source, error) {
+	switch {
+	case c.WebhookURLKeyVault != "":
+		return secrets.ParseKeyVault(c.WebhookURLKeyVault)
+	case c.WebhookURLAWSSSM != "":
+		return secrets.ParseSSMParameter(c.WebhookURLAWSSSM)
+	case c.WebhookURLAWSSecrets != "":
+		return secrets.ParseAWSSecret(c.WebhookURLAWSSecrets)
+	default:
+		return nil, nil
+	}
+}
+
+// fetchWebhookURL sets the webhook URL from the secret store specified via
+// the url-keyvault, url-aws-ssm or url-aws-secrets flags (or configuration
+// file settings), if any.
+func (c *Config) fetchWebhookURL() error {
+	ref, err := c.webhookURLSecret()
+	if err != nil || ref == nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretLookupTimeout)
+	defer cancel()
+
+	webhookURL, err := ref.Fetch(ctx, &http.Client{Timeout: secretLookupTimeout})
+	if err != nil {
+		return fmt.Errorf("failed to retrieve webhook URL: %w", err)
+	}
+
+	if webhookURL = strings.TrimSpace(webhookURL); webhookURL == "" {
+		return fmt.Errorf("failed to retrieve webhook URL: secret %s is empty", ref)
+	}
+	c.WebhookURL = webhookURL
+
+	return nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package debugbundle
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// Redacted is the placeholder shown in place of redacted values.
+const Redacted string = "REDACTED"
+
+// urlPattern matches the http and https URLs within text. The host of each
+// URL is retained when redacted, as the kind of endpoint (e.g., an Office
+// 365 connector or a Power Automate workflow) is often relevant.
+var urlPattern = regexp.MustCompile(`(https?://)([^/\s"'<>()?#@]*@)?([^/\s"'<>()?#]+)[^\s"'<>()]*`)
+
+// RedactURLs returns the given text with the userinfo, path and query of
+// each http and https URL (which carry the tokens and signatures of webhook
+// URLs) replaced by a placeholder.
+func RedactURLs(text string) string {
+	return urlPattern.ReplaceAllStringFunc(text, func(u string) string {
+		m := urlPattern.FindStringSubmatch(u)
+		redacted := m[1] + m[3]
+		if len(u) > len(m[1])+len(m[3]) {
+			redacted += "/" + Redacted
+		}
+		return redacted
+	})
+}
+
+// file is a single file within a bundle.
+type file struct {
+	name string
+	data []byte
+}
+
+// Bundle is a collection of diagnostic files written as a zip archive.
+type Bundle struct {
+	created time.Time
+	files   []file
+}
+
+// New returns an empty bundle created at the given time.
+func New(created time.Time) *Bundle {
+	return &Bundle{created: created}
+}
+
+// Add adds a file with the given name and content to the bundle. The
+// content is added as-is; callers redact sensitive values beforehand.
+func (b *Bundle) Add(name string, data []byte) {
+	b.files = append(b.files, file{name: name, data: data})
+}
+
+// AddJSON adds a file with the given name containing the indented JSON
+// encoding of the given value to the bundle.
+func (b *Bundle) AddJSON(name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	b.Add(name, append(data, '\n'))
+
+	return nil
+}
+
+// Names returns the names of the files within the bundle, in the order they
+// were added.
+func (b *Bundle) Names() []string {
+	names := make([]string, 0, len(b.files))
+	for _, f := range b.files {
+		names = append(names, f.name)
+	}
+
+	return names
+}
+
+// Write writes the bundle as a zip archive to the given path, which must not
+// already exist.
+func (b *Bundle) Write(path string) (err error) {
+	f, err := os.OpenFile(filepath.Clean(path), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("debug bundle %s already exists", path)
+		}
+		return fmt.Errorf("failed to create debug bundle: %w", err)
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to write debug bundle: %w", closeErr)
+		}
+		if err != nil {
+			_ = os.Remove(path)
+		}
+	}()
+
+	zw := zip.NewWriter(f)
+	for _, file := range b.files {
+		w, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: b.created,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write debug bundle: %w", err)
+		}
+
+		if _, err := w.Write(file.data); err != nil {
+			return fmt.Errorf("failed to write debug bundle: %w", err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to write debug bundle: %w", err)
+	}
+
+	return nil
+}
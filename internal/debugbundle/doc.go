@@ -0,0 +1,15 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package debugbundle assembles the zip archives written by the debug-bundle
+subcommand. A bundle collects the diagnostic details (e.g., the effective
+configuration, environment summary and a sample payload) which are useful
+for triaging a problem report, with webhook URLs and credentials redacted
+so that it may be attached to a public issue.
+*/
+package debugbundle
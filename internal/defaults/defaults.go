@@ -0,0 +1,134 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package defaults
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// Directories of the embedded files.
+const (
+	ThemesDir  string = "themes"
+	SchemasDir string = "schemas"
+)
+
+// systemDir is the system-wide directory searched for overrides of the
+// embedded files.
+const systemDir string = "/etc/send2teams"
+
+// appName is the name of the directory within the user configuration
+// directory searched for overrides of the embedded files.
+const appName string = "send2teams"
+
+//go:embed files
+var embedded embed.FS
+
+// SearchPath returns the directories searched (in order) for overrides of
+// the embedded files: the send2teams directory within the user configuration
+// directory (e.g., ~/.config/send2teams/) followed by /etc/send2teams/.
+func SearchPath() []string {
+	var dirs []string
+	if dir, err := os.UserConfigDir(); err == nil {
+		dirs = append(dirs, filepath.Join(dir, appName))
+	}
+
+	return append(dirs, systemDir)
+}
+
+// ReadFile returns the content of the named file (e.g., themes/default.json)
+// from the first directory of the override search path containing it,
+// falling back to the embedded file. The path of the file used is also
+// returned; embedded files are described with an "embedded:" prefix. An
+// error wrapping fs.ErrNotExist is returned if no such file exists.
+func ReadFile(name string) ([]byte, string, error) {
+	for _, dir := range SearchPath() {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		data, err := os.ReadFile(filepath.Clean(p))
+		switch {
+		case err == nil:
+			return data, p, nil
+		case !errors.Is(err, fs.ErrNotExist):
+			return nil, "", fmt.Errorf("failed to read %s: %w", p, err)
+		}
+	}
+
+	return ReadEmbedded(name)
+}
+
+// ReadEmbedded returns the content of the named embedded file, ignoring any
+// overrides.
+func ReadEmbedded(name string) ([]byte, string, error) {
+	data, err := embedded.ReadFile(path.Join("files", name))
+	if err != nil {
+		return nil, "", fmt.Errorf("no default file named %s: %w", name, fs.ErrNotExist)
+	}
+
+	return data, "embedded:" + name, nil
+}
+
+// Names returns the names of the embedded files (e.g., themes/default.json)
+// in lexical order.
+func Names() []string {
+	var names []string
+	_ = fs.WalkDir(embedded, "files", func(p string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			names = append(names, p[len("files/"):])
+		}
+		return err
+	})
+	sort.Strings(names)
+
+	return names
+}
+
+// Export writes each embedded file to the given directory, preserving the
+// directory structure (e.g., dir/themes/default.json), so that they may be
+// customized and placed within a directory of the override search path.
+// Existing files are not modified; their paths are returned separately from
+// the paths of the files written.
+func Export(dir string) (written []string, skipped []string, err error) {
+	for _, name := range Names() {
+		data, _, err := ReadEmbedded(name)
+		if err != nil {
+			return written, skipped, err
+		}
+
+		dest := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+			return written, skipped, fmt.Errorf("failed to create directory for %s: %w", dest, err)
+		}
+
+		f, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		switch {
+		case errors.Is(err, fs.ErrExist):
+			skipped = append(skipped, dest)
+			continue
+		case err != nil:
+			return written, skipped, fmt.Errorf("failed to create %s: %w", dest, err)
+		}
+
+		if _, err := f.Write(data); err != nil {
+			_ = f.Close()
+			return written, skipped, fmt.Errorf("failed to write %s: %w", dest, err)
+		}
+
+		if err := f.Close(); err != nil {
+			return written, skipped, fmt.Errorf("failed to write %s: %w", dest, err)
+		}
+		written = append(written, dest)
+	}
+
+	return written, skipped, nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package defaults
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadFileOverride(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+	t.Setenv("HOME", configDir)
+
+	userDir, err := os.UserConfigDir()
+	if err != nil {
+		t.Skipf("user configuration directory not available: %v", err)
+	}
+
+	name := ThemesDir + "/default.json"
+	if _, source, err := ReadFile(name); err != nil || source != "embedded:"+name {
+		t.Fatalf("ReadFile() source = %q, error = %v, want embedded file", source, err)
+	}
+
+	override := filepath.Join(userDir, appName, ThemesDir, "default.json")
+	if err := os.MkdirAll(filepath.Dir(override), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(override, []byte(`{"name": "custom"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	data, source, err := ReadFile(name)
+	if err != nil || source != override || string(data) != `{"name": "custom"}` {
+		t.Errorf("ReadFile() = %q, %q, %v, want override", data, source, err)
+	}
+
+	if _, _, err := ReadFile(ThemesDir + "/missing.json"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ReadFile() error = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestExport(t *testing.T) {
+	dir := t.TempDir()
+
+	written, skipped, err := Export(dir)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if len(written) != len(Names()) || len(skipped) != 0 {
+		t.Fatalf("Export() wrote %d and skipped %d files, want %d and 0", len(written), len(skipped), len(Names()))
+	}
+
+	// Customized files are preserved when exporting again.
+	custom := filepath.Join(dir, ThemesDir, "default.json")
+	if err := os.WriteFile(custom, []byte("custom"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	written, skipped, err = Export(dir)
+	if err != nil || len(written) != 0 || len(skipped) != len(Names()) {
+		t.Fatalf("Export() = %v, %v, %v, want all files skipped", written, skipped, err)
+	}
+
+	if data, _ := os.ReadFile(custom); string(data) != "custom" {
+		t.Errorf("customized file overwritten: %q", data)
+	}
+}
@@ -0,0 +1,15 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package defaults provides the default card themes and message card schemas
+embedded within the application. Each embedded file may be overridden by a
+file of the same name within the directories of the override search path
+(e.g., /etc/send2teams/themes/default.json) and the embedded files may be
+exported to a directory as a starting point for customization.
+*/
+package defaults
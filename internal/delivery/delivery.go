@@ -0,0 +1,315 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	goteamsnotify "github.com/atc0005/go-teams-notify/v2"
+	"github.com/atc0005/send2teams/internal/archive"
+	"github.com/atc0005/send2teams/internal/breaker"
+	"github.com/atc0005/send2teams/internal/config"
+	"github.com/atc0005/send2teams/internal/schema"
+)
+
+// Message is a Microsoft Teams message which may be submitted by a
+// Deliverer. Messages generated by send2teams (*adaptivecard.Message) and
+// pre-built payloads (*teams.RawMessage) are both supported.
+type Message interface {
+	Prepare() error
+	Validate() error
+	Payload() io.Reader
+}
+
+// Deliverer submits messages to Microsoft Teams using the user-specified
+// configuration.
+type Deliverer struct {
+	cfg       *config.Config
+	client    *goteamsnotify.TeamsClient
+	archivers []archive.Archiver
+
+	// breakers are the circuit breakers for each webhook URL messages were
+	// delivered to, if enabled.
+	breakers   map[string]*breaker.Breaker
+	breakersMu sync.Mutex
+}
+
+// New creates a Deliverer using the given configuration and Microsoft Teams
+// client. An error is returned if any requested archive destinations could
+// not be initialized.
+func New(cfg *config.Config, client *goteamsnotify.TeamsClient) (*Deliverer, error) {
+	d := Deliverer{
+		cfg:      cfg,
+		client:   client,
+		breakers: make(map[string]*breaker.Breaker),
+	}
+
+	// Signing configured for another target sharing the client is removed
+	// so that its signatures are not sent to this webhook URL (nor to the
+	// archive destinations).
+	unsignRequests(client)
+
+	recordResponses(client)
+
+	if cfg.ArchiveS3 != "" {
+		s3, err := archive.NewS3(cfg.ArchiveS3, client.HTTPClient())
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize S3 archive destination: %w", err)
+		}
+		d.archivers = append(d.archivers, s3)
+	}
+
+	if cfg.ArchiveAzureBlob != "" {
+		azBlob, err := archive.NewAzureBlob(cfg.ArchiveAzureBlob, client.HTTPClient())
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize Azure Blob archive destination: %w", err)
+		}
+		d.archivers = append(d.archivers, azBlob)
+	}
+
+	// Requests are signed for the relays reached via the webhook URL, not
+	// for archive destinations.
+	if secret := cfg.SigningSecret(); secret != nil {
+		signRequests(client, secret, cfg.SignHeader)
+	}
+
+	return &d, nil
+}
+
+// Deliver submits the given message to the given webhook URL, retrying
+// submission if needed up to the configured number of retry attempts. The
+// result from the last attempt is returned. The receipt ID is used to
+// correlate any records produced for the submission.
+//
+// If requested, the generated payload is validated against the bundled card
+// schemas and is not submitted if it does not conform.
+//
+// If a pause control file is specified, submission waits while delivery is
+// paused (for as long as the given context allows) and further attempts are
+// not made once delivery is paused; the returned error wraps ErrPaused.
+//
+// If enabled, messages for a webhook URL whose circuit breaker is open are
+// rejected with an error wrapping breaker.ErrOpen without being submitted.
+//
+// If requested, the submitted payload and result are archived. Archival
+// failures are logged, but do not affect the returned result.
+func (d *Deliverer) Deliver(ctx context.Context, receiptID string, webhookURL string, message Message) error {
+	_, err := d.DeliverTimed(ctx, receiptID, webhookURL, message)
+	return err
+}
+
+// DeliverTimed behaves as Deliver, additionally returning the time spent on
+// each delivery attempt. A warning is logged for each attempt which exceeds
+// the configured threshold.
+func (d *Deliverer) DeliverTimed(ctx context.Context, receiptID string, webhookURL string, message Message) (Timing, error) {
+	var timing Timing
+
+	if d.cfg.StrictSchema {
+		if err := validateSchema(message); err != nil {
+			return timing, err
+		}
+	}
+
+	// Short pauses are waited out within the submission timeout.
+	if err := d.WaitWhilePaused(ctx); err != nil {
+		return timing, fmt.Errorf("message not submitted: %w", err)
+	}
+
+	b := d.breaker(webhookURL)
+	if err := b.Allow(); err != nil {
+		status := b.Status()
+		return timing, fmt.Errorf(
+			"message not submitted after %d consecutive failures; next attempt allowed at %s: %w",
+			status.ConsecutiveFailures,
+			status.ProbeAt.Format(time.RFC3339),
+			err,
+		)
+	}
+
+	start := time.Now()
+	sendErr := d.sendWithRetry(ctx, webhookURL, message, &timing)
+	timing.Total = time.Since(start)
+	timing.TotalMS = timing.Total.Milliseconds()
+
+	// Submissions cancelled by the caller say nothing about the health of
+	// the webhook URL, unlike submissions which timed out.
+	switch {
+	case sendErr != nil && errors.Is(ctx.Err(), context.Canceled):
+		b.Abandon()
+	case d.cfg.IgnoreInvalidResponse && errors.Is(sendErr, goteamsnotify.ErrInvalidWebhookURLResponseText):
+		b.Record(nil)
+	default:
+		b.Record(sendErr)
+	}
+
+	if len(d.archivers) > 0 {
+		d.archive(receiptID, webhookURL, message, sendErr)
+	}
+
+	return timing, sendErr
+}
+
+// breaker returns the circuit breaker for the given webhook URL, or nil if
+// circuit breakers are not enabled.
+func (d *Deliverer) breaker(webhookURL string) *breaker.Breaker {
+	if d.cfg.BreakerThreshold < 1 {
+		return nil
+	}
+
+	d.breakersMu.Lock()
+	defer d.breakersMu.Unlock()
+
+	b, ok := d.breakers[webhookURL]
+	if !ok {
+		b = breaker.New(d.cfg.BreakerThreshold, d.cfg.BreakerCooldown)
+		d.breakers[webhookURL] = b
+	}
+
+	return b
+}
+
+// CircuitStatus returns the state of the circuit breaker for the given
+// webhook URL. The second value is false if circuit breakers are not
+// enabled.
+func (d *Deliverer) CircuitStatus(webhookURL string) (breaker.Status, bool) {
+	if d.cfg.BreakerThreshold < 1 {
+		return breaker.Status{}, false
+	}
+
+	return d.breaker(webhookURL).Status(), true
+}
+
+// sendWithRetry submits the given message, retrying submission if needed up
+// to the configured number of retry attempts and recording the time spent
+// on each attempt. Submission is not retried after errors which further
+// attempts will not resolve. The result from the last attempt is returned.
+func (d *Deliverer) sendWithRetry(ctx context.Context, webhookURL string, message Message, timing *Timing) error {
+	attemptsAllowed := 1 + d.cfg.Retries
+	retriesDelay := time.Duration(d.cfg.RetriesDelay) * time.Second
+
+	var sendErr error
+	for number := 1; number <= attemptsAllowed; number++ {
+		var trace attemptTrace
+		var rec responseRecorder
+
+		// Each attempt may be limited so that later attempts still fit within
+		// the submission timeout.
+		attemptCtx, cancelAttempt := ctx, context.CancelFunc(func() {})
+		if timeout := d.cfg.AttemptTimeout(); timeout > 0 {
+			attemptCtx, cancelAttempt = context.WithTimeout(ctx, timeout)
+		}
+
+		start := time.Now()
+		sendErr = d.client.SendWithContext(trace.withTrace(rec.withRecorder(attemptCtx)), webhookURL, message)
+		elapsed := time.Since(start)
+		cancelAttempt()
+
+		// Workflow endpoints describe failures using error payloads and may
+		// accept a message for a run which later fails.
+		sendErr = d.classifyResponse(ctx, rec.last(), sendErr)
+		attempt := trace.attempt(number, elapsed, sendErr)
+		timing.Attempts = append(timing.Attempts, attempt)
+
+		threshold := d.cfg.AttemptWarnThreshold
+		if threshold > 0 && attempt.Duration > threshold && !d.cfg.SilentOutput {
+			log.Printf("WARNING: attempt %d exceeded %v (took %v: connect %v, wait %v)",
+				number, threshold,
+				attempt.Duration.Round(time.Millisecond),
+				attempt.Connect.Round(time.Millisecond),
+				attempt.Wait.Round(time.Millisecond),
+			)
+		}
+
+		if sendErr == nil || number == attemptsAllowed {
+			break
+		}
+
+		if !retryable(sendErr) {
+			if d.cfg.VerboseOutput {
+				log.Printf("Attempt %d of %d to send message failed with a non-retryable error: %v", number, attemptsAllowed, sendErr)
+			}
+			break
+		}
+
+		if d.cfg.VerboseOutput {
+			log.Printf("Attempt %d of %d to send message failed: %v", number, attemptsAllowed, sendErr)
+		}
+
+		// Attempts made while delivery is paused are not expected to
+		// succeed.
+		if d.Paused() {
+			return fmt.Errorf(
+				"%w; not retrying after %d of %d attempts: %w",
+				ErrPaused, number, attemptsAllowed, sendErr,
+			)
+		}
+
+		// Honor any longer delay requested by a throttled workflow endpoint.
+		delay := retriesDelay
+		var workflowErr *WorkflowError
+		if errors.As(sendErr, &workflowErr) && workflowErr.RetryAfter > delay {
+			delay = workflowErr.RetryAfter
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf(
+				"context cancelled or expired: %v; aborting message submission after %d of %d attempts: %w",
+				ctx.Err(), number, attemptsAllowed, sendErr,
+			)
+		case <-time.After(delay):
+		}
+	}
+
+	return sendErr
+}
+
+// validateSchema asserts that the payload generated for the given message
+// conforms to the bundled card schemas.
+func validateSchema(message Message) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode message for schema validation: %w", err)
+	}
+
+	return schema.ValidatePayload(payload)
+}
+
+// archive stores a copy of the submitted message and submission result in
+// each archive destination.
+func (d *Deliverer) archive(receiptID string, webhookURL string, message Message, sendErr error) {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		if !d.cfg.SilentOutput {
+			log.Printf("WARNING: Failed to encode message for archival: %v", err)
+		}
+		return
+	}
+
+	rec := archive.NewRecord(receiptID, d.cfg.Team, d.cfg.Channel, webhookURL, payload, sendErr)
+
+	// Use a separate context so that archival is still attempted if the
+	// submission context expired.
+	if err := archive.Submit(context.Background(), d.archivers, rec); err != nil {
+		if !d.cfg.SilentOutput {
+			log.Printf("WARNING: %v", err)
+		}
+		return
+	}
+
+	if d.cfg.VerboseOutput {
+		log.Printf("Archived message to %d destination(s)", len(d.archivers))
+	}
+}
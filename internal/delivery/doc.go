@@ -0,0 +1,13 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package delivery provides the shared submission path used by all modes of
+this application to deliver generated messages to Microsoft Teams and apply
+any user-requested post-submission processing (e.g., archival).
+*/
+package delivery
@@ -0,0 +1,95 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// pausePollInterval is how often the pause control file is checked while
+// delivery is paused.
+const pausePollInterval time.Duration = 5 * time.Second
+
+// ErrPaused indicates that a message was not submitted (or that further
+// attempts were not made) because delivery is paused by the pause control
+// file.
+var ErrPaused = errors.New("delivery paused")
+
+// Paused indicates whether delivery is paused by the user-specified pause
+// control file.
+func (d *Deliverer) Paused() bool {
+	if d.cfg.PauseFile == "" {
+		return false
+	}
+
+	_, err := os.Stat(d.cfg.PauseFile)
+
+	return err == nil
+}
+
+// WaitWhilePaused blocks while delivery is paused by the pause control file.
+// An error wrapping ErrPaused is returned if the given context is done
+// before delivery is resumed.
+func (d *Deliverer) WaitWhilePaused(ctx context.Context) error {
+	if !d.Paused() {
+		return nil
+	}
+
+	if !d.cfg.SilentOutput {
+		log.Printf("WARNING: Delivery paused while %s exists", d.cfg.PauseFile)
+	}
+
+	ticker := time.NewTicker(pausePollInterval)
+	defer ticker.Stop()
+
+	for d.Paused() {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w while %s exists: %v", ErrPaused, d.cfg.PauseFile, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+
+	if !d.cfg.SilentOutput {
+		log.Printf("Delivery resumed; %s removed", d.cfg.PauseFile)
+	}
+
+	return nil
+}
+
+// DeliverResumable behaves as Deliver for messages delivered in bulk (e.g.,
+// in serve mode or from a queue), waiting while delivery is paused instead
+// of spending the retry budget. Each submission is limited to the given
+// timeout. Submissions interrupted by a pause are made again once delivery
+// is resumed. The given context only limits the time spent waiting while
+// delivery is paused; an error wrapping ErrPaused is returned if it is done
+// first.
+func (d *Deliverer) DeliverResumable(ctx context.Context, timeout time.Duration, receiptID string, webhookURL string, message Message) error {
+	for {
+		if err := d.WaitWhilePaused(ctx); err != nil {
+			return err
+		}
+
+		sendCtx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := d.Deliver(sendCtx, receiptID, webhookURL, message)
+		cancel()
+
+		if !errors.Is(err, ErrPaused) {
+			return err
+		}
+
+		if d.cfg.VerboseOutput {
+			log.Printf("Delivery paused during submission (receipt %s); submitting again once resumed", receiptID)
+		}
+	}
+}
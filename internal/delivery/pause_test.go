@@ -0,0 +1,59 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package delivery
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/atc0005/send2teams/internal/config"
+)
+
+func TestSendWithRetryStopsWhenPaused(t *testing.T) {
+	pauseFile := filepath.Join(t.TempDir(), "pause")
+
+	var requests int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if err := os.WriteFile(pauseFile, nil, 0o600); err != nil {
+			t.Errorf("failed to create pause file: %v", err)
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	cfg := config.Config{Retries: 3, PauseFile: pauseFile, SilentOutput: true}
+	d, webhookURL := newTestDeliverer(t, &cfg, handler)
+
+	err := d.Deliver(context.Background(), "receipt", webhookURL, testMessage(t))
+	if !errors.Is(err, ErrPaused) {
+		t.Fatalf("expected ErrPaused, got %v", err)
+	}
+
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("expected a single request, got %d", got)
+	}
+
+	// Further messages are not submitted while paused.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err = d.DeliverResumable(ctx, time.Second, "receipt", webhookURL, testMessage(t))
+	if !errors.Is(err, ErrPaused) {
+		t.Fatalf("expected ErrPaused, got %v", err)
+	}
+
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("expected no further requests while paused, got %d", got-1)
+	}
+}
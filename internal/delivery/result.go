@@ -0,0 +1,80 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package delivery
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// Status values recorded in a Result.
+const (
+	StatusSent       string = "sent"
+	StatusFailed     string = "failed"
+	StatusDropped    string = "dropped"
+	StatusSpooled    string = "spooled"
+	StatusSuppressed string = "suppressed"
+	StatusQueued     string = "queued"
+	StatusDuplicate  string = "duplicate"
+	StatusSkipped    string = "skipped"
+)
+
+// Result is the machine-readable summary of a message submission.
+type Result struct {
+
+	// Time is when the submission completed.
+	Time time.Time `json:"time"`
+
+	// ReceiptID is the unique identifier assigned to the submission.
+	ReceiptID string `json:"receipt_id"`
+
+	// Status indicates the outcome of the submission.
+	Status string `json:"status"`
+
+	// Team is the human-readable name of the target Microsoft Teams team.
+	Team string `json:"team"`
+
+	// Channel is the human-readable name of the target channel.
+	Channel string `json:"channel"`
+
+	// Error is the reason the submission failed. Empty if successful.
+	Error string `json:"error,omitempty"`
+
+	// Timing is the time spent on each delivery attempt. Omitted if no
+	// attempts were made.
+	Timing *Timing `json:"timing,omitempty"`
+
+	// Warnings are the non-fatal issues found with the configuration (e.g.,
+	// unspecified team or channel labels). Omitted if there are none.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// NewResult creates a Result for the given receipt ID and submission error.
+func (d *Deliverer) NewResult(receiptID string, sendErr error) Result {
+	result := Result{
+		Time:      time.Now().UTC(),
+		ReceiptID: receiptID,
+		Status:    StatusSent,
+		Team:      d.cfg.Team,
+		Channel:   d.cfg.Channel,
+		Warnings:  d.cfg.Warnings(),
+	}
+
+	if sendErr != nil {
+		result.Status = StatusFailed
+		result.Error = sendErr.Error()
+	}
+
+	return result
+}
+
+// Write emits the Result in JSON format to the given writer.
+func (r Result) Write(w io.Writer) error {
+	return json.NewEncoder(w).Encode(r)
+}
@@ -0,0 +1,157 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package delivery
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	goteamsnotify "github.com/atc0005/go-teams-notify/v2"
+)
+
+// signatureScheme is the name of the hash function used to compute request
+// signatures, included in the signature header value.
+const signatureScheme string = "sha256"
+
+// ErrInvalidSignature indicates that a request signature is missing,
+// malformed or does not match the request body.
+var ErrInvalidSignature = errors.New("invalid request signature")
+
+// Sign returns the signature header value for the given request body sent
+// at the given time: the Unix timestamp and the hex-encoded HMAC-SHA256 of
+// the timestamp and body (joined by a period) using the given secret, in the
+// form t=TIMESTAMP,sha256=HMAC. Including the timestamp allows receivers to
+// reject replayed requests.
+func Sign(secret []byte, sent time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(sent.Unix(), 10)
+
+	return fmt.Sprintf("t=%s,%s=%s", timestamp, signatureScheme, signatureMAC(secret, timestamp, body))
+}
+
+// VerifySignature verifies that the given signature header value was
+// produced by Sign for the given request body using the given secret, no
+// more than maxAge before (or, allowing for clock skew, after) the given
+// time. An error wrapping ErrInvalidSignature is returned if not.
+func VerifySignature(secret []byte, header string, body []byte, now time.Time, maxAge time.Duration) error {
+	var timestamp, mac string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case signatureScheme:
+			mac = value
+		}
+	}
+
+	sent, err := strconv.ParseInt(timestamp, 10, 64)
+	switch {
+	case err != nil || mac == "":
+		return fmt.Errorf("%w: malformed signature %q", ErrInvalidSignature, header)
+	case now.Sub(time.Unix(sent, 0)) > maxAge:
+		return fmt.Errorf("%w: signature older than %v", ErrInvalidSignature, maxAge)
+	case time.Unix(sent, 0).Sub(now) > maxAge:
+		return fmt.Errorf("%w: signature timestamp more than %v in the future", ErrInvalidSignature, maxAge)
+	case !hmac.Equal([]byte(mac), []byte(signatureMAC(secret, timestamp, body))):
+		return fmt.Errorf("%w: signature does not match request body", ErrInvalidSignature)
+	}
+
+	return nil
+}
+
+// signatureMAC returns the hex-encoded HMAC-SHA256 of the given timestamp
+// and body using the given secret.
+func signatureMAC(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// signingTransport is a http.RoundTripper which adds a signature of the
+// request body to each request, allowing relays and gateways in front of
+// Microsoft Teams to authenticate the requests.
+type signingTransport struct {
+	base   http.RoundTripper
+	secret []byte
+	header string
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t signingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body for signing: %w", err)
+		}
+	}
+
+	// The request is cloned as a RoundTripper must not modify the original.
+	signed := req.Clone(req.Context())
+	signed.Body = io.NopCloser(bytes.NewReader(body))
+	signed.ContentLength = int64(len(body))
+	signed.Header.Set(t.header, Sign(t.secret, time.Now(), body))
+
+	return base.RoundTrip(signed)
+}
+
+// signRequests configures the HTTP client used by the given Microsoft Teams
+// client to sign each request using the given secret, adding the signature
+// via the given header. Any signing previously configured (e.g., for another
+// target sharing the client) is replaced.
+func signRequests(client *goteamsnotify.TeamsClient, secret []byte, header string) {
+	httpClient := client.HTTPClient()
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	base := httpClient.Transport
+	if existing, ok := base.(signingTransport); ok {
+		base = existing.base
+	}
+
+	wrapped := *httpClient
+	wrapped.Transport = signingTransport{base: base, secret: secret, header: header}
+	client.SetHTTPClient(&wrapped)
+}
+
+// unsignRequests removes any signing configured by signRequests from the
+// HTTP client used by the given Microsoft Teams client.
+func unsignRequests(client *goteamsnotify.TeamsClient) {
+	httpClient := client.HTTPClient()
+	if httpClient == nil {
+		return
+	}
+
+	existing, ok := httpClient.Transport.(signingTransport)
+	if !ok {
+		return
+	}
+
+	wrapped := *httpClient
+	wrapped.Transport = existing.base
+	client.SetHTTPClient(&wrapped)
+}
@@ -0,0 +1,111 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package delivery
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	goteamsnotify "github.com/atc0005/go-teams-notify/v2"
+	"github.com/atc0005/send2teams/internal/config"
+)
+
+func TestVerifySignature(t *testing.T) {
+	secret := []byte("0123456789abcdef")
+	body := []byte(`{"type":"message"}`)
+	sent := time.Unix(1700000000, 0)
+
+	header := Sign(secret, sent, body)
+	if !strings.HasPrefix(header, "t=1700000000,sha256=") {
+		t.Fatalf("Sign() = %q; want t=1700000000,sha256=...", header)
+	}
+
+	if err := VerifySignature(secret, header, body, sent.Add(time.Minute), 5*time.Minute); err != nil {
+		t.Errorf("VerifySignature() error = %v", err)
+	}
+
+	invalid := []struct {
+		name   string
+		secret []byte
+		header string
+		body   []byte
+		now    time.Time
+	}{
+		{"wrong secret", []byte("fedcba9876543210"), header, body, sent},
+		{"modified body", secret, header, []byte(`{"type":"other"}`), sent},
+		{"expired", secret, header, body, sent.Add(time.Hour)},
+		{"future", secret, header, body, sent.Add(-time.Hour)},
+		{"malformed", secret, "sha256=abc", body, sent},
+	}
+
+	for _, tt := range invalid {
+		if err := VerifySignature(tt.secret, tt.header, tt.body, tt.now, 5*time.Minute); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: VerifySignature() error = %v; want ErrInvalidSignature", tt.name, err)
+		}
+	}
+}
+
+func TestSignRequests(t *testing.T) {
+	secret := []byte("0123456789abcdef")
+
+	var verifyErr error
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		verifyErr = VerifySignature(secret, r.Header.Get("X-Relay-Signature"), body, time.Now(), time.Minute)
+	}))
+	t.Cleanup(server.Close)
+
+	client := goteamsnotify.NewTeamsClient()
+	signRequests(client, []byte("replaced-secret-value"), "X-Relay-Signature")
+	signRequests(client, secret, "X-Relay-Signature")
+
+	resp, err := client.HTTPClient().Post(server.URL, "application/json", strings.NewReader(`{"type":"message"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	if verifyErr != nil {
+		t.Errorf("signed request not verified: %v", verifyErr)
+	}
+}
+
+// signTestHeader is the header carrying request signatures in tests.
+const signTestHeader string = "X-Relay-Signature"
+
+func TestSigningNotSharedBetweenTargets(t *testing.T) {
+	var headers []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Get(signTestHeader))
+	}))
+	t.Cleanup(server.Close)
+
+	// The first target signs requests using the shared client, while the
+	// second does not specify a secret.
+	client := goteamsnotify.NewTeamsClient()
+	signRequests(client, []byte("0123456789abcdef"), signTestHeader)
+
+	if _, err := New(&config.Config{}, client); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.HTTPClient().Post(server.URL, "application/json", strings.NewReader(`{"type":"message"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	if len(headers) != 1 || headers[0] != "" {
+		t.Errorf("got signature headers %q for target without a secret; want none", headers)
+	}
+}
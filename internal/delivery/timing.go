@@ -0,0 +1,186 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package delivery
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+)
+
+// histogramWidth is the maximum width (in characters) of the bars used to
+// display attempt durations.
+const histogramWidth = 30
+
+// Attempt records the time spent on a single delivery attempt.
+type Attempt struct {
+
+	// Number is the (1-based) number of the attempt.
+	Number int `json:"number"`
+
+	// Duration is the total time spent on the attempt.
+	Duration time.Duration `json:"-"`
+
+	// Connect is the time spent establishing a connection (DNS lookup, TCP
+	// connect and TLS handshake) to the endpoint or proxy. Zero if an
+	// existing connection was reused.
+	Connect time.Duration `json:"-"`
+
+	// Wait is the time spent waiting for the first byte of the response
+	// after the request was written; this is dominated by the processing
+	// time of the remote endpoint.
+	Wait time.Duration `json:"-"`
+
+	// DurationMS is Duration in milliseconds.
+	DurationMS int64 `json:"duration_ms"`
+
+	// ConnectMS is Connect in milliseconds.
+	ConnectMS int64 `json:"connect_ms"`
+
+	// WaitMS is Wait in milliseconds.
+	WaitMS int64 `json:"wait_ms"`
+
+	// Error is the reason the attempt failed. Empty if successful.
+	Error string `json:"error,omitempty"`
+}
+
+// Timing records the time spent on each attempt to deliver a message.
+type Timing struct {
+
+	// Attempts are the delivery attempts made, in order.
+	Attempts []Attempt `json:"attempts"`
+
+	// Total is the cumulative time spent delivering the message, including
+	// any retry delays.
+	Total time.Duration `json:"-"`
+
+	// TotalMS is Total in milliseconds.
+	TotalMS int64 `json:"total_ms"`
+}
+
+// attemptTrace collects connection timing details for a single attempt.
+type attemptTrace struct {
+	mu           sync.Mutex
+	connectStart time.Time
+	connect      time.Duration
+	wroteRequest time.Time
+	wait         time.Duration
+}
+
+// withTrace returns a copy of the given context which records connection
+// timing details for requests made using it.
+func (t *attemptTrace) withTrace(ctx context.Context) context.Context {
+	trace := httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if t.connectStart.IsZero() {
+				t.connectStart = time.Now()
+			}
+		},
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if t.connectStart.IsZero() {
+				t.connectStart = time.Now()
+			}
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if !t.connectStart.IsZero() {
+				t.connect = time.Since(t.connectStart)
+			}
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if !info.Reused && !t.connectStart.IsZero() {
+				t.connect = time.Since(t.connectStart)
+			}
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.wroteRequest = time.Now()
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if !t.wroteRequest.IsZero() {
+				t.wait = time.Since(t.wroteRequest)
+			}
+		},
+	}
+
+	return httptrace.WithClientTrace(ctx, &trace)
+}
+
+// attempt returns the Attempt recorded by the trace.
+func (t *attemptTrace) attempt(number int, duration time.Duration, err error) Attempt {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	a := Attempt{
+		Number:     number,
+		Duration:   duration,
+		Connect:    t.connect,
+		Wait:       t.wait,
+		DurationMS: duration.Milliseconds(),
+		ConnectMS:  t.connect.Milliseconds(),
+		WaitMS:     t.wait.Milliseconds(),
+	}
+
+	if err != nil {
+		a.Error = err.Error()
+	}
+
+	return a
+}
+
+// Histogram returns a human-readable histogram of the attempt durations
+// along with the cumulative time spent delivering the message.
+func (t Timing) Histogram() string {
+	var longest time.Duration
+	for _, a := range t.Attempts {
+		if a.Duration > longest {
+			longest = a.Duration
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Delivery took %v over %d attempt(s)\n", t.Total.Round(time.Millisecond), len(t.Attempts))
+
+	for _, a := range t.Attempts {
+		width := 0
+		if longest > 0 {
+			width = int(int64(histogramWidth) * int64(a.Duration) / int64(longest))
+		}
+
+		outcome := "ok"
+		if a.Error != "" {
+			outcome = "failed"
+		}
+
+		fmt.Fprintf(&b, "  attempt %d %-*s %10v (connect %v, wait %v) %s\n",
+			a.Number,
+			histogramWidth,
+			strings.Repeat("#", width),
+			a.Duration.Round(time.Millisecond),
+			a.Connect.Round(time.Millisecond),
+			a.Wait.Round(time.Millisecond),
+			outcome,
+		)
+	}
+
+	return b.String()
+}
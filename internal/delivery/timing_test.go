@@ -0,0 +1,45 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package delivery
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimingHistogram(t *testing.T) {
+	timing := Timing{
+		Attempts: []Attempt{
+			{Number: 1, Duration: 4 * time.Second, Wait: 3 * time.Second, Error: "timeout"},
+			{Number: 2, Duration: 2 * time.Second, Connect: time.Second},
+		},
+		Total: 8 * time.Second,
+	}
+
+	lines := strings.Split(strings.TrimSpace(timing.Histogram()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d: %q", len(lines), lines)
+	}
+
+	if !strings.Contains(lines[0], "8s over 2 attempt(s)") {
+		t.Errorf("unexpected summary line: %q", lines[0])
+	}
+
+	if got := strings.Count(lines[1], "#"); got != histogramWidth {
+		t.Errorf("expected longest attempt bar of %d, got %d", histogramWidth, got)
+	}
+
+	if got := strings.Count(lines[2], "#"); got != histogramWidth/2 {
+		t.Errorf("expected half-width bar of %d, got %d", histogramWidth/2, got)
+	}
+
+	if !strings.HasSuffix(lines[1], "failed") || !strings.HasSuffix(lines[2], "ok") {
+		t.Errorf("unexpected attempt outcomes: %q", lines[1:])
+	}
+}
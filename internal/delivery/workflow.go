@@ -0,0 +1,437 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	goteamsnotify "github.com/atc0005/go-teams-notify/v2"
+)
+
+// maxRecordedBody is the maximum number of bytes of a response body retained
+// to classify workflow error payloads and run status responses.
+const maxRecordedBody = 64 * 1024
+
+// defaultRunPollInterval is the interval between requests for the status of
+// a workflow run if the endpoint does not specify one. Overridden by tests.
+var defaultRunPollInterval = 2 * time.Second
+
+// Headers returned by Power Automate and Logic Apps workflow endpoints.
+const (
+	headerLocation      string = "Location"
+	headerRetryAfter    string = "Retry-After"
+	headerWorkflowRunID string = "x-ms-workflow-run-id"
+)
+
+var (
+	// ErrWorkflowRunFailed indicates that a workflow accepted a message, but
+	// the run triggered by it did not complete successfully.
+	ErrWorkflowRunFailed = errors.New("workflow run failed")
+
+	// ErrWorkflowRunUnverified indicates that a workflow accepted a message,
+	// but the outcome of the run triggered by it could not be determined.
+	// Submission is not retried since the message may have been delivered.
+	ErrWorkflowRunUnverified = errors.New("workflow run not verified")
+)
+
+// fatalWorkflowErrorCodes are the workflow error codes which indicate a
+// problem with the workflow or the submitted payload that further attempts
+// will not resolve.
+var fatalWorkflowErrorCodes = map[string]struct{}{
+	"WorkflowTriggerIsNotEnabled":    {},
+	"WorkflowNotFound":               {},
+	"WorkflowDisabled":               {},
+	"DirectApiAuthorizationRequired": {},
+	"AuthorizationFailed":            {},
+	"InvalidRequestContent":          {},
+	"TriggerInputSchemaMismatch":     {},
+	"InvalidTemplate":                {},
+}
+
+// retryableWorkflowErrorCodes are the workflow error codes which indicate a
+// transient problem.
+var retryableWorkflowErrorCodes = map[string]struct{}{
+	"WorkflowRequestsThrottled": {},
+	"TooManyRequests":           {},
+	"ServiceUnavailable":        {},
+	"GatewayTimeout":            {},
+}
+
+// WorkflowError is an error payload returned by a Power Automate or Logic
+// Apps workflow endpoint in response to a submitted message.
+type WorkflowError struct {
+
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+
+	// Code is the workflow error code (e.g., WorkflowTriggerIsNotEnabled).
+	Code string
+
+	// Message is the description of the error provided by the endpoint.
+	Message string
+
+	// RetryAfter is the delay requested by the endpoint before a further
+	// attempt is made. Zero if not specified.
+	RetryAfter time.Duration
+
+	// Err is the error returned by the Microsoft Teams client.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *WorkflowError) Error() string {
+	return fmt.Sprintf("workflow error %s (HTTP %d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+// Unwrap returns the error returned by the Microsoft Teams client.
+func (e *WorkflowError) Unwrap() error {
+	return e.Err
+}
+
+// Retryable indicates whether a further attempt to submit the message may
+// succeed. Error codes which are not known to be transient are treated as
+// fatal unless the status code indicates a throttled request or a server
+// side problem.
+func (e *WorkflowError) Retryable() bool {
+	if _, ok := fatalWorkflowErrorCodes[e.Code]; ok {
+		return false
+	}
+
+	if _, ok := retryableWorkflowErrorCodes[e.Code]; ok {
+		return true
+	}
+
+	switch {
+	case e.StatusCode == http.StatusRequestTimeout,
+		e.StatusCode == http.StatusTooManyRequests,
+		e.StatusCode >= http.StatusInternalServerError:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryable indicates whether submission should be retried after the given
+// error.
+func retryable(err error) bool {
+	var workflowErr *WorkflowError
+	if errors.As(err, &workflowErr) {
+		return workflowErr.Retryable()
+	}
+
+	return !errors.Is(err, ErrWorkflowRunUnverified)
+}
+
+// recordedResponse is the response to a request made on behalf of a
+// delivery attempt.
+type recordedResponse struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+}
+
+// responseRecorder retains the last response to requests made using a
+// context returned by withRecorder.
+type responseRecorder struct {
+	mu   sync.Mutex
+	resp *recordedResponse
+}
+
+// responseRecorderKey is the context key for a responseRecorder.
+type responseRecorderKey struct{}
+
+// withRecorder returns a copy of the given context which records responses
+// to requests made using it.
+func (r *responseRecorder) withRecorder(ctx context.Context) context.Context {
+	return context.WithValue(ctx, responseRecorderKey{}, r)
+}
+
+// last returns the last recorded response, or nil if none was recorded.
+func (r *responseRecorder) last() *recordedResponse {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.resp
+}
+
+// recordingTransport is a http.RoundTripper which records responses for
+// requests made using a context returned by withRecorder. The Microsoft
+// Teams client does not otherwise expose the status code, headers or body
+// of the response.
+type recordingTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	rec, ok := req.Context().Value(responseRecorderKey{}).(*responseRecorder)
+	if !ok {
+		return resp, nil
+	}
+
+	// Read the start of the body, then restore it for the client.
+	body, readErr := io.ReadAll(io.LimitReader(resp.Body, maxRecordedBody))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+
+	if readErr != nil {
+		return resp, nil
+	}
+
+	rec.mu.Lock()
+	rec.resp = &recordedResponse{
+		statusCode: resp.StatusCode,
+		header:     resp.Header.Clone(),
+		body:       body,
+	}
+	rec.mu.Unlock()
+
+	return resp, nil
+}
+
+// recordResponses configures the HTTP client used by the given Microsoft
+// Teams client to record responses for delivery attempts.
+func recordResponses(client *goteamsnotify.TeamsClient) {
+	httpClient := client.HTTPClient()
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	if _, ok := httpClient.Transport.(recordingTransport); ok {
+		return
+	}
+
+	wrapped := *httpClient
+	wrapped.Transport = recordingTransport{base: httpClient.Transport}
+	client.SetHTTPClient(&wrapped)
+}
+
+// workflowErrorPayload is the error payload returned by workflow endpoints.
+type workflowErrorPayload struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// workflowError returns the workflow error described by the given response,
+// or nil if the response is not a workflow error payload.
+func workflowError(resp *recordedResponse, sendErr error) *WorkflowError {
+	if resp == nil || resp.statusCode < http.StatusMultipleChoices {
+		return nil
+	}
+
+	var payload workflowErrorPayload
+	if err := json.Unmarshal(resp.body, &payload); err != nil || payload.Error.Code == "" {
+		return nil
+	}
+
+	return &WorkflowError{
+		StatusCode: resp.statusCode,
+		Code:       payload.Error.Code,
+		Message:    payload.Error.Message,
+		RetryAfter: retryAfter(resp.header, 0),
+		Err:        sendErr,
+	}
+}
+
+// classifyResponse returns the error for a delivery attempt given the
+// recorded response and the error returned by the Microsoft Teams client.
+// Workflow error payloads are returned as a WorkflowError. If requested, the
+// run triggered by a message accepted by a workflow is verified.
+func (d *Deliverer) classifyResponse(ctx context.Context, resp *recordedResponse, sendErr error) error {
+	if workflowErr := workflowError(resp, sendErr); workflowErr != nil {
+		return workflowErr
+	}
+
+	if d.cfg.VerifyWorkflowRun && resp != nil && resp.statusCode == http.StatusAccepted {
+		return d.verifyWorkflowRun(ctx, resp)
+	}
+
+	return sendErr
+}
+
+// verifyWorkflowRun waits for the run triggered by a message accepted by a
+// workflow to complete, returning an error wrapping ErrWorkflowRunFailed if
+// it did not complete successfully. If the endpoint does not provide a
+// status URL (as is common for the Teams webhook trigger) the run is assumed
+// to have succeeded.
+func (d *Deliverer) verifyWorkflowRun(ctx context.Context, accepted *recordedResponse) error {
+	runID := accepted.header.Get(headerWorkflowRunID)
+	if runID == "" {
+		runID = "(unknown)"
+	}
+
+	statusURL := accepted.header.Get(headerLocation)
+	if statusURL == "" {
+		if !d.cfg.SilentOutput {
+			log.Printf("WARNING: workflow accepted message (run %s), but did not provide a run status URL; run status not verified", runID)
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.cfg.VerifyWorkflowRunTimeout)
+	defer cancel()
+
+	interval := retryAfter(accepted.header, defaultRunPollInterval)
+	for {
+		if interval <= 0 {
+			interval = defaultRunPollInterval
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: run %s did not complete within %v", ErrWorkflowRunUnverified, runID, d.cfg.VerifyWorkflowRunTimeout)
+		case <-time.After(interval):
+		}
+
+		resp, err := d.pollRunStatus(ctx, statusURL)
+		if err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+			return fmt.Errorf("%w: failed to retrieve status of run %s: %v", ErrWorkflowRunUnverified, runID, err)
+		}
+
+		if d.cfg.VerboseOutput {
+			log.Printf("Status of workflow run %s: HTTP %d", runID, resp.statusCode)
+		}
+
+		switch {
+		case resp.statusCode == http.StatusAccepted:
+			interval = retryAfter(resp.header, interval)
+			continue
+
+		case resp.statusCode >= http.StatusMultipleChoices:
+			if workflowErr := workflowError(resp, nil); workflowErr != nil {
+				return fmt.Errorf("%w: run %s: %v", ErrWorkflowRunFailed, runID, workflowErr)
+			}
+			return fmt.Errorf("%w: run %s: HTTP %d: %q", ErrWorkflowRunFailed, runID, resp.statusCode, resp.body)
+		}
+
+		if status := runStatus(resp.body); isFailedRunStatus(status) {
+			return fmt.Errorf("%w: run %s: status %s", ErrWorkflowRunFailed, runID, status)
+		}
+
+		if d.cfg.VerboseOutput {
+			log.Printf("Workflow run %s completed successfully", runID)
+		}
+
+		return nil
+	}
+}
+
+// pollRunStatus requests the status of a workflow run from the given status
+// URL.
+func (d *Deliverer) pollRunStatus(ctx context.Context, statusURL string) (*recordedResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, statusURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", d.cfg.UserAgent())
+
+	resp, err := d.client.HTTPClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("error closing response body: %v", err)
+		}
+	}()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRecordedBody))
+	if err != nil {
+		return nil, err
+	}
+
+	return &recordedResponse{
+		statusCode: resp.StatusCode,
+		header:     resp.Header,
+		body:       body,
+	}, nil
+}
+
+// runStatus returns the run status (e.g., Succeeded, Failed) reported by a
+// run status response body, or an empty string if none is reported.
+func runStatus(body []byte) string {
+	var payload struct {
+		Status     string `json:"status"`
+		Properties struct {
+			Status string `json:"status"`
+		} `json:"properties"`
+	}
+
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+
+	if payload.Properties.Status != "" {
+		return payload.Properties.Status
+	}
+
+	return payload.Status
+}
+
+// isFailedRunStatus indicates whether the given run status describes a run
+// which did not complete successfully.
+func isFailedRunStatus(status string) bool {
+	switch strings.ToLower(status) {
+	case "failed", "cancelled", "canceled", "timedout", "aborted":
+		return true
+	default:
+		return false
+	}
+}
+
+// retryAfter returns the delay specified by the Retry-After header (either
+// a number of seconds or a HTTP date), or the given fallback if the header
+// is missing or invalid.
+func retryAfter(header http.Header, fallback time.Duration) time.Duration {
+	value := strings.TrimSpace(header.Get(headerRetryAfter))
+	if value == "" {
+		return fallback
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if when, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(when); delay > 0 {
+			return delay
+		}
+		return 0
+	}
+
+	return fallback
+}
@@ -0,0 +1,202 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package delivery
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	goteamsnotify "github.com/atc0005/go-teams-notify/v2"
+	"github.com/atc0005/go-teams-notify/v2/adaptivecard"
+	"github.com/atc0005/send2teams/internal/config"
+)
+
+// newTestDeliverer returns a Deliverer which submits messages to the given
+// handler, along with the URL of the test endpoint.
+func newTestDeliverer(t *testing.T, cfg *config.Config, handler http.Handler) (*Deliverer, string) {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := goteamsnotify.NewTeamsClient()
+	client.SkipWebhookURLValidationOnSend(true)
+
+	d, err := New(cfg, client)
+	if err != nil {
+		t.Fatalf("failed to create deliverer: %v", err)
+	}
+
+	return d, server.URL
+}
+
+func testMessage(t *testing.T) *adaptivecard.Message {
+	t.Helper()
+
+	msg, err := adaptivecard.NewSimpleMessage("text", "title", true)
+	if err != nil {
+		t.Fatalf("failed to create message: %v", err)
+	}
+
+	return msg
+}
+
+func TestWorkflowErrorRetryable(t *testing.T) {
+	tests := []struct {
+		err  WorkflowError
+		want bool
+	}{
+		{WorkflowError{StatusCode: http.StatusBadRequest, Code: "WorkflowTriggerIsNotEnabled"}, false},
+		{WorkflowError{StatusCode: http.StatusUnauthorized, Code: "DirectApiAuthorizationRequired"}, false},
+		{WorkflowError{StatusCode: http.StatusBadRequest, Code: "SomethingNew"}, false},
+		{WorkflowError{StatusCode: http.StatusTooManyRequests, Code: "WorkflowRequestsThrottled"}, true},
+		{WorkflowError{StatusCode: http.StatusBadGateway, Code: "SomethingNew"}, true},
+		{WorkflowError{StatusCode: http.StatusInternalServerError, Code: "InvalidTemplate"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.err.Retryable(); got != tt.want {
+			t.Errorf("Retryable() for %s (HTTP %d) = %t, want %t", tt.err.Code, tt.err.StatusCode, got, tt.want)
+		}
+	}
+}
+
+func TestSendWithRetryStopsOnFatalWorkflowError(t *testing.T) {
+	var requests int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":{"code":"WorkflowTriggerIsNotEnabled","message":"The workflow is disabled."}}`))
+	})
+
+	cfg := config.Config{Retries: 3, SilentOutput: true}
+	d, webhookURL := newTestDeliverer(t, &cfg, handler)
+
+	timing, err := d.DeliverTimed(context.Background(), "receipt", webhookURL, testMessage(t))
+
+	var workflowErr *WorkflowError
+	if !errors.As(err, &workflowErr) {
+		t.Fatalf("expected WorkflowError, got %v", err)
+	}
+
+	if workflowErr.Code != "WorkflowTriggerIsNotEnabled" {
+		t.Errorf("unexpected error code %q", workflowErr.Code)
+	}
+
+	if got := atomic.LoadInt32(&requests); got != 1 || len(timing.Attempts) != 1 {
+		t.Errorf("expected a single attempt, got %d requests and %d attempts", got, len(timing.Attempts))
+	}
+}
+
+func TestSendWithRetryRetriesThrottledWorkflow(t *testing.T) {
+	var requests int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"error":{"code":"WorkflowRequestsThrottled","message":"Slow down."}}`))
+			return
+		}
+		_, _ = w.Write([]byte(goteamsnotify.ExpectedWebhookURLResponseText))
+	})
+
+	cfg := config.Config{Retries: 2, SilentOutput: true}
+	d, webhookURL := newTestDeliverer(t, &cfg, handler)
+
+	if err := d.Deliver(context.Background(), "receipt", webhookURL, testMessage(t)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("expected 2 requests, got %d", got)
+	}
+}
+
+func TestVerifyWorkflowRun(t *testing.T) {
+	interval := defaultRunPollInterval
+	defaultRunPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { defaultRunPollInterval = interval })
+
+	tests := []struct {
+		name       string
+		statusBody string
+		wantErr    error
+	}{
+		{name: "succeeded", statusBody: `{"properties":{"status":"Succeeded"}}`},
+		{name: "failed", statusBody: `{"properties":{"status":"Failed"}}`, wantErr: ErrWorkflowRunFailed},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var polls int32
+			mux := http.NewServeMux()
+			mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&polls, 1) == 1 {
+					w.Header().Set(headerRetryAfter, "0")
+					w.WriteHeader(http.StatusAccepted)
+					return
+				}
+				_, _ = w.Write([]byte(tt.statusBody))
+			})
+
+			var statusURL string
+			mux.HandleFunc("/webhook", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set(headerLocation, statusURL)
+				w.Header().Set(headerRetryAfter, "0")
+				w.Header().Set(headerWorkflowRunID, "08585")
+				w.WriteHeader(http.StatusAccepted)
+			})
+
+			cfg := config.Config{
+				SilentOutput:             true,
+				VerifyWorkflowRun:        true,
+				VerifyWorkflowRunTimeout: 10 * time.Second,
+			}
+			d, baseURL := newTestDeliverer(t, &cfg, mux)
+			statusURL = baseURL + "/status"
+
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			defer cancel()
+
+			err := d.Deliver(ctx, "receipt", baseURL+"/webhook", testMessage(t))
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != nil && !errors.Is(err, tt.wantErr):
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+
+			if got := atomic.LoadInt32(&polls); got != 2 {
+				t.Errorf("expected 2 status requests, got %d", got)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	header := http.Header{}
+	if got := retryAfter(header, time.Second); got != time.Second {
+		t.Errorf("expected fallback for missing header, got %v", got)
+	}
+
+	header.Set(headerRetryAfter, "7")
+	if got := retryAfter(header, time.Second); got != 7*time.Second {
+		t.Errorf("expected 7s, got %v", got)
+	}
+
+	header.Set(headerRetryAfter, "soon")
+	if got := retryAfter(header, time.Second); got != time.Second {
+		t.Errorf("expected fallback for invalid header, got %v", got)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package events
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// azureMonitorSchemaID is the schema ID of Azure Monitor alerts using the
+// common alert schema.
+const azureMonitorSchemaID string = "azureMonitorCommonAlertSchema"
+
+// azureMonitorResolved is the monitor condition of resolved Azure Monitor
+// alerts.
+const azureMonitorResolved string = "Resolved"
+
+// AzureMonitorAlert is an Azure Monitor alert using the common alert schema.
+// Only the essentials section is translated since the alert context varies
+// by signal type.
+type AzureMonitorAlert struct {
+	SchemaID string `json:"schemaId"`
+	Data     struct {
+		Essentials struct {
+			AlertID           string   `json:"alertId"`
+			AlertRule         string   `json:"alertRule"`
+			Severity          string   `json:"severity"`
+			SignalType        string   `json:"signalType"`
+			MonitorCondition  string   `json:"monitorCondition"`
+			MonitoringService string   `json:"monitoringService"`
+			AlertTargetIDs    []string `json:"alertTargetIDs"`
+			FiredDateTime     string   `json:"firedDateTime"`
+			ResolvedDateTime  string   `json:"resolvedDateTime"`
+			Description       string   `json:"description"`
+		} `json:"essentials"`
+	} `json:"data"`
+}
+
+// translate returns the translation of the alert.
+func (a AzureMonitorAlert) translate() Translation {
+	e := a.Data.Essentials
+
+	msg := teams.Message{
+		Title: e.AlertRule,
+		Text:  e.Description,
+	}
+
+	if e.MonitorCondition != "" {
+		msg.Title = fmt.Sprintf("%s: %s", e.MonitorCondition, e.AlertRule)
+	}
+
+	if msg.Text == "" {
+		msg.Text = "(no description)"
+	}
+
+	// Alert targets are resource IDs; the resource names are displayed.
+	targets := make([]string, 0, len(e.AlertTargetIDs))
+	for _, id := range e.AlertTargetIDs {
+		if id != "" {
+			targets = append(targets, path.Base(id))
+		}
+	}
+
+	var alertID string
+	if e.AlertID != "" {
+		alertID = path.Base(e.AlertID)
+	}
+
+	appendFact(&msg, "Severity", e.Severity)
+	appendFact(&msg, "Resource", strings.Join(targets, ", "))
+	appendFact(&msg, "Signal", strings.TrimSpace(e.MonitoringService+" "+e.SignalType))
+	appendFact(&msg, "Fired", e.FiredDateTime)
+	appendFact(&msg, "Resolved", e.ResolvedDateTime)
+	appendFact(&msg, "Alert ID", alertID)
+
+	severity := SeverityInfo
+	switch {
+	case e.MonitorCondition == azureMonitorResolved:
+		severity = SeverityOK
+	case e.Severity == "Sev0", e.Severity == "Sev1":
+		severity = SeverityCritical
+	case e.Severity == "Sev2":
+		severity = SeverityWarning
+	}
+
+	return Translation{Message: msg, Severity: severity}
+}
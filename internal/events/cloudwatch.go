@@ -0,0 +1,102 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// CloudWatch alarm states.
+const (
+	cloudWatchStateAlarm            string = "ALARM"
+	cloudWatchStateOK               string = "OK"
+	cloudWatchStateInsufficientData string = "INSUFFICIENT_DATA"
+)
+
+// CloudWatchAlarm is an Amazon CloudWatch alarm state change notification,
+// as published to SNS topics by alarm actions.
+type CloudWatchAlarm struct {
+	AlarmName        string `json:"AlarmName"`
+	AlarmDescription string `json:"AlarmDescription"`
+	AWSAccountID     string `json:"AWSAccountId"`
+	NewStateValue    string `json:"NewStateValue"`
+	NewStateReason   string `json:"NewStateReason"`
+	StateChangeTime  string `json:"StateChangeTime"`
+	Region           string `json:"Region"`
+	AlarmArn         string `json:"AlarmArn"`
+	OldStateValue    string `json:"OldStateValue"`
+	Trigger          struct {
+		MetricName         string      `json:"MetricName"`
+		Namespace          string      `json:"Namespace"`
+		Statistic          string      `json:"Statistic"`
+		Period             int         `json:"Period"`
+		Threshold          json.Number `json:"Threshold"`
+		ComparisonOperator string      `json:"ComparisonOperator"`
+	} `json:"Trigger"`
+}
+
+// translate returns the translation of the alarm.
+func (a CloudWatchAlarm) translate() Translation {
+	msg := teams.Message{
+		Title: a.AlarmName,
+		Text:  a.NewStateReason,
+	}
+
+	if a.NewStateValue != "" {
+		msg.Title = fmt.Sprintf("%s: %s", a.NewStateValue, a.AlarmName)
+	}
+
+	if a.AlarmDescription != "" {
+		msg.Text = strings.TrimSpace(a.AlarmDescription + "\n\n" + msg.Text)
+	}
+
+	if msg.Text == "" {
+		msg.Text = "(no state reason)"
+	}
+
+	var transition string
+	if a.OldStateValue != "" && a.NewStateValue != "" {
+		transition = a.OldStateValue + " -> " + a.NewStateValue
+	}
+
+	var metric string
+	if a.Trigger.MetricName != "" {
+		metric = a.Trigger.MetricName
+		if a.Trigger.Namespace != "" {
+			metric = a.Trigger.Namespace + "/" + metric
+		}
+	}
+
+	var threshold string
+	if a.Trigger.ComparisonOperator != "" {
+		threshold = strings.TrimSpace(fmt.Sprintf("%s %s %s", a.Trigger.Statistic, a.Trigger.ComparisonOperator, a.Trigger.Threshold))
+	}
+
+	appendFact(&msg, "State", transition)
+	appendFact(&msg, "Metric", metric)
+	appendFact(&msg, "Threshold", threshold)
+	appendFact(&msg, "Account", a.AWSAccountID)
+	appendFact(&msg, "Region", a.Region)
+	appendFact(&msg, "Time", a.StateChangeTime)
+
+	var severity Severity
+	switch a.NewStateValue {
+	case cloudWatchStateAlarm:
+		severity = SeverityCritical
+	case cloudWatchStateInsufficientData:
+		severity = SeverityWarning
+	case cloudWatchStateOK:
+		severity = SeverityOK
+	}
+
+	return Translation{Message: msg, Severity: severity}
+}
@@ -0,0 +1,17 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package events translates event payloads into messages. This includes the
+payloads delivered to serverless functions (e.g., AWS SNS notifications
+delivered to Lambda, Azure Event Grid events delivered to Azure Functions)
+and the alert formats selected via the input-format flag (e.g., CloudWatch
+alarms, Azure Monitor alerts using the common alert schema), whose severity
+selects the title color. Nagios notifications are translated from the
+macros Nagios provides to notification commands as environment variables.
+*/
+package events
@@ -0,0 +1,120 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// subscriptionValidationEvent is the type of the event sent by Event Grid to
+// validate an endpoint when a subscription is created. These events do not
+// produce messages.
+const subscriptionValidationEvent string = "Microsoft.EventGrid.SubscriptionValidationEvent"
+
+// EventGridEvent is an Azure Event Grid event using either the Event Grid
+// schema or the CloudEvents v1.0 schema.
+type EventGridEvent struct {
+
+	// Event Grid schema fields.
+	ID          string          `json:"id"`
+	Topic       string          `json:"topic"`
+	Subject     string          `json:"subject"`
+	EventType   string          `json:"eventType"`
+	EventTime   string          `json:"eventTime"`
+	Data        json.RawMessage `json:"data"`
+	DataVersion string          `json:"dataVersion"`
+
+	// CloudEvents schema fields.
+	SpecVersion string `json:"specversion"`
+	Type        string `json:"type"`
+	Source      string `json:"source"`
+	Time        string `json:"time"`
+}
+
+// EventGrid translates the given Event Grid payload (a single event or an
+// array of events, using either the Event Grid or CloudEvents schema) into
+// messages. Subscription validation events are skipped.
+func EventGrid(payload []byte) ([]teams.Message, error) {
+	payload = bytes.TrimSpace(payload)
+
+	var events []EventGridEvent
+	switch {
+	case bytes.HasPrefix(payload, []byte("[")):
+		if err := json.Unmarshal(payload, &events); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnrecognized, err)
+		}
+
+	default:
+		var event EventGridEvent
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnrecognized, err)
+		}
+		events = []EventGridEvent{event}
+	}
+
+	msgs := make([]teams.Message, 0, len(events))
+	for i, event := range events {
+		if event.eventType() == "" {
+			return nil, fmt.Errorf("%w: event %d does not specify an event type", ErrUnrecognized, i)
+		}
+
+		if event.eventType() == subscriptionValidationEvent {
+			continue
+		}
+
+		msgs = append(msgs, event.TeamsMessage())
+	}
+
+	return msgs, nil
+}
+
+// eventType returns the type of the event for either schema.
+func (e EventGridEvent) eventType() string {
+	if e.EventType != "" {
+		return e.EventType
+	}
+
+	return e.Type
+}
+
+// TeamsMessage returns the message for the event.
+func (e EventGridEvent) TeamsMessage() teams.Message {
+	msg := teams.Message{
+		Title: e.eventType(),
+		Text:  dataText(e.Data),
+	}
+
+	if msg.Text == "" {
+		msg.Text = e.Subject
+	}
+
+	if msg.Text == "" {
+		msg.Text = "(no event data)"
+	}
+
+	source := e.Topic
+	if source == "" {
+		source = e.Source
+	}
+
+	eventTime := e.EventTime
+	if eventTime == "" {
+		eventTime = e.Time
+	}
+
+	appendFact(&msg, "Source", source)
+	appendFact(&msg, "Subject", e.Subject)
+	appendFact(&msg, "Event time", eventTime)
+	appendFact(&msg, "Event ID", e.ID)
+
+	return msg
+}
@@ -0,0 +1,95 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// ErrUnrecognized indicates that an event payload is not in a supported
+// format.
+var ErrUnrecognized = errors.New("unrecognized event payload")
+
+// maxDataBytes is the maximum number of bytes of event data included in a
+// message.
+const maxDataBytes int = 4096
+
+// Lambda translates the payload of an AWS Lambda invocation into messages.
+// SNS notification events produce one message per record; other payloads
+// are decoded as a message (e.g., {"title": "...", "text": "..."}) for
+// direct invocations.
+func Lambda(payload []byte) ([]teams.Message, error) {
+	var event snsEvent
+	if err := json.Unmarshal(payload, &event); err == nil && len(event.Records) > 0 {
+		return event.messages()
+	}
+
+	return direct(payload)
+}
+
+// direct decodes the given payload as a message.
+func direct(payload []byte) ([]teams.Message, error) {
+	var msg teams.Message
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnrecognized, err)
+	}
+
+	if err := msg.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnrecognized, err)
+	}
+
+	return []teams.Message{msg}, nil
+}
+
+// dataText returns the given event data formatted for display within a
+// message, truncated if very large.
+func dataText(data json.RawMessage) string {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || string(data) == "null" {
+		return ""
+	}
+
+	// String values (e.g., an SNS message) are displayed as-is.
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return s
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, data, "", "  "); err != nil {
+		indented.Reset()
+		indented.Write(data)
+	}
+
+	text := indented.String()
+	if len(text) > maxDataBytes {
+		// Back off to the start of a rune so that a multi-byte character is
+		// not split.
+		cut := maxDataBytes
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text = text[:cut] + "\n..."
+	}
+
+	return "```\n" + text + "\n```"
+}
+
+// appendFact appends a fact with the given title and value to the message
+// if the value is not empty.
+func appendFact(msg *teams.Message, title string, value string) {
+	if value != "" {
+		msg.Facts = append(msg.Facts, teams.Fact{Title: title, Value: value})
+	}
+}
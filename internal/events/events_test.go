@@ -0,0 +1,293 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestLambdaSNS(t *testing.T) {
+	payload := `{"Records": [
+		{"EventSource": "aws:sns", "Sns": {"MessageId": "m-1", "TopicArn": "arn:aws:sns:us-east-1:123456789012:alerts", "Subject": "Disk full", "Message": "/var is 98% full", "Timestamp": "2021-01-01T00:00:00.000Z"}},
+		{"EventSource": "aws:sns", "Sns": {"Message": "{\"detail-type\":\"EC2 Instance State-change Notification\"}"}}
+	]}`
+
+	msgs, err := Lambda([]byte(payload))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(msgs) != 2 {
+		t.Fatalf("got %d messages, want 2", len(msgs))
+	}
+
+	if msgs[0].Title != "Disk full" || msgs[0].Text != "/var is 98% full" {
+		t.Errorf("unexpected first message: %+v", msgs[0])
+	}
+
+	if len(msgs[0].Facts) != 3 || msgs[0].Facts[0].Value != "arn:aws:sns:us-east-1:123456789012:alerts" {
+		t.Errorf("unexpected facts: %+v", msgs[0].Facts)
+	}
+
+	if msgs[1].Title != "SNS notification" || !strings.Contains(msgs[1].Text, `"detail-type": "EC2 Instance State-change Notification"`) {
+		t.Errorf("unexpected second message: %+v", msgs[1])
+	}
+}
+
+func TestLambdaDirect(t *testing.T) {
+	msgs, err := Lambda([]byte(`{"title": "Deploy", "text": "Deployment complete"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(msgs) != 1 || msgs[0].Title != "Deploy" {
+		t.Errorf("unexpected messages: %+v", msgs)
+	}
+
+	for _, payload := range []string{`not json`, `{}`, `{"Records": [{"EventSource": "aws:sqs"}]}`} {
+		if _, err := Lambda([]byte(payload)); !errors.Is(err, ErrUnrecognized) {
+			t.Errorf("payload %s: got error %v, want ErrUnrecognized", payload, err)
+		}
+	}
+}
+
+func TestDataTextTruncation(t *testing.T) {
+	// The three byte runes straddle the truncation limit.
+	prefix := `{"message": "` + strings.Repeat("x", maxDataBytes-len(`{`+"\n"+`  "message": "`)-1)
+	data := json.RawMessage(prefix + strings.Repeat("€", 10) + `"}`)
+
+	text := dataText(data)
+	if !utf8.ValidString(text) {
+		t.Fatalf("got invalid UTF-8 after truncation: %q", text[len(text)-16:])
+	}
+
+	if !strings.HasSuffix(text, "x\n...\n```") {
+		t.Errorf("got %q; want truncation before the split rune", text[len(text)-16:])
+	}
+
+	if len(text) > maxDataBytes+len("```\n"+"\n...\n```") {
+		t.Errorf("got %d bytes; want at most %d bytes of data", len(text), maxDataBytes)
+	}
+}
+
+func TestEventGrid(t *testing.T) {
+	payload := `[
+		{"id": "e-1", "topic": "/subscriptions/x/resourceGroups/rg", "subject": "/blobs/a.txt", "eventType": "Microsoft.Storage.BlobCreated", "eventTime": "2021-01-01T00:00:00Z", "data": {"url": "https://example.blob.core.windows.net/a.txt"}},
+		{"id": "e-2", "eventType": "Microsoft.EventGrid.SubscriptionValidationEvent", "data": {"validationCode": "abc"}},
+		{"specversion": "1.0", "id": "e-3", "type": "Custom.Deployed", "source": "/apps/web", "subject": "v1.2.3", "time": "2021-01-01T00:00:00Z"}
+	]`
+
+	msgs, err := EventGrid([]byte(payload))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(msgs) != 2 {
+		t.Fatalf("got %d messages, want 2", len(msgs))
+	}
+
+	if msgs[0].Title != "Microsoft.Storage.BlobCreated" || !strings.Contains(msgs[0].Text, "a.txt") {
+		t.Errorf("unexpected first message: %+v", msgs[0])
+	}
+
+	if msgs[1].Title != "Custom.Deployed" || msgs[1].Text != "v1.2.3" || msgs[1].Facts[0].Value != "/apps/web" {
+		t.Errorf("unexpected second message: %+v", msgs[1])
+	}
+
+	if _, err := EventGrid([]byte(`{"id": "no-type"}`)); !errors.Is(err, ErrUnrecognized) {
+		t.Errorf("got error %v, want ErrUnrecognized", err)
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	alarm := `{"AlarmName": "api-5xx", "AlarmDescription": "API errors", "AWSAccountId": "123456789012", "NewStateValue": "ALARM", "NewStateReason": "Threshold Crossed", "OldStateValue": "OK", "Region": "US East (N. Virginia)", "Trigger": {"MetricName": "5XXError", "Namespace": "AWS/ApiGateway", "Statistic": "SUM", "Threshold": 10.0, "ComparisonOperator": "GreaterThanThreshold"}}`
+	quoted, _ := json.Marshal(alarm)
+
+	tests := []struct {
+		name     string
+		format   string
+		payload  string
+		title    string
+		severity Severity
+		fact     string
+	}{
+		{
+			name:     "cloudwatch alarm",
+			format:   FormatCloudWatchAlarm,
+			payload:  alarm,
+			title:    "ALARM: api-5xx",
+			severity: SeverityCritical,
+			fact:     "AWS/ApiGateway/5XXError",
+		},
+		{
+			name:     "cloudwatch alarm via sns",
+			format:   FormatAuto,
+			payload:  `{"Type": "Notification", "MessageId": "m-1", "TopicArn": "arn:aws:sns:us-east-1:123456789012:alarms", "Subject": "ALARM", "Message": ` + string(quoted) + `}`,
+			title:    "ALARM: api-5xx",
+			severity: SeverityCritical,
+			fact:     "SUM GreaterThanThreshold 10.0",
+		},
+		{
+			name:     "sns",
+			format:   FormatSNS,
+			payload:  `{"Type": "Notification", "TopicArn": "arn:aws:sns:us-east-1:123456789012:builds", "Subject": "Build passed", "Message": "main is green"}`,
+			title:    "Build passed",
+			severity: SeverityNone,
+			fact:     "arn:aws:sns:us-east-1:123456789012:builds",
+		},
+		{
+			name:     "azure monitor",
+			format:   FormatAuto,
+			payload:  `{"schemaId": "azureMonitorCommonAlertSchema", "data": {"essentials": {"alertId": "/subscriptions/x/providers/Microsoft.AlertsManagement/alerts/a-1", "alertRule": "High CPU", "severity": "Sev2", "signalType": "Metric", "monitorCondition": "Fired", "monitoringService": "Platform", "alertTargetIDs": ["/subscriptions/x/resourcegroups/rg/providers/microsoft.compute/virtualmachines/vm-1"], "description": "CPU above 90%"}}}`,
+			title:    "Fired: High CPU",
+			severity: SeverityWarning,
+			fact:     "vm-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Translate(tt.format, []byte(tt.payload))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got.Message.Title != tt.title {
+				t.Errorf("got title %q, want %q", got.Message.Title, tt.title)
+			}
+
+			if got.Severity != tt.severity {
+				t.Errorf("got severity %q, want %q", got.Severity, tt.severity)
+			}
+
+			var found bool
+			for _, fact := range got.Message.Facts {
+				found = found || fact.Value == tt.fact
+			}
+			if !found {
+				t.Errorf("fact value %q not found in %+v", tt.fact, got.Message.Facts)
+			}
+		})
+	}
+
+	if _, err := Translate(FormatAuto, []byte(`{"unknown": true}`)); !errors.Is(err, ErrUnrecognized) {
+		t.Errorf("got error %v, want ErrUnrecognized", err)
+	}
+
+	if _, err := Translate(FormatAzureMonitor, []byte(alarm)); !errors.Is(err, ErrUnrecognized) {
+		t.Errorf("got error %v, want ErrUnrecognized", err)
+	}
+}
+
+func TestNagiosTranslate(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		title    string
+		text     string
+		severity Severity
+		fact     string
+	}{
+		{
+			name: "service problem",
+			env: map[string]string{
+				"NAGIOS_NOTIFICATIONTYPE":   "PROBLEM",
+				"NAGIOS_HOSTNAME":           "web01",
+				"NAGIOS_HOSTALIAS":          "Web server",
+				"NAGIOS_SERVICEDESC":        "HTTP",
+				"NAGIOS_SERVICESTATE":       "CRITICAL",
+				"NAGIOS_SERVICEATTEMPT":     "3",
+				"NAGIOS_MAXSERVICEATTEMPTS": "3",
+				"NAGIOS_SERVICEOUTPUT":      "HTTP CRITICAL - connection refused",
+				"NAGIOS_LONGSERVICEOUTPUT":  `port 80\nport 443`,
+			},
+			title:    "PROBLEM: HTTP on web01 is CRITICAL",
+			text:     "HTTP CRITICAL - connection refused\n\nport 80\nport 443",
+			severity: SeverityCritical,
+			fact:     "CRITICAL (attempt 3/3)",
+		},
+		{
+			name: "host recovery",
+			env: map[string]string{
+				"NAGIOS_NOTIFICATIONTYPE":   "RECOVERY",
+				"NAGIOS_NOTIFICATIONNUMBER": "4",
+				"NAGIOS_HOSTNAME":           "db01",
+				"NAGIOS_HOSTSTATE":          "UP",
+				"NAGIOS_HOSTOUTPUT":         "PING OK",
+			},
+			title:    "RECOVERY: db01 is UP",
+			text:     "PING OK",
+			severity: SeverityOK,
+			fact:     "RECOVERY (#4)",
+		},
+		{
+			name: "acknowledgement",
+			env: map[string]string{
+				"NAGIOS_NOTIFICATIONTYPE":   "ACKNOWLEDGEMENT",
+				"NAGIOS_NOTIFICATIONAUTHOR": "jdoe",
+				"NAGIOS_HOSTNAME":           "db01",
+				"NAGIOS_HOSTSTATE":          "DOWN",
+			},
+			title:    "ACKNOWLEDGEMENT: db01 is DOWN",
+			text:     "(no plugin output)",
+			severity: SeverityInfo,
+			fact:     "jdoe",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NagiosFromEnv(func(name string) string { return tt.env[name] }).Translate()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got.Message.Title != tt.title || got.Message.Text != tt.text {
+				t.Errorf("got title %q and text %q, want %q and %q", got.Message.Title, got.Message.Text, tt.title, tt.text)
+			}
+
+			if got.Severity != tt.severity {
+				t.Errorf("got severity %q, want %q", got.Severity, tt.severity)
+			}
+
+			var found bool
+			for _, fact := range got.Message.Facts {
+				found = found || fact.Value == tt.fact
+			}
+			if !found {
+				t.Errorf("fact value %q not found in %+v", tt.fact, got.Message.Facts)
+			}
+		})
+	}
+
+	if _, err := NagiosFromEnv(func(string) string { return "" }).Translate(); !errors.Is(err, ErrUnrecognized) {
+		t.Errorf("got error %v, want ErrUnrecognized", err)
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	tests := map[string]Severity{
+		"":         SeverityNone,
+		"fatal":    SeverityCritical,
+		"Error":    SeverityCritical,
+		"Sev1":     SeverityCritical,
+		"warning":  SeverityWarning,
+		"resolved": SeverityOK,
+		"debug":    SeverityInfo,
+	}
+
+	for value, want := range tests {
+		if got := ParseSeverity(value); got != want {
+			t.Errorf("ParseSeverity(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
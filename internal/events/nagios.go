@@ -0,0 +1,193 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package events
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// nagiosMacroPrefix is the prefix of the names of the environment variables
+// which Nagios uses to provide macros to notification commands.
+const nagiosMacroPrefix string = "NAGIOS_"
+
+// Nagios notification types which do not report a change of state.
+const (
+	nagiosTypeRecovery        string = "RECOVERY"
+	nagiosTypeAcknowledgement string = "ACKNOWLEDGEMENT"
+	nagiosTypeFlappingPrefix  string = "FLAPPING"
+	nagiosTypeDowntimePrefix  string = "DOWNTIME"
+	nagiosTypeCustom          string = "CUSTOM"
+)
+
+// NagiosNotification is a Nagios host or service notification, as described
+// by the standard macros Nagios provides to notification commands via
+// NAGIOS_* environment variables (e.g., NAGIOS_HOSTNAME).
+type NagiosNotification struct {
+	Type               string
+	Number             string
+	Author             string
+	Comment            string
+	DateTime           string
+	HostName           string
+	HostAlias          string
+	HostAddress        string
+	HostState          string
+	HostOutput         string
+	LongHostOutput     string
+	HostAttempt        string
+	MaxHostAttempts    string
+	HostDuration       string
+	ServiceDesc        string
+	ServiceState       string
+	ServiceOutput      string
+	LongServiceOutput  string
+	ServiceAttempt     string
+	MaxServiceAttempts string
+	ServiceDuration    string
+}
+
+// NagiosFromEnv returns the Nagios notification described by the NAGIOS_*
+// environment variables retrieved using the given function (e.g.,
+// os.Getenv).
+func NagiosFromEnv(getenv func(string) string) NagiosNotification {
+	macro := func(name string) string {
+		return strings.TrimSpace(getenv(nagiosMacroPrefix + name))
+	}
+
+	return NagiosNotification{
+		Type:               macro("NOTIFICATIONTYPE"),
+		Number:             macro("NOTIFICATIONNUMBER"),
+		Author:             macro("NOTIFICATIONAUTHOR"),
+		Comment:            macro("NOTIFICATIONCOMMENT"),
+		DateTime:           macro("LONGDATETIME"),
+		HostName:           macro("HOSTNAME"),
+		HostAlias:          macro("HOSTALIAS"),
+		HostAddress:        macro("HOSTADDRESS"),
+		HostState:          macro("HOSTSTATE"),
+		HostOutput:         macro("HOSTOUTPUT"),
+		LongHostOutput:     macro("LONGHOSTOUTPUT"),
+		HostAttempt:        macro("HOSTATTEMPT"),
+		MaxHostAttempts:    macro("MAXHOSTATTEMPTS"),
+		HostDuration:       macro("HOSTDURATION"),
+		ServiceDesc:        macro("SERVICEDESC"),
+		ServiceState:       macro("SERVICESTATE"),
+		ServiceOutput:      macro("SERVICEOUTPUT"),
+		LongServiceOutput:  macro("LONGSERVICEOUTPUT"),
+		ServiceAttempt:     macro("SERVICEATTEMPT"),
+		MaxServiceAttempts: macro("MAXSERVICEATTEMPTS"),
+		ServiceDuration:    macro("SERVICEDURATION"),
+	}
+}
+
+// Translate returns the translation of the notification. Service
+// notifications are assumed if a service description is provided. An error
+// is returned if the host name is not provided (e.g., environment macros
+// are disabled via the enable_environment_macros Nagios setting).
+func (n NagiosNotification) Translate() (Translation, error) {
+	if n.HostName == "" {
+		return Translation{}, fmt.Errorf(
+			"%w: %sHOSTNAME not set (environment macros may be disabled via the enable_environment_macros setting)",
+			ErrUnrecognized,
+			nagiosMacroPrefix,
+		)
+	}
+
+	isService := n.ServiceDesc != ""
+
+	subject, state, output, longOutput := n.HostName, n.HostState, n.HostOutput, n.LongHostOutput
+	attempt, maxAttempts, duration := n.HostAttempt, n.MaxHostAttempts, n.HostDuration
+	if isService {
+		subject = fmt.Sprintf("%s on %s", n.ServiceDesc, n.HostName)
+		state, output, longOutput = n.ServiceState, n.ServiceOutput, n.LongServiceOutput
+		attempt, maxAttempts, duration = n.ServiceAttempt, n.MaxServiceAttempts, n.ServiceDuration
+	}
+
+	msg := teams.Message{Title: subject}
+	if state != "" {
+		msg.Title = fmt.Sprintf("%s is %s", subject, state)
+	}
+	if n.Type != "" {
+		msg.Title = fmt.Sprintf("%s: %s", n.Type, msg.Title)
+	}
+
+	// Nagios escapes the newlines of long plugin output.
+	longOutput = strings.ReplaceAll(longOutput, `\n`, "\n")
+	msg.Text = strings.TrimSpace(output + "\n\n" + longOutput)
+	if msg.Text == "" {
+		msg.Text = "(no plugin output)"
+	}
+
+	host := n.HostName
+	if n.HostAlias != "" && n.HostAlias != n.HostName {
+		host = fmt.Sprintf("%s (%s)", n.HostName, n.HostAlias)
+	}
+
+	if state != "" && attempt != "" && maxAttempts != "" {
+		state = fmt.Sprintf("%s (attempt %s/%s)", state, attempt, maxAttempts)
+	}
+
+	appendFact(&msg, "Host", host)
+	appendFact(&msg, "Address", n.HostAddress)
+	if isService {
+		appendFact(&msg, "Service", n.ServiceDesc)
+	}
+	appendFact(&msg, "State", state)
+	appendFact(&msg, "Duration", duration)
+	appendFact(&msg, "Notification", notificationLabel(n.Type, n.Number))
+	appendFact(&msg, "Author", n.Author)
+	appendFact(&msg, "Comment", n.Comment)
+	appendFact(&msg, "Time", n.DateTime)
+
+	return Translation{Message: msg, Severity: n.severity()}, nil
+}
+
+// notificationLabel returns the notification type along with the
+// notification number, if known.
+func notificationLabel(notificationType string, number string) string {
+	if notificationType == "" || number == "" || number == "0" {
+		return notificationType
+	}
+
+	return fmt.Sprintf("%s (#%s)", notificationType, number)
+}
+
+// severity returns the severity of the notification. Notifications which do
+// not report a change of state (e.g., acknowledgements, downtime and
+// flapping notifications) are informational.
+func (n NagiosNotification) severity() Severity {
+	switch {
+	case n.Type == nagiosTypeRecovery:
+		return SeverityOK
+	case n.Type == nagiosTypeAcknowledgement,
+		n.Type == nagiosTypeCustom,
+		strings.HasPrefix(n.Type, nagiosTypeFlappingPrefix),
+		strings.HasPrefix(n.Type, nagiosTypeDowntimePrefix):
+		return SeverityInfo
+	}
+
+	state := n.HostState
+	if n.ServiceDesc != "" {
+		state = n.ServiceState
+	}
+
+	switch state {
+	case "OK", "UP":
+		return SeverityOK
+	case "WARNING", "UNKNOWN":
+		return SeverityWarning
+	case "CRITICAL", "DOWN", "UNREACHABLE":
+		return SeverityCritical
+	case "":
+		return SeverityNone
+	default:
+		return SeverityInfo
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// snsEventSource is the event source of SNS records delivered to Lambda.
+const snsEventSource string = "aws:sns"
+
+// snsEvent is an SNS event delivered to an AWS Lambda function.
+type snsEvent struct {
+	Records []struct {
+		EventSource string          `json:"EventSource"`
+		SNS         SNSNotification `json:"Sns"`
+	} `json:"Records"`
+}
+
+// SNSNotification is an Amazon SNS notification, as delivered to Lambda
+// functions and HTTP/S subscriptions.
+type SNSNotification struct {
+	Type      string `json:"Type"`
+	MessageID string `json:"MessageId"`
+	TopicArn  string `json:"TopicArn"`
+	Subject   string `json:"Subject"`
+	Message   string `json:"Message"`
+	Timestamp string `json:"Timestamp"`
+}
+
+// messages returns a message for each SNS record of the event.
+func (e snsEvent) messages() ([]teams.Message, error) {
+	msgs := make([]teams.Message, 0, len(e.Records))
+	for i, record := range e.Records {
+		if record.EventSource != snsEventSource {
+			return nil, fmt.Errorf("%w: record %d has unsupported event source %q", ErrUnrecognized, i, record.EventSource)
+		}
+		msgs = append(msgs, record.SNS.TeamsMessage())
+	}
+
+	return msgs, nil
+}
+
+// TeamsMessage returns the message for the notification.
+func (n SNSNotification) TeamsMessage() teams.Message {
+	return n.translate().Message
+}
+
+// translate returns the translation of the notification. CloudWatch alarms
+// delivered via SNS are translated as alarms.
+func (n SNSNotification) translate() Translation {
+	var alarm CloudWatchAlarm
+	if err := json.Unmarshal([]byte(n.Message), &alarm); err == nil && alarm.AlarmName != "" {
+		t := alarm.translate()
+		appendFact(&t.Message, "Topic", n.TopicArn)
+		appendFact(&t.Message, "Message ID", n.MessageID)
+
+		return t
+	}
+
+	msg := teams.Message{
+		Title: n.Subject,
+		Text:  n.Message,
+	}
+
+	if msg.Title == "" {
+		msg.Title = "SNS notification"
+	}
+
+	// JSON messages (e.g., from other AWS services) are displayed formatted.
+	if json.Valid([]byte(n.Message)) {
+		if text := dataText(json.RawMessage(n.Message)); text != "" {
+			msg.Text = text
+		}
+	}
+
+	if msg.Text == "" {
+		msg.Text = "(empty message)"
+	}
+
+	appendFact(&msg, "Topic", n.TopicArn)
+	appendFact(&msg, "Message ID", n.MessageID)
+	appendFact(&msg, "Timestamp", n.Timestamp)
+
+	return Translation{Message: msg}
+}
@@ -0,0 +1,190 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/atc0005/go-teams-notify/v2/adaptivecard"
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// Supported input formats.
+const (
+
+	// FormatAuto detects the format of the payload from its content.
+	FormatAuto string = "auto"
+
+	// FormatSNS is an Amazon SNS notification as delivered to HTTP/S
+	// subscriptions. CloudWatch alarms delivered via SNS are translated as
+	// alarms.
+	FormatSNS string = "sns"
+
+	// FormatCloudWatchAlarm is an Amazon CloudWatch alarm state change
+	// notification.
+	FormatCloudWatchAlarm string = "cloudwatch-alarm"
+
+	// FormatAzureMonitor is an Azure Monitor alert using the common alert
+	// schema.
+	FormatAzureMonitor string = "azure-monitor"
+)
+
+// Formats returns the supported input formats.
+func Formats() []string {
+	return []string{FormatAuto, FormatSNS, FormatCloudWatchAlarm, FormatAzureMonitor}
+}
+
+// Severity is the severity of a translated event.
+type Severity string
+
+// Supported severities.
+const (
+	SeverityNone     Severity = ""
+	SeverityInfo     Severity = "info"
+	SeverityOK       Severity = "ok"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+// Color returns the Adaptive Card color used for the title of messages with
+// the severity, or an empty string if the severity does not select a color.
+func (s Severity) Color() string {
+	switch s {
+	case SeverityOK:
+		return adaptivecard.ColorGood
+	case SeverityWarning:
+		return adaptivecard.ColorWarning
+	case SeverityCritical:
+		return adaptivecard.ColorAttention
+	default:
+		return ""
+	}
+}
+
+// ParseSeverity returns the severity described by the given value, as
+// commonly used by monitoring and error tracking tools (e.g., "fatal",
+// "error", "warning", "resolved", "Sev1"). Unrecognized values are treated
+// as informational.
+func ParseSeverity(value string) Severity {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "":
+		return SeverityNone
+	case "critical", "crit", "fatal", "emergency", "alert", "error", "err",
+		"failure", "failed", "down", "alarm", "sev0", "sev1", "p1", "high":
+		return SeverityCritical
+	case "warning", "warn", "insufficient_data", "degraded", "sev2", "p2", "medium":
+		return SeverityWarning
+	case "ok", "good", "success", "succeeded", "up", "resolved", "recovered":
+		return SeverityOK
+	default:
+		return SeverityInfo
+	}
+}
+
+// Translation is the message translated from an event payload.
+type Translation struct {
+
+	// Message is the translated message.
+	Message teams.Message
+
+	// Severity is the severity of the event, if known.
+	Severity Severity
+}
+
+// Translate translates the given payload using the given input format.
+func Translate(format string, payload []byte) (Translation, error) {
+	payload = bytes.TrimSpace(payload)
+
+	if format == FormatAuto {
+		format = detectFormat(payload)
+		if format == "" {
+			return Translation{}, fmt.Errorf("%w: format not detected", ErrUnrecognized)
+		}
+	}
+
+	switch format {
+	case FormatSNS:
+		var n SNSNotification
+		if err := decodeObject(payload, &n); err != nil {
+			return Translation{}, err
+		}
+		if n.Type == "" && n.Message == "" {
+			return Translation{}, fmt.Errorf("%w: not an SNS notification", ErrUnrecognized)
+		}
+		return n.translate(), nil
+
+	case FormatCloudWatchAlarm:
+		var a CloudWatchAlarm
+		if err := decodeObject(payload, &a); err != nil {
+			return Translation{}, err
+		}
+		if a.AlarmName == "" {
+			return Translation{}, fmt.Errorf("%w: not a CloudWatch alarm", ErrUnrecognized)
+		}
+		return a.translate(), nil
+
+	case FormatAzureMonitor:
+		var a AzureMonitorAlert
+		if err := decodeObject(payload, &a); err != nil {
+			return Translation{}, err
+		}
+		if a.SchemaID != azureMonitorSchemaID {
+			return Translation{}, fmt.Errorf("%w: not an Azure Monitor common alert schema payload", ErrUnrecognized)
+		}
+		return a.translate(), nil
+
+	default:
+		return Translation{}, fmt.Errorf(
+			"unsupported input format %q; expected one of %s",
+			format,
+			strings.Join(Formats(), ", "),
+		)
+	}
+}
+
+// detectFormat returns the input format of the given payload, or an empty
+// string if the format is not recognized.
+func detectFormat(payload []byte) string {
+	var probe struct {
+		Type      string `json:"Type"`
+		TopicArn  string `json:"TopicArn"`
+		AlarmName string `json:"AlarmName"`
+		SchemaID  string `json:"schemaId"`
+	}
+
+	if err := json.Unmarshal(payload, &probe); err != nil {
+		return ""
+	}
+
+	switch {
+	case probe.SchemaID == azureMonitorSchemaID:
+		return FormatAzureMonitor
+	case probe.AlarmName != "":
+		return FormatCloudWatchAlarm
+	case probe.Type != "" && probe.TopicArn != "":
+		return FormatSNS
+	default:
+		return ""
+	}
+}
+
+// decodeObject decodes the given payload as a JSON object.
+func decodeObject(payload []byte, v interface{}) error {
+	if !bytes.HasPrefix(payload, []byte("{")) {
+		return fmt.Errorf("%w: payload is not a JSON object", ErrUnrecognized)
+	}
+
+	if err := json.Unmarshal(payload, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrUnrecognized, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,14 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package followup records pending follow-up messages for sends identified by a
+correlation ID. If a send resolving the correlation ID does not occur before
+the follow-up is due, the follow-up message is posted, implementing simple
+escalation for issues which remain unresolved.
+*/
+package followup
@@ -0,0 +1,239 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package followup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// fileExt is the extension of files recording pending follow-ups.
+const fileExt string = ".json"
+
+// claimedExt is the extension applied to a pending follow-up file while it
+// is being taken for delivery so that concurrent invocations do not deliver
+// the same follow-up.
+const claimedExt string = ".claimed"
+
+// claimTimeout is the time after which a claimed follow-up which was not
+// delivered (e.g., because the claiming process was killed) is made pending
+// again.
+const claimTimeout time.Duration = 10 * time.Minute
+
+// titlePrefix is prepended to the title of the original message to form the
+// title of the follow-up message.
+const titlePrefix string = "Follow-up:"
+
+// FollowUp is a pending follow-up message.
+type FollowUp struct {
+
+	// CorrelationID identifies the issue which the original message reported.
+	CorrelationID string `json:"correlation_id"`
+
+	// Due is when the follow-up message is posted if the issue is not
+	// resolved.
+	Due time.Time `json:"due"`
+
+	// ReceiptID is the receipt ID of the original message.
+	ReceiptID string `json:"receipt_id"`
+
+	// Sent is when the original message was sent.
+	Sent time.Time `json:"sent"`
+
+	// Title is the title of the original message.
+	Title string `json:"title,omitempty"`
+
+	// Message is the text of the follow-up message.
+	Message string `json:"message"`
+
+	// Sender is the (optional) application which sent the original message.
+	Sender string `json:"sender,omitempty"`
+}
+
+// TeamsMessage returns the follow-up message.
+func (f FollowUp) TeamsMessage() teams.Message {
+	title := titlePrefix + " " + f.Title
+	if f.Title == "" {
+		title = strings.TrimSuffix(titlePrefix, ":")
+	}
+
+	return teams.Message{
+		Title:  title,
+		Text:   f.Message,
+		Sender: f.Sender,
+		Facts: []teams.Fact{
+			{Title: "Correlation ID", Value: f.CorrelationID},
+			{Title: "Original receipt", Value: f.ReceiptID},
+			{Title: "Originally sent", Value: f.Sent.Format(time.RFC3339)},
+		},
+	}
+}
+
+// Store records pending follow-ups for a single webhook URL within a
+// directory. Multiple processes may safely share a Store directory.
+type Store struct {
+	dir string
+}
+
+// Open returns the Store for the given webhook URL within the given
+// directory. The directory is created once a follow-up is scheduled.
+func Open(dir string, webhookURL string) (*Store, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("follow-up directory not specified")
+	}
+
+	// The webhook URL is a credential and is not recorded as-is.
+	sum := sha256.Sum256([]byte(webhookURL))
+
+	return &Store{dir: filepath.Join(dir, hex.EncodeToString(sum[:8]))}, nil
+}
+
+// Schedule records the given follow-up, replacing any pending follow-up for
+// the same correlation ID.
+func (s *Store) Schedule(f FollowUp) error {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("failed to encode follow-up: %w", err)
+	}
+
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create follow-up directory: %w", err)
+	}
+
+	path := s.path(f.CorrelationID)
+	tmp := path + ".tmp" + strconv.Itoa(os.Getpid())
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to record follow-up: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to record follow-up: %w", err)
+	}
+
+	return nil
+}
+
+// Resolve removes the pending follow-up for the given correlation ID,
+// indicating whether one was pending.
+func (s *Store) Resolve(correlationID string) (bool, error) {
+	err := os.Remove(s.path(correlationID))
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, fs.ErrNotExist):
+		return false, nil
+	default:
+		return false, fmt.Errorf("failed to resolve follow-up: %w", err)
+	}
+}
+
+// TakeDue removes and returns the follow-ups due at the given time, oldest
+// first. Follow-ups which cannot be delivered should be returned to the
+// Store using Schedule.
+func (s *Store) TakeDue(now time.Time) ([]FollowUp, error) {
+	s.releaseStale(now)
+
+	files, err := os.ReadDir(s.dir)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to read follow-ups: %w", err)
+	}
+
+	var due []FollowUp
+	var errs []string
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), fileExt) {
+			continue
+		}
+
+		path := filepath.Join(s.dir, file.Name())
+		f, err := readFollowUp(path)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+
+		if f.Due.After(now) {
+			continue
+		}
+
+		// Only the process which successfully claims a follow-up delivers it.
+		claimed := path + claimedExt
+		if err := os.Rename(path, claimed); err != nil {
+			continue
+		}
+
+		// The follow-up may have been rescheduled before it was claimed.
+		if f, err = readFollowUp(claimed); err != nil || f.Due.After(now) {
+			_ = os.Rename(claimed, path)
+			continue
+		}
+
+		_ = os.Remove(claimed)
+		due = append(due, f)
+	}
+
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].Due.Before(due[j].Due)
+	})
+
+	if len(errs) > 0 {
+		return due, fmt.Errorf("failed to read follow-ups: %s", strings.Join(errs, "; "))
+	}
+
+	return due, nil
+}
+
+// releaseStale makes follow-ups claimed by a process which did not complete
+// delivery pending again.
+func (s *Store) releaseStale(now time.Time) {
+	claimed, _ := filepath.Glob(filepath.Join(s.dir, "*"+fileExt+claimedExt))
+	for _, path := range claimed {
+		info, err := os.Stat(path)
+		if err == nil && now.Sub(info.ModTime()) > claimTimeout {
+			_ = os.Rename(path, strings.TrimSuffix(path, claimedExt))
+		}
+	}
+}
+
+// path returns the path of the file recording the pending follow-up for the
+// given correlation ID.
+func (s *Store) path(correlationID string) string {
+	sum := sha256.Sum256([]byte(correlationID))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:16])+fileExt)
+}
+
+// readFollowUp reads the follow-up recorded in the given file.
+func readFollowUp(path string) (FollowUp, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return FollowUp{}, err
+	}
+
+	var f FollowUp
+	if err := json.Unmarshal(data, &f); err != nil {
+		return FollowUp{}, fmt.Errorf("failed to decode follow-up %s: %w", filepath.Base(path), err)
+	}
+
+	return f, nil
+}
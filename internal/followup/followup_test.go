@@ -0,0 +1,69 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package followup
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+
+	s, err := Open(dir, "https://example.webhook.office.com/webhookb2/a")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	for _, f := range []FollowUp{
+		{CorrelationID: "disk-web01", Due: now.Add(-time.Minute), Title: "Disk full", Message: "still unresolved"},
+		{CorrelationID: "disk-web02", Due: now.Add(-2 * time.Minute), Message: "still unresolved"},
+		{CorrelationID: "disk-web03", Due: now.Add(time.Hour), Message: "still unresolved"},
+		{CorrelationID: "disk-web04", Due: now.Add(-time.Minute), Message: "still unresolved"},
+	} {
+		if err := s.Schedule(f); err != nil {
+			t.Fatalf("Schedule() error = %v", err)
+		}
+	}
+
+	if resolved, err := s.Resolve("disk-web04"); err != nil || !resolved {
+		t.Fatalf("Resolve() = %t, %v, want true", resolved, err)
+	}
+
+	if resolved, err := s.Resolve("unknown"); err != nil || resolved {
+		t.Fatalf("Resolve() = %t, %v, want false", resolved, err)
+	}
+
+	// Follow-ups are scoped to the webhook URL.
+	other, err := Open(dir, "https://example.webhook.office.com/webhookb2/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if due, err := other.TakeDue(now); err != nil || len(due) != 0 {
+		t.Fatalf("TakeDue() for other webhook = %v, %v, want none", due, err)
+	}
+
+	due, err := s.TakeDue(now)
+	if err != nil {
+		t.Fatalf("TakeDue() error = %v", err)
+	}
+
+	if len(due) != 2 || due[0].CorrelationID != "disk-web02" || due[1].CorrelationID != "disk-web01" {
+		t.Fatalf("TakeDue() = %+v, want disk-web02 then disk-web01", due)
+	}
+
+	if got := due[1].TeamsMessage().Title; got != "Follow-up: Disk full" {
+		t.Errorf("TeamsMessage().Title = %q", got)
+	}
+
+	// Taken follow-ups are not returned again.
+	if due, err := s.TakeDue(now.Add(2 * time.Hour)); err != nil || len(due) != 1 || due[0].CorrelationID != "disk-web03" {
+		t.Fatalf("TakeDue() = %+v, %v, want disk-web03 only", due, err)
+	}
+}
@@ -0,0 +1,13 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package help renders structured usage information (flags grouped by
+category, subcommands and examples) as terminal help text or as a man page,
+so that both are generated from a single source.
+*/
+package help
@@ -0,0 +1,331 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package help
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// textWidth is the maximum width (in characters) of wrapped help text.
+const textWidth int = 80
+
+// Flag describes a single flag.
+type Flag struct {
+
+	// Name is the name of the flag, without leading dashes.
+	Name string
+
+	// Type is the name of the value type accepted by the flag (e.g., string,
+	// duration). Empty for boolean flags.
+	Type string
+
+	// Default is the default value of the flag. Empty if the default is the
+	// zero value for the type.
+	Default string
+
+	// Usage describes the purpose of the flag.
+	Usage string
+}
+
+// Group is a category of related flags.
+type Group struct {
+
+	// Name is the name of the category (e.g., Webhook).
+	Name string
+
+	// Description is an (optional) overview of the category, shown in long
+	// help and man pages only.
+	Description string
+
+	// Flags are the flags within the category, in display order.
+	Flags []Flag
+}
+
+// Example is an example invocation.
+type Example struct {
+
+	// Description explains what the example does.
+	Description string
+
+	// Command is the example command line.
+	Command string
+}
+
+// Command is a subcommand summary.
+type Command struct {
+	Name    string
+	Summary string
+}
+
+// Page is the usage information for an application or one of its
+// subcommands.
+type Page struct {
+
+	// Name is the name of the command (e.g., send2teams or send2teams serve).
+	Name string
+
+	// Version is the version of the application.
+	Version string
+
+	// URL is the location of the project home.
+	URL string
+
+	// Summary is a short (single line) description of the command.
+	Summary string
+
+	// Description is an (optional) detailed description of the command,
+	// shown in long help and man pages only. Paragraphs are separated by
+	// blank lines.
+	Description string
+
+	// Synopsis lists the supported forms of the command line.
+	Synopsis []string
+
+	// Commands are the subcommands of the command.
+	Commands []Command
+
+	// Groups are the categories of flags supported by the command.
+	Groups []Group
+
+	// Examples are example invocations, shown in long help and man pages
+	// only.
+	Examples []Example
+
+	// Footer is an (optional) closing note shown in short terminal help
+	// only.
+	Footer string
+}
+
+// WriteText writes the usage information to the given writer as terminal
+// help text. Group descriptions, the detailed description and examples are
+// only included if long help is requested.
+func (p Page) WriteText(w io.Writer, long bool) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s - %s\n", p.Name, p.Summary)
+
+	if long && p.Description != "" {
+		b.WriteString("\n")
+		for _, paragraph := range paragraphs(p.Description) {
+			writeWrapped(&b, paragraph, "  ")
+			b.WriteString("\n")
+		}
+	} else {
+		b.WriteString("\n")
+	}
+
+	b.WriteString("Usage:\n")
+	for _, line := range p.Synopsis {
+		fmt.Fprintf(&b, "  %s\n", line)
+	}
+
+	if len(p.Commands) > 0 {
+		b.WriteString("\nSubcommands:\n")
+
+		width := 0
+		for _, cmd := range p.Commands {
+			if len(cmd.Name) > width {
+				width = len(cmd.Name)
+			}
+		}
+
+		for _, cmd := range p.Commands {
+			fmt.Fprintf(&b, "  %-*s  %s\n", width, cmd.Name, cmd.Summary)
+		}
+	}
+
+	for _, group := range p.Groups {
+		if len(group.Flags) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "\n%s:\n", group.Name)
+
+		if long && group.Description != "" {
+			writeWrapped(&b, group.Description, "  ")
+			b.WriteString("\n")
+		}
+
+		for _, f := range group.Flags {
+			fmt.Fprintf(&b, "  -%s", f.Name)
+			if f.Type != "" {
+				fmt.Fprintf(&b, " %s", f.Type)
+			}
+			b.WriteString("\n")
+
+			writeWrapped(&b, f.usage(), "        ")
+		}
+	}
+
+	if long && len(p.Examples) > 0 {
+		b.WriteString("\nExamples:\n")
+		for i, example := range p.Examples {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			writeWrapped(&b, example.Description, "  ")
+			fmt.Fprintf(&b, "    %s\n", example.Command)
+		}
+	}
+
+	if !long && p.Footer != "" {
+		b.WriteString("\n")
+		writeWrapped(&b, p.Footer, "")
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+// WriteMan writes the usage information to the given writer as a section 1
+// man page dated with the given date.
+func (p Page) WriteMan(w io.Writer, date time.Time) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, ".TH %s 1 %q %q \"User Commands\"\n",
+		strings.ToUpper(manName(p.Name)),
+		date.Format("2006-01-02"),
+		strings.TrimSpace(p.Name+" "+p.Version),
+	)
+
+	fmt.Fprintf(&b, ".SH NAME\n%s \\- %s\n", manName(p.Name), roff(p.Summary))
+
+	b.WriteString(".SH SYNOPSIS\n")
+	for i, line := range p.Synopsis {
+		if i > 0 {
+			b.WriteString(".br\n")
+		}
+		fmt.Fprintf(&b, "%s\n", roff(line))
+	}
+
+	if p.Description != "" {
+		b.WriteString(".SH DESCRIPTION\n")
+		for i, paragraph := range paragraphs(p.Description) {
+			if i > 0 {
+				b.WriteString(".PP\n")
+			}
+			fmt.Fprintf(&b, "%s\n", roff(paragraph))
+		}
+	}
+
+	if len(p.Commands) > 0 {
+		b.WriteString(".SH SUBCOMMANDS\n")
+		for _, cmd := range p.Commands {
+			fmt.Fprintf(&b, ".TP\n.B %s\n%s\n", roff(cmd.Name), roff(cmd.Summary))
+		}
+	}
+
+	if len(p.Groups) > 0 {
+		b.WriteString(".SH OPTIONS\n")
+	}
+
+	for _, group := range p.Groups {
+		if len(group.Flags) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, ".SS %s\n", roff(group.Name))
+		if group.Description != "" {
+			fmt.Fprintf(&b, "%s\n", roff(group.Description))
+		}
+
+		for _, f := range group.Flags {
+			fmt.Fprintf(&b, ".TP\n\\fB\\-%s\\fR", roff(f.Name))
+			if f.Type != "" {
+				fmt.Fprintf(&b, " \\fI%s\\fR", roff(f.Type))
+			}
+			fmt.Fprintf(&b, "\n%s\n", roff(f.usage()))
+		}
+	}
+
+	if len(p.Examples) > 0 {
+		b.WriteString(".SH EXAMPLES\n")
+		for i, example := range p.Examples {
+			if i > 0 {
+				b.WriteString(".PP\n")
+			}
+			fmt.Fprintf(&b, "%s\n.PP\n.RS\n.nf\n%s\n.fi\n.RE\n", roff(example.Description), roff(example.Command))
+		}
+	}
+
+	if p.URL != "" {
+		fmt.Fprintf(&b, ".SH SEE ALSO\n%s\n", roff(p.URL))
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+// usage returns the usage text for the flag, noting the default value.
+func (f Flag) usage() string {
+	if f.Default == "" {
+		return f.Usage
+	}
+
+	return fmt.Sprintf("%s (default %s)", f.Usage, f.Default)
+}
+
+// manName returns the man page name for the given command name (e.g.,
+// send2teams-serve for send2teams serve).
+func manName(name string) string {
+	return strings.Join(strings.Fields(name), "-")
+}
+
+// paragraphs splits the given text into paragraphs separated by blank lines,
+// joining the lines of each paragraph.
+func paragraphs(text string) []string {
+	var result []string
+	for _, block := range strings.Split(text, "\n\n") {
+		if block = strings.Join(strings.Fields(block), " "); block != "" {
+			result = append(result, block)
+		}
+	}
+
+	return result
+}
+
+// writeWrapped writes the given text word wrapped to the text width, with
+// each line prefixed by the given indent.
+func writeWrapped(b *strings.Builder, text string, indent string) {
+	line := indent
+	for _, word := range strings.Fields(text) {
+		if line != indent && len(line)+1+len(word) > textWidth {
+			b.WriteString(line + "\n")
+			line = indent
+		}
+
+		if line != indent {
+			line += " "
+		}
+		line += word
+	}
+
+	if line != indent {
+		b.WriteString(line + "\n")
+	}
+}
+
+// roff escapes the given text for use in a man page.
+func roff(text string) string {
+	text = strings.ReplaceAll(text, `\`, `\e`)
+	text = strings.ReplaceAll(text, "-", `\-`)
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,163 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package help
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func testPage() Page {
+	return Page{
+		Name:        "send2teams",
+		Version:     "v1.2.3",
+		Summary:     "Send messages to Microsoft Teams",
+		Description: "First paragraph.\n\nSecond paragraph.",
+		Synopsis:    []string{"send2teams [flags]"},
+		Commands:    []Command{{Name: "serve", Summary: "Relay messages"}},
+		Groups: []Group{
+			{
+				Name:        "Webhook",
+				Description: "Where messages are sent.",
+				Flags: []Flag{
+					{Name: "url", Type: "string", Usage: "The webhook URL."},
+					{Name: "retries", Type: "int", Default: "2", Usage: "The number of attempts."},
+				},
+			},
+			{Name: "Empty"},
+		},
+		Examples: []Example{
+			{Description: "Send a message.", Command: "send2teams -url URL -message .hello"},
+		},
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	var short, long strings.Builder
+	if err := testPage().WriteText(&short, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := testPage().WriteText(&long, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, want := range []string{"Webhook:\n", "  -url string\n", "(default 2)", "  serve  Relay messages\n"} {
+		if !strings.Contains(short.String(), want) {
+			t.Errorf("short help missing %q:\n%s", want, short.String())
+		}
+	}
+
+	for _, unwanted := range []string{"Empty:", "Examples:", "Where messages are sent.", "Second paragraph."} {
+		if strings.Contains(short.String(), unwanted) {
+			t.Errorf("short help unexpectedly contains %q", unwanted)
+		}
+	}
+
+	for _, want := range []string{"Examples:\n", "Where messages are sent.", "Second paragraph."} {
+		if !strings.Contains(long.String(), want) {
+			t.Errorf("long help missing %q:\n%s", want, long.String())
+		}
+	}
+}
+
+func TestWriteMan(t *testing.T) {
+	var b strings.Builder
+	date := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	if err := testPage().WriteMan(&b, date); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	man := b.String()
+	for _, want := range []string{
+		".TH SEND2TEAMS 1 \"2024-01-02\" \"send2teams v1.2.3\"",
+		".SH NAME\nsend2teams \\- Send messages",
+		".SS Webhook\n",
+		"\\fB\\-url\\fR \\fIstring\\fR\n",
+		"send2teams \\-url URL \\-message .hello\n",
+	} {
+		if !strings.Contains(man, want) {
+			t.Errorf("man page missing %q:\n%s", want, man)
+		}
+	}
+
+	if strings.Contains(man, ".SS Empty") {
+		t.Errorf("man page unexpectedly contains empty group")
+	}
+}
+
+func TestWriteWrapped(t *testing.T) {
+	var b strings.Builder
+	writeWrapped(&b, strings.Repeat("word ", 40), "    ")
+
+	for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n") {
+		if len(line) > textWidth {
+			t.Errorf("line exceeds %d characters: %q", textWidth, line)
+		}
+		if !strings.HasPrefix(line, "    word") {
+			t.Errorf("line not indented: %q", line)
+		}
+	}
+}
+
+func TestWritePowerShell(t *testing.T) {
+	page := testPage()
+	page.Groups = append(page.Groups, Group{
+		Name: "Other",
+		Flags: []Flag{
+			{Name: "target-url", Type: "value", Usage: "A button #> URL."},
+			{Name: "verbose", Usage: "Whether detailed output is shown."},
+			{Name: "silent", Usage: "Whether output is suppressed."},
+		},
+	})
+
+	var b strings.Builder
+	if err := page.WritePowerShell(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	module := b.String()
+
+	for _, want := range []string{
+		"function Send-TeamsMessage {",
+		"    Url = @('url', 'value')\n",
+		"    Retries = @('retries', 'value')\n",
+		"    TargetUrl = @('target-url', 'list')\n",
+		"    Silent = @('silent', 'switch')\n",
+		"    Verbose = @('verbose', 'common')\n",
+		"        [int] $Retries,\n",
+		"        [string[]] $TargetUrl,\n",
+		"        [switch] $Silent,\n",
+		"    The number of attempts. (default 2)\n",
+		"Export-ModuleMember -Function Send-TeamsMessage\n",
+	} {
+		if !strings.Contains(module, want) {
+			t.Errorf("module missing %q", want)
+		}
+	}
+
+	// Common parameters are not declared and comments are not terminated
+	// early by usage text.
+	if strings.Contains(module, "$Verbose,") {
+		t.Error("module declares the Verbose common parameter")
+	}
+	if strings.Count(module, "#>") != 1 {
+		t.Errorf("expected a single comment terminator, got %d", strings.Count(module, "#>"))
+	}
+}
+
+func TestParameterName(t *testing.T) {
+	for flagName, want := range map[string]string{
+		"url":                         "Url",
+		"retries-delay":               "RetriesDelay",
+		"verify-workflow-run-timeout": "VerifyWorkflowRunTimeout",
+	} {
+		if got := ParameterName(flagName); got != want {
+			t.Errorf("ParameterName(%q) = %q, want %q", flagName, got, want)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package help
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// PowerShellFunction is the name of the function exported by the generated
+// PowerShell module.
+const PowerShellFunction string = "Send-TeamsMessage"
+
+// powerShellCommonParameters are the parameters supported by every advanced
+// PowerShell function. Flags with the same name are set from the common
+// parameter instead of being declared.
+var powerShellCommonParameters = map[string]struct{}{
+	"Verbose": {}, "Debug": {}, "ErrorAction": {}, "WarningAction": {},
+	"InformationAction": {}, "ErrorVariable": {}, "WarningVariable": {},
+	"InformationVariable": {}, "OutVariable": {}, "OutBuffer": {},
+	"PipelineVariable": {}, "WhatIf": {}, "Confirm": {},
+}
+
+// Kinds of PowerShell parameters, which determine how the flag arguments are
+// generated.
+const (
+	psKindSwitch string = "switch"
+	psKindList   string = "list"
+	psKindValue  string = "value"
+	psKindCommon string = "common"
+)
+
+// powerShellParam is a PowerShell parameter mapped to a flag.
+type powerShellParam struct {
+	name string
+	kind string
+	flag Flag
+}
+
+// ParameterName returns the PowerShell parameter name for the given flag
+// name (e.g., RetriesDelay for retries-delay).
+func ParameterName(flagName string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(flagName, func(r rune) bool { return r == '-' || r == '_' }) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+
+	return b.String()
+}
+
+// powerShellParams returns the PowerShell parameters for the flags of the
+// page, in display order.
+func (p Page) powerShellParams() []powerShellParam {
+	var params []powerShellParam
+	for _, group := range p.Groups {
+		for _, f := range group.Flags {
+			param := powerShellParam{name: ParameterName(f.Name), flag: f}
+
+			switch {
+			case isCommonParameter(param.name):
+				param.kind = psKindCommon
+			case f.Type == "":
+				param.kind = psKindSwitch
+			case f.Type == "value":
+				// Flags implementing flag.Value may be repeated.
+				param.kind = psKindList
+			default:
+				param.kind = psKindValue
+			}
+
+			params = append(params, param)
+		}
+	}
+
+	return params
+}
+
+// isCommonParameter indicates whether the given parameter name is a common
+// parameter of advanced PowerShell functions.
+func isCommonParameter(name string) bool {
+	_, ok := powerShellCommonParameters[name]
+	return ok
+}
+
+// psType returns the PowerShell type declared for the parameter.
+func (param powerShellParam) psType() string {
+	switch {
+	case param.kind == psKindSwitch:
+		return "[switch]"
+	case param.kind == psKindList:
+		return "[string[]]"
+	case param.flag.Type == "int", param.flag.Type == "uint":
+		return "[int]"
+	case param.flag.Type == "float":
+		return "[double]"
+	default:
+		return "[string]"
+	}
+}
+
+// psQuote returns the given text as a single-quoted PowerShell string.
+func psQuote(text string) string {
+	return "'" + strings.ReplaceAll(text, "'", "''") + "'"
+}
+
+// psComment returns the given text made safe for use within a PowerShell
+// block comment.
+func psComment(text string) string {
+	return strings.ReplaceAll(text, "#>", "# >")
+}
+
+// WritePowerShell writes a PowerShell module to the given writer which
+// exports the Send-TeamsMessage function. The function maps a parameter to
+// each flag of the page and runs the application binary, accepting message
+// content from the pipeline.
+func (p Page) WritePowerShell(w io.Writer) error {
+	params := p.powerShellParams()
+	binary := strings.Fields(p.Name)[0]
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Code generated by %q; DO NOT EDIT.\n", binary+" -help-powershell")
+	fmt.Fprintf(&b, "#\n# %s module for %s %s\n", PowerShellFunction, binary, p.Version)
+	if p.URL != "" {
+		fmt.Fprintf(&b, "# %s\n", p.URL)
+	}
+	b.WriteString("\nSet-StrictMode -Version 2.0\n\n")
+
+	// Map each parameter to its flag name and kind.
+	b.WriteString("$script:Send2TeamsFlags = [ordered]@{\n")
+	for _, param := range params {
+		fmt.Fprintf(&b, "    %s = @(%s, %s)\n", param.name, psQuote(param.flag.Name), psQuote(param.kind))
+	}
+	b.WriteString("}\n\n")
+
+	fmt.Fprintf(&b, "function %s {\n    <#\n    .SYNOPSIS\n", PowerShellFunction)
+	writeWrapped(&b, psComment(p.Summary), "    ")
+
+	b.WriteString("\n    .DESCRIPTION\n")
+	for _, paragraph := range paragraphs(p.Description) {
+		writeWrapped(&b, psComment(paragraph), "    ")
+		b.WriteString("\n")
+	}
+	writeWrapped(&b, fmt.Sprintf(
+		"Strings piped to %s are joined (one per line) and sent as a single message. "+
+			"Objects with properties matching parameter names (e.g., Title and Message) each send a message. "+
+			"A non-terminating error is written if %s exits with a non-zero exit code.",
+		PowerShellFunction, binary,
+	), "    ")
+
+	b.WriteString("\n    .PARAMETER InputObject\n")
+	writeWrapped(&b, "The message content (strings) or objects with properties matching parameter names, accepted from the pipeline.", "    ")
+
+	for _, param := range params {
+		if param.kind == psKindCommon {
+			continue
+		}
+		fmt.Fprintf(&b, "\n    .PARAMETER %s\n", param.name)
+		writeWrapped(&b, psComment(param.flag.usage()), "    ")
+	}
+
+	fmt.Fprintf(&b, "\n    .PARAMETER Send2TeamsPath\n")
+	writeWrapped(&b, fmt.Sprintf(
+		"The path to the %s binary. If not specified, the SEND2TEAMS_PATH environment variable is used, then %s is located using the PATH.",
+		binary, binary,
+	), "    ")
+
+	fmt.Fprintf(&b, `
+    .EXAMPLE
+    %[1]s -Url $env:WEBHOOK_URL -Title 'Backup complete' -Message 'The nightly backup finished.'
+
+    .EXAMPLE
+    Get-Content .\report.txt | %[1]s -Url $env:WEBHOOK_URL -Title 'Daily report'
+
+    .EXAMPLE
+    Import-Csv .\alerts.csv | %[1]s -Url $env:WEBHOOK_URL -Json
+`, PowerShellFunction)
+
+	if p.URL != "" {
+		fmt.Fprintf(&b, "\n    .LINK\n    %s\n", p.URL)
+	}
+	b.WriteString("    #>\n")
+
+	b.WriteString("    [CmdletBinding()]\n    param(\n")
+	b.WriteString("        [Parameter(ValueFromPipeline = $true)]\n        [object] $InputObject,\n\n")
+	for _, param := range params {
+		if param.kind == psKindCommon {
+			continue
+		}
+		fmt.Fprintf(&b, "        [Parameter(ValueFromPipelineByPropertyName = $true)]\n        %s $%s,\n\n", param.psType(), param.name)
+	}
+	b.WriteString("        [string] $Send2TeamsPath\n    )\n")
+
+	fmt.Fprintf(&b, `
+    begin {
+        $lines = [System.Collections.Generic.List[string]]::new()
+
+        $binary = $Send2TeamsPath
+        if (-not $binary) { $binary = $env:SEND2TEAMS_PATH }
+        if (-not $binary) { $binary = %[1]s }
+
+        function Invoke-Send2Teams($Parameters) {
+            $arguments = [System.Collections.Generic.List[string]]::new()
+            foreach ($name in $script:Send2TeamsFlags.Keys) {
+                if (-not $Parameters.ContainsKey($name)) { continue }
+                $flag, $kind = $script:Send2TeamsFlags[$name]
+                $value = $Parameters[$name]
+                switch ($kind) {
+                    { $_ -in 'switch', 'common' } { $arguments.Add("-$flag=$(([bool]$value).ToString().ToLowerInvariant())") }
+                    'list' { foreach ($item in $value) { $arguments.Add("-$flag=$item") } }
+                    default { $arguments.Add("-$flag=$value") }
+                }
+            }
+
+            $output = & $binary @arguments
+            $exitCode = $LASTEXITCODE
+
+            # Each JSON result is written on a single line.
+            if ($Parameters.ContainsKey('Json') -and $Parameters['Json']) {
+                $output | Where-Object { $_ } | ConvertFrom-Json
+            } else {
+                $output
+            }
+
+            if ($exitCode -ne 0) {
+                Write-Error "%[2]s exited with code $exitCode"
+            }
+        }
+    }
+
+    process {
+        if ($null -eq $InputObject) { return }
+
+        if ($InputObject -is [string]) {
+            $lines.Add($InputObject)
+            return
+        }
+
+        Invoke-Send2Teams $PSBoundParameters
+    }
+
+    end {
+        if ($lines.Count -gt 0) {
+            $parameters = @{}
+            foreach ($key in $PSBoundParameters.Keys) { $parameters[$key] = $PSBoundParameters[$key] }
+            $parameters['Message'] = $lines -join [char]10
+            Invoke-Send2Teams $parameters
+        } elseif (-not $MyInvocation.ExpectingInput) {
+            Invoke-Send2Teams $PSBoundParameters
+        }
+    }
+}
+
+Export-ModuleMember -Function %[3]s
+`, psQuote(binary), binary, PowerShellFunction)
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
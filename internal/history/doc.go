@@ -0,0 +1,19 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package history records the outcome of each message sent by this
+application in a small local store so that past sends may be queried (e.g.,
+to confirm that a backup alert was actually sent last night) without
+searching the Microsoft Teams channel.
+
+The store is a directory of newline delimited JSON files, one for each UTC
+day. The file names act as an index by time: queries only read the files
+for the days within the requested period, and files for days older than the
+retention period are removed as new records are appended.
+*/
+package history
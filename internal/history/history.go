@@ -0,0 +1,225 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// dayLayout is the layout of the date used as the name of the file holding
+// the records for a single day.
+const dayLayout string = "2006-01-02"
+
+// fileExtension is the extension of the files holding history records.
+const fileExtension string = ".jsonl"
+
+// maxRecordSize is the maximum size of a single history record.
+const maxRecordSize int = 1024 * 1024
+
+// Record is the outcome of a single message sent by this application.
+type Record struct {
+	Time      time.Time `json:"time"`
+	ReceiptID string    `json:"receipt_id"`
+	Title     string    `json:"title,omitempty"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Target    string    `json:"target,omitempty"`
+	Team      string    `json:"team,omitempty"`
+	Channel   string    `json:"channel,omitempty"`
+}
+
+// Failed indicates whether the message could not be sent.
+func (r Record) Failed() bool {
+	return r.Error != ""
+}
+
+// Query selects the records returned by Search.
+type Query struct {
+
+	// Since is the time from which records are returned.
+	Since time.Time
+
+	// Target is the (optional) name of the target (or channel) whose
+	// records are returned. Names are compared case-insensitively.
+	Target string
+
+	// FailedOnly indicates whether only the records of messages which could
+	// not be sent are returned.
+	FailedOnly bool
+}
+
+// matches indicates whether the given record is selected by the query.
+func (q Query) matches(rec Record) bool {
+	switch {
+	case rec.Time.Before(q.Since):
+		return false
+	case q.FailedOnly && !rec.Failed():
+		return false
+	case q.Target != "":
+		return strings.EqualFold(rec.Target, q.Target) || strings.EqualFold(rec.Channel, q.Target)
+	}
+
+	return true
+}
+
+// dayFile returns the path to the file holding the records for the day of
+// the given time within the given directory.
+func dayFile(dir string, t time.Time) string {
+	return filepath.Join(dir, t.UTC().Format(dayLayout)+fileExtension)
+}
+
+// fileDay returns the day of the records held by the file with the given
+// name. False is returned for files which do not hold history records.
+func fileDay(name string) (time.Time, bool) {
+	if !strings.HasSuffix(name, fileExtension) {
+		return time.Time{}, false
+	}
+
+	day, err := time.Parse(dayLayout, strings.TrimSuffix(name, fileExtension))
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return day, true
+}
+
+// Append adds the given record to the history within the given directory,
+// creating the directory as needed.
+func Append(dir string, rec Record) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode history record: %w", err)
+	}
+
+	// Each record is written with a single append so that records from
+	// concurrent invocations are not interleaved.
+	file := dayFile(dir, rec.Time)
+	f, err := os.OpenFile(filepath.Clean(file), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to record send in history: %w", err)
+	}
+
+	return f.Close()
+}
+
+// Prune removes the records for the days before the given time from the
+// history within the given directory.
+func Prune(dir string, before time.Time) error {
+	entries, err := os.ReadDir(dir)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to read history directory: %w", err)
+	}
+
+	cutoff := before.UTC().Truncate(24 * time.Hour)
+
+	var errs []error
+	for _, entry := range entries {
+		day, ok := fileDay(entry.Name())
+		if !ok || entry.IsDir() || !day.Before(cutoff) {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to prune history: %w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// Search returns the records within the given directory selected by the
+// given query, ordered by time. Only the files for the days within the
+// requested period are read.
+func Search(dir string, q Query) ([]Record, error) {
+	entries, err := os.ReadDir(dir)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to read history directory: %w", err)
+	}
+
+	first := q.Since.UTC().Truncate(24 * time.Hour)
+
+	var records []Record
+	for _, entry := range entries {
+		day, ok := fileDay(entry.Name())
+		if !ok || entry.IsDir() || day.Before(first) {
+			continue
+		}
+
+		dayRecords, err := readFile(filepath.Join(dir, entry.Name()), q)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, dayRecords...)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Time.Before(records[j].Time)
+	})
+
+	return records, nil
+}
+
+// readFile returns the records of the given history file selected by the
+// given query. Records which cannot be decoded (e.g., a partially written
+// final record) are skipped.
+func readFile(path string, q Query) ([]Record, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRecordSize)
+
+	for scanner.Scan() {
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+
+		if q.matches(rec) {
+			records = append(records, rec)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history file %s: %w", path, err)
+	}
+
+	return records, nil
+}
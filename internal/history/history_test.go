@@ -0,0 +1,102 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSearch(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	records := []Record{
+		{Time: now.Add(-72 * time.Hour), ReceiptID: "old", Status: "sent", Target: "ops"},
+		{Time: now.Add(-20 * time.Hour), ReceiptID: "backup", Title: "Backup", Status: "sent", Target: "ops"},
+		{Time: now.Add(-2 * time.Hour), ReceiptID: "failed", Status: "failed", Error: "timeout", Target: "ops"},
+		{Time: now.Add(-1 * time.Hour), ReceiptID: "dev", Status: "sent", Channel: "Dev"},
+	}
+
+	for _, rec := range records {
+		if err := Append(dir, rec); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	// A partially written record is skipped.
+	f, err := os.OpenFile(dayFile(dir, now), os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(`{"time": "2024-03-`)
+	_ = f.Close()
+
+	tests := map[string]struct {
+		query Query
+		want  []string
+	}{
+		"since":       {query: Query{Since: now.Add(-24 * time.Hour)}, want: []string{"backup", "failed", "dev"}},
+		"target":      {query: Query{Since: now.Add(-24 * time.Hour), Target: "OPS"}, want: []string{"backup", "failed"}},
+		"channel":     {query: Query{Since: now.Add(-24 * time.Hour), Target: "dev"}, want: []string{"dev"}},
+		"failed only": {query: Query{Since: now.Add(-96 * time.Hour), FailedOnly: true}, want: []string{"failed"}},
+		"all":         {query: Query{}, want: []string{"old", "backup", "failed", "dev"}},
+	}
+
+	for name, tt := range tests {
+		got, err := Search(dir, tt.query)
+		if err != nil {
+			t.Fatalf("%s: Search() error = %v", name, err)
+		}
+
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %d records (%+v); want %v", name, len(got), got, tt.want)
+			continue
+		}
+
+		for i := range got {
+			if got[i].ReceiptID != tt.want[i] {
+				t.Errorf("%s: record %d is %q; want %q", name, i, got[i].ReceiptID, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	for _, age := range []time.Duration{0, 24 * time.Hour, 48 * time.Hour} {
+		if err := Append(dir, Record{Time: now.Add(-age), Status: "sent"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	other := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(other, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Prune(dir, now.Add(-30*time.Hour)); err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+
+	got, err := Search(dir, Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Errorf("got %d records after pruning; want 2", len(got))
+	}
+
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("unrelated file removed: %v", err)
+	}
+}
@@ -0,0 +1,14 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package idempotency records the messages sent for idempotency keys so that a
+message identified by a key already sent (e.g., by an earlier run of an
+infrastructure pipeline) is not sent again, and the outcome of the original
+send can be reported instead.
+*/
+package idempotency
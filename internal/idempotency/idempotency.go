@@ -0,0 +1,164 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// fileExt is the extension of files recording sent messages.
+const fileExt string = ".json"
+
+// lockExt is the extension of files held while a message is being sent so
+// that concurrent invocations do not send the same message.
+const lockExt string = ".lock"
+
+// lockTimeout is the time after which a lock which was not released (e.g.,
+// because the sending process was killed) is ignored.
+const lockTimeout time.Duration = 10 * time.Minute
+
+// ErrInProgress indicates that a message with the same idempotency key is
+// being sent by another invocation.
+var ErrInProgress = errors.New("message with the same idempotency key is being sent by another invocation")
+
+// Record describes a message sent for an idempotency key.
+type Record struct {
+
+	// Key is the idempotency key.
+	Key string `json:"key"`
+
+	// ReceiptID is the receipt ID of the message.
+	ReceiptID string `json:"receipt_id"`
+
+	// Time is when the message was sent.
+	Time time.Time `json:"time"`
+
+	// Title is the title of the message.
+	Title string `json:"title,omitempty"`
+
+	// Team and Channel are the (optional) names of the team and channel the
+	// message was sent to.
+	Team    string `json:"team,omitempty"`
+	Channel string `json:"channel,omitempty"`
+}
+
+// Store records the messages sent to a single webhook URL within a
+// directory. Multiple processes may safely share a Store directory.
+type Store struct {
+	dir string
+}
+
+// Open returns the Store for the given webhook URL within the given
+// directory. The directory is created once a message is recorded.
+func Open(dir string, webhookURL string) (*Store, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("idempotency directory not specified")
+	}
+
+	// The webhook URL is a credential and is not recorded as-is.
+	sum := sha256.Sum256([]byte(webhookURL))
+
+	return &Store{dir: filepath.Join(dir, hex.EncodeToString(sum[:8]))}, nil
+}
+
+// Lookup returns the record of the message sent for the given idempotency
+// key, indicating whether one was sent.
+func (s *Store) Lookup(key string) (Record, bool, error) {
+	data, err := os.ReadFile(s.path(key) + fileExt)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return Record{}, false, nil
+	case err != nil:
+		return Record{}, false, fmt.Errorf("failed to read idempotency record: %w", err)
+	}
+
+	var rec Record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return Record{}, false, fmt.Errorf("failed to decode idempotency record: %w", err)
+	}
+
+	return rec, true, nil
+}
+
+// Lock reserves the given idempotency key while a message is sent,
+// returning a function which releases the reservation (and may safely be
+// called more than once). ErrInProgress is returned if the key is reserved by another invocation.
+func (s *Store) Lock(key string) (func(), error) {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create idempotency directory: %w", err)
+	}
+
+	path := s.path(key) + lockExt
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			_ = f.Close()
+
+			var once sync.Once
+			return func() { once.Do(func() { _ = os.Remove(path) }) }, nil
+		}
+
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("failed to lock idempotency key: %w", err)
+		}
+
+		// Locks left behind by a process which did not complete sending are
+		// removed.
+		info, statErr := os.Stat(path)
+		if statErr != nil || time.Since(info.ModTime()) <= lockTimeout {
+			break
+		}
+		_ = os.Remove(path)
+	}
+
+	return nil, ErrInProgress
+}
+
+// Save records the given message. The record for the same idempotency key
+// (if any) is replaced.
+func (s *Store) Save(rec Record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode idempotency record: %w", err)
+	}
+
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create idempotency directory: %w", err)
+	}
+
+	path := s.path(rec.Key) + fileExt
+	tmp := path + ".tmp" + strconv.Itoa(os.Getpid())
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to save idempotency record: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to save idempotency record: %w", err)
+	}
+
+	return nil
+}
+
+// path returns the path (without extension) of the files used for the given
+// idempotency key.
+func (s *Store) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:16]))
+}
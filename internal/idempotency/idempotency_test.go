@@ -0,0 +1,55 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package idempotency
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	store, err := Open(t.TempDir(), "https://example.com/webhook")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, found, err := store.Lookup("apply-1"); err != nil || found {
+		t.Fatalf("unexpected record for unused key (found %t, error %v)", found, err)
+	}
+
+	release, err := store.Lock("apply-1")
+	if err != nil {
+		t.Fatalf("failed to lock key: %v", err)
+	}
+
+	if _, err := store.Lock("apply-1"); !errors.Is(err, ErrInProgress) {
+		t.Errorf("got error %v locking a locked key, want %v", err, ErrInProgress)
+	}
+
+	want := Record{Key: "apply-1", ReceiptID: "abc", Time: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), Title: "Apply"}
+	if err := store.Save(want); err != nil {
+		t.Fatalf("failed to save record: %v", err)
+	}
+	release()
+
+	got, found, err := store.Lookup("apply-1")
+	if err != nil || !found {
+		t.Fatalf("record not found (error %v)", err)
+	}
+	if got != want {
+		t.Errorf("got record %+v, want %+v", got, want)
+	}
+
+	release, err = store.Lock("apply-1")
+	if err != nil {
+		t.Errorf("failed to lock released key: %v", err)
+	} else {
+		release()
+	}
+}
@@ -0,0 +1,19 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package inlineimage prepares small local images (e.g., charts or QR codes)
+for embedding directly within an Adaptive Card as base64 encoded data URIs,
+for environments without image hosting reachable by Microsoft Teams.
+
+Microsoft Teams rejects messages larger than approximately 28 KB, so the
+size of each image is strictly limited. Images exceeding the limit are
+rejected unless fitting is requested, in which case they are re-encoded
+(as PNG and then as JPEG at decreasing quality) and downscaled until they
+fit.
+*/
+package inlineimage
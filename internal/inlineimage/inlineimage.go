@@ -0,0 +1,255 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package inlineimage
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+
+	// Registers the GIF decoder; the PNG and JPEG decoders are registered
+	// by the encoders used to reduce images.
+	_ "image/gif"
+)
+
+// DefaultMaxBytes is the default maximum size in bytes of an embedded image
+// (before base64 encoding, which increases the size by a third).
+const DefaultMaxBytes int = 12 * 1024
+
+// maxFileSize is the maximum size in bytes of an image file read for
+// embedding, including images which are reduced to fit.
+const maxFileSize int64 = 16 * 1024 * 1024
+
+// maxPixels is the maximum number of pixels of an image decoded to fit the
+// size limit, guarding against images which expand to excessive sizes.
+const maxPixels int = 25 * 1024 * 1024
+
+// minDimension is the smallest width or height to which an image is
+// downscaled to fit the size limit.
+const minDimension int = 16
+
+// scaleStep is the factor by which the dimensions of an image are reduced
+// on each attempt to fit the size limit.
+const scaleStep float64 = 0.75
+
+// jpegQualities are the qualities at which an image is encoded as JPEG on
+// each attempt to fit the size limit, from best to worst.
+var jpegQualities = []int{85, 70, 55, 40}
+
+// Media types of supported images.
+const (
+	MediaTypePNG  string = "image/png"
+	MediaTypeJPEG string = "image/jpeg"
+	MediaTypeGIF  string = "image/gif"
+)
+
+// mediaTypes are the media types of supported images, keyed by the format
+// name reported by the image package.
+var mediaTypes = map[string]string{
+	"png":  MediaTypePNG,
+	"jpeg": MediaTypeJPEG,
+	"gif":  MediaTypeGIF,
+}
+
+// ErrUnsupportedFormat indicates that an image is not a PNG, JPEG or GIF
+// image.
+var ErrUnsupportedFormat = errors.New("unsupported image format; expected PNG, JPEG or GIF")
+
+// ErrTooLarge indicates that an image exceeds the size limit and could not
+// be (or was not requested to be) reduced to fit.
+var ErrTooLarge = errors.New("image exceeds size limit")
+
+// Image is an image prepared for embedding.
+type Image struct {
+
+	// MediaType is the media type of the encoded image (e.g., image/png).
+	MediaType string
+
+	// Data is the encoded image.
+	Data []byte
+
+	// Width and Height are the dimensions of the encoded image in pixels.
+	Width  int
+	Height int
+
+	// Reduced indicates that the image was re-encoded (and possibly
+	// downscaled) to fit the size limit.
+	Reduced bool
+}
+
+// DataURI returns the image as a base64 encoded data URI.
+func (i Image) DataURI() string {
+	return "data:" + i.MediaType + ";base64," + base64.StdEncoding.EncodeToString(i.Data)
+}
+
+// ReadFile reads the image from the given file and prepares it for
+// embedding. See Prepare.
+func ReadFile(path string, maxBytes int, fit bool) (Image, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return Image{}, err
+	}
+	defer func() { _ = f.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxFileSize+1))
+	switch {
+	case err != nil:
+		return Image{}, fmt.Errorf("failed to read %s: %w", path, err)
+	case int64(len(data)) > maxFileSize:
+		return Image{}, fmt.Errorf("%s exceeds %d bytes", path, maxFileSize)
+	}
+
+	img, err := Prepare(data, maxBytes, fit)
+	if err != nil {
+		return Image{}, fmt.Errorf("invalid image %s: %w", path, err)
+	}
+
+	return img, nil
+}
+
+// Prepare prepares the given encoded image for embedding. Images no larger
+// than maxBytes are used as-is. Larger images are rejected with ErrTooLarge
+// unless fit is true, in which case they are re-encoded and downscaled
+// until they fit.
+func Prepare(data []byte, maxBytes int, fit bool) (Image, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, ErrUnsupportedFormat
+	}
+
+	mediaType, ok := mediaTypes[format]
+	if !ok {
+		return Image{}, ErrUnsupportedFormat
+	}
+
+	if len(data) <= maxBytes {
+		return Image{MediaType: mediaType, Data: data, Width: cfg.Width, Height: cfg.Height}, nil
+	}
+
+	if !fit {
+		return Image{}, fmt.Errorf("%w: %d bytes exceeds limit of %d bytes", ErrTooLarge, len(data), maxBytes)
+	}
+
+	if cfg.Width*cfg.Height > maxPixels {
+		return Image{}, fmt.Errorf("%w: %dx%d pixels is too large to reduce", ErrTooLarge, cfg.Width, cfg.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	return reduce(src, maxBytes, len(data))
+}
+
+// reduce re-encodes the given image, downscaling it as needed, until it is
+// no larger than maxBytes. PNG encoding is attempted first at each size as
+// it preserves sharp edges (e.g., of charts and QR codes), then JPEG
+// encoding at decreasing quality.
+func reduce(src image.Image, maxBytes int, originalSize int) (Image, error) {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	for scale := 1.0; ; scale *= scaleStep {
+		w, h := int(float64(width)*scale), int(float64(height)*scale)
+		if w < minDimension || h < minDimension {
+			return Image{}, fmt.Errorf(
+				"%w: %d bytes exceeds limit of %d bytes even when reduced",
+				ErrTooLarge,
+				originalSize,
+				maxBytes,
+			)
+		}
+
+		img := src
+		if scale < 1 {
+			img = downscale(src, w, h)
+		}
+
+		var buf bytes.Buffer
+		encoder := png.Encoder{CompressionLevel: png.BestCompression}
+		if err := encoder.Encode(&buf, img); err != nil {
+			return Image{}, fmt.Errorf("failed to encode image: %w", err)
+		}
+		if buf.Len() <= maxBytes {
+			return Image{MediaType: MediaTypePNG, Data: buf.Bytes(), Width: w, Height: h, Reduced: true}, nil
+		}
+
+		// JPEG offers no transparency, so the image is flattened onto a
+		// white background.
+		flat := flatten(img)
+		for _, quality := range jpegQualities {
+			buf.Reset()
+			if err := jpeg.Encode(&buf, flat, &jpeg.Options{Quality: quality}); err != nil {
+				return Image{}, fmt.Errorf("failed to encode image: %w", err)
+			}
+			if buf.Len() <= maxBytes {
+				return Image{MediaType: MediaTypeJPEG, Data: buf.Bytes(), Width: w, Height: h, Reduced: true}, nil
+			}
+		}
+	}
+}
+
+// downscale returns the given image reduced to the given dimensions. Each
+// pixel is the average of the source pixels it covers.
+func downscale(src image.Image, width int, height int) *image.RGBA64 {
+	bounds := src.Bounds()
+	dst := image.NewRGBA64(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := bounds.Min.Y + (y+1)*bounds.Dy()/height
+
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := bounds.Min.X + (x+1)*bounds.Dx()/width
+
+			// Alpha-premultiplied values are averaged so that transparent
+			// pixels do not darken their neighbors.
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					sr, sg, sb, sa := src.At(sx, sy).RGBA()
+					r += uint64(sr)
+					g += uint64(sg)
+					b += uint64(sb)
+					a += uint64(sa)
+					n++
+				}
+			}
+
+			dst.SetRGBA64(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+
+	return dst
+}
+
+// flatten returns the given image drawn onto a white background.
+func flatten(src image.Image) *image.RGBA {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, bounds, image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, bounds, src, bounds.Min, draw.Over)
+
+	return dst
+}
@@ -0,0 +1,83 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package inlineimage
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// noisyPNG returns a PNG image of the given dimensions which compresses
+// poorly.
+func noisyPNG(t *testing.T, width int, height int) []byte {
+	t.Helper()
+
+	rnd := rand.New(rand.NewSource(1))
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(rnd.Intn(256)), G: uint8(x), B: uint8(y), A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestPrepare(t *testing.T) {
+	small := noisyPNG(t, 8, 8)
+
+	img, err := Prepare(small, DefaultMaxBytes, false)
+	switch {
+	case err != nil:
+		t.Fatal(err)
+	case img.Reduced || !bytes.Equal(img.Data, small) || img.Width != 8:
+		t.Errorf("small image modified: %+v", img)
+	case !strings.HasPrefix(img.DataURI(), "data:image/png;base64,"):
+		t.Errorf("got data URI %.40s", img.DataURI())
+	}
+
+	large := noisyPNG(t, 200, 150)
+	if len(large) <= DefaultMaxBytes {
+		t.Fatalf("test image only %d bytes", len(large))
+	}
+
+	if _, err := Prepare(large, DefaultMaxBytes, false); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("got error %v; want %v", err, ErrTooLarge)
+	}
+
+	img, err = Prepare(large, DefaultMaxBytes, true)
+	switch {
+	case err != nil:
+		t.Fatal(err)
+	case !img.Reduced || len(img.Data) > DefaultMaxBytes:
+		t.Errorf("got %d byte %s image; want no more than %d bytes", len(img.Data), img.MediaType, DefaultMaxBytes)
+	}
+
+	if _, _, err := image.Decode(bytes.NewReader(img.Data)); err != nil {
+		t.Errorf("reduced image invalid: %v", err)
+	}
+
+	if _, err := Prepare(large, 10, true); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("got error %v for impossible limit; want %v", err, ErrTooLarge)
+	}
+
+	if _, err := Prepare([]byte("<svg></svg>"), DefaultMaxBytes, true); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("got error %v; want %v", err, ErrUnsupportedFormat)
+	}
+}
@@ -0,0 +1,12 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package input provides helper functions used to retrieve message content from
+sources other than command-line flag values.
+*/
+package input
@@ -0,0 +1,224 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package input
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// execWaitDelay is the amount of time allowed for output from a command to
+// be collected after the command is terminated (e.g., if a child process
+// holds the output pipe open).
+const execWaitDelay time.Duration = 2 * time.Second
+
+// ErrEmptyCommand indicates that no command was specified.
+var ErrEmptyCommand = errors.New("no command specified")
+
+// ErrUnterminatedQuote indicates that a command string contains an
+// unterminated quoted argument.
+var ErrUnterminatedQuote = errors.New("unterminated quoted argument")
+
+// cappedBuffer is a bytes.Buffer which discards writes beyond a limit while
+// recording that output was discarded.
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// Write implements the io.Writer interface. Writes always report success so
+// that the command is not interrupted when the limit is reached.
+func (cb *cappedBuffer) Write(p []byte) (int, error) {
+	remaining := cb.limit - cb.buf.Len()
+	switch {
+	case remaining <= 0:
+		if len(p) > 0 {
+			cb.truncated = true
+		}
+	case len(p) > remaining:
+		cb.buf.Write(p[:remaining])
+		cb.truncated = true
+	default:
+		cb.buf.Write(p)
+	}
+
+	return len(p), nil
+}
+
+// ExecResult is the captured output of a command.
+type ExecResult struct {
+
+	// Stdout is the (possibly truncated) standard output of the command.
+	Stdout string
+
+	// Stderr is the (possibly truncated) standard error of the command.
+	Stderr string
+
+	// Truncated indicates that standard output beyond the limit was
+	// discarded.
+	Truncated bool
+
+	// StderrTruncated indicates that standard error beyond the limit was
+	// discarded.
+	StderrTruncated bool
+
+	// ExitCode is the exit code of the command, or -1 if the command could
+	// not be started or was terminated.
+	ExitCode int
+
+	// Duration is how long the command ran.
+	Duration time.Duration
+
+	// TimedOut indicates that the command was terminated because it did not
+	// complete within the timeout.
+	TimedOut bool
+}
+
+// Exec runs the given command string without invoking a shell, returning its
+// captured output. Arguments are split on whitespace; single or double
+// quotes may be used to group arguments containing whitespace. The command
+// is terminated if it does not complete within the given timeout and at most
+// maxBytes of each output stream is retained. An error is returned if the
+// command could not be started, did not complete in time or exited with a
+// non-zero status.
+func Exec(ctx context.Context, command string, timeout time.Duration, maxBytes int) (ExecResult, error) {
+	return ExecEnv(ctx, command, nil, timeout, maxBytes)
+}
+
+// ExecEnv behaves as Exec, adding the given environment variables (in
+// key=value form) to the environment inherited by the command.
+func ExecEnv(ctx context.Context, command string, env []string, timeout time.Duration, maxBytes int) (ExecResult, error) {
+	args, err := SplitCommand(command)
+	if err != nil {
+		return ExecResult{}, err
+	}
+
+	return ExecArgs(ctx, args, env, timeout, maxBytes)
+}
+
+// ExecArgs behaves as ExecEnv, running the command given as already split
+// arguments. The command is allowed to run until it completes if the given
+// timeout is zero.
+func ExecArgs(ctx context.Context, args []string, env []string, timeout time.Duration, maxBytes int) (ExecResult, error) {
+	if len(args) == 0 || args[0] == "" {
+		return ExecResult{ExitCode: -1}, ErrEmptyCommand
+	}
+
+	cancel := func() {}
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	defer cancel()
+
+	stdout := cappedBuffer{limit: maxBytes}
+	stderr := cappedBuffer{limit: maxBytes}
+
+	// #nosec G204 -- running a user-specified command is the intent
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = nil
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = execWaitDelay
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+
+	started := time.Now()
+	runErr := cmd.Run()
+
+	result := ExecResult{
+		Stdout:          stdout.buf.String(),
+		Stderr:          stderr.buf.String(),
+		Truncated:       stdout.truncated,
+		StderrTruncated: stderr.truncated,
+		ExitCode:        -1,
+		Duration:        time.Since(started),
+	}
+
+	if cmd.ProcessState != nil {
+		result.ExitCode = cmd.ProcessState.ExitCode()
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		result.TimedOut = true
+		return result, fmt.Errorf("command %q did not complete within %v: %w", args[0], timeout, ctx.Err())
+
+	case runErr != nil:
+		return result, fmt.Errorf("command %q failed: %w", args[0], runErr)
+	}
+
+	return result, nil
+}
+
+// SplitCommand splits the given command string into arguments. Arguments are
+// separated by whitespace; single or double quotes group characters
+// (including whitespace) into a single argument and a backslash escapes the
+// following character outside of single quotes.
+func SplitCommand(command string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	var inArg bool
+	var quote rune
+	var escaped bool
+
+	for _, r := range command {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+
+		case quote != 0:
+			if r == quote {
+				quote = 0
+				continue
+			}
+			current.WriteRune(r)
+
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, ErrUnterminatedQuote
+	}
+
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	if len(args) == 0 {
+		return nil, ErrEmptyCommand
+	}
+
+	return args, nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package input
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+		err     error
+	}{
+		{command: "df -h", want: []string{"df", "-h"}},
+		{command: "  /usr/bin/uptime  ", want: []string{"/usr/bin/uptime"}},
+		{command: `grep "disk full" /var/log/messages`, want: []string{"grep", "disk full", "/var/log/messages"}},
+		{command: `echo 'a "quoted" value'`, want: []string{"echo", `a "quoted" value`}},
+		{command: `echo a\ b ""`, want: []string{"echo", "a b", ""}},
+		{command: `echo 'unterminated`, err: ErrUnterminatedQuote},
+		{command: "   ", err: ErrEmptyCommand},
+	}
+
+	for _, tt := range tests {
+		got, err := SplitCommand(tt.command)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("SplitCommand(%q) error = %v; want %v", tt.command, err, tt.err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitCommand(%q) = %q; want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestExecExitCode(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	result, err := Exec(context.Background(), "sh -c 'echo partial; exit 3'", 5*time.Second, 1024)
+	if err == nil {
+		t.Fatal("expected error for non-zero exit status")
+	}
+
+	if result.ExitCode != 3 || result.Stdout != "partial\n" || result.TimedOut {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	result, err = Exec(context.Background(), "sleep 5", 50*time.Millisecond, 1024)
+	if err == nil || !result.TimedOut || result.ExitCode != -1 {
+		t.Errorf("expected timeout, got result %+v and error %v", result, err)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// gzipMagic is the header which starts gzip compressed content.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// FileExcerpt is the leading content of a file along with details
+// describing the complete file.
+type FileExcerpt struct {
+
+	// Name is the base name of the file.
+	Name string
+
+	// Content is the (possibly truncated) leading content of the file.
+	Content string
+
+	// SHA256 is the hex encoded SHA-256 checksum of the complete file.
+	SHA256 string
+
+	// Size is the size in bytes of the complete file.
+	Size int64
+
+	// Truncated indicates that content beyond the limit was omitted.
+	Truncated bool
+
+	// Language is the display name of the language of the file content
+	// (e.g., Python, YAML). Empty if the language was not detected.
+	Language string
+}
+
+// ReadExcerpt reads up to maxBytes from the start of the given file. The
+// checksum and size always describe the complete file so that recipients are
+// able to verify that an excerpt corresponds to the original artifact.
+func ReadExcerpt(path string, maxBytes int) (FileExcerpt, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return FileExcerpt{}, err
+	}
+	defer func() { _ = f.Close() }()
+
+	hash := sha256.New()
+	content := cappedBuffer{limit: maxBytes}
+
+	size, err := io.Copy(io.MultiWriter(hash, &content), f)
+	if err != nil {
+		return FileExcerpt{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return FileExcerpt{
+		Name:      filepath.Base(path),
+		Content:   content.buf.String(),
+		SHA256:    hex.EncodeToString(hash.Sum(nil)),
+		Size:      size,
+		Truncated: content.truncated,
+		Language:  DetectLanguage(path, content.buf.String()),
+	}, nil
+}
+
+// ReadDecompressed reads up to maxBytes of the content of the given file,
+// indicating whether content beyond the limit was omitted. Gzip compressed
+// files (detected by their content rather than a .gz extension) are
+// decompressed transparently and the limit applies to the decompressed
+// content. Reading stops at the limit so that a highly compressed file is
+// not expanded in full.
+func ReadDecompressed(path string, maxBytes int) ([]byte, bool, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, false, err
+	}
+	defer func() { _ = f.Close() }()
+
+	br := bufio.NewReader(f)
+
+	var r io.Reader = br
+	if header, err := br.Peek(len(gzipMagic)); err == nil && bytes.Equal(header, gzipMagic) {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to decompress %s: %w", path, err)
+		}
+		defer func() { _ = zr.Close() }()
+		r = zr
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, int64(maxBytes)+1))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if len(data) > maxBytes {
+		return data[:maxBytes], true, nil
+	}
+
+	return data, false, nil
+}
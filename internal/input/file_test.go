@@ -0,0 +1,86 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package input
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadExcerpt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "build.log")
+	if err := os.WriteFile(path, []byte("hello world\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Checksum of the complete file content.
+	const want = "a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447"
+
+	excerpt, err := ReadExcerpt(path, 5)
+	if err != nil {
+		t.Fatalf("ReadExcerpt() error = %v", err)
+	}
+
+	if excerpt.Name != "build.log" || excerpt.Content != "hello" || !excerpt.Truncated {
+		t.Errorf("ReadExcerpt() = %+v; want truncated excerpt %q of build.log", excerpt, "hello")
+	}
+
+	if excerpt.SHA256 != want || excerpt.Size != 12 {
+		t.Errorf("ReadExcerpt() checksum, size = %s, %d; want %s, 12", excerpt.SHA256, excerpt.Size, want)
+	}
+}
+
+func TestReadDecompressed(t *testing.T) {
+	dir := t.TempDir()
+	content := strings.Repeat("ERROR disk full\n", 100)
+
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	if _, err := zw.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string][]byte{
+		"app.log":     []byte(content),
+		"app.log.gz":  compressed.Bytes(),
+		"archive.txt": compressed.Bytes(),
+	}
+
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		got, truncated, err := ReadDecompressed(path, len(content))
+		if err != nil || string(got) != content || truncated {
+			t.Errorf("ReadDecompressed(%s) = %d bytes, %t, %v; want %d bytes", name, len(got), truncated, err, len(content))
+		}
+
+		got, truncated, err = ReadDecompressed(path, 16)
+		if err != nil || string(got) != "ERROR disk full\n" || !truncated {
+			t.Errorf("ReadDecompressed(%s, 16) = %q, %t, %v; want truncated first line", name, got, truncated, err)
+		}
+	}
+
+	corrupt := filepath.Join(dir, "corrupt.gz")
+	if err := os.WriteFile(corrupt, compressed.Bytes()[:20], 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := ReadDecompressed(corrupt, len(content)); err == nil {
+		t.Error("ReadDecompressed() of a corrupt file succeeded; want error")
+	}
+}
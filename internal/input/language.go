@@ -0,0 +1,135 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package input
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// languagesByExtension maps (lowercase) file extensions to the display name
+// of the language of the file content.
+var languagesByExtension = map[string]string{
+	".bash":  "Shell",
+	".bat":   "Batch",
+	".c":     "C",
+	".cc":    "C++",
+	".cfg":   "INI",
+	".cmd":   "Batch",
+	".cpp":   "C++",
+	".cs":    "C#",
+	".css":   "CSS",
+	".go":    "Go",
+	".h":     "C",
+	".hcl":   "HCL",
+	".html":  "HTML",
+	".ini":   "INI",
+	".java":  "Java",
+	".js":    "JavaScript",
+	".json":  "JSON",
+	".ksh":   "Shell",
+	".lua":   "Lua",
+	".md":    "Markdown",
+	".php":   "PHP",
+	".pl":    "Perl",
+	".ps1":   "PowerShell",
+	".psm1":  "PowerShell",
+	".py":    "Python",
+	".rb":    "Ruby",
+	".rs":    "Rust",
+	".sh":    "Shell",
+	".sql":   "SQL",
+	".tf":    "HCL",
+	".toml":  "TOML",
+	".ts":    "TypeScript",
+	".vbs":   "VBScript",
+	".xml":   "XML",
+	".yaml":  "YAML",
+	".yml":   "YAML",
+	".zsh":   "Shell",
+	".patch": "Diff",
+	".diff":  "Diff",
+}
+
+// languagesByName maps (lowercase) file names without a distinguishing
+// extension to the display name of the language of the file content.
+var languagesByName = map[string]string{
+	"dockerfile":  "Dockerfile",
+	"makefile":    "Makefile",
+	"gnumakefile": "Makefile",
+	"jenkinsfile": "Groovy",
+	"vagrantfile": "Ruby",
+}
+
+// languagesByInterpreter maps the (versionless) interpreter named by a
+// shebang line to the display name of the language of the script.
+var languagesByInterpreter = map[string]string{
+	"ash":    "Shell",
+	"bash":   "Shell",
+	"dash":   "Shell",
+	"ksh":    "Shell",
+	"sh":     "Shell",
+	"zsh":    "Shell",
+	"node":   "JavaScript",
+	"perl":   "Perl",
+	"php":    "PHP",
+	"pwsh":   "PowerShell",
+	"python": "Python",
+	"ruby":   "Ruby",
+	"lua":    "Lua",
+}
+
+// DetectLanguage returns the display name of the language (e.g., Python,
+// YAML) of the given file content, determined from the file name (extension
+// or well-known name) or from a shebang line. An empty string is returned if
+// the language could not be determined.
+func DetectLanguage(name string, content string) string {
+	base := filepath.Base(name)
+
+	if lang, ok := languagesByExtension[strings.ToLower(filepath.Ext(base))]; ok && lang != "" {
+		return lang
+	}
+
+	if lang, ok := languagesByName[strings.ToLower(base)]; ok {
+		return lang
+	}
+
+	return shebangLanguage(content)
+}
+
+// shebangLanguage returns the display name of the language of a script
+// based on the interpreter named by its shebang line, if any.
+func shebangLanguage(content string) string {
+	if !strings.HasPrefix(content, "#!") {
+		return ""
+	}
+
+	line, _, _ := strings.Cut(content[2:], "\n")
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	interpreter := filepath.Base(fields[0])
+
+	// e.g., #!/usr/bin/env python3 or #!/usr/bin/env -S perl -w
+	if interpreter == "env" {
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") && !strings.Contains(field, "=") {
+				interpreter = filepath.Base(field)
+				break
+			}
+		}
+	}
+
+	// e.g., python3, python3.11
+	interpreter = strings.TrimRight(interpreter, "0123456789.")
+
+	return languagesByInterpreter[interpreter]
+}
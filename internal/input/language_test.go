@@ -0,0 +1,33 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package input
+
+import "testing"
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "/etc/app/config.yml", want: "YAML"},
+		{name: "deploy.PS1", want: "PowerShell"},
+		{name: "Dockerfile", want: "Dockerfile"},
+		{name: "backup", content: "#!/bin/bash\nset -e\n", want: "Shell"},
+		{name: "report", content: "#!/usr/bin/env python3\n", want: "Python"},
+		{name: "check", content: "#!/usr/bin/env -S perl -w\n", want: "Perl"},
+		{name: "notes.txt", content: "#!/bin/sh\n", want: "Shell"},
+		{name: "build.log", content: "ok\n", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := DetectLanguage(tt.name, tt.content); got != tt.want {
+			t.Errorf("DetectLanguage(%q) = %q; want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package input
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// tailChunkSize is the number of bytes read at a time, working backwards
+// from the end of a file, while locating the last lines of the file.
+const tailChunkSize int64 = 4096
+
+// FileTail is the trailing lines of a file.
+type FileTail struct {
+
+	// Name is the base name of the file.
+	Name string
+
+	// Content is the trailing lines of the file, without a final newline.
+	Content string
+
+	// Lines is the number of lines included in Content.
+	Lines int
+
+	// Truncated indicates that earlier content of the file was omitted.
+	Truncated bool
+}
+
+// ReadTail reads up to maxLines lines from the end of the given file (e.g.,
+// a log file), retaining no more than maxBytes bytes. The earliest lines are
+// omitted if the limit is reached and only the last maxBytes bytes of a
+// single longer line are retained. The file is read from the end so that
+// large files are not read in full.
+func ReadTail(path string, maxLines int, maxBytes int) (FileTail, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return FileTail{}, err
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	switch {
+	case err != nil:
+		return FileTail{}, fmt.Errorf("failed to read %s: %w", path, err)
+	case !info.Mode().IsRegular():
+		return FileTail{}, fmt.Errorf("failed to read %s: not a regular file", path)
+	}
+
+	// Chunks are read until one line more than requested has been found
+	// (so that the first line retained is known to be complete), the start
+	// of the file is reached or the byte limit is exceeded.
+	var data []byte
+	offset := info.Size()
+	for offset > 0 && len(data) <= maxBytes && countLines(data) <= maxLines {
+		n := tailChunkSize
+		if offset < n {
+			n = offset
+		}
+		offset -= n
+
+		chunk := make([]byte, n)
+		if _, err := f.ReadAt(chunk, offset); err != nil && err != io.EOF {
+			return FileTail{}, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		data = append(chunk, data...)
+	}
+
+	text := strings.TrimRight(string(data), "\r\n")
+	if text == "" {
+		return FileTail{Name: filepath.Base(path), Truncated: offset > 0}, nil
+	}
+
+	lines := strings.Split(text, "\n")
+	truncated := offset > 0
+
+	switch {
+	case len(lines) > maxLines:
+		lines = lines[len(lines)-maxLines:]
+		truncated = true
+	case offset > 0 && len(lines) > 1:
+		// The first line may have started before the content read.
+		lines = lines[1:]
+	}
+
+	for i := range lines {
+		lines[i] = strings.TrimSuffix(lines[i], "\r")
+	}
+
+	for len(lines) > 1 && len(strings.Join(lines, "\n")) > maxBytes {
+		lines = lines[1:]
+		truncated = true
+	}
+
+	content := strings.Join(lines, "\n")
+	if len(content) > maxBytes {
+		content = strings.ToValidUTF8(content[len(content)-maxBytes:], "")
+		truncated = true
+	}
+
+	return FileTail{
+		Name:      filepath.Base(path),
+		Content:   content,
+		Lines:     len(lines),
+		Truncated: truncated,
+	}, nil
+}
+
+// countLines returns the number of lines in the given content, not counting
+// an empty line following a final newline.
+func countLines(data []byte) int {
+	data = bytes.TrimRight(data, "\r\n")
+	if len(data) == 0 {
+		return 0
+	}
+
+	return bytes.Count(data, []byte("\n")) + 1
+}
@@ -0,0 +1,68 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package input
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadTail(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 2000; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		maxLines  int
+		maxBytes  int
+		want      string
+		truncated bool
+	}{
+		{maxLines: 3, maxBytes: 1024, want: "line 1998\nline 1999\nline 2000", truncated: true},
+		{maxLines: 5000, maxBytes: 1 << 20, want: strings.Join(lines, "\n")},
+		{maxLines: 20, maxBytes: 20, want: "line 1999\nline 2000", truncated: true},
+		{maxLines: 1, maxBytes: 4, want: "2000", truncated: true},
+	}
+
+	for _, tt := range tests {
+		tail, err := ReadTail(path, tt.maxLines, tt.maxBytes)
+		if err != nil {
+			t.Fatalf("ReadTail(%d, %d) error = %v", tt.maxLines, tt.maxBytes, err)
+		}
+
+		if tail.Name != "app.log" || tail.Content != tt.want || tail.Truncated != tt.truncated {
+			t.Errorf("ReadTail(%d, %d) = %q (truncated %t); want %q (truncated %t)",
+				tt.maxLines, tt.maxBytes, shorten(tail.Content), tail.Truncated, shorten(tt.want), tt.truncated)
+		}
+
+		if want := strings.Count(tt.want, "\n") + 1; tail.Lines != want {
+			t.Errorf("ReadTail(%d, %d) lines = %d; want %d", tt.maxLines, tt.maxBytes, tail.Lines, want)
+		}
+	}
+
+	if _, err := ReadTail(t.TempDir(), 10, 1024); err == nil {
+		t.Error("ReadTail() of a directory succeeded; want error")
+	}
+}
+
+// shorten returns the end of long test content for display.
+func shorten(s string) string {
+	if len(s) > 40 {
+		return "..." + s[len(s)-40:]
+	}
+	return s
+}
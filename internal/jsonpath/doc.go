@@ -0,0 +1,17 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package jsonpath provides a small JSON path implementation used to extract
+values from JSON documents provided by upstream tools.
+
+Only simple paths are supported: an optional leading "$" followed by any
+number of ".name", "['name']" or "[index]" selectors (e.g., "$.host",
+".alert.labels['instance']" or "$.checks[0].state"). Wildcards, filters and
+recursive descent are not supported.
+*/
+package jsonpath
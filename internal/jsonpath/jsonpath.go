@@ -0,0 +1,182 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package jsonpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidPath indicates that a JSON path expression could not be parsed.
+var ErrInvalidPath = errors.New("invalid JSON path")
+
+// selector is a single step within a Path. Exactly one of key or index is
+// meaningful, as indicated by isIndex.
+type selector struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// Path is a parsed JSON path expression.
+type Path struct {
+	expr      string
+	selectors []selector
+}
+
+// Parse parses the given JSON path expression.
+func Parse(expr string) (Path, error) {
+	p := Path{expr: strings.TrimSpace(expr)}
+
+	rest := strings.TrimPrefix(p.expr, "$")
+	if rest == "" {
+		// The root document.
+		return p, nil
+	}
+
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+
+			key := rest[:end]
+			if key == "" {
+				return Path{}, fmt.Errorf("%w %q: empty field name", ErrInvalidPath, expr)
+			}
+
+			p.selectors = append(p.selectors, selector{key: key})
+			rest = rest[end:]
+
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return Path{}, fmt.Errorf("%w %q: unterminated selector", ErrInvalidPath, expr)
+			}
+
+			sel, err := parseBracket(rest[1:end])
+			if err != nil {
+				return Path{}, fmt.Errorf("%w %q: %v", ErrInvalidPath, expr, err)
+			}
+
+			p.selectors = append(p.selectors, sel)
+			rest = rest[end+1:]
+
+		default:
+			return Path{}, fmt.Errorf("%w %q: unexpected %q", ErrInvalidPath, expr, rest[0])
+		}
+	}
+
+	return p, nil
+}
+
+// parseBracket parses the content of a bracketed selector, either a quoted
+// field name or an array index.
+func parseBracket(content string) (selector, error) {
+	if len(content) >= 2 {
+		quote := content[0]
+		if (quote == '\'' || quote == '"') && content[len(content)-1] == quote {
+			return selector{key: content[1 : len(content)-1]}, nil
+		}
+	}
+
+	index, err := strconv.Atoi(content)
+	if err != nil || index < 0 {
+		return selector{}, fmt.Errorf("unsupported selector [%s]", content)
+	}
+
+	return selector{index: index, isIndex: true}, nil
+}
+
+// String returns the original path expression.
+func (p Path) String() string {
+	return p.expr
+}
+
+// Name returns the final field name of the path, suitable for use as a
+// label. The expression itself is returned if the path does not end in a
+// field name.
+func (p Path) Name() string {
+	if n := len(p.selectors); n > 0 && !p.selectors[n-1].isIndex {
+		return p.selectors[n-1].key
+	}
+
+	return p.expr
+}
+
+// Lookup returns the value at the path within the given decoded JSON
+// document. The second return value is false if the path does not exist.
+func (p Path) Lookup(doc interface{}) (interface{}, bool) {
+	value := doc
+
+	for _, sel := range p.selectors {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if sel.isIndex {
+				return nil, false
+			}
+
+			next, ok := v[sel.key]
+			if !ok {
+				return nil, false
+			}
+			value = next
+
+		case []interface{}:
+			if !sel.isIndex || sel.index >= len(v) {
+				return nil, false
+			}
+			value = v[sel.index]
+
+		default:
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
+// Decode parses the given JSON document for use with Lookup. Numbers are
+// retained in their original form.
+func Decode(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON document: %w", err)
+	}
+
+	return doc, nil
+}
+
+// Format returns the given decoded value as display text. Strings are
+// returned as-is, objects and arrays in compact JSON form.
+func Format(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil:
+		return "null"
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(data)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
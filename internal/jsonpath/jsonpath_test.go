@@ -0,0 +1,69 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package jsonpath
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	doc, err := Decode([]byte(`{
+		"host": "web01",
+		"durationSeconds": 12.5,
+		"alert": {"labels": {"team name": "ops"}},
+		"checks": [{"state": "OK"}, {"state": "CRITICAL"}],
+		"tags": ["a", "b"]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		expr  string
+		want  string
+		name  string
+		found bool
+	}{
+		{expr: "$.host", want: "web01", name: "host", found: true},
+		{expr: "$.durationSeconds", want: "12.5", name: "durationSeconds", found: true},
+		{expr: ".alert.labels['team name']", want: "ops", name: "team name", found: true},
+		{expr: "$.checks[1].state", want: "CRITICAL", name: "state", found: true},
+		{expr: "$.tags", want: `["a","b"]`, name: "tags", found: true},
+		{expr: "$.checks[2].state", name: "state"},
+		{expr: "$.host.name", name: "name"},
+	}
+
+	for _, tt := range tests {
+		p, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.expr, err)
+			continue
+		}
+
+		if p.Name() != tt.name {
+			t.Errorf("Parse(%q).Name() = %q; want %q", tt.expr, p.Name(), tt.name)
+		}
+
+		value, found := p.Lookup(doc)
+		if found != tt.found {
+			t.Errorf("Lookup(%q) found = %t; want %t", tt.expr, found, tt.found)
+			continue
+		}
+
+		if found && Format(value) != tt.want {
+			t.Errorf("Lookup(%q) = %q; want %q", tt.expr, Format(value), tt.want)
+		}
+	}
+
+	for _, expr := range []string{"$.", "$[x]", "$.a[0", "host"} {
+		if _, err := Parse(expr); !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("Parse(%q) error = %v; want %v", expr, err, ErrInvalidPath)
+		}
+	}
+}
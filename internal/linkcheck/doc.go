@@ -0,0 +1,13 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package linkcheck verifies that the URLs referenced by a message (e.g.,
+runbook buttons and Markdown links) are reachable before the message is
+sent, so that recipients are not presented with dead links.
+*/
+package linkcheck
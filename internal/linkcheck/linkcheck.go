@@ -0,0 +1,189 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package linkcheck
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxConcurrentChecks is the maximum number of URLs checked concurrently.
+const maxConcurrentChecks int = 4
+
+// urlPattern matches http and https URLs within message text, including the
+// targets of Markdown links.
+var urlPattern = regexp.MustCompile(`https?://[^\s<>()\[\]"'` + "`" + `]+`)
+
+// Options configures how URLs are checked.
+type Options struct {
+
+	// Timeout is the maximum time spent checking each URL.
+	Timeout time.Duration
+
+	// UserAgent is the (optional) User-Agent header sent with each request.
+	UserAgent string
+}
+
+// Result is the outcome of checking a URL.
+type Result struct {
+
+	// URL is the URL which was checked.
+	URL string
+
+	// StatusCode is the HTTP status code of the response. Zero if no
+	// response was received.
+	StatusCode int
+
+	// Err is the reason no response was received, if any.
+	Err error
+}
+
+// Dead indicates whether the URL is considered dead: no response was
+// received or the response indicates that the resource does not exist or
+// the server failed. Responses requiring authentication or rate limiting
+// requests are not considered dead since the link may work for recipients.
+func (r Result) Dead() bool {
+	switch {
+	case r.Err != nil:
+		return true
+	case r.StatusCode == http.StatusUnauthorized,
+		r.StatusCode == http.StatusForbidden,
+		r.StatusCode == http.StatusTooManyRequests:
+		return false
+	default:
+		return r.StatusCode >= http.StatusBadRequest
+	}
+}
+
+// String returns a description of the outcome.
+func (r Result) String() string {
+	if r.Err != nil {
+		return fmt.Sprintf("%s: %v", r.URL, r.Err)
+	}
+
+	return fmt.Sprintf("%s: %d %s", r.URL, r.StatusCode, http.StatusText(r.StatusCode))
+}
+
+// Extract returns the unique http and https URLs found within the given
+// text (e.g., bare URLs and Markdown link targets), in order of appearance.
+func Extract(text string) []string {
+	var urls []string
+	seen := make(map[string]struct{})
+
+	for _, match := range urlPattern.FindAllString(text, -1) {
+		// Trailing punctuation is more likely to end a sentence than to be
+		// part of the URL.
+		match = strings.TrimRight(match, ".,;:!?*_~")
+		if _, dup := seen[match]; dup {
+			continue
+		}
+		seen[match] = struct{}{}
+		urls = append(urls, match)
+	}
+
+	return urls
+}
+
+// Allowed indicates whether the host of the given URL matches one of the
+// given allowlist entries. Entries match the host exactly or, if prefixed
+// with "*.", any subdomain of the given domain.
+func Allowed(rawURL string, allowlist []string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+
+	for _, entry := range allowlist {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+		case strings.HasPrefix(entry, "*."):
+			if strings.HasSuffix(host, entry[1:]) {
+				return true
+			}
+		case host == entry:
+			return true
+		}
+	}
+
+	return false
+}
+
+// Check checks each of the given URLs concurrently, spending no more than
+// the timeout specified by the given options on each, and returns the
+// results in the same order. A HEAD request is used, falling back to GET for
+// servers which do not support HEAD requests.
+func Check(ctx context.Context, client *http.Client, urls []string, opts Options) []Result {
+	results := make([]Result, len(urls))
+	sem := make(chan struct{}, maxConcurrentChecks)
+
+	var wg sync.WaitGroup
+	for i, u := range urls {
+		wg.Add(1)
+		go func(i int, u string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results[i] = check(ctx, client, u, opts)
+		}(i, u)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// check checks the given URL.
+func check(ctx context.Context, client *http.Client, rawURL string, opts Options) Result {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	result := Result{URL: rawURL}
+
+	status, err := request(ctx, client, http.MethodHead, rawURL, opts.UserAgent)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = request(ctx, client, http.MethodGet, rawURL, opts.UserAgent)
+	}
+
+	result.StatusCode, result.Err = status, err
+
+	return result
+}
+
+// request performs a request using the given method, returning the status
+// code of the response.
+func request(ctx context.Context, client *http.Client, method string, rawURL string, userAgent string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	if userAgent != "" {
+		req.Header.Set("User-Agent", userAgent)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	// Only the status is of interest; the body of GET responses is not read
+	// beyond a small amount to allow the connection to be reused.
+	_, _ = io.CopyN(io.Discard, resp.Body, 4096)
+	_ = resp.Body.Close()
+
+	return resp.StatusCode, nil
+}
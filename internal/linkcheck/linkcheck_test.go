@@ -0,0 +1,89 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package linkcheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExtract(t *testing.T) {
+	text := "See the [runbook](https://wiki.example.com/runbook#disk) or https://status.example.com. " +
+		"Again: https://status.example.com, and <http://host.example.com/a?b=c>."
+
+	want := []string{
+		"https://wiki.example.com/runbook#disk",
+		"https://status.example.com",
+		"http://host.example.com/a?b=c",
+	}
+
+	if got := Extract(text); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	allowlist := []string{"intranet.example.com", "*.corp.example.com"}
+
+	tests := map[string]bool{
+		"https://intranet.example.com/page":   true,
+		"https://INTRANET.example.com:8443/x": true,
+		"https://wiki.corp.example.com/x":     true,
+		"https://corp.example.com/x":          false,
+		"https://example.com/x":               false,
+	}
+
+	for u, want := range tests {
+		if got := Allowed(u, allowlist); got != want {
+			t.Errorf("Allowed(%q) = %t, want %t", u, got, want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusOK)
+		case "/get-only":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		case "/private":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	urls := []string{
+		server.URL + "/ok",
+		server.URL + "/get-only",
+		server.URL + "/private",
+		server.URL + "/missing",
+		"http://127.0.0.1:1/unreachable",
+	}
+	wantDead := []bool{false, false, false, true, true}
+
+	results := Check(context.Background(), server.Client(), urls, Options{Timeout: 2 * time.Second})
+	for i, result := range results {
+		if result.URL != urls[i] {
+			t.Errorf("result %d: got URL %q, want %q", i, result.URL, urls[i])
+		}
+		if result.Dead() != wantDead[i] {
+			t.Errorf("result %s: got dead %t, want %t", result, result.Dead(), wantDead[i])
+		}
+	}
+}
@@ -0,0 +1,14 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package mock provides a local HTTP server which emulates a Microsoft Teams
+webhook endpoint, accepting submitted messages and responding as Microsoft
+Teams does. It is used to exercise the submission pipeline without sending
+messages to a real endpoint.
+*/
+package mock
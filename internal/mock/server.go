@@ -0,0 +1,108 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package mock
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	goteamsnotify "github.com/atc0005/go-teams-notify/v2"
+)
+
+// webhookPath is the URL path accepted by the Server.
+const webhookPath = "/webhook"
+
+// Server is a local HTTP server emulating a Microsoft Teams webhook
+// endpoint.
+type Server struct {
+	server   *http.Server
+	listener net.Listener
+
+	// latency is the simulated processing time for each request.
+	latency time.Duration
+
+	requests atomic.Uint64
+}
+
+// Start starts a Server listening on a random loopback port. Each request is
+// answered after the given simulated latency. The returned Server must be
+// closed once no longer needed.
+func Start(latency time.Duration) (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to start mock server: %w", err)
+	}
+
+	s := Server{
+		listener: listener,
+		latency:  latency,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(webhookPath, s.handle)
+
+	s.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		_ = s.server.Serve(listener)
+	}()
+
+	return &s, nil
+}
+
+// handle accepts a submitted message.
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, err := io.Copy(io.Discard, r.Body); err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	if s.latency > 0 {
+		select {
+		case <-time.After(s.latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	s.requests.Add(1)
+
+	_, _ = io.WriteString(w, goteamsnotify.ExpectedWebhookURLResponseText)
+}
+
+// URL returns the webhook URL served by the Server.
+func (s *Server) URL() string {
+	return "http://" + s.listener.Addr().String() + webhookPath
+}
+
+// Requests returns the number of messages accepted by the Server.
+func (s *Server) Requests() uint64 {
+	return s.requests.Load()
+}
+
+// Close stops the Server, waiting briefly for in-flight requests to
+// complete.
+func (s *Server) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return s.server.Shutdown(ctx)
+}
@@ -0,0 +1,14 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package netcheck provides fast detection of conditions where a webhook
+endpoint cannot be reached at all (e.g., no network route, DNS unavailable)
+so that callers are able to defer submission instead of spending their retry
+budget on attempts which cannot succeed.
+*/
+package netcheck
@@ -0,0 +1,144 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package netcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"syscall"
+	"time"
+)
+
+// ErrOffline indicates that the network is unavailable: the endpoint host
+// could not be resolved because DNS is unreachable, or no route to the host
+// exists.
+var ErrOffline = errors.New("network unavailable")
+
+// Check determines whether the host for the given URL (or the proxy used to
+// reach it) is reachable, spending no more than the given timeout. An error
+// wrapping ErrOffline is returned if the network is unavailable. Other
+// problems (e.g., a host which does not exist or refuses connections) are
+// not reported since they are better described by a submission attempt.
+func Check(ctx context.Context, rawURL string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	host, port, err := endpoint(rawURL)
+	if err != nil {
+		return nil
+	}
+
+	addrs := []string{host}
+	if net.ParseIP(host) == nil {
+		addrs, err = net.DefaultResolver.LookupHost(ctx, host)
+		if err != nil {
+			var dnsErr *net.DNSError
+			if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+				return nil
+			}
+			return fmt.Errorf("%w: failed to resolve %s: %v", ErrOffline, host, err)
+		}
+	}
+
+	var dialer net.Dialer
+	var lastErr error
+	for _, addr := range addrs {
+		conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(addr, port))
+		if err == nil {
+			_ = conn.Close()
+			return nil
+		}
+
+		// A connection attempt which does not complete in time is treated
+		// as unreachable; the host may be dropping traffic from this network.
+		if !IsOffline(err) && !errors.Is(err, os.ErrDeadlineExceeded) {
+			return nil
+		}
+		lastErr = err
+	}
+
+	if lastErr != nil {
+		return fmt.Errorf("%w: failed to connect to %s: %v", ErrOffline, host, lastErr)
+	}
+
+	return nil
+}
+
+// Probe determines whether a connection can be established to the host for
+// the given URL (or the proxy used to reach it), spending no more than the
+// given timeout. Unlike Check, any failure (e.g., a host which does not
+// exist or refuses connections) is reported.
+func Probe(ctx context.Context, rawURL string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	host, port, err := endpoint(rawURL)
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", host, err)
+	}
+
+	return conn.Close()
+}
+
+// endpoint returns the host and port connected to in order to reach the
+// given URL. When a proxy is used the endpoint host is resolved by the
+// proxy, so the host and port of the proxy are returned.
+func endpoint(rawURL string) (string, string, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse URL: %w", err)
+	}
+
+	req := http.Request{URL: target}
+	if proxy, err := http.ProxyFromEnvironment(&req); err == nil && proxy != nil {
+		target = proxy
+	}
+
+	host, port := target.Hostname(), target.Port()
+	if port == "" {
+		port = "443"
+		if target.Scheme == "http" {
+			port = "80"
+		}
+	}
+
+	return host, port, nil
+}
+
+// IsOffline indicates whether the given error (e.g., from a submission
+// attempt) was caused by the network being unavailable.
+func IsOffline(err error) bool {
+	switch {
+	case err == nil:
+		return false
+
+	case errors.Is(err, ErrOffline),
+		errors.Is(err, syscall.ENETUNREACH),
+		errors.Is(err, syscall.EHOSTUNREACH),
+		errors.Is(err, syscall.ENETDOWN):
+		return true
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return !dnsErr.IsNotFound
+	}
+
+	return false
+}
@@ -0,0 +1,59 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package netcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestIsOffline(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "no route", err: &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.EHOSTUNREACH)}, want: true},
+		{name: "network unreachable", err: fmt.Errorf("send failed: %w", syscall.ENETUNREACH), want: true},
+		{name: "dns unavailable", err: &net.DNSError{Err: "server misbehaving", IsTemporary: true}, want: true},
+		{name: "no such host", err: &net.DNSError{Err: "no such host", IsNotFound: true}, want: false},
+		{name: "connection refused", err: syscall.ECONNREFUSED, want: false},
+		{name: "other", err: errors.New("400 Bad Request"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsOffline(tt.err); got != tt.want {
+				t.Errorf("IsOffline(%v) = %t, want %t", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckReachable(t *testing.T) {
+	t.Setenv("HTTP_PROXY", "")
+	t.Setenv("NO_PROXY", "*")
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	url := fmt.Sprintf("http://%s/webhook", listener.Addr())
+	if err := Check(context.Background(), url, time.Second); err != nil {
+		t.Errorf("Check(%q) = %v, want nil", url, err)
+	}
+}
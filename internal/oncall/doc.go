@@ -0,0 +1,13 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package oncall resolves the users currently on call for a schedule managed
+by an on-call scheduling provider (e.g., PagerDuty, Opsgenie) so that they
+may be mentioned in a message at send time.
+*/
+package oncall
@@ -0,0 +1,150 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package oncall
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Supported on-call scheduling providers.
+const (
+	ProviderPagerDuty string = "pagerduty"
+	ProviderOpsgenie  string = "opsgenie"
+
+	// ProviderOpsgenieEU is Opsgenie using the EU service region.
+	ProviderOpsgenieEU string = "opsgenie-eu"
+)
+
+// maxErrorBodySize is the maximum number of bytes of an error response
+// included in returned errors.
+const maxErrorBodySize int64 = 512
+
+// ErrUnsupportedProvider indicates that an unknown on-call scheduling
+// provider was specified.
+var ErrUnsupportedProvider = errors.New("unsupported on-call provider")
+
+// ErrNoOnCall indicates that nobody is currently on call for a schedule.
+var ErrNoOnCall = errors.New("nobody on call")
+
+// User is a user currently on call.
+type User struct {
+
+	// Name is the display name of the user. The email address is used if
+	// the provider does not supply a name.
+	Name string
+
+	// Email is the email address of the user. Microsoft Teams accepts the
+	// email address (user principal name) as the user mention ID.
+	Email string
+}
+
+// Provider is an on-call scheduling provider.
+type Provider interface {
+
+	// OnCall returns the users currently on call for the given schedule.
+	OnCall(ctx context.Context, schedule string) ([]User, error)
+}
+
+// New creates the named on-call scheduling provider using the given API
+// token and HTTP client. If the token is empty, the provider-specific
+// environment variable (PAGERDUTY_TOKEN or OPSGENIE_API_KEY) is used.
+func New(name string, token string, client *http.Client) (Provider, error) {
+	switch name {
+	case ProviderPagerDuty:
+		if token == "" {
+			token = os.Getenv("PAGERDUTY_TOKEN")
+		}
+		if token == "" {
+			return nil, fmt.Errorf("API token not specified for %s", name)
+		}
+		return &PagerDuty{client: client, baseURL: pagerDutyBaseURL, token: token}, nil
+
+	case ProviderOpsgenie, ProviderOpsgenieEU:
+		if token == "" {
+			token = os.Getenv("OPSGENIE_API_KEY")
+		}
+		if token == "" {
+			return nil, fmt.Errorf("API key not specified for %s", name)
+		}
+
+		baseURL := opsgenieBaseURL
+		if name == ProviderOpsgenieEU {
+			baseURL = opsgenieEUBaseURL
+		}
+		return &Opsgenie{client: client, baseURL: baseURL, apiKey: token}, nil
+
+	default:
+		return nil, fmt.Errorf(
+			"%w %q; expected one of %s, %s or %s",
+			ErrUnsupportedProvider,
+			name,
+			ProviderPagerDuty,
+			ProviderOpsgenie,
+			ProviderOpsgenieEU,
+		)
+	}
+}
+
+// getJSON performs the given request and decodes the JSON response into the
+// given value.
+func getJSON(client *http.Client, req *http.Request, v interface{}) error {
+	req.Header.Set("User-Agent", "send2teams")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return fmt.Errorf(
+			"unexpected response from %s: %s: %s",
+			req.URL.Host,
+			resp.Status,
+			strings.TrimSpace(string(body)),
+		)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response from %s: %w", req.URL.Host, err)
+	}
+
+	return nil
+}
+
+// unique returns the given users with duplicate email addresses removed,
+// retaining their order.
+func unique(users []User) []User {
+	seen := make(map[string]struct{}, len(users))
+	result := make([]User, 0, len(users))
+
+	for _, user := range users {
+		key := strings.ToLower(user.Email)
+		if _, dup := seen[key]; dup || user.Email == "" {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		if user.Name == "" {
+			user.Name = user.Email
+		}
+		result = append(result, user)
+	}
+
+	return result
+}
@@ -0,0 +1,116 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package oncall
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestPagerDutyOnCall(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Token token=secret" {
+			t.Errorf("Authorization header = %q", got)
+		}
+		if got := r.URL.Query().Get("schedule_ids[]"); got != "PABC123" {
+			t.Errorf("schedule ID = %q", got)
+		}
+
+		_, _ = w.Write([]byte(`{"oncalls": [
+			{"escalation_level": 1, "user": {"name": "Jane Doe", "email": "jane@example.com"}},
+			{"escalation_level": 1, "user": {"name": "Jane Doe", "email": "JANE@example.com"}},
+			{"escalation_level": 2, "user": {"name": "", "email": "ops@example.com"}}
+		]}`))
+	}))
+	defer server.Close()
+
+	provider := PagerDuty{client: server.Client(), baseURL: server.URL, token: "secret"}
+
+	users, err := provider.OnCall(context.Background(), "PABC123")
+	if err != nil {
+		t.Fatalf("OnCall() unexpected error: %v", err)
+	}
+
+	want := []User{
+		{Name: "Jane Doe", Email: "jane@example.com"},
+		{Name: "ops@example.com", Email: "ops@example.com"},
+	}
+	if !reflect.DeepEqual(users, want) {
+		t.Errorf("OnCall() = %+v, want %+v", users, want)
+	}
+}
+
+func TestOpsgenieOnCall(t *testing.T) {
+	tests := []struct {
+		schedule       string
+		identifierType string
+	}{
+		{schedule: "Primary Rotation", identifierType: "name"},
+		{schedule: "3b3f5f6d-5c2a-4a8e-9b8e-1f2d3c4b5a69", identifierType: "id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.identifierType, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("Authorization"); got != "GenieKey secret" {
+					t.Errorf("Authorization header = %q", got)
+				}
+				if got := r.URL.Path; got != "/v2/schedules/"+tt.schedule+"/on-calls" {
+					t.Errorf("path = %q", got)
+				}
+				if got := r.URL.Query().Get("scheduleIdentifierType"); got != tt.identifierType {
+					t.Errorf("scheduleIdentifierType = %q, want %q", got, tt.identifierType)
+				}
+
+				_, _ = w.Write([]byte(`{"data": {"onCallRecipients": ["jane@example.com"]}}`))
+			}))
+			defer server.Close()
+
+			provider := Opsgenie{client: server.Client(), baseURL: server.URL, apiKey: "secret"}
+
+			users, err := provider.OnCall(context.Background(), tt.schedule)
+			if err != nil {
+				t.Fatalf("OnCall() unexpected error: %v", err)
+			}
+
+			want := []User{{Name: "jane@example.com", Email: "jane@example.com"}}
+			if !reflect.DeepEqual(users, want) {
+				t.Errorf("OnCall() = %+v, want %+v", users, want)
+			}
+		})
+	}
+}
+
+func TestOnCallErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("schedule_ids[]") == "PEMPTY" {
+			_, _ = w.Write([]byte(`{"oncalls": []}`))
+			return
+		}
+		http.Error(w, `{"error": {"message": "Not Found"}}`, http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	provider := PagerDuty{client: server.Client(), baseURL: server.URL, token: "secret"}
+
+	if _, err := provider.OnCall(context.Background(), "PEMPTY"); !errors.Is(err, ErrNoOnCall) {
+		t.Errorf("OnCall() error = %v, want %v", err, ErrNoOnCall)
+	}
+
+	if _, err := provider.OnCall(context.Background(), "PMISSING"); err == nil {
+		t.Error("OnCall() expected error for unknown schedule")
+	}
+
+	if _, err := New("victorops", "secret", server.Client()); !errors.Is(err, ErrUnsupportedProvider) {
+		t.Errorf("New() error = %v, want %v", err, ErrUnsupportedProvider)
+	}
+}
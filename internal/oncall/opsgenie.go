@@ -0,0 +1,82 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package oncall
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+)
+
+// Base URLs of the Opsgenie REST API for each service region.
+const (
+	opsgenieBaseURL   string = "https://api.opsgenie.com"
+	opsgenieEUBaseURL string = "https://api.eu.opsgenie.com"
+)
+
+// opsgenieIDPattern matches Opsgenie schedule IDs. Other values are treated
+// as schedule names.
+var opsgenieIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Opsgenie is the Opsgenie on-call scheduling provider.
+type Opsgenie struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+}
+
+// opsgenieOnCalls is the subset of the Opsgenie get on-calls response used
+// to resolve on-call users.
+type opsgenieOnCalls struct {
+	Data struct {
+		OnCallRecipients []string `json:"onCallRecipients"`
+	} `json:"data"`
+}
+
+// OnCall returns the users currently on call for the schedule with the given
+// ID or name.
+func (o *Opsgenie) OnCall(ctx context.Context, schedule string) ([]User, error) {
+	identifierType := "name"
+	if opsgenieIDPattern.MatchString(schedule) {
+		identifierType = "id"
+	}
+
+	query := url.Values{}
+	query.Set("scheduleIdentifierType", identifierType)
+	query.Set("flat", "true")
+
+	endpoint := fmt.Sprintf("%s/v2/schedules/%s/on-calls?%s", o.baseURL, url.PathEscape(schedule), query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "GenieKey "+o.apiKey)
+
+	var response opsgenieOnCalls
+	if err := getJSON(o.client, req, &response); err != nil {
+		return nil, fmt.Errorf("failed to retrieve on-call users for Opsgenie schedule %s: %w", schedule, err)
+	}
+
+	// Opsgenie identifies users by their username (email address) only.
+	users := make([]User, 0, len(response.Data.OnCallRecipients))
+	for _, recipient := range response.Data.OnCallRecipients {
+		users = append(users, User{Email: recipient})
+	}
+
+	users = unique(users)
+	if len(users) == 0 {
+		return nil, fmt.Errorf("%w for Opsgenie schedule %s", ErrNoOnCall, schedule)
+	}
+
+	return users, nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package oncall
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// pagerDutyBaseURL is the base URL of the PagerDuty REST API.
+const pagerDutyBaseURL string = "https://api.pagerduty.com"
+
+// PagerDuty is the PagerDuty on-call scheduling provider.
+type PagerDuty struct {
+	client  *http.Client
+	baseURL string
+	token   string
+}
+
+// pagerDutyOnCalls is the subset of the PagerDuty list on-calls response
+// used to resolve on-call users.
+type pagerDutyOnCalls struct {
+	OnCalls []struct {
+		User struct {
+			Name  string `json:"name"`
+			Email string `json:"email"`
+		} `json:"user"`
+	} `json:"oncalls"`
+}
+
+// OnCall returns the users currently on call for the schedule with the given
+// ID.
+func (p *PagerDuty) OnCall(ctx context.Context, schedule string) ([]User, error) {
+	query := url.Values{}
+	query.Set("schedule_ids[]", schedule)
+	query.Set("include[]", "users")
+	query.Set("earliest", "true")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/oncalls?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/vnd.pagerduty+json;version=2")
+	req.Header.Set("Authorization", "Token token="+p.token)
+
+	var response pagerDutyOnCalls
+	if err := getJSON(p.client, req, &response); err != nil {
+		return nil, fmt.Errorf("failed to retrieve on-call users for PagerDuty schedule %s: %w", schedule, err)
+	}
+
+	users := make([]User, 0, len(response.OnCalls))
+	for _, oncall := range response.OnCalls {
+		users = append(users, User{Name: oncall.User.Name, Email: oncall.User.Email})
+	}
+
+	users = unique(users)
+	if len(users) == 0 {
+		return nil, fmt.Errorf("%w for PagerDuty schedule %s", ErrNoOnCall, schedule)
+	}
+
+	return users, nil
+}
@@ -0,0 +1,14 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package replay records the effective configuration and message content of an
+invocation to a file so that the invocation may be re-executed exactly
+(e.g., to reproduce a problem in a bug report or to test a migration to a
+new webhook URL) without access to the original inputs.
+*/
+package replay
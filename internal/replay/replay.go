@@ -0,0 +1,158 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package replay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/atc0005/send2teams/internal/teams"
+	"github.com/atc0005/send2teams/internal/theme"
+)
+
+// FormatVersion is the version of the invocation file format written by
+// this package.
+const FormatVersion int = 1
+
+// ErrInvalidInvocation indicates that an invocation file is malformed or
+// uses an unsupported format version.
+var ErrInvalidInvocation = errors.New("invalid invocation file")
+
+// Invocation is the recorded configuration and message content of an
+// invocation.
+type Invocation struct {
+
+	// Version is the version of the invocation file format.
+	Version int `json:"version"`
+
+	// AppVersion is the version of the application which recorded the
+	// invocation.
+	AppVersion string `json:"app_version"`
+
+	// Recorded is when the invocation was recorded.
+	Recorded time.Time `json:"recorded"`
+
+	// Flags are the effective values of all flags, including those applied
+	// from a configuration file. Credentials other than the webhook URL are
+	// omitted.
+	Flags map[string]string `json:"flags"`
+
+	// ResponseChoices are the values of the (repeatable) response-choice
+	// flag, which are recorded separately from other flags.
+	ResponseChoices []string `json:"response_choices,omitempty"`
+
+	// WebhookURL is the webhook URL the message was submitted to.
+	WebhookURL string `json:"webhook_url"`
+
+	// Message is the message content after all inputs (e.g., command output,
+	// templates, files) were applied.
+	Message teams.Message `json:"message"`
+
+	// Card is the collection of settings used to generate the card.
+	Card Card `json:"card"`
+}
+
+// Card is the collection of settings used to generate a card from the
+// recorded message.
+type Card struct {
+	Trailer           string       `json:"trailer,omitempty"`
+	ComplianceTags    []teams.Fact `json:"compliance_tags,omitempty"`
+	ConvertEOL        bool         `json:"convert_eol,omitempty"`
+	ConvertEscapedEOL bool         `json:"convert_escaped_eol,omitempty"`
+	LegacyConvertEOL  bool         `json:"legacy_convert_eol,omitempty"`
+	BidiIsolate       bool         `json:"bidi_isolate,omitempty"`
+	EmojiFallback     bool         `json:"emoji_fallback,omitempty"`
+	ExpandTabs        int          `json:"expand_tabs,omitempty"`
+	IntegrityFact     bool         `json:"integrity_fact,omitempty"`
+	TitleColor        string       `json:"title_color,omitempty"`
+	Theme             theme.Theme  `json:"theme"`
+}
+
+// NewCard returns the settings recorded for the given card options. The
+// title color selected by any color rules is recorded in place of the rules.
+func NewCard(msg teams.Message, opts teams.CardOptions) Card {
+	titleColor := opts.TitleColor
+	if titleColor == "" {
+		titleColor = opts.ColorRules.Match(msg.Title, msg.Text)
+	}
+
+	return Card{
+		Trailer:           opts.Trailer,
+		ComplianceTags:    opts.ComplianceTags,
+		ConvertEOL:        opts.ConvertEOL,
+		ConvertEscapedEOL: opts.ConvertEscapedEOL,
+		LegacyConvertEOL:  opts.LegacyConvertEOL,
+		BidiIsolate:       opts.BidiIsolate,
+		EmojiFallback:     opts.EmojiFallback,
+		ExpandTabs:        opts.ExpandTabs,
+		IntegrityFact:     opts.IntegrityFact,
+		TitleColor:        titleColor,
+		Theme:             opts.Theme,
+	}
+}
+
+// Options returns the card options for the recorded settings.
+func (c Card) Options() teams.CardOptions {
+	return teams.CardOptions{
+		Trailer:           c.Trailer,
+		ComplianceTags:    c.ComplianceTags,
+		ConvertEOL:        c.ConvertEOL,
+		ConvertEscapedEOL: c.ConvertEscapedEOL,
+		LegacyConvertEOL:  c.LegacyConvertEOL,
+		BidiIsolate:       c.BidiIsolate,
+		EmojiFallback:     c.EmojiFallback,
+		ExpandTabs:        c.ExpandTabs,
+		IntegrityFact:     c.IntegrityFact,
+		TitleColor:        c.TitleColor,
+		Theme:             c.Theme,
+	}
+}
+
+// Write records the given invocation to the given file. The file is only
+// readable by the owner since it contains the webhook URL.
+func Write(path string, inv Invocation) error {
+	inv.Version = FormatVersion
+
+	data, err := json.MarshalIndent(inv, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode invocation: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Clean(path), append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to record invocation: %w", err)
+	}
+
+	return nil
+}
+
+// Read returns the invocation recorded in the given file.
+func Read(path string) (Invocation, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return Invocation{}, fmt.Errorf("failed to read invocation file: %w", err)
+	}
+
+	var inv Invocation
+	if err := json.Unmarshal(data, &inv); err != nil {
+		return Invocation{}, fmt.Errorf("%w %s: %v", ErrInvalidInvocation, path, err)
+	}
+
+	if inv.Version != FormatVersion {
+		return Invocation{}, fmt.Errorf("%w %s: unsupported version %d; expected %d", ErrInvalidInvocation, path, inv.Version, FormatVersion)
+	}
+
+	if err := inv.Message.Validate(); err != nil {
+		return Invocation{}, fmt.Errorf("%w %s: %v", ErrInvalidInvocation, path, err)
+	}
+
+	return inv, nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package replay
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+func TestWriteRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "invocation.json")
+
+	msg := teams.Message{
+		Title: "Backup failed",
+		Text:  "exit status 1",
+		Facts: []teams.Fact{{Title: "Host", Value: "web01"}},
+	}
+	opts := teams.CardOptions{Trailer: "trailer", ConvertEOL: true, TitleColor: "attention"}
+
+	inv := Invocation{
+		Flags:      map[string]string{"retries": "2"},
+		WebhookURL: "https://example.webhook.office.com/webhookb2/x",
+		Message:    msg,
+		Card:       NewCard(msg, opts),
+	}
+
+	if err := Write(path, inv); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("invocation file mode = %o, want 600", perm)
+	}
+
+	got, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(got.Message, msg) || got.WebhookURL != inv.WebhookURL || got.Flags["retries"] != "2" {
+		t.Errorf("Read() = %+v, want %+v", got, inv)
+	}
+
+	replayed := got.Card.Options()
+	if replayed.Trailer != "trailer" || !replayed.ConvertEOL || replayed.TitleColor != "attention" {
+		t.Errorf("Options() = %+v", replayed)
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	for _, content := range []string{"{", `{"version": 99}`, `{"version": 1, "message": {}}`} {
+		path := filepath.Join(t.TempDir(), "invocation.json")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := Read(path); !errors.Is(err, ErrInvalidInvocation) {
+			t.Errorf("Read(%q) error = %v, want ErrInvalidInvocation", content, err)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package report converts CSV reports (e.g., nightly inventory or compliance
+reports) into Microsoft Teams messages: a summary of the report followed by
+one message for each page of rows.
+
+Reports with two columns are listed as facts (the first column giving the
+title of each fact); other reports are listed as tables.
+*/
+package report
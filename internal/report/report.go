@@ -0,0 +1,176 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// maxReportSize is the maximum size in bytes of a CSV report.
+const maxReportSize int64 = 16 * 1024 * 1024
+
+// utf8BOM is the byte order mark prepended to CSV files by some spreadsheet
+// applications.
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// emptyValue is displayed in place of empty values listed as facts, which
+// must not be empty.
+const emptyValue string = "(empty)"
+
+// ErrMissingHeader indicates that a CSV report provides no header row.
+var ErrMissingHeader = errors.New("missing header row")
+
+// Report is the content of a CSV report.
+type Report struct {
+
+	// Name is the base name of the report file.
+	Name string
+
+	// Columns are the column headings given by the first row of the report.
+	Columns []string
+
+	// Rows are the cell values of the remaining rows.
+	Rows [][]string
+}
+
+// ReadFile reads the CSV report from the given file.
+func ReadFile(path string) (Report, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return Report{}, err
+	}
+	defer func() { _ = f.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxReportSize+1))
+	switch {
+	case err != nil:
+		return Report{}, fmt.Errorf("failed to read %s: %w", path, err)
+	case int64(len(data)) > maxReportSize:
+		return Report{}, fmt.Errorf("%s exceeds %d bytes", path, maxReportSize)
+	}
+
+	r, err := Parse(filepath.Base(path), data)
+	if err != nil {
+		return Report{}, fmt.Errorf("invalid CSV report %s: %w", path, err)
+	}
+
+	return r, nil
+}
+
+// Parse parses the given CSV report content. The first row provides the
+// column headings. Rows need not have the same number of values as the
+// heading row.
+func Parse(name string, data []byte) (Report, error) {
+	cr := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, utf8BOM)))
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return Report{}, err
+	}
+
+	if len(records) == 0 {
+		return Report{}, ErrMissingHeader
+	}
+
+	return Report{Name: name, Columns: records[0], Rows: records[1:]}, nil
+}
+
+// Pages returns the number of pages listing the rows of the report, given
+// the maximum number of rows listed on each page.
+func (r Report) Pages(rowsPerPage int) int {
+	if rowsPerPage < 1 {
+		return 0
+	}
+
+	return (len(r.Rows) + rowsPerPage - 1) / rowsPerPage
+}
+
+// Summary returns the text describing the report, used as the message of
+// the summary if none is specified.
+func (r Report) Summary(rowsPerPage int) string {
+	return fmt.Sprintf(
+		"Report **%s** lists %d row(s) on %d card(s).",
+		r.Name,
+		len(r.Rows),
+		r.Pages(rowsPerPage),
+	)
+}
+
+// SummaryFacts returns the facts describing the report, added to the
+// summary message.
+func (r Report) SummaryFacts(rowsPerPage int) []teams.Fact {
+	return []teams.Fact{
+		{Title: "Report", Value: r.Name},
+		{Title: "Rows", Value: strconv.Itoa(len(r.Rows))},
+		{Title: "Columns", Value: strings.Join(r.Columns, ", ")},
+		{Title: "Cards", Value: strconv.Itoa(r.Pages(rowsPerPage))},
+	}
+}
+
+// PageMessages returns a message listing each page of rows of the report.
+// Each message is titled with the given title (or the report name if not
+// specified) along with the page number.
+func (r Report) PageMessages(title string, sender string, rowsPerPage int) []teams.Message {
+	pages := r.Pages(rowsPerPage)
+	if title == "" {
+		title = r.Name
+	}
+
+	msgs := make([]teams.Message, 0, pages)
+	for page := 0; page < pages; page++ {
+		first := page * rowsPerPage
+		last := first + rowsPerPage
+		if last > len(r.Rows) {
+			last = len(r.Rows)
+		}
+		rows := r.Rows[first:last]
+
+		msg := teams.Message{
+			Title:  fmt.Sprintf("%s (%d of %d)", title, page+1, pages),
+			Text:   fmt.Sprintf("Rows %d to %d of %d.", first+1, last, len(r.Rows)),
+			Sender: sender,
+		}
+
+		switch len(r.Columns) {
+		case 2:
+			msg.Text = fmt.Sprintf("%s: %s (rows %d to %d of %d).", r.Columns[0], r.Columns[1], first+1, last, len(r.Rows))
+			for _, row := range rows {
+				msg.Facts = append(msg.Facts, teams.Fact{Title: cell(row, 0), Value: cell(row, 1)})
+			}
+
+		default:
+			msg.Tables = []teams.Table{{Columns: r.Columns, Rows: rows}}
+		}
+
+		msgs = append(msgs, msg)
+	}
+
+	return msgs
+}
+
+// cell returns the value of the given column of a row, or emptyValue if the
+// value is empty or the row has fewer values.
+func cell(row []string, column int) string {
+	if column < len(row) && row[column] != "" {
+		return row[column]
+	}
+
+	return emptyValue
+}
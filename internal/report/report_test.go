@@ -0,0 +1,67 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package report
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	r, err := Parse("hosts.csv", []byte("\xef\xbb\xbfhost,os,owner\nweb1, linux,ops\nweb2,windows\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := len(r.Columns); got != 3 || r.Columns[0] != "host" {
+		t.Errorf("got columns %q; want header row without BOM", r.Columns)
+	}
+
+	if got := len(r.Rows); got != 2 {
+		t.Fatalf("got %d rows; want 2", got)
+	}
+
+	if r.Rows[0][1] != "linux" {
+		t.Errorf("got value %q; want leading space trimmed", r.Rows[0][1])
+	}
+
+	if _, err := Parse("empty.csv", nil); !errors.Is(err, ErrMissingHeader) {
+		t.Errorf("got error %v for empty report; want %v", err, ErrMissingHeader)
+	}
+}
+
+func TestPageMessages(t *testing.T) {
+	r := Report{Name: "hosts.csv", Columns: []string{"host", "os", "owner"}}
+	for i := 0; i < 45; i++ {
+		r.Rows = append(r.Rows, []string{"host", "linux", "ops"})
+	}
+
+	msgs := r.PageMessages("Inventory", "", 20)
+	if len(msgs) != 3 || r.Pages(20) != 3 {
+		t.Fatalf("got %d pages; want 3", len(msgs))
+	}
+
+	if want := "Inventory (3 of 3)"; msgs[2].Title != want {
+		t.Errorf("got title %q; want %q", msgs[2].Title, want)
+	}
+
+	if got := len(msgs[2].Tables[0].Rows); got != 5 {
+		t.Errorf("got %d rows on last page; want 5", got)
+	}
+
+	pairs := Report{Name: "owners.csv", Columns: []string{"host", "owner"}, Rows: [][]string{{"web1", "ops"}, {"web2"}}}
+	msgs = pairs.PageMessages("", "", 20)
+	switch {
+	case len(msgs) != 1:
+		t.Fatalf("got %d pages; want 1", len(msgs))
+	case len(msgs[0].Facts) != 2 || len(msgs[0].Tables) != 0:
+		t.Errorf("got %d facts and %d tables; want two column report listed as facts", len(msgs[0].Facts), len(msgs[0].Tables))
+	case msgs[0].Facts[1].Value != emptyValue:
+		t.Errorf("got value %q for missing cell; want %q", msgs[0].Facts[1].Value, emptyValue)
+	}
+}
@@ -0,0 +1,20 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package schema provides client-side validation of generated Microsoft Teams
+webhook payloads against bundled MessageCard and Adaptive Card JSON Schemas.
+
+Microsoft Teams webhook endpoints commonly reject malformed payloads with a
+generic HTTP 400 response. Validating a payload before submission allows
+problems to be reported locally along with the specific field at fault.
+
+The bundled schemas describe the subset of each card format supported by
+Microsoft Teams incoming webhooks. Only the JSON Schema keywords used by the
+bundled schemas are implemented.
+*/
+package schema
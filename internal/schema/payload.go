@@ -0,0 +1,61 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/atc0005/send2teams/internal/defaults"
+)
+
+// Names of the bundled schemas.
+const (
+	AdaptiveCard = "adaptive-card"
+	MessageCard  = "message-card"
+)
+
+// Load returns the bundled schema with the given name. A schema of the same
+// name within the schemas directory of the defaults override search path
+// (e.g., /etc/send2teams/schemas/adaptive-card.json) is used in place of the
+// bundled schema.
+func Load(name string) (*Schema, error) {
+	data, source, err := defaults.ReadFile(defaults.SchemasDir + "/" + name + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("%w: no bundled schema named %q: %v", ErrInvalidSchema, name, err)
+	}
+
+	s, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+
+	return s, nil
+}
+
+// ValidatePayload asserts that the given Microsoft Teams webhook payload
+// conforms to the bundled schema for its format. Payloads with a top-level
+// "@type" field are validated as legacy MessageCards, all others as
+// messages containing Adaptive Cards.
+func ValidatePayload(payload []byte) error {
+	name := AdaptiveCard
+
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &probe); err == nil {
+		if _, ok := probe["@type"]; ok {
+			name = MessageCard
+		}
+	}
+
+	s, err := Load(name)
+	if err != nil {
+		return err
+	}
+
+	return s.Validate(payload)
+}
@@ -0,0 +1,495 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// rootField is the field name used when reporting problems with the payload
+// as a whole.
+const rootField = "(root)"
+
+// ErrInvalidPayload indicates that a payload does not conform to a schema.
+var ErrInvalidPayload = errors.New("payload does not conform to schema")
+
+// ErrInvalidSchema indicates that a schema document could not be parsed or
+// uses unsupported constructs.
+var ErrInvalidSchema = errors.New("invalid schema")
+
+// FieldError describes a single schema violation for a payload field.
+type FieldError struct {
+
+	// Field is the path to the offending field (e.g.,
+	// "attachments[0].content.body[2].text").
+	Field string
+
+	// Message describes the problem with the field.
+	Message string
+}
+
+// Error returns the field path along with a description of the problem.
+func (fe FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", fe.Field, fe.Message)
+}
+
+// ValidationError is the collection of schema violations found for a
+// payload.
+type ValidationError struct {
+
+	// Schema is the title of the schema used for validation.
+	Schema string
+
+	// Fields is the collection of schema violations, ordered by field path.
+	Fields []FieldError
+}
+
+// Error returns a summary of all schema violations.
+func (ve *ValidationError) Error() string {
+	problems := make([]string, 0, len(ve.Fields))
+	for _, fe := range ve.Fields {
+		problems = append(problems, fe.Error())
+	}
+
+	return fmt.Sprintf(
+		"payload does not conform to the %s schema: %s",
+		ve.Schema,
+		strings.Join(problems, "; "),
+	)
+}
+
+// Unwrap allows callers to match a ValidationError against
+// ErrInvalidPayload.
+func (ve *ValidationError) Unwrap() error {
+	return ErrInvalidPayload
+}
+
+// Schema is a parsed JSON Schema document.
+type Schema struct {
+	root     map[string]interface{}
+	patterns map[string]*regexp.Regexp
+	title    string
+}
+
+// Parse parses the given JSON Schema document. Every regular expression used
+// by the schema is compiled up front so that problems with the schema itself
+// are reported here instead of during validation.
+func Parse(data []byte) (*Schema, error) {
+	var root map[string]interface{}
+	if err := decode(data, &root); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+
+	s := Schema{
+		root:     root,
+		patterns: make(map[string]*regexp.Regexp),
+		title:    "JSON",
+	}
+
+	if title, ok := root["title"].(string); ok && title != "" {
+		s.title = title
+	}
+
+	if err := s.compilePatterns(root); err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// Title returns the title of the schema.
+func (s *Schema) Title() string {
+	return s.title
+}
+
+// Validate asserts that the given JSON payload conforms to the schema. A
+// *ValidationError is returned listing every violation found.
+func (s *Schema) Validate(payload []byte) error {
+	var value interface{}
+	if err := decode(payload, &value); err != nil {
+		return &ValidationError{
+			Schema: s.title,
+			Fields: []FieldError{{Field: rootField, Message: fmt.Sprintf("invalid JSON: %v", err)}},
+		}
+	}
+
+	problems := s.check(s.root, value, "")
+	if len(problems) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Field < problems[j].Field
+	})
+
+	return &ValidationError{
+		Schema: s.title,
+		Fields: problems,
+	}
+}
+
+// decode parses JSON content, retaining numbers in their original form so
+// that integer and exact value comparisons are reliable.
+func decode(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+
+	if dec.More() {
+		return errors.New("unexpected content after JSON value")
+	}
+
+	return nil
+}
+
+// compilePatterns walks the given schema node and compiles every pattern
+// keyword value.
+func (s *Schema) compilePatterns(node interface{}) error {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if pattern, ok := n["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("%w: pattern %q: %v", ErrInvalidSchema, pattern, err)
+			}
+			s.patterns[pattern] = re
+		}
+
+		for key, child := range n {
+			// Enumerated and constant values are data, not schema nodes.
+			if key == "enum" || key == "const" {
+				continue
+			}
+			if err := s.compilePatterns(child); err != nil {
+				return err
+			}
+		}
+
+	case []interface{}:
+		for _, child := range n {
+			if err := s.compilePatterns(child); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolve returns the schema node referenced by the given local reference
+// (e.g., "#/definitions/TextBlock").
+func (s *Schema) resolve(ref string) (map[string]interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("%w: unsupported reference %q", ErrInvalidSchema, ref)
+	}
+
+	var node interface{} = s.root
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#"), "/") {
+		if token == "" {
+			continue
+		}
+
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%w: unresolvable reference %q", ErrInvalidSchema, ref)
+		}
+
+		if node, ok = m[token]; !ok {
+			return nil, fmt.Errorf("%w: unresolvable reference %q", ErrInvalidSchema, ref)
+		}
+	}
+
+	target, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: reference %q is not a schema", ErrInvalidSchema, ref)
+	}
+
+	return target, nil
+}
+
+// check validates the given value against the given schema node, returning
+// any violations found. The path identifies the value within the payload.
+func (s *Schema) check(node map[string]interface{}, value interface{}, path string) []FieldError {
+	var problems []FieldError
+
+	report := func(format string, args ...interface{}) {
+		field := path
+		if field == "" {
+			field = rootField
+		}
+		problems = append(problems, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if ref, ok := node["$ref"].(string); ok {
+		target, err := s.resolve(ref)
+		if err != nil {
+			report("%v", err)
+			return problems
+		}
+		problems = append(problems, s.check(target, value, path)...)
+	}
+
+	if types, ok := typeList(node["type"]); ok && !matchesType(value, types) {
+		report("expected %s, got %s", strings.Join(types, " or "), jsonType(value))
+
+		// Further checks are not meaningful for a value of the wrong type.
+		return problems
+	}
+
+	if want, ok := node["const"]; ok && !reflect.DeepEqual(value, want) {
+		report("expected %s, got %s", describe(want), describe(value))
+	}
+
+	if allowed, ok := node["enum"].([]interface{}); ok && !inList(value, allowed) {
+		choices := make([]string, 0, len(allowed))
+		for _, choice := range allowed {
+			choices = append(choices, describe(choice))
+		}
+		report("unsupported value %s; expected one of %s", describe(value), strings.Join(choices, ", "))
+	}
+
+	switch v := value.(type) {
+	case string:
+		if minLength, ok := intValue(node["minLength"]); ok && utf8.RuneCountInString(v) < minLength {
+			report("value %s shorter than minimum length of %d", describe(v), minLength)
+		}
+
+		if pattern, ok := node["pattern"].(string); ok && !s.patterns[pattern].MatchString(v) {
+			msg := fmt.Sprintf("unsupported value %s", describe(v))
+			if hint, ok := node["description"].(string); ok && hint != "" {
+				msg += "; expected " + hint
+			}
+			report("%s", msg)
+		}
+
+	case map[string]interface{}:
+		problems = append(problems, s.checkObject(node, v, path)...)
+
+	case []interface{}:
+		if minItems, ok := intValue(node["minItems"]); ok && len(v) < minItems {
+			report("expected at least %d item(s), got %d", minItems, len(v))
+		}
+
+		if items, ok := node["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				problems = append(problems, s.check(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+
+	if all, ok := node["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			if subSchema, ok := sub.(map[string]interface{}); ok {
+				problems = append(problems, s.check(subSchema, value, path)...)
+			}
+		}
+	}
+
+	if anyOf, ok := node["anyOf"].([]interface{}); ok {
+		problems = append(problems, s.checkAnyOf(anyOf, value, path)...)
+	}
+
+	if cond, ok := node["if"].(map[string]interface{}); ok {
+		branch := "else"
+		if len(s.check(cond, value, path)) == 0 {
+			branch = "then"
+		}
+		if subSchema, ok := node[branch].(map[string]interface{}); ok {
+			problems = append(problems, s.check(subSchema, value, path)...)
+		}
+	}
+
+	return problems
+}
+
+// checkObject applies the object specific keywords of the given schema node
+// to the given object.
+func (s *Schema) checkObject(node map[string]interface{}, obj map[string]interface{}, path string) []FieldError {
+	var problems []FieldError
+
+	if required, ok := node["required"].([]interface{}); ok {
+		for _, name := range required {
+			field, _ := name.(string)
+			if _, present := obj[field]; !present {
+				problems = append(problems, FieldError{
+					Field:   joinField(path, field),
+					Message: "required field missing",
+				})
+			}
+		}
+	}
+
+	properties, _ := node["properties"].(map[string]interface{})
+	for name, value := range obj {
+		if propSchema, ok := properties[name].(map[string]interface{}); ok {
+			problems = append(problems, s.check(propSchema, value, joinField(path, name))...)
+			continue
+		}
+
+		switch additional := node["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				problems = append(problems, FieldError{
+					Field:   joinField(path, name),
+					Message: "unknown field",
+				})
+			}
+		case map[string]interface{}:
+			problems = append(problems, s.check(additional, value, joinField(path, name))...)
+		}
+	}
+
+	return problems
+}
+
+// checkAnyOf asserts that the given value satisfies at least one of the
+// given schema nodes. If none are satisfied, the violations for the closest
+// match are returned.
+func (s *Schema) checkAnyOf(anyOf []interface{}, value interface{}, path string) []FieldError {
+	var closest []FieldError
+
+	for i, sub := range anyOf {
+		subSchema, ok := sub.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		problems := s.check(subSchema, value, path)
+		if len(problems) == 0 {
+			return nil
+		}
+
+		if i == 0 || len(problems) < len(closest) {
+			closest = problems
+		}
+	}
+
+	return closest
+}
+
+// joinField appends the given field name to the given path.
+func joinField(path string, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
+
+// typeList normalizes the value of a type keyword to a list of type names.
+func typeList(v interface{}) ([]string, bool) {
+	switch t := v.(type) {
+	case string:
+		return []string{t}, true
+	case []interface{}:
+		types := make([]string, 0, len(t))
+		for _, item := range t {
+			if name, ok := item.(string); ok {
+				types = append(types, name)
+			}
+		}
+		return types, len(types) > 0
+	default:
+		return nil, false
+	}
+}
+
+// matchesType indicates whether the given value is one of the given JSON
+// Schema types.
+func matchesType(value interface{}, types []string) bool {
+	actual := jsonType(value)
+
+	for _, t := range types {
+		switch {
+		case t == actual:
+			return true
+		case t == "number" && actual == "integer":
+			return true
+		}
+	}
+
+	return false
+}
+
+// jsonType returns the JSON Schema type name for the given decoded value.
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// intValue returns the given decoded schema keyword value as an int.
+func intValue(v interface{}) (int, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, false
+	}
+
+	i, err := n.Int64()
+	if err != nil {
+		return 0, false
+	}
+
+	return int(i), true
+}
+
+// inList indicates whether the given value is present in the given list of
+// values.
+func inList(value interface{}, list []interface{}) bool {
+	for _, item := range list {
+		if reflect.DeepEqual(value, item) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// describe returns a short representation of the given decoded value for
+// use in error messages.
+func describe(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		const maxLen = 40
+		if utf8.RuneCountInString(v) > maxLen {
+			v = string([]rune(v)[:maxLen]) + "..."
+		}
+		return fmt.Sprintf("%q", v)
+	case map[string]interface{}, []interface{}:
+		return jsonType(v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package schema
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidatePayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		fields  []string
+	}{
+		{
+			name:    "valid adaptive card",
+			payload: `{"type":"message","attachments":[{"contentType":"application/vnd.microsoft.card.adaptive","content":{"type":"AdaptiveCard","version":"1.5","body":[{"type":"TextBlock","text":"hello","color":"Good","wrap":true}],"msteams":{"width":"Full"}}}]}`,
+		},
+		{
+			name:    "invalid adaptive card element values",
+			payload: `{"type":"message","attachments":[{"contentType":"application/vnd.microsoft.card.adaptive","content":{"type":"AdaptiveCard","version":"1.5","body":[{"type":"TextBlock","text":"hello"},{"type":"Container","items":[{"type":"TextBlock","size":"huge"}]}]}}]}`,
+			fields: []string{
+				"attachments[0].content.body[1].items[0].size",
+				"attachments[0].content.body[1].items[0].text",
+			},
+		},
+		{
+			name:    "unsupported adaptive card action",
+			payload: `{"type":"message","attachments":[{"contentType":"application/vnd.microsoft.card.adaptive","content":{"type":"AdaptiveCard","version":"1.5","body":[],"actions":[{"type":"Action.Submit","title":"OK"}]}}]}`,
+			fields:  []string{"attachments[0].content.actions[0].type"},
+		},
+		{
+			name:    "valid message card",
+			payload: `{"@type":"MessageCard","@context":"https://schema.org/extensions","summary":"hello","themeColor":"#FF0000","sections":[{"facts":[{"name":"Host","value":"web01"}]}]}`,
+		},
+		{
+			name:    "invalid message card",
+			payload: `{"@type":"MessageCard","text":"hello","potentialAction":[{"@type":"OpenUri","name":"Docs","targets":[{"os":"linux","uri":"https://example.com"}]}]}`,
+			fields:  []string{"potentialAction[0].targets[0].os"},
+		},
+	}
+
+	for _, tt := range tests {
+		err := ValidatePayload([]byte(tt.payload))
+
+		if len(tt.fields) == 0 {
+			if err != nil {
+				t.Errorf("%s: ValidatePayload() error = %v; want nil", tt.name, err)
+			}
+			continue
+		}
+
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) || !errors.Is(err, ErrInvalidPayload) {
+			t.Errorf("%s: ValidatePayload() error = %v; want *ValidationError", tt.name, err)
+			continue
+		}
+
+		var got []string
+		for _, fe := range validationErr.Fields {
+			got = append(got, fe.Field)
+		}
+
+		if strings.Join(got, ",") != strings.Join(tt.fields, ",") {
+			t.Errorf("%s: ValidatePayload() fields = %q; want %q (%v)", tt.name, got, tt.fields, err)
+		}
+	}
+}
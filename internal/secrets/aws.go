@@ -0,0 +1,199 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/atc0005/send2teams/internal/aws"
+)
+
+// awsEndpoint returns the regional endpoint for the given AWS service.
+var awsEndpoint = func(service string, region string) string {
+	return fmt.Sprintf("https://%s.%s.amazonaws.com/", service, region)
+}
+
+// SSMParameter is a reference to a (String or SecureString) parameter
+// stored in AWS Systems Manager Parameter Store.
+//
+// Requests are authorized using the default AWS credential chain. The
+// region is taken from the parameter ARN if given, otherwise from the
+// default region.
+type SSMParameter struct {
+
+	// Name is the name (e.g., /ops/teams-webhook) or ARN of the parameter.
+	Name string
+}
+
+// AWSSecret is a reference to a secret stored in AWS Secrets Manager. The
+// secret string is used as-is.
+//
+// Requests are authorized using the default AWS credential chain. The
+// region is taken from the secret ARN if given, otherwise from the default
+// region.
+type AWSSecret struct {
+
+	// Name is the name or ARN of the secret.
+	Name string
+}
+
+// ParseSSMParameter parses a reference to a Parameter Store parameter.
+func ParseSSMParameter(ref string) (SSMParameter, error) {
+	if ref = strings.TrimSpace(ref); ref == "" {
+		return SSMParameter{}, fmt.Errorf("%w: parameter name not specified", ErrInvalidReference)
+	}
+
+	return SSMParameter{Name: ref}, nil
+}
+
+// ParseAWSSecret parses a reference to a Secrets Manager secret.
+func ParseAWSSecret(ref string) (AWSSecret, error) {
+	if ref = strings.TrimSpace(ref); ref == "" {
+		return AWSSecret{}, fmt.Errorf("%w: secret name not specified", ErrInvalidReference)
+	}
+
+	return AWSSecret{Name: ref}, nil
+}
+
+// String returns a description of the parameter.
+func (p SSMParameter) String() string {
+	return "SSM parameter " + p.Name
+}
+
+// String returns a description of the secret.
+func (s AWSSecret) String() string {
+	return "Secrets Manager secret " + s.Name
+}
+
+// Fetch retrieves the (decrypted) value of the parameter.
+func (p SSMParameter) Fetch(ctx context.Context, client *http.Client) (string, error) {
+	body, err := awsRequest(ctx, client, "ssm", "AmazonSSM.GetParameter", p.Name, map[string]interface{}{
+		"Name":           p.Name,
+		"WithDecryption": true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve %s: %w", p, err)
+	}
+
+	var resp struct {
+		Parameter struct {
+			Value string `json:"Value"`
+		} `json:"Parameter"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("failed to decode %s: %w", p, err)
+	}
+
+	return resp.Parameter.Value, nil
+}
+
+// Fetch retrieves the current value of the secret.
+func (s AWSSecret) Fetch(ctx context.Context, client *http.Client) (string, error) {
+	body, err := awsRequest(ctx, client, "secretsmanager", "secretsmanager.GetSecretValue", s.Name, map[string]interface{}{
+		"SecretId": s.Name,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve %s: %w", s, err)
+	}
+
+	var resp struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("failed to decode %s: %w", s, err)
+	}
+
+	if resp.SecretString == "" {
+		return "", fmt.Errorf("%s does not contain a secret string", s)
+	}
+
+	return resp.SecretString, nil
+}
+
+// awsRequest calls the given AWS JSON protocol API action, returning the
+// response body. The region is taken from the given resource name if it is
+// an ARN.
+func awsRequest(ctx context.Context, client *http.Client, service string, target string, name string, params map[string]interface{}) ([]byte, error) {
+	creds, err := aws.DefaultCredentials(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	region := regionFromARN(name)
+	if region == "" {
+		if region, err = aws.DefaultRegion(ctx, client); err != nil {
+			return nil, err
+		}
+	}
+
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, awsEndpoint(service, region), bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", target)
+	aws.SignRequest(req, aws.HashHex(payload), creds, region, service, time.Now())
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return readResponse(resp, awsErrorMessage)
+}
+
+// regionFromARN returns the region of the given ARN, or an empty string if
+// the name is not an ARN.
+func regionFromARN(name string) string {
+	// arn:partition:service:region:account-id:resource
+	parts := strings.SplitN(name, ":", 6)
+	if len(parts) < 6 || parts[0] != "arn" {
+		return ""
+	}
+
+	return parts[3]
+}
+
+// awsErrorMessage returns the error type and message from an AWS JSON
+// protocol error response body.
+func awsErrorMessage(body []byte) string {
+	var resp struct {
+		Type         string `json:"__type"`
+		Message      string `json:"message"`
+		MessageUpper string `json:"Message"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return ""
+	}
+
+	// The type may be qualified with a namespace (e.g.,
+	// com.amazonaws.ssm#ParameterNotFound).
+	errType := resp.Type
+	if i := strings.LastIndex(errType, "#"); i >= 0 {
+		errType = errType[i+1:]
+	}
+
+	msg := resp.Message
+	if msg == "" {
+		msg = resp.MessageUpper
+	}
+
+	return strings.TrimSpace(errType + " " + msg)
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAWSFetch(t *testing.T) {
+	var gotRegion string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Authorization"), "AKID/") {
+			http.Error(w, "unsigned request", http.StatusForbidden)
+			return
+		}
+
+		var params map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&params)
+
+		switch r.Header.Get("X-Amz-Target") {
+		case "AmazonSSM.GetParameter":
+			if params["Name"] != "/ops/teams-webhook" || params["WithDecryption"] != true {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"__type":"ParameterNotFound","message":"not found"}`)
+				return
+			}
+			fmt.Fprint(w, `{"Parameter":{"Value":"https://example.com/ssm"}}`)
+
+		case "secretsmanager.GetSecretValue":
+			if !strings.HasSuffix(params["SecretId"].(string), "teams-webhook") {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"__type":"com.amazonaws.secretsmanager#ResourceNotFoundException","Message":"no such secret"}`)
+				return
+			}
+			fmt.Fprint(w, `{"SecretString":"https://example.com/secret"}`)
+		}
+	}))
+	defer srv.Close()
+
+	orig := awsEndpoint
+	awsEndpoint = func(_ string, region string) string {
+		gotRegion = region
+		return srv.URL + "/"
+	}
+	defer func() { awsEndpoint = orig }()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "SECRET")
+	t.Setenv("AWS_REGION", "us-west-2")
+
+	ctx := context.Background()
+
+	got, err := SSMParameter{Name: "/ops/teams-webhook"}.Fetch(ctx, srv.Client())
+	if err != nil || got != "https://example.com/ssm" {
+		t.Errorf("SSM: got %q, error %v", got, err)
+	}
+	if gotRegion != "us-west-2" {
+		t.Errorf("SSM: got region %q", gotRegion)
+	}
+
+	got, err = AWSSecret{Name: "arn:aws:secretsmanager:eu-central-1:123456789012:secret:teams-webhook"}.Fetch(ctx, srv.Client())
+	if err != nil || got != "https://example.com/secret" {
+		t.Errorf("Secrets Manager: got %q, error %v", got, err)
+	}
+	if gotRegion != "eu-central-1" {
+		t.Errorf("Secrets Manager: expected region from ARN, got %q", gotRegion)
+	}
+
+	_, err = SSMParameter{Name: "/missing"}.Fetch(ctx, srv.Client())
+	if err == nil || !strings.Contains(err.Error(), "ParameterNotFound not found") {
+		t.Errorf("expected ParameterNotFound error, got %v", err)
+	}
+
+	_, err = AWSSecret{Name: "missing"}.Fetch(ctx, srv.Client())
+	if err == nil || !strings.Contains(err.Error(), "ResourceNotFoundException no such secret") {
+		t.Errorf("expected ResourceNotFoundException error, got %v", err)
+	}
+}
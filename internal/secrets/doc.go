@@ -0,0 +1,13 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package secrets retrieves secrets (e.g., webhook URLs) from cloud secret
+stores at runtime so that they are not stored on disk alongside the scripts
+which use them.
+*/
+package secrets
@@ -0,0 +1,259 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// keyVaultAPIVersion is the Azure Key Vault REST API version requested when
+// retrieving secrets.
+const keyVaultAPIVersion string = "7.4"
+
+// keyVaultDomain is the domain of Key Vault instances in the Azure public
+// cloud, used when a reference specifies only the vault name.
+const keyVaultDomain string = "vault.azure.net"
+
+// Managed identity endpoints and API versions. The instance metadata
+// service endpoint is used by virtual machines; the IDENTITY_ENDPOINT
+// environment variable is set by App Service, Functions and Automation.
+const (
+	imdsAPIVersion     string = "2018-02-01"
+	identityAPIVersion string = "2019-08-01"
+)
+
+// imdsEndpoint is the Azure instance metadata service token endpoint.
+var imdsEndpoint = "http://169.254.169.254/metadata/identity/oauth2/token"
+
+// loginEndpoint is the Microsoft identity platform endpoint used to request
+// tokens with client credentials.
+var loginEndpoint = "https://login.microsoftonline.com"
+
+// KeyVault is a reference to a secret stored in Azure Key Vault.
+//
+// Requests are authorized using client credentials if the AZURE_TENANT_ID,
+// AZURE_CLIENT_ID and AZURE_CLIENT_SECRET environment variables are set,
+// and otherwise using the managed identity of the host. The AZURE_CLIENT_ID
+// environment variable selects a user-assigned managed identity.
+type KeyVault struct {
+
+	// Host is the host name of the vault (e.g., example.vault.azure.net).
+	Host string
+
+	// Secret is the name of the secret.
+	Secret string
+
+	// Version is the (optional) version of the secret. The current version
+	// is retrieved if not specified.
+	Version string
+}
+
+// ParseKeyVault parses a reference in the form of "vault-name/secret-name"
+// with an optional trailing "/version". The vault may be given as a host
+// name (e.g., example.vault.azure.cn) for sovereign clouds.
+func ParseKeyVault(ref string) (KeyVault, error) {
+	parts := strings.Split(strings.TrimSpace(ref), "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return KeyVault{}, fmt.Errorf("%w %q: expected vault-name/secret-name", ErrInvalidReference, ref)
+	}
+
+	for _, part := range parts {
+		if part == "" {
+			return KeyVault{}, fmt.Errorf("%w %q: expected vault-name/secret-name", ErrInvalidReference, ref)
+		}
+	}
+
+	kv := KeyVault{Host: parts[0], Secret: parts[1]}
+	if len(parts) == 3 {
+		kv.Version = parts[2]
+	}
+
+	if !strings.Contains(kv.Host, ".") {
+		kv.Host += "." + keyVaultDomain
+	}
+
+	return kv, nil
+}
+
+// String returns a description of the secret.
+func (k KeyVault) String() string {
+	s := "Key Vault secret " + k.Host + "/" + k.Secret
+	if k.Version != "" {
+		s += "/" + k.Version
+	}
+
+	return s
+}
+
+// Fetch retrieves the value of the secret.
+func (k KeyVault) Fetch(ctx context.Context, client *http.Client) (string, error) {
+	token, err := azureToken(ctx, client, k.resource())
+	if err != nil {
+		return "", fmt.Errorf("failed to authenticate to Azure Key Vault: %w", err)
+	}
+
+	secretPath := "/secrets/" + url.PathEscape(k.Secret)
+	if k.Version != "" {
+		secretPath += "/" + url.PathEscape(k.Version)
+	}
+
+	secretURL := fmt.Sprintf("https://%s%s?api-version=%s", k.Host, secretPath, keyVaultAPIVersion)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, secretURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve %s: %w", k, err)
+	}
+
+	body, err := readResponse(resp, azureErrorMessage)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve %s: %w", k, err)
+	}
+
+	var secret struct {
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return "", fmt.Errorf("failed to decode %s: %w", k, err)
+	}
+
+	return secret.Value, nil
+}
+
+// resource returns the resource (audience) of the token used to access the
+// vault: the vault domain (e.g., https://vault.azure.net) for the cloud
+// hosting the vault.
+func (k KeyVault) resource() string {
+	domain := k.Host
+	if i := strings.Index(domain, "."); i >= 0 {
+		domain = domain[i+1:]
+	}
+
+	return "https://" + domain
+}
+
+// azureToken requests an access token for the given resource using client
+// credentials if specified via the environment, otherwise using the managed
+// identity of the host.
+func azureToken(ctx context.Context, client *http.Client, resource string) (string, error) {
+	tenantID := os.Getenv("AZURE_TENANT_ID")
+	clientID := os.Getenv("AZURE_CLIENT_ID")
+	clientSecret := os.Getenv("AZURE_CLIENT_SECRET")
+
+	var req *http.Request
+	var err error
+
+	useClientCredentials := tenantID != "" && clientID != "" && clientSecret != ""
+
+	switch {
+	case useClientCredentials:
+		form := url.Values{
+			"grant_type":    {"client_credentials"},
+			"client_id":     {clientID},
+			"client_secret": {clientSecret},
+			"scope":         {resource + "/.default"},
+		}
+
+		tokenURL := fmt.Sprintf("%s/%s/oauth2/v2.0/token", loginEndpoint, url.PathEscape(tenantID))
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	case os.Getenv("IDENTITY_ENDPOINT") != "" && os.Getenv("IDENTITY_HEADER") != "":
+		query := url.Values{"api-version": {identityAPIVersion}, "resource": {resource}}
+		if clientID != "" {
+			query.Set("client_id", clientID)
+		}
+
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, os.Getenv("IDENTITY_ENDPOINT")+"?"+query.Encode(), nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("X-Identity-Header", os.Getenv("IDENTITY_HEADER"))
+
+	default:
+		query := url.Values{"api-version": {imdsAPIVersion}, "resource": {resource}}
+		if clientID != "" {
+			query.Set("client_id", clientID)
+		}
+
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, imdsEndpoint+"?"+query.Encode(), nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Metadata", "true")
+	}
+
+	resp, err := client.Do(req)
+	switch {
+	case err != nil && useClientCredentials:
+		return "", fmt.Errorf("failed to request access token: %w", err)
+
+	case err != nil:
+		return "", fmt.Errorf(
+			"%w: managed identity unavailable and AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_CLIENT_SECRET not set: %v",
+			ErrMissingCredentials, err,
+		)
+	}
+
+	body, err := readResponse(resp, azureErrorMessage)
+	if err != nil {
+		return "", err
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil || token.AccessToken == "" {
+		return "", fmt.Errorf("failed to decode access token response")
+	}
+
+	return token.AccessToken, nil
+}
+
+// azureErrorMessage returns the message from an Azure error response body
+// (Key Vault or Microsoft identity platform format).
+func azureErrorMessage(body []byte) string {
+	var resp struct {
+		Error json.RawMessage `json:"error"`
+
+		// Microsoft identity platform responses describe the error
+		// separately.
+		Description string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return ""
+	}
+
+	if resp.Description != "" {
+		return resp.Description
+	}
+
+	var detail struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(resp.Error, &detail); err == nil && detail.Message != "" {
+		return strings.TrimSpace(detail.Code + " " + detail.Message)
+	}
+
+	return ""
+}
@@ -0,0 +1,111 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseKeyVault(t *testing.T) {
+	tests := map[string]struct {
+		ref  string
+		want KeyVault
+		err  bool
+	}{
+		"name only": {
+			ref:  "ops-vault/teams-webhook",
+			want: KeyVault{Host: "ops-vault.vault.azure.net", Secret: "teams-webhook"},
+		},
+		"with version": {
+			ref:  "ops-vault/teams-webhook/0123abcd",
+			want: KeyVault{Host: "ops-vault.vault.azure.net", Secret: "teams-webhook", Version: "0123abcd"},
+		},
+		"sovereign cloud host": {
+			ref:  "ops-vault.vault.azure.cn/teams-webhook",
+			want: KeyVault{Host: "ops-vault.vault.azure.cn", Secret: "teams-webhook"},
+		},
+		"missing secret":  {ref: "ops-vault", err: true},
+		"empty secret":    {ref: "ops-vault/", err: true},
+		"too many values": {ref: "a/b/c/d", err: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseKeyVault(tt.ref)
+			switch {
+			case tt.err:
+				if !errors.Is(err, ErrInvalidReference) {
+					t.Fatalf("expected ErrInvalidReference, got %v", err)
+				}
+			case err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case got != tt.want:
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	kv, _ := ParseKeyVault("ops-vault.vault.azure.cn/teams-webhook")
+	if got := kv.resource(); got != "https://vault.azure.cn" {
+		t.Errorf("resource: got %q", got)
+	}
+}
+
+func TestKeyVaultFetch(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Identity-Header") != "identity-secret" {
+			http.Error(w, "missing identity header", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"access_token":"token-123"}`)
+	})
+	mux.HandleFunc("/secrets/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token-123" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if r.URL.Path != "/secrets/teams-webhook" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":{"code":"SecretNotFound","message":"A secret with that name was not found."}}`)
+			return
+		}
+		fmt.Fprint(w, `{"value":"https://example.webhook.office.com/webhookb2/x"}`)
+	})
+
+	srv := httptest.NewTLSServer(mux)
+	defer srv.Close()
+
+	t.Setenv("AZURE_TENANT_ID", "")
+	t.Setenv("AZURE_CLIENT_SECRET", "")
+	t.Setenv("AZURE_CLIENT_ID", "")
+	t.Setenv("IDENTITY_ENDPOINT", srv.URL+"/token")
+	t.Setenv("IDENTITY_HEADER", "identity-secret")
+
+	host := strings.TrimPrefix(srv.URL, "https://")
+
+	got, err := KeyVault{Host: host, Secret: "teams-webhook"}.Fetch(context.Background(), srv.Client())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "https://example.webhook.office.com/webhookb2/x" {
+		t.Errorf("got %q", got)
+	}
+
+	_, err = KeyVault{Host: host, Secret: "missing"}.Fetch(context.Background(), srv.Client())
+	if err == nil || !strings.Contains(err.Error(), "SecretNotFound") {
+		t.Errorf("expected SecretNotFound error, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrInvalidReference indicates that a secret reference is malformed.
+var ErrInvalidReference = errors.New("invalid secret reference")
+
+// ErrMissingCredentials indicates that credentials for a secret store could
+// not be found.
+var ErrMissingCredentials = errors.New("credentials not found")
+
+// Source is a secret which may be retrieved from a secret store.
+type Source interface {
+
+	// Fetch retrieves the value of the secret.
+	Fetch(ctx context.Context, client *http.Client) (string, error)
+
+	// String returns a description of the secret.
+	String() string
+}
+
+// maxResponseBytes is the maximum number of bytes read from a secret store
+// response.
+const maxResponseBytes int64 = 1 << 20
+
+// readResponse returns the body of the given response, or an error
+// describing the failure (using the given function to extract a message
+// from an error response body) if the request was unsuccessful.
+func readResponse(resp *http.Response, describe func([]byte) string) ([]byte, error) {
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if msg := strings.TrimSpace(describe(body)); msg != "" {
+			return nil, fmt.Errorf("request failed: %s: %s", resp.Status, msg)
+		}
+		return nil, fmt.Errorf("request failed: %s", resp.Status)
+	}
+
+	return body, nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package serve
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// clientTimeout is the maximum amount of time allowed for a running serve
+// instance to respond to a command.
+const clientTimeout time.Duration = 5 * time.Second
+
+// ErrCommandRejected indicates that a running serve instance rejected a
+// command.
+var ErrCommandRejected = errors.New("command rejected")
+
+// Client submits commands to a running serve instance.
+type Client struct {
+	conn    net.Conn
+	encoder *json.Encoder
+	decoder *json.Decoder
+}
+
+// Dial connects to the serve instance listening on the given unix domain
+// socket.
+func Dial(path string) (*Client, error) {
+	conn, err := net.DialTimeout("unix", path, clientTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		conn:    conn,
+		encoder: json.NewEncoder(conn),
+		decoder: json.NewDecoder(conn),
+	}, nil
+}
+
+// Close closes the connection to the serve instance.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// do submits the given request and returns the response.
+func (c *Client) do(req Request) (Response, error) {
+	_ = c.conn.SetDeadline(time.Now().Add(clientTimeout))
+
+	if err := c.encoder.Encode(req); err != nil {
+		return Response{}, err
+	}
+
+	var resp Response
+	if err := c.decoder.Decode(&resp); err != nil {
+		return Response{}, err
+	}
+
+	if resp.Status == StatusRejected {
+		return resp, fmt.Errorf("%w: %s", ErrCommandRejected, resp.Error)
+	}
+
+	return resp, nil
+}
+
+// Status retrieves a snapshot of the delivery queue.
+func (c *Client) Status() (QueueStatus, error) {
+	resp, err := c.do(Request{Command: CommandStatus})
+	if err != nil {
+		return QueueStatus{}, err
+	}
+
+	if resp.Queue == nil {
+		return QueueStatus{}, fmt.Errorf("response did not include queue status")
+	}
+
+	return *resp.Queue, nil
+}
+
+// Retry requests that the failed message with the given receipt ID is
+// queued for delivery again.
+func (c *Client) Retry(receiptID string) error {
+	_, err := c.do(Request{Command: CommandRetry, ReceiptID: receiptID})
+	return err
+}
+
+// Discard requests that the queued or failed message with the given receipt
+// ID is dropped.
+func (c *Client) Discard(receiptID string) error {
+	_, err := c.do(Request{Command: CommandDiscard, ReceiptID: receiptID})
+	return err
+}
@@ -0,0 +1,21 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package serve provides a long-lived relay which accepts messages from local
+clients and submits them to Microsoft Teams on their behalf.
+
+Clients connect to a unix domain socket and write one or more JSON encoded
+messages (e.g., `{"title": "Backup", "text": "Backup complete"}`). Each
+message is acknowledged with a single line JSON response indicating whether
+the message was queued for delivery. Access to the relay is controlled by the
+filesystem permissions applied to the socket.
+
+Messages may also be submitted via a versioned HTTP API (POST /v1/messages),
+which describes the supported formats and limits via GET /v1/capabilities.
+*/
+package serve
@@ -0,0 +1,164 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package serve
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// fifoSource describes the named pipe in log messages.
+const fifoSource string = "named pipe"
+
+// ErrFIFOUnsupported indicates that named pipes are not supported on this
+// platform.
+var ErrFIFOUnsupported = errors.New("named pipes are not supported on this platform")
+
+// FIFO is a named pipe from which serve mode reads messages.
+type FIFO struct {
+	file    *os.File
+	path    string
+	created bool
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// Path returns the path of the named pipe.
+func (f *FIFO) Path() string {
+	return f.path
+}
+
+// Read reads from the named pipe.
+func (f *FIFO) Read(p []byte) (int, error) {
+	return f.file.Read(p)
+}
+
+// Close closes the named pipe, removing it if it was created by OpenFIFO.
+// Close may be called multiple times.
+func (f *FIFO) Close() error {
+	f.closeOnce.Do(func() {
+		f.closeErr = f.file.Close()
+		if f.created {
+			if err := os.Remove(f.path); err != nil && f.closeErr == nil {
+				f.closeErr = err
+			}
+		}
+	})
+
+	return f.closeErr
+}
+
+// readFIFO queues a message for each line (or JSON encoded message) read
+// from the given named pipe until it is closed. Lines starting with { are
+// read as a JSON encoded Request, which may span multiple lines; all other
+// (non-empty) lines are delivered as the text of a message. There is no
+// client to respond to, so rejected messages are only logged.
+func (s *Server) readFIFO(ctx context.Context, r io.Reader) {
+	reader := bufio.NewReader(r)
+
+	var pending []byte
+	for {
+		line, tooLong, err := readLine(reader, maxMessageSize)
+		if err != nil {
+			if ctx.Err() == nil && !s.cfg.SilentOutput {
+				log.Printf("ERROR: Stopped reading messages from %s: %v", fifoSource, err)
+			}
+			return
+		}
+
+		if tooLong {
+			if !s.cfg.SilentOutput {
+				log.Printf("Rejected message from %s: message exceeds %d bytes", fifoSource, maxMessageSize)
+			}
+			pending = nil
+			continue
+		}
+
+		if len(pending) == 0 {
+			line = bytes.TrimSpace(line)
+			if len(line) == 0 {
+				continue
+			}
+
+			if line[0] != '{' {
+				s.accept(Request{Message: teams.Message{Text: string(line)}}, fifoSource)
+				continue
+			}
+		}
+
+		pending = append(pending, line...)
+		pending = append(pending, '\n')
+
+		if len(pending) > int(maxMessageSize) {
+			if !s.cfg.SilentOutput {
+				log.Printf("Rejected message from %s: message exceeds %d bytes", fifoSource, maxMessageSize)
+			}
+			pending = nil
+			continue
+		}
+
+		var req Request
+		err = json.NewDecoder(bytes.NewReader(pending)).Decode(&req)
+		switch {
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			// The JSON encoded message continues on the next line.
+			continue
+
+		case err != nil:
+			if !s.cfg.SilentOutput {
+				log.Printf("Rejected message from %s: %v", fifoSource, err)
+			}
+
+		case req.Command != "":
+			if !s.cfg.SilentOutput {
+				log.Printf("Rejected command %q from %s: commands are only supported via the unix domain socket", req.Command, fifoSource)
+			}
+
+		default:
+			s.accept(req, fifoSource)
+		}
+
+		pending = nil
+	}
+}
+
+// readLine returns the next line from the given reader without the line
+// ending, indicating whether the line was discarded for exceeding the given
+// number of bytes.
+func readLine(reader *bufio.Reader, limit int64) ([]byte, bool, error) {
+	var line []byte
+	tooLong := false
+
+	for {
+		chunk, isPrefix, err := reader.ReadLine()
+		if err != nil {
+			return nil, false, err
+		}
+
+		if !tooLong {
+			line = append(line, chunk...)
+			if int64(len(line)) > limit {
+				line, tooLong = nil, true
+			}
+		}
+
+		if !isPrefix {
+			return line, tooLong, nil
+		}
+	}
+}
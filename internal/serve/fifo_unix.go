@@ -0,0 +1,59 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+//go:build !windows
+
+package serve
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// OpenFIFO opens the named pipe at the given path for reading, creating it
+// with the specified filesystem permissions if it does not exist. An error
+// is returned if the path refers to something other than a named pipe.
+//
+// The named pipe is also opened for writing so that reads do not report
+// end-of-file each time the last writer closes it.
+func OpenFIFO(path string, mode os.FileMode) (*FIFO, error) {
+	created := false
+
+	info, err := os.Lstat(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		if err := syscall.Mkfifo(path, uint32(mode.Perm())); err != nil {
+			return nil, fmt.Errorf("failed to create named pipe %s: %w", path, err)
+		}
+		created = true
+
+		// The permissions requested when creating the named pipe are
+		// subject to the umask.
+		if err := os.Chmod(path, mode); err != nil {
+			_ = os.Remove(path)
+			return nil, fmt.Errorf("failed to apply mode %o to named pipe %s: %w", mode, path, err)
+		}
+
+	case err != nil:
+		return nil, fmt.Errorf("failed to check named pipe path %s: %w", path, err)
+
+	case info.Mode()&os.ModeNamedPipe == 0:
+		return nil, fmt.Errorf("refusing to read from non-FIFO file %s", path)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		if created {
+			_ = os.Remove(path)
+		}
+		return nil, fmt.Errorf("failed to open named pipe %s: %w", path, err)
+	}
+
+	return &FIFO{file: file, path: path, created: created}, nil
+}
@@ -0,0 +1,21 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+//go:build windows
+
+package serve
+
+import (
+	"fmt"
+	"os"
+)
+
+// OpenFIFO is not supported on Windows; an error wrapping
+// ErrFIFOUnsupported is always returned.
+func OpenFIFO(path string, _ os.FileMode) (*FIFO, error) {
+	return nil, fmt.Errorf("%s: %w", path, ErrFIFOUnsupported)
+}
@@ -0,0 +1,123 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package serve
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/atc0005/send2teams/internal/config"
+	"github.com/atc0005/send2teams/internal/followup"
+)
+
+// followUpInterval is how often pending follow-ups are checked.
+const followUpInterval time.Duration = 15 * time.Second
+
+// parseFollowUp validates the follow-up settings of the given request,
+// returning the time after which a follow-up is posted (zero if none).
+func (s *Server) parseFollowUp(req Request) (time.Duration, error) {
+	var after time.Duration
+	if req.FollowUpAfter != "" {
+		var err error
+		if after, err = time.ParseDuration(req.FollowUpAfter); err != nil {
+			return 0, fmt.Errorf("invalid follow-up time %q: %w", req.FollowUpAfter, err)
+		}
+	}
+
+	if err := config.ValidateFollowUp(req.CorrelationID, after, s.followUpMessage(req), req.Resolved); err != nil {
+		return 0, err
+	}
+
+	return after, nil
+}
+
+// updateFollowUp records the follow-up (if any) requested by the given
+// accepted request or cancels the pending follow-up for a resolved message.
+func (s *Server) updateFollowUp(req Request, receiptID string, after time.Duration) {
+	switch {
+	case req.Resolved:
+		resolved, err := s.followUps.Resolve(req.CorrelationID)
+		switch {
+		case err != nil && !s.cfg.SilentOutput:
+			log.Printf("WARNING: Failed to cancel follow-up for correlation ID %q: %v", req.CorrelationID, err)
+		case resolved && s.cfg.VerboseOutput:
+			log.Printf("Cancelled pending follow-up for correlation ID %q", req.CorrelationID)
+		}
+
+	case after > 0:
+		now := time.Now().UTC()
+		f := followup.FollowUp{
+			CorrelationID: req.CorrelationID,
+			Due:           now.Add(after),
+			ReceiptID:     receiptID,
+			Sent:          now,
+			Title:         req.Title,
+			Message:       s.followUpMessage(req),
+			Sender:        req.Sender,
+		}
+
+		if err := s.followUps.Schedule(f); err != nil && !s.cfg.SilentOutput {
+			log.Printf("WARNING: Failed to schedule follow-up for correlation ID %q: %v", req.CorrelationID, err)
+		}
+	}
+}
+
+// followUpMessage returns the text of the follow-up message requested by the
+// given request, defaulting to the follow-up-message flag value.
+func (s *Server) followUpMessage(req Request) string {
+	if req.FollowUpMessage != "" {
+		return req.FollowUpMessage
+	}
+
+	return s.cfg.FollowUpMessage
+}
+
+// watchFollowUps queues follow-up messages as they become due until the
+// given channel is closed.
+func (s *Server) watchFollowUps(done <-chan struct{}) {
+	ticker := time.NewTicker(followUpInterval)
+	defer ticker.Stop()
+
+	for {
+		s.queueFollowUps(time.Now())
+
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// queueFollowUps queues the follow-up messages due at the given time.
+// Follow-ups which cannot be queued are retained for the next check.
+func (s *Server) queueFollowUps(now time.Time) {
+	due, err := s.followUps.TakeDue(now)
+	if err != nil && !s.cfg.SilentOutput {
+		log.Printf("WARNING: %v", err)
+	}
+
+	for _, f := range due {
+		receiptID, _, err := s.enqueue(f.TeamsMessage(), "")
+		if err != nil {
+			if !s.cfg.SilentOutput {
+				log.Printf("WARNING: Failed to queue follow-up for correlation ID %q: %v", f.CorrelationID, err)
+			}
+
+			if err := s.followUps.Schedule(f); err != nil && !s.cfg.SilentOutput {
+				log.Printf("WARNING: Failed to reschedule follow-up for correlation ID %q: %v", f.CorrelationID, err)
+			}
+			continue
+		}
+
+		if !s.cfg.SilentOutput {
+			log.Printf("Queued follow-up for correlation ID %q (receipt %s)", f.CorrelationID, receiptID)
+		}
+	}
+}
@@ -0,0 +1,344 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package serve
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// APIVersion is the current version of the serve mode HTTP API. Within a
+// version, endpoints and fields are never removed or given a different
+// meaning; new optional request fields, response fields and endpoints may be
+// added. Clients should ignore response fields they do not recognize.
+// Changes which are not backward compatible are introduced as a new version
+// served alongside the previous versions.
+const APIVersion string = "v1"
+
+// APIVersionHeader is the response header indicating the API version which
+// handled the request.
+const APIVersionHeader string = "Send2Teams-API-Version"
+
+// IdempotencyKeyHeader is the (optional) request header providing the
+// idempotency key of a submitted message, as an alternative to the
+// idempotency_key field.
+const IdempotencyKeyHeader string = "Idempotency-Key"
+
+// Paths of the serve mode HTTP API endpoints.
+const (
+	apiPathMessages     string = "/" + APIVersion + "/messages"
+	apiPathCapabilities string = "/" + APIVersion + "/capabilities"
+)
+
+// httpReadHeaderTimeout is the maximum amount of time allowed to read the
+// headers of an HTTP API request.
+const httpReadHeaderTimeout time.Duration = 10 * time.Second
+
+// httpShutdownTimeout is the maximum amount of time HTTP API requests in
+// progress are given to complete during shutdown.
+const httpShutdownTimeout time.Duration = 5 * time.Second
+
+// apiVersions are the versions of the HTTP API served, oldest first.
+var apiVersions = []string{APIVersion}
+
+// apiContentTypes are the media types accepted for submitted messages.
+var apiContentTypes = []string{"application/json"}
+
+// Capabilities describes the HTTP API provided by a serve instance so that
+// producers can detect the supported versions, formats and limits rather
+// than assuming them.
+type Capabilities struct {
+
+	// APIVersion is the version of the API describing these capabilities.
+	APIVersion string `json:"api_version"`
+
+	// APIVersions are all API versions served, oldest first.
+	APIVersions []string `json:"api_versions"`
+
+	// Version is the version of this application.
+	Version string `json:"version"`
+
+	// Endpoints are the paths of the endpoints provided by APIVersion.
+	Endpoints []string `json:"endpoints"`
+
+	// ContentTypes are the media types accepted for submitted messages.
+	ContentTypes []string `json:"content_types"`
+
+	// MessageFields are the names of the fields supported within submitted
+	// messages.
+	MessageFields []string `json:"message_fields"`
+
+	// MaxRequestBytes is the maximum size of a submitted message.
+	MaxRequestBytes int64 `json:"max_request_bytes"`
+
+	// Queue is a summary of the delivery queue.
+	Queue QueueSummary `json:"queue"`
+}
+
+// QueueSummary summarizes the state of the delivery queue without
+// describing individual messages.
+type QueueSummary struct {
+
+	// Depth is the number of messages waiting in the delivery queue.
+	Depth int `json:"depth"`
+
+	// Capacity is the maximum number of messages which may wait in the
+	// delivery queue.
+	Capacity int `json:"capacity"`
+
+	// Failed is the number of failed messages retained for retry.
+	Failed int `json:"failed"`
+
+	// Paused indicates whether delivery is paused by the pause control
+	// file.
+	Paused bool `json:"paused"`
+
+	// Throttled indicates whether the last delivery attempt was rejected by
+	// Microsoft Teams due to rate limiting.
+	Throttled bool `json:"throttled"`
+}
+
+// ListenHTTP creates a TCP listener for the HTTP API at the given address
+// (e.g., 127.0.0.1:8080).
+func ListenHTTP(addr string) (net.Listener, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	return listener, nil
+}
+
+// newHTTPServer returns the HTTP server providing the HTTP API on the given
+// listen address.
+func (s *Server) newHTTPServer(addr net.Addr) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPathMessages, s.handleMessages)
+	mux.HandleFunc(apiPathCapabilities, s.handleCapabilities)
+	mux.HandleFunc("/", s.handleUnknown)
+
+	return &http.Server{
+		Handler:           requireHost(addr, mux),
+		ReadHeaderTimeout: httpReadHeaderTimeout,
+	}
+}
+
+// requireHost rejects requests whose Host header does not name the given
+// listen address. Otherwise a web page could submit messages by resolving
+// its own host name to the listen address (DNS rebinding).
+func requireHost(addr net.Addr, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !allowedHost(r.Host, addr) {
+			rejectRequest(w, http.StatusMisdirectedRequest,
+				"host %q does not match the listen address %s", r.Host, addr)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowedHost indicates whether the given Host header names the given listen
+// address. The port must match the listen port. IP addresses are accepted if
+// they match the listen address (or it is unspecified), localhost if it is a
+// loopback or unspecified address and the host name of this system if it is
+// not a loopback address.
+func allowedHost(host string, addr net.Addr) bool {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+
+	name, port, err := net.SplitHostPort(host)
+	if err != nil {
+		name, port = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"), "80"
+	}
+
+	if port != strconv.Itoa(tcpAddr.Port) {
+		return false
+	}
+
+	if ip := net.ParseIP(name); ip != nil {
+		return tcpAddr.IP.IsUnspecified() || ip.Equal(tcpAddr.IP)
+	}
+
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	if name == "localhost" {
+		return tcpAddr.IP.IsLoopback() || tcpAddr.IP.IsUnspecified()
+	}
+
+	if tcpAddr.IP.IsLoopback() {
+		return false
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		return false
+	}
+	hostname = strings.ToLower(hostname)
+	shortName, _, _ := strings.Cut(hostname, ".")
+
+	return name == hostname || name == shortName
+}
+
+// writeJSON writes the given value as the JSON encoded response body along
+// with the given status code.
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(APIVersionHeader, APIVersion)
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// rejectRequest writes a response rejecting the request for the given
+// reason.
+func rejectRequest(w http.ResponseWriter, code int, format string, args ...interface{}) {
+	writeJSON(w, code, Response{Status: StatusRejected, Error: fmt.Sprintf(format, args...)})
+}
+
+// handleMessages queues the message submitted in the request body for
+// delivery. The response body is the Response also returned to unix domain
+// socket clients; the status code is 202 if the message was queued (or was
+// a duplicate of an accepted message).
+func (s *Server) handleMessages(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		rejectRequest(w, http.StatusMethodNotAllowed, "method %s not allowed; use %s", r.Method, http.MethodPost)
+		return
+	}
+
+	// Requiring a JSON content type also prevents web pages from submitting
+	// messages using simple (form or text/plain) cross-origin requests.
+	contentType := r.Header.Get("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(contentType); err != nil || mediaType != apiContentTypes[0] {
+		rejectRequest(w, http.StatusUnsupportedMediaType,
+			"unsupported content type %q; expected %s", contentType, strings.Join(apiContentTypes, ", "))
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxMessageSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			rejectRequest(w, http.StatusRequestEntityTooLarge, "message exceeds %d bytes", maxMessageSize)
+			return
+		}
+		rejectRequest(w, http.StatusBadRequest, "failed to read message: %v", err)
+		return
+	}
+
+	// Unknown fields are ignored so that producers written against a later
+	// release are accepted by an earlier one.
+	var req Request
+	if err := json.Unmarshal(body, &req); err != nil {
+		rejectRequest(w, http.StatusBadRequest, "invalid message: %v", err)
+		return
+	}
+
+	if req.Command != "" {
+		rejectRequest(w, http.StatusBadRequest,
+			"unsupported: commands are only accepted via the unix domain socket")
+		return
+	}
+
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = r.Header.Get(IdempotencyKeyHeader)
+	}
+
+	resp := s.accept(req, "HTTP client "+r.RemoteAddr)
+
+	switch {
+	case resp.Status == StatusQueued:
+		writeJSON(w, http.StatusAccepted, resp)
+	case resp.Error == ErrQueueFull.Error():
+		w.Header().Set("Retry-After", "5")
+		writeJSON(w, http.StatusServiceUnavailable, resp)
+	default:
+		writeJSON(w, http.StatusBadRequest, resp)
+	}
+}
+
+// handleCapabilities describes the HTTP API provided by this instance.
+func (s *Server) handleCapabilities(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", http.MethodGet)
+		rejectRequest(w, http.StatusMethodNotAllowed, "method %s not allowed; use %s", r.Method, http.MethodGet)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.Capabilities())
+}
+
+// handleUnknown rejects requests for paths not provided by the HTTP API,
+// noting the supported versions for requests of other API versions.
+func (s *Server) handleUnknown(w http.ResponseWriter, r *http.Request) {
+	rejectRequest(w, http.StatusNotFound,
+		"unknown endpoint %s; supported API versions: %s (see %s)",
+		r.URL.Path, strings.Join(apiVersions, ", "), apiPathCapabilities)
+}
+
+// Capabilities returns the description of the HTTP API provided by this
+// instance.
+func (s *Server) Capabilities() Capabilities {
+	status := s.Status()
+
+	return Capabilities{
+		APIVersion:      APIVersion,
+		APIVersions:     append([]string{}, apiVersions...),
+		Version:         s.cfg.App.Version,
+		Endpoints:       []string{apiPathCapabilities, apiPathMessages},
+		ContentTypes:    append([]string{}, apiContentTypes...),
+		MessageFields:   messageFields(),
+		MaxRequestBytes: maxMessageSize,
+		Queue: QueueSummary{
+			Depth:     status.Depth,
+			Capacity:  status.Capacity,
+			Failed:    len(status.Failed),
+			Paused:    status.Paused,
+			Throttled: status.Throttled,
+		},
+	}
+}
+
+// messageFields returns the names of the JSON fields of a submitted message,
+// excluding those used only by commands.
+func messageFields() []string {
+	var fields []string
+
+	var collect func(t reflect.Type)
+	collect = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.Anonymous {
+				collect(field.Type)
+				continue
+			}
+
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "" || name == "-" || name == "command" || name == "receipt_id" {
+				continue
+			}
+			fields = append(fields, name)
+		}
+	}
+	collect(reflect.TypeOf(Request{}))
+
+	sort.Strings(fields)
+
+	return fields
+}
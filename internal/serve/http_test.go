@@ -0,0 +1,190 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package serve
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	goteamsnotify "github.com/atc0005/go-teams-notify/v2"
+	"github.com/atc0005/send2teams/internal/config"
+	"github.com/atc0005/send2teams/internal/delivery"
+)
+
+func TestHTTPAPI(t *testing.T) {
+	cfg := config.Config{SilentOutput: true, FollowUpDir: t.TempDir()}
+	cfg.App.Version = "v1.2.3"
+
+	deliverer, err := delivery.New(&cfg, goteamsnotify.NewTeamsClient())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := New(&cfg, deliverer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewUnstartedServer(nil)
+	srv.Config = s.newHTTPServer(srv.Listener.Addr())
+	srv.Start()
+	defer srv.Close()
+
+	post := func(body string, header http.Header) (int, Response) {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodPost, srv.URL+apiPathMessages, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+		for name, values := range header {
+			req.Header[name] = values
+			if len(values) == 0 {
+				req.Header.Del(name)
+			}
+		}
+		req.Host = req.Header.Get("Host")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		if got := resp.Header.Get(APIVersionHeader); got != APIVersion {
+			t.Errorf("got %s header %q; want %q", APIVersionHeader, got, APIVersion)
+		}
+
+		var result Response
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+
+		return resp.StatusCode, result
+	}
+
+	code, resp := post(`{"title": "Example", "text": "Hello", "added_in_a_later_release": true}`, nil)
+	if code != http.StatusAccepted || resp.Status != StatusQueued || resp.ReceiptID == "" {
+		t.Fatalf("got %d %+v; want message with unknown field queued", code, resp)
+	}
+
+	header := http.Header{IdempotencyKeyHeader: []string{"key-1"}}
+	_, first := post(`{"title": "Example", "text": "Hello"}`, header)
+	code, resp = post(`{"title": "Example", "text": "Hello"}`, header)
+	if code != http.StatusAccepted || !resp.Duplicate || resp.ReceiptID != first.ReceiptID {
+		t.Errorf("got %d %+v; want duplicate of %s", code, resp, first.ReceiptID)
+	}
+
+	const valid = `{"text": "Hello"}`
+	port := srv.Listener.Addr().(*net.TCPAddr).Port
+	tests := map[string]struct {
+		body   string
+		header http.Header
+		want   int
+	}{
+		"invalid JSON":    {body: `{"text": `, want: http.StatusBadRequest},
+		"no text":         {body: `{"title": "Example"}`, want: http.StatusBadRequest},
+		"command":         {body: `{"command": "status"}`, want: http.StatusBadRequest},
+		"too large":       {body: `{"text": "` + strings.Repeat("x", int(maxMessageSize)) + `"}`, want: http.StatusRequestEntityTooLarge},
+		"no content type": {body: valid, header: http.Header{"Content-Type": nil}, want: http.StatusUnsupportedMediaType},
+		"text/plain":      {body: valid, header: http.Header{"Content-Type": {"text/plain"}}, want: http.StatusUnsupportedMediaType},
+		"form":            {body: valid, header: http.Header{"Content-Type": {"application/x-www-form-urlencoded"}}, want: http.StatusUnsupportedMediaType},
+		"rebound host":    {body: valid, header: http.Header{"Host": {fmt.Sprintf("attacker.example.com:%d", port)}}, want: http.StatusMisdirectedRequest},
+		"other port":      {body: valid, header: http.Header{"Host": {fmt.Sprintf("127.0.0.1:%d", port+1)}}, want: http.StatusMisdirectedRequest},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			code, resp := post(tt.body, tt.header)
+			if code != tt.want || resp.Status != StatusRejected || resp.Error == "" {
+				t.Errorf("got %d %+v; want %d rejection", code, resp, tt.want)
+			}
+		})
+	}
+
+	unknown, err := http.Post(srv.URL+"/v2/messages", "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknown.Body.Close()
+	if unknown.StatusCode != http.StatusNotFound {
+		t.Errorf("got status %d for unsupported API version; want %d", unknown.StatusCode, http.StatusNotFound)
+	}
+
+	capsResp, err := http.Get(srv.URL + apiPathCapabilities)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer capsResp.Body.Close()
+
+	var caps Capabilities
+	if err := json.NewDecoder(capsResp.Body).Decode(&caps); err != nil {
+		t.Fatal(err)
+	}
+
+	if caps.APIVersion != APIVersion || caps.Version != "v1.2.3" || caps.MaxRequestBytes != maxMessageSize {
+		t.Errorf("got capabilities %+v", caps)
+	}
+
+	if caps.Queue.Depth != 2 || caps.Queue.Capacity != queueSize {
+		t.Errorf("got queue %+v; want depth 2 of %d", caps.Queue, queueSize)
+	}
+
+	fields := strings.Join(caps.MessageFields, ",")
+	for _, want := range []string{"text", "title", "idempotency_key"} {
+		if !strings.Contains(","+fields+",", ","+want+",") {
+			t.Errorf("message fields %s missing %q", fields, want)
+		}
+	}
+	if strings.Contains(fields, "command") {
+		t.Errorf("message fields %s include command", fields)
+	}
+}
+
+func TestAllowedHost(t *testing.T) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loopback := &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 8080}
+	unspecified := &net.TCPAddr{IP: net.IPv6unspecified, Port: 8080}
+
+	tests := map[string]struct {
+		host string
+		addr net.Addr
+		want bool
+	}{
+		"loopback IP":             {host: "127.0.0.1:8080", addr: loopback, want: true},
+		"localhost":               {host: "LocalHost:8080", addr: loopback, want: true},
+		"other IP":                {host: "192.0.2.1:8080", addr: loopback, want: false},
+		"other port":              {host: "127.0.0.1:8081", addr: loopback, want: false},
+		"no port":                 {host: "127.0.0.1", addr: loopback, want: false},
+		"rebound name":            {host: "attacker.example.com:8080", addr: loopback, want: false},
+		"hostname on loopback":    {host: hostname + ":8080", addr: loopback, want: false},
+		"IPv6 on unspecified":     {host: "[2001:db8::1]:8080", addr: unspecified, want: true},
+		"hostname on unspecified": {host: hostname + ":8080", addr: unspecified, want: true},
+		"rebound on unspecified":  {host: "attacker.example.com:8080", addr: unspecified, want: false},
+		"default port":            {host: "127.0.0.1", addr: &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 80}, want: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := allowedHost(tt.host, tt.addr); got != tt.want {
+				t.Errorf("allowedHost(%q, %s) = %t; want %t", tt.host, tt.addr, got, tt.want)
+			}
+		})
+	}
+}
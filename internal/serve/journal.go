@@ -0,0 +1,312 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package serve
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// Names of the files and directories within a journal directory.
+const (
+	journalPendingDir    string = "pending"
+	journalDeliveredFile string = "delivered.json"
+)
+
+// deliveredRetention is how long records of delivered messages are retained
+// to detect duplicate submissions.
+const deliveredRetention time.Duration = 24 * time.Hour
+
+// journalEntry is the persisted form of a message accepted for delivery.
+type journalEntry struct {
+	ReceiptID      string        `json:"receipt_id"`
+	IdempotencyKey string        `json:"idempotency_key,omitempty"`
+	Queued         time.Time     `json:"queued"`
+	Message        teams.Message `json:"message"`
+
+	// Error is the reason the last delivery attempt failed. Empty if no
+	// delivery attempt has failed.
+	Error string `json:"error,omitempty"`
+}
+
+// deliveredRecord records a delivered message.
+type deliveredRecord struct {
+	ReceiptID      string    `json:"receipt_id"`
+	IdempotencyKey string    `json:"idempotency_key,omitempty"`
+	Time           time.Time `json:"time"`
+}
+
+// journal checkpoints messages accepted for delivery so that they survive a
+// restart of the serve instance, and tracks the idempotency keys of accepted
+// and delivered messages so that duplicate submissions are detected. If no
+// directory is specified the journal is kept in memory only.
+type journal struct {
+	dir string
+
+	mu        sync.Mutex
+	delivered []deliveredRecord
+
+	// keys maps the idempotency keys of accepted (pending, failed or
+	// delivered) messages to their receipt IDs.
+	keys map[string]string
+}
+
+// openJournal opens the journal within the given directory, creating it if
+// necessary, and returns the messages which were not delivered before the
+// last shutdown (oldest first). Messages which were delivered, but not yet
+// removed from the journal, are removed.
+func openJournal(dir string) (*journal, []journalEntry, error) {
+	j := journal{dir: dir, keys: make(map[string]string)}
+	if dir == "" {
+		return &j, nil, nil
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, journalPendingDir), 0o700); err != nil {
+		return nil, nil, fmt.Errorf("failed to create journal directory: %w", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, journalDeliveredFile))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, nil, fmt.Errorf("failed to read journal: %w", err)
+	default:
+		if err := json.Unmarshal(data, &j.delivered); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode journal: %w", err)
+		}
+	}
+
+	j.prune(time.Now())
+
+	deliveredIDs := make(map[string]struct{}, len(j.delivered))
+	for _, rec := range j.delivered {
+		deliveredIDs[rec.ReceiptID] = struct{}{}
+		if rec.IdempotencyKey != "" {
+			j.keys[rec.IdempotencyKey] = rec.ReceiptID
+		}
+	}
+
+	entries, err := j.readPending()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	undelivered := make([]journalEntry, 0, len(entries))
+	for _, entry := range entries {
+		if _, ok := deliveredIDs[entry.ReceiptID]; ok {
+			if err := j.remove(entry.ReceiptID); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+
+		if entry.IdempotencyKey != "" {
+			j.keys[entry.IdempotencyKey] = entry.ReceiptID
+		}
+		undelivered = append(undelivered, entry)
+	}
+
+	return &j, undelivered, nil
+}
+
+// readPending returns the journal entries for undelivered messages, oldest
+// first.
+func (j *journal) readPending() ([]journalEntry, error) {
+	pendingDir := filepath.Join(j.dir, journalPendingDir)
+
+	files, err := os.ReadDir(pendingDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	entries := make([]journalEntry, 0, len(files))
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(pendingDir, file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read journal entry: %w", err)
+		}
+
+		var entry journalEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, fmt.Errorf("failed to decode journal entry %s: %w", file.Name(), err)
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.SliceStable(entries, func(i, k int) bool {
+		return entries[i].Queued.Before(entries[k].Queued)
+	})
+
+	return entries, nil
+}
+
+// reserve records the idempotency key of an accepted message, returning
+// the given receipt ID. If a message with the same key was already accepted
+// its receipt ID is returned instead, along with false.
+func (j *journal) reserve(key string, receiptID string) (string, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if existing, ok := j.keys[key]; ok {
+		return existing, false
+	}
+	j.keys[key] = receiptID
+
+	return receiptID, true
+}
+
+// discard removes the entry and any idempotency key for the message with
+// the given receipt ID, which was not accepted or was discarded.
+func (j *journal) discard(receiptID string) error {
+	j.mu.Lock()
+	for key, id := range j.keys {
+		if id == receiptID {
+			delete(j.keys, key)
+		}
+	}
+	j.mu.Unlock()
+
+	return j.remove(receiptID)
+}
+
+// save persists the given entry, replacing any previous entry for the same
+// message. The entry is flushed to stable storage before save returns.
+func (j *journal) save(entry journalEntry) error {
+	if j.dir == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode journal entry: %w", err)
+	}
+
+	return writeFileSync(j.entryPath(entry.ReceiptID), data)
+}
+
+// remove removes the entry for the message with the given receipt ID.
+func (j *journal) remove(receiptID string) error {
+	if j.dir == "" {
+		return nil
+	}
+
+	if err := os.Remove(j.entryPath(receiptID)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove journal entry: %w", err)
+	}
+
+	return nil
+}
+
+// markDelivered records that the given message was delivered and removes
+// its entry. The delivered record is persisted before the entry is removed
+// so that a message is not delivered again if the serve instance stops
+// in between.
+func (j *journal) markDelivered(receiptID string, key string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	j.prune(now)
+	j.delivered = append(j.delivered, deliveredRecord{
+		ReceiptID:      receiptID,
+		IdempotencyKey: key,
+		Time:           now.UTC(),
+	})
+
+	if j.dir == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(j.delivered)
+	if err != nil {
+		return fmt.Errorf("failed to encode journal: %w", err)
+	}
+
+	if err := writeFileSync(filepath.Join(j.dir, journalDeliveredFile), data); err != nil {
+		return err
+	}
+
+	return j.remove(receiptID)
+}
+
+// prune discards delivered records older than the retention period. The
+// caller must hold mu unless the journal is not yet shared.
+func (j *journal) prune(now time.Time) {
+	cutoff := now.Add(-deliveredRetention)
+
+	delivered := j.delivered[:0]
+	for _, rec := range j.delivered {
+		if rec.Time.After(cutoff) {
+			delivered = append(delivered, rec)
+			continue
+		}
+
+		if rec.IdempotencyKey != "" && j.keys[rec.IdempotencyKey] == rec.ReceiptID {
+			delete(j.keys, rec.IdempotencyKey)
+		}
+	}
+	j.delivered = delivered
+}
+
+// entryPath returns the path to the entry for the message with the given
+// receipt ID.
+func (j *journal) entryPath(receiptID string) string {
+	return filepath.Join(j.dir, journalPendingDir, receiptID+".json")
+}
+
+// writeFileSync atomically replaces the given file with the given data,
+// flushing it to stable storage.
+func writeFileSync(path string, data []byte) error {
+	tmp := path + ".tmp"
+
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+
+	// Persist the rename itself; failures are not fatal on platforms which
+	// do not support syncing directories.
+	if dir, err := os.Open(filepath.Dir(path)); err == nil {
+		_ = dir.Sync()
+		_ = dir.Close()
+	}
+
+	return nil
+}
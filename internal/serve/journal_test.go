@@ -0,0 +1,74 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package serve
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+func TestJournalRecovery(t *testing.T) {
+	dir := t.TempDir()
+
+	j, entries, err := openJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("got %d entries from new journal; want 0", len(entries))
+	}
+
+	now := time.Now().UTC()
+	delivered := journalEntry{ReceiptID: "delivered", IdempotencyKey: "key-1", Queued: now, Message: teams.Message{Text: "one"}}
+	pending := journalEntry{ReceiptID: "pending", IdempotencyKey: "key-2", Queued: now.Add(time.Second), Message: teams.Message{Text: "two"}}
+
+	for _, entry := range []journalEntry{delivered, pending} {
+		if err := j.save(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := j.markDelivered(delivered.ReceiptID, delivered.IdempotencyKey); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a restart between recording delivery and removing the entry.
+	if err := j.save(delivered); err != nil {
+		t.Fatal(err)
+	}
+
+	j, entries, err = openJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 || entries[0].ReceiptID != pending.ReceiptID || entries[0].Message.Text != "two" {
+		t.Fatalf("got %+v; want only the undelivered entry", entries)
+	}
+
+	if _, err := os.Stat(j.entryPath(delivered.ReceiptID)); !os.IsNotExist(err) {
+		t.Errorf("expected entry for delivered message to be removed; got %v", err)
+	}
+
+	for key, want := range map[string]string{"key-1": delivered.ReceiptID, "key-2": pending.ReceiptID} {
+		if got, ok := j.reserve(key, "new"); ok || got != want {
+			t.Errorf("reserve(%q) = %q, %t; want duplicate of %q", key, got, ok, want)
+		}
+	}
+
+	if err := j.discard(pending.ReceiptID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := j.reserve("key-2", "new"); !ok {
+		t.Error("expected idempotency key of discarded message to be released")
+	}
+}
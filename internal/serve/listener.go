@@ -0,0 +1,78 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package serve
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+)
+
+// ErrSocketInUse indicates that another process is already accepting
+// connections on the requested unix domain socket.
+var ErrSocketInUse = errors.New("unix domain socket already in use")
+
+// ListenUnix creates a unix domain socket listener at the given path with
+// the specified filesystem permissions. The socket is created with these
+// permissions (rather than having them applied afterwards) so that it is
+// never accessible with looser permissions. A stale socket
+// left behind by a previous (unclean) shutdown is removed; an error is
+// returned if the socket is in use by another process or if the path refers
+// to something other than a socket.
+func ListenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+
+	listener, err := listenUnixSocket(path, mode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on unix domain socket %s: %w", path, err)
+	}
+
+	// The mode may include bits (e.g., setgid) not applied at creation.
+	if err := os.Chmod(path, mode); err != nil {
+		_ = listener.Close()
+
+		return nil, fmt.Errorf(
+			"failed to apply mode %o to unix domain socket %s: %w",
+			mode,
+			path,
+			err,
+		)
+	}
+
+	return listener, nil
+}
+
+// removeStaleSocket removes a unix domain socket at the given path if no
+// process is accepting connections on it.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to check unix domain socket path %s: %w", path, err)
+	case info.Mode()&os.ModeSocket == 0:
+		return fmt.Errorf("refusing to replace non-socket file %s", path)
+	}
+
+	conn, err := net.Dial("unix", path)
+	if err == nil {
+		_ = conn.Close()
+
+		return fmt.Errorf("%s: %w", path, ErrSocketInUse)
+	}
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove stale unix domain socket %s: %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+//go:build !windows
+
+package serve
+
+import (
+	"net"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// umaskMu serializes changes to the (process wide) umask.
+var umaskMu sync.Mutex
+
+// listenUnixSocket creates a unix domain socket listener at the given path.
+// The umask is tightened while the socket is created so that it is created
+// with (at most) the specified filesystem permissions.
+func listenUnixSocket(path string, mode os.FileMode) (net.Listener, error) {
+	umaskMu.Lock()
+	defer umaskMu.Unlock()
+
+	oldMask := syscall.Umask(int(^mode.Perm() & os.ModePerm))
+	defer syscall.Umask(oldMask)
+
+	return net.Listen("unix", path)
+}
@@ -0,0 +1,45 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+//go:build !windows
+
+package serve
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestListenUnixSocketMode(t *testing.T) {
+	// Without tightening the umask the socket would be created writable by
+	// all users.
+	oldMask := syscall.Umask(0)
+	defer syscall.Umask(oldMask)
+
+	path := filepath.Join(t.TempDir(), "send2teams.sock")
+
+	listener, err := listenUnixSocket(path, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := info.Mode().Perm(); got != 0o600 {
+		t.Errorf("got socket created with mode %o; want %o", got, 0o600)
+	}
+
+	if mask := syscall.Umask(0); mask != 0 {
+		t.Errorf("got umask %o after creating socket; want it restored", mask)
+	}
+}
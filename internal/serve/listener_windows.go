@@ -0,0 +1,22 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+//go:build windows
+
+package serve
+
+import (
+	"net"
+	"os"
+)
+
+// listenUnixSocket creates a unix domain socket listener at the given path.
+// Windows has no umask, so the socket is created with the default
+// permissions.
+func listenUnixSocket(path string, _ os.FileMode) (net.Listener, error) {
+	return net.Listen("unix", path)
+}
@@ -0,0 +1,602 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	goteamsnotify "github.com/atc0005/go-teams-notify/v2"
+	"github.com/atc0005/send2teams/internal/config"
+	"github.com/atc0005/send2teams/internal/delivery"
+	"github.com/atc0005/send2teams/internal/followup"
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// queueSize is the number of accepted messages which may be waiting for
+// delivery before new submissions are rejected.
+const queueSize int = 100
+
+// maxMessageSize is the maximum number of bytes read from a client for a
+// single connection. Microsoft Teams rejects messages far smaller than this.
+const maxMessageSize int64 = 1024 * 1024
+
+// clientIdleTimeout is the amount of time a client connection may remain
+// idle before it is closed.
+const clientIdleTimeout time.Duration = 30 * time.Second
+
+// Status values returned to clients in response to submitted messages and
+// commands.
+const (
+	StatusQueued   string = "queued"
+	StatusRejected string = "rejected"
+	StatusOK       string = "ok"
+)
+
+// Commands which may be submitted by clients in place of a message.
+const (
+
+	// CommandStatus requests a snapshot of the delivery queue.
+	CommandStatus string = "status"
+
+	// CommandRetry requests that a failed message be queued for delivery
+	// again.
+	CommandRetry string = "retry"
+
+	// CommandDiscard requests that a queued or failed message be dropped.
+	CommandDiscard string = "discard"
+)
+
+// ErrUnknownCommand indicates that a client submitted an unsupported
+// command.
+var ErrUnknownCommand = errors.New("unknown command")
+
+// ErrQueueFull indicates that a message was rejected because the delivery
+// queue is at capacity.
+var ErrQueueFull = errors.New("delivery queue is full")
+
+// Response is returned to clients for each submitted message.
+type Response struct {
+
+	// Status indicates whether the submitted message was accepted.
+	Status string `json:"status"`
+
+	// ReceiptID is the unique identifier assigned to a queued message.
+	ReceiptID string `json:"receipt_id,omitempty"`
+
+	// Error provides the reason a message or command was rejected.
+	Error string `json:"error,omitempty"`
+
+	// Queue is the snapshot of the delivery queue returned for the status
+	// command.
+	Queue *QueueStatus `json:"queue,omitempty"`
+
+	// Duplicate indicates that a message with the same idempotency key was
+	// already accepted. The message is not queued again; ReceiptID is that
+	// of the original message.
+	Duplicate bool `json:"duplicate,omitempty"`
+}
+
+// Request is a message or command submitted by a client. If Command is
+// empty, the request is a message to deliver.
+type Request struct {
+	teams.Message
+
+	// Command is the (optional) command to run in place of delivering a
+	// message.
+	Command string `json:"command,omitempty"`
+
+	// ReceiptID identifies the message that a retry or discard command
+	// applies to.
+	ReceiptID string `json:"receipt_id,omitempty"`
+
+	// IdempotencyKey is an (optional) client-assigned key identifying the
+	// message. A message with the same key as a message accepted within the
+	// last 24 hours is not queued again, allowing clients to safely resubmit
+	// a message if they did not receive a response.
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+
+	// CorrelationID is the (optional) ID correlating messages about the same
+	// issue.
+	CorrelationID string `json:"correlation_id,omitempty"`
+
+	// FollowUpAfter is the (optional) time (e.g., "1h") after which a
+	// follow-up message is posted if a resolved message with the same
+	// correlation ID has not been accepted.
+	FollowUpAfter string `json:"follow_up_after,omitempty"`
+
+	// FollowUpMessage is the (optional) text of the follow-up message. If
+	// not specified, the follow-up-message flag value is used.
+	FollowUpMessage string `json:"follow_up_message,omitempty"`
+
+	// Resolved indicates whether the message reports the resolution of the
+	// issue identified by CorrelationID, cancelling any pending follow-up.
+	Resolved bool `json:"resolved,omitempty"`
+}
+
+// queueItem is a message accepted for delivery.
+type queueItem struct {
+	receiptID      string
+	idempotencyKey string
+	queued         time.Time
+	msg            teams.Message
+}
+
+// entry returns the journal entry for the message.
+func (item queueItem) entry() journalEntry {
+	return journalEntry{
+		ReceiptID:      item.receiptID,
+		IdempotencyKey: item.idempotencyKey,
+		Queued:         item.queued,
+		Message:        item.msg,
+	}
+}
+
+// Server accepts messages from clients and submits them to Microsoft Teams.
+type Server struct {
+	cfg       *config.Config
+	deliverer *delivery.Deliverer
+	queue     chan queueItem
+
+	// journal checkpoints accepted messages so that they survive a restart.
+	journal *journal
+
+	// followUps records pending follow-up messages.
+	followUps *followup.Store
+
+	// recovered is the collection of messages checkpointed, but not
+	// delivered, before the last shutdown. These are delivered ahead of the
+	// queue.
+	recovered []queueItem
+
+	// state records the delivery state of accepted messages for display to
+	// clients.
+	state   tracker
+	stateMu sync.Mutex
+
+	// conns tracks active client connections so that they may be closed
+	// when the server is shutting down.
+	conns   map[net.Conn]struct{}
+	connsMu sync.Mutex
+	connsWG sync.WaitGroup
+}
+
+// New creates a Server which submits messages using the given configuration
+// and Deliverer. Messages checkpointed by a previous instance using the same
+// journal directory, but not delivered, are recovered; messages whose last
+// delivery attempt failed are retained so that they may be retried or
+// discarded.
+func New(cfg *config.Config, deliverer *delivery.Deliverer) (*Server, error) {
+	s := Server{
+		cfg:       cfg,
+		deliverer: deliverer,
+		queue:     make(chan queueItem, queueSize),
+		state:     newTracker(),
+		conns:     make(map[net.Conn]struct{}),
+	}
+
+	j, entries, err := openJournal(cfg.ServeJournalDir())
+	if err != nil {
+		return nil, err
+	}
+	s.journal = j
+
+	if s.followUps, err = followup.Open(cfg.FollowUpDir, cfg.WebhookURL); err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		item := queueItem{
+			receiptID:      entry.ReceiptID,
+			idempotencyKey: entry.IdempotencyKey,
+			queued:         entry.Queued,
+			msg:            entry.Message,
+		}
+
+		if entry.Error != "" {
+			s.state.failed = append(s.state.failed, item)
+			s.state.failedState[item.receiptID] = ItemStatus{
+				Queued:    item.queued,
+				ReceiptID: item.receiptID,
+				Title:     item.msg.Title,
+				State:     StateFailed,
+				Error:     entry.Error,
+			}
+			continue
+		}
+
+		s.queued(item)
+		s.recovered = append(s.recovered, item)
+	}
+
+	if len(entries) > 0 && !cfg.SilentOutput {
+		log.Printf("Recovered %d undelivered message(s) from journal", len(entries))
+	}
+
+	return &s, nil
+}
+
+// Serve accepts client connections on the given listener, reads messages
+// from the given named pipe and provides the HTTP API on the given HTTP
+// listener until the provided context is cancelled. Any of the listeners or
+// the named pipe may be nil. Messages already queued for delivery are
+// submitted before Serve returns.
+func (s *Server) Serve(ctx context.Context, listener net.Listener, fifo *FIFO, httpListener net.Listener) error {
+	var deliveryWG sync.WaitGroup
+	deliveryWG.Add(1)
+	go func() {
+		defer deliveryWG.Done()
+		for _, item := range s.recovered {
+			s.deliver(ctx, item)
+		}
+		for item := range s.queue {
+			s.deliver(ctx, item)
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		if listener != nil {
+			_ = listener.Close()
+		}
+		if fifo != nil {
+			_ = fifo.Close()
+		}
+		s.closeConns()
+	}()
+
+	var httpServer *http.Server
+	httpErr := make(chan error, 1)
+	if httpListener != nil {
+		httpServer = s.newHTTPServer(httpListener.Addr())
+		go func() {
+			err := httpServer.Serve(httpListener)
+			if errors.Is(err, http.ErrServerClosed) {
+				err = nil
+			}
+			httpErr <- err
+		}()
+	}
+
+	var fifoWG sync.WaitGroup
+	if fifo != nil {
+		fifoWG.Add(1)
+		go func() {
+			defer fifoWG.Done()
+			s.readFIFO(ctx, fifo)
+		}()
+	}
+
+	// Due follow-ups are queued until no further messages are accepted.
+	followUpsDone := make(chan struct{})
+	var followUpsWG sync.WaitGroup
+	followUpsWG.Add(1)
+	go func() {
+		defer followUpsWG.Done()
+		s.watchFollowUps(followUpsDone)
+	}()
+
+	var acceptErr error
+	for listener != nil {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() == nil {
+				acceptErr = err
+			}
+			break
+		}
+
+		s.trackConn(conn)
+		go s.handleConn(conn)
+	}
+	if listener == nil {
+		select {
+		case <-ctx.Done():
+		case acceptErr = <-httpErr:
+			httpServer = nil
+		}
+	}
+
+	// No further messages are accepted once all client connections, the
+	// named pipe and the HTTP API are closed; the remaining messages in the
+	// queue are delivered.
+	if httpServer != nil {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			_ = httpServer.Close()
+		}
+		cancel()
+		if err := <-httpErr; err != nil && acceptErr == nil && ctx.Err() == nil {
+			acceptErr = err
+		}
+	}
+	s.closeConns()
+	s.connsWG.Wait()
+	if fifo != nil {
+		_ = fifo.Close()
+	}
+	fifoWG.Wait()
+	close(followUpsDone)
+	followUpsWG.Wait()
+	close(s.queue)
+	deliveryWG.Wait()
+
+	return acceptErr
+}
+
+// trackConn records the given connection as active.
+func (s *Server) trackConn(conn net.Conn) {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+
+	s.conns[conn] = struct{}{}
+	s.connsWG.Add(1)
+}
+
+// untrackConn closes the given connection and removes it from the
+// collection of active connections.
+func (s *Server) untrackConn(conn net.Conn) {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+
+	_ = conn.Close()
+	delete(s.conns, conn)
+	s.connsWG.Done()
+}
+
+// closeConns closes all active client connections.
+func (s *Server) closeConns() {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+}
+
+// handleConn decodes messages submitted by a client and responds to each
+// with the result of queuing it for delivery.
+func (s *Server) handleConn(conn net.Conn) {
+	defer s.untrackConn(conn)
+
+	decoder := json.NewDecoder(io.LimitReader(conn, maxMessageSize))
+	encoder := json.NewEncoder(conn)
+
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(clientIdleTimeout))
+
+		var req Request
+		err := decoder.Decode(&req)
+		switch {
+		case errors.Is(err, io.EOF):
+			return
+
+		case err != nil:
+			// The stream cannot be resynchronized after a decoding error.
+			_ = encoder.Encode(Response{Status: StatusRejected, Error: err.Error()})
+			return
+		}
+
+		if req.Command != "" {
+			_ = encoder.Encode(s.runCommand(req))
+			continue
+		}
+
+		_ = encoder.Encode(s.accept(req, "client"))
+	}
+}
+
+// accept queues the message submitted by the given request for delivery,
+// returning the response to send to the client. The source of the request is
+// noted in log messages.
+func (s *Server) accept(req Request, source string) Response {
+	followUpAfter, err := s.parseFollowUp(req)
+	if err != nil {
+		return Response{Status: StatusRejected, Error: err.Error()}
+	}
+
+	receiptID, duplicate, err := s.enqueue(req.Message, req.IdempotencyKey)
+	if err != nil {
+		if !s.cfg.SilentOutput {
+			log.Printf("Rejected message from %s: %v", source, err)
+		}
+		return Response{Status: StatusRejected, Error: err.Error()}
+	}
+
+	if duplicate && s.cfg.VerboseOutput {
+		log.Printf("Ignoring duplicate message with idempotency key %q (receipt %s)", req.IdempotencyKey, receiptID)
+	}
+
+	if !duplicate {
+		s.updateFollowUp(req, receiptID, followUpAfter)
+	}
+
+	return Response{Status: StatusQueued, ReceiptID: receiptID, Duplicate: duplicate}
+}
+
+// runCommand runs the given client command, returning the response to send
+// to the client.
+func (s *Server) runCommand(req Request) Response {
+	var err error
+	switch req.Command {
+	case CommandStatus:
+		status := s.Status()
+		return Response{Status: StatusOK, Queue: &status}
+
+	case CommandRetry:
+		err = s.Retry(req.ReceiptID)
+
+	case CommandDiscard:
+		err = s.Discard(req.ReceiptID)
+
+	default:
+		err = fmt.Errorf("%w: %q", ErrUnknownCommand, req.Command)
+	}
+
+	if err != nil {
+		return Response{Status: StatusRejected, ReceiptID: req.ReceiptID, Error: err.Error()}
+	}
+
+	if !s.cfg.SilentOutput {
+		log.Printf("Client requested %s of message (receipt %s)", req.Command, req.ReceiptID)
+	}
+
+	return Response{Status: StatusOK, ReceiptID: req.ReceiptID}
+}
+
+// enqueue validates the given message, checkpoints it in the journal and
+// places it in the delivery queue, returning the receipt ID assigned to it.
+// If a message with the given (optional) idempotency key was already
+// accepted, the receipt ID of that message is returned along with true.
+func (s *Server) enqueue(msg teams.Message, idempotencyKey string) (string, bool, error) {
+	if err := msg.Validate(); err != nil {
+		return "", false, err
+	}
+
+	if msg.Title == "" && s.cfg.AllowUntitled {
+		msg.Title = teams.DeriveTitle(msg.Text, msg.Sender)
+	}
+	msg.Title = s.cfg.DecorateTitle(msg.Title)
+
+	item := queueItem{
+		receiptID:      teams.NewReceiptID(),
+		idempotencyKey: idempotencyKey,
+		queued:         time.Now().UTC(),
+		msg:            msg,
+	}
+
+	if idempotencyKey != "" {
+		if receiptID, ok := s.journal.reserve(idempotencyKey, item.receiptID); !ok {
+			return receiptID, true, nil
+		}
+	}
+
+	// The message is only acknowledged once it is checkpointed.
+	if err := s.journal.save(item.entry()); err != nil {
+		s.forget(item.receiptID)
+		return "", false, err
+	}
+
+	s.queued(item)
+
+	select {
+	case s.queue <- item:
+		return item.receiptID, false, nil
+	default:
+		s.unqueued(item.receiptID)
+		s.forget(item.receiptID)
+		return "", false, ErrQueueFull
+	}
+}
+
+// forget removes the message with the given receipt ID from the journal,
+// logging any failure.
+func (s *Server) forget(receiptID string) {
+	if err := s.journal.discard(receiptID); err != nil && !s.cfg.SilentOutput {
+		log.Printf("WARNING: %v (receipt %s)", err, receiptID)
+	}
+}
+
+// checkpoint records the result of delivering the given message in the
+// journal, logging any failure. Failed messages remain in the journal so
+// that they may be retried after a restart.
+func (s *Server) checkpoint(item queueItem, sendErr error) {
+	var err error
+	switch {
+	case sendErr != nil:
+		entry := item.entry()
+		entry.Error = sendErr.Error()
+		err = s.journal.save(entry)
+
+	default:
+		err = s.journal.markDelivered(item.receiptID, item.idempotencyKey)
+	}
+
+	if err != nil && !s.cfg.SilentOutput {
+		log.Printf("WARNING: %v (receipt %s)", err, item.receiptID)
+	}
+}
+
+// deliver generates and submits a Microsoft Teams message, retrying
+// submission as needed up to the configured number of retry attempts.
+// Delivery waits while paused by the pause control file until the given
+// context is cancelled, after which the message is retained in the journal
+// for delivery after a restart.
+func (s *Server) deliver(ctx context.Context, item queueItem) {
+	msg := item.msg
+
+	if !s.sending(item.receiptID) {
+		if s.cfg.VerboseOutput {
+			log.Printf("Skipping discarded message %q (receipt %s)", msg.Title, item.receiptID)
+		}
+		return
+	}
+
+	opts := s.cfg.CardOptions(msg.Sender)
+	if s.cfg.ReceiptFact {
+		opts.ReceiptID = item.receiptID
+	}
+
+	message, err := teams.NewAdaptiveCardMessage(msg, opts)
+	if err != nil {
+		if !s.cfg.SilentOutput {
+			log.Printf("ERROR: Failed to generate message %q (receipt %s): %v", msg.Title, item.receiptID, err)
+		}
+		s.completed(item, err)
+		s.checkpoint(item, err)
+		return
+	}
+
+	sendErr := s.deliverer.DeliverResumable(ctx, s.cfg.TeamsSubmissionTimeout(), item.receiptID, s.cfg.WebhookURL, message)
+
+	if ctx.Err() != nil && errors.Is(sendErr, delivery.ErrPaused) {
+		if !s.cfg.SilentOutput {
+			log.Printf("WARNING: Message %q (receipt %s) retained for delivery after restart: %v", msg.Title, item.receiptID, sendErr)
+		}
+		return
+	}
+
+	ignoreSendErr := s.cfg.IgnoreInvalidResponse &&
+		errors.Is(sendErr, goteamsnotify.ErrInvalidWebhookURLResponseText)
+
+	if ignoreSendErr {
+		s.completed(item, nil)
+		s.checkpoint(item, nil)
+	} else {
+		s.completed(item, sendErr)
+		s.checkpoint(item, sendErr)
+	}
+
+	switch {
+	case ignoreSendErr:
+
+		if !s.cfg.SilentOutput {
+			log.Printf("WARNING: invalid response received for message %q (receipt %s); ignoring as requested: %v",
+				msg.Title, item.receiptID, sendErr)
+		}
+
+	case sendErr != nil:
+		if !s.cfg.SilentOutput {
+			log.Printf("ERROR: Failed to submit message %q (receipt %s) to %q channel in the %q team: %v",
+				msg.Title, item.receiptID, s.cfg.Channel, s.cfg.Team, sendErr)
+		}
+
+	default:
+		if s.cfg.VerboseOutput {
+			log.Printf("Message %q successfully sent (receipt %s)", msg.Title, item.receiptID)
+		}
+	}
+}
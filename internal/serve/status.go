@@ -0,0 +1,348 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package serve
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/atc0005/send2teams/internal/breaker"
+)
+
+// maxRecentItems is the number of completed deliveries retained for display
+// to clients.
+const maxRecentItems int = 20
+
+// maxFailedItems is the number of failed messages retained so that they may
+// be retried or discarded by clients.
+const maxFailedItems int = 100
+
+// throttledResponseStatus is included in submission errors when Microsoft
+// Teams rejects a message due to rate limiting.
+const throttledResponseStatus string = "429 Too Many Requests"
+
+// Delivery state values reported for each message.
+const (
+	StateQueued    string = "queued"
+	StateSending   string = "sending"
+	StateSent      string = "sent"
+	StateFailed    string = "failed"
+	StateDiscarded string = "discarded"
+)
+
+// ErrUnknownReceipt indicates that a command referenced a message which is
+// not waiting for delivery and is not retained after a failed delivery.
+var ErrUnknownReceipt = errors.New("no queued or failed message with the given receipt ID")
+
+// ItemStatus describes the delivery state of a single message.
+type ItemStatus struct {
+
+	// Queued is when the message was accepted for delivery.
+	Queued time.Time `json:"queued"`
+
+	// Completed is when the last delivery attempt finished.
+	Completed time.Time `json:"completed,omitempty"`
+
+	// ReceiptID is the unique identifier assigned to the message.
+	ReceiptID string `json:"receipt_id"`
+
+	// Title is the title of the message.
+	Title string `json:"title"`
+
+	// State is the delivery state of the message.
+	State string `json:"state"`
+
+	// Error is the reason the last delivery attempt failed.
+	Error string `json:"error,omitempty"`
+}
+
+// QueueStatus is a snapshot of the serve mode delivery queue.
+type QueueStatus struct {
+
+	// Pending is the collection of messages waiting for (or currently
+	// being) delivered, oldest first.
+	Pending []ItemStatus `json:"pending"`
+
+	// Recent is the collection of recently completed deliveries, newest
+	// first.
+	Recent []ItemStatus `json:"recent"`
+
+	// Failed is the collection of failed messages which may be retried or
+	// discarded, oldest first.
+	Failed []ItemStatus `json:"failed"`
+
+	// Depth is the number of messages waiting in the delivery queue.
+	Depth int `json:"depth"`
+
+	// Capacity is the maximum number of messages which may wait in the
+	// delivery queue.
+	Capacity int `json:"capacity"`
+
+	// SentTotal is the number of messages delivered since startup.
+	SentTotal int `json:"sent_total"`
+
+	// FailedTotal is the number of failed delivery attempts since startup.
+	FailedTotal int `json:"failed_total"`
+
+	// Throttled indicates whether the last delivery attempt was rejected by
+	// Microsoft Teams due to rate limiting.
+	Throttled bool `json:"throttled"`
+
+	// Paused indicates whether delivery is paused by the pause control
+	// file.
+	Paused bool `json:"paused"`
+
+	// Circuit is the state of the circuit breaker for the webhook URL. Nil
+	// if circuit breakers are not enabled.
+	Circuit *breaker.Status `json:"circuit,omitempty"`
+}
+
+// tracker records the delivery state of messages accepted by a Server.
+type tracker struct {
+	pending     []*ItemStatus
+	recent      []ItemStatus
+	failed      []queueItem
+	failedState map[string]ItemStatus
+	discarded   map[string]struct{}
+	sentTotal   int
+	failedTotal int
+	throttled   bool
+}
+
+// newTracker creates an empty tracker.
+func newTracker() tracker {
+	return tracker{
+		failedState: make(map[string]ItemStatus),
+		discarded:   make(map[string]struct{}),
+	}
+}
+
+// queued records that the given message was accepted for delivery.
+func (s *Server) queued(item queueItem) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	s.state.pending = append(s.state.pending, &ItemStatus{
+		Queued:    item.queued,
+		ReceiptID: item.receiptID,
+		Title:     item.msg.Title,
+		State:     StateQueued,
+	})
+}
+
+// unqueued removes the record of a message which could not be placed in the
+// delivery queue.
+func (s *Server) unqueued(receiptID string) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	s.removePending(receiptID)
+}
+
+// removePending removes and returns the pending record for the given
+// message. The caller must hold stateMu.
+func (s *Server) removePending(receiptID string) *ItemStatus {
+	for i, item := range s.state.pending {
+		if item.ReceiptID == receiptID {
+			s.state.pending = append(s.state.pending[:i], s.state.pending[i+1:]...)
+			return item
+		}
+	}
+
+	return nil
+}
+
+// sending records that delivery of the given message is starting. False is
+// returned if the message was discarded while waiting in the queue.
+func (s *Server) sending(receiptID string) bool {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	if _, ok := s.state.discarded[receiptID]; ok {
+		delete(s.state.discarded, receiptID)
+		return false
+	}
+
+	for _, item := range s.state.pending {
+		if item.ReceiptID == receiptID {
+			item.State = StateSending
+		}
+	}
+
+	return true
+}
+
+// completed records the result of delivering the given message. Failed
+// messages are retained so that they may be retried or discarded.
+func (s *Server) completed(item queueItem, sendErr error) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	status := s.removePending(item.receiptID)
+	if status == nil {
+		status = &ItemStatus{ReceiptID: item.receiptID, Title: item.msg.Title}
+	}
+	status.Completed = time.Now().UTC()
+
+	switch {
+	case sendErr != nil:
+		status.State = StateFailed
+		status.Error = sendErr.Error()
+		s.state.failedTotal++
+		s.state.throttled = strings.Contains(sendErr.Error(), throttledResponseStatus)
+
+		if len(s.state.failed) == maxFailedItems {
+			evicted := s.state.failed[0]
+			delete(s.state.failedState, evicted.receiptID)
+			s.state.failed = s.state.failed[1:]
+			s.forget(evicted.receiptID)
+		}
+		s.state.failed = append(s.state.failed, item)
+		s.state.failedState[item.receiptID] = *status
+
+	default:
+		status.State = StateSent
+		s.state.sentTotal++
+		s.state.throttled = false
+	}
+
+	s.state.recent = append([]ItemStatus{*status}, s.state.recent...)
+	if len(s.state.recent) > maxRecentItems {
+		s.state.recent = s.state.recent[:maxRecentItems]
+	}
+}
+
+// takeFailed removes and returns the retained failed message with the given
+// receipt ID along with its status and position among the failed messages.
+// The caller must hold stateMu.
+func (s *Server) takeFailed(receiptID string) (queueItem, ItemStatus, int, bool) {
+	for i, item := range s.state.failed {
+		if item.receiptID == receiptID {
+			status := s.state.failedState[receiptID]
+			s.state.failed = append(s.state.failed[:i], s.state.failed[i+1:]...)
+			delete(s.state.failedState, receiptID)
+			return item, status, i, true
+		}
+	}
+
+	return queueItem{}, ItemStatus{}, 0, false
+}
+
+// restoreFailed returns a message removed by takeFailed to its previous
+// position among the failed messages. The caller must hold stateMu.
+func (s *Server) restoreFailed(item queueItem, status ItemStatus, index int) {
+	if index > len(s.state.failed) {
+		index = len(s.state.failed)
+	}
+
+	s.state.failed = append(s.state.failed, queueItem{})
+	copy(s.state.failed[index+1:], s.state.failed[index:])
+	s.state.failed[index] = item
+	s.state.failedState[item.receiptID] = status
+}
+
+// Status returns a snapshot of the delivery queue.
+func (s *Server) Status() QueueStatus {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	status := QueueStatus{
+		Pending:     make([]ItemStatus, 0, len(s.state.pending)),
+		Recent:      append([]ItemStatus{}, s.state.recent...),
+		Failed:      make([]ItemStatus, 0, len(s.state.failed)),
+		Depth:       len(s.queue),
+		Capacity:    cap(s.queue),
+		SentTotal:   s.state.sentTotal,
+		FailedTotal: s.state.failedTotal,
+		Throttled:   s.state.throttled,
+		Paused:      s.deliverer.Paused(),
+	}
+
+	if circuit, ok := s.deliverer.CircuitStatus(s.cfg.WebhookURL); ok {
+		status.Circuit = &circuit
+	}
+
+	for _, item := range s.state.pending {
+		status.Pending = append(status.Pending, *item)
+	}
+
+	for _, item := range s.state.failed {
+		status.Failed = append(status.Failed, s.state.failedState[item.receiptID])
+	}
+
+	return status
+}
+
+// Retry places the retained failed message with the given receipt ID back
+// in the delivery queue. If the delivery queue is full, ErrQueueFull is
+// returned and the message is retained as before.
+func (s *Server) Retry(receiptID string) error {
+	s.stateMu.Lock()
+	item, prior, index, ok := s.takeFailed(receiptID)
+	s.stateMu.Unlock()
+
+	if !ok {
+		return ErrUnknownReceipt
+	}
+
+	s.queued(item)
+
+	select {
+	case s.queue <- item:
+		// A restart before delivery recovers the message as pending.
+		if err := s.journal.save(item.entry()); err != nil && !s.cfg.SilentOutput {
+			log.Printf("WARNING: %v (receipt %s)", err, receiptID)
+		}
+		return nil
+	default:
+		s.stateMu.Lock()
+		defer s.stateMu.Unlock()
+
+		// The message remains failed as if the retry was not requested.
+		s.removePending(receiptID)
+		s.restoreFailed(item, prior, index)
+
+		return ErrQueueFull
+	}
+}
+
+// Discard drops the queued or retained failed message with the given
+// receipt ID. A message which is currently being delivered cannot be
+// discarded.
+func (s *Server) Discard(receiptID string) error {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	if _, _, _, ok := s.takeFailed(receiptID); ok {
+		s.forget(receiptID)
+		return nil
+	}
+
+	for _, item := range s.state.pending {
+		if item.ReceiptID == receiptID && item.State == StateQueued {
+			s.removePending(receiptID)
+			s.forget(receiptID)
+			s.state.discarded[receiptID] = struct{}{}
+			s.state.recent = append([]ItemStatus{{
+				Queued:    item.Queued,
+				Completed: time.Now().UTC(),
+				ReceiptID: receiptID,
+				Title:     item.Title,
+				State:     StateDiscarded,
+			}}, s.state.recent...)
+			if len(s.state.recent) > maxRecentItems {
+				s.state.recent = s.state.recent[:maxRecentItems]
+			}
+			return nil
+		}
+	}
+
+	return ErrUnknownReceipt
+}
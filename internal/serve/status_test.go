@@ -0,0 +1,168 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package serve
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	goteamsnotify "github.com/atc0005/go-teams-notify/v2"
+	"github.com/atc0005/send2teams/internal/config"
+	"github.com/atc0005/send2teams/internal/delivery"
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// newTestServer creates a Server whose delivery queue is not consumed.
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	cfg := config.Config{SilentOutput: true, FollowUpDir: t.TempDir()}
+
+	deliverer, err := delivery.New(&cfg, goteamsnotify.NewTeamsClient())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := New(&cfg, deliverer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+// submit queues a message with the given title, returning its receipt ID.
+func submit(t *testing.T, s *Server, title string) string {
+	t.Helper()
+
+	resp := s.accept(Request{Message: teams.Message{Title: title, Text: "Hello"}}, "test")
+	if resp.Status != StatusQueued {
+		t.Fatalf("got %+v; want message queued", resp)
+	}
+
+	return resp.ReceiptID
+}
+
+// fail takes the next message from the delivery queue and records that its
+// delivery failed.
+func fail(t *testing.T, s *Server) queueItem {
+	t.Helper()
+
+	item := <-s.queue
+	if !s.sending(item.receiptID) {
+		t.Fatalf("message %s unexpectedly discarded", item.receiptID)
+	}
+	s.completed(item, errors.New("500 Internal Server Error"))
+
+	return item
+}
+
+func TestRetryFailed(t *testing.T) {
+	s := newTestServer(t)
+
+	receiptID := submit(t, s, "Failing")
+	fail(t, s)
+
+	status := s.Status()
+	if len(status.Failed) != 1 || status.Failed[0].ReceiptID != receiptID || status.Failed[0].State != StateFailed {
+		t.Fatalf("got failed %+v; want %s failed", status.Failed, receiptID)
+	}
+
+	if resp := s.runCommand(Request{Command: CommandRetry, ReceiptID: receiptID}); resp.Status != StatusOK {
+		t.Fatalf("got %+v; want retry accepted", resp)
+	}
+
+	status = s.Status()
+	if len(status.Failed) != 0 {
+		t.Errorf("got failed %+v after retry; want none", status.Failed)
+	}
+	if len(status.Pending) != 1 || status.Pending[0].ReceiptID != receiptID || status.Pending[0].State != StateQueued {
+		t.Errorf("got pending %+v after retry; want %s queued", status.Pending, receiptID)
+	}
+
+	if item := <-s.queue; item.receiptID != receiptID {
+		t.Errorf("got %s queued for delivery; want %s", item.receiptID, receiptID)
+	}
+}
+
+func TestDiscardQueued(t *testing.T) {
+	s := newTestServer(t)
+
+	receiptID := submit(t, s, "Unwanted")
+
+	if resp := s.runCommand(Request{Command: CommandDiscard, ReceiptID: receiptID}); resp.Status != StatusOK {
+		t.Fatalf("got %+v; want discard accepted", resp)
+	}
+
+	status := s.Status()
+	if len(status.Pending) != 0 {
+		t.Errorf("got pending %+v after discard; want none", status.Pending)
+	}
+	if len(status.Recent) != 1 || status.Recent[0].ReceiptID != receiptID || status.Recent[0].State != StateDiscarded {
+		t.Errorf("got recent %+v after discard; want %s discarded", status.Recent, receiptID)
+	}
+
+	// The discarded message is skipped when taken from the delivery queue.
+	item := <-s.queue
+	if s.sending(item.receiptID) {
+		t.Errorf("got discarded message %s delivered", item.receiptID)
+	}
+}
+
+func TestRetryQueueFull(t *testing.T) {
+	s := newTestServer(t)
+
+	first := submit(t, s, "First")
+	second := submit(t, s, "Second")
+	fail(t, s)
+	fail(t, s)
+
+	for i := 0; i < queueSize; i++ {
+		submit(t, s, "Filler")
+	}
+
+	prior := s.Status()
+
+	resp := s.runCommand(Request{Command: CommandRetry, ReceiptID: first})
+	if resp.Status != StatusRejected || resp.Error != ErrQueueFull.Error() {
+		t.Fatalf("got %+v; want retry rejected with %q", resp, ErrQueueFull)
+	}
+
+	status := s.Status()
+	if !reflect.DeepEqual(status.Failed, prior.Failed) {
+		t.Errorf("got failed %+v after rejected retry; want %+v", status.Failed, prior.Failed)
+	}
+	if status.Failed[0].ReceiptID != first || status.Failed[1].ReceiptID != second {
+		t.Errorf("got failed %+v; want %s then %s", status.Failed, first, second)
+	}
+	if !reflect.DeepEqual(status.Pending, prior.Pending) {
+		t.Errorf("got %d pending after rejected retry; want %d", len(status.Pending), len(prior.Pending))
+	}
+}
+
+func TestCommandUnknownReceipt(t *testing.T) {
+	s := newTestServer(t)
+
+	receiptID := submit(t, s, "Queued")
+
+	for _, command := range []string{CommandRetry, CommandDiscard} {
+		t.Run(command, func(t *testing.T) {
+			resp := s.runCommand(Request{Command: command, ReceiptID: "unknown"})
+			if resp.Status != StatusRejected || resp.Error != ErrUnknownReceipt.Error() {
+				t.Errorf("got %+v; want rejection with %q", resp, ErrUnknownReceipt)
+			}
+		})
+	}
+
+	// A queued message has not failed, so it cannot be retried.
+	resp := s.runCommand(Request{Command: CommandRetry, ReceiptID: receiptID})
+	if resp.Status != StatusRejected || resp.Error != ErrUnknownReceipt.Error() {
+		t.Errorf("got %+v; want retry of queued message rejected with %q", resp, ErrUnknownReceipt)
+	}
+}
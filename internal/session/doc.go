@@ -0,0 +1,19 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package session records the outcome of each message submitted under a
+user-specified session ID so that a single summary of all sends performed
+by a long-running, multi-step script can be posted once the script
+completes.
+
+Each session is stored as a file of newline delimited JSON records within a
+session directory. Records are appended by each invocation and claimed
+(and removed) when the summary is posted, so that records appended while the
+summary is posted are kept for a later summary.
+*/
+package session
@@ -0,0 +1,252 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package session
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// maxIDLength is the maximum length of a session ID.
+const maxIDLength int = 128
+
+// validID matches the session IDs accepted by this package. Session IDs are
+// used as file names and are restricted accordingly.
+var validID = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ErrInvalidID indicates that a session ID is not suitable for use.
+var ErrInvalidID = errors.New("invalid session ID")
+
+// ErrNoRecords indicates that no sends have been recorded for a session.
+var ErrNoRecords = errors.New("no sends recorded for session")
+
+// Record is the outcome of a single message submitted under a session.
+type Record struct {
+	Time      time.Time `json:"time"`
+	ReceiptID string    `json:"receipt_id"`
+	Title     string    `json:"title,omitempty"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// ValidateID asserts that the given session ID is suitable for use.
+func ValidateID(id string) error {
+	switch {
+	case id == "":
+		return fmt.Errorf("%w: session ID not specified", ErrInvalidID)
+	case len(id) > maxIDLength:
+		return fmt.Errorf("%w: %q exceeds %d characters", ErrInvalidID, id, maxIDLength)
+	case !validID.MatchString(id):
+		return fmt.Errorf(
+			"%w: %q; only letters, digits, '.', '_' and '-' are permitted",
+			ErrInvalidID,
+			id,
+		)
+	}
+
+	return nil
+}
+
+// path returns the path to the file used to store records for the given
+// session ID within the given directory.
+func path(dir string, id string) (string, error) {
+	if err := ValidateID(id); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, id+".jsonl"), nil
+}
+
+// Append adds the given record to the session with the given ID within the
+// given directory, creating the directory and session as needed.
+func Append(dir string, id string, rec Record) error {
+	file, err := path(dir, id)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create session directory: %w", err)
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode session record: %w", err)
+	}
+
+	// Each record is written with a single append so that records from
+	// concurrent invocations are not interleaved.
+	f, err := os.OpenFile(filepath.Clean(file), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open session %s: %w", id, err)
+	}
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to record send for session %s: %w", id, err)
+	}
+
+	return f.Close()
+}
+
+// Load returns all records for the session with the given ID within the
+// given directory, in the order recorded. ErrNoRecords is returned if no
+// sends have been recorded.
+func Load(dir string, id string) ([]Record, error) {
+	file, err := path(dir, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return readRecords(file, id)
+}
+
+// readRecords returns all records within the given file for the session with
+// the given ID, in the order recorded.
+func readRecords(file string, id string) ([]Record, error) {
+	data, err := os.ReadFile(filepath.Clean(file))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, fmt.Errorf("%w %s", ErrNoRecords, id)
+	case err != nil:
+		return nil, fmt.Errorf("failed to read session %s: %w", id, err)
+	}
+
+	var records []Record
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("failed to decode session %s record %d: %w", id, line, err)
+		}
+		records = append(records, rec)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read session %s: %w", id, err)
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w %s", ErrNoRecords, id)
+	}
+
+	return records, nil
+}
+
+// Remove discards all records for the session with the given ID within the
+// given directory.
+func Remove(dir string, id string) error {
+	file, err := path(dir, id)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove session %s: %w", id, err)
+	}
+
+	return nil
+}
+
+// Claimed is the collection of records for a session claimed by Claim.
+type Claimed struct {
+
+	// Records are the claimed records, in the order recorded.
+	Records []Record
+
+	dir  string
+	id   string
+	file string
+}
+
+// Claim takes all records for the session with the given ID within the given
+// directory by renaming the session file to a unique name, so that sends
+// recorded afterwards are retained for a later summary rather than removed
+// along with the claimed records. ErrNoRecords is returned if no sends have
+// been recorded. The claimed records must be removed using Remove once they
+// have been summarized, or returned to the session using Restore.
+func Claim(dir string, id string) (*Claimed, error) {
+	file, err := path(dir, id)
+	if err != nil {
+		return nil, err
+	}
+
+	claimed := fmt.Sprintf("%s.%d-%d.claimed", file, os.Getpid(), time.Now().UnixNano())
+
+	switch err := os.Rename(file, claimed); {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, fmt.Errorf("%w %s", ErrNoRecords, id)
+	case err != nil:
+		return nil, fmt.Errorf("failed to claim session %s: %w", id, err)
+	}
+
+	records, err := readRecords(claimed, id)
+	if err != nil {
+		c := Claimed{dir: dir, id: id, file: claimed}
+		if restoreErr := c.Restore(); restoreErr != nil {
+			return nil, errors.Join(err, restoreErr)
+		}
+		return nil, err
+	}
+
+	return &Claimed{Records: records, dir: dir, id: id, file: claimed}, nil
+}
+
+// Remove discards the claimed records.
+func (c *Claimed) Remove() error {
+	if err := os.Remove(c.file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove claimed session %s: %w", c.id, err)
+	}
+
+	return nil
+}
+
+// Restore returns the claimed records to the session, after any sends
+// recorded since they were claimed.
+func (c *Claimed) Restore() error {
+	data, err := os.ReadFile(filepath.Clean(c.file))
+	if err != nil {
+		return fmt.Errorf("failed to read claimed session %s: %w", c.id, err)
+	}
+
+	file, err := path(c.dir, c.id)
+	if err != nil {
+		return err
+	}
+
+	// The records are written with a single append, as by Append.
+	f, err := os.OpenFile(filepath.Clean(file), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open session %s: %w", c.id, err)
+	}
+
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to restore records for session %s: %w", c.id, err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to restore records for session %s: %w", c.id, err)
+	}
+
+	return c.Remove()
+}
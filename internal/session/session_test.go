@@ -0,0 +1,138 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package session
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAppendLoadSummarize(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)
+
+	records := []Record{
+		{Time: start, ReceiptID: "a", Title: "Step 1", Status: "sent"},
+		{Time: start.Add(time.Minute), ReceiptID: "b", Title: "Step 2", Status: "failed", Error: "500 Internal Server Error"},
+		{Time: start.Add(5 * time.Minute), ReceiptID: "c", Title: "Step 3", Status: "sent"},
+	}
+
+	for _, rec := range records {
+		if err := Append(dir, "maint-42", rec); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	loaded, err := Load(dir, "maint-42")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	summary := Summarize("maint-42", loaded)
+
+	switch {
+	case summary.Total != 3:
+		t.Errorf("Total = %d; want 3", summary.Total)
+	case summary.Counts["sent"] != 2 || summary.Counts["failed"] != 1:
+		t.Errorf("Counts = %v; want 2 sent, 1 failed", summary.Counts)
+	case !summary.First.Equal(start) || !summary.Last.Equal(start.Add(5*time.Minute)):
+		t.Errorf("First, Last = %v, %v; want %v, %v", summary.First, summary.Last, start, start.Add(5*time.Minute))
+	case len(summary.Failures) != 1 || summary.Failures[0].ReceiptID != "b":
+		t.Errorf("Failures = %v; want receipt b", summary.Failures)
+	}
+
+	if err := Remove(dir, "maint-42"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+
+	if _, err := Load(dir, "maint-42"); !errors.Is(err, ErrNoRecords) {
+		t.Errorf("Load() after Remove() error = %v; want %v", err, ErrNoRecords)
+	}
+
+	if err := Append(dir, "../escape", records[0]); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("Append() with invalid ID error = %v; want %v", err, ErrInvalidID)
+	}
+}
+
+func TestClaim(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)
+
+	appendRecord := func(receiptID string) {
+		t.Helper()
+		if err := Append(dir, "maint-42", Record{Time: start, ReceiptID: receiptID, Status: "sent"}); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	receiptIDs := func(records []Record) []string {
+		ids := make([]string, 0, len(records))
+		for _, rec := range records {
+			ids = append(ids, rec.ReceiptID)
+		}
+		return ids
+	}
+
+	appendRecord("a")
+	appendRecord("b")
+
+	claimed, err := Claim(dir, "maint-42")
+	if err != nil {
+		t.Fatalf("Claim() error = %v", err)
+	}
+	if got := receiptIDs(claimed.Records); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Fatalf("Claim() records = %v; want [a b]", got)
+	}
+
+	// A send recorded while the summary is posted is kept for the next
+	// summary.
+	appendRecord("c")
+	if err := claimed.Remove(); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+
+	loaded, err := Load(dir, "maint-42")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := receiptIDs(loaded); !reflect.DeepEqual(got, []string{"c"}) {
+		t.Errorf("Load() after Remove() records = %v; want [c]", got)
+	}
+
+	// Claimed records are returned if the summary is not posted.
+	if claimed, err = Claim(dir, "maint-42"); err != nil {
+		t.Fatalf("Claim() error = %v", err)
+	}
+	appendRecord("d")
+	if err := claimed.Restore(); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	loaded, err = Load(dir, "maint-42")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := receiptIDs(loaded); !reflect.DeepEqual(got, []string{"d", "c"}) {
+		t.Errorf("Load() after Restore() records = %v; want [d c]", got)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("got %d files in session directory; want only the session file", len(entries))
+	}
+
+	if _, err := Claim(dir, "other"); !errors.Is(err, ErrNoRecords) {
+		t.Errorf("Claim() of unknown session error = %v; want %v", err, ErrNoRecords)
+	}
+}
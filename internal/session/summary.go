@@ -0,0 +1,131 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package session
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxSummaryFailures is the maximum number of failed sends listed
+// individually in a summary.
+const maxSummaryFailures int = 10
+
+// maxSummaryErrorLength is the maximum number of characters shown for each
+// failed send error in a summary.
+const maxSummaryErrorLength int = 200
+
+// Summary aggregates the records for a session.
+type Summary struct {
+
+	// First is the time of the earliest recorded send.
+	First time.Time
+
+	// Last is the time of the latest recorded send.
+	Last time.Time
+
+	// Counts is the number of recorded sends for each status.
+	Counts map[string]int
+
+	// ID is the session ID.
+	ID string
+
+	// Failures is the collection of sends which resulted in an error.
+	Failures []Record
+
+	// Total is the number of recorded sends.
+	Total int
+}
+
+// Summarize aggregates the given records for the session with the given ID.
+func Summarize(id string, records []Record) Summary {
+	s := Summary{
+		ID:     id,
+		Counts: make(map[string]int),
+		Total:  len(records),
+	}
+
+	for i, rec := range records {
+		if i == 0 || rec.Time.Before(s.First) {
+			s.First = rec.Time
+		}
+		if i == 0 || rec.Time.After(s.Last) {
+			s.Last = rec.Time
+		}
+
+		s.Counts[rec.Status]++
+
+		if rec.Error != "" {
+			s.Failures = append(s.Failures, rec)
+		}
+	}
+
+	return s
+}
+
+// Text returns a Markdown formatted description of the summary suitable for
+// use as message text.
+func (s Summary) Text() string {
+	var output strings.Builder
+
+	fmt.Fprintf(
+		&output,
+		"Session `%s` recorded %d send(s) between %s and %s (%s).",
+		s.ID,
+		s.Total,
+		s.First.UTC().Format(time.RFC3339),
+		s.Last.UTC().Format(time.RFC3339),
+		s.Last.Sub(s.First).Round(time.Second),
+	)
+
+	statuses := make([]string, 0, len(s.Counts))
+	for status := range s.Counts {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+
+	output.WriteString("\n")
+	for _, status := range statuses {
+		fmt.Fprintf(&output, "\n- %s: %d", status, s.Counts[status])
+	}
+
+	if len(s.Failures) == 0 {
+		return output.String()
+	}
+
+	output.WriteString("\n\nFailures:\n")
+	for i, rec := range s.Failures {
+		if i == maxSummaryFailures {
+			fmt.Fprintf(&output, "\n- ... and %d more", len(s.Failures)-maxSummaryFailures)
+			break
+		}
+
+		title := rec.Title
+		if title == "" {
+			title = "(untitled)"
+		}
+
+		errText := rec.Error
+		if runes := []rune(errText); len(runes) > maxSummaryErrorLength {
+			errText = string(runes[:maxSummaryErrorLength]) + "..."
+		}
+
+		fmt.Fprintf(
+			&output,
+			"\n- %s %s (receipt %s): %s",
+			rec.Time.UTC().Format(time.RFC3339),
+			title,
+			rec.ReceiptID,
+			errText,
+		)
+	}
+
+	return output.String()
+}
@@ -0,0 +1,20 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package spool reads the messages dropped as files into the spool directory
+watched by the watch-spool subcommand.
+
+Each file describes a single message. Files with a .json extension contain a
+JSON object in the format of a batch file entry; the content of any other
+file is used as the message text. Files are picked up once they have not
+been modified for the settle period, oldest first. Hidden files and files
+with a .tmp or .part extension are ignored so that producers may write a
+file under a temporary name before renaming it into place, as are files
+which could not be sent and were set aside with a .failed extension.
+*/
+package spool
@@ -0,0 +1,227 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package spool
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/send2teams/internal/batch"
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// MaxFileSize is the maximum size (in bytes) of a spooled file.
+const MaxFileSize int64 = batch.MaxEntrySize
+
+// FailedSuffix is appended to the name of a spooled file which does not
+// describe a valid message, setting it aside for inspection.
+const FailedSuffix string = ".failed"
+
+// jsonExt is the extension of spooled files containing a JSON object in the
+// format of a batch file entry.
+const jsonExt string = ".json"
+
+// ignoredExts are the extensions of files which are not picked up from the
+// spool directory.
+var ignoredExts = []string{".tmp", ".part", FailedSuffix}
+
+// File is a file dropped into the spool directory.
+type File struct {
+
+	// Path is the path of the file.
+	Path string
+
+	// Name is the name of the file within the spool directory.
+	Name string
+
+	// ModTime is when the file was last modified.
+	ModTime time.Time
+}
+
+// Options control how the spool directory is watched.
+type Options struct {
+
+	// Interval is how often the spool directory is checked for files.
+	Interval time.Duration
+
+	// Settle is how long a file must remain unmodified before it is picked
+	// up, so that files which are still being written are not sent.
+	Settle time.Duration
+}
+
+// Scan returns the files in the given spool directory which have not been
+// modified for the given settle period as of now, oldest first.
+// Subdirectories and ignored files are skipped.
+func Scan(dir string, settle time.Duration, now time.Time) ([]File, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool directory: %w", err)
+	}
+
+	var files []File
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || ignored(name) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			// The file was removed since the directory was read.
+			continue
+		}
+
+		if now.Sub(info.ModTime()) < settle {
+			continue
+		}
+
+		files = append(files, File{
+			Path:    filepath.Join(dir, name),
+			Name:    name,
+			ModTime: info.ModTime(),
+		})
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		if !files[i].ModTime.Equal(files[j].ModTime) {
+			return files[i].ModTime.Before(files[j].ModTime)
+		}
+		return files[i].Name < files[j].Name
+	})
+
+	return files, nil
+}
+
+// ignored indicates whether the file with the given name is not picked up
+// from the spool directory.
+func ignored(name string) bool {
+	if strings.HasPrefix(name, ".") {
+		return true
+	}
+
+	ext := filepath.Ext(name)
+	for _, ignoredExt := range ignoredExts {
+		if strings.EqualFold(ext, ignoredExt) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Watch checks the given spool directory at the given interval until the
+// context is canceled, calling handle for each file picked up. Files left
+// in place by handle (e.g., because the message could not be sent) are
+// picked up again by the next check. If handle returns an error, the
+// remaining files are left for the next check. An error is returned if the
+// spool directory cannot be read.
+func Watch(ctx context.Context, dir string, opts Options, handle func(File) error) error {
+	if opts.Interval <= 0 {
+		return fmt.Errorf("spool interval too short")
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read spool directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("spool path %s is not a directory", dir)
+	}
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	// Files spooled while the directory was not watched are picked up
+	// immediately.
+	for {
+		files, err := Scan(dir, opts.Settle, time.Now())
+		if err != nil {
+			return err
+		}
+
+		for _, file := range files {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			if err := handle(file); err != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// ReadEntry returns the message described by the given spooled file. An
+// error wrapping batch.ErrInvalidEntry is returned if the file does not
+// describe a valid message.
+func ReadEntry(file File) (batch.Entry, error) {
+	f, err := os.Open(file.Path)
+	if err != nil {
+		return batch.Entry{}, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, MaxFileSize+1))
+	if err != nil {
+		return batch.Entry{}, err
+	}
+
+	if int64(len(data)) > MaxFileSize {
+		return batch.Entry{}, fmt.Errorf("%w: file exceeds %d bytes", batch.ErrInvalidEntry, MaxFileSize)
+	}
+
+	if strings.EqualFold(filepath.Ext(file.Name), jsonExt) {
+		return batch.ParseEntry(data)
+	}
+
+	entry := batch.Entry{Message: teams.Message{Text: strings.ToValidUTF8(string(data), "")}}
+
+	return entry, entry.Validate()
+}
+
+// Archive moves the given spooled file to the given archive directory,
+// prefixing its name with the given receipt ID so that it may be correlated
+// with the log entries and history for the message. The file is removed if
+// no archive directory is specified.
+func Archive(file File, archiveDir string, receiptID string) error {
+	if archiveDir == "" {
+		if err := os.Remove(file.Path); err != nil {
+			return fmt.Errorf("failed to remove spooled file: %w", err)
+		}
+		return nil
+	}
+
+	if err := os.Rename(file.Path, filepath.Join(archiveDir, receiptID+"-"+file.Name)); err != nil {
+		return fmt.Errorf("failed to archive spooled file: %w", err)
+	}
+
+	return nil
+}
+
+// MarkFailed renames the given spooled file with FailedSuffix so that it is
+// no longer picked up, returning the new path.
+func MarkFailed(file File) (string, error) {
+	path := file.Path + FailedSuffix
+	if err := os.Rename(file.Path, path); err != nil {
+		return "", fmt.Errorf("failed to set aside spooled file: %w", err)
+	}
+
+	return path, nil
+}
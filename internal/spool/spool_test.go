@@ -0,0 +1,125 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package spool
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/atc0005/send2teams/internal/batch"
+)
+
+func TestScan(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+
+	files := map[string]time.Duration{
+		"second.txt":      2 * time.Minute,
+		"first.json":      3 * time.Minute,
+		"recent.txt":      time.Second,
+		".hidden":         time.Hour,
+		"partial.tmp":     time.Hour,
+		"upload.part":     time.Hour,
+		"bad.json.failed": time.Hour,
+	}
+	for name, age := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("text"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "subdir"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	scanned, err := Scan(dir, time.Minute, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, file := range scanned {
+		got = append(got, file.Name)
+	}
+
+	want := []string{"first.json", "second.txt"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got files %q; want %q", got, want)
+	}
+}
+
+func TestReadEntry(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		content  string
+		wantText string
+		wantErr  error
+	}{
+		{name: "report.txt", content: "Disk usage high\n", wantText: "Disk usage high\n"},
+		{name: "alert.json", content: "{\n  \"title\": \"Disk\",\n  \"text\": \"Usage high\"\n}\n", wantText: "Usage high"},
+		{name: "empty.txt", content: " \n", wantErr: batch.ErrInvalidEntry},
+		{name: "broken.json", content: "{\"text\": ", wantErr: batch.ErrInvalidEntry},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			entry, err := ReadEntry(File{Path: path, Name: tt.name})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v; want %v", err, tt.wantErr)
+			}
+
+			if err == nil && entry.Text != tt.wantText {
+				t.Errorf("got text %q; want %q", entry.Text, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestArchive(t *testing.T) {
+	dir := t.TempDir()
+	archiveDir := t.TempDir()
+
+	for _, name := range []string{"archived.txt", "removed.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("text"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := Archive(File{Path: filepath.Join(dir, "archived.txt"), Name: "archived.txt"}, archiveDir, "receipt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(archiveDir, "receipt-archived.txt")); err != nil {
+		t.Errorf("archived file not found: %v", err)
+	}
+
+	if err := Archive(File{Path: filepath.Join(dir, "removed.txt"), Name: "removed.txt"}, "", "receipt"); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("got %d file(s) left in spool directory; want none", len(entries))
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package teams
+
+import (
+	"strings"
+)
+
+// minAlignedLines is the minimum number of consecutive column-aligned lines
+// placed in a code block by FenceAlignedBlocks.
+const minAlignedLines int = 2
+
+// minColumnGap is the minimum number of spaces separating the columns of a
+// column-aligned line.
+const minColumnGap int = 2
+
+// ExpandTabs replaces the tab characters in each line of the given text with
+// spaces up to the next tab stop, placed every width columns.
+func ExpandTabs(text string, width int) string {
+	if width < 1 || !strings.Contains(text, "\t") {
+		return text
+	}
+
+	var b strings.Builder
+	b.Grow(len(text))
+
+	column := 0
+	for _, r := range text {
+		switch r {
+		case '\t':
+			spaces := width - column%width
+			b.WriteString(strings.Repeat(" ", spaces))
+			column += spaces
+		case '\n', '\r':
+			b.WriteRune(r)
+			column = 0
+		default:
+			b.WriteRune(r)
+			column++
+		}
+	}
+
+	return b.String()
+}
+
+// FenceAlignedBlocks places runs of column-aligned lines (e.g., the output
+// of df or netstat) of the given text within Markdown code blocks so that
+// their alignment is preserved when displayed in a proportional font.
+// Lines are considered column-aligned if they contain columns separated by
+// runs of at least two spaces which start or end at the same position on
+// each line. Existing code blocks and Markdown tables are left as-is. Tabs
+// should be expanded beforehand.
+func FenceAlignedBlocks(text string) string {
+	lines := strings.Split(text, "\n")
+
+	out := make([]string, 0, len(lines))
+	var inCodeBlock bool
+	for i := 0; i < len(lines); i++ {
+		if strings.HasPrefix(strings.TrimSpace(lines[i]), codeFence) {
+			inCodeBlock = !inCodeBlock
+		}
+
+		if inCodeBlock || !isColumnar(lines[i]) {
+			out = append(out, lines[i])
+			continue
+		}
+
+		end := i + 1
+		for end < len(lines) && isColumnar(lines[end]) && !strings.HasPrefix(strings.TrimSpace(lines[end]), codeFence) {
+			end++
+		}
+
+		block := lines[i:end]
+		if len(block) < minAlignedLines || !sharesColumn(block) {
+			out = append(out, lines[i])
+			continue
+		}
+
+		// Carriage returns of Windows line endings are retained for the
+		// fence lines.
+		eol := ""
+		if strings.HasSuffix(block[0], "\r") {
+			eol = "\r"
+		}
+
+		out = append(out, codeFence+eol)
+		out = append(out, block...)
+		out = append(out, codeFence+eol)
+		i = end - 1
+	}
+
+	return strings.Join(out, "\n")
+}
+
+// isColumnar indicates whether the given line contains multiple columns
+// separated by runs of spaces (and is not a Markdown table row).
+func isColumnar(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "|") {
+		return false
+	}
+
+	return strings.Contains(trimmed, strings.Repeat(" ", minColumnGap))
+}
+
+// columnEdges returns the (rune) positions at which the columns of the given
+// line start (other than the first column) and end (other than the last
+// column).
+func columnEdges(line string) (map[int]bool, map[int]bool) {
+	runes := []rune(strings.TrimRight(line, " \r"))
+	starts := make(map[int]bool)
+	ends := make(map[int]bool)
+
+	first := -1
+	for i, r := range runes {
+		if r == ' ' {
+			continue
+		}
+
+		if first == -1 {
+			first = i
+		}
+
+		if i > first && runes[i-1] == ' ' {
+			starts[i] = true
+		}
+		if i+1 < len(runes) && runes[i+1] == ' ' {
+			ends[i] = true
+		}
+	}
+
+	return starts, ends
+}
+
+// sharesColumn indicates whether a column starts or ends at the same
+// position on each of the given lines.
+func sharesColumn(lines []string) bool {
+	sharedStarts, sharedEnds := columnEdges(lines[0])
+
+	for _, line := range lines[1:] {
+		starts, ends := columnEdges(line)
+		for pos := range sharedStarts {
+			if !starts[pos] {
+				delete(sharedStarts, pos)
+			}
+		}
+		for pos := range sharedEnds {
+			if !ends[pos] {
+				delete(sharedEnds, pos)
+			}
+		}
+	}
+
+	return len(sharedStarts) > 0 || len(sharedEnds) > 0
+}
@@ -0,0 +1,89 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package teams
+
+import "testing"
+
+func TestExpandTabs(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		width int
+		want  string
+	}{
+		{name: "disabled", text: "a\tb", width: 0, want: "a\tb"},
+		{name: "tab stops", text: "a\tbc\td", width: 4, want: "a   bc  d"},
+		{name: "leading tab", text: "\tx", width: 8, want: "        x"},
+		{name: "per line", text: "abc\td\nx\ty", width: 4, want: "abc d\nx   y"},
+		{name: "runes", text: "é\tx", width: 4, want: "é   x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExpandTabs(tt.text, tt.width); got != tt.want {
+				t.Errorf("got %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFenceAlignedBlocks(t *testing.T) {
+	df := "Filesystem      Size  Used Avail Use% Mounted on\n" +
+		"/dev/sda1        20G  5.0G   14G  27% /\n" +
+		"tmpfs           3.9G     0  3.9G   0% /dev/shm"
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "prose",
+			text: "Disk usage is fine.\nNothing  to see here.",
+			want: "Disk usage is fine.\nNothing  to see here.",
+		},
+		{
+			name: "df output",
+			text: "Disk usage:\n" + df + "\nDone.",
+			want: "Disk usage:\n```\n" + df + "\n```\nDone.",
+		},
+		{
+			name: "single line",
+			text: "Name    Value",
+			want: "Name    Value",
+		},
+		{
+			name: "unaligned",
+			text: "ab  cd\nefgh  ij",
+			want: "ab  cd\nefgh  ij",
+		},
+		{
+			name: "existing code block",
+			text: "```\n" + df + "\n```",
+			want: "```\n" + df + "\n```",
+		},
+		{
+			name: "markdown table",
+			text: "| a  | b  |\n| c  | d  |",
+			want: "| a  | b  |\n| c  | d  |",
+		},
+		{
+			name: "windows line endings",
+			text: "a  b\r\nc  d\r\n",
+			want: "```\r\na  b\r\nc  d\r\n```\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FenceAlignedBlocks(tt.text); got != tt.want {
+				t.Errorf("got %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
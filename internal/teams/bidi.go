@@ -0,0 +1,61 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package teams
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Unicode bidirectional isolation characters. Text placed between these
+// characters is laid out independently of surrounding text, with the
+// direction of the isolated text determined by its first strong character.
+const (
+	firstStrongIsolate = "\u2068"
+	popDirectional     = "\u2069"
+)
+
+// codeFence is the Markdown marker for the start or end of a fenced code
+// block.
+const codeFence string = "```"
+
+// markdownBlockPrefix matches leading Markdown block markers (e.g., list
+// items, headings and quotes) which must remain at the start of a line in
+// order to be recognized.
+var markdownBlockPrefix = regexp.MustCompile(`^\s*(?:(?:[-*+>]|#{1,6}|\d+[.)])\s+)*`)
+
+// BidiIsolate wraps each line of the given text in Unicode bidirectional
+// isolation characters so that mixed right-to-left (e.g., Hebrew, Arabic)
+// and left-to-right content is displayed in the correct order. Leading
+// Markdown block markers are left outside of the isolated text and lines
+// within fenced code blocks are left as-is.
+func BidiIsolate(text string) string {
+	lines := strings.Split(text, "\n")
+
+	var inCodeBlock bool
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), codeFence) {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+
+		if inCodeBlock || strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		prefix := markdownBlockPrefix.FindString(line)
+		content := strings.TrimRight(line[len(prefix):], "\r")
+		if content == "" {
+			continue
+		}
+
+		lines[i] = prefix + firstStrongIsolate + content + popDirectional + line[len(prefix)+len(content):]
+	}
+
+	return strings.Join(lines, "\n")
+}
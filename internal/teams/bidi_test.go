@@ -0,0 +1,46 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package teams
+
+import "testing"
+
+func TestBidiIsolate(t *testing.T) {
+	const fsi, pdi = "\u2068", "\u2069"
+
+	tests := map[string]struct {
+		input string
+		want  string
+	}{
+		"single line": {
+			input: "שרת db01 לא זמין",
+			want:  fsi + "שרת db01 לא זמין" + pdi,
+		},
+		"multiple lines": {
+			input: "line one\n\nline two",
+			want:  fsi + "line one" + pdi + "\n\n" + fsi + "line two" + pdi,
+		},
+		"markdown markers": {
+			input: "# כותרת\n- פריט\n1. first",
+			want:  "# " + fsi + "כותרת" + pdi + "\n- " + fsi + "פריט" + pdi + "\n1. " + fsi + "first" + pdi,
+		},
+		"code block": {
+			input: "```\nx := 1\n```",
+			want:  "```\nx := 1\n```",
+		},
+		"windows newlines": {
+			input: "one\r\ntwo",
+			want:  fsi + "one" + pdi + "\r\n" + fsi + "two" + pdi,
+		},
+	}
+
+	for name, tt := range tests {
+		if got := BidiIsolate(tt.input); got != tt.want {
+			t.Errorf("%s: BidiIsolate(%q) = %q; want %q", name, tt.input, got, tt.want)
+		}
+	}
+}
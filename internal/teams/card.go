@@ -0,0 +1,694 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package teams
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/atc0005/go-teams-notify/v2/adaptivecard"
+	"github.com/atc0005/send2teams/internal/colorrule"
+	"github.com/atc0005/send2teams/internal/theme"
+)
+
+// CardOptions controls how a Message is rendered into a Microsoft Teams
+// card.
+type CardOptions struct {
+
+	// Trailer is the (optional) branding trailer text appended to the card
+	// in a dedicated container. If empty, no trailer is added.
+	Trailer string
+
+	// ComplianceTags is the (optional) collection of compliance fields (e.g.,
+	// the data classification) displayed in the card footer, in order.
+	ComplianceTags []Fact
+
+	// ReceiptID is the (optional) receipt ID added to the card as a fact. If
+	// empty, no receipt fact is added.
+	ReceiptID string
+
+	// IntegrityFact indicates whether the SHA-256 checksum of the original
+	// (unformatted) message text is added to the card as a fact.
+	IntegrityFact bool
+
+	// ConvertEOL indicates whether actual Windows, Mac and Linux newlines in
+	// the message text are converted before the card is generated.
+	ConvertEOL bool
+
+	// ConvertEscapedEOL indicates whether escaped Windows, Mac and Linux
+	// newline sequences in the message text are converted before the card
+	// is generated.
+	ConvertEscapedEOL bool
+
+	// TitleColor is the (optional) Adaptive Card color (e.g., "good",
+	// "attention") applied to the message title.
+	TitleColor string
+
+	// ColorRules is the (optional) collection of rules selecting the title
+	// color from the message content if TitleColor is not specified.
+	ColorRules colorrule.Rules
+
+	// BidiIsolate indicates whether user-provided text is wrapped in Unicode
+	// bidirectional isolation characters so that mixed right-to-left and
+	// left-to-right content is displayed in the correct order.
+	BidiIsolate bool
+
+	// EmojiFallback indicates whether emoji in user-provided text are
+	// replaced with text tokens (e.g., [WARN]) for channels whose
+	// compliance policies strip emoji.
+	EmojiFallback bool
+
+	// ExpandTabs is the (optional) width of the tab stops used to expand
+	// tabs in the message text before the card is generated. If greater
+	// than zero, runs of column-aligned lines (e.g., the output of df) are
+	// also placed in code blocks so that their alignment is preserved.
+	ExpandTabs int
+
+	// LegacyConvertEOL indicates whether the original (bug-compatible)
+	// conversion behavior is applied when ConvertEOL is set. This behavior
+	// converts escaped newline sequences along with Windows and Mac
+	// newlines, but leaves Linux newlines untouched.
+	LegacyConvertEOL bool
+
+	// Theme is the (optional) theme bundle applied to the card. The zero
+	// value applies no theming.
+	Theme theme.Theme
+}
+
+// NewAdaptiveCardMessage generates a Microsoft Teams message containing a
+// single Adaptive Card from the given Message using the specified options.
+func NewAdaptiveCardMessage(msg Message, opts CardOptions) (*adaptivecard.Message, error) {
+	// Color rules are matched against the original content.
+	content := msg
+	if opts.EmojiFallback {
+		content = replaceMessageEmoji(msg)
+	}
+
+	text := convertText(content.Text, opts)
+	footer := convertText(content.Footer, opts)
+	title := content.Title
+	targetURLs := content.TargetURLs
+
+	if opts.BidiIsolate {
+		text = BidiIsolate(text)
+		title = BidiIsolate(title)
+		footer = BidiIsolate(footer)
+
+		targetURLs = make([]TargetURL, 0, len(content.TargetURLs))
+		for _, target := range content.TargetURLs {
+			target.Description = BidiIsolate(target.Description)
+			targetURLs = append(targetURLs, target)
+		}
+	}
+
+	card, err := adaptivecard.NewTextBlockCard(text, title, true)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to create new card using specified text/title values: %w",
+			err,
+		)
+	}
+	if opts.Theme.FullWidth() {
+		card.SetFullWidth()
+	}
+
+	titleColor := resolveTitleColor(msg, opts)
+
+	// The title, if present, is the first element of a new card followed by
+	// the message text.
+	textIndex := 0
+	if title != "" {
+		textIndex = 1
+		if titleColor != "" {
+			card.Body[0].Color = titleColor
+		}
+	}
+	card.Body[textIndex].Color = opts.Theme.Palette.Text
+
+	if icon := opts.Theme.Icon(titleColor); icon != "" && title != "" {
+		addTitleIcon(&card, icon)
+	}
+
+	if !content.Activity.IsZero() {
+		activity := content.Activity
+		if opts.BidiIsolate {
+			activity.Title = BidiIsolate(activity.Title)
+			activity.Subtitle = BidiIsolate(activity.Subtitle)
+		}
+		addActivity(&card, textIndex, activity)
+	}
+
+	if err := addFacts(&card, content.Facts); err != nil {
+		return nil, err
+	}
+
+	sections := content.Sections
+	if opts.BidiIsolate {
+		sections = make([]Section, 0, len(content.Sections))
+		for _, section := range content.Sections {
+			section.Title = BidiIsolate(section.Title)
+			section.Text = BidiIsolate(section.Text)
+			sections = append(sections, section)
+		}
+	}
+
+	if err := addSections(&card, sections, titleColor, opts); err != nil {
+		return nil, err
+	}
+
+	if err := addTables(&card, content.Tables); err != nil {
+		return nil, err
+	}
+
+	if err := addImages(&card, msg.Images); err != nil {
+		return nil, err
+	}
+
+	if err := addUserMentions(&card, msg.UserMentions); err != nil {
+		return nil, err
+	}
+
+	if err := addAttachments(&card, msg.Attachments, opts.Theme.Layout); err != nil {
+		return nil, err
+	}
+
+	if err := addTargetURLs(&card, targetURLs, opts.Theme.Layout); err != nil {
+		return nil, err
+	}
+
+	if opts.ReceiptID != "" {
+		if err := addReceiptFact(&card, opts.ReceiptID); err != nil {
+			return nil, err
+		}
+	}
+
+	// The checksum is of the text as provided, so that it can be compared
+	// with the content recorded by the source system.
+	if opts.IntegrityFact {
+		if err := addIntegrityFact(&card, msg.Text); err != nil {
+			return nil, err
+		}
+	}
+
+	if footer != "" || opts.Trailer != "" || opts.Theme.Footer.Text != "" || len(opts.ComplianceTags) > 0 {
+		if err := addTrailer(&card, footer, opts.Trailer, opts.ComplianceTags, opts.Theme); err != nil {
+			return nil, err
+		}
+	}
+
+	message, err := adaptivecard.NewMessageFromCard(card)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to create new message from card: %w",
+			err,
+		)
+	}
+
+	return message, nil
+}
+
+// resolveTitleColor returns the Adaptive Card color applied to the title of
+// the given message: the color specified by the options, the color selected
+// by the color rules from the original content or the theme title color, in
+// that order.
+func resolveTitleColor(msg Message, opts CardOptions) string {
+	if opts.TitleColor != "" {
+		return opts.TitleColor
+	}
+
+	if color := opts.ColorRules.Match(msg.Title, msg.Text); color != "" {
+		return color
+	}
+
+	return opts.Theme.Palette.Title
+}
+
+// convertText applies any requested tab expansion and newline conversion
+// (useful for output from scripts) to the given message text.
+func convertText(text string, opts CardOptions) string {
+	// Column-aligned lines are detected before newline conversion separates
+	// them with blank lines.
+	if opts.ExpandTabs > 0 {
+		text = FenceAlignedBlocks(ExpandTabs(text, opts.ExpandTabs))
+	}
+
+	if opts.ConvertEOL && opts.LegacyConvertEOL {
+		// Not 100% safe to apply across the board.
+		//
+		// It is unlikely, but not impossible that someone would submit raw
+		// text with break statements. When you consider that the flag is
+		// named "convert-eol", it is entirely reasonable that the user would
+		// expect break statements to remain untouched.
+		//
+		// text = adaptivecard.ConvertBreakToEOL(text)
+		return adaptivecard.ConvertEOL(text)
+	}
+
+	// Actual newlines are converted first so that the output of escaped
+	// newline conversion is not converted a second time.
+	if opts.ConvertEOL {
+		text = ConvertEOL(text)
+	}
+
+	if opts.ConvertEscapedEOL {
+		text = ConvertEscapedEOL(text)
+	}
+
+	return text
+}
+
+// addFacts appends the given title and value pairs to the card as a fact
+// set. Newlines within values are converted for display (Markdown within
+// values is rendered as-is); titles are shown on a single line.
+func addFacts(card *adaptivecard.Card, facts []Fact) error {
+	if len(facts) == 0 {
+		return nil
+	}
+
+	factSet := adaptivecard.NewFactSet()
+	for _, fact := range facts {
+		title := strings.Join(strings.Fields(fact.Title), " ")
+		value := ConvertEOL(strings.TrimRight(fact.Value, "\r\n"))
+
+		if err := factSet.AddFact(adaptivecard.Fact{Title: title, Value: value}); err != nil {
+			return fmt.Errorf("failed to add fact %q: %w", fact.Title, err)
+		}
+	}
+
+	if err := card.AddFactSet(false, factSet); err != nil {
+		return fmt.Errorf("failed to add fact set to card: %w", err)
+	}
+
+	return nil
+}
+
+// addSections appends the given sections to the card, each in a dedicated
+// container separated from the preceding content unless the section
+// continues the preceding group. Newline conversion is applied to the text of
+// each section as for the message text. The given title color selects the
+// style of sections using the severity style.
+func addSections(card *adaptivecard.Card, sections []Section, titleColor string, opts CardOptions) error {
+	for _, section := range sections {
+		container := adaptivecard.NewContainer()
+		container.Separator = !section.Continued
+		container.Spacing = adaptivecard.SpacingMedium
+		container.Style = containerStyle(section.Style, titleColor)
+
+		if section.Title != "" {
+			heading := adaptivecard.NewTextBlock(section.Title, true)
+			heading.Weight = adaptivecard.WeightBolder
+			heading.Size = adaptivecard.SizeMedium
+
+			if err := container.AddElement(false, heading); err != nil {
+				return fmt.Errorf("failed to add title of section %q: %w", section.Title, err)
+			}
+		}
+
+		if section.Text != "" {
+			text := adaptivecard.NewTextBlock(convertText(section.Text, opts), true)
+			if err := container.AddElement(false, text); err != nil {
+				return fmt.Errorf("failed to add text of section %q: %w", section.Title, err)
+			}
+		}
+
+		if len(section.Facts) > 0 {
+			factSet := adaptivecard.NewFactSet()
+			for _, fact := range section.Facts {
+				title := strings.Join(strings.Fields(fact.Title), " ")
+				value := ConvertEOL(strings.TrimRight(fact.Value, "\r\n"))
+
+				if err := factSet.AddFact(adaptivecard.Fact{Title: title, Value: value}); err != nil {
+					return fmt.Errorf("failed to add fact %q to section %q: %w", fact.Title, section.Title, err)
+				}
+			}
+
+			if err := container.AddElement(false, adaptivecard.Element(factSet)); err != nil {
+				return fmt.Errorf("failed to add facts of section %q: %w", section.Title, err)
+			}
+		}
+
+		if len(container.Items) == 0 {
+			continue
+		}
+
+		if err := card.AddContainer(false, container); err != nil {
+			return fmt.Errorf("failed to add section %q to card: %w", section.Title, err)
+		}
+	}
+
+	return nil
+}
+
+// addTables appends the given tabular content to the card, each as a table
+// whose first row contains the column headings.
+func addTables(card *adaptivecard.Card, tables []Table) error {
+	for _, table := range tables {
+		if len(table.Columns) == 0 {
+			continue
+		}
+
+		cells := make([][]adaptivecard.TableCell, 0, len(table.Rows)+1)
+		for _, values := range append([][]string{table.Columns}, table.Rows...) {
+			items := make([]interface{}, len(table.Columns))
+			for i := range items {
+				// Empty values are displayed as empty cells.
+				if i < len(values) && values[i] != "" {
+					items[i] = values[i]
+				}
+			}
+
+			row, err := adaptivecard.NewTableCellsWithTextBlock(items)
+			if err != nil {
+				return fmt.Errorf("failed to add table row: %w", err)
+			}
+			cells = append(cells, row)
+		}
+
+		element, err := adaptivecard.NewTableFromTableCells(cells, len(table.Columns), true, true)
+		if err != nil {
+			return fmt.Errorf("failed to create table: %w", err)
+		}
+
+		if err := card.AddElement(false, element); err != nil {
+			return fmt.Errorf("failed to add table to card: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// addUserMentions processes the given user mention details and attaches the
+// resulting user mention values to the card.
+func addUserMentions(card *adaptivecard.Card, mentions []UserMention) error {
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	userMentions := make([]adaptivecard.Mention, 0, len(mentions))
+	for _, mention := range mentions {
+		userMention, err := adaptivecard.NewMention(mention.Name, mention.ID)
+		if err != nil {
+			return fmt.Errorf("failed to process user mention: %w", err)
+		}
+		userMentions = append(userMentions, userMention)
+	}
+
+	if err := card.AddMention(true, userMentions...); err != nil {
+		return fmt.Errorf("failed to add user mentions to message: %w", err)
+	}
+
+	return nil
+}
+
+// addTitleIcon replaces the title of the card (the first element) with a
+// column set showing the icon at the given URL alongside the title.
+func addTitleIcon(card *adaptivecard.Card, iconURL string) {
+	title := card.Body[0]
+
+	icon := adaptivecard.Element{
+		Type: adaptivecard.TypeElementImage,
+		URL:  iconURL,
+		Size: adaptivecard.SizeSmall,
+	}
+
+	card.Body[0] = adaptivecard.Element{
+		Type: adaptivecard.TypeElementColumnSet,
+		Columns: []adaptivecard.Column{
+			{
+				Type:  adaptivecard.TypeColumn,
+				Width: adaptivecard.ColumnWidthAuto,
+				Items: []*adaptivecard.Element{&icon},
+			},
+			{
+				Type:  adaptivecard.TypeColumn,
+				Width: adaptivecard.ColumnWidthStretch,
+				Items: []*adaptivecard.Element{&title},
+			},
+		},
+	}
+}
+
+// addActivity inserts a column set showing the given activity details (an
+// avatar image alongside a title and subtitle) at the given position within
+// the card body.
+func addActivity(card *adaptivecard.Card, index int, activity Activity) {
+	var details []*adaptivecard.Element
+
+	if activity.Title != "" {
+		details = append(details, &adaptivecard.Element{
+			Type:   adaptivecard.TypeElementTextBlock,
+			Text:   activity.Title,
+			Weight: adaptivecard.WeightBolder,
+			Wrap:   true,
+		})
+	}
+
+	if activity.Subtitle != "" {
+		details = append(details, &adaptivecard.Element{
+			Type:    adaptivecard.TypeElementTextBlock,
+			Text:    activity.Subtitle,
+			Size:    adaptivecard.SizeSmall,
+			Spacing: adaptivecard.SpacingNone,
+			Wrap:    true,
+		})
+	}
+
+	var columns []adaptivecard.Column
+
+	if activity.Image != "" {
+		columns = append(columns, adaptivecard.Column{
+			Type:  adaptivecard.TypeColumn,
+			Width: adaptivecard.ColumnWidthAuto,
+			Items: []*adaptivecard.Element{
+				{
+					Type: adaptivecard.TypeElementImage,
+					URL:  activity.Image,
+					Size: adaptivecard.SizeSmall,
+				},
+			},
+		})
+	}
+
+	if len(details) > 0 {
+		columns = append(columns, adaptivecard.Column{
+			Type:  adaptivecard.TypeColumn,
+			Width: adaptivecard.ColumnWidthStretch,
+			Items: details,
+		})
+	}
+
+	header := adaptivecard.Element{
+		Type:    adaptivecard.TypeElementColumnSet,
+		Columns: columns,
+	}
+
+	body := make([]adaptivecard.Element, 0, len(card.Body)+1)
+	body = append(body, card.Body[:index]...)
+	body = append(body, header)
+	body = append(body, card.Body[index:]...)
+	card.Body = body
+}
+
+// addTargetURLs uses the given target URLs and their descriptions to add
+// labelled URL "buttons" to the card using the given theme layout.
+func addTargetURLs(card *adaptivecard.Card, targetURLs []TargetURL, layout theme.Layout) error {
+	if len(targetURLs) == 0 {
+		return nil
+	}
+
+	// Create dedicated container for all action items.
+	actionsContainer := adaptivecard.NewContainer()
+	actionsContainer.Separator = false
+	actionsContainer.Style = valueOr(layout.ContainerStyle, adaptivecard.ContainerStyleEmphasis)
+	actionsContainer.Spacing = valueOr(layout.Spacing, adaptivecard.SpacingExtraLarge)
+
+	actions := make([]adaptivecard.Action, 0, len(targetURLs))
+
+	for i := range targetURLs {
+		urlAction, err := adaptivecard.NewActionOpenURL(
+			targetURLs[i].URL,
+			targetURLs[i].Description,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to process openURL action: %w", err)
+		}
+		actions = append(actions, urlAction)
+	}
+
+	if err := actionsContainer.AddAction(true, actions...); err != nil {
+		return fmt.Errorf("failed to add openURL action to container: %w", err)
+	}
+
+	if err := card.AddContainer(false, actionsContainer); err != nil {
+		return fmt.Errorf("failed to add actions container to card: %w", err)
+	}
+
+	return nil
+}
+
+// addImages appends the given images to the card.
+func addImages(card *adaptivecard.Card, images []Image) error {
+	for _, img := range images {
+		element := adaptivecard.Element{
+			Type:    adaptivecard.TypeElementImage,
+			URL:     img.URL,
+			Spacing: adaptivecard.SpacingMedium,
+		}
+
+		if err := card.AddElement(false, element); err != nil {
+			return fmt.Errorf("failed to add image to card: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// addAttachments appends the given file content to the card, each in a
+// dedicated container. If provided, the size and checksum of the complete
+// file are included as facts so that recipients are able to verify that the
+// content corresponds to the original artifact. The given theme layout is
+// applied to each container.
+func addAttachments(card *adaptivecard.Card, attachments []Attachment, layout theme.Layout) error {
+	for _, attachment := range attachments {
+		container := adaptivecard.NewContainer()
+		container.Style = valueOr(layout.ContainerStyle, adaptivecard.ContainerStyleEmphasis)
+		container.Spacing = valueOr(layout.Spacing, adaptivecard.SpacingMedium)
+
+		heading := adaptivecard.NewTextBlock(attachment.Name, true)
+		heading.Weight = adaptivecard.WeightBolder
+
+		// Adaptive Card TextBlock elements offer no syntax highlighting, so
+		// the language is given as a label instead.
+		if attachment.Language != "" {
+			heading.Text += " (" + attachment.Language + ")"
+		}
+
+		content := ConvertEOL(strings.TrimRight(attachment.Content, "\r\n"))
+		if attachment.Truncated {
+			content += adaptiveCardEOL + "*(excerpt; remaining content omitted)*"
+		}
+		body := adaptivecard.NewTextBlock(content, true)
+		body.Size = adaptivecard.SizeSmall
+
+		for _, element := range []adaptivecard.Element{heading, body} {
+			if err := container.AddElement(false, element); err != nil {
+				return fmt.Errorf("failed to add content for attachment %s: %w", attachment.Name, err)
+			}
+		}
+
+		if attachment.SHA256 != "" {
+			factSet := adaptivecard.NewFactSet()
+			factSet.Spacing = adaptivecard.SpacingSmall
+
+			facts := []adaptivecard.Fact{
+				{Title: "Size", Value: fmt.Sprintf("%s bytes", formatCount(int(attachment.Size)))},
+				{Title: "SHA-256", Value: attachment.SHA256},
+			}
+			if err := factSet.AddFact(facts...); err != nil {
+				return fmt.Errorf("failed to add checksum facts for attachment %s: %w", attachment.Name, err)
+			}
+
+			if err := container.AddElement(false, adaptivecard.Element(factSet)); err != nil {
+				return fmt.Errorf("failed to add checksum facts for attachment %s: %w", attachment.Name, err)
+			}
+		}
+
+		if err := card.AddContainer(false, container); err != nil {
+			return fmt.Errorf("failed to add attachment container to card: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// addReceiptFact appends a fact containing the given receipt ID to the card
+// so that recipients are able to correlate the message with the invocation
+// which produced it.
+//
+// The fact is visible rather than hidden: Teams provides no way for
+// recipients to view hidden (isVisible: false) elements or card metadata, so
+// a hidden receipt ID could not be used to recognize a message delivered
+// again after a relay restart or to find the log entries for a message.
+func addReceiptFact(card *adaptivecard.Card, receiptID string) error {
+	factSet := adaptivecard.NewFactSet()
+	factSet.Spacing = adaptivecard.SpacingSmall
+
+	if err := factSet.AddFact(adaptivecard.Fact{Title: "Receipt", Value: receiptID}); err != nil {
+		return fmt.Errorf("failed to add receipt fact: %w", err)
+	}
+
+	if err := card.AddFactSet(false, factSet); err != nil {
+		return fmt.Errorf("failed to add receipt fact set to card: %w", err)
+	}
+
+	return nil
+}
+
+// addTrailer appends the given compliance tags (if any), the given message
+// footer text (if any), the theme footer text (if any) and the given branding
+// trailer text (if any) to the card in a dedicated container styled as
+// specified by the given theme.
+func addTrailer(card *adaptivecard.Card, footer string, trailer string, tags []Fact, t theme.Theme) error {
+	trailerContainer := adaptivecard.NewContainer()
+	trailerContainer.Separator = true
+	trailerContainer.Spacing = adaptivecard.SpacingExtraLarge
+	trailerContainer.Style = t.Footer.Style
+
+	if len(tags) > 0 {
+		factSet := adaptivecard.NewFactSet()
+		for _, tag := range tags {
+			if err := factSet.AddFact(adaptivecard.Fact{Title: tag.Title, Value: tag.Value}); err != nil {
+				return fmt.Errorf("failed to add compliance tag %q: %w", tag.Title, err)
+			}
+		}
+
+		if err := trailerContainer.AddElement(false, adaptivecard.Element(factSet)); err != nil {
+			return fmt.Errorf("failed to add compliance tags to trailer container: %w", err)
+		}
+	}
+
+	// NOTE: Unlike MessageCard text which has benefited from \r\n (windows),
+	// \r (mac) and \n (unix) conversion to <br> statements in the past, <br>
+	// statements in Adaptive Card text remain as-is in the final rendered
+	// message. This is not useful.
+	lines := make([]string, 0, 3)
+	for _, line := range []string{footer, t.Footer.Text, trailer} {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	if len(lines) > 0 {
+		trailerText := fmt.Sprintf("\n\n%s", strings.Join(lines, adaptiveCardEOL))
+
+		trailerTextBlock := adaptivecard.NewTextBlock(trailerText, true)
+		trailerTextBlock.Size = valueOr(t.Footer.Size, adaptivecard.SizeSmall)
+		trailerTextBlock.Weight = adaptivecard.WeightLighter
+		trailerTextBlock.Color = t.Footer.Color
+
+		if err := trailerContainer.AddElement(false, trailerTextBlock); err != nil {
+			return fmt.Errorf("failed to add text block to trailer container: %w", err)
+		}
+	}
+
+	if err := card.AddContainer(false, trailerContainer); err != nil {
+		return fmt.Errorf("failed to add trailer container to card: %w", err)
+	}
+
+	return nil
+}
+
+// valueOr returns the given value, or the given fallback if the value is
+// empty.
+func valueOr(value string, fallback string) string {
+	if value != "" {
+		return value
+	}
+
+	return fallback
+}
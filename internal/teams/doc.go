@@ -0,0 +1,13 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package teams provides types and functions used to translate user-provided
+message details into the Microsoft Teams message formats supported by the
+atc0005/go-teams-notify package.
+*/
+package teams
@@ -0,0 +1,237 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package teams
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Characters which modify the presentation of the preceding emoji or join
+// emoji into a sequence.
+const (
+	variationSelectorText  rune = '\uFE0E'
+	variationSelectorEmoji rune = '\uFE0F'
+	zeroWidthJoiner        rune = '\u200D'
+	combiningKeycap        rune = '\u20E3'
+)
+
+// emojiTokens are the text tokens used in place of common emoji by
+// ReplaceEmoji.
+var emojiTokens = map[rune]string{
+	'⚠': "[WARN]",
+	'🚨': "[ALERT]",
+	'❗': "[!]",
+	'❓': "[?]",
+	'ℹ': "[INFO]",
+	'✅': "[OK]",
+	'✔': "[OK]",
+	'☑': "[OK]",
+	'🆗': "[OK]",
+	'❌': "[FAIL]",
+	'✖': "[FAIL]",
+	'❎': "[FAIL]",
+	'⛔': "[STOP]",
+	'🛑': "[STOP]",
+	'🔥': "[FIRE]",
+	'💥': "[CRASH]",
+	'🐛': "[BUG]",
+	'🔧': "[FIX]",
+	'🚀': "[DEPLOY]",
+	'🎉': "[DONE]",
+	'⏰': "[TIME]",
+	'⏳': "[WAIT]",
+	'🔒': "[LOCKED]",
+	'🔓': "[UNLOCKED]",
+	'📈': "[UP]",
+	'📉': "[DOWN]",
+	'👍': "[+1]",
+	'👎': "[-1]",
+	'🔴': "[RED]",
+	'🟠': "[ORANGE]",
+	'🟡': "[YELLOW]",
+	'🟢': "[GREEN]",
+	'🔵': "[BLUE]",
+}
+
+// symbolEmoji are the characters outside of the pictograph blocks which are
+// displayed as emoji by default (Emoji_Presentation). Other symbols in these
+// blocks (e.g., ✓ or ☎) are only displayed as emoji if followed by the emoji
+// variation selector.
+var symbolEmoji = map[rune]bool{
+	0x231A: true, 0x231B: true, 0x23E9: true, 0x23EA: true, 0x23EB: true,
+	0x23EC: true, 0x23F0: true, 0x23F3: true, 0x25FD: true, 0x25FE: true,
+	0x2614: true, 0x2615: true, 0x267F: true, 0x2693: true, 0x26A1: true,
+	0x26AA: true, 0x26AB: true, 0x26BD: true, 0x26BE: true, 0x26C4: true,
+	0x26C5: true, 0x26CE: true, 0x26D4: true, 0x26EA: true, 0x26F2: true,
+	0x26F3: true, 0x26F5: true, 0x26FA: true, 0x26FD: true, 0x2705: true,
+	0x270A: true, 0x270B: true, 0x2728: true, 0x274C: true, 0x274E: true,
+	0x2753: true, 0x2754: true, 0x2755: true, 0x2757: true, 0x2795: true,
+	0x2796: true, 0x2797: true, 0x27B0: true, 0x27BF: true, 0x2B1B: true,
+	0x2B1C: true, 0x2B50: true, 0x2B55: true,
+}
+
+// isEmoji indicates whether the given rune is displayed as an emoji when
+// followed by the given rune.
+func isEmoji(r rune, next rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF:
+		// Mahjong tiles through Symbols and Pictographs Extended-A,
+		// including regional indicators.
+		return true
+	case symbolEmoji[r]:
+		return true
+	case next == variationSelectorEmoji:
+		return r == 0x00A9 || r == 0x00AE || r == 0x2122 || r == 0x2139 ||
+			(r >= 0x2190 && r <= 0x21FF) ||
+			(r >= 0x2300 && r <= 0x23FF) ||
+			(r >= 0x25A0 && r <= 0x27BF) ||
+			(r >= 0x2900 && r <= 0x297F) ||
+			(r >= 0x2B00 && r <= 0x2BFF) ||
+			r == 0x3030 || r == 0x303D || r == 0x3297 || r == 0x3299
+	}
+
+	return false
+}
+
+// isEmojiModifier indicates whether the given rune modifies the
+// presentation of the preceding emoji.
+func isEmojiModifier(r rune) bool {
+	return r == variationSelectorText ||
+		r == variationSelectorEmoji ||
+		r == combiningKeycap ||
+		(r >= 0x1F3FB && r <= 0x1F3FF)
+}
+
+// ReplaceEmoji replaces common emoji in the given text with text tokens
+// (e.g., ⚠️ with [WARN]) for channels whose compliance policies strip
+// emoji. Other emoji (including emoji sequences such as flags and family
+// members) are removed.
+func ReplaceEmoji(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+
+		// Presentation modifiers are dropped, including those following
+		// characters which are not emoji themselves (e.g., keycaps).
+		if isEmojiModifier(r) {
+			continue
+		}
+
+		next, _ := utf8.DecodeRuneInString(text[i:])
+		if !isEmoji(r, next) {
+			b.WriteRune(r)
+			continue
+		}
+
+		token := emojiTokens[r]
+
+		// Any presentation modifiers and the remainder of an emoji sequence
+		// are dropped along with the emoji.
+		for i < len(text) {
+			next, nextSize := utf8.DecodeRuneInString(text[i:])
+			switch {
+			case isEmojiModifier(next):
+				i += nextSize
+				continue
+			case next == zeroWidthJoiner:
+				i += nextSize
+				if i < len(text) {
+					_, joinedSize := utf8.DecodeRuneInString(text[i:])
+					i += joinedSize
+				}
+				token = ""
+				continue
+			case next >= 0x1F1E6 && next <= 0x1F1FF && r >= 0x1F1E6 && r <= 0x1F1FF:
+				// The second regional indicator of a flag.
+				i += nextSize
+				r = 0
+				continue
+			}
+			break
+		}
+
+		b.WriteString(token)
+	}
+
+	return b.String()
+}
+
+// replaceMessageEmoji returns a copy of the given message with emoji in the
+// title, text, facts, sections, tables, target URL labels and activity replaced by
+// ReplaceEmoji.
+func replaceMessageEmoji(msg Message) Message {
+	msg.Title = ReplaceEmoji(msg.Title)
+	msg.Text = ReplaceEmoji(msg.Text)
+	msg.Footer = ReplaceEmoji(msg.Footer)
+	msg.Activity.Title = ReplaceEmoji(msg.Activity.Title)
+	msg.Activity.Subtitle = ReplaceEmoji(msg.Activity.Subtitle)
+
+	if msg.Facts != nil {
+		facts := make([]Fact, 0, len(msg.Facts))
+		for _, fact := range msg.Facts {
+			facts = append(facts, Fact{Title: ReplaceEmoji(fact.Title), Value: ReplaceEmoji(fact.Value)})
+		}
+		msg.Facts = facts
+	}
+
+	if msg.Sections != nil {
+		sections := make([]Section, 0, len(msg.Sections))
+		for _, section := range msg.Sections {
+			facts := make([]Fact, 0, len(section.Facts))
+			for _, fact := range section.Facts {
+				facts = append(facts, Fact{Title: ReplaceEmoji(fact.Title), Value: ReplaceEmoji(fact.Value)})
+			}
+
+			sections = append(sections, Section{
+				Title:     ReplaceEmoji(section.Title),
+				Text:      ReplaceEmoji(section.Text),
+				Facts:     facts,
+				Continued: section.Continued,
+				Style:     section.Style,
+			})
+		}
+		msg.Sections = sections
+	}
+
+	if msg.TargetURLs != nil {
+		targetURLs := make([]TargetURL, 0, len(msg.TargetURLs))
+		for _, target := range msg.TargetURLs {
+			target.Description = ReplaceEmoji(target.Description)
+			targetURLs = append(targetURLs, target)
+		}
+		msg.TargetURLs = targetURLs
+	}
+
+	if msg.Tables != nil {
+		tables := make([]Table, 0, len(msg.Tables))
+		for _, table := range msg.Tables {
+			columns := make([]string, 0, len(table.Columns))
+			for _, column := range table.Columns {
+				columns = append(columns, ReplaceEmoji(column))
+			}
+
+			rows := make([][]string, 0, len(table.Rows))
+			for _, row := range table.Rows {
+				cells := make([]string, 0, len(row))
+				for _, cell := range row {
+					cells = append(cells, ReplaceEmoji(cell))
+				}
+				rows = append(rows, cells)
+			}
+
+			tables = append(tables, Table{Columns: columns, Rows: rows})
+		}
+		msg.Tables = tables
+	}
+
+	return msg
+}
@@ -0,0 +1,38 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package teams
+
+import "testing"
+
+func TestReplaceEmoji(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "no emoji", text: "Disk usage 95% ✓ → done", want: "Disk usage 95% ✓ → done"},
+		{name: "token", text: "⚠️ Disk space low", want: "[WARN] Disk space low"},
+		{name: "without variation selector", text: "✅ Backup done", want: "[OK] Backup done"},
+		{name: "symbol with variation selector", text: "✔️ Backup done", want: "[OK] Backup done"},
+		{name: "symbol without variation selector", text: "✔ Backup done", want: "✔ Backup done"},
+		{name: "skin tone", text: "\U0001F44D\U0001F3FD approved", want: "[+1] approved"},
+		{name: "unknown emoji removed", text: "Lunch \U0001F355 time", want: "Lunch  time"},
+		{name: "flag removed", text: "Region \U0001F1FA\U0001F1F8 east", want: "Region  east"},
+		{name: "sequence removed", text: "Team \U0001F468‍\U0001F4BB ready", want: "Team  ready"},
+		{name: "keycap", text: "Step 1️⃣", want: "Step 1"},
+		{name: "multiple", text: "\U0001F534 down → \U0001F7E2 up", want: "[RED] down → [GREEN] up"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReplaceEmoji(tt.text); got != tt.want {
+				t.Errorf("got %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package teams
+
+import "strings"
+
+// Newline patterns (actual and escaped) processed when converting message
+// text for display in an Adaptive Card TextBlock element.
+const (
+	windowsEOLActual  = "\r\n"
+	windowsEOLEscaped = `\r\n`
+	macEOLActual      = "\r"
+	macEOLEscaped     = `\r`
+	unixEOLActual     = "\n"
+	unixEOLEscaped    = `\n`
+
+	// adaptiveCardEOL provides spacing in rendered TextBlock text comparable
+	// to native display of a single newline.
+	adaptiveCardEOL = unixEOLActual + unixEOLActual
+)
+
+// ConvertEOL converts actual \r\n (windows), \r (mac) and \n (unix) newlines
+// into \n\n for display in an Adaptive Card TextBlock element. Escaped
+// newline sequences (e.g., a literal `\n` within a code snippet) are left
+// as-is.
+func ConvertEOL(s string) string {
+	s = strings.ReplaceAll(s, windowsEOLActual, unixEOLActual)
+	s = strings.ReplaceAll(s, macEOLActual, unixEOLActual)
+
+	return strings.ReplaceAll(s, unixEOLActual, adaptiveCardEOL)
+}
+
+// ConvertEscapedEOL converts escaped \r\n (windows), \r (mac) and \n (unix)
+// newline sequences (i.e., the literal backslash character followed by a
+// letter) into \n\n for display in an Adaptive Card TextBlock element. This
+// is useful for text provided by tools which are unable to pass actual
+// newlines.
+func ConvertEscapedEOL(s string) string {
+	s = strings.ReplaceAll(s, windowsEOLEscaped, adaptiveCardEOL)
+	s = strings.ReplaceAll(s, macEOLEscaped, adaptiveCardEOL)
+
+	return strings.ReplaceAll(s, unixEOLEscaped, adaptiveCardEOL)
+}
@@ -0,0 +1,46 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package teams
+
+import "testing"
+
+func TestConvertEOL(t *testing.T) {
+	tests := map[string]struct {
+		input string
+		want  string
+	}{
+		"windows":         {input: "one\r\ntwo", want: "one\n\ntwo"},
+		"mac":             {input: "one\rtwo", want: "one\n\ntwo"},
+		"unix":            {input: "one\ntwo", want: "one\n\ntwo"},
+		"escaped ignored": {input: `fmt.Printf("done\n")` + "\n", want: `fmt.Printf("done\n")` + "\n\n"},
+	}
+
+	for name, tt := range tests {
+		if got := ConvertEOL(tt.input); got != tt.want {
+			t.Errorf("%s: ConvertEOL(%q) = %q; want %q", name, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestConvertEscapedEOL(t *testing.T) {
+	tests := map[string]struct {
+		input string
+		want  string
+	}{
+		"windows":       {input: `one\r\ntwo`, want: "one\n\ntwo"},
+		"mac":           {input: `one\rtwo`, want: "one\n\ntwo"},
+		"unix":          {input: `one\ntwo`, want: "one\n\ntwo"},
+		"actual intact": {input: "one\ntwo", want: "one\ntwo"},
+	}
+
+	for name, tt := range tests {
+		if got := ConvertEscapedEOL(tt.input); got != tt.want {
+			t.Errorf("%s: ConvertEscapedEOL(%q) = %q; want %q", name, tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package teams
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/atc0005/go-teams-notify/v2/adaptivecard"
+)
+
+// IntegrityFactTitle is the title of the fact providing the checksum of the
+// message text.
+const IntegrityFactTitle string = "SHA-256"
+
+// ContentDigest returns the hex encoded SHA-256 checksum of the given
+// message text, as provided to the card before any formatting (e.g., newline
+// conversion or emoji replacement) is applied. Recipients may compare the
+// checksum with that of the content recorded by the source system (e.g.,
+// printf '%s' "$text" | sha256sum).
+func ContentDigest(text string) string {
+	sum := sha256.Sum256([]byte(text))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// addIntegrityFact appends a fact providing the checksum of the given
+// message text to the card.
+func addIntegrityFact(card *adaptivecard.Card, text string) error {
+	factSet := adaptivecard.NewFactSet()
+	factSet.Spacing = adaptivecard.SpacingSmall
+
+	if err := factSet.AddFact(adaptivecard.Fact{Title: IntegrityFactTitle, Value: ContentDigest(text)}); err != nil {
+		return fmt.Errorf("failed to add integrity fact: %w", err)
+	}
+
+	if err := card.AddFactSet(false, factSet); err != nil {
+		return fmt.Errorf("failed to add integrity fact set to card: %w", err)
+	}
+
+	return nil
+}
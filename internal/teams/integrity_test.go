@@ -0,0 +1,57 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package teams
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestIntegrityFact(t *testing.T) {
+	text := "Disk usage 95%\n:warning: on web01"
+	want := "fa1f3b413721f76f8014ce1153b656e868c3d451f6306fae9674fa41830ba031"
+	if got := ContentDigest(text); got != want {
+		t.Fatalf("got digest %q; want %q", got, want)
+	}
+
+	msg := Message{Title: "Disk", Text: text}
+
+	// The checksum is of the text as provided rather than as formatted.
+	opts := CardOptions{IntegrityFact: true, ConvertEOL: true, EmojiFallback: true}
+	message, err := NewAdaptiveCardMessage(msg, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(data), `"title":"`+IntegrityFactTitle+`","value":"`+want+`"`) {
+		t.Errorf("card does not contain integrity fact %s: %s", want, data)
+	}
+
+	preview, err := msg.Preview(opts, PreviewOptions{Width: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(preview, want) {
+		t.Errorf("preview does not contain integrity fact %s:\n%s", want, preview)
+	}
+
+	message, err = NewAdaptiveCardMessage(msg, CardOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ = json.Marshal(message)
+	if strings.Contains(string(data), want) {
+		t.Errorf("card contains integrity fact when not requested: %s", data)
+	}
+}
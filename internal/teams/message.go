@@ -0,0 +1,206 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package teams
+
+import "errors"
+
+// ErrMissingMessageText indicates that a message was provided without any
+// text content.
+var ErrMissingMessageText = errors.New("message content too short")
+
+// TargetURL is a URL and description used to generate labelled URL
+// "buttons" within a Microsoft Teams message.
+type TargetURL struct {
+
+	// URL is the target for a labelled "button" within a Microsoft Teams
+	// message.
+	URL string `json:"url"`
+
+	// Description is the text used as the label for a link "button" within
+	// a Microsoft Teams message.
+	Description string `json:"description"`
+}
+
+// UserMention is a pair of name and ID values used to generate a user
+// mention within a Microsoft Teams message.
+type UserMention struct {
+
+	// Name is the DisplayName of the user mentioned.
+	Name string `json:"name"`
+
+	// ID is the unique identifier for a user that is mentioned. This value
+	// can be an object ID or a UserPrincipalName.
+	ID string `json:"id"`
+}
+
+// Fact is a title and value pair displayed within a Microsoft Teams
+// message.
+type Fact struct {
+
+	// Title is the label for the fact.
+	Title string `json:"title"`
+
+	// Value is the text displayed for the fact.
+	Value string `json:"value"`
+}
+
+// Attachment is file content included within a Microsoft Teams message.
+type Attachment struct {
+
+	// Name is the file name displayed with the content.
+	Name string `json:"name"`
+
+	// Content is the (possibly truncated) file content.
+	Content string `json:"content"`
+
+	// SHA256 is the (optional) hex encoded SHA-256 checksum of the complete
+	// file. If specified, the checksum and size are displayed as facts.
+	SHA256 string `json:"sha256,omitempty"`
+
+	// Size is the size in bytes of the complete file.
+	Size int64 `json:"size,omitempty"`
+
+	// Truncated indicates that the content is an excerpt of the file.
+	Truncated bool `json:"truncated,omitempty"`
+
+	// Language is the (optional) display name of the language of the
+	// content (e.g., Python, YAML), shown alongside the file name.
+	Language string `json:"language,omitempty"`
+}
+
+// Image is an image displayed within a Microsoft Teams message.
+type Image struct {
+
+	// URL is the URL of the image, which may be a base64 encoded data URI
+	// (e.g., data:image/png;base64,...).
+	URL string `json:"url"`
+}
+
+// Section is a titled group of text and facts displayed within a Microsoft
+// Teams message.
+type Section struct {
+
+	// Title is the (optional) heading of the section.
+	Title string `json:"title,omitempty"`
+
+	// Text is the (optional) Markdown formatted text of the section.
+	Text string `json:"text,omitempty"`
+
+	// Facts is the (optional) collection of title and value pairs displayed
+	// after the text of the section.
+	Facts []Fact `json:"facts,omitempty"`
+
+	// Continued indicates that the section continues the group of content
+	// preceding it, and so is displayed without the separator line which
+	// otherwise starts a new group.
+	Continued bool `json:"continued,omitempty"`
+
+	// Style is the (optional) Adaptive Card container style (e.g.,
+	// attention) applied to the section, or the severity style to match the
+	// title color.
+	Style string `json:"style,omitempty"`
+}
+
+// Table is tabular content (e.g., rows of a report) displayed within a
+// Microsoft Teams message.
+type Table struct {
+
+	// Columns are the column headings.
+	Columns []string `json:"columns"`
+
+	// Rows are the cell values of each row. Rows with fewer values than
+	// there are columns are padded with empty cells.
+	Rows [][]string `json:"rows"`
+}
+
+// Activity is the (optional) header identifying the source of a Microsoft
+// Teams message, shown as an avatar image alongside a title and subtitle in
+// the style of first-party connectors.
+type Activity struct {
+
+	// Title is the (optional) activity title (e.g., the application name).
+	Title string `json:"title,omitempty"`
+
+	// Subtitle is the (optional) text shown below the activity title.
+	Subtitle string `json:"subtitle,omitempty"`
+
+	// Image is the (optional) URL of the avatar image.
+	Image string `json:"image,omitempty"`
+}
+
+// IsZero indicates whether no activity details are specified.
+func (a Activity) IsZero() bool {
+	return a == Activity{}
+}
+
+// Message is the format-neutral description of a message to submit to a
+// Microsoft Teams channel. Values are provided by command-line flags or by
+// clients of the serve mode listener.
+type Message struct {
+
+	// Title is the (optional) text shown on the top portion of the message.
+	Title string `json:"title,omitempty"`
+
+	// Text is an (optionally) Markdown-formatted string representing the
+	// message body.
+	Text string `json:"text"`
+
+	// Sender is an optional value used to indicate what application was
+	// responsible for generating the message.
+	Sender string `json:"sender,omitempty"`
+
+	// TargetURLs is the collection of URLs and descriptions displayed as
+	// labelled "buttons" within the message.
+	TargetURLs []TargetURL `json:"target_urls,omitempty"`
+
+	// UserMentions is the collection of users mentioned within the message.
+	UserMentions []UserMention `json:"user_mentions,omitempty"`
+
+	// Facts is the collection of title and value pairs displayed after the
+	// message text.
+	Facts []Fact `json:"facts,omitempty"`
+
+	// Sections is the collection of titled groups of text and facts
+	// displayed after the facts.
+	Sections []Section `json:"sections,omitempty"`
+
+	// Tables is the collection of tabular content displayed after the
+	// sections.
+	Tables []Table `json:"tables,omitempty"`
+
+	// Images is the collection of images displayed after the tables.
+	Images []Image `json:"images,omitempty"`
+
+	// Attachments is the collection of file content included within the
+	// message.
+	Attachments []Attachment `json:"attachments,omitempty"`
+
+	// Activity is the (optional) header identifying the source of the
+	// message.
+	Activity Activity `json:"activity,omitempty"`
+
+	// Footer is the (optional) Markdown-formatted text displayed in the card
+	// footer ahead of the theme footer text and branding trailer.
+	Footer string `json:"footer,omitempty"`
+}
+
+// Validate asserts that the minimum required values for a message have been
+// provided.
+func (m Message) Validate() error {
+	if m.Text == "" {
+		return ErrMissingMessageText
+	}
+
+	for _, section := range m.Sections {
+		if err := ValidateSectionStyle(section.Style); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,504 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package teams
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/atc0005/go-teams-notify/v2/adaptivecard"
+)
+
+// DefaultPreviewWidth is the default width in columns (including the
+// border) of a card preview.
+const DefaultPreviewWidth int = 72
+
+// minPreviewWidth is the smallest width in columns of a card preview.
+const minPreviewWidth int = 24
+
+// maxPreviewAttachmentLines is the maximum number of lines of each
+// attachment shown in a card preview.
+const maxPreviewAttachmentLines int = 8
+
+// ANSI escape sequences used to style card previews.
+const (
+	ansiReset string = "\x1b[0m"
+	ansiBold  string = "\x1b[1m"
+	ansiDim   string = "\x1b[2m"
+)
+
+// previewColors are the ANSI escape sequences approximating each Adaptive
+// Card color in a card preview.
+var previewColors = map[string]string{
+	adaptivecard.ColorDark:      "\x1b[90m",
+	adaptivecard.ColorLight:     "\x1b[37m",
+	adaptivecard.ColorAccent:    "\x1b[34m",
+	adaptivecard.ColorGood:      "\x1b[32m",
+	adaptivecard.ColorWarning:   "\x1b[33m",
+	adaptivecard.ColorAttention: "\x1b[31m",
+}
+
+// PreviewOptions controls how a Message is displayed by Preview.
+type PreviewOptions struct {
+
+	// Width is the width in columns of the preview, including the border.
+	// DefaultPreviewWidth is used if zero.
+	Width int
+
+	// Color indicates whether ANSI escape sequences are used to style the
+	// preview (e.g., to show the title color as the color of the border).
+	Color bool
+}
+
+// Preview returns an approximation of the card generated for the message
+// using the given card options, drawn with box drawing characters for
+// display in a terminal. Markdown is shown as-is and elements such as
+// images are represented by placeholders.
+func (m Message) Preview(opts CardOptions, popts PreviewOptions) (string, error) {
+	if err := m.Validate(); err != nil {
+		return "", err
+	}
+
+	content := m
+	if opts.EmojiFallback {
+		content = replaceMessageEmoji(m)
+	}
+
+	width := popts.Width
+	switch {
+	case width == 0:
+		width = DefaultPreviewWidth
+	case width < minPreviewWidth:
+		width = minPreviewWidth
+	}
+
+	titleColor := resolveTitleColor(m, opts)
+
+	p := preview{
+		inner: width - 4,
+		color: popts.Color,
+		trim:  previewColors[titleColor],
+		text:  previewColors[opts.Theme.Palette.Text],
+	}
+
+	p.border("╭", "╮")
+
+	if content.Title != "" {
+		p.paragraph(content.Title, ansiBold+previewColors[titleColor])
+	}
+
+	if !content.Activity.IsZero() {
+		activity := content.Activity.Title
+		if content.Activity.Image != "" {
+			activity = strings.TrimSpace("[avatar] " + activity)
+		}
+		p.blank()
+		p.paragraph(activity, ansiBold)
+		p.paragraph(content.Activity.Subtitle, ansiDim)
+	}
+
+	if content.Title != "" || !content.Activity.IsZero() {
+		p.blank()
+	}
+	p.paragraph(convertText(content.Text, opts), p.text)
+
+	p.facts(content.Facts)
+
+	for _, section := range content.Sections {
+		if section.Title == "" && section.Text == "" && len(section.Facts) == 0 {
+			continue
+		}
+
+		if section.Continued {
+			p.blank()
+		} else {
+			p.border("├", "┤")
+		}
+		// Styled sections are approximated by the color of their title; the
+		// good, warning, attention and accent styles share the names of the
+		// matching colors.
+		p.paragraph(section.Title, ansiBold+previewColors[containerStyle(section.Style, titleColor)])
+		p.paragraph(convertText(section.Text, opts), "")
+		p.facts(section.Facts)
+	}
+
+	for _, table := range content.Tables {
+		p.table(table)
+	}
+
+	for _, img := range m.Images {
+		p.blank()
+		p.paragraph(imagePlaceholder(img), ansiDim)
+	}
+
+	if len(m.UserMentions) > 0 {
+		names := make([]string, 0, len(m.UserMentions))
+		for _, mention := range m.UserMentions {
+			names = append(names, "@"+mention.Name)
+		}
+		p.blank()
+		p.paragraph("Mentions: "+strings.Join(names, ", "), ansiDim)
+	}
+
+	for _, attachment := range m.Attachments {
+		p.attachment(attachment)
+	}
+
+	if len(content.TargetURLs) > 0 {
+		p.border("├", "┤")
+		p.buttons(content.TargetURLs)
+	}
+
+	if opts.ReceiptID != "" {
+		p.blank()
+		p.facts([]Fact{{Title: "Receipt", Value: opts.ReceiptID}})
+	}
+
+	if opts.IntegrityFact {
+		p.blank()
+		p.facts([]Fact{{Title: IntegrityFactTitle, Value: ContentDigest(m.Text)}})
+	}
+
+	if content.Footer != "" || opts.Trailer != "" || opts.Theme.Footer.Text != "" || len(opts.ComplianceTags) > 0 {
+		p.border("├", "┤")
+		p.facts(opts.ComplianceTags)
+		p.paragraph(convertText(content.Footer, opts), ansiDim)
+		p.paragraph(opts.Theme.Footer.Text, ansiDim)
+		p.paragraph(opts.Trailer, ansiDim)
+	}
+
+	p.border("╰", "╯")
+
+	return p.b.String(), nil
+}
+
+// preview accumulates the lines of a card preview.
+type preview struct {
+	b strings.Builder
+
+	// inner is the number of columns available for content between the
+	// border and its padding.
+	inner int
+
+	// color indicates whether ANSI escape sequences are used; trim and text
+	// are the sequences applied to the border and message text.
+	color bool
+	trim  string
+	text  string
+
+	// gap indicates whether the preview ends with an empty line or a
+	// border, so that no further space is needed before new content.
+	gap bool
+}
+
+// style returns the given text wrapped in the given ANSI escape sequence,
+// if any (and if colors are enabled).
+func (p *preview) style(text string, seq string) string {
+	if !p.color || seq == "" || text == "" {
+		return text
+	}
+
+	return seq + text + ansiReset
+}
+
+// border adds a horizontal border spanning the preview, starting and ending
+// with the given corner (or junction) characters.
+func (p *preview) border(left string, right string) {
+	p.b.WriteString(p.style(left+strings.Repeat("─", p.inner+2)+right, p.trim))
+	p.b.WriteByte('\n')
+	p.gap = true
+}
+
+// line adds a line of content, which must fit within the preview, styled
+// with the given ANSI escape sequence.
+func (p *preview) line(text string, seq string) {
+	side := p.style("│", p.trim)
+	padding := strings.Repeat(" ", p.inner-utf8.RuneCountInString(text))
+
+	p.b.WriteString(side + " " + p.style(text, seq) + padding + " " + side + "\n")
+	p.gap = text == ""
+}
+
+// blank adds an empty line, unless the preview already ends with one (or
+// with a border).
+func (p *preview) blank() {
+	if !p.gap {
+		p.line("", "")
+	}
+}
+
+// paragraph adds the given text wrapped to fit within the preview. Runs of
+// blank lines are shown as a single blank line.
+func (p *preview) paragraph(text string, seq string) {
+	text = strings.TrimSpace(strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(text))
+	if text == "" {
+		return
+	}
+
+	blank := false
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			blank = true
+			continue
+		}
+
+		if blank {
+			p.line("", "")
+			blank = false
+		}
+
+		for _, wrapped := range wrapText(line, p.inner) {
+			p.line(wrapped, seq)
+		}
+	}
+}
+
+// facts adds the given title and value pairs, with the values aligned in
+// a column after the (bold) titles.
+func (p *preview) facts(facts []Fact) {
+	if len(facts) == 0 {
+		return
+	}
+
+	titleWidth := 0
+	for _, fact := range facts {
+		if n := utf8.RuneCountInString(strings.Join(strings.Fields(fact.Title), " ")); n > titleWidth {
+			titleWidth = n
+		}
+	}
+	if titleWidth > p.inner/3 {
+		titleWidth = p.inner / 3
+	}
+	valueWidth := p.inner - titleWidth - 2
+
+	p.blank()
+	for _, fact := range facts {
+		titles := wrapText(strings.Join(strings.Fields(fact.Title), " "), titleWidth)
+
+		var values []string
+		value := strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(strings.TrimRight(fact.Value, "\r\n"))
+		for _, line := range strings.Split(value, "\n") {
+			values = append(values, wrapText(line, valueWidth)...)
+		}
+
+		for i := 0; i < len(titles) || i < len(values); i++ {
+			var title, value string
+			if i < len(titles) {
+				title = titles[i]
+			}
+			if i < len(values) {
+				value = values[i]
+			}
+
+			side := p.style("│", p.trim)
+			padding := strings.Repeat(" ", titleWidth-utf8.RuneCountInString(title)+2)
+			valuePadding := strings.Repeat(" ", valueWidth-utf8.RuneCountInString(value))
+
+			p.b.WriteString(side + " " + p.style(title, ansiBold) + padding + value + valuePadding + " " + side + "\n")
+			p.gap = false
+		}
+	}
+}
+
+// table adds the given tabular content with the columns separated by
+// vertical lines. Cell values too long for the available width are
+// shortened.
+func (p *preview) table(table Table) {
+	if len(table.Columns) == 0 {
+		return
+	}
+
+	widths := make([]int, len(table.Columns))
+	for _, values := range append([][]string{table.Columns}, table.Rows...) {
+		for i := range widths {
+			if i < len(values) {
+				if n := utf8.RuneCountInString(previewCell(values[i])); n > widths[i] {
+					widths[i] = n
+				}
+			}
+		}
+	}
+
+	// Each column is separated from the next by " │ ".
+	available := p.inner - 3*(len(widths)-1)
+	for total(widths) > available {
+		widest := 0
+		for i := range widths {
+			if widths[i] > widths[widest] {
+				widest = i
+			}
+		}
+		if widths[widest] <= 1 {
+			break
+		}
+		widths[widest]--
+	}
+
+	format := func(values []string) string {
+		cells := make([]string, len(widths))
+		for i := range widths {
+			var value string
+			if i < len(values) {
+				value = shortenText(previewCell(values[i]), widths[i])
+			}
+			cells[i] = value + strings.Repeat(" ", widths[i]-utf8.RuneCountInString(value))
+		}
+		return strings.TrimRight(strings.Join(cells, " │ "), " ")
+	}
+
+	p.blank()
+	p.line(shortenText(format(table.Columns), p.inner), ansiBold)
+
+	separators := make([]string, len(widths))
+	for i := range widths {
+		separators[i] = strings.Repeat("─", widths[i])
+	}
+	p.line(shortenText(strings.Join(separators, "─┼─"), p.inner), "")
+
+	for _, row := range table.Rows {
+		p.line(shortenText(format(row), p.inner), "")
+	}
+}
+
+// attachment adds the name and (the first lines of) the content of the
+// given attachment.
+func (p *preview) attachment(attachment Attachment) {
+	heading := attachment.Name
+	if attachment.Language != "" {
+		heading += " (" + attachment.Language + ")"
+	}
+
+	p.blank()
+	p.paragraph(heading, ansiBold)
+
+	lines := strings.Split(strings.TrimRight(attachment.Content, "\r\n"), "\n")
+	omitted := 0
+	if len(lines) > maxPreviewAttachmentLines {
+		omitted = len(lines) - maxPreviewAttachmentLines
+		lines = lines[:maxPreviewAttachmentLines]
+	}
+
+	for _, line := range lines {
+		p.line(shortenText(strings.TrimRight(ExpandTabs(line, 8), "\r"), p.inner), "")
+	}
+
+	switch {
+	case omitted > 0:
+		p.paragraph(fmt.Sprintf("(%d more lines)", omitted), ansiDim)
+	case attachment.Truncated:
+		p.paragraph("(excerpt; remaining content omitted)", ansiDim)
+	}
+
+	if attachment.SHA256 != "" {
+		p.facts([]Fact{
+			{Title: "Size", Value: fmt.Sprintf("%s bytes", formatCount(int(attachment.Size)))},
+			{Title: "SHA-256", Value: attachment.SHA256},
+		})
+	}
+}
+
+// buttons adds the labels of the given target URLs as buttons, as many to a
+// line as fit.
+func (p *preview) buttons(targetURLs []TargetURL) {
+	var line string
+	for _, target := range targetURLs {
+		button := "[ " + shortenText(strings.Join(strings.Fields(target.Description), " "), p.inner-4) + " ]"
+
+		switch {
+		case line == "":
+			line = button
+		case utf8.RuneCountInString(line)+2+utf8.RuneCountInString(button) <= p.inner:
+			line += "  " + button
+		default:
+			p.line(line, ansiBold)
+			line = button
+		}
+	}
+
+	p.line(line, ansiBold)
+}
+
+// imagePlaceholder returns the text shown in place of the given image.
+func imagePlaceholder(img Image) string {
+	const prefix = "data:"
+	if !strings.HasPrefix(img.URL, prefix) {
+		return "[image " + img.URL + "]"
+	}
+
+	mediaType, data, _ := strings.Cut(strings.TrimPrefix(img.URL, prefix), ",")
+	mediaType = strings.TrimSuffix(mediaType, ";base64")
+
+	return fmt.Sprintf("[embedded %s image, %s bytes]", mediaType, formatCount(base64.StdEncoding.DecodedLen(len(data))))
+}
+
+// previewCell returns the given table cell value on a single line.
+func previewCell(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
+
+// total returns the sum of the given column widths.
+func total(widths []int) int {
+	var sum int
+	for _, width := range widths {
+		sum += width
+	}
+
+	return sum
+}
+
+// wrapText splits the given line into lines no wider than the given width,
+// breaking at spaces where possible. Leading indentation is retained on the
+// first line only.
+func wrapText(line string, width int) []string {
+	line = strings.TrimRight(ExpandTabs(line, 8), " ")
+	if utf8.RuneCountInString(line) <= width || width < 1 {
+		return []string{line}
+	}
+
+	var lines []string
+	var current []rune
+	for _, word := range strings.SplitAfter(line, " ") {
+		runes := []rune(word)
+
+		if len(current) > 0 && len(current)+utf8.RuneCountInString(strings.TrimRight(word, " ")) > width {
+			lines = append(lines, strings.TrimRight(string(current), " "))
+			current = nil
+		}
+
+		// Words longer than the width are broken wherever they reach it.
+		for len(current) == 0 && utf8.RuneCountInString(strings.TrimRight(string(runes), " ")) > width {
+			lines = append(lines, string(runes[:width]))
+			runes = runes[width:]
+		}
+
+		current = append(current, runes...)
+	}
+
+	if last := strings.TrimRight(string(current), " "); last != "" {
+		lines = append(lines, last)
+	}
+
+	return lines
+}
+
+// shortenText returns the given text shortened (with a trailing ellipsis)
+// to no more than the given width.
+func shortenText(text string, width int) string {
+	runes := []rune(text)
+	if len(runes) <= width {
+		return text
+	}
+
+	if width < 1 {
+		return ""
+	}
+
+	return string(runes[:width-1]) + "…"
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package teams
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestPreview(t *testing.T) {
+	msg := Message{
+		Title: "Backup failed",
+		Text:  "The nightly backup of db01 failed after 42 minutes with an error reported by the storage array.",
+		Facts: []Fact{{Title: "Host", Value: "db01"}},
+		Sections: []Section{
+			{Title: "Details", Facts: []Fact{{Title: "Exit code", Value: "3"}}},
+		},
+		Tables: []Table{
+			{Columns: []string{"Volume", "Free"}, Rows: [][]string{{"/var", "2%"}}},
+		},
+		TargetURLs: []TargetURL{
+			{URL: "https://example.com/runbook", Description: "Runbook"},
+			{URL: "https://example.com/logs", Description: "View logs"},
+		},
+	}
+
+	got, err := msg.Preview(CardOptions{TitleColor: "attention", Trailer: "Sent by send2teams"}, PreviewOptions{Width: 40})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	for i, line := range lines {
+		if n := utf8.RuneCountInString(line); n != 40 {
+			t.Errorf("line %d is %d columns wide; want 40:\n%s", i+1, n, got)
+		}
+	}
+
+	for _, want := range []string{
+		"╭─", "│ Backup failed ", "│ The nightly backup of db01 failed    │",
+		"│ Host  db01", "├─", "│ Details ", "│ Exit code  3", "│ Volume │ Free",
+		"│ /var   │ 2%", "│ [ Runbook ]  [ View logs ]", "│ Sent by send2teams", "─╯",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("preview missing %q:\n%s", want, got)
+		}
+	}
+
+	if strings.Contains(got, "\x1b[") {
+		t.Errorf("preview without colors contains escape sequences:\n%s", got)
+	}
+
+	colored, err := msg.Preview(CardOptions{TitleColor: "attention"}, PreviewOptions{Color: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(colored, previewColors["attention"]+"╭") {
+		t.Errorf("border not colored by title color:\n%q", colored)
+	}
+
+	if _, err := (Message{Title: "Empty"}).Preview(CardOptions{}, PreviewOptions{}); !errors.Is(err, ErrMissingMessageText) {
+		t.Errorf("got error %v; want %v", err, ErrMissingMessageText)
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	tests := []struct {
+		line  string
+		width int
+		want  []string
+	}{
+		{line: "short", width: 10, want: []string{"short"}},
+		{line: "the quick brown fox", width: 10, want: []string{"the quick", "brown fox"}},
+		{line: "abcdefghijkl mn", width: 5, want: []string{"abcde", "fghij", "kl mn"}},
+	}
+
+	for _, tt := range tests {
+		got := wrapText(tt.line, tt.width)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("wrapText(%q, %d) = %q; want %q", tt.line, tt.width, got, tt.want)
+		}
+	}
+}
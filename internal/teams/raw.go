@@ -0,0 +1,150 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package teams
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/atc0005/go-teams-notify/v2/adaptivecard"
+)
+
+// ErrUnsupportedPayload indicates that a pre-built payload is not in a
+// supported Microsoft Teams message format.
+var ErrUnsupportedPayload = errors.New("unsupported payload format")
+
+// Formats of pre-built payloads reported by RawMessage.Format.
+const (
+	PayloadFormatMessageCard  string = "MessageCard"
+	PayloadFormatAdaptiveCard string = "AdaptiveCard"
+)
+
+// legacyMessageCardType is the @type value of a legacy MessageCard.
+const legacyMessageCardType string = "MessageCard"
+
+// RawMessage is a pre-built Microsoft Teams webhook payload which is
+// submitted as-is instead of being generated from a Message.
+type RawMessage struct {
+	payload []byte
+	format  string
+}
+
+// NewRawMessage returns a RawMessage for the given pre-built JSON payload.
+// Legacy MessageCards (identified by a top-level "@type" of "MessageCard")
+// and messages containing one or more Adaptive Card attachments are
+// submitted as-is. A bare Adaptive Card is wrapped in the message envelope
+// required by webhook URLs. An error wrapping ErrUnsupportedPayload is
+// returned for other JSON documents.
+func NewRawMessage(payload []byte) (*RawMessage, error) {
+	var probe struct {
+		AtType      string            `json:"@type"`
+		Type        string            `json:"type"`
+		Attachments []json.RawMessage `json:"attachments"`
+	}
+
+	if err := json.Unmarshal(payload, &probe); err != nil {
+		return nil, fmt.Errorf("%w: payload is not a JSON object: %v", ErrUnsupportedPayload, err)
+	}
+
+	switch {
+	case probe.AtType == legacyMessageCardType:
+		return &RawMessage{payload: compactJSON(payload), format: PayloadFormatMessageCard}, nil
+
+	case probe.Type == adaptivecard.TypeMessage:
+		if len(probe.Attachments) == 0 {
+			return nil, fmt.Errorf("%w: message contains no attachments", ErrUnsupportedPayload)
+		}
+		return &RawMessage{payload: compactJSON(payload), format: PayloadFormatAdaptiveCard}, nil
+
+	case probe.Type == adaptivecard.TypeAdaptiveCard:
+		envelope := struct {
+			Type        string        `json:"type"`
+			Attachments []interface{} `json:"attachments"`
+		}{
+			Type: adaptivecard.TypeMessage,
+			Attachments: []interface{}{
+				struct {
+					ContentType string          `json:"contentType"`
+					Content     json.RawMessage `json:"content"`
+				}{
+					ContentType: adaptivecard.AttachmentContentType,
+					Content:     compactJSON(payload),
+				},
+			},
+		}
+
+		wrapped, err := json.Marshal(envelope)
+		if err != nil {
+			return nil, fmt.Errorf("failed to wrap Adaptive Card in message: %w", err)
+		}
+		return &RawMessage{payload: wrapped, format: PayloadFormatAdaptiveCard}, nil
+
+	default:
+		return nil, fmt.Errorf(
+			"%w: expected a MessageCard, an Adaptive Card or a message containing Adaptive Cards",
+			ErrUnsupportedPayload,
+		)
+	}
+}
+
+// compactJSON returns the given (valid) JSON document with insignificant
+// whitespace removed, or the document unchanged if it could not be
+// compacted.
+func compactJSON(payload []byte) []byte {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, payload); err != nil {
+		return payload
+	}
+
+	return buf.Bytes()
+}
+
+// Format returns the format of the payload, either PayloadFormatMessageCard
+// or PayloadFormatAdaptiveCard.
+func (m *RawMessage) Format() string {
+	return m.format
+}
+
+// Prepare is a no-op; the payload is already built.
+func (m *RawMessage) Prepare() error {
+	return nil
+}
+
+// Validate asserts that the payload is not empty. The content of the payload
+// is only validated (against the bundled card schemas) if requested.
+func (m *RawMessage) Validate() error {
+	if len(m.payload) == 0 {
+		return fmt.Errorf("%w: payload is empty", ErrUnsupportedPayload)
+	}
+
+	return nil
+}
+
+// Payload returns the payload as submitted to the webhook URL.
+func (m *RawMessage) Payload() io.Reader {
+	return bytes.NewReader(m.payload)
+}
+
+// MarshalJSON returns the payload as submitted to the webhook URL.
+func (m *RawMessage) MarshalJSON() ([]byte, error) {
+	return m.payload, nil
+}
+
+// PrettyPrint returns the payload in an indented format suitable for
+// display.
+func (m *RawMessage) PrettyPrint() string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, m.payload, "", "\t"); err != nil {
+		return string(m.payload)
+	}
+
+	return buf.String()
+}
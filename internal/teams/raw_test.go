@@ -0,0 +1,69 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package teams
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestNewRawMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		format  string
+		want    string
+	}{
+		{
+			name:    "message card",
+			payload: "{\n  \"@type\": \"MessageCard\",\n  \"text\": \"hello\"\n}\n",
+			format:  PayloadFormatMessageCard,
+			want:    `{"@type":"MessageCard","text":"hello"}`,
+		},
+		{
+			name:    "message",
+			payload: `{"type":"message","attachments":[{"contentType":"application/vnd.microsoft.card.adaptive","content":{}}]}`,
+			format:  PayloadFormatAdaptiveCard,
+			want:    `{"type":"message","attachments":[{"contentType":"application/vnd.microsoft.card.adaptive","content":{}}]}`,
+		},
+		{
+			name:    "bare adaptive card",
+			payload: `{"type": "AdaptiveCard", "version": "1.4"}`,
+			format:  PayloadFormatAdaptiveCard,
+			want:    `{"type":"message","attachments":[{"contentType":"application/vnd.microsoft.card.adaptive","content":{"type":"AdaptiveCard","version":"1.4"}}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewRawMessage([]byte(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if m.Format() != tt.format {
+				t.Errorf("got format %q; want %q", m.Format(), tt.format)
+			}
+
+			got, err := io.ReadAll(m.Payload())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got payload:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+
+	for _, payload := range []string{`not json`, `{"type":"message"}`, `{"text":"hello"}`, `[]`} {
+		if _, err := NewRawMessage([]byte(payload)); !errors.Is(err, ErrUnsupportedPayload) {
+			t.Errorf("payload %s: got error %v; want %v", payload, err, ErrUnsupportedPayload)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package teams
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// NewReceiptID generates a random (version 4) UUID used to correlate a
+// submitted message with the invocation, log entries and records produced
+// for it.
+func NewReceiptID() string {
+	var uuid [16]byte
+
+	// crypto/rand.Read only fails if the operating system is unable to
+	// provide random data; there is no reasonable fallback.
+	if _, err := rand.Read(uuid[:]); err != nil {
+		panic(fmt.Sprintf("failed to generate receipt ID: %v", err))
+	}
+
+	uuid[6] = (uuid[6] & 0x0f) | 0x40 // version 4
+	uuid[8] = (uuid[8] & 0x3f) | 0x80 // RFC 4122 variant
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:])
+}
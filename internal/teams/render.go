@@ -0,0 +1,70 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package teams
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Supported formats for rendered messages.
+const (
+
+	// FormatAdaptiveCard is a message containing a single Adaptive Card,
+	// encoded exactly as submitted to a webhook URL.
+	FormatAdaptiveCard string = "adaptivecard"
+)
+
+// ErrUnsupportedFormat indicates that a message could not be rendered in a
+// requested format.
+var ErrUnsupportedFormat = errors.New("unsupported format")
+
+// Render returns the JSON payload which would be submitted for the message
+// in the given format (an empty format selects FormatAdaptiveCard) without
+// performing any network I/O. No card options (e.g., a branding trailer)
+// are applied.
+func (m Message) Render(format string) ([]byte, error) {
+	return m.RenderWithOptions(format, CardOptions{})
+}
+
+// RenderWithOptions behaves as Render, generating the card using the
+// specified options.
+func (m Message) RenderWithOptions(format string, opts CardOptions) ([]byte, error) {
+	switch format {
+	case "", FormatAdaptiveCard:
+	default:
+		return nil, fmt.Errorf("%w %q; expected %q", ErrUnsupportedFormat, format, FormatAdaptiveCard)
+	}
+
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	message, err := NewAdaptiveCardMessage(m, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply the same steps as the Microsoft Teams client performs before
+	// submission.
+	if err := message.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to validate message: %w", err)
+	}
+
+	if err := message.Prepare(); err != nil {
+		return nil, fmt.Errorf("failed to prepare message: %w", err)
+	}
+
+	payload, err := io.ReadAll(message.Payload())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prepared message: %w", err)
+	}
+
+	return payload, nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package teams
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/atc0005/go-teams-notify/v2/adaptivecard"
+)
+
+// SectionStyleSeverity is the section style which selects the Adaptive Card
+// container style matching the title color (e.g., attention for a critical
+// alert), so that the severity of a message is conveyed by the section
+// itself rather than only by the title.
+const SectionStyleSeverity string = "severity"
+
+// ErrInvalidSectionStyle indicates that a section specified an unsupported
+// container style.
+var ErrInvalidSectionStyle = errors.New("invalid section style")
+
+// titleColorStyles maps Adaptive Card title colors to the container style
+// applied to sections using the severity style. Titles using other colors do
+// not style the section.
+var titleColorStyles = map[string]string{
+	adaptivecard.ColorGood:      adaptivecard.ContainerStyleGood,
+	adaptivecard.ColorWarning:   adaptivecard.ContainerStyleWarning,
+	adaptivecard.ColorAttention: adaptivecard.ContainerStyleAttention,
+	adaptivecard.ColorAccent:    adaptivecard.ContainerStyleAccent,
+}
+
+// SectionStyles returns the supported section styles: the Adaptive Card
+// container styles and the severity style.
+func SectionStyles() []string {
+	return []string{
+		adaptivecard.ContainerStyleDefault,
+		adaptivecard.ContainerStyleEmphasis,
+		adaptivecard.ContainerStyleGood,
+		adaptivecard.ContainerStyleAttention,
+		adaptivecard.ContainerStyleWarning,
+		adaptivecard.ContainerStyleAccent,
+		SectionStyleSeverity,
+	}
+}
+
+// ValidateSectionStyle asserts that the given (optional) section style is
+// supported.
+func ValidateSectionStyle(style string) error {
+	if style == "" {
+		return nil
+	}
+
+	for _, supported := range SectionStyles() {
+		if style == supported {
+			return nil
+		}
+	}
+
+	return fmt.Errorf(
+		"%w %q; expected one of %s",
+		ErrInvalidSectionStyle,
+		style,
+		strings.Join(SectionStyles(), ", "),
+	)
+}
+
+// containerStyle returns the Adaptive Card container style applied to a
+// section with the given style, resolving the severity style using the
+// given title color.
+func containerStyle(style string, titleColor string) string {
+	if style == SectionStyleSeverity {
+		return titleColorStyles[titleColor]
+	}
+
+	return style
+}
@@ -0,0 +1,67 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package teams
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/atc0005/go-teams-notify/v2/adaptivecard"
+)
+
+func TestSectionStyles(t *testing.T) {
+	msg := Message{
+		Title: "Disk usage high",
+		Text:  "Free space is low.",
+		Sections: []Section{
+			{Title: "Plain", Text: "a"},
+			{Title: "Emphasis", Text: "b", Style: adaptivecard.ContainerStyleEmphasis},
+			{Title: "Severity", Text: "c", Style: SectionStyleSeverity},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		titleColor string
+		want       []string
+	}{
+		{name: "attention", titleColor: adaptivecard.ColorAttention, want: []string{"", "emphasis", "attention"}},
+		{name: "good", titleColor: adaptivecard.ColorGood, want: []string{"", "emphasis", "good"}},
+		{name: "unmapped color", titleColor: adaptivecard.ColorDark, want: []string{"", "emphasis", ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, err := NewAdaptiveCardMessage(msg, CardOptions{TitleColor: tt.titleColor})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, element := range message.Attachments[0].Content.Body {
+				if element.Type == adaptivecard.TypeElementContainer && len(element.Items) > 0 {
+					got = append(got, element.Style)
+				}
+			}
+
+			if len(got) < len(tt.want) {
+				t.Fatalf("got %d containers; want at least %d", len(got), len(tt.want))
+			}
+			for i, want := range tt.want {
+				if got[i] != want {
+					t.Errorf("section %d: got style %q; want %q", i, got[i], want)
+				}
+			}
+		})
+	}
+
+	msg.Sections[0].Style = "loud"
+	if err := msg.Validate(); !errors.Is(err, ErrInvalidSectionStyle) {
+		t.Errorf("got error %v; want %v", err, ErrInvalidSectionStyle)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package teams
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxSummaryLineLength is the maximum number of characters retained from each
+// line of a summarized message.
+const maxSummaryLineLength int = 300
+
+// maxSummaryRepeats is the maximum number of repeated omitted lines listed
+// in a summarized message.
+const maxSummaryRepeats int = 5
+
+// summaryDigits matches runs of digits which are ignored when grouping
+// similar omitted lines (e.g., timestamps, counters, process IDs).
+var summaryDigits = regexp.MustCompile(`[0-9]+`)
+
+// repeatedLine is a group of similar lines omitted from a summary.
+type repeatedLine struct {
+	example string
+	count   int
+	first   int
+}
+
+// Summarize reduces the given text to the first and last excerptLines lines
+// along with a count of the omitted lines and a list of the most frequently
+// repeated omitted lines. Lines which differ only by digits are treated as
+// similar. Overly long lines are shortened. Text with no more than twice
+// excerptLines lines is returned with only long lines shortened.
+func Summarize(text string, excerptLines int) string {
+	lines := strings.Split(strings.TrimRight(text, "\r\n"), "\n")
+	for i := range lines {
+		lines[i] = shortenLine(strings.TrimSuffix(lines[i], "\r"))
+	}
+
+	if excerptLines < 1 || len(lines) <= 2*excerptLines {
+		return strings.Join(lines, "\n")
+	}
+
+	head := lines[:excerptLines]
+	tail := lines[len(lines)-excerptLines:]
+	omitted := lines[excerptLines : len(lines)-excerptLines]
+
+	var output strings.Builder
+
+	output.WriteString(strings.Join(head, "\n"))
+	fmt.Fprintf(
+		&output,
+		"\n\n*[first %d and last %d lines shown; %s lines omitted]*\n\n",
+		excerptLines,
+		excerptLines,
+		formatCount(len(omitted)),
+	)
+	output.WriteString(strings.Join(tail, "\n"))
+
+	repeats := rankRepeatedLines(omitted)
+	if len(repeats) > 0 {
+		output.WriteString("\n\n**Most frequent omitted lines:**\n")
+		for _, r := range repeats {
+			fmt.Fprintf(&output, "\n- %s similar lines: `%s`", formatCount(r.count), r.example)
+		}
+	}
+
+	return output.String()
+}
+
+// shortenLine truncates the given line to maxSummaryLineLength characters.
+func shortenLine(line string) string {
+	if utf8.RuneCountInString(line) <= maxSummaryLineLength {
+		return line
+	}
+
+	return string([]rune(line)[:maxSummaryLineLength]) + "…"
+}
+
+// rankRepeatedLines groups similar lines, returning the most frequent groups
+// which occur more than once.
+func rankRepeatedLines(lines []string) []repeatedLine {
+	groups := make(map[string]*repeatedLine)
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+
+		key := summaryDigits.ReplaceAllString(trimmed, "#")
+		if g, ok := groups[key]; ok {
+			g.count++
+			continue
+		}
+
+		// Backticks within an example would end the inline code span.
+		groups[key] = &repeatedLine{
+			example: strings.ReplaceAll(trimmed, "`", "'"),
+			count:   1,
+			first:   i,
+		}
+	}
+
+	repeats := make([]repeatedLine, 0, len(groups))
+	for _, g := range groups {
+		if g.count > 1 {
+			repeats = append(repeats, *g)
+		}
+	}
+
+	sort.Slice(repeats, func(i, j int) bool {
+		if repeats[i].count != repeats[j].count {
+			return repeats[i].count > repeats[j].count
+		}
+		return repeats[i].first < repeats[j].first
+	})
+
+	if len(repeats) > maxSummaryRepeats {
+		repeats = repeats[:maxSummaryRepeats]
+	}
+
+	return repeats
+}
+
+// formatCount formats the given count with thousands separators (e.g.,
+// 4,312).
+func formatCount(n int) string {
+	digits := strconv.Itoa(n)
+	if n < 1000 {
+		return digits
+	}
+
+	var output strings.Builder
+	lead := len(digits) % 3
+	if lead > 0 {
+		output.WriteString(digits[:lead])
+	}
+
+	for i := lead; i < len(digits); i += 3 {
+		if output.Len() > 0 {
+			output.WriteByte(',')
+		}
+		output.WriteString(digits[i : i+3])
+	}
+
+	return output.String()
+}
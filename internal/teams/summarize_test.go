@@ -0,0 +1,55 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package teams
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	var lines []string
+	lines = append(lines, "start")
+	for i := 0; i < 4312; i++ {
+		lines = append(lines, fmt.Sprintf("retry %d: connection refused", i))
+	}
+	for i := 0; i < 3; i++ {
+		lines = append(lines, "disk warning")
+	}
+	lines = append(lines, "end")
+
+	got := Summarize(strings.Join(lines, "\n"), 1)
+
+	for _, want := range []string{
+		"start\n\n",
+		"4,315 lines omitted",
+		"\n\nend\n\n",
+		"- 4,312 similar lines: `retry 0: connection refused`\n- 3 similar lines: `disk warning`",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("summary missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestSummarizeShortText(t *testing.T) {
+	text := "one\ntwo\nthree"
+	if got := Summarize(text, 2); got != text {
+		t.Errorf("Summarize(%q) = %q; want unchanged", text, got)
+	}
+}
+
+func TestFormatCount(t *testing.T) {
+	tests := map[int]string{0: "0", 999: "999", 1000: "1,000", 4312: "4,312", 1234567: "1,234,567"}
+	for n, want := range tests {
+		if got := formatCount(n); got != want {
+			t.Errorf("formatCount(%d) = %q; want %q", n, got, want)
+		}
+	}
+}
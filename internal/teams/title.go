@@ -0,0 +1,50 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package teams
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// maxDerivedTitleLength is the maximum length in characters of a title
+// derived from the message text.
+const maxDerivedTitleLength int = 80
+
+// derivedTitleEllipsis is appended to derived titles which were shortened.
+const derivedTitleEllipsis string = "..."
+
+// titleMarkdownPrefixes are the Markdown heading, quote and list markers
+// removed from the start of a line used as a title.
+const titleMarkdownPrefixes string = "#>*-+ \t"
+
+// DeriveTitle returns a title for an untitled message with the given text
+// and sender: the first non-empty line of the text (without any leading
+// Markdown heading, quote or list markers), shortened if needed, or the
+// sender if the text provides no such line.
+func DeriveTitle(text string, sender string) string {
+	text = strings.ReplaceAll(text, windowsEOLActual, unixEOLActual)
+	text = strings.ReplaceAll(text, macEOLActual, unixEOLActual)
+
+	for _, line := range strings.Split(text, unixEOLActual) {
+		line = strings.TrimSpace(strings.TrimLeft(line, titleMarkdownPrefixes))
+		line = strings.Trim(line, "*_`")
+		if line == "" {
+			continue
+		}
+
+		if utf8.RuneCountInString(line) > maxDerivedTitleLength {
+			runes := []rune(line)
+			line = strings.TrimSpace(string(runes[:maxDerivedTitleLength-len(derivedTitleEllipsis)])) + derivedTitleEllipsis
+		}
+
+		return line
+	}
+
+	return strings.TrimSpace(sender)
+}
@@ -0,0 +1,42 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package teams
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDeriveTitle(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		sender string
+		want   string
+	}{
+		{name: "first line", text: "Backup completed\r\nAll volumes copied.", want: "Backup completed"},
+		{name: "blank leading lines", text: "\n\n  \nDisk space low", want: "Disk space low"},
+		{name: "markdown heading", text: "## **Deploy finished**\ndetails", want: "Deploy finished"},
+		{name: "list item", text: "- item one\n- item two", want: "item one"},
+		{name: "sender fallback", text: "\n---\n", sender: "backup.sh", want: "backup.sh"},
+		{name: "no title", text: "   ", want: ""},
+		{
+			name: "shortened",
+			text: strings.Repeat("x", 100),
+			want: strings.Repeat("x", maxDerivedTitleLength-len(derivedTitleEllipsis)) + derivedTitleEllipsis,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DeriveTitle(tt.text, tt.sender); got != tt.want {
+				t.Errorf("got %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package templates
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// maxDataSize is the maximum number of bytes read from a template data
+// file.
+const maxDataSize int64 = 1024 * 1024
+
+// ReadDataFile reads the JSON encoded values available to a template as
+// .Data from the given file. Numbers are retained as written (e.g., large
+// integers are not converted to floating point values).
+func ReadDataFile(path string) (interface{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template data: %w", err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxDataSize+1))
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("failed to read template data: %w", err)
+	case int64(len(data)) > maxDataSize:
+		return nil, fmt.Errorf("template data file %s exceeds %d bytes", path, maxDataSize)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var values interface{}
+	if err := decoder.Decode(&values); err != nil {
+		return nil, fmt.Errorf("invalid template data file %s: %w", path, err)
+	}
+
+	if decoder.More() {
+		return nil, fmt.Errorf("invalid template data file %s: unexpected content after JSON value", path)
+	}
+
+	return values, nil
+}
@@ -0,0 +1,17 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package templates provides support for retrieving and rendering message
+templates.
+
+Templates may be retrieved from a local file, an HTTPS URL or a file within a
+Git repository (e.g., git+https://example.com/templates.git#alert.tmpl).
+Remote templates are cached locally and may be pinned to a specific SHA-256
+checksum so that centrally managed templates cannot change unexpectedly.
+*/
+package templates
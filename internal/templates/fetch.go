@@ -0,0 +1,485 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package templates
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// maxTemplateSize is the maximum number of bytes read from a template
+// source. Microsoft Teams rejects messages far smaller than this.
+const maxTemplateSize int64 = 1024 * 1024
+
+// Prefixes used to identify the type of a template source.
+const (
+	gitSourcePrefix   string = "git+"
+	httpsSourcePrefix string = "https://"
+	httpSourcePrefix  string = "http://"
+)
+
+// checksumPrefix is the optional prefix for a pinned template checksum.
+const checksumPrefix string = "sha256:"
+
+// gitCacheRef is the ref used to retain the last retrieved commit within a
+// cached Git template repository.
+const gitCacheRef string = "refs/send2teams/template"
+
+// ErrInsecureSource indicates that a remote template source does not use
+// HTTPS.
+var ErrInsecureSource = errors.New("remote template sources must use https")
+
+// maxRedirects is the maximum number of redirects followed when retrieving a
+// template from an HTTPS source.
+const maxRedirects int = 10
+
+// ErrChecksumMismatch indicates that the content of a template does not
+// match the pinned checksum.
+var ErrChecksumMismatch = errors.New("template checksum mismatch")
+
+// ErrInvalidChecksum indicates that a pinned checksum is not a valid SHA-256
+// checksum.
+var ErrInvalidChecksum = errors.New("invalid template checksum")
+
+// ErrInvalidGitSource indicates that a Git template source does not specify
+// a repository and file.
+var ErrInvalidGitSource = errors.New("git template source must be specified as git+https://host/repo.git#path/to/file")
+
+// FetchOptions controls how templates are retrieved.
+type FetchOptions struct {
+
+	// Client is used to retrieve templates from HTTPS sources. If not
+	// specified, http.DefaultClient is used.
+	Client *http.Client
+
+	// CacheDir is the directory used to cache remote templates. If not
+	// specified, remote templates are not cached.
+	CacheDir string
+
+	// Checksum is the (optional) SHA-256 checksum, as a hex encoded string
+	// with an optional "sha256:" prefix, that the template content must
+	// match.
+	Checksum string
+}
+
+// Template is the retrieved content of a template.
+type Template struct {
+
+	// RefreshErr is the error encountered when retrieving a remote template
+	// if a previously cached copy was used instead.
+	RefreshErr error
+
+	// Source is the user-specified template source.
+	Source string
+
+	// Content is the unrendered template.
+	Content []byte
+
+	// Cached indicates whether the template content was retrieved from the
+	// local cache.
+	Cached bool
+}
+
+// DefaultCacheDir returns the default directory used to cache remote
+// templates. An empty string is returned if the user cache directory cannot
+// be determined.
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "send2teams", "templates")
+}
+
+// Fetch retrieves the template from the given source. A source is either a
+// local file path, an HTTPS URL or a Git repository URL with the path to a
+// file within the repository given as the URL fragment. An optional ref
+// query parameter selects the Git branch or tag (e.g.,
+// git+https://example.com/templates.git?ref=v1#alert.tmpl).
+//
+// If a checksum is pinned, remote templates are retrieved from the cache
+// when the cached copy matches the checksum. Otherwise the remote template
+// is retrieved and cached, falling back to a previously cached copy (which
+// must match any pinned checksum) if retrieval fails.
+func Fetch(ctx context.Context, source string, opts FetchOptions) (Template, error) {
+	want, err := parseChecksum(opts.Checksum)
+	if err != nil {
+		return Template{}, err
+	}
+
+	var content []byte
+	tmpl := Template{Source: source}
+
+	switch {
+	case strings.HasPrefix(source, gitSourcePrefix):
+		tmpl, err = fetchGit(ctx, source, want, opts)
+		if err != nil {
+			return Template{}, err
+		}
+		content = tmpl.Content
+
+	case strings.HasPrefix(source, httpsSourcePrefix):
+		tmpl, err = fetchHTTPS(ctx, source, want, opts)
+		if err != nil {
+			return Template{}, err
+		}
+		content = tmpl.Content
+
+	case strings.HasPrefix(source, httpSourcePrefix):
+		return Template{}, ErrInsecureSource
+
+	default:
+		content, err = readFile(source)
+		if err != nil {
+			return Template{}, fmt.Errorf("failed to read template file: %w", err)
+		}
+		tmpl.Content = content
+	}
+
+	if err := verifyChecksum(content, want); err != nil {
+		return Template{}, err
+	}
+
+	return tmpl, nil
+}
+
+// parseChecksum decodes the given hex encoded SHA-256 checksum. A nil value
+// is returned if no checksum is specified.
+func parseChecksum(checksum string) ([]byte, error) {
+	checksum = strings.TrimSpace(checksum)
+	if checksum == "" {
+		return nil, nil
+	}
+
+	checksum = strings.TrimPrefix(strings.ToLower(checksum), checksumPrefix)
+
+	sum, err := hex.DecodeString(checksum)
+	if err != nil || len(sum) != sha256.Size {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidChecksum, checksum)
+	}
+
+	return sum, nil
+}
+
+// verifyChecksum asserts that the given content matches the pinned
+// checksum, if one is specified.
+func verifyChecksum(content []byte, want []byte) error {
+	if want == nil {
+		return nil
+	}
+
+	got := sha256.Sum256(content)
+	if !bytes.Equal(got[:], want) {
+		return fmt.Errorf(
+			"%w: expected %x, got %x",
+			ErrChecksumMismatch,
+			want,
+			got,
+		)
+	}
+
+	return nil
+}
+
+// cacheKey returns the name used to cache the template retrieved from the
+// given source.
+func cacheKey(source string) string {
+	sum := sha256.Sum256([]byte(source))
+	return hex.EncodeToString(sum[:])
+}
+
+// readFile reads up to maxTemplateSize bytes from the given file.
+func readFile(path string) ([]byte, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	return readLimited(f)
+}
+
+// readLimited reads from the given reader, returning an error if more than
+// maxTemplateSize bytes are available.
+func readLimited(r io.Reader) ([]byte, error) {
+	content, err := io.ReadAll(io.LimitReader(r, maxTemplateSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(content)) > maxTemplateSize {
+		return nil, fmt.Errorf("template exceeds maximum size of %d bytes", maxTemplateSize)
+	}
+
+	return content, nil
+}
+
+// writeCache atomically stores the given content in the cache.
+func writeCache(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".template-*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// fetchHTTPS retrieves a template from an HTTPS source, using the cache as
+// described for Fetch.
+func fetchHTTPS(ctx context.Context, source string, want []byte, opts FetchOptions) (Template, error) {
+	tmpl := Template{Source: source}
+
+	var cachePath string
+	var cached []byte
+	if opts.CacheDir != "" {
+		cachePath = filepath.Join(opts.CacheDir, "https", cacheKey(source))
+		if content, err := readFile(cachePath); err == nil {
+			cached = content
+		}
+	}
+
+	// A cached copy matching the pinned checksum cannot be stale.
+	if cached != nil && want != nil && verifyChecksum(cached, want) == nil {
+		tmpl.Content = cached
+		tmpl.Cached = true
+		return tmpl, nil
+	}
+
+	content, fetchErr := download(ctx, source, opts.Client)
+	switch {
+	case fetchErr == nil:
+		if err := verifyChecksum(content, want); err != nil {
+			return Template{}, err
+		}
+
+		if cachePath != "" {
+			// Failure to cache the template does not prevent its use.
+			_ = writeCache(cachePath, content)
+		}
+
+		tmpl.Content = content
+		return tmpl, nil
+
+	case cached != nil && want == nil:
+		tmpl.Content = cached
+		tmpl.Cached = true
+		tmpl.RefreshErr = fetchErr
+		return tmpl, nil
+
+	default:
+		return Template{}, fmt.Errorf("failed to retrieve template: %w", fetchErr)
+	}
+}
+
+// download retrieves the content of the given URL. Redirects to sources
+// other than HTTPS are refused.
+func download(ctx context.Context, source string, client *http.Client) ([]byte, error) {
+	client = httpsOnly(client)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+
+	return readLimited(resp.Body)
+}
+
+// httpsOnly returns a copy of the given client (or of http.DefaultClient if
+// not specified) which refuses to follow redirects to sources other than
+// HTTPS.
+func httpsOnly(client *http.Client) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	checkRedirect := client.CheckRedirect
+	secured := *client
+	secured.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if req.URL.Scheme != "https" {
+			return fmt.Errorf("%w: refusing redirect to %s://%s", ErrInsecureSource, req.URL.Scheme, req.URL.Host)
+		}
+
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+
+		return nil
+	}
+
+	return &secured
+}
+
+// parseGitSource splits a Git template source into the repository URL, the
+// optional ref and the path to the template within the repository.
+func parseGitSource(source string) (repo string, ref string, file string, err error) {
+	u, err := url.Parse(strings.TrimPrefix(source, gitSourcePrefix))
+	if err != nil {
+		return "", "", "", fmt.Errorf("%w: %v", ErrInvalidGitSource, err)
+	}
+
+	if u.Scheme != "https" {
+		return "", "", "", ErrInsecureSource
+	}
+
+	file = strings.TrimPrefix(u.Fragment, "/")
+	if u.Host == "" || file == "" {
+		return "", "", "", ErrInvalidGitSource
+	}
+
+	query := u.Query()
+	ref = query.Get("ref")
+	query.Del("ref")
+
+	u.RawQuery = query.Encode()
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	return u.String(), ref, file, nil
+}
+
+// fetchGit retrieves a template from a file within a Git repository. A
+// shallow fetch of the requested ref is kept in the cache directory so that
+// the last retrieved copy remains available if the repository cannot be
+// reached.
+func fetchGit(ctx context.Context, source string, want []byte, opts FetchOptions) (Template, error) {
+	repo, ref, file, err := parseGitSource(source)
+	if err != nil {
+		return Template{}, err
+	}
+
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	tmpl := Template{Source: source}
+
+	var dir string
+	switch {
+	case opts.CacheDir != "":
+		dir = filepath.Join(opts.CacheDir, "git", cacheKey(repo+"?ref="+ref))
+
+	default:
+		tmpDir, err := os.MkdirTemp("", "send2teams-template-")
+		if err != nil {
+			return Template{}, err
+		}
+		defer func() { _ = os.RemoveAll(tmpDir) }()
+		dir = tmpDir
+	}
+
+	_, statErr := os.Stat(filepath.Join(dir, "HEAD"))
+	initialized := statErr == nil
+
+	if initialized {
+		cached, err := runGit(ctx, dir, "show", gitCacheRef+":"+file)
+		if err == nil && want != nil && verifyChecksum(cached, want) == nil {
+			tmpl.Content = cached
+			tmpl.Cached = true
+			return tmpl, nil
+		}
+	}
+
+	if !initialized {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return Template{}, err
+		}
+
+		if _, err := runGit(ctx, dir, "init", "--quiet", "--bare"); err != nil {
+			return Template{}, fmt.Errorf("failed to initialize template repository cache: %w", err)
+		}
+	}
+
+	_, fetchErr := runGit(ctx, dir, "fetch", "--quiet", "--depth", "1", repo, "+"+ref+":"+gitCacheRef)
+	if fetchErr != nil && (!initialized || want != nil) {
+		return Template{}, fmt.Errorf("failed to retrieve template repository: %w", fetchErr)
+	}
+
+	content, err := runGit(ctx, dir, "show", gitCacheRef+":"+file)
+	if err != nil {
+		return Template{}, fmt.Errorf("failed to retrieve template %q from repository: %w", file, err)
+	}
+
+	tmpl.Content = content
+	if fetchErr != nil {
+		tmpl.Cached = true
+		tmpl.RefreshErr = fetchErr
+	}
+
+	return tmpl, nil
+}
+
+// runGit runs the git command with the given arguments against the
+// repository in the given directory, returning the standard output.
+func runGit(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	args = append([]string{"--git-dir", dir}, args...)
+
+	// #nosec G204
+	cmd := exec.CommandContext(ctx, "git", args...)
+
+	// Prevent prompts for credentials from blocking indefinitely.
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg, _, _ := strings.Cut(strings.TrimSpace(stderr.String()), "\n")
+		if msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+
+	if int64(stdout.Len()) > maxTemplateSize {
+		return nil, fmt.Errorf("template exceeds maximum size of %d bytes", maxTemplateSize)
+	}
+
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package templates
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func checksumOf(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func TestFetchFileChecksum(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alert.tmpl")
+	if err := os.WriteFile(path, []byte("{{ .Message }}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Fetch(context.Background(), path, FetchOptions{Checksum: checksumOf("{{ .Message }}")}); err != nil {
+		t.Fatalf("unexpected error for matching checksum: %v", err)
+	}
+
+	_, err := Fetch(context.Background(), path, FetchOptions{Checksum: checksumOf("other")})
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("got %v; want %v", err, ErrChecksumMismatch)
+	}
+}
+
+func TestFetchHTTPSCache(t *testing.T) {
+	const content = "Alert: {{ .Message }}"
+
+	var requests atomic.Int32
+	var fail atomic.Bool
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		if fail.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	opts := FetchOptions{Client: server.Client(), CacheDir: t.TempDir()}
+	source := server.URL + "/alert.tmpl"
+
+	tmpl, err := Fetch(context.Background(), source, opts)
+	if err != nil || tmpl.Cached || string(tmpl.Content) != content {
+		t.Fatalf("initial fetch: got (%+v, %v)", tmpl, err)
+	}
+
+	// Unpinned templates fall back to the cache when retrieval fails.
+	fail.Store(true)
+	tmpl, err = Fetch(context.Background(), source, opts)
+	if err != nil || !tmpl.Cached || tmpl.RefreshErr == nil {
+		t.Fatalf("fallback fetch: got (%+v, %v)", tmpl, err)
+	}
+
+	// Pinned templates matching the cache are not retrieved again.
+	fail.Store(false)
+	before := requests.Load()
+	opts.Checksum = checksumOf(content)
+	tmpl, err = Fetch(context.Background(), source, opts)
+	if err != nil || !tmpl.Cached || requests.Load() != before {
+		t.Fatalf("pinned fetch: got (%+v, %v), %d requests", tmpl, err, requests.Load()-before)
+	}
+
+	// Pinned templates which do not match are rejected.
+	opts.Checksum = checksumOf("other")
+	if _, err := Fetch(context.Background(), source, opts); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("got %v; want %v", err, ErrChecksumMismatch)
+	}
+}
+
+func TestFetchHTTPSRedirectDowngrade(t *testing.T) {
+	insecure := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("{{ .Message }}"))
+	}))
+	defer insecure.Close()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, insecure.URL+"/alert.tmpl", http.StatusFound)
+	}))
+	defer server.Close()
+
+	_, err := Fetch(context.Background(), server.URL+"/alert.tmpl", FetchOptions{Client: server.Client()})
+	if !errors.Is(err, ErrInsecureSource) {
+		t.Fatalf("got %v; want %v", err, ErrInsecureSource)
+	}
+}
+
+func TestParseGitSource(t *testing.T) {
+	repo, ref, file, err := parseGitSource("git+https://example.com/ops/templates.git?ref=v1#alerts/disk.tmpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if repo != "https://example.com/ops/templates.git" || ref != "v1" || file != "alerts/disk.tmpl" {
+		t.Errorf("got (%q, %q, %q)", repo, ref, file)
+	}
+
+	if _, _, _, err := parseGitSource("git+https://example.com/ops/templates.git"); !errors.Is(err, ErrInvalidGitSource) {
+		t.Errorf("got %v; want %v", err, ErrInvalidGitSource)
+	}
+
+	if _, _, _, err := parseGitSource("git+ssh://example.com/ops/templates.git#a.tmpl"); !errors.Is(err, ErrInsecureSource) {
+		t.Errorf("got %v; want %v", err, ErrInsecureSource)
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package templates
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Data is the set of values available to a template when it is rendered.
+type Data struct {
+
+	// Title is the user-specified message title.
+	Title string
+
+	// Message is the user-specified message content.
+	Message string
+
+	// Sender is the user-specified sending application name.
+	Sender string
+
+	// Team is the user-specified name of the target team.
+	Team string
+
+	// Channel is the user-specified name of the target channel.
+	Channel string
+
+	// Locale is the (optional) locale (e.g., "de", "fr-CA") the template is
+	// rendered for.
+	Locale string
+
+	// Exec describes the outcome of the command whose output is used as the
+	// message. Nil if no command was run.
+	Exec *ExecData
+
+	// Watch describes the changes which triggered the message in watch-file
+	// mode. Nil if not running in watch-file mode.
+	Watch *WatchData
+
+	// Data is the (optional) collection of values read from a template data
+	// file (e.g., a JSON object of monitoring results). Nil if no data file
+	// is specified.
+	Data interface{}
+}
+
+// ExecData describes the outcome of a command whose output is used as the
+// message, allowing templates to render distinct success and failure cards.
+type ExecData struct {
+
+	// Command is the command string which was run.
+	Command string
+
+	// ExitCode is the exit code of the command, or -1 if the command could
+	// not be started or was terminated.
+	ExitCode int
+
+	// Duration is how long the command ran.
+	Duration time.Duration
+
+	// Stdout is the (possibly truncated) standard output of the command.
+	Stdout string
+
+	// Stderr is the (possibly truncated) standard error of the command.
+	Stderr string
+
+	// Truncated indicates that standard output beyond the size limit was
+	// discarded.
+	Truncated bool
+
+	// TimedOut indicates that the command was terminated because it did not
+	// complete within the timeout.
+	TimedOut bool
+
+	// Failed indicates that the command could not be started, timed out or
+	// exited with a non-zero status.
+	Failed bool
+
+	// Error describes why the command failed. Empty if successful.
+	Error string
+}
+
+// WatchData describes the changes to a watched file or directory which
+// triggered a message.
+type WatchData struct {
+
+	// Path is the watched file or directory.
+	Path string
+
+	// Time is when the changes were detected.
+	Time time.Time
+
+	// Changes are the changed files, ordered by path.
+	Changes []WatchChange
+
+	// Diff is a unified diff of the changes made to text files. Empty if
+	// diffs are disabled or no text files were changed.
+	Diff string
+
+	// DiffTruncated indicates that the diff was reduced to the maximum
+	// number of lines.
+	DiffTruncated bool
+}
+
+// WatchChange is a change to a single watched file.
+type WatchChange struct {
+
+	// Path is the path of the file, relative to the watched directory. For
+	// a watched file this is the file name.
+	Path string
+
+	// Op is the kind of change: created, modified or removed.
+	Op string
+}
+
+// funcs are the functions available to a template in addition to the
+// text/template package builtins. Templates may be retrieved from remote
+// sources, so no functions exposing the environment (e.g., secrets) are
+// provided.
+var funcs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+	"default": func(fallback string, value string) string {
+		if value == "" {
+			return fallback
+		}
+		return value
+	},
+}
+
+// Render applies the given data to the template content, returning the
+// result. References to undefined values are reported as an error.
+//
+// If a locale is specified, a localized variant defined within the template
+// content (e.g., {{define "fr-CA"}}...{{end}}) is rendered in place of the
+// template itself. The variant for the full locale is preferred over the
+// variant for the language alone (e.g., "fr"). If no variant is defined, the
+// template itself is rendered.
+func Render(tmpl Template, data Data) (string, error) {
+	t, err := template.New(tmpl.Source).
+		Funcs(funcs).
+		Option("missingkey=error").
+		Parse(string(tmpl.Content))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	if variant := localeVariant(t, data.Locale); variant != nil {
+		t = variant
+	}
+
+	var output strings.Builder
+	if err := t.Execute(&output, data); err != nil {
+		return "", fmt.Errorf("failed to render template: %w", err)
+	}
+
+	return output.String(), nil
+}
+
+// localeVariant returns the template defined for the given locale or its
+// language, or nil if neither is defined.
+func localeVariant(t *template.Template, locale string) *template.Template {
+	if locale == "" {
+		return nil
+	}
+
+	// POSIX style locales (e.g., fr_CA) are accepted as well.
+	locale = strings.ReplaceAll(locale, "_", "-")
+
+	if variant := t.Lookup(locale); variant != nil {
+		return variant
+	}
+
+	if language, _, found := strings.Cut(locale, "-"); found {
+		return t.Lookup(language)
+	}
+
+	return nil
+}
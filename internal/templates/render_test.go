@@ -0,0 +1,102 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenderLocale(t *testing.T) {
+	tmpl := Template{
+		Source:  "alert.tmpl",
+		Content: []byte(`{{define "de"}}Festplatte voll: {{.Message}}{{end}}{{define "fr-CA"}}Disque plein : {{.Message}}{{end}}Disk full: {{.Message}}`),
+	}
+
+	tests := map[string]string{
+		"":      "Disk full: web01",
+		"en-US": "Disk full: web01",
+		"de":    "Festplatte voll: web01",
+		"de-AT": "Festplatte voll: web01",
+		"fr_CA": "Disque plein : web01",
+		"fr":    "Disk full: web01",
+	}
+
+	for locale, want := range tests {
+		got, err := Render(tmpl, Data{Message: "web01", Locale: locale})
+		if err != nil {
+			t.Errorf("Render() for locale %q error = %v", locale, err)
+			continue
+		}
+
+		if got != want {
+			t.Errorf("Render() for locale %q = %q; want %q", locale, got, want)
+		}
+	}
+}
+
+func TestRenderExec(t *testing.T) {
+	tmpl := Template{
+		Source:  "job.tmpl",
+		Content: []byte(`{{if .Exec.Failed}}Failed with exit code {{.Exec.ExitCode}}: {{trim .Exec.Stderr}}{{else}}Succeeded: {{.Message}}{{end}}`),
+	}
+
+	tests := map[string]struct {
+		exec ExecData
+		want string
+	}{
+		"success": {exec: ExecData{Stdout: "done"}, want: "Succeeded: done"},
+		"failure": {exec: ExecData{ExitCode: 3, Stderr: "disk full\n", Failed: true}, want: "Failed with exit code 3: disk full"},
+	}
+
+	for name, tt := range tests {
+		exec := tt.exec
+		got, err := Render(tmpl, Data{Message: exec.Stdout, Exec: &exec})
+		if err != nil {
+			t.Errorf("%s: Render() error = %v", name, err)
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("%s: Render() = %q; want %q", name, got, tt.want)
+		}
+	}
+}
+
+func TestRenderData(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	content := `{"host": "web1", "checks": [{"name": "disk", "used": 95}, {"name": "load", "used": 12345678901234567}]}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := ReadDataFile(path)
+	if err != nil {
+		t.Fatalf("ReadDataFile() error = %v", err)
+	}
+
+	tmpl := Template{
+		Source:  "checks.tmpl",
+		Content: []byte(`{{.Title}} on {{.Data.host}}:{{range .Data.checks}} {{.name}}={{.used}}{{end}}`),
+	}
+
+	got, err := Render(tmpl, Data{Title: "Checks", Data: values})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	if want := "Checks on web1: disk=95 load=12345678901234567"; got != want {
+		t.Errorf("Render() = %q; want %q", got, want)
+	}
+
+	missing := Template{Source: "missing.tmpl", Content: []byte(`{{.Data.port}}`)}
+	if _, err := Render(missing, Data{Data: values}); err == nil {
+		t.Error("Render() with undefined data value succeeded; want error")
+	}
+}
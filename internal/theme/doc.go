@@ -0,0 +1,14 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package theme loads card theme bundles. A theme bundle is a JSON file
+defining the color palette, icon set, footer style and layout defaults
+applied to every generated card so that organizations are able to enforce
+consistent branding across all scripts which submit messages.
+*/
+package theme
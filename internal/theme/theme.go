@@ -0,0 +1,260 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package theme
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/atc0005/go-teams-notify/v2/adaptivecard"
+)
+
+// fileExt is the file extension of theme bundles.
+const fileExt string = ".json"
+
+// IconDefault is the icon set key used for messages whose title color has no
+// dedicated icon.
+const IconDefault string = "default"
+
+// ErrInvalidTheme indicates that a theme bundle could not be loaded.
+var ErrInvalidTheme = errors.New("invalid theme")
+
+// Theme is a card theme bundle.
+type Theme struct {
+
+	// Name is the name of the theme.
+	Name string `json:"name"`
+
+	// Palette is the collection of colors applied to card text.
+	Palette Palette `json:"palette"`
+
+	// Icons is the collection of icon image URLs shown alongside the card
+	// title, keyed by the title color (e.g., "good", "attention") or
+	// IconDefault.
+	Icons map[string]string `json:"icons"`
+
+	// Footer is the style of the footer shown at the end of the card.
+	Footer Footer `json:"footer"`
+
+	// Layout is the collection of card layout defaults.
+	Layout Layout `json:"layout"`
+}
+
+// Palette is the collection of colors applied to card text. Each color is an
+// Adaptive Card color (e.g., "accent", "good", "attention").
+type Palette struct {
+
+	// Title is the color of the card title. Overridden by the color of the
+	// selected message class, if any.
+	Title string `json:"title"`
+
+	// Text is the color of the message text.
+	Text string `json:"text"`
+}
+
+// Footer is the style of the footer shown at the end of the card.
+type Footer struct {
+
+	// Text is the (optional) text shown in the footer ahead of the branding
+	// trailer (e.g., an organization name or support contact). Shown even
+	// if the branding trailer is disabled.
+	Text string `json:"text"`
+
+	// Style is the Adaptive Card container style of the footer (e.g.,
+	// "default", "emphasis").
+	Style string `json:"style"`
+
+	// Color is the Adaptive Card color of the footer text.
+	Color string `json:"color"`
+
+	// Size is the Adaptive Card text size of the footer text (e.g.,
+	// "small", "default").
+	Size string `json:"size"`
+}
+
+// Layout is the collection of card layout defaults.
+type Layout struct {
+
+	// FullWidth indicates whether the card uses the full width of the
+	// channel. Defaults to true.
+	FullWidth *bool `json:"full_width"`
+
+	// Spacing is the Adaptive Card spacing (e.g., "small", "large") used
+	// between the sections of the card.
+	Spacing string `json:"spacing"`
+
+	// ContainerStyle is the Adaptive Card container style (e.g.,
+	// "emphasis", "accent") of the sections of the card containing links and
+	// file content.
+	ContainerStyle string `json:"container_style"`
+}
+
+// Resolve returns the path of the theme bundle with the given name within
+// the given directory. Values which are already a path (i.e., which contain
+// a path separator or file extension) are returned as-is.
+func Resolve(dir string, name string) string {
+	if strings.ContainsRune(name, '/') ||
+		strings.ContainsRune(name, filepath.Separator) ||
+		strings.HasSuffix(name, fileExt) {
+		return name
+	}
+
+	return filepath.Join(dir, name+fileExt)
+}
+
+// Load reads and validates the theme bundle at the given path.
+func Load(path string) (Theme, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return Theme{}, fmt.Errorf("failed to read theme %s: %w", path, err)
+	}
+
+	return Parse(data, path)
+}
+
+// Parse decodes and validates the given theme bundle read from the given
+// path. If the bundle does not specify a name, the file name (without
+// extension) is used.
+func Parse(data []byte, path string) (Theme, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var t Theme
+	if err := dec.Decode(&t); err != nil {
+		return Theme{}, fmt.Errorf("%w %s: %v", ErrInvalidTheme, path, err)
+	}
+
+	if t.Name == "" {
+		t.Name = strings.TrimSuffix(filepath.Base(path), fileExt)
+	}
+
+	if err := t.Validate(); err != nil {
+		return Theme{}, fmt.Errorf("%w %s: %v", ErrInvalidTheme, path, err)
+	}
+
+	return t, nil
+}
+
+// Validate verifies that the theme specifies only supported values.
+func (t Theme) Validate() error {
+	colors := []string{
+		adaptivecard.ColorDefault,
+		adaptivecard.ColorDark,
+		adaptivecard.ColorLight,
+		adaptivecard.ColorAccent,
+		adaptivecard.ColorGood,
+		adaptivecard.ColorWarning,
+		adaptivecard.ColorAttention,
+	}
+
+	containerStyles := []string{
+		adaptivecard.ContainerStyleDefault,
+		adaptivecard.ContainerStyleEmphasis,
+		adaptivecard.ContainerStyleGood,
+		adaptivecard.ContainerStyleAttention,
+		adaptivecard.ContainerStyleWarning,
+		adaptivecard.ContainerStyleAccent,
+	}
+
+	checks := []struct {
+		field     string
+		value     string
+		supported []string
+	}{
+		{field: "palette.title", value: t.Palette.Title, supported: colors},
+		{field: "palette.text", value: t.Palette.Text, supported: colors},
+		{field: "footer.style", value: t.Footer.Style, supported: containerStyles},
+		{field: "footer.color", value: t.Footer.Color, supported: colors},
+		{
+			field: "footer.size",
+			value: t.Footer.Size,
+			supported: []string{
+				adaptivecard.SizeSmall,
+				adaptivecard.SizeDefault,
+				adaptivecard.SizeMedium,
+				adaptivecard.SizeLarge,
+				adaptivecard.SizeExtraLarge,
+			},
+		},
+		{
+			field: "layout.spacing",
+			value: t.Layout.Spacing,
+			supported: []string{
+				adaptivecard.SpacingNone,
+				adaptivecard.SpacingSmall,
+				adaptivecard.SpacingDefault,
+				adaptivecard.SpacingMedium,
+				adaptivecard.SpacingLarge,
+				adaptivecard.SpacingExtraLarge,
+				adaptivecard.SpacingPadding,
+			},
+		},
+		{field: "layout.container_style", value: t.Layout.ContainerStyle, supported: containerStyles},
+	}
+
+	for _, check := range checks {
+		if check.value != "" && !contains(check.supported, check.value) {
+			return fmt.Errorf(
+				"unsupported %s value %q; expected one of %s",
+				check.field,
+				check.value,
+				strings.Join(check.supported, ", "),
+			)
+		}
+	}
+
+	for key, iconURL := range t.Icons {
+		if key != IconDefault && !contains(colors, key) {
+			return fmt.Errorf(
+				"unsupported icon %q; expected %s or one of %s",
+				key,
+				IconDefault,
+				strings.Join(colors, ", "),
+			)
+		}
+
+		u, err := url.Parse(iconURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("invalid URL %q for icon %q", iconURL, key)
+		}
+	}
+
+	return nil
+}
+
+// Icon returns the URL of the icon shown for a card title with the given
+// color. An empty string is returned if the theme has no suitable icon.
+func (t Theme) Icon(titleColor string) string {
+	if icon, ok := t.Icons[titleColor]; ok && titleColor != "" {
+		return icon
+	}
+
+	return t.Icons[IconDefault]
+}
+
+// FullWidth indicates whether the card uses the full width of the channel.
+func (t Theme) FullWidth() bool {
+	return t.Layout.FullWidth == nil || *t.Layout.FullWidth
+}
+
+// contains indicates whether the given value is in the given list.
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
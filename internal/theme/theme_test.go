@@ -0,0 +1,90 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package theme
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTheme(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "corporate.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write theme: %v", err)
+	}
+
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	path := writeTheme(t, `{
+		"palette": {"title": "accent"},
+		"icons": {"default": "https://example.com/info.png", "attention": "https://example.com/error.png"},
+		"layout": {"full_width": false}
+	}`)
+
+	theme, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+
+	if theme.Name != "corporate" {
+		t.Errorf("theme name = %q, want name derived from file", theme.Name)
+	}
+
+	if theme.FullWidth() {
+		t.Error("theme FullWidth() = true, want false")
+	}
+
+	for color, want := range map[string]string{
+		"attention": "https://example.com/error.png",
+		"good":      "https://example.com/info.png",
+		"":          "https://example.com/info.png",
+	} {
+		if got := theme.Icon(color); got != want {
+			t.Errorf("theme Icon(%q) = %q, want %q", color, got, want)
+		}
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown field":   `{"colour": {}}`,
+		"unknown color":   `{"palette": {"title": "pink"}}`,
+		"unknown icon":    `{"icons": {"critical": "https://example.com/x.png"}}`,
+		"invalid icon":    `{"icons": {"default": "not a url"}}`,
+		"unknown spacing": `{"layout": {"spacing": "huge"}}`,
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Load(writeTheme(t, content)); !errors.Is(err, ErrInvalidTheme) {
+				t.Errorf("Load() error = %v, want %v", err, ErrInvalidTheme)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := map[string]string{
+		"corporate":               filepath.Join("themes", "corporate.json"),
+		"corporate.json":          "corporate.json",
+		"/etc/themes/corporate":   "/etc/themes/corporate",
+		"./themes/corporate.json": "./themes/corporate.json",
+	}
+
+	for name, want := range tests {
+		if got := Resolve("themes", name); got != want {
+			t.Errorf("Resolve(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
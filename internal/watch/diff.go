@@ -0,0 +1,194 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package watch
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// maxDiffCells bounds the work done comparing two versions of a file; files
+// with more lines are described without a diff.
+const maxDiffCells = 4_000_000
+
+// editKind is the kind of an edit within a diff.
+type editKind byte
+
+const (
+	editEqual  editKind = ' '
+	editDelete editKind = '-'
+	editInsert editKind = '+'
+)
+
+// edit is a single line of a diff.
+type edit struct {
+	kind editKind
+	text string
+
+	// oldLine and newLine are the (0-based) line numbers of the line within
+	// the old and new versions of the file.
+	oldLine int
+	newLine int
+}
+
+// Unified returns the lines of a unified diff between the given old and new
+// versions of the named file. No lines are returned if the versions are
+// identical.
+func Unified(name string, old string, new string) []string {
+	if old == new {
+		return nil
+	}
+
+	oldLines, newLines := splitLines(old), splitLines(new)
+	if len(oldLines)*len(newLines) > maxDiffCells {
+		return []string{fmt.Sprintf("File %s changed (too many lines to compare)", name)}
+	}
+
+	edits := diffLines(oldLines, newLines)
+
+	lines := []string{"--- a/" + name, "+++ b/" + name}
+	for _, hunk := range hunks(edits) {
+		lines = append(lines, hunk...)
+	}
+
+	return lines
+}
+
+// splitLines splits the given text into lines, ignoring a trailing newline.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines returns the edits transforming the old lines into the new lines,
+// using the longest common subsequence of the lines.
+func diffLines(oldLines []string, newLines []string) []edit {
+	n, m := len(oldLines), len(newLines)
+
+	// lcs[i][k] is the length of the longest common subsequence of
+	// oldLines[i:] and newLines[k:].
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+
+	for i := n - 1; i >= 0; i-- {
+		for k := m - 1; k >= 0; k-- {
+			switch {
+			case oldLines[i] == newLines[k]:
+				lcs[i][k] = lcs[i+1][k+1] + 1
+			case lcs[i+1][k] >= lcs[i][k+1]:
+				lcs[i][k] = lcs[i+1][k]
+			default:
+				lcs[i][k] = lcs[i][k+1]
+			}
+		}
+	}
+
+	edits := make([]edit, 0, n+m)
+	i, k := 0, 0
+	for i < n || k < m {
+		switch {
+		case i < n && k < m && oldLines[i] == newLines[k]:
+			edits = append(edits, edit{kind: editEqual, text: oldLines[i], oldLine: i, newLine: k})
+			i++
+			k++
+		case k == m || (i < n && lcs[i+1][k] >= lcs[i][k+1]):
+			edits = append(edits, edit{kind: editDelete, text: oldLines[i], oldLine: i, newLine: k})
+			i++
+		default:
+			edits = append(edits, edit{kind: editInsert, text: newLines[k], oldLine: i, newLine: k})
+			k++
+		}
+	}
+
+	return edits
+}
+
+// hunks groups the given edits into hunks, each with a header and up to
+// diffContext unchanged lines around the changes it contains.
+func hunks(edits []edit) [][]string {
+	var result [][]string
+
+	for start := 0; start < len(edits); {
+		// Find the next change.
+		first := start
+		for first < len(edits) && edits[first].kind == editEqual {
+			first++
+		}
+		if first == len(edits) {
+			break
+		}
+
+		// Extend the hunk while changes are separated by no more than twice
+		// the context.
+		last := first
+		for next := first + 1; next < len(edits); next++ {
+			if edits[next].kind == editEqual {
+				continue
+			}
+			if next-last > 2*diffContext {
+				break
+			}
+			last = next
+		}
+
+		from := first - diffContext
+		if from < start {
+			from = start
+		}
+		if from < 0 {
+			from = 0
+		}
+
+		to := last + diffContext + 1
+		if to > len(edits) {
+			to = len(edits)
+		}
+
+		result = append(result, formatHunk(edits[from:to]))
+		start = to
+	}
+
+	return result
+}
+
+// formatHunk returns the lines of the hunk containing the given edits.
+func formatHunk(edits []edit) []string {
+	var oldCount, newCount int
+	for _, e := range edits {
+		if e.kind != editInsert {
+			oldCount++
+		}
+		if e.kind != editDelete {
+			newCount++
+		}
+	}
+
+	oldStart, newStart := edits[0].oldLine+1, edits[0].newLine+1
+	if oldCount == 0 {
+		oldStart--
+	}
+	if newCount == 0 {
+		newStart--
+	}
+
+	lines := make([]string, 0, len(edits)+1)
+	lines = append(lines, fmt.Sprintf("@@ -%d,%d +%d,%d @@", oldStart, oldCount, newStart, newCount))
+	for _, e := range edits {
+		lines = append(lines, string(e.kind)+e.text)
+	}
+
+	return lines
+}
@@ -0,0 +1,13 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package watch detects changes to a file or the files within a directory by
+periodically comparing their content, coalescing bursts of changes into a
+single event and describing modified text files using a unified diff.
+*/
+package watch
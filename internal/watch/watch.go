@@ -0,0 +1,477 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package watch
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxFiles is the maximum number of files watched within a directory.
+const maxFiles = 10000
+
+// maxCompareSize is the maximum size in bytes of a file whose content is
+// compared. Changes to larger files are detected using their size and
+// modification time.
+const maxCompareSize = 16 * 1024 * 1024
+
+// maxDiffSize is the maximum size in bytes of a file whose content is
+// retained to describe changes using a diff.
+const maxDiffSize = 256 * 1024
+
+// maxRetainedSize is the maximum total size in bytes of the content retained
+// for all watched files. Changes to files whose content is not retained (once
+// the limit is reached) are described without a diff; other files are
+// tracked using only their size, modification time and checksum.
+const maxRetainedSize = 8 * 1024 * 1024
+
+// Op is a kind of change to a watched file. Ops may be combined to select
+// the changes of interest.
+type Op uint8
+
+// Supported kinds of changes.
+const (
+	Create Op = 1 << iota
+	Modify
+	Remove
+)
+
+// AllOps selects all kinds of changes.
+const AllOps = Create | Modify | Remove
+
+// String returns a description of the change (e.g., "modified").
+func (op Op) String() string {
+	switch op {
+	case Create:
+		return "created"
+	case Modify:
+		return "modified"
+	case Remove:
+		return "removed"
+	default:
+		return fmt.Sprintf("Op(%d)", uint8(op))
+	}
+}
+
+// Change is a change to a single watched file.
+type Change struct {
+
+	// Path is the path of the file, relative to the watched directory. For
+	// a watched file this is the file name.
+	Path string
+
+	// Op is the kind of change.
+	Op Op
+}
+
+// Event describes the changes made to the watched path since the previous
+// event (or since watching started).
+type Event struct {
+
+	// Path is the watched path.
+	Path string
+
+	// Time is when the changes were detected.
+	Time time.Time
+
+	// Changes are the changes made, ordered by path.
+	Changes []Change
+
+	// Diff is a unified diff of the changes made to text files. Empty if
+	// diffs are disabled or no text files were changed.
+	Diff string
+
+	// DiffTruncated indicates that the diff was reduced to the maximum
+	// number of lines.
+	DiffTruncated bool
+}
+
+// Options controls how a path is watched.
+type Options struct {
+
+	// Interval is the time between checks of the watched path.
+	Interval time.Duration
+
+	// Debounce is how long the watched path must remain unchanged before an
+	// event is emitted, so that a burst of changes (e.g., an editor saving
+	// a file or a report being regenerated) produces a single event.
+	Debounce time.Duration
+
+	// Ops selects the kinds of changes which produce an event. All changes
+	// are selected if zero.
+	Ops Op
+
+	// DiffLines is the maximum number of lines of the diff included in an
+	// event. Diffs are disabled (and file content is not retained) if zero.
+	DiffLines int
+
+	// OnError is called (if set) when an error first prevents a file, or
+	// the watched path, from being checked. The file is skipped, retaining
+	// its previous state, and watching continues.
+	OnError func(path string, err error)
+}
+
+// fileState is the state of a watched file when last checked.
+type fileState struct {
+	size    int64
+	modTime time.Time
+	mode    fs.FileMode
+
+	// sum is the checksum of the file content. Not set if the file is too
+	// large to compare.
+	sum    [sha256.Size]byte
+	hashed bool
+
+	// content is the file content, retained for text files small enough to
+	// describe using a diff if diffs are enabled and maxRetainedSize is not
+	// exceeded.
+	content []byte
+	binary  bool
+}
+
+// snapshot is the state of each watched file, keyed by path relative to
+// the watched directory.
+type snapshot map[string]fileState
+
+// scanner collects the state of watched files.
+type scanner struct {
+
+	// retain indicates whether file content is retained to describe changes
+	// using a diff.
+	retain bool
+
+	// onError is called (if set) with each error preventing a file from
+	// being checked.
+	onError func(path string, err error)
+
+	// failed records the errors reported by the previous scan, keyed by
+	// path, so that a file which remains unreadable is reported only once;
+	// failing records those of the current scan.
+	failed  map[string]string
+	failing map[string]string
+}
+
+// skip reports the given error preventing the given path from being
+// checked, unless the same error was reported by the previous scan.
+func (sc *scanner) skip(path string, err error) {
+	if sc.failing == nil {
+		sc.failing = make(map[string]string)
+	}
+	sc.failing[path] = err.Error()
+
+	if sc.onError != nil && sc.failed[path] != err.Error() {
+		sc.onError(path, err)
+	}
+}
+
+// Watch checks the given file or directory for changes until the given
+// context is done, calling handle with each event. A watched file which
+// does not yet exist is reported as created once it does. An error is
+// returned if the path cannot be checked initially; later errors are
+// reported to opts.OnError and the affected files are skipped.
+func Watch(ctx context.Context, path string, opts Options, handle func(Event)) error {
+	if opts.Interval <= 0 {
+		return fmt.Errorf("watch interval too short")
+	}
+
+	ops := opts.Ops
+	if ops == 0 {
+		ops = AllOps
+	}
+
+	sc := &scanner{retain: opts.DiffLines > 0, onError: opts.OnError}
+
+	baseline, err := sc.scan(path, nil)
+	if err != nil {
+		return err
+	}
+	sc.failed, sc.failing = sc.failing, nil
+	current := baseline
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	var lastChange time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		next, err := sc.scan(path, current)
+		if err != nil {
+			sc.skip(path, err)
+		}
+		sc.failed, sc.failing = sc.failing, nil
+		if err != nil {
+			continue
+		}
+
+		now := time.Now()
+		if len(compare(current, next)) > 0 {
+			lastChange = now
+		}
+		current = next
+
+		if lastChange.IsZero() || now.Sub(lastChange) < opts.Debounce {
+			continue
+		}
+		lastChange = time.Time{}
+
+		// Changes which were reverted during the debounce period are not
+		// reported.
+		previous := baseline
+		baseline = current
+
+		var changes []Change
+		for _, change := range compare(previous, current) {
+			if change.Op&ops != 0 {
+				changes = append(changes, change)
+			}
+		}
+
+		if len(changes) == 0 {
+			continue
+		}
+
+		event := Event{
+			Path:    path,
+			Time:    now,
+			Changes: changes,
+		}
+
+		if opts.DiffLines > 0 {
+			event.Diff, event.DiffTruncated = diffChanges(previous, current, changes, opts.DiffLines)
+		}
+
+		handle(event)
+	}
+}
+
+// scan returns the state of the given file or of each file within the given
+// directory. The content of files whose size and modification time are
+// unchanged from the previous snapshot is not read again. Files which cannot
+// be read are skipped, retaining their previous state; an error is returned
+// only if the watched path itself cannot be checked.
+func (sc *scanner) scan(path string, previous snapshot) (snapshot, error) {
+	info, err := os.Stat(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return snapshot{}, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to check watched path: %w", err)
+	}
+
+	snap := make(snapshot)
+	var retained int
+
+	// keep records the state of the given file, retaining its content only
+	// while maxRetainedSize is not exceeded.
+	keep := func(rel string, state fileState) {
+		retained -= len(snap[rel].content)
+		if state.content != nil {
+			if !sc.retain || retained+len(state.content) > maxRetainedSize {
+				state.content = nil
+			}
+			retained += len(state.content)
+		}
+		snap[rel] = state
+	}
+
+	// skipFiles reports the given error and retains the previous state of
+	// the given file, or of the files within the given directory.
+	skipFiles := func(file string, rel string, dir bool, err error) {
+		sc.skip(file, err)
+		for name, state := range previous {
+			if name == rel || (dir && (rel == "." || strings.HasPrefix(name, rel+"/"))) {
+				keep(name, state)
+			}
+		}
+	}
+
+	if !info.IsDir() {
+		name := filepath.Base(path)
+		state, err := readState(path, info, previous[name])
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+		case err != nil:
+			skipFiles(path, name, false, err)
+		default:
+			keep(name, state)
+		}
+
+		return snap, nil
+	}
+
+	err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		rel, relErr := filepath.Rel(path, file)
+		if relErr != nil {
+			return relErr
+		}
+		rel = filepath.ToSlash(rel)
+
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			return nil
+		case err != nil:
+			skipFiles(file, rel, entry == nil || entry.IsDir(), err)
+			return nil
+		case entry.IsDir() || !entry.Type().IsRegular():
+			return nil
+		}
+
+		if len(snap) >= maxFiles {
+			return fmt.Errorf("more than %d files within watched directory", maxFiles)
+		}
+
+		info, err := entry.Info()
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			return nil
+		case err != nil:
+			skipFiles(file, rel, false, err)
+			return nil
+		}
+
+		state, err := readState(file, info, previous[rel])
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			return nil
+		case err != nil:
+			skipFiles(file, rel, false, err)
+			return nil
+		}
+		keep(rel, state)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to check watched path: %w", err)
+	}
+
+	return snap, nil
+}
+
+// readState returns the state of the given file, reusing the previous state
+// if the size and modification time of the file are unchanged.
+func readState(path string, info fs.FileInfo, previous fileState) (fileState, error) {
+	state := fileState{
+		size:    info.Size(),
+		modTime: info.ModTime(),
+		mode:    info.Mode(),
+	}
+
+	if state.size == previous.size && state.modTime.Equal(previous.modTime) && state.mode == previous.mode {
+		return previous, nil
+	}
+
+	if state.size > maxCompareSize {
+		return state, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fileState{}, err
+	}
+
+	state.sum = sha256.Sum256(content)
+	state.hashed = true
+	state.binary = isBinary(content)
+	if !state.binary && len(content) <= maxDiffSize {
+		state.content = content
+	}
+
+	return state, nil
+}
+
+// isBinary indicates whether the given content does not appear to be text.
+func isBinary(content []byte) bool {
+	sample := content
+	if len(sample) > 8000 {
+		sample = sample[:8000]
+	}
+
+	return bytes.IndexByte(sample, 0) >= 0 || !utf8.Valid(content)
+}
+
+// changed indicates whether the file content differs between the given
+// states.
+func changed(old fileState, new fileState) bool {
+	if old.hashed && new.hashed {
+		return old.sum != new.sum
+	}
+
+	return old.size != new.size || !old.modTime.Equal(new.modTime)
+}
+
+// compare returns the changes between the given snapshots, ordered by path.
+func compare(old snapshot, new snapshot) []Change {
+	var changes []Change
+
+	for path, state := range new {
+		prev, ok := old[path]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Path: path, Op: Create})
+		case changed(prev, state):
+			changes = append(changes, Change{Path: path, Op: Modify})
+		}
+	}
+
+	for path := range old {
+		if _, ok := new[path]; !ok {
+			changes = append(changes, Change{Path: path, Op: Remove})
+		}
+	}
+
+	sort.Slice(changes, func(i, k int) bool {
+		return changes[i].Path < changes[k].Path
+	})
+
+	return changes
+}
+
+// diffChanges returns a unified diff describing the given changes, limited
+// to the given number of lines, and whether the diff was truncated.
+func diffChanges(old snapshot, new snapshot, changes []Change, maxLines int) (string, bool) {
+	var lines []string
+
+	for _, change := range changes {
+		before, after := old[change.Path], new[change.Path]
+
+		switch {
+		case before.binary || after.binary:
+			lines = append(lines, fmt.Sprintf("Binary file %s %s", change.Path, change.Op))
+			continue
+
+		case change.Op != Create && before.content == nil && before.size > 0,
+			change.Op != Remove && after.content == nil && after.size > 0:
+			lines = append(lines, fmt.Sprintf("File %s %s (too large to compare)", change.Path, change.Op))
+			continue
+		}
+
+		lines = append(lines, Unified(change.Path, string(before.content), string(after.content))...)
+	}
+
+	if len(lines) > maxLines {
+		return strings.Join(lines[:maxLines], "\n"), true
+	}
+
+	return strings.Join(lines, "\n"), false
+}
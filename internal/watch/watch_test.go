@@ -0,0 +1,218 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package watch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUnified(t *testing.T) {
+	old := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	new := "a\nb\nc\nD\ne\nf\ng\nh\ni\nj\nk\n"
+
+	want := []string{
+		"--- a/app.conf",
+		"+++ b/app.conf",
+		"@@ -1,7 +1,7 @@",
+		" a",
+		" b",
+		" c",
+		"-d",
+		"+D",
+		" e",
+		" f",
+		" g",
+		"@@ -8,3 +8,4 @@",
+		" h",
+		" i",
+		" j",
+		"+k",
+	}
+
+	if got := Unified("app.conf", old, new); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if got := Unified("app.conf", old, old); got != nil {
+		t.Errorf("expected no diff for identical content, got %q", got)
+	}
+
+	created := Unified("new.conf", "", "x\n")
+	if len(created) != 4 || created[2] != "@@ -0,0 +1,1 @@" || created[3] != "+x" {
+		t.Errorf("unexpected diff for created file: %q", created)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	old := snapshot{
+		"kept":    {size: 1, hashed: true, sum: [32]byte{1}},
+		"edited":  {size: 1, hashed: true, sum: [32]byte{1}},
+		"touched": {size: 1, hashed: true, sum: [32]byte{1}, modTime: time.Unix(1, 0)},
+		"removed": {size: 1},
+	}
+	new := snapshot{
+		"kept":    {size: 1, hashed: true, sum: [32]byte{1}},
+		"edited":  {size: 1, hashed: true, sum: [32]byte{2}},
+		"touched": {size: 1, hashed: true, sum: [32]byte{1}, modTime: time.Unix(2, 0)},
+		"created": {size: 1},
+	}
+
+	want := []Change{
+		{Path: "created", Op: Create},
+		{Path: "edited", Op: Modify},
+		{Path: "removed", Op: Remove},
+	}
+
+	if got := compare(old, new); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestWatchDebouncesChanges(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.txt")
+	if err := os.WriteFile(path, []byte("one\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	events := make(chan Event, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- Watch(ctx, dir, Options{
+			Interval:  10 * time.Millisecond,
+			Debounce:  200 * time.Millisecond,
+			DiffLines: 50,
+		}, func(e Event) { events <- e })
+	}()
+
+	// Allow the initial scan to complete, then make a burst of changes.
+	time.Sleep(50 * time.Millisecond)
+	for _, content := range []string{"two\n", "three\n"} {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(30 * time.Millisecond)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "extra.txt"), []byte("x\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var event Event
+	select {
+	case event = <-events:
+	case <-ctx.Done():
+		t.Fatal("no event received")
+	}
+
+	want := []Change{{Path: "extra.txt", Op: Create}, {Path: "report.txt", Op: Modify}}
+	if !reflect.DeepEqual(event.Changes, want) {
+		t.Errorf("got changes %v, want %v", event.Changes, want)
+	}
+
+	if !strings.Contains(event.Diff, "-one\n+three") {
+		t.Errorf("unexpected diff:\n%s", event.Diff)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	select {
+	case extra := <-events:
+		t.Errorf("expected a single event, also got %v", extra.Changes)
+	default:
+	}
+}
+
+func TestScanSkipsUnreadableFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "secret.txt")
+	if err := os.WriteFile(path, []byte("one\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var reported []string
+	sc := &scanner{retain: true, onError: func(path string, err error) {
+		reported = append(reported, path)
+	}}
+
+	previous, err := sc.scan(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte("two\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.ReadFile(path); err == nil {
+		t.Skip("unable to make file unreadable (e.g., running as root)")
+	}
+
+	for i := 0; i < 2; i++ {
+		next, err := sc.scan(dir, previous)
+		if err != nil {
+			t.Fatalf("got error %v; want unreadable file skipped", err)
+		}
+		sc.failed, sc.failing = sc.failing, nil
+
+		if changes := compare(previous, next); len(changes) != 0 {
+			t.Errorf("got changes %v; want previous state of unreadable file retained", changes)
+		}
+	}
+
+	if !reflect.DeepEqual(reported, []string{path}) {
+		t.Errorf("got errors reported for %v; want %s reported once", reported, path)
+	}
+}
+
+func TestScanLimitsRetainedContent(t *testing.T) {
+	dir := t.TempDir()
+	content := []byte(strings.Repeat("line\n", maxDiffSize/5))
+	count := maxRetainedSize/len(content) + 2
+	for i := 0; i < count; i++ {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%02d.txt", i)), content, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, retain := range []bool{true, false} {
+		sc := &scanner{retain: retain}
+		snap, err := sc.scan(dir, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var retained int
+		for _, state := range snap {
+			retained += len(state.content)
+		}
+
+		switch {
+		case len(snap) != count:
+			t.Errorf("got %d files; want %d", len(snap), count)
+		case retain && (retained == 0 || retained > maxRetainedSize):
+			t.Errorf("got %d bytes of content retained; want at most %d", retained, maxRetainedSize)
+		case !retain && retained != 0:
+			t.Errorf("got %d bytes of content retained with diffs disabled; want none", retained)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultHost is the host used when composing a webhook URL if one is not
+// specified.
+const DefaultHost string = "outlook.office.com"
+
+// ErrInvalidParts indicates that the components given to compose a webhook
+// URL are malformed.
+var ErrInvalidParts = errors.New("invalid webhook URL components")
+
+var (
+	guidPattern        = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	connectorIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{32}$`)
+	signaturePattern   = regexp.MustCompile(`^[-_a-zA-Z0-9]+$`)
+	hostPattern        = regexp.MustCompile(`^[a-zA-Z0-9]([-a-zA-Z0-9]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([-a-zA-Z0-9]*[a-zA-Z0-9])?)+$`)
+)
+
+// Compose assembles a webhook URL from the given host and comma-separated
+// components, specified in the order tenant GUID, webhook GUID, connector ID
+// and group ID with an optional trailing signature (used by the newer
+// *.webhook.office.com format). The resulting URL has the form:
+//
+//	https://<host>/webhook/<webhook-guid>@<tenant-guid>/IncomingWebhook/<connector-id>/<group-id>[/<signature>]
+//
+// The webhookb2 path is used for *.webhook.office.com hosts. If the host is
+// empty DefaultHost is used. An error wrapping ErrInvalidParts is returned
+// if a component is missing or malformed.
+func Compose(host string, parts string) (string, error) {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if host == "" {
+		host = DefaultHost
+	}
+
+	if !hostPattern.MatchString(host) {
+		return "", fmt.Errorf("%w: host %q is not a hostname (omit the scheme and path)", ErrInvalidParts, host)
+	}
+
+	fields := strings.Split(parts, ",")
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+
+	if len(fields) != 4 && len(fields) != 5 {
+		return "", fmt.Errorf(
+			"%w: expected tenant GUID, webhook GUID, connector ID and group ID (and optional signature), got %d component(s)",
+			ErrInvalidParts, len(fields),
+		)
+	}
+
+	checks := []struct {
+		name    string
+		pattern *regexp.Regexp
+		format  string
+	}{
+		{name: "tenant GUID", pattern: guidPattern, format: "a GUID"},
+		{name: "webhook GUID", pattern: guidPattern, format: "a GUID"},
+		{name: "connector ID", pattern: connectorIDPattern, format: "32 hexadecimal characters"},
+		{name: "group ID", pattern: guidPattern, format: "a GUID"},
+		{name: "signature", pattern: signaturePattern, format: "letters, digits, dashes or underscores"},
+	}
+
+	for i, field := range fields {
+		if !checks[i].pattern.MatchString(field) {
+			return "", fmt.Errorf("%w: %s (component %d) is not %s", ErrInvalidParts, checks[i].name, i+1, checks[i].format)
+		}
+	}
+
+	path := "webhook"
+	if strings.HasSuffix(host, ".webhook.office.com") {
+		path = "webhookb2"
+	}
+
+	webhookURL := fmt.Sprintf("https://%s/%s/%s@%s/IncomingWebhook/%s/%s",
+		host, path, fields[1], fields[0], fields[2], fields[3])
+	if len(fields) == 5 {
+		webhookURL += "/" + fields[4]
+	}
+
+	return webhookURL, nil
+}
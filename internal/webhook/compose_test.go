@@ -0,0 +1,61 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package webhook
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCompose(t *testing.T) {
+	const (
+		tenant    = "9e7b80c7-d1eb-4b52-8582-76f921e416d9"
+		hook      = "a1269812-6d10-44b1-abc5-b84f93580ba0"
+		connector = "3fdd6767bae44ac58e5995547d66a4e4"
+		group     = "f332c8d9-3397-4ac5-957b-b8e3fc465a8c"
+		parts     = tenant + "," + hook + "," + connector + "," + group
+		path      = hook + "@" + tenant + "/IncomingWebhook/" + connector + "/" + group
+	)
+
+	tests := []struct {
+		name  string
+		host  string
+		parts string
+		want  string
+		err   bool
+	}{
+		{name: "default host", parts: parts, want: "https://outlook.office.com/webhook/" + path},
+		{name: "office365", host: "outlook.office365.com", parts: parts, want: "https://outlook.office365.com/webhook/" + path},
+		{name: "new format", host: "Example.webhook.office.com", parts: parts + ", V2abc_1", want: "https://example.webhook.office.com/webhookb2/" + path + "/V2abc_1"},
+		{name: "spaces", parts: " " + tenant + " , " + hook + "," + connector + "," + group, want: "https://outlook.office.com/webhook/" + path},
+		{name: "too few", parts: tenant + "," + hook, err: true},
+		{name: "bad guid", parts: "x," + hook + "," + connector + "," + group, err: true},
+		{name: "bad connector", parts: tenant + "," + hook + "," + group + "," + group, err: true},
+		{name: "scheme in host", host: "https://outlook.office.com", parts: parts, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Compose(tt.host, tt.parts)
+			switch {
+			case tt.err:
+				if !errors.Is(err, ErrInvalidParts) {
+					t.Fatalf("Compose() error = %v, want ErrInvalidParts", err)
+				}
+			case err != nil:
+				t.Fatalf("Compose() error = %v", err)
+			case got != tt.want:
+				t.Errorf("Compose() = %q, want %q", got, tt.want)
+			}
+
+			if !tt.err && !Explain(tt.name, got).Valid() {
+				t.Errorf("composed URL %q fails validation", got)
+			}
+		})
+	}
+}
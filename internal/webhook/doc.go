@@ -0,0 +1,18 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package webhook provides a detailed, stage-by-stage explanation of webhook URL
+validation so that users can see exactly which aspect of a URL (e.g.,
+surrounding whitespace from a copy and paste, a truncated path) causes it to
+be rejected, along with hints for correcting it.
+
+Classify identifies the kind of a webhook URL (an Office 365 connector or
+a Power Automate workflow), which is used along with RetirementTimeline to
+explain how the retirement of Office 365 connectors affects a URL.
+*/
+package webhook
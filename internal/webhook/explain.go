@@ -0,0 +1,213 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	goteamsnotify "github.com/atc0005/go-teams-notify/v2"
+)
+
+// Check result values.
+const (
+	StatusPass string = "PASS"
+	StatusFail string = "FAIL"
+	StatusWarn string = "WARN"
+	StatusSkip string = "SKIP"
+)
+
+// minLength is the length of the shortest complete webhook URL:
+// https://outlook.office.com/webhook/GUID@GUID/IncomingWebhook/HEX32/GUID
+const minLength int = len("https://outlook.office.com/webhook/") + 36 + 1 + 36 +
+	len("/IncomingWebhook/") + 32 + 1 + 36
+
+// pathPattern matches the path of a webhook URL, including the additional
+// trailing segment used by the newer *.webhook.office.com format.
+var pathPattern = regexp.MustCompile(
+	`^/webhook(?:b2)?/[-a-zA-Z0-9]{36}@[-a-zA-Z0-9]{36}/IncomingWebhook/[-a-zA-Z0-9]{32}/[-a-zA-Z0-9]{36}(?:/[-_a-zA-Z0-9]+)?$`,
+)
+
+// Check is the result of a single validation stage.
+type Check struct {
+
+	// Name is the short name of the validation stage.
+	Name string
+
+	// Status is the result of the stage.
+	Status string
+
+	// Detail describes what was checked or found.
+	Detail string
+
+	// Hint is the (optional) remediation advice for a failed stage.
+	Hint string
+}
+
+// Report is the collection of validation stage results for a webhook URL.
+type Report struct {
+
+	// Label identifies the webhook URL (e.g., a target name).
+	Label string
+
+	// Checks is the collection of stage results, in the order evaluated.
+	Checks []Check
+}
+
+// Valid indicates whether the webhook URL passed validation (warnings are
+// permitted).
+func (r Report) Valid() bool {
+	for _, check := range r.Checks {
+		if check.Status == StatusFail || check.Status == StatusSkip {
+			return false
+		}
+	}
+
+	return len(r.Checks) > 0
+}
+
+// Explain runs each webhook URL validation stage against the given URL and
+// returns the result of every stage instead of stopping at the first
+// failure. The final stage applies the same validation as is applied before
+// submitting messages. Stages which depend on an earlier failed stage are
+// skipped.
+func Explain(label string, rawURL string) Report {
+	r := Report{Label: label}
+	add := func(name, status, detail, hint string) bool {
+		r.Checks = append(r.Checks, Check{Name: name, Status: status, Detail: detail, Hint: hint})
+		return status != StatusFail
+	}
+	skip := func(names ...string) {
+		for _, name := range names {
+			add(name, StatusSkip, "not evaluated due to an earlier failure", "")
+		}
+	}
+
+	presentDetail := "webhook URL specified"
+	if rawURL == "" {
+		presentDetail = "no webhook URL specified"
+	}
+	if !add("present", statusIf(rawURL != ""), presentDetail,
+		"specify the url flag, the url key in a configuration file or a webhook URL for each target") {
+		skip("whitespace", "parse", "scheme", "host", "pattern", "path", "length")
+		return r
+	}
+
+	// Later stages evaluate the URL without surrounding quotes or whitespace
+	// so that any other problems are also reported.
+	trimmed := strings.Trim(rawURL, " \t\r\n\"'")
+	whitespaceDetail := "no surrounding quotes or whitespace"
+	if trimmed != rawURL || strings.ContainsAny(rawURL, " \t\r\n") {
+		whitespaceDetail = "contains quotes or whitespace"
+	}
+	add("whitespace", statusIf(trimmed == rawURL && !strings.ContainsAny(rawURL, " \t\r\n")),
+		whitespaceDetail,
+		"remove quotes and whitespace (often introduced by copy and paste or configuration templating)")
+
+	u, err := url.Parse(trimmed)
+	if !add("parse", statusIf(err == nil && u.IsAbs()), parseDetail(err),
+		"provide the complete URL as shown when the webhook was created, starting with https://") {
+		skip("scheme", "host", "pattern", "path", "length")
+		return r
+	}
+
+	add("scheme", statusIf(u.Scheme == "https"), fmt.Sprintf("scheme is %q", u.Scheme),
+		"webhook URLs use https; http is not accepted")
+
+	host := strings.ToLower(u.Hostname())
+	knownHost := host == "outlook.office.com" || host == "outlook.office365.com" ||
+		strings.HasSuffix(host, ".webhook.office.com")
+	hostHint := "expected outlook.office.com, outlook.office365.com or <tenant>.webhook.office.com"
+	if strings.HasSuffix(host, ".logic.azure.com") || strings.Contains(host, "powerplatform.com") {
+		hostHint = "Power Automate workflow URLs are not matched by the default validation; specify the disable-url-validation flag"
+	}
+	add("host", statusIf(knownHost), fmt.Sprintf("host is %q", host), hostHint)
+
+	patternMatched := regexp.MustCompile(goteamsnotify.DefaultWebhookURLValidationPattern).MatchString(rawURL)
+	patternDetail := "matches " + goteamsnotify.DefaultWebhookURLValidationPattern
+	if !patternMatched {
+		patternDetail = "does not match " + goteamsnotify.DefaultWebhookURLValidationPattern
+	}
+	add("pattern", statusIf(patternMatched), patternDetail,
+		"the URL must begin with a known Microsoft Teams webhook prefix")
+
+	segments := strings.Count(strings.Trim(u.Path, "/"), "/") + 1
+	pathStatus := StatusPass
+	if !pathPattern.MatchString(u.Path) {
+		pathStatus = StatusWarn
+	}
+	add("path", pathStatus, fmt.Sprintf("path has %d segment(s)", segments),
+		"expected /webhook(b2)/<GUID>@<GUID>/IncomingWebhook/<ID>/<GUID>; the URL may be truncated or have been edited")
+
+	lengthStatus := StatusPass
+	if len(rawURL) < minLength {
+		lengthStatus = StatusWarn
+	}
+	add("length", lengthStatus, fmt.Sprintf("%d characters (complete URLs have at least %d)", len(rawURL), minLength),
+		"the URL may have been truncated (e.g., by line wrapping when copied)")
+
+	return r
+}
+
+// statusIf returns StatusPass if the given condition holds, otherwise
+// StatusFail.
+func statusIf(ok bool) string {
+	if ok {
+		return StatusPass
+	}
+
+	return StatusFail
+}
+
+// parseDetail describes the result of parsing the URL. The URL itself is
+// omitted since it is a credential.
+func parseDetail(err error) string {
+	var urlErr *url.Error
+	switch {
+	case errors.As(err, &urlErr):
+		return "failed to parse: " + urlErr.Err.Error()
+	case err != nil:
+		return "failed to parse: " + err.Error()
+	default:
+		return "parsed as an absolute URL"
+	}
+}
+
+// Write emits the report as a table to the given writer. Hints are shown
+// for failed stages and warnings.
+func (r Report) Write(w io.Writer) error {
+	result := "valid"
+	if !r.Valid() {
+		result = "invalid"
+	}
+
+	if _, err := fmt.Fprintf(w, "Webhook URL validation (%s): %s\n\n", r.Label, result); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "CHECK\tRESULT\tDETAIL")
+	for _, check := range r.Checks {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n", check.Name, check.Status, check.Detail)
+		if check.Hint != "" && (check.Status == StatusFail || check.Status == StatusWarn) {
+			_, _ = fmt.Fprintf(tw, "\t\tHINT: %s\n", check.Hint)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintln(w)
+
+	return err
+}
@@ -0,0 +1,81 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package webhook
+
+import (
+	"strings"
+	"testing"
+)
+
+const validURL = "https://example.webhook.office.com/webhookb2/" +
+	"aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa@bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb" +
+	"/IncomingWebhook/0123456789abcdef0123456789abcdef/cccccccc-cccc-cccc-cccc-cccccccccccc"
+
+func TestExplain(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		valid  bool
+		status map[string]string
+	}{
+		{
+			name:   "valid",
+			url:    validURL,
+			valid:  true,
+			status: map[string]string{"pattern": StatusPass, "path": StatusPass, "length": StatusPass},
+		},
+		{
+			name:   "empty",
+			url:    "",
+			status: map[string]string{"present": StatusFail, "pattern": StatusSkip},
+		},
+		{
+			name:   "quoted",
+			url:    `"` + validURL + `"`,
+			status: map[string]string{"whitespace": StatusFail, "host": StatusPass, "pattern": StatusFail},
+		},
+		{
+			name:   "truncated",
+			url:    validURL[:120],
+			valid:  true,
+			status: map[string]string{"pattern": StatusPass, "path": StatusWarn, "length": StatusWarn},
+		},
+		{
+			name:   "workflow",
+			url:    "https://prod-00.westus.logic.azure.com/workflows/abc",
+			status: map[string]string{"scheme": StatusPass, "host": StatusFail, "pattern": StatusFail},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Explain(tt.name, tt.url)
+
+			if got := report.Valid(); got != tt.valid {
+				t.Errorf("Valid() = %t, want %t", got, tt.valid)
+			}
+
+			for _, check := range report.Checks {
+				if want, ok := tt.status[check.Name]; ok && check.Status != want {
+					t.Errorf("check %s status = %s, want %s", check.Name, check.Status, want)
+				}
+			}
+		})
+	}
+}
+
+func TestReportWriteOmitsURL(t *testing.T) {
+	var b strings.Builder
+	if err := Explain("url", "https://outlook.office.com/webhook/%zz-secret").Write(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(b.String(), "secret") {
+		t.Errorf("report contains webhook URL:\n%s", b.String())
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package webhook
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Kinds of webhook URL, as reported by Classify.
+const (
+
+	// KindConnector is an Office 365 connector (incoming webhook) URL using
+	// the current *.webhook.office.com format.
+	KindConnector string = "connector"
+
+	// KindLegacyConnector is an Office 365 connector URL using the original
+	// outlook.office.com (or outlook.office365.com) format.
+	KindLegacyConnector string = "legacy-connector"
+
+	// KindWorkflow is a Power Automate or Logic Apps workflow URL.
+	KindWorkflow string = "workflow"
+
+	// KindUnknown is a URL which is not recognized.
+	KindUnknown string = "unknown"
+)
+
+// RetirementAnnouncementURL is the Microsoft announcement describing the
+// retirement of Office 365 connectors within Microsoft Teams. The timeline
+// has been revised since it was first published; the announcement has the
+// current dates.
+const RetirementAnnouncementURL string = "https://devblogs.microsoft.com/microsoft365dev/retirement-of-office-365-connectors-within-microsoft-teams/"
+
+// Milestone is a step in the retirement of Office 365 connectors.
+type Milestone struct {
+
+	// Date is when the step takes effect, as announced by Microsoft.
+	Date string
+
+	// Description describes the effect on connector URLs.
+	Description string
+
+	// Kinds are the kinds of webhook URL affected by the step.
+	Kinds []string
+}
+
+// RetirementTimeline is the announced timeline for the retirement of Office
+// 365 connectors within Microsoft Teams.
+var RetirementTimeline = []Milestone{
+	{
+		Date:        "2024-08-15",
+		Description: "new connectors can no longer be created; workflows (Power Automate) replace them",
+		Kinds:       []string{KindConnector, KindLegacyConnector},
+	},
+	{
+		Date:        "2025-01-31",
+		Description: "connector URLs not updated to the *.webhook.office.com format stop accepting messages",
+		Kinds:       []string{KindLegacyConnector},
+	},
+	{
+		Date:        "announced separately",
+		Description: "remaining connectors are retired; messages must be sent to a workflow URL instead",
+		Kinds:       []string{KindConnector, KindLegacyConnector},
+	},
+}
+
+// Affects indicates whether the Milestone affects webhook URLs of the given
+// kind.
+func (m Milestone) Affects(kind string) bool {
+	for _, k := range m.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Classify returns the kind of the given webhook URL based on its host and
+// path.
+func Classify(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || !u.IsAbs() {
+		return KindUnknown
+	}
+
+	host := strings.ToLower(u.Hostname())
+	path := strings.ToLower(u.Path)
+
+	switch {
+	case host == "outlook.office.com" || host == "outlook.office365.com":
+		return KindLegacyConnector
+
+	case strings.HasSuffix(host, ".webhook.office.com") && strings.HasPrefix(path, "/webhookb2/"):
+		return KindConnector
+
+	case strings.HasSuffix(host, ".logic.azure.com"),
+		strings.Contains(host, "powerplatform.com"),
+		strings.Contains(host, "powerautomate.com"):
+		return KindWorkflow
+
+	default:
+		return KindUnknown
+	}
+}
+
+// IsConnector indicates whether the given kind of webhook URL is an Office
+// 365 connector affected by the retirement.
+func IsConnector(kind string) bool {
+	return kind == KindConnector || kind == KindLegacyConnector
+}
@@ -0,0 +1,27 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package webhook
+
+import "testing"
+
+func TestClassify(t *testing.T) {
+	tests := map[string]string{
+		validURL: KindConnector,
+		"https://outlook.office.com/webhook/aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa@bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb/IncomingWebhook/x/y": KindLegacyConnector,
+		"https://prod-01.westus.logic.azure.com:443/workflows/abc/triggers/manual/paths/invoke?api-version=2016-06-01":                     KindWorkflow,
+		"https://default1234.56.environment.api.powerplatform.com/powerautomate/automations/direct/workflows/abc/triggers/manual":          KindWorkflow,
+		"https://example.com/hook": KindUnknown,
+		"not a url":                KindUnknown,
+	}
+
+	for rawURL, want := range tests {
+		if got := Classify(rawURL); got != want {
+			t.Errorf("Classify(%q) = %q; want %q", rawURL, got, want)
+		}
+	}
+}
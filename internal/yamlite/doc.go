@@ -0,0 +1,18 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package yamlite provides a small YAML parser used to read card definitions
+written by hand.
+
+Only block style documents are supported: mappings, sequences (including
+sequences of mappings written as "- key: value"), plain, single-quoted and
+double-quoted scalars, literal (|) and folded (>) block scalars and comments.
+Flow collections ([a, b] or {a: b}), anchors, aliases, tags and multiple
+documents are not supported. All scalars are returned as strings.
+*/
+package yamlite
@@ -0,0 +1,708 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package yamlite
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrSyntax indicates that a document is not valid YAML or uses YAML
+// features which are not supported.
+var ErrSyntax = errors.New("invalid YAML")
+
+// Kind is the kind of a Node.
+type Kind int
+
+// Kinds of nodes.
+const (
+	KindNull Kind = iota
+	KindScalar
+	KindMapping
+	KindSequence
+)
+
+// String returns the name of the kind of node for use in messages.
+func (k Kind) String() string {
+	switch k {
+	case KindScalar:
+		return "scalar"
+	case KindMapping:
+		return "mapping"
+	case KindSequence:
+		return "sequence"
+	default:
+		return "null"
+	}
+}
+
+// Node is a value within a YAML document.
+type Node struct {
+
+	// Kind is the kind of the value.
+	Kind Kind
+
+	// Line is the (1-based) line on which the value starts.
+	Line int
+
+	// Value is the value of a scalar.
+	Value string
+
+	// Pairs are the entries of a mapping, in document order.
+	Pairs []Pair
+
+	// Items are the entries of a sequence.
+	Items []*Node
+}
+
+// Pair is an entry of a mapping.
+type Pair struct {
+
+	// Key is the key of the entry.
+	Key string
+
+	// Line is the (1-based) line on which the key is specified.
+	Line int
+
+	// Value is the value of the entry.
+	Value *Node
+}
+
+// line is a line of a document.
+type line struct {
+	number int
+	indent int
+
+	// raw is the complete line without the line ending.
+	raw string
+
+	// text is the content of the line following the indentation, without
+	// trailing whitespace.
+	text string
+}
+
+// parser parses the lines of a document.
+type parser struct {
+	lines []line
+	pos   int
+}
+
+// Parse parses the given YAML document. An empty document is returned as a
+// null node. An error wrapping ErrSyntax is returned if the document is not
+// valid YAML or uses YAML features which are not supported.
+func Parse(data []byte) (*Node, error) {
+	p, err := newParser(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipEmpty()
+	if p.pos >= len(p.lines) {
+		return &Node{Kind: KindNull, Line: 1}, nil
+	}
+
+	node, err := p.parseBlock(p.lines[p.pos].indent)
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipEmpty()
+	if p.pos < len(p.lines) {
+		return nil, syntaxError(p.lines[p.pos].number, "unexpected content (check the indentation)")
+	}
+
+	return node, nil
+}
+
+// newParser splits the given document into lines, dropping any document
+// start and end markers.
+func newParser(doc string) (*parser, error) {
+	doc = strings.TrimPrefix(doc, "\uFEFF")
+
+	var p parser
+	started := false
+	for i, raw := range strings.Split(strings.TrimSuffix(doc, "\n"), "\n") {
+		number := i + 1
+		raw = strings.TrimSuffix(raw, "\r")
+		trimmed := strings.TrimRight(raw, " \t")
+
+		switch {
+		case trimmed == "---" || strings.HasPrefix(trimmed, "--- "):
+			if started {
+				return nil, syntaxError(number, "multiple documents are not supported")
+			}
+			started = true
+			if rest := strings.TrimSpace(trimmed[3:]); rest != "" && !strings.HasPrefix(rest, "#") {
+				return nil, syntaxError(number, "content on the document start line is not supported")
+			}
+			raw, trimmed = "", ""
+
+		case trimmed == "...":
+			return &p, nil
+
+		case strings.HasPrefix(trimmed, "%"):
+			return nil, syntaxError(number, "directives are not supported")
+		}
+
+		if strings.TrimSpace(trimmed) != "" && !strings.HasPrefix(trimmed, "#") {
+			started = true
+		}
+
+		indent := len(trimmed) - len(strings.TrimLeft(trimmed, " "))
+		p.lines = append(p.lines, line{
+			number: number,
+			indent: indent,
+			raw:    raw,
+			text:   trimmed[indent:],
+		})
+	}
+
+	return &p, nil
+}
+
+// syntaxError returns an error wrapping ErrSyntax for the given line.
+func syntaxError(number int, format string, a ...interface{}) error {
+	return fmt.Errorf("line %d: %w: %s", number, ErrSyntax, fmt.Sprintf(format, a...))
+}
+
+// isEmpty indicates whether the given line contains no content other than a
+// comment.
+func isEmpty(l line) bool {
+	return l.text == "" || strings.HasPrefix(l.text, "#")
+}
+
+// skipEmpty advances past any lines without content.
+func (p *parser) skipEmpty() {
+	for p.pos < len(p.lines) && isEmpty(p.lines[p.pos]) {
+		p.pos++
+	}
+}
+
+// next returns the next line with content without advancing past it.
+func (p *parser) next() (line, bool) {
+	p.skipEmpty()
+	if p.pos >= len(p.lines) {
+		return line{}, false
+	}
+
+	return p.lines[p.pos], true
+}
+
+// isSequenceItem indicates whether the given text starts a sequence item.
+func isSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ") || strings.HasPrefix(text, "-\t")
+}
+
+// parseBlock parses the mapping, sequence or scalar starting on the current
+// line, which is indented by the given number of spaces.
+func (p *parser) parseBlock(indent int) (*Node, error) {
+	l := p.lines[p.pos]
+
+	if strings.HasPrefix(l.text, "\t") {
+		return nil, syntaxError(l.number, "tabs may not be used for indentation")
+	}
+
+	if isSequenceItem(l.text) {
+		return p.parseSequence(indent)
+	}
+
+	_, _, isMapping, err := splitMappingEntry(l.text, l.number)
+	switch {
+	case err != nil:
+		return nil, err
+	case isMapping:
+		return p.parseMapping(indent)
+	}
+
+	p.pos++
+
+	return p.parseScalar(l.text, l.number, indent-1)
+}
+
+// parseSequence parses the sequence whose items start at the given
+// indentation.
+func (p *parser) parseSequence(indent int) (*Node, error) {
+	node := &Node{Kind: KindSequence, Line: p.lines[p.pos].number}
+
+	for {
+		l, ok := p.next()
+		if !ok || l.indent < indent {
+			break
+		}
+
+		if l.indent > indent {
+			return nil, syntaxError(l.number, "unexpected indentation")
+		}
+
+		if strings.HasPrefix(l.text, "\t") {
+			return nil, syntaxError(l.number, "tabs may not be used for indentation")
+		}
+
+		if !isSequenceItem(l.text) {
+			break
+		}
+
+		rest := strings.TrimLeft(l.text[1:], " \t")
+		offset := len(l.text) - len(rest)
+
+		var item *Node
+		var err error
+
+		switch {
+		case rest == "" || strings.HasPrefix(rest, "#"):
+			p.pos++
+			item, err = p.parseNested(indent, l.number)
+
+		default:
+			// The item starts on the same line as the indicator; parse the
+			// remainder of the line as though it started a line at the same
+			// column.
+			current := &p.lines[p.pos]
+			current.indent += offset
+			current.text = rest
+			item, err = p.parseBlock(current.indent)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		node.Items = append(node.Items, item)
+	}
+
+	return node, nil
+}
+
+// parseNested parses the value on the following lines indented by more than
+// the given number of spaces, returning a null node for the given line if
+// there is none.
+func (p *parser) parseNested(indent int, number int) (*Node, error) {
+	l, ok := p.next()
+	if !ok || l.indent <= indent {
+		return &Node{Kind: KindNull, Line: number}, nil
+	}
+
+	return p.parseBlock(l.indent)
+}
+
+// parseMapping parses the mapping whose keys start at the given
+// indentation.
+func (p *parser) parseMapping(indent int) (*Node, error) {
+	node := &Node{Kind: KindMapping, Line: p.lines[p.pos].number}
+	seen := make(map[string]struct{})
+
+	for {
+		l, ok := p.next()
+		if !ok || l.indent < indent {
+			break
+		}
+
+		if l.indent > indent {
+			return nil, syntaxError(l.number, "unexpected indentation")
+		}
+
+		if strings.HasPrefix(l.text, "\t") {
+			return nil, syntaxError(l.number, "tabs may not be used for indentation")
+		}
+
+		if isSequenceItem(l.text) {
+			return nil, syntaxError(l.number, "unexpected sequence item (check the indentation)")
+		}
+
+		key, rest, ok, err := splitMappingEntry(l.text, l.number)
+		switch {
+		case err != nil:
+			return nil, err
+		case !ok:
+			return nil, syntaxError(l.number, "expected a mapping entry (key: value)")
+		}
+
+		if _, dup := seen[key]; dup {
+			return nil, syntaxError(l.number, "duplicate key %q", key)
+		}
+		seen[key] = struct{}{}
+
+		p.pos++
+
+		var value *Node
+		switch {
+		case rest == "" || strings.HasPrefix(rest, "#"):
+			next, ok := p.next()
+			switch {
+			case ok && next.indent > indent:
+				value, err = p.parseBlock(next.indent)
+
+			// Sequences are commonly written at the same indentation as
+			// their key.
+			case ok && next.indent == indent && isSequenceItem(next.text):
+				value, err = p.parseSequence(indent)
+
+			default:
+				value = &Node{Kind: KindNull, Line: l.number}
+			}
+
+		default:
+			value, err = p.parseScalar(rest, l.number, indent)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		node.Pairs = append(node.Pairs, Pair{Key: key, Line: l.number, Value: value})
+	}
+
+	return node, nil
+}
+
+// splitMappingEntry splits the given text into the key and (unparsed) value
+// of a mapping entry. The value is empty if it is specified on the following
+// lines. Whether the text is a mapping entry is indicated.
+func splitMappingEntry(text string, number int) (string, string, bool, error) {
+	if text == "" {
+		return "", "", false, nil
+	}
+
+	switch text[0] {
+	case '"', '\'':
+		key, end, err := parseQuoted(text, number)
+		if err != nil {
+			return "", "", false, err
+		}
+
+		rest := strings.TrimLeft(text[end:], " \t")
+		if !strings.HasPrefix(rest, ":") || (len(rest) > 1 && rest[1] != ' ' && rest[1] != '\t') {
+			return "", "", false, nil
+		}
+
+		return key, strings.TrimLeft(rest[1:], " \t"), true, nil
+
+	case '[', '{':
+		return "", "", false, syntaxError(number, "flow collections are not supported")
+
+	case '?':
+		if text == "?" || strings.HasPrefix(text, "? ") {
+			return "", "", false, syntaxError(number, "complex mapping keys are not supported")
+		}
+	}
+
+	for i := 0; i < len(text); i++ {
+		switch {
+		case text[i] == '#' && i > 0 && (text[i-1] == ' ' || text[i-1] == '\t'):
+			// The remainder of the line is a comment.
+			return "", "", false, nil
+
+		case text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ' || text[i+1] == '\t'):
+			key := strings.TrimRight(text[:i], " \t")
+			if key == "" {
+				return "", "", false, syntaxError(number, "empty mapping key")
+			}
+
+			return key, strings.TrimLeft(text[i+1:], " \t"), true, nil
+		}
+	}
+
+	return "", "", false, nil
+}
+
+// parseScalar parses the scalar value starting with the given text, found
+// on the given line within a block indented by the given number of spaces.
+// Block scalars and plain scalars continued on following lines consume
+// those lines.
+func (p *parser) parseScalar(text string, number int, indent int) (*Node, error) {
+	switch text[0] {
+	case '|', '>':
+		value, err := p.parseBlockScalar(text, number, indent)
+		if err != nil {
+			return nil, err
+		}
+		return &Node{Kind: KindScalar, Line: number, Value: value}, nil
+
+	case '"', '\'':
+		value, end, err := parseQuoted(text, number)
+		if err != nil {
+			return nil, err
+		}
+
+		if rest := strings.TrimLeft(text[end:], " \t"); rest != "" && !strings.HasPrefix(rest, "#") {
+			return nil, syntaxError(number, "unexpected content after quoted scalar")
+		}
+		return &Node{Kind: KindScalar, Line: number, Value: value}, nil
+
+	case '[', '{':
+		return nil, syntaxError(number, "flow collections are not supported")
+
+	case '&', '*', '!':
+		return nil, syntaxError(number, "anchors, aliases and tags are not supported")
+
+	case '@', '`':
+		return nil, syntaxError(number, "plain scalars may not start with %q", text[0])
+	}
+
+	value := stripComment(text)
+	if strings.Contains(value, ": ") {
+		return nil, syntaxError(number, "mapping values are not allowed here (quote the value if it contains \": \")")
+	}
+
+	// Plain scalars may be continued on more indented lines, which are
+	// folded into a single line.
+	breaks := 0
+	for p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		if l.text == "" {
+			breaks++
+			p.pos++
+			continue
+		}
+		if l.indent <= indent || strings.HasPrefix(l.text, "#") {
+			break
+		}
+
+		if _, _, isMapping, _ := splitMappingEntry(l.text, l.number); isMapping {
+			return nil, syntaxError(l.number, "unexpected indentation")
+		}
+
+		switch {
+		case breaks == 0:
+			value += " "
+		default:
+			value += strings.Repeat("\n", breaks)
+		}
+		value += stripComment(l.text)
+		breaks = 0
+		p.pos++
+	}
+
+	// Trailing blank lines are not part of the value; leave them to be
+	// skipped by the caller.
+	if breaks > 0 {
+		p.pos -= breaks
+	}
+
+	switch value {
+	case "~", "null", "Null", "NULL":
+		return &Node{Kind: KindNull, Line: number}, nil
+	}
+
+	return &Node{Kind: KindScalar, Line: number, Value: value}, nil
+}
+
+// stripComment returns the given plain scalar text without any trailing
+// comment or whitespace.
+func stripComment(text string) string {
+	for i := 1; i < len(text); i++ {
+		if text[i] == '#' && (text[i-1] == ' ' || text[i-1] == '\t') {
+			text = text[:i]
+			break
+		}
+	}
+
+	return strings.TrimRight(text, " \t")
+}
+
+// parseQuoted parses the single or double quoted scalar at the start of the
+// given text, returning the value and the offset following the closing quote.
+// Quoted scalars must end on the line on which they start.
+func parseQuoted(text string, number int) (string, int, error) {
+	quote := text[0]
+
+	var b strings.Builder
+	for i := 1; i < len(text); i++ {
+		c := text[i]
+
+		switch {
+		case c == quote && quote == '\'' && i+1 < len(text) && text[i+1] == '\'':
+			b.WriteByte('\'')
+			i++
+
+		case c == quote:
+			return b.String(), i + 1, nil
+
+		case c == '\\' && quote == '"':
+			if i+1 >= len(text) {
+				return "", 0, syntaxError(number, "quoted scalars must end on the line on which they start")
+			}
+
+			r, size, err := parseEscape(text[i+1:])
+			if err != nil {
+				return "", 0, syntaxError(number, "%v", err)
+			}
+			b.WriteString(r)
+			i += size
+
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return "", 0, syntaxError(number, "quoted scalars must end on the line on which they start")
+}
+
+// doubleQuotedEscapes are the single character escape sequences supported
+// within double quoted scalars.
+var doubleQuotedEscapes = map[byte]string{
+	'0':  "\x00",
+	'a':  "\a",
+	'b':  "\b",
+	't':  "\t",
+	'\t': "\t",
+	'n':  "\n",
+	'v':  "\v",
+	'f':  "\f",
+	'r':  "\r",
+	'e':  "\x1b",
+	' ':  " ",
+	'"':  "\"",
+	'/':  "/",
+	'\\': "\\",
+	'N':  "\u0085",
+	'_':  "\u00A0",
+	'L':  "\u2028",
+	'P':  "\u2029",
+}
+
+// parseEscape parses the escape sequence at the start of the given text
+// (following the backslash), returning the escaped value and the length of
+// the sequence.
+func parseEscape(text string) (string, int, error) {
+	if value, ok := doubleQuotedEscapes[text[0]]; ok {
+		return value, 1, nil
+	}
+
+	digits := map[byte]int{'x': 2, 'u': 4, 'U': 8}[text[0]]
+	if digits == 0 {
+		return "", 0, fmt.Errorf("unsupported escape sequence \\%c", text[0])
+	}
+
+	if len(text) < 1+digits {
+		return "", 0, fmt.Errorf("incomplete escape sequence \\%s", text)
+	}
+
+	code, err := strconv.ParseUint(text[1:1+digits], 16, 32)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid escape sequence \\%s", text[:1+digits])
+	}
+
+	return string(rune(code)), 1 + digits, nil
+}
+
+// parseBlockScalar parses the literal (|) or folded (>) block scalar with the
+// given header, found on the given line within a block indented by the
+// given number of spaces.
+func (p *parser) parseBlockScalar(header string, number int, indent int) (string, error) {
+	folded := header[0] == '>'
+	chomp := byte(0)
+	contentIndent := 0
+
+	for _, c := range []byte(stripComment(header)[1:]) {
+		switch {
+		case (c == '-' || c == '+') && chomp == 0:
+			chomp = c
+		case c >= '1' && c <= '9' && contentIndent == 0:
+			contentIndent = int(c - '0')
+			if indent > 0 {
+				contentIndent += indent
+			}
+		default:
+			return "", syntaxError(number, "invalid block scalar header %q", header)
+		}
+	}
+
+	var lines []string
+	for p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		blank := strings.TrimSpace(l.raw) == ""
+
+		if !blank && l.indent <= indent {
+			break
+		}
+
+		if !blank && strings.HasPrefix(l.raw[l.indent:], "\t") && contentIndent == 0 {
+			return "", syntaxError(l.number, "tabs may not be used for indentation")
+		}
+
+		if contentIndent == 0 && !blank {
+			contentIndent = l.indent
+		}
+
+		switch {
+		case blank:
+			lines = append(lines, "")
+		case l.indent < contentIndent:
+			return "", syntaxError(l.number, "block scalar line is less indented than the first line")
+		default:
+			lines = append(lines, l.raw[contentIndent:])
+		}
+
+		p.pos++
+	}
+
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+
+	var value string
+	switch {
+	case folded:
+		value = foldLines(lines)
+	default:
+		value = strings.Join(lines, "\n")
+	}
+
+	switch {
+	case chomp == '-' || value == "" && chomp != '+':
+	case chomp == '+':
+		value += "\n" + strings.Repeat("\n", trailing)
+	default:
+		value += "\n"
+	}
+
+	return value, nil
+}
+
+// foldLines joins the given lines of a folded block scalar: line breaks
+// between lines are replaced with spaces, while blank lines and lines which
+// are more indented than the block are preserved.
+func foldLines(lines []string) string {
+	var b strings.Builder
+
+	breaks := 0
+	prevMore := false
+	for i, l := range lines {
+		if l == "" {
+			breaks++
+			continue
+		}
+
+		more := l[0] == ' ' || l[0] == '\t'
+		if i > breaks {
+			switch {
+			case breaks == 0 && !more && !prevMore:
+				b.WriteByte(' ')
+			case breaks == 0:
+				b.WriteByte('\n')
+			case more || prevMore:
+				b.WriteString(strings.Repeat("\n", breaks+1))
+			default:
+				b.WriteString(strings.Repeat("\n", breaks))
+			}
+		} else {
+			b.WriteString(strings.Repeat("\n", breaks))
+		}
+
+		b.WriteString(l)
+		breaks = 0
+		prevMore = more
+	}
+
+	return b.String()
+}
@@ -0,0 +1,135 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package yamlite
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// dump returns a compact description of the given node for comparison.
+func dump(n *Node) string {
+	switch n.Kind {
+	case KindScalar:
+		return fmt.Sprintf("%q", n.Value)
+	case KindMapping:
+		parts := make([]string, 0, len(n.Pairs))
+		for _, pair := range n.Pairs {
+			parts = append(parts, fmt.Sprintf("%s@%d=%s", pair.Key, pair.Line, dump(pair.Value)))
+		}
+		return "{" + strings.Join(parts, " ") + "}"
+	case KindSequence:
+		parts := make([]string, 0, len(n.Items))
+		for _, item := range n.Items {
+			parts = append(parts, dump(item))
+		}
+		return "[" + strings.Join(parts, " ") + "]"
+	default:
+		return "null"
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{
+			name: "mapping",
+			doc:  "---\n# comment\ntitle: Disk usage high  # trailing comment\ncolor: 'attention'\nempty:\n",
+			want: `{title@3="Disk usage high" color@4="attention" empty@5=null}`,
+		},
+		{
+			name: "nested",
+			doc:  "card:\n  title: A\n  facts:\n  - title: Host\n    value: web01\n  -   title: Mount\n      value: \"/var\"\n",
+			want: `{card@1={title@2="A" facts@3=[{title@4="Host" value@5="web01"} {title@6="Mount" value@7="/var"}]}}`,
+		},
+		{
+			name: "sequence of scalars",
+			doc:  "- one\n-\n  two\n- - nested\n  - list\n",
+			want: `["one" "two" ["nested" "list"]]`,
+		},
+		{
+			name: "literal block",
+			doc:  "text: |\n  line one\n\n    indented\n  line two\n\nnext: x\n",
+			want: `{text@1="line one\n\n  indented\nline two\n" next@7="x"}`,
+		},
+		{
+			name: "folded block strip",
+			doc:  "text: >-\n  folded\n  lines\n\n  paragraph\n",
+			want: `{text@1="folded lines\nparagraph"}`,
+		},
+		{
+			name: "keep chomping",
+			doc:  "text: |+\n  kept\n\n",
+			want: `{text@1="kept\n\n"}`,
+		},
+		{
+			name: "plain continuation",
+			doc:  "text: a long\n  value\nurl: https://example.com/a#b\n",
+			want: `{text@1="a long value" url@3="https://example.com/a#b"}`,
+		},
+		{
+			name: "quoted",
+			doc:  "a: \"tab\\tquote\\\" \\u00e9\"\nb: 'it''s # not a comment'\n\"c d\": ~\n",
+			want: `{a@1="tab\tquote\" é" b@2="it's # not a comment" c d@3=null}`,
+		},
+		{
+			name: "empty",
+			doc:  "# nothing\n\n",
+			want: "null",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse([]byte(tt.doc))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := dump(node); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{name: "flow", doc: "facts: [a, b]\n", want: "line 1"},
+		{name: "duplicate", doc: "a: 1\nb: 2\na: 3\n", want: "line 3"},
+		{name: "indentation", doc: "a: 1\n   b: 2\n", want: "line 2"},
+		{name: "tab", doc: "a:\n\tb: 2\n", want: "line 2"},
+		{name: "unterminated", doc: "a: \"open\n", want: "line 1"},
+		{name: "mapping value", doc: "a: b: c\n", want: "line 1"},
+		{name: "anchor", doc: "a: &x 1\n", want: "line 1"},
+		{name: "documents", doc: "a: 1\n---\nb: 2\n", want: "line 2"},
+		{name: "sequence in mapping", doc: "a: 1\n- b\n", want: "line 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.doc))
+			if !errors.Is(err, ErrSyntax) {
+				t.Fatalf("got error %v; want %v", err, ErrSyntax)
+			}
+
+			if !strings.HasPrefix(err.Error(), tt.want+":") {
+				t.Errorf("got error %q; want error for %s", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package sender provides a client for services which embed send2teams message
+generation and delivery.
+
+A Client submits messages synchronously (Send) or asynchronously (SendAsync)
+using an internal pool of workers. The pool size caps the number of
+concurrent submissions so that client code may fire many notifications
+without managing goroutines itself.
+
+	client, err := sender.New(webhookURL, sender.WithWorkers(4))
+	if err != nil {
+		// handle error
+	}
+	defer client.Close()
+
+	result, err := client.Send(ctx, sender.Message{Title: "Backup", Text: "Backup complete"})
+
+	results := client.SendAsync(ctx, sender.Message{Text: "Disk usage high"})
+	// ... later
+	result = <-results
+
+Render returns the exact payload Send would submit, with the options of the
+Client applied, without performing any network I/O. This is useful for
+golden file testing of notification content:
+
+	payload, err := client.Render(msg, sender.FormatAdaptiveCard)
+
+Submission attempts are logged using the logger provided via WithLogger, if
+any. Each Client has its own logger, so services running several clients
+concurrently are able to keep their log streams separate. The logger only
+receives the messages of the Client: the go-teams-notify library used to
+submit messages still logs (including the payload) via its package-level
+logger, which is muted unless enabled via goteamsnotify.EnableLogging.
+
+	client, err := sender.New(webhookURL, sender.WithLogger(log.New(os.Stderr, "[alerts] ", log.LstdFlags)))
+
+Services submitting many messages (e.g., hundreds per hour to several
+channels) should use a long-lived Pool instead of creating a Client for each
+message. A Pool keeps a Client for each webhook URL; the Clients share an
+HTTP client so that connections and TLS sessions are reused. Each Client
+has a circuit breaker (see WithCircuitBreaker) so that messages for a target
+which keeps failing are rejected immediately with ErrCircuitOpen, allowing
+the service to fall back to another channel, until a probe submission
+succeeds. Health reports the state of each target for use as a health
+signal, identifying targets by host and TargetID rather than by the (secret)
+webhook URL:
+
+	pool := sender.NewPool(sender.WithRetries(2, 2))
+	defer pool.Close()
+
+	if _, err := pool.Send(ctx, webhookURL, msg); errors.Is(err, sender.ErrCircuitOpen) {
+		// fall back to another channel
+	}
+
+	for _, target := range pool.Health() {
+		if !target.Healthy() {
+			// report the failing target
+		}
+	}
+*/
+package sender
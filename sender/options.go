@@ -0,0 +1,151 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package sender
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/atc0005/send2teams/internal/breaker"
+)
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithWorkers sets the number of workers used to submit messages. Values
+// less than one are ignored.
+func WithWorkers(workers int) Option {
+	return func(c *Client) {
+		if workers > 0 {
+			c.workers = workers
+		}
+	}
+}
+
+// WithQueueSize sets the number of messages which may be waiting for a
+// worker before SendAsync blocks. Negative values are ignored.
+func WithQueueSize(size int) Option {
+	return func(c *Client) {
+		if size >= 0 {
+			c.queueSize = size
+		}
+	}
+}
+
+// WithRetries sets the number of retry attempts and the number of seconds
+// between attempts used when submitting messages.
+func WithRetries(retries int, retriesDelay int) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.retriesDelay = retriesDelay
+	}
+}
+
+// WithHTTPClient sets a custom http.Client used to submit messages.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.teamsClient.SetHTTPClient(httpClient)
+	}
+}
+
+// WithUserAgent sets a custom user agent used to submit messages.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.teamsClient.SetUserAgent(userAgent)
+	}
+}
+
+// WithWebhookURLValidation controls whether the webhook URL is validated
+// before submitting messages. Validation is enabled by default; disabling it
+// is useful when submitting messages to a non-standard endpoint.
+func WithWebhookURLValidation(enabled bool) Option {
+	return func(c *Client) {
+		c.teamsClient.SkipWebhookURLValidationOnSend(!enabled)
+	}
+}
+
+// WithConvertEOL controls whether Windows, Mac and Linux newlines in message
+// text are converted before submission.
+func WithConvertEOL(enabled bool) Option {
+	return func(c *Client) {
+		c.cardOpts.ConvertEOL = enabled
+	}
+}
+
+// WithConvertEscapedEOL controls whether escaped Windows, Mac and Linux
+// newline sequences (e.g., a literal `\n`) in message text are converted
+// before submission.
+func WithConvertEscapedEOL(enabled bool) Option {
+	return func(c *Client) {
+		c.cardOpts.ConvertEscapedEOL = enabled
+	}
+}
+
+// WithTrailer sets text appended to every message in a dedicated trailer
+// container (e.g., to credit the generating service).
+func WithTrailer(trailer string) Option {
+	return func(c *Client) {
+		c.cardOpts.Trailer = trailer
+	}
+}
+
+// WithBidiIsolate controls whether message text is wrapped in Unicode
+// bidirectional isolation characters so that mixed right-to-left and
+// left-to-right content is displayed in the correct order.
+func WithBidiIsolate(enabled bool) Option {
+	return func(c *Client) {
+		c.cardOpts.BidiIsolate = enabled
+	}
+}
+
+// WithEmojiFallback controls whether emoji in message text are replaced
+// with text tokens (e.g., [WARN]) for channels whose compliance policies
+// strip emoji.
+func WithEmojiFallback(enabled bool) Option {
+	return func(c *Client) {
+		c.cardOpts.EmojiFallback = enabled
+	}
+}
+
+// WithExpandTabs sets the width of the tab stops used to expand tabs in
+// message text. If greater than zero, runs of column-aligned lines (e.g.,
+// the output of df) are also placed in code blocks so that their alignment
+// is preserved. A value of 0 (the default) disables tab expansion.
+func WithExpandTabs(width int) Option {
+	return func(c *Client) {
+		c.cardOpts.ExpandTabs = width
+	}
+}
+
+// WithLogger sets the logger used to record submission attempts (e.g.,
+// failed attempts which are retried) for the Client. Each Client logs only
+// to its own logger, so concurrent users are able to keep their log streams
+// separate without modifying package-level state. A nil logger discards
+// log output, which is the default.
+func WithLogger(logger *log.Logger) Option {
+	return func(c *Client) {
+		if logger == nil {
+			logger = log.New(io.Discard, "", 0)
+		}
+		c.logger = logger
+	}
+}
+
+// WithCircuitBreaker enables a circuit breaker which opens after the given
+// number of consecutive failed submissions. While open, messages are
+// rejected immediately with ErrCircuitOpen instead of being submitted; once
+// the given cooldown period has elapsed a single probe submission is
+// allowed, closing the circuit breaker if it succeeds. A threshold less than
+// one disables the circuit breaker, which is the default for a Client.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(c *Client) {
+		c.breaker = breaker.New(threshold, cooldown)
+	}
+}
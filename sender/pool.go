@@ -0,0 +1,192 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package sender
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Default settings applied to the Clients of a Pool unless overridden by an
+// Option.
+const (
+	defaultPoolIdleConnsPerHost int           = 16
+	defaultPoolIdleConnTimeout  time.Duration = 90 * time.Second
+	defaultBreakerThreshold     int           = 5
+	defaultBreakerCooldown      time.Duration = 30 * time.Second
+)
+
+// targetIDLength is the number of hex encoded characters of the hash of a
+// webhook URL used as its TargetID.
+const targetIDLength int = 12
+
+// TargetHealth is the health of the Client of a Pool for a single webhook
+// URL. The webhook URL itself is not included, as anyone with it is able to
+// submit messages, so that the health may be reported as-is.
+type TargetHealth struct {
+
+	// ID identifies the webhook URL (see TargetID).
+	ID string `json:"id"`
+
+	// Host is the host portion of the webhook URL.
+	Host string `json:"host"`
+
+	Health
+}
+
+// TargetID returns a short hash identifying the given webhook URL within
+// the health reported by a Pool without exposing the URL.
+func TargetID(webhookURL string) string {
+	sum := sha256.Sum256([]byte(webhookURL))
+
+	return hex.EncodeToString(sum[:])[:targetIDLength]
+}
+
+// Pool is a long-lived set of Clients, one for each webhook URL (target)
+// messages are submitted to, for services submitting many messages. The
+// Clients share an HTTP client so that connections (and TLS sessions) are
+// reused across messages and targets, and each Client has its own circuit
+// breaker so that a failing target does not delay messages for the others.
+// A Pool is safe for concurrent use.
+type Pool struct {
+	opts      []Option
+	transport *http.Transport
+
+	mu      sync.Mutex
+	clients map[string]*Client
+	closed  bool
+}
+
+// NewPool creates a Pool whose Clients are created using the given options.
+// Unless overridden by the options, Clients share an HTTP client which keeps
+// idle connections open for reuse and use a circuit breaker which opens
+// after 5 consecutive failures, probing the target again after 30 seconds.
+// Close should be called once the Pool is no longer needed.
+func NewPool(opts ...Option) *Pool {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = defaultPoolIdleConnsPerHost
+	transport.IdleConnTimeout = defaultPoolIdleConnTimeout
+
+	defaults := []Option{
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithCircuitBreaker(defaultBreakerThreshold, defaultBreakerCooldown),
+	}
+
+	return &Pool{
+		opts:      append(defaults, opts...),
+		transport: transport,
+		clients:   make(map[string]*Client),
+	}
+}
+
+// Client returns the Client used to submit messages to the given webhook
+// URL, creating it if needed. The Client is owned by the Pool and is closed
+// along with it.
+func (p *Pool) Client(webhookURL string) (*Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, ErrClientClosed
+	}
+
+	if c, ok := p.clients[webhookURL]; ok {
+		return c, nil
+	}
+
+	c, err := New(webhookURL, p.opts...)
+	if err != nil {
+		return nil, err
+	}
+	p.clients[webhookURL] = c
+
+	return c, nil
+}
+
+// Send submits the given message to the given webhook URL and waits for the
+// result. ErrCircuitOpen is returned without submitting the message if the
+// target keeps failing.
+func (p *Pool) Send(ctx context.Context, webhookURL string, msg Message) (Result, error) {
+	result := <-p.SendAsync(ctx, webhookURL, msg)
+
+	return result, result.Err
+}
+
+// SendAsync queues the given message for submission to the given webhook
+// URL and returns a channel which receives the result once submission
+// completes, as for Client.SendAsync.
+func (p *Pool) SendAsync(ctx context.Context, webhookURL string, msg Message) <-chan Result {
+	c, err := p.Client(webhookURL)
+	if err != nil {
+		results := make(chan Result, 1)
+		results <- Result{Err: err, Started: time.Now()}
+		return results
+	}
+
+	return c.SendAsync(ctx, msg)
+}
+
+// Health returns the health of the Client for each webhook URL messages
+// were submitted to, ordered by host and ID.
+func (p *Pool) Health() []TargetHealth {
+	p.mu.Lock()
+	clients := make(map[string]*Client, len(p.clients))
+	for webhookURL, c := range p.clients {
+		clients[webhookURL] = c
+	}
+	p.mu.Unlock()
+
+	health := make([]TargetHealth, 0, len(clients))
+	for webhookURL, c := range clients {
+		target := TargetHealth{ID: TargetID(webhookURL), Health: c.Health()}
+		if u, err := url.Parse(webhookURL); err == nil {
+			target.Host = u.Host
+		}
+		health = append(health, target)
+	}
+
+	sort.Slice(health, func(i, j int) bool {
+		if health[i].Host != health[j].Host {
+			return health[i].Host < health[j].Host
+		}
+		return health[i].ID < health[j].ID
+	})
+
+	return health
+}
+
+// Close stops accepting new messages and waits for messages queued by each
+// Client to be submitted.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	clients := p.clients
+	p.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, c := range clients {
+		wg.Add(1)
+		go func(c *Client) {
+			defer wg.Done()
+			c.Close()
+		}(c)
+	}
+	wg.Wait()
+
+	p.transport.CloseIdleConnections()
+}
@@ -0,0 +1,108 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package sender
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPoolReusesConnections(t *testing.T) {
+	var received, conns int32
+	server := newTestServer(t, &received)
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+
+	pool := NewPool(WithWebhookURLValidation(false), WithRetries(0, 0))
+	defer pool.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		if _, err := pool.Send(ctx, server.URL, Message{Text: "reused"}); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+
+	if got := atomic.LoadInt32(&received); got != 5 {
+		t.Errorf("got %d messages; want 5", got)
+	}
+	if got := atomic.LoadInt32(&conns); got != 1 {
+		t.Errorf("got %d connections for sequential messages; want 1", got)
+	}
+}
+
+func TestPoolCircuitBreaker(t *testing.T) {
+	var healthy int32
+	good := newTestServer(t, &healthy)
+
+	var attempts int32
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(bad.Close)
+
+	pool := NewPool(
+		WithWebhookURLValidation(false),
+		WithRetries(0, 0),
+		WithCircuitBreaker(2, time.Hour),
+	)
+	defer pool.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		_, err := pool.Send(ctx, bad.URL, Message{Text: "failing"})
+		if err == nil {
+			t.Fatal("Send to failing target succeeded")
+		}
+		if i >= 2 && !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("got %v for message %d; want %v", err, i+1, ErrCircuitOpen)
+		}
+	}
+
+	if got := atomic.LoadInt32(&attempts); got != 2 {
+		t.Errorf("got %d attempts against failing target; want 2", got)
+	}
+
+	// Other targets are not affected.
+	if _, err := pool.Send(ctx, good.URL, Message{Text: "healthy"}); err != nil {
+		t.Errorf("Send to healthy target failed: %v", err)
+	}
+
+	health := pool.Health()
+	if len(health) != 2 {
+		t.Fatalf("got health for %d targets; want 2", len(health))
+	}
+
+	for _, target := range health {
+		if strings.Contains(target.Host, "/") || strings.Contains(target.ID, "/") {
+			t.Errorf("got health %+v; want webhook URL omitted", target)
+		}
+
+		switch target.ID {
+		case TargetID(bad.URL):
+			if target.Healthy() || target.Failed != 2 || target.Rejected != 2 {
+				t.Errorf("got health %+v for failing target; want open circuit, 2 failed and 2 rejected", target.Health)
+			}
+		case TargetID(good.URL):
+			if !target.Healthy() || target.Sent != 1 {
+				t.Errorf("got health %+v for healthy target; want closed circuit and 1 sent", target.Health)
+			}
+		}
+	}
+}
@@ -0,0 +1,384 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package sender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	goteamsnotify "github.com/atc0005/go-teams-notify/v2"
+	"github.com/atc0005/go-teams-notify/v2/adaptivecard"
+	"github.com/atc0005/send2teams/internal/breaker"
+	"github.com/atc0005/send2teams/internal/teams"
+)
+
+// Default settings applied to a Client unless overridden by an Option.
+const (
+	defaultWorkers      int = 4
+	defaultQueueSize    int = 100
+	defaultRetries      int = 2
+	defaultRetriesDelay int = 2
+)
+
+// FormatAdaptiveCard is the format of a rendered message containing a
+// single Adaptive Card, encoded exactly as submitted to a webhook URL.
+const FormatAdaptiveCard = teams.FormatAdaptiveCard
+
+// ErrClientClosed indicates that a message was submitted after the Client
+// was closed.
+var ErrClientClosed = errors.New("client is closed")
+
+// ErrCircuitOpen indicates that a message was not submitted since the
+// circuit breaker of the Client is open after repeated failures.
+var ErrCircuitOpen = breaker.ErrOpen
+
+// States of the circuit breaker of a Client, as reported by Health.
+const (
+	CircuitClosed   = breaker.StateClosed
+	CircuitOpen     = breaker.StateOpen
+	CircuitHalfOpen = breaker.StateHalfOpen
+)
+
+// ErrUnsupportedFormat indicates that a message could not be rendered in a
+// requested format.
+var ErrUnsupportedFormat = teams.ErrUnsupportedFormat
+
+// Message is the format-neutral description of a message to submit.
+type Message = teams.Message
+
+// TargetURL is a URL and description used to generate a labelled URL
+// "button" within a message.
+type TargetURL = teams.TargetURL
+
+// UserMention is a pair of name and ID values used to generate a user
+// mention within a message.
+type UserMention = teams.UserMention
+
+// Fact is a title and value pair displayed after the text of a message.
+type Fact = teams.Fact
+
+// Section is a titled group of text and facts displayed after the facts of
+// a message.
+type Section = teams.Section
+
+// Table is tabular content displayed after the sections of a message.
+type Table = teams.Table
+
+// Attachment is file content included within a message.
+type Attachment = teams.Attachment
+
+// Activity is the header identifying the source of a message.
+type Activity = teams.Activity
+
+// Result is the outcome of submitting a message.
+type Result struct {
+
+	// Err is the error from the last submission attempt, or nil if the
+	// message was successfully submitted.
+	Err error
+
+	// Started is when submission of the message began.
+	Started time.Time
+
+	// Duration is how long submission of the message took, including any
+	// retry attempts.
+	Duration time.Duration
+}
+
+// Health describes the recent delivery outcomes of a Client, for use as a
+// health signal by services embedding the Client.
+type Health struct {
+
+	// Circuit is the state of the circuit breaker: one of CircuitClosed,
+	// CircuitOpen or CircuitHalfOpen. Always CircuitClosed if no circuit
+	// breaker is used.
+	Circuit string `json:"circuit"`
+
+	// ConsecutiveFailures is the number of failed submissions since the
+	// last successful submission.
+	ConsecutiveFailures int `json:"consecutive_failures"`
+
+	// ProbeAt is when the open circuit breaker next allows a probe
+	// submission. Zero unless the circuit breaker is open or half-open.
+	ProbeAt time.Time `json:"probe_at,omitempty"`
+
+	// Sent, Failed and Rejected are the number of messages successfully
+	// submitted, whose submission failed and which were rejected by the open
+	// circuit breaker.
+	Sent     uint64 `json:"sent"`
+	Failed   uint64 `json:"failed"`
+	Rejected uint64 `json:"rejected"`
+
+	// LastSuccess and LastFailure are when a message was last successfully
+	// submitted and when a submission last failed.
+	LastSuccess time.Time `json:"last_success,omitempty"`
+	LastFailure time.Time `json:"last_failure,omitempty"`
+
+	// LastError is the error from the last failed submission.
+	LastError string `json:"last_error,omitempty"`
+}
+
+// Healthy indicates whether messages are currently accepted for submission,
+// i.e., the circuit breaker is not open.
+func (h Health) Healthy() bool {
+	return h.Circuit != CircuitOpen
+}
+
+// job is a message waiting for submission by a worker.
+type job struct {
+	ctx     context.Context
+	msg     Message
+	results chan<- Result
+}
+
+// Client submits messages to a Microsoft Teams webhook URL using a pool of
+// workers. A Client is safe for concurrent use.
+type Client struct {
+	teamsClient  *goteamsnotify.TeamsClient
+	jobs         chan job
+	webhookURL   string
+	cardOpts     teams.CardOptions
+	workers      int
+	queueSize    int
+	retries      int
+	retriesDelay int
+	logger       *log.Logger
+
+	// breaker is the (optional) circuit breaker for the webhook URL.
+	breaker *breaker.Breaker
+
+	healthMu sync.Mutex
+	health   Health
+
+	mu       sync.RWMutex
+	closed   bool
+	workerWG sync.WaitGroup
+}
+
+// New creates a Client which submits messages to the given webhook URL and
+// starts its pool of workers. Close should be called to stop the workers
+// once the Client is no longer needed.
+func New(webhookURL string, opts ...Option) (*Client, error) {
+	c := Client{
+		teamsClient:  goteamsnotify.NewTeamsClient(),
+		webhookURL:   webhookURL,
+		workers:      defaultWorkers,
+		queueSize:    defaultQueueSize,
+		retries:      defaultRetries,
+		retriesDelay: defaultRetriesDelay,
+		logger:       log.New(io.Discard, "", 0),
+	}
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	if c.retries < 0 || c.retriesDelay < 0 {
+		return nil, fmt.Errorf("invalid retry settings: retries %d, retries delay %d", c.retries, c.retriesDelay)
+	}
+
+	if err := c.teamsClient.ValidateWebhook(webhookURL); err != nil {
+		return nil, fmt.Errorf("webhook URL validation failed: %w", err)
+	}
+
+	c.jobs = make(chan job, c.queueSize)
+
+	c.workerWG.Add(c.workers)
+	for i := 0; i < c.workers; i++ {
+		go c.work()
+	}
+
+	return &c, nil
+}
+
+// Send submits the given message and waits for the result. The provided
+// context controls cancellation of the submission, including any retry
+// attempts.
+func (c *Client) Send(ctx context.Context, msg Message) (Result, error) {
+	result := <-c.SendAsync(ctx, msg)
+
+	return result, result.Err
+}
+
+// SendAsync queues the given message for submission by the worker pool and
+// returns a channel which receives the result once submission completes.
+// SendAsync blocks only while the queue is full; the provided context
+// controls cancellation of both queuing and submission.
+func (c *Client) SendAsync(ctx context.Context, msg Message) <-chan Result {
+	results := make(chan Result, 1)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		results <- Result{Err: ErrClientClosed, Started: time.Now()}
+		return results
+	}
+
+	select {
+	case c.jobs <- job{ctx: ctx, msg: msg, results: results}:
+	case <-ctx.Done():
+		results <- Result{Err: ctx.Err(), Started: time.Now()}
+	}
+
+	return results
+}
+
+// Render returns the JSON payload which would be submitted for the given
+// message in the given format (an empty format selects FormatAdaptiveCard),
+// applying the options of the Client (e.g., a trailer), without performing
+// any network I/O. This is useful for golden file testing of notification
+// content. Unlike Message.Render, which applies no options, the output
+// matches what Send submits.
+func (c *Client) Render(msg Message, format string) ([]byte, error) {
+	return msg.RenderWithOptions(format, c.cardOpts)
+}
+
+// Health returns the recent delivery outcomes of the Client.
+func (c *Client) Health() Health {
+	c.healthMu.Lock()
+	health := c.health
+	c.healthMu.Unlock()
+
+	status := c.breaker.Status()
+	health.Circuit = status.State
+	health.ConsecutiveFailures = status.ConsecutiveFailures
+	health.ProbeAt = status.ProbeAt
+
+	return health
+}
+
+// Close stops accepting new messages and waits for queued messages to be
+// submitted.
+func (c *Client) Close() {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+	c.closed = true
+	close(c.jobs)
+	c.mu.Unlock()
+
+	c.workerWG.Wait()
+}
+
+// work submits queued messages until the queue is closed.
+func (c *Client) work() {
+	defer c.workerWG.Done()
+
+	for j := range c.jobs {
+		j.results <- c.submit(j.ctx, j.msg)
+	}
+}
+
+// submit generates and submits a message, retrying as needed.
+func (c *Client) submit(ctx context.Context, msg Message) (result Result) {
+	result.Started = time.Now()
+	defer func() { result.Duration = time.Since(result.Started) }()
+
+	if err := ctx.Err(); err != nil {
+		result.Err = err
+		return result
+	}
+
+	if err := msg.Validate(); err != nil {
+		result.Err = err
+		return result
+	}
+
+	message, err := teams.NewAdaptiveCardMessage(msg, c.cardOpts)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	if err := c.breaker.Allow(); err != nil {
+		c.recordHealth(func(h *Health) { h.Rejected++ })
+		result.Err = err
+		return result
+	}
+
+	result.Err = c.sendWithRetry(ctx, message)
+
+	switch {
+	case result.Err == nil:
+		c.breaker.Record(nil)
+		c.recordHealth(func(h *Health) {
+			h.Sent++
+			h.LastSuccess = time.Now()
+		})
+
+	default:
+		// Submissions cancelled by the caller say nothing about the health
+		// of the webhook URL, unlike submissions which timed out.
+		if errors.Is(ctx.Err(), context.Canceled) {
+			c.breaker.Abandon()
+		} else {
+			c.breaker.Record(result.Err)
+		}
+
+		c.recordHealth(func(h *Health) {
+			h.Failed++
+			h.LastFailure = time.Now()
+			h.LastError = result.Err.Error()
+		})
+	}
+
+	return result
+}
+
+// recordHealth applies the given update to the health of the Client.
+func (c *Client) recordHealth(update func(*Health)) {
+	c.healthMu.Lock()
+	update(&c.health)
+	c.healthMu.Unlock()
+}
+
+// sendWithRetry submits the given message, retrying submission if needed up
+// to the configured number of retry attempts. Attempts are logged using the
+// logger of the Client. The result from the last attempt is returned.
+func (c *Client) sendWithRetry(ctx context.Context, message *adaptivecard.Message) error {
+	attemptsAllowed := 1 + c.retries
+	retriesDelay := time.Duration(c.retriesDelay) * time.Second
+
+	var sendErr error
+	for attempt := 1; attempt <= attemptsAllowed; attempt++ {
+		start := time.Now()
+		sendErr = c.teamsClient.SendWithContext(ctx, c.webhookURL, message)
+
+		if sendErr == nil {
+			c.logger.Printf("attempt %d of %d to send message succeeded after %v",
+				attempt, attemptsAllowed, time.Since(start).Round(time.Millisecond))
+			return nil
+		}
+
+		c.logger.Printf("attempt %d of %d to send message failed after %v: %v",
+			attempt, attemptsAllowed, time.Since(start).Round(time.Millisecond), sendErr)
+
+		if attempt == attemptsAllowed {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf(
+				"context cancelled or expired: %v; aborting message submission after %d of %d attempts: %w",
+				ctx.Err(), attempt, attemptsAllowed, sendErr,
+			)
+		case <-time.After(retriesDelay):
+		}
+	}
+
+	return sendErr
+}
@@ -0,0 +1,200 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package sender
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// newTestServer returns a fake webhook endpoint which responds as Microsoft
+// Teams does to successfully submitted messages.
+func newTestServer(t *testing.T, received *int32) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(received, 1)
+		_, _ = w.Write([]byte("1"))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestSendAndSendAsync(t *testing.T) {
+	var received int32
+	server := newTestServer(t, &received)
+
+	client, err := New(
+		server.URL,
+		WithWebhookURLValidation(false),
+		WithWorkers(2),
+		WithRetries(0, 0),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if _, err := client.Send(ctx, Message{Title: "sync", Text: "synchronous"}); err != nil {
+		t.Errorf("Send failed: %v", err)
+	}
+
+	const asyncCount = 5
+	results := make([]<-chan Result, 0, asyncCount)
+	for i := 0; i < asyncCount; i++ {
+		results = append(results, client.SendAsync(ctx, Message{Text: "asynchronous"}))
+	}
+
+	for _, ch := range results {
+		if result := <-ch; result.Err != nil {
+			t.Errorf("SendAsync failed: %v", result.Err)
+		}
+	}
+
+	client.Close()
+
+	if got := atomic.LoadInt32(&received); got != 1+asyncCount {
+		t.Errorf("endpoint received %d messages; want %d", got, 1+asyncCount)
+	}
+
+	if _, err := client.Send(ctx, Message{Text: "too late"}); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("Send after Close returned %v; want %v", err, ErrClientClosed)
+	}
+}
+
+func TestSendInvalidMessage(t *testing.T) {
+	var received int32
+	server := newTestServer(t, &received)
+
+	client, err := New(server.URL, WithWebhookURLValidation(false))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.Send(context.Background(), Message{Title: "no text"}); err == nil {
+		t.Error("Send succeeded for message without text; want error")
+	}
+
+	if got := atomic.LoadInt32(&received); got != 0 {
+		t.Errorf("endpoint received %d messages; want 0", got)
+	}
+}
+
+func TestRender(t *testing.T) {
+	var received int32
+	server := newTestServer(t, &received)
+
+	client, err := New(server.URL, WithWebhookURLValidation(false), WithTrailer("Sent by backup service"))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	msg := Message{Title: "Backup", Text: "Backup complete"}
+
+	plain, err := msg.Render(FormatAdaptiveCard)
+	if err != nil {
+		t.Fatalf("Message.Render failed: %v", err)
+	}
+
+	withTrailer, err := client.Render(msg, "")
+	if err != nil {
+		t.Fatalf("Client.Render failed: %v", err)
+	}
+
+	if !strings.Contains(string(plain), `"text":"Backup complete"`) {
+		t.Errorf("rendered payload missing message text: %s", plain)
+	}
+
+	if strings.Contains(string(plain), "Sent by backup service") ||
+		!strings.Contains(string(withTrailer), "Sent by backup service") {
+		t.Errorf("trailer not applied as expected:\n%s\n%s", plain, withTrailer)
+	}
+
+	if _, err := msg.Render("messagecard"); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("got %v; want ErrUnsupportedFormat", err)
+	}
+
+	if atomic.LoadInt32(&received) != 0 {
+		t.Errorf("rendering unexpectedly submitted %d message(s)", received)
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	var received int32
+	server := newTestServer(t, &received)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(failing.Close)
+
+	var okLog, failLog safeBuffer
+
+	okClient, err := New(server.URL, WithWebhookURLValidation(false), WithRetries(0, 0),
+		WithLogger(log.New(&okLog, "ok: ", 0)))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer okClient.Close()
+
+	failClient, err := New(failing.URL, WithWebhookURLValidation(false), WithRetries(1, 0),
+		WithLogger(log.New(&failLog, "fail: ", 0)))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer failClient.Close()
+
+	ctx := context.Background()
+	okResult := okClient.SendAsync(ctx, Message{Text: "ok"})
+	failResult := failClient.SendAsync(ctx, Message{Text: "fail"})
+
+	if result := <-okResult; result.Err != nil {
+		t.Errorf("unexpected error: %v", result.Err)
+	}
+	if result := <-failResult; result.Err == nil {
+		t.Error("expected error from failing endpoint")
+	}
+
+	if got := okLog.String(); strings.Count(got, "ok: attempt 1 of 1 to send message succeeded") != 1 || strings.Contains(got, "fail: ") {
+		t.Errorf("unexpected log output for successful client: %q", got)
+	}
+
+	if got := failLog.String(); strings.Count(got, "fail: attempt") != 2 || strings.Contains(got, "ok: ") {
+		t.Errorf("unexpected log output for failing client: %q", got)
+	}
+}
+
+// safeBuffer is a bytes.Buffer which is safe for concurrent use.
+type safeBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *safeBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *safeBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
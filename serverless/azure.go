@@ -0,0 +1,157 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package serverless
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// AzureFunctionsPortEnv is the environment variable through which Azure
+// Functions provides the port on which a custom handler listens.
+const AzureFunctionsPortEnv string = "FUNCTIONS_CUSTOMHANDLER_PORT"
+
+// azureMaxRequestBytes is the maximum size of an invocation request
+// accepted by the custom handler.
+const azureMaxRequestBytes int64 = 4 << 20
+
+// azureShutdownTimeout is the maximum time spent waiting for in-progress
+// invocations once the custom handler is shut down.
+const azureShutdownTimeout = 10 * time.Second
+
+// azureRequest is an invocation request sent by the Functions host to a
+// custom handler. Data maps the names of the input bindings of the function
+// (e.g., an Event Grid trigger) to their values.
+type azureRequest struct {
+	Data     map[string]json.RawMessage `json:"Data"`
+	Metadata map[string]json.RawMessage `json:"Metadata"`
+}
+
+// azureResponse is the response of a custom handler to an invocation
+// request.
+type azureResponse struct {
+	Outputs     map[string]interface{} `json:"Outputs"`
+	Logs        []string               `json:"Logs"`
+	ReturnValue interface{}            `json:"ReturnValue"`
+}
+
+// ServeAzureFunctions serves invocation requests from the Azure Functions
+// host as a custom handler (with enableForwardingHttpRequest disabled) until
+// the given context is cancelled. If addr is empty the handler listens on
+// the loopback interface using the port specified by the Functions host.
+// Requests for any function are handled, using the value of each input
+// binding as an Event Grid payload.
+func ServeAzureFunctions(ctx context.Context, h *Handler, addr string) error {
+	if addr == "" {
+		port := os.Getenv(AzureFunctionsPortEnv)
+		if port == "" {
+			return fmt.Errorf("%s environment variable not set", AzureFunctionsPortEnv)
+		}
+		addr = net.JoinHostPort("127.0.0.1", port)
+	}
+
+	server := http.Server{
+		Addr:              addr,
+		Handler:           h.AzureFunctionsHandler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() { errs <- server.ListenAndServe() }()
+
+	select {
+	case err := <-errs:
+		return err
+
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), azureShutdownTimeout)
+		defer cancel()
+
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			return err
+		}
+
+		if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+
+		return ctx.Err()
+	}
+}
+
+// AzureFunctionsHandler returns an http.Handler which serves invocation
+// requests from the Azure Functions host as a custom handler. This allows
+// the handler to be served by an existing HTTP server.
+func (h *Handler) AzureFunctionsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req azureRequest
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, azureMaxRequestBytes))
+		if err := dec.Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("failed to decode invocation request: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		resp := azureResponse{Outputs: map[string]interface{}{}, Logs: []string{}}
+
+		var sent int
+		var errs []error
+		for binding, value := range req.Data {
+			n, err := h.HandleEventGrid(r.Context(), bindingPayload(value))
+			sent += n
+			if err != nil {
+				errs = append(errs, fmt.Errorf("binding %s: %w", binding, err))
+				continue
+			}
+			resp.Logs = append(resp.Logs, fmt.Sprintf("binding %s: submitted %d message(s)", binding, n))
+		}
+
+		status := http.StatusOK
+		if err := errors.Join(errs...); err != nil {
+			status = http.StatusInternalServerError
+			resp.Logs = append(resp.Logs, "ERROR: "+err.Error())
+		}
+		resp.ReturnValue = invocationResult{Sent: sent}
+
+		body, err := json.Marshal(resp)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write(body)
+	})
+}
+
+// bindingPayload returns the event payload for the value of an input
+// binding. The Functions host may provide the event as a JSON object or as
+// a string containing JSON.
+func bindingPayload(value json.RawMessage) []byte {
+	value = bytes.TrimSpace(value)
+
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		return []byte(s)
+	}
+
+	return value
+}
@@ -0,0 +1,41 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package serverless runs send2teams as an AWS Lambda function or an Azure
+Functions custom handler, translating the events which trigger the function
+into messages submitted to a Microsoft Teams channel.
+
+AWS Lambda functions are typically triggered by Amazon SNS notifications;
+one message is submitted for each SNS record. Other payloads are decoded as
+a message (e.g., {"title": "...", "text": "..."}), which allows the function
+to be invoked directly.
+
+Azure Functions are typically triggered by Azure Event Grid events using
+either the Event Grid or CloudEvents schema; one message is submitted for
+each event.
+
+A Handler wraps a sender.Client:
+
+	client, err := sender.New(webhookURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	h := serverless.NewHandler(client)
+
+	// Within an AWS Lambda custom runtime (e.g., the provided.al2023
+	// runtime), process invocations until the runtime is shut down.
+	if err := serverless.ServeLambda(context.Background(), h); err != nil {
+		log.Fatal(err)
+	}
+
+The cmd/send2teams-function command provides a ready-made entrypoint for
+both platforms.
+*/
+package serverless
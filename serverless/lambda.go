@@ -0,0 +1,175 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package serverless
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// LambdaRuntimeAPIEnv is the environment variable through which AWS Lambda
+// provides the address of the runtime API to custom runtimes.
+const LambdaRuntimeAPIEnv string = "AWS_LAMBDA_RUNTIME_API"
+
+// lambdaRuntimePrefix is the path prefix of the supported Lambda runtime API
+// version.
+const lambdaRuntimePrefix string = "/2018-06-01/runtime"
+
+// Headers provided by the Lambda runtime API for each invocation.
+const (
+	lambdaRequestIDHeader string = "Lambda-Runtime-Aws-Request-Id"
+	lambdaDeadlineHeader  string = "Lambda-Runtime-Deadline-Ms"
+)
+
+// ErrNotLambda indicates that ServeLambda was called outside of an AWS
+// Lambda custom runtime.
+var ErrNotLambda = errors.New(LambdaRuntimeAPIEnv + " environment variable not set")
+
+// lambdaError is the error reported to the Lambda runtime API for a failed
+// invocation.
+type lambdaError struct {
+	ErrorMessage string `json:"errorMessage"`
+	ErrorType    string `json:"errorType"`
+}
+
+// ServeLambda processes AWS Lambda invocations using the runtime API of a
+// custom runtime until the given context is cancelled or the runtime API
+// fails. Each invocation has a deadline set by Lambda; messages not
+// submitted by then are reported as failed.
+func ServeLambda(ctx context.Context, h *Handler) error {
+	api := os.Getenv(LambdaRuntimeAPIEnv)
+	if api == "" {
+		return ErrNotLambda
+	}
+
+	runtime := lambdaRuntime{
+		baseURL: "http://" + api + lambdaRuntimePrefix,
+
+		// Requests for the next invocation block until one is available.
+		client: &http.Client{},
+	}
+
+	for {
+		if err := runtime.next(ctx, h); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+	}
+}
+
+// lambdaRuntime is a client of the Lambda runtime API.
+type lambdaRuntime struct {
+	baseURL string
+	client  *http.Client
+}
+
+// next waits for the next invocation, handles it and reports the outcome.
+func (r lambdaRuntime) next(ctx context.Context, h *Handler) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.baseURL+"/invocation/next", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve next invocation: %w", err)
+	}
+
+	payload, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to retrieve next invocation: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to retrieve next invocation: %s", resp.Status)
+	}
+
+	requestID := resp.Header.Get(lambdaRequestIDHeader)
+	if requestID == "" {
+		return fmt.Errorf("failed to retrieve next invocation: %s header missing", lambdaRequestIDHeader)
+	}
+
+	invokeCtx := ctx
+	if ms, err := strconv.ParseInt(resp.Header.Get(lambdaDeadlineHeader), 10, 64); err == nil {
+		var cancel context.CancelFunc
+		invokeCtx, cancel = context.WithDeadline(ctx, time.UnixMilli(ms))
+		defer cancel()
+	}
+
+	sent, err := h.HandleLambda(invokeCtx, payload)
+	if err != nil {
+		errorType := "SendError"
+		if errors.Is(err, ErrUnrecognizedEvent) {
+			errorType = "UnrecognizedEvent"
+		}
+
+		return r.post(ctx, "/invocation/"+requestID+"/error", lambdaError{
+			ErrorMessage: err.Error(),
+			ErrorType:    errorType,
+		})
+	}
+
+	return r.post(ctx, "/invocation/"+requestID+"/response", invocationResult{Sent: sent})
+}
+
+// post reports the given value to the runtime API.
+func (r lambdaRuntime) post(ctx context.Context, path string, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to report invocation result: %w", err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	// Lambda rejects results for invocations which already timed out; this
+	// does not prevent further invocations from being processed.
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("failed to report invocation result: %s", resp.Status)
+	}
+
+	return nil
+}
+
+// ReportLambdaInitError reports a failure to initialize the function (e.g.,
+// a missing webhook URL) to the Lambda runtime API. The function should exit
+// afterwards.
+func ReportLambdaInitError(ctx context.Context, initErr error) error {
+	api := os.Getenv(LambdaRuntimeAPIEnv)
+	if api == "" {
+		return ErrNotLambda
+	}
+
+	runtime := lambdaRuntime{baseURL: "http://" + api + lambdaRuntimePrefix, client: &http.Client{}}
+
+	return runtime.post(ctx, "/init/error", lambdaError{
+		ErrorMessage: initErr.Error(),
+		ErrorType:    "InitError",
+	})
+}
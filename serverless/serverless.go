@@ -0,0 +1,84 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package serverless
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/atc0005/send2teams/internal/events"
+	"github.com/atc0005/send2teams/internal/teams"
+	"github.com/atc0005/send2teams/sender"
+)
+
+// ErrUnrecognizedEvent indicates that the payload of an invocation is not in
+// a supported format.
+var ErrUnrecognizedEvent = events.ErrUnrecognized
+
+// invocationResult is the result returned for a successful invocation.
+type invocationResult struct {
+	Sent int `json:"sent"`
+}
+
+// Handler submits messages for the events which trigger a function.
+type Handler struct {
+	client *sender.Client
+}
+
+// NewHandler creates a Handler which submits messages using the given
+// client.
+func NewHandler(client *sender.Client) *Handler {
+	return &Handler{client: client}
+}
+
+// HandleLambda submits messages for the payload of an AWS Lambda
+// invocation (e.g., an SNS event), returning the number of messages
+// submitted.
+func (h *Handler) HandleLambda(ctx context.Context, payload []byte) (int, error) {
+	msgs, err := events.Lambda(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	return h.send(ctx, msgs)
+}
+
+// HandleEventGrid submits messages for the given Azure Event Grid payload
+// (a single event or an array of events), returning the number of messages
+// submitted. Subscription validation events are ignored.
+func (h *Handler) HandleEventGrid(ctx context.Context, payload []byte) (int, error) {
+	msgs, err := events.EventGrid(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	return h.send(ctx, msgs)
+}
+
+// send submits the given messages concurrently, returning the number of
+// messages submitted and an error describing any which failed.
+func (h *Handler) send(ctx context.Context, msgs []teams.Message) (int, error) {
+	results := make([]<-chan sender.Result, 0, len(msgs))
+	for _, msg := range msgs {
+		results = append(results, h.client.SendAsync(ctx, msg))
+	}
+
+	var sent int
+	var errs []error
+	for i, ch := range results {
+		result := <-ch
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("message %d of %d: %w", i+1, len(msgs), result.Err))
+			continue
+		}
+		sent++
+	}
+
+	return sent, errors.Join(errs...)
+}
@@ -0,0 +1,134 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package serverless
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/atc0005/send2teams/sender"
+)
+
+// newTestHandler returns a Handler which submits messages to a fake webhook
+// endpoint, counting the messages received.
+func newTestHandler(t *testing.T, received *int32) *Handler {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(received, 1)
+		_, _ = w.Write([]byte("1"))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := sender.New(server.URL, sender.WithWebhookURLValidation(false), sender.WithRetries(0, 0))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	t.Cleanup(client.Close)
+
+	return NewHandler(client)
+}
+
+func TestServeLambda(t *testing.T) {
+	var received int32
+	h := newTestHandler(t, &received)
+
+	invocations := []string{
+		`{"Records": [{"EventSource": "aws:sns", "Sns": {"Subject": "a", "Message": "one"}}, {"EventSource": "aws:sns", "Sns": {"Message": "two"}}]}`,
+		`not an event`,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	results := make(map[string]string)
+	var next int32
+
+	runtimeAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/invocation/next"):
+			i := int(atomic.AddInt32(&next, 1)) - 1
+			if i >= len(invocations) {
+				cancel()
+				<-r.Context().Done()
+				return
+			}
+			w.Header().Set(lambdaRequestIDHeader, []string{"req-1", "req-2"}[i])
+			_, _ = io.WriteString(w, invocations[i])
+
+		case r.Method == http.MethodPost:
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			results[strings.TrimPrefix(r.URL.Path, lambdaRuntimePrefix)] = string(body)
+			mu.Unlock()
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer runtimeAPI.Close()
+
+	t.Setenv(LambdaRuntimeAPIEnv, strings.TrimPrefix(runtimeAPI.URL, "http://"))
+
+	if err := ServeLambda(ctx, h); err != context.Canceled {
+		t.Fatalf("got error %v, want context.Canceled", err)
+	}
+
+	if received := atomic.LoadInt32(&received); received != 2 {
+		t.Errorf("got %d messages, want 2", received)
+	}
+
+	if got := results["/invocation/req-1/response"]; got != `{"sent":2}` {
+		t.Errorf("unexpected response for first invocation: %q", got)
+	}
+
+	if got := results["/invocation/req-2/error"]; !strings.Contains(got, `"errorType":"UnrecognizedEvent"`) {
+		t.Errorf("unexpected error for second invocation: %q", got)
+	}
+}
+
+func TestAzureFunctionsHandler(t *testing.T) {
+	var received int32
+	h := newTestHandler(t, &received)
+
+	event := `[{"id": "1", "eventType": "Custom.Deployed", "subject": "v1", "data": {"version": "v1"}}]`
+	quoted, _ := json.Marshal(event)
+
+	body := `{"Data": {"eventGridEvent": ` + string(quoted) + `}, "Metadata": {}}`
+	req := httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	h.AzureFunctionsHandler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var resp azureResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if received := atomic.LoadInt32(&received); received != 1 || len(resp.Logs) != 1 {
+		t.Errorf("got %d messages and logs %q, want 1 message and 1 log entry", received, resp.Logs)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(`{"Data": {"event": {"id": "x"}}}`))
+	rec = httptest.NewRecorder()
+	h.AzureFunctionsHandler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("got status %d for unrecognized event, want %d", rec.Code, http.StatusInternalServerError)
+	}
+}
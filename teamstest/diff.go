@@ -0,0 +1,183 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package teamstest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxDiffValueLength is the maximum length of a value quoted in a reported
+// difference.
+const maxDiffValueLength int = 60
+
+// Diff compares the given JSON payloads, returning a description of each
+// difference found, ordered by JSON path (e.g., "$.attachments[0].content.
+// body[1].text: want "a", got "b""). No differences are returned for
+// payloads which differ only in formatting or in the order of object keys.
+//
+// Differences at (or below) any of the given paths are ignored. Paths use
+// the same syntax as reported differences, with "[*]" matching any array
+// index (e.g., "$.attachments[*].content.body[*].facts[*].value"). An error
+// is returned if either payload is not valid JSON or a path is invalid.
+func Diff(want []byte, got []byte, ignore ...string) ([]string, error) {
+	wantDoc, err := decode(want)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode wanted payload: %w", err)
+	}
+
+	gotDoc, err := decode(got)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode payload: %w", err)
+	}
+
+	ignored := make([]*regexp.Regexp, 0, len(ignore))
+	for _, path := range ignore {
+		if !strings.HasPrefix(path, "$") {
+			return nil, fmt.Errorf("invalid ignored path %q: paths start with \"$\"", path)
+		}
+
+		pattern := strings.ReplaceAll(regexp.QuoteMeta(path), `\[\*\]`, `\[\d+\]`)
+		ignored = append(ignored, regexp.MustCompile(`^`+pattern+`(?:$|[.\[])`))
+	}
+
+	d := differ{ignored: ignored}
+	d.compare("$", wantDoc, gotDoc)
+
+	return d.diffs, nil
+}
+
+// decode decodes the given JSON document, preserving numbers as written.
+func decode(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// differ accumulates the differences between two decoded JSON documents.
+type differ struct {
+	ignored []*regexp.Regexp
+	diffs   []string
+}
+
+// isIgnored indicates whether differences at the given path are ignored.
+func (d *differ) isIgnored(path string) bool {
+	for _, re := range d.ignored {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// report records a difference at the given path.
+func (d *differ) report(path string, format string, args ...interface{}) {
+	d.diffs = append(d.diffs, path+": "+fmt.Sprintf(format, args...))
+}
+
+// compare records the differences between the given values found at the
+// given path.
+func (d *differ) compare(path string, want interface{}, got interface{}) {
+	if d.isIgnored(path) {
+		return
+	}
+
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			d.report(path, "want object, got %s", describe(got))
+			return
+		}
+
+		keys := make([]string, 0, len(w)+len(g))
+		for key := range w {
+			keys = append(keys, key)
+		}
+		for key := range g {
+			if _, ok := w[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			keyPath := path + "." + key
+			wv, inWant := w[key]
+			gv, inGot := g[key]
+
+			switch {
+			case d.isIgnored(keyPath):
+			case !inGot:
+				d.report(keyPath, "missing; want %s", describe(wv))
+			case !inWant:
+				d.report(keyPath, "unexpected %s", describe(gv))
+			default:
+				d.compare(keyPath, wv, gv)
+			}
+		}
+
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok {
+			d.report(path, "want array, got %s", describe(got))
+			return
+		}
+
+		for i := 0; i < len(w) || i < len(g); i++ {
+			indexPath := path + "[" + strconv.Itoa(i) + "]"
+
+			switch {
+			case d.isIgnored(indexPath):
+			case i >= len(g):
+				d.report(indexPath, "missing; want %s", describe(w[i]))
+			case i >= len(w):
+				d.report(indexPath, "unexpected %s", describe(g[i]))
+			default:
+				d.compare(indexPath, w[i], g[i])
+			}
+		}
+
+	default:
+		if want != got {
+			d.report(path, "want %s, got %s", describe(want), describe(got))
+		}
+	}
+}
+
+// describe returns a short description of the given decoded JSON value for
+// use in a reported difference.
+func describe(v interface{}) string {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return fmt.Sprintf("object with %d key(s)", len(v))
+	case []interface{}:
+		return fmt.Sprintf("array of %d element(s)", len(v))
+	case string:
+		if runes := []rune(v); len(runes) > maxDiffValueLength {
+			return strconv.Quote(string(runes[:maxDiffValueLength])) + "..."
+		}
+		return strconv.Quote(v)
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+/*
+Package teamstest provides utilities for testing code which submits messages
+to Microsoft Teams using send2teams, either by embedding the sender package
+or by running the send2teams binary.
+
+Webhook is a fake webhook endpoint, based on httptest.Server, which records
+submitted payloads and responds as Microsoft Teams does. Options control how
+the endpoint misbehaves, so that retry, throttling and failure handling can
+be tested without a real endpoint:
+
+	hook := teamstest.NewWebhook(
+		teamstest.WithThrottle(2, 5*time.Second),
+		teamstest.WithDelay(100*time.Millisecond),
+	)
+	defer hook.Close()
+
+	client, err := sender.New(hook.URL(), sender.WithWebhookURLValidation(false))
+
+The URL of a Webhook is not a valid Microsoft Teams webhook URL, so webhook
+URL validation must be disabled (WithWebhookURLValidation for the sender
+package or the -disable-url-validation flag of send2teams).
+
+The golden payload corpus is a set of canonical messages along with the
+payloads send2teams generates for them, kept current by the tests of this
+package. Code which builds on send2teams output (e.g., a proxy inspecting
+submitted cards) can be tested against the corpus rather than against
+hand-written payloads:
+
+	for _, name := range teamstest.GoldenNames() {
+		payload, err := teamstest.GoldenPayload(name)
+		// ...
+	}
+
+Diff compares two JSON payloads semantically, ignoring formatting and the
+order of object keys, and reports each difference by its JSON path. Paths
+which are expected to differ (e.g., receipt IDs) may be ignored:
+
+	diffs, err := teamstest.Diff(want, got, "$.attachments[*].content.body[*].facts[*].value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range diffs {
+		t.Error(d)
+	}
+*/
+package teamstest
@@ -0,0 +1,146 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package teamstest
+
+import (
+	"embed"
+	"fmt"
+	"sort"
+
+	"github.com/atc0005/send2teams/sender"
+)
+
+// goldenDir is the directory of the golden payload corpus within goldenFS.
+const goldenDir string = "testdata/golden"
+
+//go:embed testdata/golden/*.json
+var goldenFS embed.FS
+
+// goldenMessages are the canonical messages of the golden payload corpus,
+// keyed by name. Each is chosen to exercise a distinct part of the generated
+// card.
+var goldenMessages = map[string]sender.Message{
+	"text": {
+		Text: "Backup completed successfully.",
+	},
+	"title": {
+		Title: "Backup report",
+		Text:  "Backup of **db01** completed in 4m12s.",
+	},
+	"facts": {
+		Title: "Disk usage high",
+		Text:  "Free space on /var is below the warning threshold.",
+		Facts: []sender.Fact{
+			{Title: "Host", Value: "web01"},
+			{Title: "Mount", Value: "/var"},
+			{Title: "Free", Value: "8%"},
+		},
+	},
+	"multiline-facts": {
+		Title: "Certificate renewed",
+		Text:  "The certificate for **web01** was renewed.",
+		Facts: []sender.Fact{
+			{Title: "Subject", Value: "CN=web01.example.com\nO=Example Corp\nC=US"},
+			{Title: "Expires", Value: "**2022-06-30**"},
+		},
+	},
+	"sections": {
+		Title: "Disk usage high",
+		Text:  "Free space on /var is below the warning threshold.",
+		Sections: []sender.Section{
+			{
+				Title: "Largest directories",
+				Facts: []sender.Fact{
+					{Title: "/var/log", Value: "12 GB"},
+					{Title: "/var/cache", Value: "3 GB"},
+				},
+			},
+			{Text: "Cleanup runs nightly at **02:00**."},
+		},
+	},
+	"target-urls": {
+		Title: "Deployment finished",
+		Text:  "Release 2.4.0 was deployed to production.",
+		TargetURLs: []sender.TargetURL{
+			{URL: "https://example.com/releases/2.4.0", Description: "Release notes"},
+			{URL: "https://example.com/dashboards/prod", Description: "Dashboard"},
+		},
+	},
+	"mentions": {
+		Title: "On-call handover",
+		Text:  "Please acknowledge the open incidents.",
+		UserMentions: []sender.UserMention{
+			{Name: "Jane Doe", ID: "jane.doe@example.com"},
+		},
+	},
+	"table": {
+		Title: "Certificate expiry",
+		Text:  "Certificates expiring within 30 days.",
+		Tables: []sender.Table{
+			{
+				Columns: []string{"Host", "Expires", "Days"},
+				Rows: [][]string{
+					{"mail.example.com", "2021-07-01", "12"},
+					{"vpn.example.com", "2021-07-15", "26"},
+				},
+			},
+		},
+	},
+	"attachment": {
+		Title: "Cron job failed",
+		Text:  "The nightly cleanup job exited with status 1.",
+		Attachments: []sender.Attachment{
+			{Name: "cleanup.log", Content: "removing stale files\nerror: permission denied"},
+		},
+	},
+	"activity": {
+		Title: "Pipeline failed",
+		Text:  "Stage **test** failed on branch main.",
+		Activity: sender.Activity{
+			Title:    "CI",
+			Subtitle: "build #1234",
+			Image:    "https://example.com/ci.png",
+		},
+	},
+}
+
+// GoldenNames returns the sorted names of the messages in the golden payload
+// corpus.
+func GoldenNames() []string {
+	names := make([]string, 0, len(goldenMessages))
+	for name := range goldenMessages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// GoldenMessage returns the named canonical message of the golden payload
+// corpus, indicating whether the name is known.
+func GoldenMessage(name string) (sender.Message, bool) {
+	msg, ok := goldenMessages[name]
+
+	return msg, ok
+}
+
+// GoldenPayload returns the payload send2teams generates for the named
+// canonical message (as returned by Message.Render), exactly as submitted to
+// a webhook URL.
+func GoldenPayload(name string) ([]byte, error) {
+	if _, ok := goldenMessages[name]; !ok {
+		return nil, fmt.Errorf("unknown golden message %q", name)
+	}
+
+	payload, err := goldenFS.ReadFile(goldenDir + "/" + name + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read golden payload %q: %w", name, err)
+	}
+
+	return payload, nil
+}
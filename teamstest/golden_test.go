@@ -0,0 +1,106 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package teamstest
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden payload corpus")
+
+// TestGoldenPayloads asserts that the golden payload corpus matches the
+// payloads currently generated for the canonical messages. Run the tests
+// with the -update flag to regenerate the corpus after an intended change.
+func TestGoldenPayloads(t *testing.T) {
+	for _, name := range GoldenNames() {
+		t.Run(name, func(t *testing.T) {
+			msg, _ := GoldenMessage(name)
+
+			got, err := msg.Render("")
+			if err != nil {
+				t.Fatalf("failed to render message: %v", err)
+			}
+
+			if *update {
+				if err := os.WriteFile(filepath.Join(goldenDir, name+".json"), got, 0o600); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := GoldenPayload(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			diffs, err := Diff(want, got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, d := range diffs {
+				t.Error(d)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	want := []byte(`{"type": "message", "body": [{"text": "a", "id": 1}, {"text": "b"}]}`)
+
+	tests := []struct {
+		name   string
+		got    string
+		ignore []string
+		want   []string
+	}{
+		{
+			name: "formatting and key order",
+			got:  `{"body":[{"id":1,"text":"a"},{"text":"b"}],"type":"message"}`,
+		},
+		{
+			name: "changed and missing values",
+			got:  `{"type": "message", "body": [{"text": "x", "id": "1"}], "extra": true}`,
+			want: []string{
+				`$.body[0].id: want 1, got "1"`,
+				`$.body[0].text: want "a", got "x"`,
+				`$.body[1]: missing; want object with 1 key(s)`,
+				`$.extra: unexpected true`,
+			},
+		},
+		{
+			name:   "ignored paths",
+			got:    `{"type": "message", "body": [{"text": "x", "id": 1}, {"text": "y"}]}`,
+			ignore: []string{"$.body[*].text"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Diff(want, []byte(tt.got), tt.ignore...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("got differences %q; want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("got difference %q; want %q", got[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	if _, err := Diff(want, []byte("{"), "$"); err == nil {
+		t.Error("Diff of invalid payload succeeded; want error")
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package teamstest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	goteamsnotify "github.com/atc0005/go-teams-notify/v2"
+)
+
+// Always applies a behavior of a Webhook to every request it receives rather
+// than to the first n requests.
+const Always = -1
+
+// Request is a request received by a Webhook.
+type Request struct {
+
+	// Method and Header are as provided by the client.
+	Method string
+	Header http.Header
+
+	// Body is the submitted payload.
+	Body []byte
+
+	// Received is when the request was received.
+	Received time.Time
+
+	// Status is the HTTP status code of the response.
+	Status int
+
+	// Accepted indicates whether the message was accepted (i.e., the
+	// response was the one Microsoft Teams gives for delivered messages).
+	Accepted bool
+}
+
+// Option configures the behavior of a Webhook.
+type Option func(*Webhook)
+
+// WithDelay delays the response to each request by the given duration,
+// emulating a slow endpoint. Requests cancelled by the client while delayed
+// are recorded without a response.
+func WithDelay(delay time.Duration) Option {
+	return func(w *Webhook) {
+		w.delay = delay
+	}
+}
+
+// WithThrottle responds to the first n requests (or, if n is Always, to
+// every request) with HTTP status 429 (Too Many Requests), as Microsoft Teams
+// does when a webhook URL is rate limited. A Retry-After header is included
+// if retryAfter is at least one second.
+func WithThrottle(n int, retryAfter time.Duration) Option {
+	return func(w *Webhook) {
+		w.throttle = n
+		w.retryAfter = retryAfter
+	}
+}
+
+// WithRejection responds to the first n requests (or, if n is Always, to
+// every request) which are not throttled with the given HTTP status code
+// and body. If body is empty, the status text is used. Microsoft Teams
+// rejects some payloads with status 200 and a body other than "1"; use
+// http.StatusOK along with an error text to emulate this.
+func WithRejection(n int, status int, body string) Option {
+	return func(w *Webhook) {
+		w.reject = n
+		w.rejectStatus = status
+		w.rejectBody = body
+	}
+}
+
+// Webhook is a fake Microsoft Teams webhook endpoint which records the
+// requests it receives. Unless configured otherwise, each request is
+// accepted as Microsoft Teams does. A Webhook is safe for concurrent use.
+type Webhook struct {
+	server *httptest.Server
+
+	delay        time.Duration
+	retryAfter   time.Duration
+	rejectStatus int
+	rejectBody   string
+
+	mu       sync.Mutex
+	throttle int
+	reject   int
+	requests []Request
+}
+
+// NewWebhook starts a Webhook with the given options, listening on a random
+// loopback port. Close should be called once the Webhook is no longer
+// needed.
+func NewWebhook(opts ...Option) *Webhook {
+	w := Webhook{}
+
+	for _, opt := range opts {
+		opt(&w)
+	}
+
+	w.server = httptest.NewServer(http.HandlerFunc(w.handle))
+
+	return &w
+}
+
+// URL returns the webhook URL served by the Webhook.
+func (w *Webhook) URL() string {
+	return w.server.URL + "/webhook"
+}
+
+// Close stops the Webhook, blocking until all outstanding requests have
+// completed.
+func (w *Webhook) Close() {
+	w.server.Close()
+}
+
+// Requests returns the requests received so far, in the order they were
+// received.
+func (w *Webhook) Requests() []Request {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	requests := make([]Request, len(w.requests))
+	copy(requests, w.requests)
+
+	return requests
+}
+
+// Payloads returns the payloads of the requests which were accepted, in the
+// order they were received.
+func (w *Webhook) Payloads() [][]byte {
+	var payloads [][]byte
+	for _, r := range w.Requests() {
+		if r.Accepted {
+			payloads = append(payloads, r.Body)
+		}
+	}
+
+	return payloads
+}
+
+// handle records and responds to a request.
+func (w *Webhook) handle(rw http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(rw, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	req := Request{
+		Method:   r.Method,
+		Header:   r.Header.Clone(),
+		Body:     body,
+		Received: time.Now(),
+	}
+
+	if w.delay > 0 {
+		select {
+		case <-time.After(w.delay):
+		case <-r.Context().Done():
+			w.record(req)
+			return
+		}
+	}
+
+	if r.Method != http.MethodPost {
+		req.Status = http.StatusMethodNotAllowed
+		http.Error(rw, http.StatusText(req.Status), req.Status)
+		w.record(req)
+		return
+	}
+
+	w.mu.Lock()
+	throttled := take(&w.throttle)
+	rejected := !throttled && take(&w.reject)
+	w.mu.Unlock()
+
+	switch {
+	case throttled:
+		req.Status = http.StatusTooManyRequests
+		if seconds := int(w.retryAfter / time.Second); seconds > 0 {
+			rw.Header().Set("Retry-After", strconv.Itoa(seconds))
+		}
+		http.Error(rw, http.StatusText(req.Status), req.Status)
+
+	case rejected:
+		req.Status = w.rejectStatus
+		text := w.rejectBody
+		if text == "" {
+			text = http.StatusText(req.Status)
+		}
+		rw.WriteHeader(req.Status)
+		_, _ = io.WriteString(rw, text)
+
+	default:
+		req.Status = http.StatusOK
+		req.Accepted = true
+		_, _ = io.WriteString(rw, goteamsnotify.ExpectedWebhookURLResponseText)
+	}
+
+	w.record(req)
+}
+
+// record appends the given request to those received.
+func (w *Webhook) record(req Request) {
+	w.mu.Lock()
+	w.requests = append(w.requests, req)
+	w.mu.Unlock()
+}
+
+// take consumes one use of the behavior with the given remaining count,
+// indicating whether the behavior applies.
+func take(remaining *int) bool {
+	switch {
+	case *remaining == Always:
+		return true
+	case *remaining > 0:
+		*remaining--
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/send2teams
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package teamstest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/atc0005/send2teams/sender"
+)
+
+// send submits a message to the given Webhook using a sender Client,
+// retrying once.
+func send(ctx context.Context, t *testing.T, hook *Webhook, msg sender.Message) error {
+	t.Helper()
+
+	client, err := sender.New(
+		hook.URL(),
+		sender.WithWebhookURLValidation(false),
+		sender.WithWorkers(1),
+		sender.WithRetries(1, 0),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	_, err = client.Send(ctx, msg)
+
+	return err
+}
+
+func TestWebhook(t *testing.T) {
+	msg, _ := GoldenMessage("facts")
+
+	golden, err := GoldenPayload("facts")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		timeout  time.Duration
+		wantErr  bool
+		statuses []int
+	}{
+		{
+			name:     "accepted",
+			statuses: []int{http.StatusOK},
+		},
+		{
+			name:     "throttled then accepted",
+			opts:     []Option{WithThrottle(1, time.Second)},
+			statuses: []int{http.StatusTooManyRequests, http.StatusOK},
+		},
+		{
+			name:     "rejected",
+			opts:     []Option{WithRejection(Always, http.StatusOK, "Webhook message delivery failed")},
+			wantErr:  true,
+			statuses: []int{http.StatusOK, http.StatusOK},
+		},
+		{
+			name:     "delayed beyond timeout",
+			opts:     []Option{WithDelay(time.Second)},
+			timeout:  50 * time.Millisecond,
+			wantErr:  true,
+			statuses: []int{0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook := NewWebhook(tt.opts...)
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			err := send(ctx, t, hook, msg)
+			hook.Close()
+
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("Send returned %v; want error %t", err, tt.wantErr)
+			}
+
+			requests := hook.Requests()
+			if len(requests) != len(tt.statuses) {
+				t.Fatalf("webhook received %d request(s); want %d", len(requests), len(tt.statuses))
+			}
+			for i, r := range requests {
+				if r.Status != tt.statuses[i] {
+					t.Errorf("request %d answered with status %d; want %d", i, r.Status, tt.statuses[i])
+				}
+			}
+
+			payloads := hook.Payloads()
+			if tt.wantErr {
+				if len(payloads) != 0 {
+					t.Errorf("webhook accepted %d payload(s); want none", len(payloads))
+				}
+				return
+			}
+
+			if len(payloads) != 1 {
+				t.Fatalf("webhook accepted %d payload(s); want 1", len(payloads))
+			}
+
+			diffs, err := Diff(golden, payloads[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, d := range diffs {
+				t.Error(d)
+			}
+		})
+	}
+}